@@ -187,8 +187,14 @@ func (d DirectoryURL) Rename(ctx context.Context, options RenameDirectoryOptions
 	}
 	destinationDirectoryURL := NewDirectoryURL(urlParts.URL(), d.directoryClient.Pipeline())
 
+	var ifNoneMatch *string
+	if options.FailIfExists {
+		star := "*" // see https://docs.microsoft.com/en-us/rest/api/storageservices/datalakestoragegen2/path/create
+		ifNoneMatch = &star
+	}
+
 	_, err := destinationDirectoryURL.directoryClient.Create(ctx, *fileSystemName, options.DestinationPath, PathResourceNone, nil, PathRenameModeLegacy,
-		nil, nil, nil, nil, nil, nil, nil, nil, nil, &renameSource, nil, nil, nil, nil, nil, nil, nil, nil, nil,
+		nil, nil, nil, nil, nil, nil, nil, nil, nil, &renameSource, nil, nil, nil, nil, nil, nil, ifNoneMatch, nil, nil,
 		nil, nil, nil, nil, nil, nil, nil)
 
 	if err != nil {
@@ -136,9 +136,19 @@ func (dr *DownloadResponse) Body(o RetryReaderOptions) io.ReadCloser {
 // Delete immediately removes the file from the storage account.
 // For more information, see https://docs.microsoft.com/en-us/rest/api/storageservices/delete-file2.
 func (f FileURL) Delete(ctx context.Context) (*PathDeleteResponse, error) {
+	return f.DeleteWithOptions(ctx, DeleteFileOptions{})
+}
+
+// DeleteWithOptions deletes the file, subject to the conditions in options
+func (f FileURL) DeleteWithOptions(ctx context.Context, options DeleteFileOptions) (*PathDeleteResponse, error) {
 	recursive := false
+	var ifUnmodifiedSince *string
+	if !options.IfUnmodifiedSince.IsZero() {
+		formatted := options.IfUnmodifiedSince.UTC().Format(http.TimeFormat)
+		ifUnmodifiedSince = &formatted
+	}
 	return f.fileClient.Delete(ctx, f.fileSystemName, f.path, &recursive,
-		nil, nil, nil, nil, nil, nil,
+		nil, nil, nil, nil, nil, ifUnmodifiedSince,
 		nil, nil, nil)
 }
 
@@ -237,8 +247,14 @@ func (f FileURL) Rename(ctx context.Context, options RenameFileOptions) (FileURL
 	}
 	destinationFileURL := NewFileURL(urlParts.URL(), f.fileClient.Pipeline())
 
+	var ifNoneMatch *string
+	if options.FailIfExists {
+		star := "*" // see https://docs.microsoft.com/en-us/rest/api/storageservices/datalakestoragegen2/path/create
+		ifNoneMatch = &star
+	}
+
 	_, err := destinationFileURL.fileClient.Create(ctx, *fileSystemName, options.DestinationPath, PathResourceNone, nil, PathRenameModeLegacy,
-		nil, nil, nil, nil, nil, nil, nil, nil, nil, &renameSource, nil, nil, nil, nil, nil, nil, nil, nil, nil,
+		nil, nil, nil, nil, nil, nil, nil, nil, nil, &renameSource, nil, nil, nil, nil, nil, nil, ifNoneMatch, nil, nil,
 		nil, nil, nil, nil, nil, nil, nil)
 
 	if err != nil {
@@ -19,4 +19,6 @@ type RenameDirectoryOptions struct {
 	DestinationPath string
 	// The new SAS for a destination Directory
 	DestinationSas *string
+	// Whether to fail, rather than replace the destination, if it already exists.
+	FailIfExists bool
 }
@@ -3,6 +3,8 @@
 
 package azbfs
 
+import "time"
+
 // For more information, see https://docs.microsoft.com/en-us/rest/api/storageservices/datalakestoragegen2/path/create.
 type CreateFileOptions struct {
 	// Custom headers to apply to the file.
@@ -19,4 +21,12 @@ type RenameFileOptions struct {
 	DestinationPath string
 	// The new SAS for a destination file
 	DestinationSas *string
+	// Whether to fail, rather than replace the destination, if it already exists.
+	FailIfExists bool
+}
+
+// For more information, see https://docs.microsoft.com/en-us/rest/api/storageservices/datalakestoragegen2/path/delete.
+type DeleteFileOptions struct {
+	// If set, the file is only deleted if it has not been modified since this time.
+	IfUnmodifiedSince time.Time
 }
@@ -171,20 +171,40 @@ type rawCopyCmdArgs struct {
 
 	// Optional flag that permanently deletes soft-deleted snapshots/versions
 	permanentDeleteOption string
-}
 
-func (raw *rawCopyCmdArgs) parsePatterns(pattern string) (cookedPatterns []string) {
-	cookedPatterns = make([]string, 0)
-	rawPatterns := strings.Split(pattern, ";")
-	for _, pattern := range rawPatterns {
+	// Optional snapshot (timestamp, or URL of a managed disk snapshot) from which to incrementally copy a page blob
+	incrementalFrom string
 
-		// skip the empty patterns
-		if len(pattern) != 0 {
-			cookedPatterns = append(cookedPatterns, pattern)
-		}
-	}
+	// Optional format of local disk images, which are then converted to fixed VHDs as they are uploaded
+	diskImageFormat string
 
-	return
+	// Optional flag to keep appending to the destination as the source file grows
+	follow bool
+
+	// Optional scheduling limits, applied when several jobs share this process's transfer engine
+	jobPriority       string
+	jobMaxConcurrency int
+	jobCapMbps        float64
+
+	// where to write an event as each transfer is done: stdout, or a file or named pipe
+	transferEvents string
+
+	// what to notify, or run, as the job starts and ends and as transfers fail
+	hookURL     string
+	hookCommand string
+	hookEvents  string
+
+	// when setting the tier of archived blobs, how soon to rehydrate them
+	rehydratePriority string
+
+	// set by move, so that each source is deleted once it's been transferred
+	deleteSourceOnSuccess bool
+
+	// when removing, how old the objects must be, and which earlier versions or snapshots of each blob to purge,
+	// keeping the given number of the latest of them
+	olderThan  string
+	purge      string
+	keepLatest int
 }
 
 // blocSizeInBytes converts a FLOATING POINT number of MiB, to a number of bytes
@@ -252,8 +272,18 @@ func (raw rawCopyCmdArgs) stripTrailingWildcardOnRemoteSource(location common.Lo
 }
 
 func (raw rawCopyCmdArgs) cook() (CookedCopyCmdArgs, error) {
+	cooked, err := raw.cookWith(glcm, azcopyCurrentJobID)
+	if cooked.scanningLogger != nil {
+		azcopyScanningLogger = cooked.scanningLogger
+	}
+	return cooked, err
+}
+
+// cookWith cooks the job with the given ID, whose output goes to lcm (rather than to glcm, e.g. when it's run in-process)
+func (raw rawCopyCmdArgs) cookWith(lcm common.LifecycleMgr, jobID common.JobID) (CookedCopyCmdArgs, error) {
 	cooked := CookedCopyCmdArgs{
-		jobID: azcopyCurrentJobID,
+		jobID: jobID,
+		lcm:   lcm,
 	}
 
 	err := cooked.LogVerbosity.Parse(raw.logVerbosity)
@@ -262,25 +292,26 @@ func (raw rawCopyCmdArgs) cook() (CookedCopyCmdArgs, error) {
 	}
 
 	// set up the front end scanning logger
-	azcopyScanningLogger = common.NewJobLogger(azcopyCurrentJobID, cooked.LogVerbosity, azcopyLogPathFolder, "-scanning")
-	azcopyScanningLogger.OpenLog()
-	glcm.RegisterCloseFunc(func() {
-		azcopyScanningLogger.CloseLog()
+	scanningLogger := common.NewJobLogger(jobID, cooked.LogVerbosity, azcopyLogPathFolder, "-scanning", azcopyLogSettings)
+	scanningLogger.OpenLog()
+	lcm.RegisterCloseFunc(func() {
+		scanningLogger.CloseLog()
 	})
+	cooked.scanningLogger = scanningLogger
 
 	/* We support DFS by using blob end-point of the account. We replace dfs by blob in src and dst */
 	if src, dst := InferArgumentLocation(raw.src), InferArgumentLocation(raw.dst); src == common.ELocation.BlobFS() || dst == common.ELocation.BlobFS() {
 		srcDfs := src == common.ELocation.BlobFS() && dst != common.ELocation.Local()
 		if srcDfs {
 			raw.src = strings.Replace(raw.src, ".dfs", ".blob", 1)
-			glcm.Info("Switching to use blob endpoint on source account.")
+			lcm.Info("Switching to use blob endpoint on source account.")
 
 		}
 
 		dstDfs := dst == common.ELocation.BlobFS() && src != common.ELocation.Local()
 		if dstDfs {
 			raw.dst = strings.Replace(raw.dst, ".dfs", ".blob", 1)
-			glcm.Info("Switching to use blob endpoint on destination account.")
+			lcm.Info("Switching to use blob endpoint on destination account.")
 		}
 
 		cooked.isHNStoHNS = srcDfs && dstDfs
@@ -376,6 +407,10 @@ func (raw rawCopyCmdArgs) cook() (CookedCopyCmdArgs, error) {
 	if err != nil {
 		return cooked, err
 	}
+	err = cooked.rehydratePriority.Parse(raw.rehydratePriority)
+	if err != nil {
+		return cooked, err
+	}
 
 	// Everything uses the new implementation of list-of-files now.
 	// This handles both list-of-files and include-path as a list enumerator.
@@ -386,11 +421,6 @@ func (raw rawCopyCmdArgs) cook() (CookedCopyCmdArgs, error) {
 		return cooked, fmt.Errorf("the include and exclude parameters have been replaced by include-pattern; include-path; exclude-pattern and exclude-path. For info, run: azcopy copy help")
 	}
 
-	if (len(raw.include) > 0 || len(raw.exclude) > 0) && cooked.FromTo == common.EFromTo.BlobFSTrash() {
-		return cooked, fmt.Errorf("include/exclude flags are not supported for this destination")
-		// note there's another, more rigorous check, in removeBfsResources()
-	}
-
 	// warn on exclude unsupported wildcards here. Include have to be later, to cover list-of-files
 	raw.warnIfHasWildcard(excludeWarningOncer, "exclude-path", raw.excludePath)
 
@@ -448,7 +478,7 @@ func (raw rawCopyCmdArgs) cook() (CookedCopyCmdArgs, error) {
 						jsonStartNoBrace := strings.TrimPrefix(jsonStart, "{")
 						isJson := cleanedLine == jsonStart || firstLineIsCurlyBrace && cleanedLine == jsonStartNoBrace
 						if isJson {
-							glcm.Error("The format for list-of-files has changed. The old JSON format is no longer supported")
+							lcm.Error("The format for list-of-files has changed. The old JSON format is no longer supported")
 						}
 					}
 					headerLineNum++
@@ -459,7 +489,7 @@ func (raw rawCopyCmdArgs) cook() (CookedCopyCmdArgs, error) {
 		}
 
 		// This occurs much earlier than the other include or exclude filters. It would be preferable to move them closer later on in the refactor.
-		includePathList := raw.parsePatterns(raw.includePath)
+		includePathList := parsePatterns(raw.includePath)
 
 		for _, v := range includePathList {
 			addToChannel(v, "include-path")
@@ -476,24 +506,19 @@ func (raw rawCopyCmdArgs) cook() (CookedCopyCmdArgs, error) {
 		cooked.ListOfFilesChannel = listChan
 	}
 
-	if raw.includeBefore != "" {
-		// must set chooseEarliest = false, so that if there's an ambiguous local date, the latest will be returned
-		// (since that's safest for includeBefore.  Better to choose the later time and do more work, than the earlier one and fail to pick up a changed file
-		parsedIncludeBefore, err := IncludeBeforeDateFilter{}.ParseISO8601(raw.includeBefore, false)
-		if err != nil {
-			return cooked, err
-		}
-		cooked.IncludeBefore = &parsedIncludeBefore
+	if cooked.IncludeBefore, cooked.IncludeAfter, err = parseIncludeDates(raw.includeBefore, raw.includeAfter); err != nil {
+		return cooked, err
 	}
 
-	if raw.includeAfter != "" {
-		// must set chooseEarliest = true, so that if there's an ambiguous local date, the earliest will be returned
-		// (since that's safest for includeAfter.  Better to choose the earlier time and do more work, than the later one and fail to pick up a changed file
-		parsedIncludeAfter, err := IncludeAfterDateFilter{}.ParseISO8601(raw.includeAfter, true)
+	if raw.olderThan != "" {
+		threshold, err := parseOlderThan(raw.olderThan, time.Now())
 		if err != nil {
 			return cooked, err
 		}
-		cooked.IncludeAfter = &parsedIncludeAfter
+		// both include the objects modified before a time, so only the earlier of the two times matters
+		if cooked.IncludeBefore == nil || threshold.Before(*cooked.IncludeBefore) {
+			cooked.IncludeBefore = &threshold
+		}
 	}
 
 	versionsChan := make(chan string)
@@ -545,7 +570,7 @@ func (raw rawCopyCmdArgs) cook() (CookedCopyCmdArgs, error) {
 	cooked.preserveLastModifiedTime = raw.preserveLastModifiedTime
 	cooked.disableAutoDecoding = raw.disableAutoDecoding
 
-	if cooked.FromTo.To() != common.ELocation.Blob() && raw.blobTags != "" {
+	if cooked.FromTo.To() != common.ELocation.Blob() && cooked.FromTo != common.EFromTo.BlobNone() && raw.blobTags != "" {
 		return cooked, errors.New("blob tags can only be set when transferring to blob storage")
 	}
 	blobTags := common.ToCommonBlobTagsMap(raw.blobTags)
@@ -586,14 +611,14 @@ func (raw rawCopyCmdArgs) cook() (CookedCopyCmdArgs, error) {
 		// We only support transfer from source encrypted by user key when user wishes to download.
 		// Due to service limitation, S2S transfer is not supported for source encrypted by user key.
 		if cooked.FromTo.IsDownload() {
-			glcm.Info("Client Provided Key (CPK) for encryption/decryption is provided for download scenario. " +
+			lcm.Info("Client Provided Key (CPK) for encryption/decryption is provided for download scenario. " +
 				"Assuming source is encrypted.")
 			cpkOptions.IsSourceEncrypted = true
 		}
 
 		// TODO: Remove these warnings once service starts supporting it
 		if cooked.blockBlobTier != common.EBlockBlobTier.None() || cooked.pageBlobTier != common.EPageBlobTier.None() {
-			glcm.Info("Tier is provided by user explicitly. Ignoring it because Azure Service currently does" +
+			lcm.Info("Tier is provided by user explicitly. Ignoring it because Azure Service currently does" +
 				" not support setting tier when client provided keys are involved.")
 		}
 
@@ -611,6 +636,14 @@ func (raw rawCopyCmdArgs) cook() (CookedCopyCmdArgs, error) {
 		return cooked, err
 	}
 
+	if raw.purge != "" || raw.keepLatest != 0 {
+		cooked.purge, err = validatePurge(raw, cooked.FromTo, cooked.deleteSnapshotsOption)
+		if err != nil {
+			return cooked, err
+		}
+		cooked.keepLatest = raw.keepLatest
+	}
+
 	if cooked.contentType != "" {
 		cooked.noGuessMimeType = true // As specified in the help text, noGuessMimeType is inferred here.
 	}
@@ -630,7 +663,7 @@ func (raw rawCopyCmdArgs) cook() (CookedCopyCmdArgs, error) {
 
 	// if redirection is triggered, avoid printing any output
 	if cooked.isRedirection() {
-		glcm.SetOutputFormat(common.EOutputFormat.None())
+		lcm.SetOutputFormat(common.EOutputFormat.None())
 	}
 
 	cooked.preserveSMBInfo = areBothLocationsSMBAware(cooked.FromTo)
@@ -645,7 +678,7 @@ func (raw rawCopyCmdArgs) cook() (CookedCopyCmdArgs, error) {
 
 	isUserPersistingPermissions := raw.preservePermissions || raw.preserveSMBPermissions
 	if cooked.preserveSMBInfo && !isUserPersistingPermissions {
-		glcm.Info("Please note: the preserve-permissions flag is set to false, thus AzCopy will not copy SMB ACLs between the source and destination. To learn more: https://aka.ms/AzCopyandAzureFiles.")
+		lcm.Info("Please note: the preserve-permissions flag is set to false, thus AzCopy will not copy SMB ACLs between the source and destination. To learn more: https://aka.ms/AzCopyandAzureFiles.")
 	}
 
 	if err = validatePreserveSMBPropertyOption(isUserPersistingPermissions, cooked.FromTo, &cooked.ForceWrite, PreservePermissionsFlag); err != nil {
@@ -679,6 +712,47 @@ func (raw rawCopyCmdArgs) cook() (CookedCopyCmdArgs, error) {
 		return cooked, err
 	}
 
+	cooked.pageDiffBaseline, cooked.pageDiffBaselineSAS, err = validateIncrementalFrom(raw.incrementalFrom, cooked.Source, cooked.FromTo, cooked.ForceWrite, cooked.Recursive)
+	if err != nil {
+		return cooked, err
+	}
+
+	err = cooked.diskImageFormat.Parse(raw.diskImageFormat)
+	if err != nil {
+		return cooked, err
+	}
+	if err = validateDiskImageFormat(cooked.diskImageFormat, cooked.FromTo, cooked.blobType); err != nil {
+		return cooked, err
+	}
+	if cooked.diskImageFormat != common.EDiskImageFormat.None() {
+		// fixed VHDs must be uploaded as page blobs
+		cooked.blobType = common.EBlobType.PageBlob()
+	}
+
+	if err = validateFollow(raw.follow, cooked.FromTo, cooked.blobType, cooked.Recursive); err != nil {
+		return cooked, err
+	}
+	if raw.follow {
+		if fi, err := common.OSStat(cooked.Source.ValueLocal()); err != nil || fi.IsDir() {
+			return cooked, errors.New("follow requires the source to be a single existing file")
+		}
+	}
+	cooked.followSource = raw.follow
+
+	cooked.jobPriority, cooked.jobMaxConcurrency, cooked.jobMaxBytesPerSecond, err = validateJobLimits(raw.jobPriority, raw.jobMaxConcurrency, raw.jobCapMbps)
+	if err != nil {
+		return cooked, err
+	}
+
+	if !raw.dryrun {
+		if cooked.transferEvents, err = openTransferEvents(lcm, raw.transferEvents); err != nil {
+			return cooked, err
+		}
+		if cooked.hooks, err = newJobHooks(lcm, raw.hookURL, raw.hookCommand, raw.hookEvents); err != nil {
+			return cooked, err
+		}
+	}
+
 	// check for the flag value relative to fromTo location type
 	// Example1: for Local to Blob, preserve-last-modified-time flag should not be set to true
 	// Example2: for Blob to Local, follow-symlinks, blob-tier flags should not be provided with values.
@@ -805,7 +879,7 @@ func (raw rawCopyCmdArgs) cook() (CookedCopyCmdArgs, error) {
 			return cooked, fmt.Errorf("content-type, content-encoding, content-language, content-disposition, cache-control, or metadata is not supported while copying from service to service")
 		}
 	}
-	if err = validatePutMd5(cooked.putMd5, cooked.FromTo); err != nil {
+	if err = validatePutMd5(lcm, cooked.putMd5, cooked.FromTo); err != nil {
 		return cooked, err
 	}
 	if err = validateMd5Option(cooked.md5ValidationOption, cooked.FromTo); err != nil {
@@ -820,17 +894,8 @@ func (raw rawCopyCmdArgs) cook() (CookedCopyCmdArgs, error) {
 	cooked.s2sSourceChangeValidation = raw.s2sSourceChangeValidation
 
 	// If the user has provided some input with excludeBlobType flag, parse the input.
-	if len(raw.excludeBlobType) > 0 {
-		// Split the string using delimiter ';' and parse the individual blobType
-		blobTypes := strings.Split(raw.excludeBlobType, ";")
-		for _, blobType := range blobTypes {
-			var eBlobType common.BlobType
-			err := eBlobType.Parse(blobType)
-			if err != nil {
-				return cooked, fmt.Errorf("error parsing the exclude-blob-type %s provided with exclude-blob-type flag ", blobType)
-			}
-			cooked.excludeBlobType = append(cooked.excludeBlobType, eBlobType.ToAzBlobType())
-		}
+	if cooked.excludeBlobType, err = parseExcludeBlobTypes(raw.excludeBlobType); err != nil {
+		return cooked, err
 	}
 
 	err = cooked.s2sInvalidMetadataHandleOption.Parse(raw.s2sInvalidMetadataHandleOption)
@@ -839,21 +904,30 @@ func (raw rawCopyCmdArgs) cook() (CookedCopyCmdArgs, error) {
 	}
 
 	// parse the filter patterns
-	cooked.IncludePatterns = raw.parsePatterns(raw.include)
-	cooked.ExcludePatterns = raw.parsePatterns(raw.exclude)
-	cooked.ExcludePathPatterns = raw.parsePatterns(raw.excludePath)
+	cooked.IncludePatterns = parsePatterns(raw.include)
+	cooked.ExcludePatterns = parsePatterns(raw.exclude)
+	cooked.ExcludePathPatterns = parsePatterns(raw.excludePath)
 
 	if (raw.includeFileAttributes != "" || raw.excludeFileAttributes != "") && fromTo.From() != common.ELocation.Local() {
 		return cooked, errors.New("cannot check file attributes on remote objects")
 	}
-	cooked.IncludeFileAttributes = raw.parsePatterns(raw.includeFileAttributes)
-	cooked.ExcludeFileAttributes = raw.parsePatterns(raw.excludeFileAttributes)
+	cooked.IncludeFileAttributes = parsePatterns(raw.includeFileAttributes)
+	cooked.ExcludeFileAttributes = parsePatterns(raw.excludeFileAttributes)
 
-	cooked.includeRegex = raw.parsePatterns(raw.includeRegex)
-	cooked.excludeRegex = raw.parsePatterns(raw.excludeRegex)
+	cooked.includeRegex = parsePatterns(raw.includeRegex)
+	cooked.excludeRegex = parsePatterns(raw.excludeRegex)
 
 	cooked.dryrunMode = raw.dryrun
 
+	if raw.deleteSourceOnSuccess {
+		if err = validateMove(cooked, raw.listOfVersionIDs != ""); err != nil {
+			return cooked, err
+		}
+		// the destination is always verified before the source is deleted
+		cooked.CheckLength = true
+		cooked.deleteSourceOnSuccess = true
+	}
+
 	return cooked, nil
 }
 
@@ -954,11 +1028,138 @@ func validateBackupMode(backupMode bool, fromTo common.FromTo) error {
 	}
 }
 
-func validatePutMd5(putMd5 bool, fromTo common.FromTo) error {
+// validateIncrementalFrom checks the use of --incremental-from, and returns the baseline to persist in the job plan.
+// Since we never persist SASs, the SAS of a baseline URL is returned separately, to be kept in memory only.
+// A baseline URL without a SAS is authorized in the same way as the source.
+// The source itself must be a snapshot, since it becomes the baseline for the next incremental copy, and so must not change after it's copied.
+func validateIncrementalFrom(incrementalFrom string, source common.ResourceString, fromTo common.FromTo, overwrite common.OverwriteOption, recursive bool) (baseline string, baselineSAS string, err error) {
+	if incrementalFrom == "" {
+		return "", "", nil
+	}
+	if fromTo != common.EFromTo.BlobBlob() {
+		return "", "", errors.New("incremental-from is only supported when copying a page blob from one blob location to another")
+	}
+	if recursive {
+		return "", "", errors.New("incremental-from applies to a single page blob, so cannot be used with recursive")
+	}
+	if overwrite != common.EOverwriteOption.True() {
+		return "", "", errors.New("incremental-from updates an existing destination, so requires overwrite to be true")
+	}
+	if srcURL, err := source.FullURL(); err != nil {
+		return "", "", fmt.Errorf("cannot parse source URL: %w", err)
+	} else if azblob.NewBlobURLParts(*srcURL).Snapshot == "" && !strings.HasPrefix(srcURL.Host, "md-") {
+		// managed disk snapshots are exported from "md-" accounts, and don't have a snapshot timestamp
+		return "", "", errors.New("incremental-from requires the source to be a snapshot, or an exported managed disk snapshot, since the source is recorded as the baseline for the next incremental copy")
+	}
+
+	if startsWith(incrementalFrom, "http") {
+		u, err := url.Parse(incrementalFrom)
+		if err != nil {
+			return "", "", fmt.Errorf("cannot parse incremental-from URL: %w", err)
+		}
+		baselineSAS = u.RawQuery
+		u.RawQuery = ""
+		incrementalFrom = u.String()
+	} else if _, err := time.Parse(azblob.SnapshotTimeFormat, incrementalFrom); err != nil {
+		return "", "", fmt.Errorf("incremental-from must be a snapshot timestamp (e.g. 2022-01-01T00:00:00.0000000Z) or the URL of a managed disk snapshot")
+	}
+
+	if len(incrementalFrom) > ste.PageDiffBaselineMaxBytes {
+		return "", "", fmt.Errorf("incremental-from cannot be longer than %d characters", ste.PageDiffBaselineMaxBytes)
+	}
+	return incrementalFrom, baselineSAS, nil
+}
+
+// validateDiskImageFormat checks that --disk-image-format is only used when uploading page blobs
+func validateDiskImageFormat(format common.DiskImageFormat, fromTo common.FromTo, blobType common.BlobType) error {
+	if format == common.EDiskImageFormat.None() {
+		return nil
+	}
+	if fromTo != common.EFromTo.LocalBlob() {
+		return errors.New("disk-image-format is only supported when uploading to Blob Storage")
+	}
+	if blobType != common.EBlobType.Detect() && blobType != common.EBlobType.PageBlob() {
+		return errors.New("disk images are uploaded as fixed VHDs, so blob-type must be PageBlob when disk-image-format is set")
+	}
+	return nil
+}
+
+// validateFollow checks that --follow is only used to tail a single local file into an append blob
+func validateFollow(follow bool, fromTo common.FromTo, blobType common.BlobType, recursive bool) error {
+	if !follow {
+		return nil
+	}
+	if fromTo != common.EFromTo.LocalBlob() || blobType != common.EBlobType.AppendBlob() {
+		return errors.New("follow is only supported when uploading to an append blob (i.e. with --blob-type=AppendBlob)")
+	}
+	if recursive {
+		return errors.New("follow applies to a single file, so cannot be used with recursive")
+	}
+	return nil
+}
+
+// validateJobLimits parses the priority and per-job caps that are used when several jobs run in one process
+func validateJobLimits(priority string, maxConcurrency int, capMbps float64) (common.JobPriority, int32, int64, error) {
+	jobPriority := common.EJobPriority.Normal()
+	if priority != "" {
+		if err := jobPriority.Parse(priority); err != nil {
+			return jobPriority, 0, 0, fmt.Errorf("invalid job-priority %q, it must be Low, Normal or High", priority)
+		}
+	}
+	if maxConcurrency < 0 || maxConcurrency > math.MaxInt32 {
+		return jobPriority, 0, 0, errors.New("job-max-concurrency must be zero (no cap) or a positive number of concurrent requests")
+	}
+	if capMbps < 0 {
+		return jobPriority, 0, 0, errors.New("job-cap-mbps cannot be negative")
+	}
+	return jobPriority, int32(maxConcurrency), int64(capMbps * 1000 * 1000 / 8), nil
+}
+
+// openTransferEvents opens the target of --transfer-events, if it's set, and closes it when AzCopy exits
+func openTransferEvents(lcm common.LifecycleMgr, target string) (*common.TransferEventWriter, error) {
+	if target == "" {
+		return nil, nil
+	}
+	events, err := common.OpenTransferEventWriter(lcm, target)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open the target of transfer-events: %w", err)
+	}
+	lcm.RegisterCloseFunc(func() {
+		_ = events.Close()
+	})
+	return events, nil
+}
+
+// flushTransferEvents waits for the events of the job that's done to be written, before AzCopy says it's done,
+// and says how many were dropped, if any
+func flushTransferEvents(lcm common.LifecycleMgr, events *common.TransferEventWriter) {
+	events.Flush()
+	if dropped := events.Dropped(); dropped > 0 {
+		lcm.Info(fmt.Sprintf("%d transfer events were dropped, because they couldn't be written as fast as transfers were done", dropped))
+	}
+}
+
+// newJobHooks returns the hooks set by --hook-url and --hook-command, if any, and waits for them to finish running when AzCopy exits
+func newJobHooks(lcm common.LifecycleMgr, url string, command string, events string) (*common.JobHooks, error) {
+	hookEvents, err := common.ParseJobHookEvents(events)
+	if err != nil {
+		return nil, err
+	}
+	if url != "" && !strings.HasPrefix(strings.ToLower(url), "http://") && !strings.HasPrefix(strings.ToLower(url), "https://") {
+		return nil, errors.New("hook-url must be an http or https URL")
+	}
+	hooks := common.NewJobHooks(url, command, hookEvents)
+	if hooks != nil {
+		lcm.RegisterCloseFunc(hooks.Close)
+	}
+	return hooks, nil
+}
+
+func validatePutMd5(lcm common.LifecycleMgr, putMd5 bool, fromTo common.FromTo) error {
 	// In case of S2S transfers, log info message to inform the users that MD5 check doesn't work for S2S Transfers.
 	// This is because we cannot calculate MD5 hash of the data stored at a remote locations.
 	if putMd5 && fromTo.IsS2S() {
-		glcm.Info(" --put-md5 flag to check data consistency between source and destination is not applicable for S2S Transfers (i.e. When both the source and the destination are remote). AzCopy cannot compute MD5 hash of data stored at remote location.")
+		lcm.Info(" --put-md5 flag to check data consistency between source and destination is not applicable for S2S Transfers (i.e. When both the source and the destination are remote). AzCopy cannot compute MD5 hash of data stored at remote location.")
 	}
 	if putMd5 && !fromTo.IsUpload() {
 		return fmt.Errorf("put-md5 is set but the job is not an upload")
@@ -1151,6 +1352,47 @@ type CookedCopyCmdArgs struct {
 
 	// Optional flag that permanently deletes soft deleted blobs
 	permanentDeleteOption common.PermanentDeleteOption
+
+	// when set, page blobs are copied incrementally: only the pages changed since this snapshot are copied
+	pageDiffBaseline string
+	// the SAS of the baseline, when it's the URL of a snapshot with a SAS of its own. It's not kept in the plan
+	pageDiffBaselineSAS string
+
+	// when set, local files are disk images in this format, and are uploaded as fixed VHDs
+	diskImageFormat common.DiskImageFormat
+
+	// when true, the append blob destination keeps receiving whatever is written to the source file, until the job is cancelled
+	followSource bool
+
+	// how this job shares the transfer engine with other jobs running in the same process
+	jobPriority          common.JobPriority
+	jobMaxConcurrency    int32
+	jobMaxBytesPerSecond int64
+
+	// receives an event as each transfer is done, if --transfer-events is set
+	transferEvents *common.TransferEventWriter
+
+	// run as the job starts and ends and as transfers fail, if --hook-url or --hook-command is set
+	hooks *common.JobHooks
+
+	// when setting properties, which of them to set, and how soon to rehydrate archived blobs whose tier is set
+	propertiesToSet   common.SetPropertiesFlags
+	rehydratePriority common.RehydratePriorityType
+
+	// when moving, the source of each file is deleted once it's been transferred and verified
+	deleteSourceOnSuccess bool
+
+	// when removing, whether to purge the earlier versions or the snapshots of each blob (instead of the blob itself),
+	// and how many of the latest of them to keep regardless of their age
+	purge      string
+	keepLatest int
+
+	// when restoring, the time to restore the blobs to, if one was given
+	restoreAsOf *time.Time
+
+	// where the output of the job goes, and the log of its enumeration
+	lcm            common.LifecycleMgr
+	scanningLogger common.ILoggerResetable
 }
 
 func (cca *CookedCopyCmdArgs) isRedirection() bool {
@@ -1179,7 +1421,7 @@ func (cca *CookedCopyCmdArgs) process() error {
 		}
 
 		// if no error, the operation is now complete
-		glcm.Exit(nil, common.EExitCode.Success())
+		cca.lcm.Exit(nil, common.EExitCode.Success())
 	}
 	return cca.processCopyJobPartOrders()
 }
@@ -1208,14 +1450,14 @@ func (cca *CookedCopyCmdArgs) processRedirectionDownload(blobResource common.Res
 	// The isPublic flag is useful in S2S transfers but doesn't much matter for download. Fortunately, no S2S happens here.
 	// This means that if there's auth, there's auth. We're happy and can move on.
 	// GetCredentialInfoForLocation also populates oauth token fields... so, it's very easy.
-	credInfo, _, err := GetCredentialInfoForLocation(ctx, common.ELocation.Blob(), blobResource.Value, blobResource.SAS, true, cca.CpkOptions)
+	credInfo, _, err := GetCredentialInfoForLocation(ctx, cca.lcm, common.ELocation.Blob(), blobResource.Value, blobResource.SAS, true, cca.CpkOptions)
 
 	if err != nil {
 		return fmt.Errorf("fatal: cannot find auth on source blob URL: %s", err.Error())
 	}
 
 	// step 1: initialize pipeline
-	p, err := createBlobPipeline(ctx, credInfo, pipeline.LogNone)
+	p, err := createBlobPipeline(ctx, cca.lcm, credInfo, pipeline.LogNone)
 	if err != nil {
 		return err
 	}
@@ -1258,14 +1500,14 @@ func (cca *CookedCopyCmdArgs) processRedirectionUpload(blobResource common.Resou
 	}
 
 	// GetCredentialInfoForLocation populates oauth token fields... so, it's very easy.
-	credInfo, _, err := GetCredentialInfoForLocation(ctx, common.ELocation.Blob(), blobResource.Value, blobResource.SAS, false, cca.CpkOptions)
+	credInfo, _, err := GetCredentialInfoForLocation(ctx, cca.lcm, common.ELocation.Blob(), blobResource.Value, blobResource.SAS, false, cca.CpkOptions)
 
 	if err != nil {
 		return fmt.Errorf("fatal: cannot find auth on source blob URL: %s", err.Error())
 	}
 
 	// step 0: initialize pipeline
-	p, err := createBlobPipeline(ctx, credInfo, pipeline.LogNone)
+	p, err := createBlobPipeline(ctx, cca.lcm, credInfo, pipeline.LogNone)
 	if err != nil {
 		return err
 	}
@@ -1314,6 +1556,12 @@ func (cca *CookedCopyCmdArgs) processRedirectionUpload(blobResource common.Resou
 // dispatches the job order (in parts) to the storage engine
 func (cca *CookedCopyCmdArgs) processCopyJobPartOrders() (err error) {
 	ctx := context.WithValue(context.TODO(), ste.ServiceAPIVersionOverride, ste.DefaultServiceApiVersion)
+	ctx, span := startEnumerationSpan(ctx, cca.jobID)
+	defer func() { endEnumerationSpan(span, err) }()
+	if cca.scanningLogger != nil {
+		// so that the requests of the enumeration are logged with their fields, in the JSON log format
+		ctx = common.WithLogContext(ctx, cca.scanningLogger, common.LogFields{})
+	}
 	// Make AUTO default for Azure Files since Azure Files throttles too easily unless user specified concurrency value
 	if jobsAdmin.JobsAdmin != nil && (cca.FromTo.From() == common.ELocation.File() || cca.FromTo.To() == common.ELocation.File()) && glcm.GetEnvironmentVariable(common.EEnvironmentVariable.ConcurrencyValue()) == "" {
 		jobsAdmin.JobsAdmin.SetConcurrencySettingsToAuto()
@@ -1326,7 +1574,7 @@ func (cca *CookedCopyCmdArgs) processCopyJobPartOrders() (err error) {
 	// Note: Currently, only one credential type is necessary for source and destination.
 	// For upload&download, only one side need credential.
 	// For S2S copy, as azcopy-v10 use Put*FromUrl, only one credential is needed for destination.
-	if cca.credentialInfo.CredentialType, err = getCredentialType(ctx, rawFromToInfo{
+	if cca.credentialInfo.CredentialType, err = getCredentialType(ctx, cca.lcm, rawFromToInfo{
 		fromTo:         cca.FromTo,
 		source:         cca.Source.Value,
 		destination:    cca.Destination.Value,
@@ -1356,9 +1604,12 @@ func (cca *CookedCopyCmdArgs) processCopyJobPartOrders() (err error) {
 		ForceWrite:      cca.ForceWrite,
 		ForceIfReadOnly: cca.ForceIfReadOnly,
 		AutoDecompress:  cca.autoDecompress,
-		Priority:        common.EJobPriority.Normal(),
+		Priority:        cca.jobPriority,
 		LogLevel:        cca.LogVerbosity,
 		ExcludeBlobType: cca.excludeBlobType,
+
+		MaxConcurrency:    cca.jobMaxConcurrency,
+		MaxBytesPerSecond: cca.jobMaxBytesPerSecond,
 		BlobAttributes: common.BlobTransferAttributes{
 			BlobType:                 cca.blobType,
 			BlockSizeInBytes:         cca.blockSize,
@@ -1376,10 +1627,17 @@ func (cca *CookedCopyCmdArgs) processCopyJobPartOrders() (err error) {
 			MD5ValidationOption:      cca.md5ValidationOption,
 			DeleteSnapshotsOption:    cca.deleteSnapshotsOption,
 			// Setting tags when tags explicitly provided by the user through blob-tags flag
-			BlobTagsString: cca.blobTags.ToString(),
+			BlobTagsString:   cca.blobTags.ToString(),
+			PageDiffBaseline: cca.pageDiffBaseline,
+			DiskImageFormat:  cca.diskImageFormat,
+			FollowSource:     cca.followSource,
 		},
 		CommandString:  cca.commandString,
 		CredentialInfo: cca.credentialInfo,
+		TransferEvents: cca.transferEvents,
+		Hooks:          cca.hooks,
+
+		PageDiffBaselineSAS: cca.pageDiffBaselineSAS,
 	}
 
 	from := cca.FromTo.From()
@@ -1429,7 +1687,7 @@ func (cca *CookedCopyCmdArgs) processCopyJobPartOrders() (err error) {
 
 		err = e.enumerate()
 	case common.EFromTo.BlobTrash(), common.EFromTo.FileTrash():
-		e, createErr := newRemoveEnumerator(cca)
+		e, createErr := newRemoveEnumerator(ctx, cca)
 		if createErr != nil {
 			return createErr
 		}
@@ -1438,7 +1696,21 @@ func (cca *CookedCopyCmdArgs) processCopyJobPartOrders() (err error) {
 
 	case common.EFromTo.BlobFSTrash():
 		// TODO merge with BlobTrash case
-		err = removeBfsResources(cca)
+		err = removeBfsResources(ctx, cca)
+
+	case common.EFromTo.BlobNone():
+		var e *CopyEnumerator
+		var createErr error
+		if cca.propertiesToSet.IsRestore() {
+			e, createErr = newRestoreEnumerator(ctx, cca)
+		} else {
+			e, createErr = newSetPropertiesEnumerator(ctx, cca)
+		}
+		if createErr != nil {
+			return createErr
+		}
+
+		err = e.enumerate()
 
 	// TODO: Hide the File to Blob direction temporarily, as service support on-going.
 	// case common.EFromTo.FileBlob():
@@ -1449,7 +1721,7 @@ func (cca *CookedCopyCmdArgs) processCopyJobPartOrders() (err error) {
 	}
 
 	if err != nil {
-		if err == NothingToRemoveError || err == NothingScheduledError {
+		if err == NothingToRemoveError || err == NothingToSetPropertiesOfError || err == NothingToRestoreError || err == NothingScheduledError {
 			return err // don't wrap it with anything that uses the word "error"
 		} else {
 			return fmt.Errorf("cannot start job due to error: %s.\n", err)
@@ -1466,7 +1738,7 @@ func (cca *CookedCopyCmdArgs) waitUntilJobCompletion(blocking bool) {
 	// print initial message to indicate that the job is starting
 	// if on dry run mode do not want to print message since no  job is being done
 	if !cca.dryrunMode {
-		glcm.Init(common.GetStandardInitOutputBuilder(cca.jobID.String(),
+		cca.lcm.Init(common.GetStandardInitOutputBuilder(cca.jobID.String(),
 			fmt.Sprintf("%s%s%s.log",
 				azcopyLogPathFolder,
 				common.OS_PATH_SEPARATOR,
@@ -1482,11 +1754,11 @@ func (cca *CookedCopyCmdArgs) waitUntilJobCompletion(blocking bool) {
 
 	// hand over control to the lifecycle manager if blocking
 	if blocking {
-		glcm.InitiateProgressReporting(cca)
-		glcm.SurrenderControl()
+		cca.lcm.InitiateProgressReporting(cca)
+		cca.lcm.SurrenderControl()
 	} else {
 		// non-blocking, return after spawning a go routine to watch the job
-		glcm.InitiateProgressReporting(cca)
+		cca.lcm.InitiateProgressReporting(cca)
 	}
 }
 
@@ -1521,16 +1793,16 @@ func (cca *CookedCopyCmdArgs) hasFollowup() bool {
 
 func (cca *CookedCopyCmdArgs) launchFollowup(priorJobExitCode common.ExitCode) {
 	go func() {
-		glcm.AllowReinitiateProgressReporting()
+		cca.lcm.AllowReinitiateProgressReporting()
 		cca.followupJobArgs.priorJobExitCode = &priorJobExitCode
 		err := cca.followupJobArgs.process()
 		if err == NothingToRemoveError {
-			glcm.Info("Cleanup completed (nothing needed to be deleted)")
-			glcm.Exit(nil, common.EExitCode.Success())
+			cca.lcm.Info("Cleanup completed (nothing needed to be deleted)")
+			cca.lcm.Exit(nil, common.EExitCode.Success())
 		} else if err != nil {
-			glcm.Error("failed to perform followup/cleanup job due to error: " + err.Error())
+			cca.lcm.Error("failed to perform followup/cleanup job due to error: " + err.Error())
 		}
-		glcm.SurrenderControl()
+		cca.lcm.SurrenderControl()
 	}()
 }
 
@@ -1608,6 +1880,7 @@ func (cca *CookedCopyCmdArgs) ReportProgressOrExit(lcm common.LifecycleMgr) (tot
 	})
 
 	if jobDone {
+		flushTransferEvents(lcm, cca.transferEvents)
 		exitCode := cca.getSuccessExitCode()
 		if summary.TransfersFailed > 0 {
 			exitCode = common.EExitCode.Error()
@@ -1764,7 +2037,7 @@ func init() {
 	cpCmd := &cobra.Command{
 		Use:        "copy [source] [destination]",
 		Aliases:    []string{"cp", "c"},
-		SuggestFor: []string{"cpy", "cy", "mv"}, // TODO why does message appear twice on the console
+		SuggestFor: []string{"cpy", "cy"}, // TODO why does message appear twice on the console
 		Short:      copyCmdShortDescription,
 		Long:       copyCmdLongDescription,
 		Example:    copyCmdExample,
@@ -1889,6 +2162,28 @@ func init() {
 	cpCmd.PersistentFlags().BoolVar(&raw.s2sPreserveBlobTags, "s2s-preserve-blob-tags", false, "Preserve index tags during service to service transfer from one blob storage to another")
 	cpCmd.PersistentFlags().BoolVar(&raw.includeDirectoryStubs, "include-directory-stub", false, "False by default to ignore directory stubs. Directory stubs are blobs with metadata 'hdi_isfolder:true'. Setting value to true will preserve directory stubs during transfers.")
 	cpCmd.PersistentFlags().BoolVar(&raw.disableAutoDecoding, "disable-auto-decoding", false, "False by default to enable automatic decoding of illegal chars on Windows. Can be set to true to disable automatic decoding.")
+	cpCmd.PersistentFlags().StringVar(&raw.incrementalFrom, "incremental-from", "", "Copy a page blob incrementally, into an existing destination that already matches the given snapshot of the source. "+
+		"Only the pages that have changed since that snapshot are copied. Specify the snapshot's timestamp or, for managed disks, the URL of the previous disk snapshot. "+
+		"A SAS on that URL is used to read the snapshot, but is not saved in the job plan, so it must be given again as --incremental-from-sas when resuming. "+
+		"When complete, the destination is snapshotted and the snapshot is tagged, in its metadata, with the baseline to use for the next incremental copy.")
+	cpCmd.PersistentFlags().StringVar(&raw.diskImageFormat, "disk-image-format", "", "Treat the local files as disk images in the given format (Raw, QCOW2 or VMDK), and upload each one as a fixed VHD page blob, ready to be imported as a managed disk. "+
+		"The image is converted as it is uploaded, so no extra local disk space is needed. The VHD is padded to a whole number of MiB, and its footer is generated by AzCopy. "+
+		"QCOW2 images must not have backing files or be encrypted, and VMDK images must be monolithicSparse or streamOptimized.")
+	cpCmd.PersistentFlags().BoolVar(&raw.follow, "follow", false, "Keep uploading a growing local file (e.g. a log file) into an append blob, until the job is cancelled. Requires --blob-type=AppendBlob. "+
+		"If the file is rotated or truncated, the content of the new file is appended after that of the old one. "+
+		"Progress is checkpointed, so 'azcopy jobs resume' continues from where the job was stopped. Note that an append blob can hold at most 50,000 appended blocks.")
+	cpCmd.PersistentFlags().StringVar(&raw.jobPriority, "job-priority", "Normal", "Share of the transfer engine this job gets when other jobs run in the same process (e.g. in 'azcopy daemon'): Low, Normal or High. "+
+		"A High priority job gets twice the share of a Normal one, which gets twice the share of a Low one.")
+	cpCmd.PersistentFlags().IntVar(&raw.jobMaxConcurrency, "job-max-concurrency", 0, "Caps the number of concurrent requests made for this job, without limiting other jobs running in the same process. If this option is set to zero, or it is omitted, the job isn't capped.")
+	cpCmd.PersistentFlags().Float64Var(&raw.jobCapMbps, "job-cap-mbps", 0, "Caps the transfer rate of this job, in megabits per second. It applies within the overall limit set by --cap-mbps. If this option is set to zero, or it is omitted, the job isn't capped.")
+	cpCmd.PersistentFlags().StringVar(&raw.transferEvents, "transfer-events", "", "Writes an event, as a line of JSON, as each transfer completes, fails or is skipped. "+
+		"The events go to the standard output if this option is set to 'stdout', where they are printed in the format of --output-type, or else are appended to the given file or named pipe. "+
+		"They are written in the background: if they can't be written as fast as transfers are done, some are dropped, and AzCopy says how many. "+
+		"Each has the source, destination, size, duration, MD5 hash, version IDs and error code of the transfer.")
+	cpCmd.PersistentFlags().StringVar(&raw.hookURL, "hook-url", "", "URL to POST a JSON description of the job to, as it starts and ends and as transfers fail. See --hook-events.")
+	cpCmd.PersistentFlags().StringVar(&raw.hookCommand, "hook-command", "", "Command to run, with a shell, as the job starts and ends and as transfers fail. "+
+		"It's given the JSON that --hook-url is sent on its standard input, and the gist of it in environment variables such as AZCOPY_HOOK_EVENT, AZCOPY_JOB_ID and AZCOPY_JOB_STATUS.")
+	cpCmd.PersistentFlags().StringVar(&raw.hookEvents, "hook-events", "", "Comma-separated list of the events that the hooks are run for: JobStarted, JobCompleted, JobCancelled and TransferFailed. By default, they are run for all of them.")
 	cpCmd.PersistentFlags().BoolVar(&raw.dryrun, "dry-run", false, "Prints the file paths that would be copied by this command. This flag does not copy the actual files.")
 	// s2sGetPropertiesInBackend is an optional flag for controlling whether S3 object's or Azure file's full properties are get during enumerating in frontend or
 	// right before transferring in ste(backend).
@@ -1925,4 +2220,7 @@ func init() {
 	// Deprecate the old persist-smb-permissions flag
 	cpCmd.PersistentFlags().MarkHidden("preserve-smb-permissions")
 	cpCmd.PersistentFlags().BoolVar(&raw.preservePermissions, PreservePermissionsFlag, false, "False by default. Preserves ACLs between aware resources (Windows and Azure Files, or ADLS Gen 2 to ADLS Gen 2). For Hierarchical Namespace accounts, you will need a container SAS or OAuth token with Modify Ownership and Modify Permissions permissions. For downloads, you will also need the --backup flag to restore permissions where the new Owner will not be the user running AzCopy. This flag applies to both files and folders, unless a file-only filter is specified (e.g. include-pattern).")
+
+	// the defaults of the flags, for the copies that are run in-process
+	rawCopyDefaults = raw
 }
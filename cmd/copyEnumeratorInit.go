@@ -35,7 +35,7 @@ func (cca *CookedCopyCmdArgs) initEnumerator(jobPartOrder common.CopyJobPartOrde
 	var isPublic bool
 	var err error
 
-	if srcCredInfo, isPublic, err = GetCredentialInfoForLocation(ctx, cca.FromTo.From(), cca.Source.Value, cca.Source.SAS, true, cca.CpkOptions); err != nil {
+	if srcCredInfo, isPublic, err = GetCredentialInfoForLocation(ctx, cca.lcm, cca.FromTo.From(), cca.Source.Value, cca.Source.SAS, true, cca.CpkOptions); err != nil {
 		return nil, err
 		// If S2S and source takes OAuthToken as its cred type (OR) source takes anonymous as its cred type, but it's not public and there's no SAS
 	} else if cca.FromTo.IsS2S() &&
@@ -45,7 +45,7 @@ func (cca *CookedCopyCmdArgs) initEnumerator(jobPartOrder common.CopyJobPartOrde
 	}
 
 	if cca.Source.SAS != "" && cca.FromTo.IsS2S() && jobPartOrder.CredentialInfo.CredentialType == common.ECredentialType.OAuthToken() {
-		glcm.Info("Authentication: If the source and destination accounts are in the same AAD tenant & the user/spn/msi has appropriate permissions on both, the source SAS token is not required and OAuth can be used round-trip.")
+		cca.lcm.Info("Authentication: If the source and destination accounts are in the same AAD tenant & the user/spn/msi has appropriate permissions on both, the source SAS token is not required and OAuth can be used round-trip.")
 	}
 
 	if cca.FromTo.IsS2S() {
@@ -78,6 +78,7 @@ func (cca *CookedCopyCmdArgs) initEnumerator(jobPartOrder common.CopyJobPartOrde
 	jobPartOrder.S2SGetPropertiesInBackend = cca.s2sPreserveProperties && !getRemoteProperties && cca.s2sGetPropertiesInBackend // Infer GetProperties if GetPropertiesInBackend is enabled.
 	jobPartOrder.S2SSourceChangeValidation = cca.s2sSourceChangeValidation
 	jobPartOrder.DestLengthValidation = cca.CheckLength
+	jobPartOrder.DeleteSourceOnSuccess = cca.deleteSourceOnSuccess
 	jobPartOrder.S2SInvalidMetadataHandleOption = cca.s2sInvalidMetadataHandleOption
 	jobPartOrder.S2SPreserveBlobTags = cca.S2sPreserveBlobTags
 
@@ -167,7 +168,7 @@ func (cca *CookedCopyCmdArgs) initEnumerator(jobPartOrder common.CopyJobPartOrde
 			// check against seenFailedContainers so we don't spam the job log with initialization failed errors
 			if _, ok := seenFailedContainers[dstContainerName]; err != nil && jobsAdmin.JobsAdmin != nil && !ok {
 				logDstContainerCreateFailureOnce.Do(func() {
-					glcm.Info("Failed to create one or more destination container(s). Your transfers may still succeed if the container already exists.")
+					cca.lcm.Info("Failed to create one or more destination container(s). Your transfers may still succeed if the container already exists.")
 				})
 				jobsAdmin.JobsAdmin.LogToJobLog(fmt.Sprintf("failed to initialize destination container %s; the transfer will continue (but be wary it may fail): %s", dstContainerName, err), pipeline.LogWarning)
 				seenFailedContainers[dstContainerName] = true
@@ -203,7 +204,7 @@ func (cca *CookedCopyCmdArgs) initEnumerator(jobPartOrder common.CopyJobPartOrde
 					// check against seenFailedContainers so we don't spam the job log with initialization failed errors
 					if _, ok := seenFailedContainers[bucketName]; err != nil && jobsAdmin.JobsAdmin != nil && !ok {
 						logDstContainerCreateFailureOnce.Do(func() {
-							glcm.Info("Failed to create one or more destination container(s). Your transfers may still succeed if the container already exists.")
+							cca.lcm.Info("Failed to create one or more destination container(s). Your transfers may still succeed if the container already exists.")
 						})
 						jobsAdmin.JobsAdmin.LogToJobLog(fmt.Sprintf("failed to initialize destination container %s; the transfer will continue (but be wary it may fail): %s", bucketName, err), pipeline.LogWarning)
 						seenFailedContainers[bucketName] = true
@@ -223,7 +224,7 @@ func (cca *CookedCopyCmdArgs) initEnumerator(jobPartOrder common.CopyJobPartOrde
 
 					if _, ok := seenFailedContainers[dstContainerName]; err != nil && jobsAdmin.JobsAdmin != nil && !ok {
 						logDstContainerCreateFailureOnce.Do(func() {
-							glcm.Info("Failed to create one or more destination container(s). Your transfers may still succeed if the container already exists.")
+							cca.lcm.Info("Failed to create one or more destination container(s). Your transfers may still succeed if the container already exists.")
 						})
 						jobsAdmin.JobsAdmin.LogToJobLog(fmt.Sprintf("failed to initialize destination container %s; the transfer will continue (but be wary it may fail): %s", dstContainerName, err), pipeline.LogWarning)
 						seenFailedContainers[dstContainerName] = true
@@ -239,7 +240,7 @@ func (cca *CookedCopyCmdArgs) initEnumerator(jobPartOrder common.CopyJobPartOrde
 	var message string
 	jobPartOrder.Fpo, message = newFolderPropertyOption(cca.FromTo, cca.Recursive, cca.StripTopDir, filters, cca.preserveSMBInfo, cca.preservePermissions.IsTruthy(), cca.isHNStoHNS, strings.EqualFold(cca.Destination.Value, common.Dev_Null))
 	if !cca.dryrunMode {
-		glcm.Info(message)
+		cca.lcm.Info(message)
 	}
 	if jobsAdmin.JobsAdmin != nil {
 		jobsAdmin.JobsAdmin.LogToJobLog(message, pipeline.LogInfo)
@@ -289,8 +290,18 @@ func (cca *CookedCopyCmdArgs) initEnumerator(jobPartOrder common.CopyJobPartOrde
 			transfer.BlobTags = cca.blobTags
 		}
 
+		if cca.diskImageFormat != common.EDiskImageFormat.None() && shouldSendToSte && transfer.EntityType == common.EEntityType.File() {
+			// the image is uploaded as a fixed VHD, so the size to transfer is that of the VHD, not of the image file
+			imagePath := common.GenerateFullPath(jobPartOrder.SourceRoot.Value, srcRelPath)
+			vhdSize, err := common.DiskImageAsFixedVHDSize(imagePath, cca.diskImageFormat)
+			if err != nil {
+				return err
+			}
+			transfer.SourceSize = vhdSize
+		}
+
 		if cca.dryrunMode && shouldSendToSte {
-			glcm.Dryrun(func(format common.OutputFormat) string {
+			cca.lcm.Dryrun(func(format common.OutputFormat) string {
 				if format == common.EOutputFormat.Json() {
 					jsonOutput, err := json.Marshal(transfer)
 					common.PanicIfErr(err)
@@ -351,7 +362,7 @@ func (cca *CookedCopyCmdArgs) isDestDirectory(dst common.ResourceString, ctx *co
 		return false
 	}
 
-	if dstCredInfo, _, err = GetCredentialInfoForLocation(*ctx, cca.FromTo.To(), cca.Destination.Value, cca.Destination.SAS, false, cca.CpkOptions); err != nil {
+	if dstCredInfo, _, err = GetCredentialInfoForLocation(*ctx, cca.lcm, cca.FromTo.To(), cca.Destination.Value, cca.Destination.SAS, false, cca.CpkOptions); err != nil {
 		return false
 	}
 
@@ -368,60 +379,19 @@ func (cca *CookedCopyCmdArgs) isDestDirectory(dst common.ResourceString, ctx *co
 
 // Initialize the modular filters outside of copy to increase readability.
 func (cca *CookedCopyCmdArgs) InitModularFilters() []ObjectFilter {
-	filters := make([]ObjectFilter, 0) // same as []ObjectFilter{} under the hood
-
-	if cca.IncludeBefore != nil {
-		filters = append(filters, &IncludeBeforeDateFilter{Threshold: *cca.IncludeBefore})
-	}
-
-	if cca.IncludeAfter != nil {
-		filters = append(filters, &IncludeAfterDateFilter{Threshold: *cca.IncludeAfter})
-	}
-
-	if len(cca.IncludePatterns) != 0 {
-		filters = append(filters, &IncludeFilter{patterns: cca.IncludePatterns}) // TODO should this call buildIncludeFilters?
-	}
-
-	if len(cca.ExcludePatterns) != 0 {
-		for _, v := range cca.ExcludePatterns {
-			filters = append(filters, &excludeFilter{pattern: v})
-		}
-	}
-
-	// include-path is not a filter, therefore it does not get handled here.
-	// Check up in cook() around the list-of-files implementation as include-path gets included in the same way.
-
-	if len(cca.ExcludePathPatterns) != 0 {
-		for _, v := range cca.ExcludePathPatterns {
-			filters = append(filters, &excludeFilter{pattern: v, targetsPath: true})
-		}
-	}
-
-	if len(cca.includeRegex) != 0 {
-		filters = append(filters, &regexFilter{patterns: cca.includeRegex, isIncluded: true})
-	}
-
-	if len(cca.excludeRegex) != 0 {
-		filters = append(filters, &regexFilter{patterns: cca.excludeRegex, isIncluded: false})
-	}
-
-	if len(cca.excludeBlobType) != 0 {
-		excludeSet := map[azblob.BlobType]bool{}
-
-		for _, v := range cca.excludeBlobType {
-			excludeSet[v] = true
-		}
-
-		filters = append(filters, &excludeBlobTypeFilter{blobTypes: excludeSet})
-	}
-
-	if len(cca.IncludeFileAttributes) != 0 {
-		filters = append(filters, buildAttrFilters(cca.IncludeFileAttributes, cca.Source.ValueLocal(), true)...)
-	}
-
-	if len(cca.ExcludeFileAttributes) != 0 {
-		filters = append(filters, buildAttrFilters(cca.ExcludeFileAttributes, cca.Source.ValueLocal(), false)...)
-	}
+	filters := modularFilterOptions{
+		includeBefore:         cca.IncludeBefore,
+		includeAfter:          cca.IncludeAfter,
+		includePatterns:       cca.IncludePatterns,
+		excludePatterns:       cca.ExcludePatterns,
+		excludePathPatterns:   cca.ExcludePathPatterns,
+		includeRegex:          cca.includeRegex,
+		excludeRegex:          cca.excludeRegex,
+		excludeBlobType:       cca.excludeBlobType,
+		includeFileAttributes: cca.IncludeFileAttributes,
+		excludeFileAttributes: cca.ExcludeFileAttributes,
+		localSource:           cca.Source.ValueLocal(),
+	}.build()
 
 	// finally, log any search prefix computed from these
 	if jobsAdmin.JobsAdmin != nil {
@@ -452,11 +422,11 @@ func (cca *CookedCopyCmdArgs) createDstContainer(containerName string, dstWithSA
 
 	// 3minutes is enough time to list properties of a container, and create new if it does not exist.
 	ctx, _ := context.WithTimeout(parentCtx, time.Minute*3)
-	if dstCredInfo, _, err = GetCredentialInfoForLocation(ctx, cca.FromTo.To(), cca.Destination.Value, cca.Destination.SAS, false, cca.CpkOptions); err != nil {
+	if dstCredInfo, _, err = GetCredentialInfoForLocation(ctx, cca.lcm, cca.FromTo.To(), cca.Destination.Value, cca.Destination.SAS, false, cca.CpkOptions); err != nil {
 		return err
 	}
 
-	dstPipeline, err := InitPipeline(ctx, cca.FromTo.To(), dstCredInfo, logLevel.ToPipelineLogLevel())
+	dstPipeline, err := InitPipeline(ctx, cca.lcm, cca.FromTo.To(), dstCredInfo, logLevel.ToPipelineLogLevel())
 	if err != nil {
 		return
 	}
@@ -135,7 +135,7 @@ func GetOAuthTokenManagerInstance() (*common.UserOAuthTokenManager, error) {
 // 4. If there is OAuth token info passed from env var, indicating using token credential. (Note: this is only for testing)
 // 5. Otherwise use anonymous credential.
 // The implementation logic follows above rule, and adjusts sequence to save web request(for verifying public resource).
-func getBlobCredentialType(ctx context.Context, blobResourceURL string, canBePublic bool, standaloneSAS bool, cpkOptions common.CpkOptions) (common.CredentialType, bool, error) {
+func getBlobCredentialType(ctx context.Context, lcm common.LifecycleMgr, blobResourceURL string, canBePublic bool, standaloneSAS bool, cpkOptions common.CpkOptions) (common.CredentialType, bool, error) {
 	resourceURL, err := url.Parse(blobResourceURL)
 
 	if err != nil {
@@ -204,7 +204,7 @@ func getBlobCredentialType(ctx context.Context, blobResourceURL string, canBePub
 	}
 
 	// If SAS token doesn't exist, it could be using OAuth token or the resource is public.
-	if !oAuthTokenExists() { // no oauth token found, then directly return anonymous credential
+	if !oAuthTokenExists(lcm) { // no oauth token found, then directly return anonymous credential
 		isPublicResource := checkPublic()
 
 		// No forms of auth are present.no SAS token or OAuth token is present and the resource is not public
@@ -234,7 +234,7 @@ func getBlobCredentialType(ctx context.Context, blobResourceURL string, canBePub
 // 2. If there is cached session OAuth token, indicating using token credential.
 // 3. If there is OAuth token info passed from env var, indicating using token credential. (Note: this is only for testing)
 // 4. Otherwise use shared key.
-func getBlobFSCredentialType(ctx context.Context, blobResourceURL string, standaloneSAS bool) (common.CredentialType, error) {
+func getBlobFSCredentialType(ctx context.Context, lcm common.LifecycleMgr, blobResourceURL string, standaloneSAS bool) (common.CredentialType, error) {
 	resourceURL, err := url.Parse(blobResourceURL)
 	if err != nil {
 		return common.ECredentialType.Unknown(), err
@@ -247,12 +247,12 @@ func getBlobFSCredentialType(ctx context.Context, blobResourceURL string, standa
 		return common.ECredentialType.Anonymous(), nil
 	}
 
-	if oAuthTokenExists() {
+	if oAuthTokenExists(lcm) {
 		return common.ECredentialType.OAuthToken(), nil
 	}
 
-	name := glcm.GetEnvironmentVariable(common.EEnvironmentVariable.AccountName())
-	key := glcm.GetEnvironmentVariable(common.EEnvironmentVariable.AccountKey())
+	name := lcm.GetEnvironmentVariable(common.EEnvironmentVariable.AccountName())
+	key := lcm.GetEnvironmentVariable(common.EEnvironmentVariable.AccountKey())
 	if name != "" && key != "" { // TODO: To remove, use for internal testing, SharedKey should not be supported from commandline
 		return common.ECredentialType.SharedKey(), nil
 	} else {
@@ -263,13 +263,13 @@ func getBlobFSCredentialType(ctx context.Context, blobResourceURL string, standa
 
 var announceOAuthTokenOnce sync.Once
 
-func oAuthTokenExists() (oauthTokenExists bool) {
+func oAuthTokenExists(lcm common.LifecycleMgr) (oauthTokenExists bool) {
 	// Note: Environment variable for OAuth token should only be used in testing, or the case user clearly now how to protect
 	// the tokens
 	if common.EnvVarOAuthTokenInfoExists() {
 		announceOAuthTokenOnce.Do(
 			func() {
-				glcm.Info(fmt.Sprintf("%v is set.", common.EnvVarOAuthTokenInfo)) // Log the case when env var is set, as it's rare case.
+				lcm.Info(fmt.Sprintf("%v is set.", common.EnvVarOAuthTokenInfo)) // Log the case when env var is set, as it's rare case.
 			},
 		)
 		oauthTokenExists = true
@@ -446,8 +446,8 @@ func checkAuthSafeForTarget(ct common.CredentialType, resource, extraSuffixesAAD
 	return nil
 }
 
-func logAuthType(ct common.CredentialType, location common.Location, isSource bool) {
-	if location == common.ELocation.Unknown() {
+func logAuthType(lcm common.LifecycleMgr, ct common.CredentialType, location common.Location, isSource bool) {
+	if location == common.ELocation.Unknown() || location == common.ELocation.None() {
 		return // nothing to log
 	} else if location.IsLocal() {
 		return // don't log local ones, no point
@@ -469,17 +469,17 @@ func logAuthType(ct common.CredentialType, location common.Location, isSource bo
 		if jobsAdmin.JobsAdmin != nil {
 			jobsAdmin.JobsAdmin.LogToJobLog(message, pipeline.LogInfo)
 		}
-		glcm.Info(message)
+		lcm.Info(message)
 	}
 }
 
 var authMessagesAlreadyLogged = &sync.Map{}
 
-func getCredentialTypeForLocation(ctx context.Context, location common.Location, resource, resourceSAS string, isSource bool, cpkOptions common.CpkOptions) (credType common.CredentialType, isPublic bool, err error) {
-	return doGetCredentialTypeForLocation(ctx, location, resource, resourceSAS, isSource, GetCredTypeFromEnvVar, cpkOptions)
+func getCredentialTypeForLocation(ctx context.Context, lcm common.LifecycleMgr, location common.Location, resource, resourceSAS string, isSource bool, cpkOptions common.CpkOptions) (credType common.CredentialType, isPublic bool, err error) {
+	return doGetCredentialTypeForLocation(ctx, lcm, location, resource, resourceSAS, isSource, GetCredTypeFromEnvVar, cpkOptions)
 }
 
-func doGetCredentialTypeForLocation(ctx context.Context, location common.Location, resource, resourceSAS string, isSource bool, getForcedCredType func() common.CredentialType, cpkOptions common.CpkOptions) (credType common.CredentialType, isPublic bool, err error) {
+func doGetCredentialTypeForLocation(ctx context.Context, lcm common.LifecycleMgr, location common.Location, resource, resourceSAS string, isSource bool, getForcedCredType func() common.CredentialType, cpkOptions common.CpkOptions) (credType common.CredentialType, isPublic bool, err error) {
 	if resourceSAS != "" {
 		credType = common.ECredentialType.Anonymous()
 	} else if credType = getForcedCredType(); credType == common.ECredentialType.Unknown() || location == common.ELocation.S3() || location == common.ELocation.GCP() {
@@ -487,7 +487,7 @@ func doGetCredentialTypeForLocation(ctx context.Context, location common.Locatio
 		case common.ELocation.Local(), common.ELocation.Benchmark():
 			credType = common.ECredentialType.Anonymous()
 		case common.ELocation.Blob():
-			credType, isPublic, err = getBlobCredentialType(ctx, resource, isSource, resourceSAS != "", cpkOptions)
+			credType, isPublic, err = getBlobCredentialType(ctx, lcm, resource, isSource, resourceSAS != "", cpkOptions)
 			if azErr, ok := err.(common.AzError); ok && azErr.Equals(common.EAzError.LoginCredMissing()) {
 				_, autoLoginErr := GetOAuthTokenManagerInstance()
 				if autoLoginErr == nil {
@@ -503,7 +503,7 @@ func doGetCredentialTypeForLocation(ctx context.Context, location common.Locatio
 				return common.ECredentialType.Unknown(), false, err
 			}
 		case common.ELocation.BlobFS():
-			credType, err = getBlobFSCredentialType(ctx, resource, resourceSAS != "")
+			credType, err = getBlobFSCredentialType(ctx, lcm, resource, resourceSAS != "")
 			if azErr, ok := err.(common.AzError); ok && azErr.Equals(common.EAzError.LoginCredMissing()) {
 				_, autoLoginErr := GetOAuthTokenManagerInstance()
 				if autoLoginErr == nil {
@@ -515,15 +515,15 @@ func doGetCredentialTypeForLocation(ctx context.Context, location common.Locatio
 				return common.ECredentialType.Unknown(), false, err
 			}
 		case common.ELocation.S3():
-			accessKeyID := glcm.GetEnvironmentVariable(common.EEnvironmentVariable.AWSAccessKeyID())
-			secretAccessKey := glcm.GetEnvironmentVariable(common.EEnvironmentVariable.AWSSecretAccessKey())
+			accessKeyID := lcm.GetEnvironmentVariable(common.EEnvironmentVariable.AWSAccessKeyID())
+			secretAccessKey := lcm.GetEnvironmentVariable(common.EEnvironmentVariable.AWSSecretAccessKey())
 			if accessKeyID == "" || secretAccessKey == "" {
 				credType = common.ECredentialType.S3PublicBucket()
 				return credType, true, nil
 			}
 			credType = common.ECredentialType.S3AccessKey()
 		case common.ELocation.GCP():
-			googleAppCredentials := glcm.GetEnvironmentVariable(common.EEnvironmentVariable.GoogleAppCredentials())
+			googleAppCredentials := lcm.GetEnvironmentVariable(common.EEnvironmentVariable.GoogleAppCredentials())
 			if googleAppCredentials == "" {
 				return common.ECredentialType.Unknown(), false, errors.New("GOOGLE_APPLICATION_CREDENTIALS environment variable must be set before using GCP transfer feature")
 			}
@@ -535,14 +535,14 @@ func doGetCredentialTypeForLocation(ctx context.Context, location common.Locatio
 		return common.ECredentialType.Unknown(), false, err
 	}
 
-	logAuthType(credType, location, isSource)
+	logAuthType(lcm, credType, location, isSource)
 	return
 }
 
-func GetCredentialInfoForLocation(ctx context.Context, location common.Location, resource, resourceSAS string, isSource bool, cpkOptions common.CpkOptions) (credInfo common.CredentialInfo, isPublic bool, err error) {
+func GetCredentialInfoForLocation(ctx context.Context, lcm common.LifecycleMgr, location common.Location, resource, resourceSAS string, isSource bool, cpkOptions common.CpkOptions) (credInfo common.CredentialInfo, isPublic bool, err error) {
 
 	// get the type
-	credInfo.CredentialType, isPublic, err = getCredentialTypeForLocation(ctx, location, resource, resourceSAS, isSource, cpkOptions)
+	credInfo.CredentialType, isPublic, err = getCredentialTypeForLocation(ctx, lcm, location, resource, resourceSAS, isSource, cpkOptions)
 
 	// flesh out the rest of the fields, for those types that require it
 	if credInfo.CredentialType == common.ECredentialType.OAuthToken() {
@@ -565,26 +565,27 @@ func GetCredentialInfoForLocation(ctx context.Context, location common.Location,
 // for current command.
 // TODO: consider replace with calls to getCredentialInfoForLocation
 // (right now, we have tweaked this to be a wrapper for that function, but really should remove this one totally)
-func getCredentialType(ctx context.Context, raw rawFromToInfo, cpkOptions common.CpkOptions) (credType common.CredentialType, err error) {
+func getCredentialType(ctx context.Context, lcm common.LifecycleMgr, raw rawFromToInfo, cpkOptions common.CpkOptions) (credType common.CredentialType, err error) {
 
 	switch {
 	case raw.fromTo.To().IsRemote():
 		// we authenticate to the destination. Source is assumed to be SAS, or public, or a local resource
-		credType, _, err = getCredentialTypeForLocation(ctx, raw.fromTo.To(), raw.destination, raw.destinationSAS, false, common.CpkOptions{})
+		credType, _, err = getCredentialTypeForLocation(ctx, lcm, raw.fromTo.To(), raw.destination, raw.destinationSAS, false, common.CpkOptions{})
 	case raw.fromTo == common.EFromTo.BlobTrash() ||
 		raw.fromTo == common.EFromTo.BlobFSTrash() ||
-		raw.fromTo == common.EFromTo.FileTrash():
-		// For to Trash direction, use source as resource URL
+		raw.fromTo == common.EFromTo.FileTrash() ||
+		raw.fromTo == common.EFromTo.BlobNone():
+		// For to Trash direction, and for setting properties in place, use source as resource URL
 		// Also, by setting isSource=false we inform getCredentialTypeForLocation() that resource
-		// being deleted cannot be public.
-		credType, _, err = getCredentialTypeForLocation(ctx, raw.fromTo.From(), raw.source, raw.sourceSAS, false, cpkOptions)
+		// being deleted, or changed, cannot be public.
+		credType, _, err = getCredentialTypeForLocation(ctx, lcm, raw.fromTo.From(), raw.source, raw.sourceSAS, false, cpkOptions)
 	case raw.fromTo.From().IsRemote() && raw.fromTo.To().IsLocal():
 		// we authenticate to the source.
-		credType, _, err = getCredentialTypeForLocation(ctx, raw.fromTo.From(), raw.source, raw.sourceSAS, true, cpkOptions)
+		credType, _, err = getCredentialTypeForLocation(ctx, lcm, raw.fromTo.From(), raw.source, raw.sourceSAS, true, cpkOptions)
 	default:
 		credType = common.ECredentialType.Anonymous()
 		// Log the FromTo types which getCredentialType hasn't solved, in case of miss-use.
-		glcm.Info(fmt.Sprintf("Use anonymous credential by default for from-to '%v'", raw.fromTo))
+		lcm.Info(fmt.Sprintf("Use anonymous credential by default for from-to '%v'", raw.fromTo))
 	}
 
 	return
@@ -593,12 +594,19 @@ func getCredentialType(ctx context.Context, raw rawFromToInfo, cpkOptions common
 // ==============================================================================================
 // pipeline factory methods
 // ==============================================================================================
-func createBlobPipeline(ctx context.Context, credInfo common.CredentialInfo, logLevel pipeline.LogLevel) (pipeline.Pipeline, error) {
-	credential := common.CreateBlobCredential(ctx, credInfo, common.CredentialOpOptions{
-		// LogInfo:  glcm.Info, //Comment out for debugging
-		LogError: glcm.Info,
+func createBlobPipeline(ctx context.Context, lcm common.LifecycleMgr, credInfo common.CredentialInfo, logLevel pipeline.LogLevel) (pipeline.Pipeline, error) {
+	return createBlobPipelineWithCredential(lcm, createBlobCredential(ctx, lcm, credInfo), logLevel), nil
+}
+
+func createBlobCredential(ctx context.Context, lcm common.LifecycleMgr, credInfo common.CredentialInfo) azblob.Credential {
+	return common.CreateBlobCredential(ctx, credInfo, common.CredentialOpOptions{
+		// LogInfo:  lcm.Info, //Comment out for debugging
+		LogError: lcm.Info,
 	})
+}
 
+// createBlobPipelineWithCredential is for callers that need the credential too, e.g. to authorize the sub-requests of batches
+func createBlobPipelineWithCredential(lcm common.LifecycleMgr, credential azblob.Credential, logLevel pipeline.LogLevel) pipeline.Pipeline {
 	logOption := pipeline.LogOptions{}
 	if azcopyScanningLogger != nil {
 		logOption = pipeline.LogOptions{
@@ -611,7 +619,7 @@ func createBlobPipeline(ctx context.Context, credInfo common.CredentialInfo, log
 		credential,
 		azblob.PipelineOptions{
 			Telemetry: azblob.TelemetryOptions{
-				Value: glcm.AddUserAgentPrefix(common.UserAgent),
+				Value: lcm.AddUserAgentPrefix(common.UserAgent),
 			},
 			Log: logOption,
 		},
@@ -625,15 +633,15 @@ func createBlobPipeline(ctx context.Context, credInfo common.CredentialInfo, log
 		nil,
 		ste.NewAzcopyHTTPClient(frontEndMaxIdleConnectionsPerHost),
 		nil, // we don't gather network stats on the credential pipeline
-	), nil
+	)
 }
 
 const frontEndMaxIdleConnectionsPerHost = http.DefaultMaxIdleConnsPerHost
 
-func createBlobFSPipeline(ctx context.Context, credInfo common.CredentialInfo, logLevel pipeline.LogLevel) (pipeline.Pipeline, error) {
+func createBlobFSPipeline(ctx context.Context, lcm common.LifecycleMgr, credInfo common.CredentialInfo, logLevel pipeline.LogLevel) (pipeline.Pipeline, error) {
 	credential := common.CreateBlobFSCredential(ctx, credInfo, common.CredentialOpOptions{
-		// LogInfo:  glcm.Info, //Comment out for debugging
-		LogError: glcm.Info,
+		// LogInfo:  lcm.Info, //Comment out for debugging
+		LogError: lcm.Info,
 	})
 
 	logOption := pipeline.LogOptions{}
@@ -648,7 +656,7 @@ func createBlobFSPipeline(ctx context.Context, credInfo common.CredentialInfo, l
 		credential,
 		azbfs.PipelineOptions{
 			Telemetry: azbfs.TelemetryOptions{
-				Value: glcm.AddUserAgentPrefix(common.UserAgent),
+				Value: lcm.AddUserAgentPrefix(common.UserAgent),
 			},
 			Log: logOption,
 		},
@@ -666,7 +674,7 @@ func createBlobFSPipeline(ctx context.Context, credInfo common.CredentialInfo, l
 }
 
 // TODO note: ctx and credInfo are ignored at the moment because we only support SAS for Azure File
-func createFilePipeline(ctx context.Context, credInfo common.CredentialInfo, logLevel pipeline.LogLevel) (pipeline.Pipeline, error) {
+func createFilePipeline(ctx context.Context, lcm common.LifecycleMgr, credInfo common.CredentialInfo, logLevel pipeline.LogLevel) (pipeline.Pipeline, error) {
 	logOption := pipeline.LogOptions{}
 	if azcopyScanningLogger != nil {
 		logOption = pipeline.LogOptions{
@@ -679,7 +687,7 @@ func createFilePipeline(ctx context.Context, credInfo common.CredentialInfo, log
 		azfile.NewAnonymousCredential(),
 		azfile.PipelineOptions{
 			Telemetry: azfile.TelemetryOptions{
-				Value: glcm.AddUserAgentPrefix(common.UserAgent),
+				Value: lcm.AddUserAgentPrefix(common.UserAgent),
 			},
 			Log: logOption,
 		},
@@ -0,0 +1,228 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+	"github.com/spf13/cobra"
+)
+
+type rawDaemonCmdArgs struct {
+	listen string
+}
+
+func init() {
+	raw := rawDaemonCmdArgs{}
+
+	daemonCmd := &cobra.Command{
+		Use:     "daemon",
+		Short:   daemonCmdShortDescription,
+		Long:    daemonCmdLongDescription,
+		Example: daemonCmdExample,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 0 {
+				return errors.New("daemon does not take any arguments")
+			}
+			return nil
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			err := runDaemon(raw.listen)
+			if err != nil {
+				glcm.Error("daemon failed: " + err.Error())
+			}
+			glcm.Exit(nil, common.EExitCode.Success())
+		},
+	}
+	rootCmd.AddCommand(daemonCmd)
+
+	daemonCmd.PersistentFlags().StringVar(&raw.listen, "listen", "127.0.0.1:0",
+		"Address to serve the API on: a loopback host:port (port 0 picks a free port), or unix:///path/to/socket.")
+}
+
+// listenDaemon opens the daemon's listener, and returns the endpoint that clients should put in AZCOPY_DAEMON_ENDPOINT.
+// TCP is restricted to loopback addresses, since the API can read and write everything the daemon's credentials can.
+func listenDaemon(address string) (net.Listener, string, error) {
+	if socketPath := strings.TrimPrefix(address, "unix://"); socketPath != address {
+		if socketPath == "" {
+			return nil, "", errors.New("the unix socket path is missing")
+		}
+		// a socket left behind by a daemon that did not shut down cleanly would stop us from listening
+		if conn, err := net.Dial("unix", socketPath); err == nil {
+			_ = conn.Close()
+			return nil, "", fmt.Errorf("another daemon is already listening on %s", socketPath)
+		}
+		_ = os.Remove(socketPath)
+
+		listener, err := net.Listen("unix", socketPath)
+		if err != nil {
+			return nil, "", err
+		}
+		if err = os.Chmod(socketPath, 0600); err != nil {
+			_ = listener.Close()
+			return nil, "", err
+		}
+		return listener, "unix://" + socketPath, nil
+	}
+
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, "", err
+	}
+	if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		return nil, "", fmt.Errorf("%s is not a loopback address", host)
+	}
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, "", err
+	}
+	return listener, "http://" + listener.Addr().String(), nil
+}
+
+func newDaemonToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func runDaemon(listenAddress string) error {
+	listener, endpoint, err := listenDaemon(listenAddress)
+	if err != nil {
+		return err
+	}
+
+	token := glcm.GetEnvironmentVariable(common.EEnvironmentVariable.DaemonToken())
+	if token == "" {
+		if token, err = newDaemonToken(); err != nil {
+			return err
+		}
+	}
+
+	// the token is only readable by this user, like the cached OAuth token
+	if err = writeDaemonConnectionFile(daemonConnectionInfo{Endpoint: endpoint, Token: token}); err != nil {
+		return fmt.Errorf("failed to write the connection file: %w", err)
+	}
+	defer removeDaemonConnectionFile()
+
+	server := &http.Server{Handler: newRpcServer(token, daemonJobSubmitter(endpoint, token))}
+	served := make(chan error, 1)
+	go func() {
+		served <- server.Serve(listener)
+	}()
+
+	glcm.Info(fmt.Sprintf("AzCopy daemon is listening on %s. Set %s=%s to send jobs to it. The token is in %s.",
+		endpoint, common.EEnvironmentVariable.DaemonEndpoint().Name, endpoint, daemonConnectionFilePath()))
+
+	// jobs that are still running when we stop can be continued with 'azcopy jobs resume'
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+	select {
+	case <-interrupt:
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = server.Shutdown(ctx)
+		return nil
+	case err = <-served:
+		return err
+	}
+}
+
+// daemonJobSubmitter runs the given azcopy command line in a child process that sends its job to this daemon.
+// The child does the enumeration, just like the CLI would with AZCOPY_DAEMON_ENDPOINT set, while the transfers run here.
+func daemonJobSubmitter(endpoint string, token string) func(args []string) (common.JobID, error) {
+	return func(args []string) (common.JobID, error) {
+		executable, err := os.Executable()
+		if err != nil {
+			return common.JobID{}, err
+		}
+
+		child := exec.Command(executable, append(args, "--output-type=json")...)
+		child.Env = append(os.Environ(),
+			common.EEnvironmentVariable.DaemonEndpoint().Name+"="+endpoint,
+			common.EEnvironmentVariable.DaemonToken().Name+"="+token)
+		child.Stderr = os.Stderr
+		stdout, err := child.StdoutPipe()
+		if err != nil {
+			return common.JobID{}, err
+		}
+		if err = child.Start(); err != nil {
+			return common.JobID{}, err
+		}
+
+		return awaitSubmittedJob(stdout, func() { _ = child.Wait() })
+	}
+}
+
+// awaitSubmittedJob reads the JSON output of a child azcopy until it announces its job.
+// The rest of the output is drained in the background, after which reap is called.
+func awaitSubmittedJob(stdout io.Reader, reap func()) (common.JobID, error) {
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	lastError := "the command ended without starting a job"
+	for scanner.Scan() {
+		var message common.JsonOutputTemplate
+		if json.Unmarshal(scanner.Bytes(), &message) != nil {
+			continue
+		}
+
+		switch message.MessageType {
+		case "Init":
+			var initMessage common.InitMsgJsonTemplate
+			if err := json.Unmarshal([]byte(message.MessageContent), &initMessage); err != nil {
+				break
+			}
+			jobID, err := common.ParseJobID(initMessage.JobID)
+			if err != nil {
+				break
+			}
+			go func() {
+				for scanner.Scan() {
+				}
+				reap()
+			}()
+			return jobID, nil
+		case "Error":
+			lastError = message.MessageContent
+		}
+	}
+
+	reap()
+	return common.JobID{}, errors.New(lastError)
+}
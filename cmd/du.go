@@ -0,0 +1,363 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+	"github.com/Azure/azure-storage-azcopy/v10/ste"
+)
+
+type rawDuCmdArgs struct {
+	// obtained from argument
+	sourcePath string
+
+	depth            int
+	ageBuckets       string
+	includeVersions  bool
+	includeSnapshots bool
+	machineReadable  bool
+}
+
+type cookedDuCmdArgs struct {
+	sourcePath string
+	location   common.Location
+
+	depth            int
+	ageBuckets       []int // in days, ascending
+	includeVersions  bool
+	includeSnapshots bool
+	machineReadable  bool
+}
+
+func (raw rawDuCmdArgs) cook() (cookedDuCmdArgs, error) {
+	cooked := cookedDuCmdArgs{
+		sourcePath:       raw.sourcePath,
+		location:         InferArgumentLocation(raw.sourcePath),
+		depth:            raw.depth,
+		includeVersions:  raw.includeVersions,
+		includeSnapshots: raw.includeSnapshots,
+		machineReadable:  raw.machineReadable,
+	}
+
+	if !isListableLocation(cooked.location) {
+		return cooked, errors.New("invalid path passed for du. given source is of type " + cooked.location.String() + " while expect is an account, container or directory")
+	}
+	if (raw.includeVersions || raw.includeSnapshots) && cooked.location != common.ELocation.Blob() {
+		return cooked, errors.New("include-versions and include-snapshots are only supported for blobs")
+	}
+	if raw.includeVersions && raw.includeSnapshots {
+		return cooked, errors.New("include-versions and include-snapshots cannot be used together")
+	}
+	if raw.depth < 0 {
+		return cooked, errors.New("depth cannot be negative")
+	}
+
+	for _, v := range strings.Split(raw.ageBuckets, ",") {
+		v = strings.TrimSuffix(strings.TrimSpace(v), "d")
+		if v == "" {
+			continue
+		}
+		days, err := strconv.Atoi(v)
+		if err != nil || days <= 0 {
+			return cooked, fmt.Errorf("invalid age bucket %q, it must be a positive number of days", v)
+		}
+		cooked.ageBuckets = append(cooked.ageBuckets, days)
+	}
+	sort.Ints(cooked.ageBuckets)
+
+	return cooked, nil
+}
+
+func init() {
+	raw := rawDuCmdArgs{}
+	duCmd := &cobra.Command{
+		Use:     "du [resourceURL]",
+		Short:   duCmdShortDescription,
+		Long:    duCmdLongDescription,
+		Example: duCmdExample,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return errors.New("this command requires the account, container or directory to report on")
+			}
+			raw.sourcePath = args[0]
+			return nil
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			cooked, err := raw.cook()
+			if err != nil {
+				glcm.Error("failed to parse user input due to error: " + err.Error())
+				return
+			}
+
+			report, err := cooked.process()
+			if err != nil {
+				glcm.Error(err.Error())
+				return
+			}
+
+			glcm.Output(func(format common.OutputFormat) string {
+				switch format {
+				case common.EOutputFormat.Json():
+					return common.GetJsonStringFromTemplate(report)
+				case common.EOutputFormat.Csv():
+					return report.csv()
+				default:
+					return report.table(cooked.machineReadable)
+				}
+			})
+			glcm.Exit(nil, common.EExitCode.Success())
+		},
+	}
+
+	duCmd.PersistentFlags().IntVar(&raw.depth, "depth", 1, "How many levels of directories to break the usage down by. 0 reports no directories.")
+	duCmd.PersistentFlags().StringVar(&raw.ageBuckets, "age-buckets", "7,30,90,365", "Comma-separated ages, in days, to break the usage down by last modified time at.")
+	duCmd.PersistentFlags().BoolVar(&raw.includeVersions, "include-versions", false, "Also count the previous versions of the blobs, and break the usage down by base blob and version.")
+	duCmd.PersistentFlags().BoolVar(&raw.includeSnapshots, "include-snapshots", false, "Also count the snapshots of the blobs, and break the usage down by base blob and snapshot.")
+	duCmd.PersistentFlags().BoolVar(&raw.machineReadable, "machine-readable", false, "Reports sizes in bytes in the text format.")
+
+	rootCmd.AddCommand(duCmd)
+}
+
+// process traverses the resource, in the same way as list, and sums up its usage
+func (cooked cookedDuCmdArgs) process() (*usageReport, error) {
+	ctx := context.WithValue(context.TODO(), ste.ServiceAPIVersionOverride, ste.DefaultServiceApiVersion)
+
+	// blob properties such as the tier are listed with the blobs, so that they're never got separately here
+	traverser, level, err := newListCmdTraverser(ctx, glcm, cookedListCmdArgs{
+		sourcePath:       cooked.sourcePath,
+		location:         cooked.location,
+		recursive:        true,
+		includeVersions:  cooked.includeVersions,
+		includeSnapshots: cooked.includeSnapshots,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	report := newUsageReport(cooked.depth, cooked.ageBuckets, level == ELocationLevel.Service(), time.Now())
+	err = traverser.Traverse(nil, func(object StoredObject) error {
+		report.add(object)
+		return nil
+	}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to traverse the resource: %s", err.Error())
+	}
+	return report, nil
+}
+
+// usageTally is how many objects there are, and how many bytes they take up
+type usageTally struct {
+	Count int64
+	Bytes int64
+}
+
+// usageReport sums up the usage of the objects in a resource, in total and by several dimensions
+type usageReport struct {
+	Total        usageTally
+	ByDirectory  map[string]*usageTally `json:",omitempty"`
+	ByAccessTier map[string]*usageTally `json:",omitempty"`
+	ByBlobType   map[string]*usageTally `json:",omitempty"`
+	ByAge        map[string]*usageTally `json:",omitempty"`
+	ByKind       map[string]*usageTally `json:",omitempty"`
+
+	depth         int
+	ageBuckets    []int
+	withContainer bool
+	now           time.Time
+}
+
+func newUsageReport(depth int, ageBuckets []int, withContainer bool, now time.Time) *usageReport {
+	return &usageReport{
+		ByDirectory:   map[string]*usageTally{},
+		ByAccessTier:  map[string]*usageTally{},
+		ByBlobType:    map[string]*usageTally{},
+		ByAge:         map[string]*usageTally{},
+		ByKind:        map[string]*usageTally{},
+		depth:         depth,
+		ageBuckets:    ageBuckets,
+		withContainer: withContainer,
+		now:           now,
+	}
+}
+
+func (r *usageReport) add(object StoredObject) {
+	if object.entityType != common.EEntityType.File() {
+		return
+	}
+
+	r.Total.Count++
+	r.Total.Bytes += object.size
+
+	tally := func(dimension map[string]*usageTally, key string) {
+		t, ok := dimension[key]
+		if !ok {
+			t = &usageTally{}
+			dimension[key] = t
+		}
+		t.Count++
+		t.Bytes += object.size
+	}
+
+	if r.depth > 0 {
+		tally(r.ByDirectory, r.directoryOf(object))
+	}
+	if object.blobAccessTier != "" {
+		tally(r.ByAccessTier, string(object.blobAccessTier))
+	}
+	if object.blobType != "" && object.blobType != "None" {
+		tally(r.ByBlobType, string(object.blobType))
+	}
+	if !object.lastModifiedTime.IsZero() {
+		tally(r.ByAge, r.ageBucketOf(object.lastModifiedTime))
+	}
+	tally(r.ByKind, usageKindOf(object))
+}
+
+// directoryOf gives the directory of the object, truncated to the depth of the report, with a trailing slash
+func (r *usageReport) directoryOf(object StoredObject) string {
+	segments := strings.Split(object.relativePath, common.AZCOPY_PATH_SEPARATOR_STRING)
+	segments = segments[:len(segments)-1] // the object's own name
+	if len(segments) > r.depth {
+		segments = segments[:r.depth]
+	}
+
+	directory := strings.Join(segments, common.AZCOPY_PATH_SEPARATOR_STRING)
+	if directory != "" {
+		directory += common.AZCOPY_PATH_SEPARATOR_STRING
+	}
+	if r.withContainer {
+		return object.ContainerName + common.AZCOPY_PATH_SEPARATOR_STRING + directory
+	}
+	if directory == "" {
+		return common.AZCOPY_PATH_SEPARATOR_STRING
+	}
+	return directory
+}
+
+// ageBucketOf names the age bucket of the last modified time, e.g. "7-30d", with the last bucket being e.g. ">=365d"
+func (r *usageReport) ageBucketOf(lmt time.Time) string {
+	age := r.now.Sub(lmt)
+	lower := 0
+	for _, days := range r.ageBuckets {
+		if age < time.Duration(days)*24*time.Hour {
+			return fmt.Sprintf("%d-%dd", lower, days)
+		}
+		lower = days
+	}
+	return fmt.Sprintf(">=%dd", lower)
+}
+
+// usageKindOf tells whether the object is a base blob, i.e. the current one, a previous version, or a snapshot
+func usageKindOf(object StoredObject) string {
+	switch {
+	case object.blobSnapshotID != "":
+		return "Snapshot"
+	case object.blobVersionID != "" && !object.blobIsCurrentVersion:
+		return "Version"
+	case object.blobDeleted:
+		return "Deleted"
+	default:
+		return "Base"
+	}
+}
+
+// usageDimension is one of the ways the report breaks the usage down
+type usageDimension struct {
+	name  string
+	tally map[string]*usageTally
+}
+
+// dimensions gives the dimensions of the report in the order they're written
+func (r *usageReport) dimensions() []usageDimension {
+	return []usageDimension{
+		{"Directory", r.ByDirectory},
+		{"AccessTier", r.ByAccessTier},
+		{"BlobType", r.ByBlobType},
+		{"Age", r.ByAge},
+		{"Kind", r.ByKind},
+	}
+}
+
+// sortedKeys sorts the keys by size, largest first, and then by name
+func sortedKeys(tally map[string]*usageTally) []string {
+	keys := make([]string, 0, len(tally))
+	for k := range tally {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if tally[keys[i]].Bytes != tally[keys[j]].Bytes {
+			return tally[keys[i]].Bytes > tally[keys[j]].Bytes
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
+
+// csv gives the report as CSV, with Dimension, Key, Count and Bytes columns, for the csv output type
+func (r *usageReport) csv() string {
+	rows := [][]string{
+		{"Dimension", "Key", "Count", "Bytes"},
+		{"Total", "", strconv.FormatInt(r.Total.Count, 10), strconv.FormatInt(r.Total.Bytes, 10)},
+	}
+	for _, d := range r.dimensions() {
+		for _, k := range sortedKeys(d.tally) {
+			rows = append(rows, []string{d.name, k, strconv.FormatInt(d.tally[k].Count, 10), strconv.FormatInt(d.tally[k].Bytes, 10)})
+		}
+	}
+	return csvRows(rows...)
+}
+
+// table writes the report as a table per dimension, for people to read
+func (r *usageReport) table(machineReadable bool) string {
+	size := func(bytes int64) string {
+		if machineReadable {
+			return strconv.FormatInt(bytes, 10)
+		}
+		return byteSizeToString(bytes)
+	}
+
+	var buf bytes.Buffer
+	tw := tabwriter.NewWriter(&buf, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "Total\t%d objects\t%s\n", r.Total.Count, size(r.Total.Bytes))
+	for _, d := range r.dimensions() {
+		if len(d.tally) == 0 {
+			continue
+		}
+		fmt.Fprintf(tw, "\t\t\n%s\tCount\tSize\n", d.name)
+		for _, k := range sortedKeys(d.tally) {
+			fmt.Fprintf(tw, "  %s\t%d\t%s\n", k, d.tally[k].Count, size(d.tally[k].Bytes))
+		}
+	}
+	_ = tw.Flush()
+	return strings.TrimSuffix(buf.String(), "\n")
+}
@@ -225,7 +225,9 @@ const showJobsCmdShortDescription = "Show detailed information for the given job
 const showJobsCmdLongDescription = `
 If you provide only a job ID, and not a flag, then this command returns the progress summary only.
 The byte counts and percent complete that appears when you run this command reflect only files that are completed in the job. They don't reflect partially completed files.
-If you set the with-status flag, then only the list of transfers associated with the given status appear.`
+If you set the with-status flag, then only the list of transfers associated with the given status appear.
+Failed transfers are listed with the HTTP status, error code and message of their last failure, and are then grouped by error.
+If you also set the export flag, then the listed transfers are written to that file, as CSV or JSON, so that the failed ones can be retried or reported on.`
 
 const resumeJobsCmdShortDescription = "Resume the existing job with the given job ID."
 
@@ -244,18 +246,59 @@ const removeJobsCmdExample = "  azcopy jobs rm e52247de-0323-b14d-4cc8-76e0be2e2
 const cleanJobsCmdShortDescription = "Remove all log and plan files for all jobs"
 
 const cleanJobsCmdLongDescription = `
+Use --older-than-days to only remove the jobs whose log and plan files haven't changed for that many days.
+To do this every time AzCopy starts, set the AZCOPY_JOB_RETENTION_DAYS environment variable instead.
+
 Note that you can customize the location where log and plan files are saved. See the env command to learn more.`
 
-const cleanJobsCmdExample = "  azcopy jobs clean --with-status=completed"
+const cleanJobsCmdExample = `  azcopy jobs clean --with-status=completed
+
+Remove the jobs that haven't changed for a month:
+
+  - azcopy jobs clean --older-than-days=30`
 
 // ===================================== LIST COMMAND ===================================== //
 const listCmdShortDescription = "List the entities in a given resource"
 
-const listCmdLongDescription = `List the entities in a given resource. Blob, Files, and ADLS Gen 2 containers, folders, and accounts are supported.`
+const listCmdLongDescription = `List the entities in a given resource. Blob, Files, and ADLS Gen 2 containers, folders, and accounts are supported, as are S3 and Google Cloud Storage buckets, folders and accounts, and local folders.
+S3 and Google Cloud Storage are authenticated in the same way as for the copy command. Their ContentType, ContentEncoding and Metadata properties are got object by object, which is slower.
+The entities can be filtered with the same include and exclude flags as the copy command. With --recursive=false, only the entities directly in the given folder are listed, along with its sub-directories (the virtual directories, for blob containers).
+The versions, snapshots and soft-deleted blobs of a container can also be listed. For scripting, --output-type=json outputs each entity as a JSON object in the MessageContent of its own message, and --output-type=csv outputs them as CSV with a header row.`
 
 const listCmdExample = "azcopy list [containerURL] --properties [semicolon(;) separated list of attributes " +
-	"(LastModifiedTime, VersionId, BlobType, BlobAccessTier, ContentType, ContentEncoding, LeaseState, LeaseDuration, LeaseStatus) " +
-	"enclosed in double quotes (\")]"
+	"(LastModifiedTime, VersionId, SnapshotId, IsDeleted, BlobType, BlobAccessTier, ContentType, ContentEncoding, LeaseState, LeaseDuration, LeaseStatus, Metadata, BlobTags) " +
+	"enclosed in double quotes (\")]" + `
+
+List the top level of a container, with its virtual directories, as JSON:
+
+  - azcopy list "https://[account].blob.core.windows.net/[container]?[SAS]" --recursive=false --output-type=json
+
+Inventory an S3 bucket before migrating it, with the total size:
+
+  - azcopy list "https://s3.amazonaws.com/[bucket]" --running-tally --properties="LastModifiedTime"
+
+List the versions of the PDF files under a virtual directory, with their metadata, as CSV:
+
+  - azcopy list "https://[account].blob.core.windows.net/[container]/[path/to/directory]?[SAS]" --include-versions --include-pattern="*.pdf" --properties="Metadata" --output-type=csv`
+
+// ===================================== DU COMMAND ===================================== //
+const duCmdShortDescription = "Report the storage usage of an account, container or directory"
+
+const duCmdLongDescription = `Report how many objects an account, container or directory has, and how many bytes they take up, for capacity planning.
+The usage is broken down by directory, to the given depth, by access tier, by blob type, by the age of the objects' last modified times and, when the versions or snapshots are included, by base blob, version and snapshot.
+Blob, Files, ADLS Gen 2, S3 and Google Cloud Storage resources and local folders are supported, and they are scanned in the same way as by the list command.`
+
+const duCmdExample = `Report the usage of a container, by top level virtual directory:
+
+  - azcopy du "https://[account].blob.core.windows.net/[container]?[SAS]"
+
+Report the usage of the blobs, and their versions, two levels of directories deep, as JSON:
+
+  - azcopy du "https://[account].blob.core.windows.net/[container]?[SAS]" --depth=2 --include-versions --output-type=json
+
+Report the usage of an S3 bucket as CSV, with objects older than 30 and 180 days set apart:
+
+  - azcopy du "https://s3.amazonaws.com/[bucket]" --age-buckets=30,180 --output-type=csv`
 
 // ===================================== LOGIN COMMAND ===================================== //
 const loginCmdShortDescription = "Log in to Azure Active Directory (AD) to access Azure Storage resources."
@@ -320,12 +363,75 @@ const logoutCmdShortDescription = "Log out to terminate access to Azure Storage
 const logoutCmdLongDescription = `This command will remove all of the cached login information for the current user.`
 
 // ===================================== MAKE COMMAND ===================================== //
-const makeCmdShortDescription = "Create a container or file share."
+const makeCmdShortDescription = "Create a container, file share or directory."
 
-const makeCmdLongDescription = `Create a container or file share represented by the given resource URL.`
+const makeCmdLongDescription = `Create a container, file share or ADLS Gen2 file system represented by the given resource URL, or a directory in a file share or file system.
+
+When a directory is given, the share or file system, and any of the directory's parents, are made too if they don't exist yet.
+The metadata, SMB properties and permissions that are given are those of the directory, rather than of its parents.
+
+By default, it's an error if the resource already exists. With --if-not-exists, the command succeeds instead, and leaves the resource as it is.`
 
 const makeCmdExample = `
   - azcopy make "https://[account-name].[blob,file,dfs].core.windows.net/[top-level-resource-name]"
+
+Create a container that's readable anonymously, with metadata, unless it exists already:
+
+  - azcopy make "https://[account-name].blob.core.windows.net/[container]?[SAS]" --public-access=blob --metadata="project=alpha;owner=data-team" --if-not-exists
+
+Create a container whose blobs are all encrypted with an encryption scope:
+
+  - azcopy make "https://[account-name].blob.core.windows.net/[container]?[SAS]" --default-encryption-scope=[scope] --prevent-encryption-scope-override
+
+Create a share in the cool tier, with a quota of 100 GiB:
+
+  - azcopy make "https://[account-name].file.core.windows.net/[share]?[SAS]" --access-tier=Cool --quota-gb=100
+
+Create a hidden directory, with its parents, in a share:
+
+  - azcopy make "https://[account-name].file.core.windows.net/[share]/[path/to/dir]?[SAS]" --file-attributes=Hidden
+
+Create a directory, with its parents, in an ADLS Gen2 file system, with permissions and a default ACL:
+
+  - azcopy make "https://[account-name].dfs.core.windows.net/[filesystem]/[path/to/dir]" --permissions=0750 --default-acl="user::rwx,group::r-x,other::---"
+`
+
+// ===================================== MOVE COMMAND ===================================== //
+const moveCmdShortDescription = "Move files and directories, by renaming them where possible"
+
+const moveCmdLongDescription = `
+Moves the source to the destination. The source is renamed, on the service, when that's possible, which is when:
+
+  - Both are in the same ADLS Gen2 filesystem (using the dfs endpoint), or
+  - Both are in the same Azure Files share,
+
+and no filters are used. A renamed directory is moved with all its contents at once, however many there are.
+
+Otherwise, the move is a copy job that deletes each source file once it has been transferred and verified. The length of
+the destination is always checked, and downloads are checked against the source's MD5 hash as --check-md5 says. The source
+is deleted before the transfer is marked as successful, so if it can't be deleted, or the job is stopped before it is, the
+transfer fails, and resuming the job with 'azcopy jobs resume' transfers it again and retries the deletion. Source blobs
+that have been changed since they were transferred, or that have snapshots, aren't deleted. Source folders are left as
+they are. Moves from Amazon S3 and Google Cloud Storage aren't supported, since AzCopy doesn't delete their objects.
+
+The same filters as 'azcopy copy' may be used, and the source and destination are given the same way.`
+
+const moveCmdExample = `
+Rename a directory in an ADLS Gen2 filesystem, with everything in it:
+
+   - azcopy move "https://[account].dfs.core.windows.net/[filesystem]/[path/to/dir]?[SAS]" "https://[account].dfs.core.windows.net/[filesystem]/[path/to/newdir]?[SAS]" --recursive=true
+
+Move a file into another directory of the same Azure Files share:
+
+   - azcopy move "https://[account].file.core.windows.net/[share]/[path/to/file]?[SAS]" "https://[account].file.core.windows.net/[share]/[path/to/existing/dir]?[SAS]"
+
+Upload the .log files of a local directory, deleting each once it has been uploaded:
+
+   - azcopy move "/path/to/dir" "https://[account].blob.core.windows.net/[container]/[path/to/virtual/dir]?[SAS]" --recursive=true --include-pattern="*.log"
+
+Move blobs between containers, and see which would be moved first:
+
+   - azcopy move "https://[srcaccount].blob.core.windows.net/[container]/[path/to/dir]?[SAS]" "https://[destaccount].blob.core.windows.net/[container]?[SAS]" --recursive=true --dry-run
 `
 
 // ===================================== REMOVE COMMAND ===================================== //
@@ -358,6 +464,18 @@ Remove specified version ids of a blob from Azure Storage. Ensure that source is
 
   - azcopy rm "https://[srcaccount].blob.core.windows.net/[containername]/[blobname]" "/path/to/dir" --list-of-versions="/path/to/dir/[versionidsfile]"
 
+Remove the blobs in a virtual directory that were last modified more than 30 days ago:
+
+   - azcopy rm "https://[account].blob.core.windows.net/[container]/[path/to/directory]?[SAS]" --recursive=true --older-than=30d
+
+Purge the earlier versions of each blob in a container, keeping the latest 5 of them, and any taken in the last 2 weeks. The current version of each blob is always kept. Add --dry-run to see what would be purged, and how much would be kept, without removing anything:
+
+   - azcopy rm "https://[account].blob.core.windows.net/[container]?[SAS]" --recursive=true --purge=versions --keep-latest=5 --older-than=2w
+
+Purge the snapshots taken before a given date:
+
+   - azcopy rm "https://[account].blob.core.windows.net/[container]/[path/to/directory]?[SAS]" --recursive=true --purge=snapshots --include-before=2021-01-01T00:00:00Z
+
 Remove specific blobs and virtual directories by putting their relative paths (NOT URL-encoded) in a file:
 
    - azcopy rm "https://[account].blob.core.windows.net/[container]/[path/to/parent/dir]" --recursive=true --list-of-files=/usr/bar/list.txt
@@ -366,13 +484,82 @@ Remove specific blobs and virtual directories by putting their relative paths (N
 	   blob1
 	   blob2
 
-Remove a single file from a Blob Storage account that has a hierarchical namespace (include/exclude not supported):
+Remove a single file from a Blob Storage account that has a hierarchical namespace:
 
    - azcopy rm "https://[account].dfs.core.windows.net/[container]/[path/to/file]?[SAS]"
 
-Remove a single directory from a Blob Storage account that has a hierarchical namespace (include/exclude not supported):
+Remove a directory tree from a Blob Storage account that has a hierarchical namespace. The service removes the whole tree, without AzCopy listing it:
+
+   - azcopy rm "https://[account].dfs.core.windows.net/[container]/[path/to/directory]?[SAS]" --recursive=true
+
+Remove only some of the files in a directory tree from a Blob Storage account that has a hierarchical namespace. The tree is listed, and each file that passes the filters is removed by itself, while directories are left in place:
+
+   - azcopy rm "https://[account].dfs.core.windows.net/[container]/[path/to/directory]?[SAS]" --recursive=true --include-pattern="*.jpg;*.pdf"
+`
+
+// ===================================== SET-PROPERTIES COMMAND ===================================== //
+const setPropertiesCmdShortDescription = "Set the access tier, metadata, blob index tags or content headers of existing blobs"
 
-   - azcopy rm "https://[account].dfs.core.windows.net/[container]/[path/to/directory]?[SAS]"
+const setPropertiesCmdLongDescription = `
+Sets properties of the blobs at the given URL, in place, without copying them. The blobs are chosen the same way as 'azcopy copy' and 'azcopy remove' choose them, so the same filters (include/exclude patterns and paths, regexes, blob types, dates, lists of files and versions) may be used.
+Any combination of these may be set at once:
+
+  - The access tier, with --block-blob-tier for block blobs and --page-blob-tier for page blobs. Setting the tier of an archived blob rehydrates it, as soon as --rehydrate-priority says.
+  - The metadata, with --metadata. It replaces the blob's metadata, and so it clears it if it's set to an empty string.
+  - The blob index tags, with --blob-tags. They replace the blob's tags, and so they clear them if they're set to an empty string.
+  - The content headers, with --content-type, --content-encoding, --content-language, --content-disposition and --cache-control. Only the headers that are given are changed.
+
+Like other jobs, set-properties jobs have a log and a plan, and so may be listed, shown and resumed with 'azcopy jobs'.`
+
+const setPropertiesCmdExample = `
+Archive every blob in a virtual directory, by using a SAS token:
+
+   - azcopy set-properties "https://[account].blob.core.windows.net/[container]/[path/to/directory]?[SAS]" --recursive=true --block-blob-tier=Archive
+
+Rehydrate the archived .parquet blobs in a container, with high priority:
+
+   - azcopy set-properties "https://[account].blob.core.windows.net/[container]?[SAS]" --recursive=true --include-pattern="*.parquet" --block-blob-tier=Hot --rehydrate-priority=High
+
+Set the metadata and content type of a single blob:
+
+   - azcopy set-properties "https://[account].blob.core.windows.net/[container]/[path/to/blob]?[SAS]" --metadata="project=apollo;owner=data" --content-type="application/json"
+
+Set the blob index tags of specific blobs, by putting their relative paths (NOT URL-encoded) in a file, and see which blobs would be changed first:
+
+   - azcopy set-properties "https://[account].blob.core.windows.net/[container]/[path/to/parent/dir]" --list-of-files=/usr/bar/list.txt --blob-tags="retention=7y" --dry-run
+`
+
+// ===================================== RESTORE COMMAND ===================================== //
+const restoreCmdShortDescription = "Undelete soft-deleted blobs, or restore earlier versions of blobs"
+
+const restoreCmdLongDescription = `
+Restores the blobs at the given URL, in place. There are two modes, which suit accounts with soft delete and accounts with versioning respectively:
+
+  - undelete (the default) undeletes the soft-deleted blobs, along with their soft-deleted snapshots. With --as-of, only the blobs that were deleted at or after that time are undeleted.
+  - version makes an earlier version of each blob its current version, by copying it over the blob. With --as-of, each blob is restored to the version that was written last before that time, unless that's its current version already.
+    Without --as-of, only the blobs that have no current version, because they were deleted, are restored, to their latest version.
+
+A URL that ends with a slash is that of a virtual directory, while one that doesn't is that of a single blob, whether or not it still exists.
+The blobs may be chosen with the same patterns, paths and regexes as 'azcopy set-properties'.
+
+Like other jobs, restore jobs have a log and a plan, and so may be listed, shown and resumed with 'azcopy jobs'.`
+
+const restoreCmdExample = `
+Undelete a single soft-deleted blob:
+
+   - azcopy restore "https://[account].blob.core.windows.net/[container]/[path/to/blob]?[SAS]"
+
+Undelete the blobs in a virtual directory that were deleted in the last day, and see which would be undeleted first:
+
+   - azcopy restore "https://[account].blob.core.windows.net/[container]/[path/to/directory]/?[SAS]" --recursive=true --as-of=24h --dry-run
+
+Restore every blob in a container, in an account with versioning, to how it was at a point in time:
+
+   - azcopy restore "https://[account].blob.core.windows.net/[container]?[SAS]" --recursive=true --mode=version --as-of=2021-08-19T15:04:00Z
+
+Bring back the .csv blobs that were deleted from a container with versioning, from their latest versions:
+
+   - azcopy restore "https://[account].blob.core.windows.net/[container]?[SAS]" --recursive=true --mode=version --include-pattern="*.csv"
 `
 
 // ===================================== SYNC COMMAND ===================================== //
@@ -525,3 +712,31 @@ Run an upload that does not delete the transferred files. (These files can then
 
    - azcopy bench "https://[account].blob.core.windows.net/[container]?<SAS>" --file-count 100 --delete-test-data=false
 `
+
+// ===================================== DAEMON COMMAND ===================================== //
+const daemonCmdShortDescription = "Run AzCopy as a long-running service with a local job API"
+
+const daemonCmdLongDescription = `Run AzCopy as a long-running service that keeps the transfer engine alive, and serves an authenticated API on a loopback port or a Unix socket.
+
+Every request must carry the daemon's token as "Authorization: Bearer <token>". The token is taken from AZCOPY_DAEMON_TOKEN, or generated at startup. 
+The endpoint and the token are written to daemon.json in the AzCopy folder, readable only by the current user, and removed when the daemon stops.
+
+The existing commands (copy, sync, remove, jobs list/show/resume, cancel) talk to the daemon when AZCOPY_DAEMON_ENDPOINT is set. 
+They still scan the source locally, but the transfers run in the daemon. Jobs that need --overwrite=prompt cannot be sent to the daemon.
+
+For orchestration, the daemon also serves a REST API:
+  - POST /jobs                     submit a job, with a body such as {"Args": ["copy", "/data", "https://[account].blob.core.windows.net/[container]?[SAS]", "--recursive"]}
+                                   (only flags that shape the job can be given: not hooks, transfer events, metrics or --output-type)
+  - GET  /jobs[?status=InProgress] list jobs
+  - GET  /jobs/{id}                the job's summary
+  - GET  /jobs/{id}/progress       a stream of summaries, one JSON document per line, until the job is done
+  - GET  /jobs/{id}/transfers[?status=Failed]
+  - POST /jobs/{id}/pause, /jobs/{id}/cancel, /jobs/{id}/resume (optional body: {"SourceSAS": "...", "DestinationSAS": "..."})
+
+Credentials from the environment (e.g. AWS or service principal secrets) must be available to the daemon, since it is the daemon that makes the requests.
+Jobs that are still running when the daemon stops can be continued later with 'azcopy jobs resume'.`
+
+const daemonCmdExample = `Start a daemon on a Unix socket, and send a copy to it:
+
+   - azcopy daemon --listen unix:///tmp/azcopy.sock
+   - AZCOPY_DAEMON_ENDPOINT=unix:///tmp/azcopy.sock azcopy copy "/data" "https://[account].blob.core.windows.net/[container]?[SAS]" --recursive`
@@ -0,0 +1,596 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+	"github.com/Azure/azure-storage-azcopy/v10/jobsAdmin"
+	"github.com/Azure/azure-storage-azcopy/v10/ste"
+)
+
+// The functions in this file let another Go program (see pkg/client) run AzCopy jobs in its own process,
+// instead of through main.main and Execute. Rather than parsing a command line, they cook the typed options of a job,
+// then enumerate it and order it from the STE, as the commands do. Each job has a lifecycle manager of its own,
+// so several of them can be enumerated at once.
+
+// InProcessSettings are what main.main would otherwise pass to Execute, and the flags of the root command
+type InProcessSettings struct {
+	AppPathFolder           string
+	LogPathFolder           string
+	JobPlanFolder           string
+	MaxFileAndSocketHandles int
+	CapMbps                 float64
+
+	// LogSettings of the job and scanning logs, as with AZCOPY_LOG_FORMAT, AZCOPY_LOG_MAX_SIZE_MB and AZCOPY_LOG_MAX_TOTAL_SIZE_MB
+	LogSettings common.JobLogSettings
+
+	// MetricsListenAddress, if set, is where Prometheus metrics are served, as with --metrics-listen
+	MetricsListenAddress string
+}
+
+var inProcessOnce = &sync.Once{}
+var inProcessSettings InProcessSettings
+var inProcessErr error
+
+// the defaults of the flags of copy, sync and remove, which the options of the in-process jobs are applied to
+var rawCopyDefaults rawCopyCmdArgs
+var rawSyncDefaults rawSyncCmdArgs
+var rawRemoveDefaults rawCopyCmdArgs
+
+// InitInProcess starts the STE in this process, so that jobs can be run in it.
+// The STE can only be started once per process, so later calls must give the same settings.
+func InitInProcess(settings InProcessSettings) error {
+	inProcessOnce.Do(func() {
+		inProcessSettings = settings
+		inProcessErr = initInProcess(settings)
+	})
+	if inProcessErr != nil {
+		return inProcessErr
+	}
+	if settings != inProcessSettings {
+		return errors.New("AzCopy is already running in this process with different settings")
+	}
+	return nil
+}
+
+func initInProcess(settings InProcessSettings) error {
+	azcopyAppPathFolder = settings.AppPathFolder
+	azcopyLogPathFolder = settings.LogPathFolder
+	common.AzcopyJobPlanFolder = settings.JobPlanFolder
+	azcopyMaxFileAndSocketHandles = settings.MaxFileAndSocketHandles
+	azcopyLogSettings = settings.LogSettings
+
+	// like Execute, we log everything that isn't specific to a job in a log of its own
+	common.AzcopyCurrentJobLogger = common.NewJobLogger(common.NewJobID(), common.ELogLevel.Debug(), settings.LogPathFolder, "", azcopyLogSettings)
+	common.AzcopyCurrentJobLogger.OpenLog()
+
+	if err := startSTE(settings.CapMbps, false, false); err != nil {
+		return err
+	}
+	if settings.MetricsListenAddress != "" {
+		if err := serveMetrics(settings.MetricsListenAddress); err != nil {
+			return err
+		}
+	}
+	return initTracing()
+}
+
+// InProcessJobOptions apply to all the jobs that are run in-process
+type InProcessJobOptions struct {
+	LogLevel       common.LogLevel
+	TransferEvents string
+	HookURL        string
+	HookCommand    string
+	HookEvents     []common.JobHookEvent
+
+	// OnMessage, if set, receives the messages of the job, such as warnings, instead of the lifecycle manager that runs it
+	OnMessage func(string)
+}
+
+// InProcessList is a listing, as with the list command
+type InProcessList struct {
+	Resource string
+
+	// OnMessage, if set, receives the messages of the listing instead of the lifecycle manager that runs it
+	OnMessage func(string)
+
+	Recursive       bool
+	IncludePatterns []string
+	ExcludePatterns []string
+	ExcludePaths    []string
+	IncludeAfter    time.Time
+	IncludeBefore   time.Time
+
+	// IncludeVersions, IncludeSnapshots and IncludeDeleted also list the versions, snapshots and soft-deleted blobs of a container
+	IncludeVersions  bool
+	IncludeSnapshots bool
+	IncludeDeleted   bool
+}
+
+func (o InProcessJobOptions) hookEvents() string {
+	events := make([]string, len(o.HookEvents))
+	for i, e := range o.HookEvents {
+		events[i] = e.String()
+	}
+	return strings.Join(events, ",")
+}
+
+// InProcessScheduling sets how a job shares the STE with the other jobs of the process
+type InProcessScheduling struct {
+	Priority       common.JobPriority
+	MaxConcurrency int
+	CapMbps        float64
+}
+
+// InProcessFilters choose which files, blobs and folders a job applies to
+type InProcessFilters struct {
+	IncludePatterns []string
+	ExcludePatterns []string
+	IncludePaths    []string
+	ExcludePaths    []string
+	IncludeAfter    time.Time
+	IncludeBefore   time.Time
+}
+
+// InProcessCopy is a copy job, as with the copy command
+type InProcessCopy struct {
+	Source      string
+	Destination string
+	InProcessJobOptions
+	InProcessScheduling
+	InProcessFilters
+
+	Recursive bool
+	// FromTo is inferred from the source and destination if it's Unknown
+	FromTo common.FromTo
+	// Overwrite cannot be Prompt, since there's nobody to ask
+	Overwrite   common.OverwriteOption
+	BlobType    common.BlobType
+	BlockSizeMB float64
+	Metadata    map[string]string
+	ContentType string
+
+	PutMd5                   bool
+	PreserveLastModifiedTime bool
+}
+
+// InProcessSync is a sync job, as with the sync command
+type InProcessSync struct {
+	Source      string
+	Destination string
+	InProcessJobOptions
+	InProcessScheduling
+
+	Recursive bool
+	// FromTo is inferred from the source and destination if it's Unknown
+	FromTo          common.FromTo
+	IncludePatterns []string
+	ExcludePatterns []string
+	ExcludePaths    []string
+
+	DeleteDestination bool
+	MirrorMode        bool
+	PutMd5            bool
+	BlockSizeMB       float64
+}
+
+// InProcessRemove is a removal, as with the remove command
+type InProcessRemove struct {
+	Target string
+	InProcessJobOptions
+	InProcessFilters
+
+	Recursive bool
+	// FromTo is inferred from the target if it's Unknown
+	FromTo common.FromTo
+}
+
+// patternList, timeFlag and fromToFlag give the values of the flags that are left to their defaults when they're empty
+func patternList(patterns []string) string {
+	return strings.Join(patterns, ";")
+}
+
+func timeFlag(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func fromToFlag(fromTo common.FromTo) string {
+	if fromTo == common.EFromTo.Unknown() {
+		return ""
+	}
+	return fromTo.String()
+}
+
+func (job InProcessCopy) raw() (rawCopyCmdArgs, error) {
+	if job.Overwrite == common.EOverwriteOption.Prompt() {
+		return rawCopyCmdArgs{}, errors.New("the Prompt overwrite option needs a user to answer it, so it cannot be used in-process")
+	}
+
+	raw := rawCopyDefaults
+	raw.src = job.Source
+	raw.dst = job.Destination
+	raw.logVerbosity = job.LogLevel.String()
+	raw.transferEvents = job.TransferEvents
+	raw.hookURL = job.HookURL
+	raw.hookCommand = job.HookCommand
+	raw.hookEvents = job.hookEvents()
+	raw.jobPriority = job.Priority.String()
+	raw.jobMaxConcurrency = job.MaxConcurrency
+	raw.jobCapMbps = job.CapMbps
+	raw.include = patternList(job.IncludePatterns)
+	raw.exclude = patternList(job.ExcludePatterns)
+	raw.includePath = patternList(job.IncludePaths)
+	raw.excludePath = patternList(job.ExcludePaths)
+	raw.includeAfter = timeFlag(job.IncludeAfter)
+	raw.includeBefore = timeFlag(job.IncludeBefore)
+	raw.recursive = job.Recursive
+	raw.fromTo = fromToFlag(job.FromTo)
+	raw.forceWrite = job.Overwrite.String()
+	raw.blobType = job.BlobType.String()
+	raw.blockSizeMB = job.BlockSizeMB
+	raw.contentType = job.ContentType
+	raw.putMd5 = job.PutMd5
+	raw.preserveLastModifiedTime = job.PreserveLastModifiedTime
+
+	pairs := make([]string, 0, len(job.Metadata))
+	for k, v := range job.Metadata {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs) // so that the metadata is set in the same order every time
+	raw.metadata = patternList(pairs)
+	return raw, nil
+}
+
+func (job InProcessSync) raw() rawSyncCmdArgs {
+	raw := rawSyncDefaults
+	raw.src = job.Source
+	raw.dst = job.Destination
+	raw.logVerbosity = job.LogLevel.String()
+	raw.transferEvents = job.TransferEvents
+	raw.hookURL = job.HookURL
+	raw.hookCommand = job.HookCommand
+	raw.hookEvents = job.hookEvents()
+	raw.jobPriority = job.Priority.String()
+	raw.jobMaxConcurrency = job.MaxConcurrency
+	raw.jobCapMbps = job.CapMbps
+	raw.recursive = job.Recursive
+	raw.fromTo = fromToFlag(job.FromTo)
+	raw.include = patternList(job.IncludePatterns)
+	raw.exclude = patternList(job.ExcludePatterns)
+	raw.excludePath = patternList(job.ExcludePaths)
+	raw.deleteDestination = strconv.FormatBool(job.DeleteDestination)
+	raw.mirrorMode = job.MirrorMode
+	raw.putMd5 = job.PutMd5
+	raw.blockSizeMB = job.BlockSizeMB
+	return raw
+}
+
+func (list InProcessList) raw() rawListCmdArgs {
+	return rawListCmdArgs{
+		sourcePath:       list.Resource,
+		recursive:        list.Recursive,
+		include:          patternList(list.IncludePatterns),
+		exclude:          patternList(list.ExcludePatterns),
+		excludePath:      patternList(list.ExcludePaths),
+		includeAfter:     timeFlag(list.IncludeAfter),
+		includeBefore:    timeFlag(list.IncludeBefore),
+		includeVersions:  list.IncludeVersions,
+		includeSnapshots: list.IncludeSnapshots,
+		includeDeleted:   list.IncludeDeleted,
+	}
+}
+
+func (job InProcessRemove) raw() (rawCopyCmdArgs, error) {
+	raw := rawRemoveDefaults
+	raw.src = job.Target
+	raw.logVerbosity = job.LogLevel.String()
+	raw.transferEvents = job.TransferEvents
+	raw.hookURL = job.HookURL
+	raw.hookCommand = job.HookCommand
+	raw.hookEvents = job.hookEvents()
+	raw.include = patternList(job.IncludePatterns)
+	raw.exclude = patternList(job.ExcludePatterns)
+	raw.includePath = patternList(job.IncludePaths)
+	raw.excludePath = patternList(job.ExcludePaths)
+	raw.includeAfter = timeFlag(job.IncludeAfter)
+	raw.includeBefore = timeFlag(job.IncludeBefore)
+	raw.recursive = job.Recursive
+	raw.fromTo = fromToFlag(job.FromTo)
+	return raw, raw.setRemoveDefaults()
+}
+
+// InProcessJob is a job that was enumerated in-process
+type InProcessJob struct {
+	// JobID identifies the job in the STE, if it was started
+	JobID      common.JobID
+	JobStarted bool
+
+	// Message is the final message of the enumeration, e.g. when a sync found nothing to do
+	Message string
+
+	lcm *inProcessLcm
+}
+
+// Close releases what the job kept open for as long as it ran, such as its scanning log and its hooks.
+// It's called once the job is done in the STE.
+func (j InProcessJob) Close() {
+	if j.lcm != nil {
+		j.lcm.runCloseFuncs()
+	}
+}
+
+// RunCopy enumerates a copy, up to the point where its job has been fully ordered from the STE.
+// The job then carries on in the STE, where it can be followed by its ID. Its messages go to lcm, or to job.OnMessage.
+// If lcm is nil, and there's no job.OnMessage, they're dropped.
+func RunCopy(lcm common.LifecycleMgr, job InProcessCopy) (InProcessJob, error) {
+	raw, err := job.raw()
+	if err != nil {
+		return InProcessJob{}, err
+	}
+	return runInProcess(lcm, job.OnMessage, func(jobLcm common.LifecycleMgr, jobID common.JobID) error {
+		cooked, err := raw.cookWith(jobLcm, jobID)
+		if err != nil {
+			return err
+		}
+		if cooked.isRedirection() || cooked.dryrunMode {
+			return errors.New("pipes and dry runs cannot be used in-process")
+		}
+		cooked.commandString = copyHandlerUtil{}.constructCommandString([]string{"copy", job.Source, job.Destination})
+		return cooked.process()
+	})
+}
+
+// RunSync enumerates a sync, like RunCopy
+func RunSync(lcm common.LifecycleMgr, job InProcessSync) (InProcessJob, error) {
+	raw := job.raw()
+	return runInProcess(lcm, job.OnMessage, func(jobLcm common.LifecycleMgr, jobID common.JobID) error {
+		cooked, err := raw.cookWith(jobLcm, jobID)
+		if err != nil {
+			return err
+		}
+		cooked.commandString = copyHandlerUtil{}.constructCommandString([]string{"sync", job.Source, job.Destination})
+		return cooked.process()
+	})
+}
+
+// RunRemove enumerates a removal, like RunCopy
+func RunRemove(lcm common.LifecycleMgr, job InProcessRemove) (InProcessJob, error) {
+	raw, err := job.raw()
+	if err != nil {
+		return InProcessJob{}, err
+	}
+	return runInProcess(lcm, job.OnMessage, func(jobLcm common.LifecycleMgr, jobID common.JobID) error {
+		cooked, err := raw.cookWith(jobLcm, jobID)
+		if err != nil {
+			return err
+		}
+		cooked.commandString = copyHandlerUtil{}.constructCommandString([]string{"remove", job.Target})
+		return cooked.process()
+	})
+}
+
+// runInProcess runs the enumeration of a new job, with a lifecycle manager of its own that reports to lcm
+func runInProcess(lcm common.LifecycleMgr, onMessage func(string), enumerate func(jobLcm common.LifecycleMgr, jobID common.JobID) error) (InProcessJob, error) {
+	if jobsAdmin.JobsAdmin == nil {
+		return InProcessJob{}, errors.New("InitInProcess must be called first")
+	}
+
+	jobLcm := newInProcessLcm(lcm, onMessage)
+	jobID := common.NewJobID()
+	err := enumerate(jobLcm, jobID)
+	jobStarted, message, exitErr := jobLcm.outcome()
+	if err == nil {
+		err = exitErr
+	}
+	if err != nil {
+		if jobStarted {
+			// the CLI would have exited, ending the job with it
+			jobsAdmin.CancelPauseJobOrder(jobID, common.EJobStatus.Cancelling())
+		}
+		jobLcm.runCloseFuncs()
+		return InProcessJob{}, err
+	}
+	return InProcessJob{JobID: jobID, JobStarted: jobStarted, Message: message, lcm: jobLcm}, nil
+}
+
+// ListedObject is a file, blob or folder found by ListObjects
+type ListedObject struct {
+	// Path is relative to the listed container or directory. When an account is listed, it starts with the container's name
+	Path             string
+	IsFolder         bool
+	Size             int64
+	LastModifiedTime time.Time
+	ContentType      string
+	ContentEncoding  string
+
+	// blob properties, which are empty for other locations
+	BlobType   string
+	AccessTier string
+	VersionID  string
+	SnapshotID string
+	IsDeleted  bool
+}
+
+// ListObjects lists a container, directory or account, in the same way as the list command.
+// Its messages go to lcm, or to list.OnMessage, as for RunCopy.
+func ListObjects(ctx context.Context, lcm common.LifecycleMgr, list InProcessList, handler func(ListedObject) error) error {
+	if jobsAdmin.JobsAdmin == nil {
+		return errors.New("InitInProcess must be called first")
+	}
+	cooked, err := list.raw().cook()
+	if err != nil {
+		return err
+	}
+
+	ctx = context.WithValue(ctx, ste.ServiceAPIVersionOverride, ste.DefaultServiceApiVersion)
+	traverser, level, err := newListCmdTraverser(ctx, newInProcessLcm(lcm, list.OnMessage), cooked)
+	if err != nil {
+		return err
+	}
+
+	return traverser.Traverse(nil, func(object StoredObject) error {
+		listed := ListedObject{
+			Path:             object.relativePath,
+			IsFolder:         object.entityType == common.EEntityType.Folder(),
+			Size:             object.size,
+			LastModifiedTime: object.lastModifiedTime,
+			ContentType:      object.contentType,
+			ContentEncoding:  object.contentEncoding,
+			BlobType:         string(object.blobType),
+			AccessTier:       string(object.blobAccessTier),
+			VersionID:        object.blobVersionID,
+			SnapshotID:       object.blobSnapshotID,
+			IsDeleted:        object.blobDeleted,
+		}
+		if level == level.Service() {
+			listed.Path = object.ContainerName + "/" + listed.Path
+		}
+		return handler(listed)
+	}, cooked.filters)
+}
+
+// inProcessLcm is the lifecycle manager of a job that is run in-process.
+// It passes the job's messages on to the lifecycle manager that runs it, or to onMessage, and records the job's outcome
+// rather than exiting. The job is followed in the STE, so its progress isn't reported, and nobody can answer its prompts.
+// Without a lifecycle manager to run it, it takes the environment from that of the process, and drops the job's messages.
+type inProcessLcm struct {
+	common.LifecycleMgr
+
+	onMessage func(string)
+
+	lock       *sync.Mutex
+	finished   bool
+	err        error
+	message    string
+	jobStarted bool
+	closeFuncs []func()
+}
+
+func newInProcessLcm(lcm common.LifecycleMgr, onMessage func(string)) *inProcessLcm {
+	if onMessage == nil {
+		if lcm != nil {
+			onMessage = lcm.Info
+		} else {
+			onMessage = func(string) {}
+		}
+	}
+	if lcm == nil {
+		lcm = common.GetLifecycleMgr()
+	}
+	return &inProcessLcm{
+		LifecycleMgr: lcm,
+		onMessage:    onMessage,
+		lock:         &sync.Mutex{},
+	}
+}
+
+// finish records the outcome of the job, unless it has already finished
+func (l *inProcessLcm) finish(err error, message string) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if !l.finished {
+		l.finished = true
+		l.err = err
+		l.message = message
+	}
+}
+
+func (l *inProcessLcm) outcome() (jobStarted bool, message string, err error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return l.jobStarted, l.message, l.err
+}
+
+func (l *inProcessLcm) runCloseFuncs() {
+	l.lock.Lock()
+	closeFuncs := l.closeFuncs
+	l.closeFuncs = nil
+	l.lock.Unlock()
+
+	for _, f := range closeFuncs {
+		f()
+	}
+}
+
+func (l *inProcessLcm) Init(o common.OutputBuilder) {
+	l.lock.Lock()
+	l.jobStarted = true
+	l.lock.Unlock()
+	l.onMessage(o(common.EOutputFormat.Text()))
+}
+
+func (l *inProcessLcm) Progress(common.OutputBuilder) {}
+
+func (l *inProcessLcm) Exit(o common.OutputBuilder, applicationExitCode common.ExitCode) {
+	message := ""
+	if o != nil {
+		message = o(common.EOutputFormat.Text())
+	}
+	if applicationExitCode == common.EExitCode.NoExit() {
+		l.onMessage(message)
+		return
+	}
+
+	var err error
+	if applicationExitCode != common.EExitCode.Success() {
+		err = fmt.Errorf("the job failed with exit code %d: %s", applicationExitCode, message)
+	}
+	l.finish(err, message)
+}
+
+func (l *inProcessLcm) Info(msg string) {
+	l.onMessage(msg)
+}
+
+func (l *inProcessLcm) Error(msg string) {
+	l.finish(errors.New(msg), "")
+}
+
+// Prompt declines, since there's nobody to ask. The in-process options don't include those that would prompt.
+func (l *inProcessLcm) Prompt(message string, details common.PromptDetails) common.ResponseOption {
+	l.onMessage(message)
+	return common.EResponseOption.No()
+}
+
+func (l *inProcessLcm) RegisterCloseFunc(closeFunc func()) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.closeFuncs = append(l.closeFuncs, closeFunc)
+}
+
+func (l *inProcessLcm) Dryrun(common.OutputBuilder)                     {}
+func (l *inProcessLcm) Output(common.OutputBuilder)                     {}
+func (l *inProcessLcm) SurrenderControl()                               {}
+func (l *inProcessLcm) InitiateProgressReporting(common.WorkController) {}
+func (l *inProcessLcm) AllowReinitiateProgressReporting()               {}
+func (l *inProcessLcm) SetOutputFormat(common.OutputFormat)             {}
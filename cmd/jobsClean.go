@@ -23,8 +23,14 @@ package cmd
 import (
 	"errors"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/Azure/azure-pipeline-go/pipeline"
 	"github.com/spf13/cobra"
 
 	"github.com/Azure/azure-storage-azcopy/v10/common"
@@ -32,7 +38,8 @@ import (
 
 func init() {
 	type JobsCleanReq struct {
-		withStatus string
+		withStatus    string
+		olderThanDays int
 	}
 
 	commandLineInput := JobsCleanReq{}
@@ -57,9 +64,18 @@ func init() {
 				glcm.Error(fmt.Sprintf("Failed to parse --with-status due to error: %s.", err))
 			}
 
-			err = handleCleanJobsCommand(withStatus)
+			if commandLineInput.olderThanDays < 0 {
+				glcm.Error("--older-than-days cannot be negative.")
+			}
+			olderThan := time.Duration(commandLineInput.olderThanDays) * 24 * time.Hour
+
+			err = handleCleanJobsCommand(withStatus, olderThan)
 			if err == nil {
-				if withStatus == common.EJobStatus.All() {
+				if olderThan > 0 {
+					glcm.Exit(func(format common.OutputFormat) string {
+						return fmt.Sprintf("Successfully removed jobs with status %s that haven't changed for %d days.", withStatus, commandLineInput.olderThanDays)
+					}, common.EExitCode.Success())
+				} else if withStatus == common.EJobStatus.All() {
 					glcm.Exit(func(format common.OutputFormat) string {
 						return fmt.Sprintf("Successfully removed all jobs.")
 					}, common.EExitCode.Success())
@@ -80,11 +96,28 @@ func init() {
 	jobsCleanCmd.PersistentFlags().StringVar(&commandLineInput.withStatus, "with-status", "All",
 		"only remove the jobs with this status, available values: All, Cancelled, Failed, Completed"+
 			" CompletedWithErrors, CompletedWithSkipped, CompletedWithErrorsAndSkipped")
+	jobsCleanCmd.PersistentFlags().IntVar(&commandLineInput.olderThanDays, "older-than-days", 0,
+		"only remove the jobs none of whose log and plan files have changed for this many days")
 }
 
-func handleCleanJobsCommand(givenStatus common.JobStatus) error {
+func handleCleanJobsCommand(givenStatus common.JobStatus, olderThan time.Duration) error {
+	var oldJobs map[common.JobID][]string
+	if olderThan > 0 {
+		jobs, err := listJobFiles()
+		if err != nil {
+			return err
+		}
+		oldJobs = jobs.olderThan(time.Now().Add(-olderThan))
+	}
+
 	if givenStatus == common.EJobStatus.All() {
-		numFilesDeleted, err := blindDeleteAllJobFiles()
+		var numFilesDeleted int
+		var err error
+		if olderThan > 0 {
+			numFilesDeleted, err = removeJobFiles(oldJobs)
+		} else {
+			numFilesDeleted, err = blindDeleteAllJobFiles()
+		}
 		glcm.Info(fmt.Sprintf("Removed %v files.", numFilesDeleted))
 		return err
 	}
@@ -100,6 +133,9 @@ func handleCleanJobsCommand(givenStatus common.JobStatus) error {
 	for _, job := range resp.JobIDDetails {
 		// delete all jobs matching the givenStatus
 		if job.JobStatus == givenStatus {
+			if _, old := oldJobs[job.JobId]; olderThan > 0 && !old {
+				continue
+			}
 			glcm.Info(fmt.Sprintf("Removing files for job %s", job.JobId))
 			err := handleRemoveSingleJob(job.JobId)
 			if err != nil {
@@ -125,7 +161,7 @@ func blindDeleteAllJobFiles() (int, error) {
 
 	// get rid of the logs
 	numLogFilesRemoved, err := removeFilesWithPredicate(azcopyLogPathFolder, func(s string) bool {
-		if strings.HasSuffix(s, ".log") {
+		if common.IsLogFileName(s) {
 			return true
 		}
 		return false
@@ -133,3 +169,98 @@ func blindDeleteAllJobFiles() (int, error) {
 
 	return numPlanFilesRemoved + numLogFilesRemoved, err
 }
+
+// jobIDLength is the length of the job ID that the names of the log and plan files start with
+const jobIDLength = 36
+
+// jobFiles are the log and plan files of each job, and when the most recent of them last changed
+type jobFiles struct {
+	paths        map[common.JobID][]string
+	lastModified map[common.JobID]time.Time
+}
+
+// listJobFiles finds the log and plan files of the jobs. Their names start with the ID of their job.
+func listJobFiles() (jobFiles, error) {
+	jobs := jobFiles{paths: map[common.JobID][]string{}, lastModified: map[common.JobID]time.Time{}}
+	seen := map[string]bool{}
+	for _, folder := range []string{common.AzcopyJobPlanFolder, azcopyLogPathFolder} {
+		infos, err := ioutil.ReadDir(folder)
+		if err != nil {
+			return jobs, err
+		}
+		for _, info := range infos {
+			name := info.Name()
+			if info.IsDir() || !(strings.Contains(name, ".steV") || common.IsLogFileName(name)) {
+				continue
+			}
+			if len(name) < jobIDLength {
+				continue
+			}
+			jobID, err := common.ParseJobID(name[:jobIDLength])
+			if err != nil {
+				continue
+			}
+			p := filepath.Join(folder, name)
+			if seen[p] { // the plan and log folders may be the same
+				continue
+			}
+			seen[p] = true
+			jobs.paths[jobID] = append(jobs.paths[jobID], p)
+			if info.ModTime().After(jobs.lastModified[jobID]) {
+				jobs.lastModified[jobID] = info.ModTime()
+			}
+		}
+	}
+	return jobs, nil
+}
+
+// olderThan returns the files of the jobs none of whose files have changed since the cutoff.
+// A job's files are considered together, so that a job that is still in use, e.g. because it was resumed, is left alone.
+func (jobs jobFiles) olderThan(cutoff time.Time) map[common.JobID][]string {
+	old := map[common.JobID][]string{}
+	for jobID, paths := range jobs.paths {
+		if jobs.lastModified[jobID].Before(cutoff) {
+			old[jobID] = paths
+		}
+	}
+	return old
+}
+
+func removeJobFiles(jobs map[common.JobID][]string) (int, error) {
+	count := 0
+	for _, paths := range jobs {
+		for _, p := range paths {
+			if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+				return count, err
+			}
+			count++
+		}
+	}
+	return count, nil
+}
+
+// removeExpiredJobs removes the files of the jobs that haven't changed for AZCOPY_JOB_RETENTION_DAYS, when AzCopy starts
+func removeExpiredJobs() error {
+	value := glcm.GetEnvironmentVariable(common.EEnvironmentVariable.JobRetentionDays())
+	if value == "" {
+		return nil
+	}
+	days, err := strconv.Atoi(value)
+	if err != nil || days < 0 {
+		return fmt.Errorf("%s must be a whole number of days, not %q", common.EEnvironmentVariable.JobRetentionDays().Name, value)
+	}
+	if days == 0 {
+		return nil
+	}
+
+	jobs, err := listJobFiles()
+	if err != nil {
+		return err
+	}
+	expired := jobs.olderThan(time.Now().Add(-time.Duration(days) * 24 * time.Hour))
+	count, err := removeJobFiles(expired)
+	if count > 0 {
+		common.AzcopyCurrentJobLogger.Log(pipeline.LogInfo, fmt.Sprintf("Removed %d log and plan files of %d jobs that hadn't changed for %d days", count, len(expired), days))
+	}
+	return err
+}
@@ -207,6 +207,7 @@ func init() {
 	// oauth options
 	resumeCmd.PersistentFlags().StringVar(&resumeCmdArgs.SourceSAS, "source-sas", "", "Source SAS token of the source for a given Job ID.")
 	resumeCmd.PersistentFlags().StringVar(&resumeCmdArgs.DestinationSAS, "destination-sas", "", "destination SAS token of the destination for a given Job ID.")
+	resumeCmd.PersistentFlags().StringVar(&resumeCmdArgs.IncrementalFromSAS, "incremental-from-sas", "", "SAS token of the snapshot that was given as --incremental-from, for a given Job ID. Without it, the snapshot is accessed with the source's credentials.")
 }
 
 type resumeCmdArgs struct {
@@ -214,8 +215,9 @@ type resumeCmdArgs struct {
 	includeTransfer string
 	excludeTransfer string
 
-	SourceSAS      string
-	DestinationSAS string
+	SourceSAS          string
+	DestinationSAS     string
+	IncrementalFromSAS string
 }
 
 // processes the resume command,
@@ -280,7 +282,7 @@ func (rca resumeCmdArgs) process() error {
 	// Initialize credential info.
 	credentialInfo := common.CredentialInfo{}
 	// TODO: Replace context with root context
-	if credentialInfo.CredentialType, err = getCredentialType(ctx, rawFromToInfo{
+	if credentialInfo.CredentialType, err = getCredentialType(ctx, glcm, rawFromToInfo{
 		fromTo:         getJobFromToResponse.FromTo,
 		source:         getJobFromToResponse.Source,
 		destination:    getJobFromToResponse.Destination,
@@ -302,12 +304,13 @@ func (rca resumeCmdArgs) process() error {
 	var resumeJobResponse common.CancelPauseResumeResponse
 	Rpc(common.ERpcCmd.ResumeJob(),
 		&common.ResumeJobRequest{
-			JobID:           jobID,
-			SourceSAS:       rca.SourceSAS,
-			DestinationSAS:  rca.DestinationSAS,
-			CredentialInfo:  credentialInfo,
-			IncludeTransfer: includeTransfer,
-			ExcludeTransfer: excludeTransfer,
+			JobID:               jobID,
+			SourceSAS:           rca.SourceSAS,
+			DestinationSAS:      rca.DestinationSAS,
+			PageDiffBaselineSAS: strings.TrimPrefix(rca.IncrementalFromSAS, "?"),
+			CredentialInfo:      credentialInfo,
+			IncludeTransfer:     includeTransfer,
+			ExcludeTransfer:     excludeTransfer,
 		},
 		&resumeJobResponse)
 
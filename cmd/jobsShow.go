@@ -21,8 +21,12 @@
 package cmd
 
 import (
+	"encoding/csv"
 	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 
 	"encoding/json"
@@ -34,6 +38,16 @@ import (
 type ListReq struct {
 	JobID    common.JobID
 	OfStatus string
+
+	// where to export the listed transfers to, and whether as csv or json
+	ExportPath   string
+	ExportFormat string
+}
+
+// jobTransfersExport is a file to write the listed transfers to, e.g. so that the failed ones can be retried by another tool
+type jobTransfersExport struct {
+	path   string
+	format string // csv or json
 }
 
 func init() {
@@ -61,7 +75,12 @@ func init() {
 			listRequest.JobID = commandLineInput.JobID
 			listRequest.OfStatus = commandLineInput.OfStatus
 
-			err := HandleShowCommand(listRequest)
+			export, err := parseJobTransfersExport(commandLineInput.ExportPath, commandLineInput.ExportFormat, listRequest.OfStatus)
+			if err != nil {
+				glcm.Error(err.Error())
+			}
+
+			err = HandleShowCommand(listRequest, export)
 			if err == nil {
 				glcm.Exit(nil, common.EExitCode.Success())
 			} else {
@@ -74,11 +93,36 @@ func init() {
 
 	// filters
 	shJob.PersistentFlags().StringVar(&commandLineInput.OfStatus, "with-status", "", "Only list the transfers of job with this status, available values: Started, Success, Failed.")
+	shJob.PersistentFlags().StringVar(&commandLineInput.ExportPath, "export", "", "Also write the listed transfers, with why they failed, to this file. Requires --with-status.")
+	shJob.PersistentFlags().StringVar(&commandLineInput.ExportFormat, "export-format", "", "Format of the --export file: csv or json. By default, it's json if the file name ends with .json, and csv otherwise.")
+}
+
+func parseJobTransfersExport(path string, format string, ofStatus string) (jobTransfersExport, error) {
+	if path == "" {
+		if format != "" {
+			return jobTransfersExport{}, errors.New("export-format requires export")
+		}
+		return jobTransfersExport{}, nil
+	}
+	if ofStatus == "" {
+		return jobTransfersExport{}, errors.New("export requires with-status, e.g. --with-status=Failed")
+	}
+	if format == "" {
+		format = "csv"
+		if strings.EqualFold(filepath.Ext(path), ".json") {
+			format = "json"
+		}
+	}
+	format = strings.ToLower(format)
+	if format != "csv" && format != "json" {
+		return jobTransfersExport{}, fmt.Errorf("invalid export-format %q, it must be csv or json", format)
+	}
+	return jobTransfersExport{path: path, format: format}, nil
 }
 
 // handles the list command
 // dispatches the list order to the transfer engine
-func HandleShowCommand(listRequest common.ListRequest) error {
+func HandleShowCommand(listRequest common.ListRequest, export jobTransfersExport) error {
 	rpcCmd := common.ERpcCmd.None()
 	if listRequest.OfStatus == "" {
 		resp := common.ListJobSummaryResponse{}
@@ -97,11 +141,47 @@ func HandleShowCommand(listRequest common.ListRequest) error {
 		resp := common.ListJobTransfersResponse{}
 		rpcCmd = common.ERpcCmd.ListJobTransfers()
 		Rpc(rpcCmd, lsRequest, &resp)
+		if export.path != "" && resp.ErrorMsg == "" {
+			if err := exportJobTransfers(resp.Details, export); err != nil {
+				return fmt.Errorf("cannot export the transfers to %s: %w", export.path, err)
+			}
+		}
 		PrintJobTransfers(resp)
 	}
 	return nil
 }
 
+// exportJobTransfers writes the transfers to the file, as CSV with a header row, or as a JSON array
+func exportJobTransfers(details []common.TransferDetail, export jobTransfersExport) (err error) {
+	f, err := os.OpenFile(export.path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, common.DEFAULT_FILE_PERM)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+
+	if export.format == "json" {
+		encoder := json.NewEncoder(f)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(details)
+	}
+
+	w := csv.NewWriter(f)
+	_ = w.Write([]string{"Source", "Destination", "IsFolder", "Status", "HTTPStatus", "ErrorCode", "ErrorMessage"})
+	for _, d := range details {
+		httpStatus := ""
+		if d.ErrorCode != 0 {
+			httpStatus = strconv.Itoa(int(d.ErrorCode))
+		}
+		_ = w.Write([]string{d.Src, d.Dst, strconv.FormatBool(d.IsFolderProperties), d.TransferStatus.String(), httpStatus, d.ErrorServiceCode, d.ErrorMessage})
+	}
+	w.Flush()
+	return w.Error()
+}
+
 // PrintJobTransfers prints the response of listOrder command when list Order command requested the list of specific transfer of an existing job
 func PrintJobTransfers(listTransfersResponse common.ListJobTransfersResponse) {
 	if listTransfersResponse.ErrorMsg != "" {
@@ -123,7 +203,22 @@ func PrintJobTransfers(listTransfersResponse common.ListJobTransfersResponse) {
 				folderChar = "/"
 			}
 			sb.WriteString("transfer--> source: " + listTransfersResponse.Details[index].Src + folderChar + " destination: " +
-				listTransfersResponse.Details[index].Dst + folderChar + " status " + listTransfersResponse.Details[index].TransferStatus.String() + "\n")
+				listTransfersResponse.Details[index].Dst + folderChar + " status " + listTransfersResponse.Details[index].TransferStatus.String())
+			if d := listTransfersResponse.Details[index]; d.TransferStatus.DidFail() && (d.ErrorCode != 0 || d.ErrorMessage != "") {
+				sb.WriteString(fmt.Sprintf(" error %d %s: %s", d.ErrorCode, d.ErrorServiceCode, d.ErrorMessage))
+			}
+			sb.WriteString("\n")
+		}
+
+		if len(listTransfersResponse.FailuresByError) > 0 {
+			sb.WriteString("----------- Failures by error -----------\n")
+			for _, g := range listTransfersResponse.FailuresByError {
+				code := g.ErrorServiceCode
+				if code == "" {
+					code = "(no error code)"
+				}
+				sb.WriteString(fmt.Sprintf("%d transfers failed with %d %s\n", g.Count, g.ErrorCode, code))
+			}
 		}
 
 		return sb.String()
@@ -22,12 +22,15 @@ package cmd
 
 import (
 	"context"
+	"encoding/csv"
 	"errors"
 	"fmt"
-	pipeline2 "github.com/Azure/azure-pipeline-go/pipeline"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
+	pipeline2 "github.com/Azure/azure-pipeline-go/pipeline"
 	"github.com/spf13/cobra"
 
 	"github.com/Azure/azure-storage-azcopy/v10/common"
@@ -42,6 +45,23 @@ type rawListCmdArgs struct {
 	MachineReadable bool
 	RunningTally    bool
 	MegaUnits       bool
+
+	recursive bool
+
+	// filters, as for copy
+	include         string
+	exclude         string
+	excludePath     string
+	includeRegex    string
+	excludeRegex    string
+	includeBefore   string
+	includeAfter    string
+	excludeBlobType string
+
+	// what else to list from blob containers
+	includeVersions  bool
+	includeSnapshots bool
+	includeDeleted   bool
 }
 
 type validProperty string
@@ -56,12 +76,17 @@ const (
 	leaseState       validProperty = "LeaseState"
 	leaseDuration    validProperty = "LeaseDuration"
 	leaseStatus      validProperty = "LeaseStatus"
+	snapshotId       validProperty = "SnapshotId"
+	isDeleted        validProperty = "IsDeleted"
+	metadata         validProperty = "Metadata"
+	blobTags         validProperty = "BlobTags"
 )
 
 // validProperties returns an array of possible values for the validProperty const type.
 func validProperties() []validProperty {
 	return []validProperty{lastModifiedTime, versionId, blobType, blobAccessTier,
-		contentType, contentEncoding, leaseState, leaseDuration, leaseStatus}
+		contentType, contentEncoding, leaseState, leaseDuration, leaseStatus,
+		snapshotId, isDeleted, metadata, blobTags}
 }
 
 func (raw *rawListCmdArgs) parseProperties(rawProperties string) []validProperty {
@@ -79,13 +104,45 @@ func (raw *rawListCmdArgs) parseProperties(rawProperties string) []validProperty
 	return parsedProperties
 }
 
+// buildFilters builds the same filters that copy does from the include and exclude flags
+func (raw *rawListCmdArgs) buildFilters() ([]ObjectFilter, error) {
+	includeBefore, includeAfter, err := parseIncludeDates(raw.includeBefore, raw.includeAfter)
+	if err != nil {
+		return nil, err
+	}
+	excludeBlobType, err := parseExcludeBlobTypes(raw.excludeBlobType)
+	if err != nil {
+		return nil, err
+	}
+	return modularFilterOptions{
+		includeBefore:       includeBefore,
+		includeAfter:        includeAfter,
+		includePatterns:     parsePatterns(raw.include),
+		excludePatterns:     parsePatterns(raw.exclude),
+		excludePathPatterns: parsePatterns(raw.excludePath),
+		includeRegex:        parsePatterns(raw.includeRegex),
+		excludeRegex:        parsePatterns(raw.excludeRegex),
+		excludeBlobType:     excludeBlobType,
+	}.build(), nil
+}
+
+// isListableLocation tells whether list can list the location, i.e. whether it has a traverser
+func isListableLocation(location common.Location) bool {
+	switch location {
+	case common.ELocation.Blob(), common.ELocation.File(), common.ELocation.BlobFS(),
+		common.ELocation.S3(), common.ELocation.GCP(), common.ELocation.Local():
+		return true
+	}
+	return false
+}
+
 func (raw rawListCmdArgs) cook() (cookedListCmdArgs, error) {
-	cooked = cookedListCmdArgs{}
-	// the expected argument in input is the container sas / or path of virtual directory in the container.
+	cooked := cookedListCmdArgs{}
+	// the expected argument in input is the container sas / or path of virtual directory in the container,
+	// or a bucket or directory of S3 or GCP, or a local directory.
 	// verifying the location type
 	location := InferArgumentLocation(raw.sourcePath)
-	// Only support listing for Azure locations
-	if location != location.Blob() && location != location.File() && location != location.BlobFS() {
+	if !isListableLocation(location) {
 		return cooked, errors.New("invalid path passed for listing. given source is of type " + location.String() + " while expect is container / container path ")
 	}
 	cooked.sourcePath = raw.sourcePath
@@ -93,11 +150,43 @@ func (raw rawListCmdArgs) cook() (cookedListCmdArgs, error) {
 	cooked.RunningTally = raw.RunningTally
 	cooked.MegaUnits = raw.MegaUnits
 	cooked.location = location
+	cooked.recursive = raw.recursive
 
 	if raw.Properties != "" {
 		cooked.properties = raw.parseProperties(raw.Properties)
 	}
 
+	if (raw.includeVersions || raw.includeSnapshots || raw.includeDeleted) && location != location.Blob() {
+		return cooked, errors.New("include-versions, include-snapshots and include-deleted are only supported when listing blobs")
+	}
+	if raw.includeVersions && raw.includeSnapshots {
+		return cooked, errors.New("include-versions and include-snapshots cannot be used together")
+	}
+	cooked.includeVersions = raw.includeVersions
+	cooked.includeSnapshots = raw.includeSnapshots
+	cooked.includeDeleted = raw.includeDeleted
+
+	// the versions, snapshots and soft-deleted blobs can't be told apart from the base blobs without these
+	if raw.includeVersions {
+		cooked.properties = cooked.withProperty(versionId)
+	}
+	if raw.includeSnapshots {
+		cooked.properties = cooked.withProperty(snapshotId)
+	}
+	if raw.includeDeleted {
+		cooked.properties = cooked.withProperty(isDeleted)
+	}
+
+	if raw.RunningTally && azcopyOutputFormat == common.EOutputFormat.Csv() {
+		return cooked, errors.New("running-tally cannot be used with the csv output type")
+	}
+
+	var err error
+	cooked.filters, err = raw.buildFilters()
+	if err != nil {
+		return cooked, err
+	}
+
 	return cooked, nil
 }
 
@@ -109,10 +198,44 @@ type cookedListCmdArgs struct {
 	MachineReadable bool
 	RunningTally    bool
 	MegaUnits       bool
+
+	recursive bool
+	filters   []ObjectFilter
+
+	includeVersions  bool
+	includeSnapshots bool
+	includeDeleted   bool
+}
+
+// withProperty returns the properties to list, with the given one added if it's missing
+func (cooked cookedListCmdArgs) withProperty(property validProperty) []validProperty {
+	for _, p := range cooked.properties {
+		if p == property {
+			return cooked.properties
+		}
+	}
+	return append(cooked.properties, property)
+}
+
+// needsObjectProperties tells whether the properties to list are only known once each object's properties are got,
+// rather than from the listing, as is the case for Azure Files, S3 and GCP
+func (cooked cookedListCmdArgs) needsObjectProperties() bool {
+	if cooked.location == common.ELocation.Blob() || cooked.location == common.ELocation.Local() {
+		return false
+	}
+	return cooked.hasProperty(contentType) || cooked.hasProperty(contentEncoding) || cooked.hasProperty(metadata)
+}
+
+func (cooked cookedListCmdArgs) hasProperty(property validProperty) bool {
+	for _, p := range cooked.properties {
+		if p == property {
+			return true
+		}
+	}
+	return false
 }
 
 var raw rawListCmdArgs
-var cooked cookedListCmdArgs
 
 func init() {
 	raw = rawListCmdArgs{}
@@ -154,126 +277,326 @@ func init() {
 	listContainerCmd.PersistentFlags().BoolVar(&raw.RunningTally, "running-tally", false, "Counts the total number of files and their sizes.")
 	listContainerCmd.PersistentFlags().BoolVar(&raw.MegaUnits, "mega-units", false, "Displays units in orders of 1000, not 1024.")
 	listContainerCmd.PersistentFlags().StringVar(&raw.Properties, "properties", "", "delimiter (;) separated values of properties required in list output.")
+	listContainerCmd.PersistentFlags().BoolVar(&raw.recursive, "recursive", true, "Look into sub-directories recursively. When false, the directories, and the virtual directories of blob containers, are listed instead of their contents.")
+
+	listContainerCmd.PersistentFlags().StringVar(&raw.include, "include-pattern", "", "Include only files where the name matches the pattern list. For example: *.jpg;*.pdf;exactName")
+	listContainerCmd.PersistentFlags().StringVar(&raw.exclude, "exclude-pattern", "", "Exclude files where the name matches the pattern list. For example: *.jpg;*.pdf;exactName")
+	listContainerCmd.PersistentFlags().StringVar(&raw.excludePath, "exclude-path", "", "Exclude these paths when listing. "+
+		"This option does not support wildcard characters (*). Checks relative path prefix. For example: myFolder;myFolder/subDirName/file.pdf")
+	listContainerCmd.PersistentFlags().StringVar(&raw.includeRegex, "include-regex", "", "Include only the relative paths of the files that match with the regular expressions. Separate regular expressions with ';'.")
+	listContainerCmd.PersistentFlags().StringVar(&raw.excludeRegex, "exclude-regex", "", "Exclude all the relative paths of the files that match with the regular expressions. Separate regular expressions with ';'.")
+	listContainerCmd.PersistentFlags().StringVar(&raw.includeBefore, common.IncludeBeforeFlagName, "", "Include only those files modified before or on the given date/time. The value should be in ISO8601 format. If no timezone is specified, the value is assumed to be in the local timezone of the machine running AzCopy. E.g. '2020-08-19T15:04:00Z' for a UTC time, or '2020-08-19' for midnight (00:00) in the local timezone.")
+	listContainerCmd.PersistentFlags().StringVar(&raw.includeAfter, common.IncludeAfterFlagName, "", "Include only those files modified on or after the given date/time. The value should be in ISO8601 format. If no timezone is specified, the value is assumed to be in the local timezone of the machine running AzCopy. E.g. '2020-08-19T15:04:00Z' for a UTC time, or '2020-08-19' for midnight (00:00) in the local timezone.")
+	listContainerCmd.PersistentFlags().StringVar(&raw.excludeBlobType, "exclude-blob-type", "", "Optionally specifies the type of blob (BlockBlob/ PageBlob/ AppendBlob) to exclude when listing. Separate the blob types with ';'.")
+
+	listContainerCmd.PersistentFlags().BoolVar(&raw.includeVersions, "include-versions", false, "Also list the previous versions of the blobs, with their version IDs.")
+	listContainerCmd.PersistentFlags().BoolVar(&raw.includeSnapshots, "include-snapshots", false, "Also list the snapshots of the blobs, with their snapshot IDs.")
+	listContainerCmd.PersistentFlags().BoolVar(&raw.includeDeleted, "include-deleted", false, "Also list the soft-deleted blobs, with the IsDeleted property.")
 
 	rootCmd.AddCommand(listContainerCmd)
 }
 
+// propertyValue gives the value of the property of the object, as listed in the text and csv output types
+func (cooked cookedListCmdArgs) propertyValue(object StoredObject, property validProperty, format common.OutputFormat) string {
+	switch property {
+	case lastModifiedTime:
+		if format == common.EOutputFormat.Csv() {
+			return object.lastModifiedTime.UTC().Format(time.RFC3339)
+		}
+		return object.lastModifiedTime.String()
+	case versionId:
+		return object.blobVersionID
+	case blobType:
+		return string(object.blobType)
+	case blobAccessTier:
+		return string(object.blobAccessTier)
+	case contentType:
+		return object.contentType
+	case contentEncoding:
+		return object.contentEncoding
+	case leaseState:
+		return string(object.leaseState)
+	case leaseStatus:
+		return string(object.leaseStatus)
+	case leaseDuration:
+		return string(object.leaseDuration)
+	case snapshotId:
+		return object.blobSnapshotID
+	case isDeleted:
+		return strconv.FormatBool(object.blobDeleted)
+	case metadata:
+		return keyValuePairs(object.Metadata, ";")
+	case blobTags:
+		return keyValuePairs(object.blobTags, "&")
+	}
+	return ""
+}
+
+// keyValuePairs writes the pairs sorted by key, as key=value separated by sep, so that the output is stable
+func keyValuePairs(pairs map[string]string, sep string) string {
+	keys := make([]string, 0, len(pairs))
+	for k := range pairs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for i, k := range keys {
+		keys[i] = k + "=" + pairs[k]
+	}
+	return strings.Join(keys, sep)
+}
+
 func (cooked cookedListCmdArgs) processProperties(object StoredObject) string {
 	builder := strings.Builder{}
 	for _, property := range cooked.properties {
-		propertyStr := string(property)
+		builder.WriteString(string(property) + ": " + cooked.propertyValue(object, property, common.EOutputFormat.Text()) + "; ")
+	}
+	return builder.String()
+}
+
+// listedObject is the schema of the objects output with the json output type. The properties are only set when asked for.
+type listedObject struct {
+	Path          string
+	ContainerName string `json:",omitempty"`
+	EntityType    string
+	ContentLength int64
+
+	LastModifiedTime *time.Time        `json:",omitempty"`
+	VersionId        string            `json:",omitempty"`
+	SnapshotId       string            `json:",omitempty"`
+	IsDeleted        *bool             `json:",omitempty"`
+	BlobType         string            `json:",omitempty"`
+	BlobAccessTier   string            `json:",omitempty"`
+	ContentType      string            `json:",omitempty"`
+	ContentEncoding  string            `json:",omitempty"`
+	LeaseState       string            `json:",omitempty"`
+	LeaseDuration    string            `json:",omitempty"`
+	LeaseStatus      string            `json:",omitempty"`
+	Metadata         map[string]string `json:",omitempty"`
+	BlobTags         map[string]string `json:",omitempty"`
+}
+
+// listTally is the last object output with the json output type, when the running tally is asked for
+type listTally struct {
+	FileCount     int64
+	TotalFileSize int64
+}
+
+// listObjectOutput builds the output of the listed objects, for whichever output type is used
+type listObjectOutput struct {
+	cooked      cookedListCmdArgs
+	level       LocationLevel
+	wroteHeader bool
+}
+
+// builder gives the output of the object: a JSON object, a CSV row (after the header row, for the first object) or a line of text
+func (lo *listObjectOutput) builder(object StoredObject) common.OutputBuilder {
+	return func(format common.OutputFormat) string {
+		switch format {
+		case common.EOutputFormat.Json():
+			return common.GetJsonStringFromTemplate(lo.toListedObject(object))
+		case common.EOutputFormat.Csv():
+			row := []string{object.relativePath, object.ContainerName, object.entityType.String(), strconv.FormatInt(object.size, 10)}
+			for _, property := range lo.cooked.properties {
+				row = append(row, lo.cooked.propertyValue(object, property, format))
+			}
+			if lo.wroteHeader {
+				return csvRows(row)
+			}
+
+			lo.wroteHeader = true
+			header := []string{"Path", "ContainerName", "EntityType", "ContentLength"}
+			for _, property := range lo.cooked.properties {
+				header = append(header, string(property))
+			}
+			return csvRows(header, row)
+		default:
+			return lo.text(object)
+		}
+	}
+}
+
+// text gives the line that lists the object for people to read
+func (lo *listObjectOutput) text(object StoredObject) string {
+	path := object.relativePath
+	if object.entityType == common.EEntityType.Folder() {
+		path += "/" // TODO: reviewer: same questions as for jobs status: OK to hard code direction of slash? OK to use trailing slash to distinguish dirs from files?
+	}
+
+	properties := "; " + lo.cooked.processProperties(object)
+	objectSummary := path + properties + " Content Length: "
+
+	if lo.level == lo.level.Service() {
+		objectSummary = object.ContainerName + "/" + objectSummary
+	}
+
+	if lo.cooked.MachineReadable {
+		objectSummary += strconv.Itoa(int(object.size))
+	} else {
+		objectSummary += lo.cooked.byteSizeToString(object.size)
+	}
+	return objectSummary
+}
+
+// csvRows formats the rows as CSV, without the line break after the last one, since each output is printed on its own line
+func csvRows(rows ...[]string) string {
+	var builder strings.Builder
+	csvWriter := csv.NewWriter(&builder)
+	_ = csvWriter.WriteAll(rows) // writing to a strings.Builder can't fail
+	return strings.TrimSuffix(builder.String(), "\n")
+}
+
+func (lo *listObjectOutput) toListedObject(object StoredObject) listedObject {
+	listed := listedObject{
+		Path:          object.relativePath,
+		EntityType:    object.entityType.String(),
+		ContentLength: object.size,
+	}
+	if lo.level == ELocationLevel.Service() {
+		listed.ContainerName = object.ContainerName
+	}
+
+	for _, property := range lo.cooked.properties {
 		switch property {
 		case lastModifiedTime:
-			builder.WriteString(propertyStr + ": " + object.lastModifiedTime.String() + "; ")
+			lmt := object.lastModifiedTime.UTC()
+			listed.LastModifiedTime = &lmt
 		case versionId:
-			builder.WriteString(propertyStr + ": " + object.blobVersionID + "; ")
+			listed.VersionId = object.blobVersionID
+		case snapshotId:
+			listed.SnapshotId = object.blobSnapshotID
+		case isDeleted:
+			deleted := object.blobDeleted
+			listed.IsDeleted = &deleted
 		case blobType:
-			builder.WriteString(propertyStr + ": " + string(object.blobType) + "; ")
+			listed.BlobType = string(object.blobType)
 		case blobAccessTier:
-			builder.WriteString(propertyStr + ": " + string(object.blobAccessTier) + "; ")
+			listed.BlobAccessTier = string(object.blobAccessTier)
 		case contentType:
-			builder.WriteString(propertyStr + ": " + object.contentType + "; ")
+			listed.ContentType = object.contentType
 		case contentEncoding:
-			builder.WriteString(propertyStr + ": " + object.contentEncoding + "; ")
+			listed.ContentEncoding = object.contentEncoding
 		case leaseState:
-			builder.WriteString(propertyStr + ": " + string(object.leaseState) + "; ")
-		case leaseStatus:
-			builder.WriteString(propertyStr + ": " + string(object.leaseStatus) + "; ")
+			listed.LeaseState = string(object.leaseState)
 		case leaseDuration:
-			builder.WriteString(propertyStr + ": " + string(object.leaseDuration) + "; ")
+			listed.LeaseDuration = string(object.leaseDuration)
+		case leaseStatus:
+			listed.LeaseStatus = string(object.leaseStatus)
+		case metadata:
+			listed.Metadata = object.Metadata
+		case blobTags:
+			listed.BlobTags = object.blobTags
 		}
 	}
-	return builder.String()
+	return listed
 }
 
-// HandleListContainerCommand handles the list container command
-func (cooked cookedListCmdArgs) HandleListContainerCommand() (err error) {
-	// TODO: Temporarily use context.TODO(), this should be replaced with a root context from main.
-	ctx := context.WithValue(context.TODO(), ste.ServiceAPIVersionOverride, ste.DefaultServiceApiVersion)
-
+// newListCmdTraverser creates a traverser of the container, directory or account to be listed, and tells which of those it is
+func newListCmdTraverser(ctx context.Context, lcm common.LifecycleMgr, cooked cookedListCmdArgs) (ResourceTraverser, LocationLevel, error) {
 	credentialInfo := common.CredentialInfo{}
+	location := cooked.location
 
-	source, err := SplitResourceString(cooked.sourcePath, cooked.location)
+	source, err := SplitResourceString(cooked.sourcePath, location)
 	if err != nil {
-		return err
+		return nil, ELocationLevel.Object(), err
 	}
 
-	level, err := DetermineLocationLevel(source.Value, cooked.location, true)
+	level, err := DetermineLocationLevel(source.Value, location, true)
 
 	if err != nil {
-		return err
+		return nil, level, err
 	}
 
 	// isSource is rather misnomer for canBePublic. We can list public containers, and hence isSource=true
-	if credentialInfo, _, err = GetCredentialInfoForLocation(ctx, cooked.location, source.Value, source.SAS, true, common.CpkOptions{}); err != nil {
-		return fmt.Errorf("failed to obtain credential info: %s", err.Error())
-	} else if cooked.location == cooked.location.File() && source.SAS == "" {
-		return errors.New("azure files requires a SAS token for authentication")
+	if credentialInfo, _, err = GetCredentialInfoForLocation(ctx, lcm, location, source.Value, source.SAS, true, common.CpkOptions{}); err != nil {
+		return nil, level, fmt.Errorf("failed to obtain credential info: %s", err.Error())
+	} else if location == location.File() && source.SAS == "" {
+		return nil, level, errors.New("azure files requires a SAS token for authentication")
 	} else if credentialInfo.CredentialType == common.ECredentialType.OAuthToken() {
 		uotm := GetUserOAuthTokenManagerInstance()
 		if tokenInfo, err := uotm.GetTokenInfo(ctx); err != nil {
-			return err
+			return nil, level, err
 		} else {
 			credentialInfo.OAuthTokenInfo = *tokenInfo
 		}
 	}
 
-	traverser, err := InitResourceTraverser(source, cooked.location, &ctx, &credentialInfo, nil, nil,
-		true, false, false, common.EPermanentDeleteOption.None(), func(common.EntityType) {},
-		nil, false, pipeline2.LogNone, common.CpkOptions{})
+	// the tags of blobs are only listed when asked for, since they're listed with them, as for copy's s2s-preserve-blob-tags
+	traverser, err := InitResourceTraverser(source, location, &ctx, &credentialInfo, nil, nil,
+		cooked.recursive, cooked.needsObjectProperties(), false, common.EPermanentDeleteOption.None(), func(common.EntityType) {},
+		nil, cooked.hasProperty(blobTags), pipeline2.LogNone, common.CpkOptions{})
 
 	if err != nil {
-		return fmt.Errorf("failed to initialize traverser: %s", err.Error())
+		return nil, level, fmt.Errorf("failed to initialize traverser: %s", err.Error())
 	}
 
-	var fileCount int64 = 0
-	var sizeCount int64 = 0
+	switch t := traverser.(type) {
+	case *blobTraverser:
+		t.includeVersion = cooked.includeVersions
+		t.includeSnapshot = cooked.includeSnapshots
+		t.includeDeleted = cooked.includeDeleted
+		t.includeVirtualDirectories = !cooked.recursive
+		return traverser, level, nil
+	case *s3Traverser:
+		t.includeVirtualDirectories = !cooked.recursive
+	case *gcpTraverser:
+		t.includeVirtualDirectories = !cooked.recursive
+	}
 
-	processor := func(object StoredObject) error {
-		path := object.relativePath
-		if object.entityType == common.EEntityType.Folder() {
-			path += "/" // TODO: reviewer: same questions as for jobs status: OK to hard code direction of slash? OK to use trailing slash to distinguish dirs from files?
-		}
+	if cooked.includeVersions || cooked.includeSnapshots || cooked.includeDeleted {
+		return nil, level, errors.New("include-versions, include-snapshots and include-deleted are only supported when listing a container or virtual directory")
+	}
+	return traverser, level, nil
+}
 
-		properties := "; " + cooked.processProperties(object)
-		objectSummary := path + properties + " Content Length: "
+// HandleListContainerCommand handles the list container command
+func (cooked cookedListCmdArgs) HandleListContainerCommand() (err error) {
+	// TODO: Temporarily use context.TODO(), this should be replaced with a root context from main.
+	ctx := context.WithValue(context.TODO(), ste.ServiceAPIVersionOverride, ste.DefaultServiceApiVersion)
 
-		if level == level.Service() {
-			objectSummary = object.ContainerName + "/" + objectSummary
-		}
+	traverser, level, err := newListCmdTraverser(ctx, glcm, cooked)
+	if err != nil {
+		return err
+	}
 
-		if cooked.MachineReadable {
-			objectSummary += strconv.Itoa(int(object.size))
-		} else {
-			objectSummary += byteSizeToString(object.size)
-		}
+	return cooked.listObjects(traverser, level)
+}
+
+// listObjects outputs each of the objects of the traverser, and then the running tally, if it's asked for
+func (cooked cookedListCmdArgs) listObjects(traverser ResourceTraverser, level LocationLevel) error {
+	var fileCount int64 = 0
+	var sizeCount int64 = 0
 
-		if cooked.RunningTally {
+	output := &listObjectOutput{cooked: cooked, level: level}
+	processor := func(object StoredObject) error {
+		if cooked.RunningTally && object.entityType == common.EEntityType.File() {
 			fileCount++
 			sizeCount += object.size
 		}
 
-		glcm.Info(objectSummary)
-
 		// No need to strip away from the name as the traverser has already done so.
+		glcm.Output(output.builder(object))
 		return nil
 	}
 
-	err = traverser.Traverse(nil, processor, nil)
+	err := traverser.Traverse(nil, processor, cooked.filters)
 
 	if err != nil {
 		return fmt.Errorf("failed to traverse container: %s", err.Error())
 	}
 
 	if cooked.RunningTally {
-		glcm.Info("")
-		glcm.Info("File count: " + strconv.Itoa(int(fileCount)))
+		glcm.Output(func(format common.OutputFormat) string {
+			if format == common.EOutputFormat.Json() {
+				return common.GetJsonStringFromTemplate(listTally{FileCount: fileCount, TotalFileSize: sizeCount})
+			}
 
-		if cooked.MachineReadable {
-			glcm.Info("Total file size: " + strconv.Itoa(int(sizeCount)))
-		} else {
-			glcm.Info("Total file size: " + byteSizeToString(sizeCount))
-		}
+			totalFileSize := cooked.byteSizeToString(sizeCount)
+			if cooked.MachineReadable {
+				totalFileSize = strconv.Itoa(int(sizeCount))
+			}
+			return "\nFile count: " + strconv.Itoa(int(fileCount)) + "\nTotal file size: " + totalFileSize
+		})
 	}
 
 	return nil
@@ -289,6 +612,14 @@ var megaSize = []string{
 	"EB",
 }
 
+// byteSizeToString gives the size in the units that were asked for with mega-units
+func (cooked cookedListCmdArgs) byteSizeToString(size int64) string {
+	if cooked.MegaUnits {
+		return formatByteSize(size, 1000, megaSize)
+	}
+	return byteSizeToString(size)
+}
+
 func byteSizeToString(size int64) string {
 	units := []string{
 		"B",
@@ -300,14 +631,12 @@ func byteSizeToString(size int64) string {
 		"EiB", // Let's face it, a file, account, or container probably won't be more than 1000 exabytes in YEARS.
 		// (and int64 literally isn't large enough to handle too many exbibytes. 128 bit processors when)
 	}
+	return formatByteSize(size, 1024, units)
+}
+
+func formatByteSize(size int64, gigSize int, units []string) string {
 	unit := 0
 	floatSize := float64(size)
-	gigSize := 1024
-
-	if cooked.MegaUnits {
-		gigSize = 1000
-		units = megaSize
-	}
 
 	for floatSize/float64(gigSize) >= 1 {
 		unit++
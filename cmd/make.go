@@ -25,6 +25,7 @@ import (
 	"fmt"
 	pipeline2 "github.com/Azure/azure-pipeline-go/pipeline"
 	"net/url"
+	"regexp"
 	"strings"
 
 	"errors"
@@ -41,8 +42,38 @@ import (
 type rawMakeCmdArgs struct {
 	resourceToCreate string
 	quota            uint32
+	ifNotExists      bool
+
+	// metadata of the container, share or directory, as key=value pairs separated by ';'
+	metadata string
+
+	// for containers
+	publicAccess                   string
+	defaultEncryptionScope         string
+	preventEncryptionScopeOverride bool
+
+	// for shares
+	accessTier string
+
+	// for ADLS Gen2 file systems and directories
+	permissions string
+	defaultACL  string
+
+	// for Azure Files directories
+	fileAttributes string
+	filePermission string
 }
 
+var shareAccessTiers = []string{
+	string(azfile.ShareAccessTierTransactionOptimized),
+	string(azfile.ShareAccessTierHot),
+	string(azfile.ShareAccessTierCool),
+	"Premium",
+}
+
+// POSIX permissions, in octal or symbolic notation, e.g. 0750 or rwxr-x---, optionally with the sticky bit
+var makePermissionsRegex = regexp.MustCompile(`^([0-7]{3,4}|[r-][w-][x-][r-][w-][x-][r-][w-][xtT-]\+?)$`)
+
 // parse raw input
 func (raw rawMakeCmdArgs) cook() (cookedMakeCmdArgs, error) {
 	parsedURL, err := url.Parse(raw.resourceToCreate)
@@ -50,16 +81,165 @@ func (raw rawMakeCmdArgs) cook() (cookedMakeCmdArgs, error) {
 		return cookedMakeCmdArgs{}, err
 	}
 
-	if strings.Count(parsedURL.Path, "/") > 1 {
-		return cookedMakeCmdArgs{}, fmt.Errorf("please provide a valid top-level(ex: File System or Container) resource URL")
-	}
-
 	// resourceLocation could be unknown at this stage, it will be handled by the caller
-	return cookedMakeCmdArgs{
+	cooked := cookedMakeCmdArgs{
 		resourceURL:      *parsedURL,
 		resourceLocation: InferArgumentLocation(raw.resourceToCreate),
 		quota:            int32(raw.quota),
-	}, nil
+		ifNotExists:      raw.ifNotExists,
+		publicAccess:     azblob.PublicAccessNone,
+	}
+
+	// directories can be made in file systems and shares, but not in containers, whose directories are only virtual
+	switch cooked.resourceLocation {
+	case common.ELocation.Blob():
+		if azblob.NewBlobURLParts(*parsedURL).BlobName != "" {
+			return cookedMakeCmdArgs{}, fmt.Errorf("please provide a valid container URL. Directories can only be made in containers by using the dfs endpoint of an account with a hierarchical namespace")
+		}
+	case common.ELocation.File():
+		cooked.directoryPath = strings.Trim(azfile.NewFileURLParts(*parsedURL).DirectoryOrFilePath, "/")
+	case common.ELocation.BlobFS():
+		cooked.directoryPath = strings.Trim(azbfs.NewBfsURLParts(*parsedURL).DirectoryOrFilePath, "/")
+	default:
+		if strings.Count(parsedURL.Path, "/") > 1 {
+			return cookedMakeCmdArgs{}, fmt.Errorf("please provide a valid top-level(ex: File System or Container) resource URL")
+		}
+	}
+
+	isBlob := cooked.resourceLocation == common.ELocation.Blob()
+	isFile := cooked.resourceLocation == common.ELocation.File()
+	isBlobFS := cooked.resourceLocation == common.ELocation.BlobFS()
+
+	if raw.metadata != "" {
+		if isBlobFS && cooked.directoryPath == "" {
+			return cookedMakeCmdArgs{}, errors.New("metadata can only be set on the directories in an ADLS Gen2 file system, not on the file system itself")
+		}
+		if cooked.metadata, err = parseMakeMetadata(raw.metadata); err != nil {
+			return cookedMakeCmdArgs{}, err
+		}
+	}
+
+	if raw.publicAccess != "" {
+		if !isBlob {
+			return cookedMakeCmdArgs{}, errors.New("public-access can only be set on containers")
+		}
+		switch strings.ToLower(raw.publicAccess) {
+		case "none":
+			cooked.publicAccess = azblob.PublicAccessNone
+		case "blob":
+			cooked.publicAccess = azblob.PublicAccessBlob
+		case "container":
+			cooked.publicAccess = azblob.PublicAccessContainer
+		default:
+			return cookedMakeCmdArgs{}, fmt.Errorf("invalid public-access %q, it must be none, blob or container", raw.publicAccess)
+		}
+	}
+
+	if raw.defaultEncryptionScope != "" || raw.preventEncryptionScopeOverride {
+		if !isBlob {
+			return cookedMakeCmdArgs{}, errors.New("default-encryption-scope and prevent-encryption-scope-override can only be set on containers")
+		}
+		if raw.defaultEncryptionScope == "" {
+			return cookedMakeCmdArgs{}, errors.New("prevent-encryption-scope-override requires default-encryption-scope")
+		}
+		cooked.defaultEncryptionScope = raw.defaultEncryptionScope
+		cooked.preventEncryptionScopeOverride = raw.preventEncryptionScopeOverride
+	}
+
+	if raw.accessTier != "" {
+		if !isFile {
+			return cookedMakeCmdArgs{}, errors.New("access-tier can only be set on file shares")
+		}
+		for _, tier := range shareAccessTiers {
+			if strings.EqualFold(raw.accessTier, tier) {
+				cooked.accessTier = tier
+			}
+		}
+		if cooked.accessTier == "" {
+			return cookedMakeCmdArgs{}, fmt.Errorf("invalid access-tier %q, it must be one of %s", raw.accessTier, strings.Join(shareAccessTiers, ", "))
+		}
+	}
+
+	if raw.permissions != "" || raw.defaultACL != "" {
+		if !isBlobFS {
+			return cookedMakeCmdArgs{}, errors.New("permissions and default-acl can only be set on ADLS Gen2 file systems and directories")
+		}
+		if raw.permissions != "" && !makePermissionsRegex.MatchString(raw.permissions) {
+			return cookedMakeCmdArgs{}, fmt.Errorf("invalid permissions %q, they must be in octal (e.g. 0750) or symbolic (e.g. rwxr-x---) notation", raw.permissions)
+		}
+		cooked.permissions = raw.permissions
+		if raw.defaultACL != "" {
+			if cooked.defaultACL, err = parseDefaultACL(raw.defaultACL); err != nil {
+				return cookedMakeCmdArgs{}, err
+			}
+		}
+	}
+
+	if raw.fileAttributes != "" || raw.filePermission != "" {
+		if !isFile || cooked.directoryPath == "" {
+			return cookedMakeCmdArgs{}, errors.New("file-attributes and file-permission can only be set on directories in file shares")
+		}
+		if raw.fileAttributes != "" {
+			attributes, err := parseMakeFileAttributes(raw.fileAttributes)
+			if err != nil {
+				return cookedMakeCmdArgs{}, err
+			}
+			cooked.smbProperties.FileAttributes = &attributes
+		}
+		if raw.filePermission != "" {
+			permission := raw.filePermission
+			cooked.smbProperties.PermissionString = &permission
+		}
+	}
+
+	return cooked, nil
+}
+
+// parseMakeMetadata parses metadata given as key=value pairs separated by ';', e.g. "project=x;owner=y"
+func parseMakeMetadata(metadataString string) (common.Metadata, error) {
+	metadata := common.Metadata{}
+	for _, keyAndValue := range strings.Split(metadataString, ";") {
+		if keyAndValue == "" {
+			continue
+		}
+		kv := strings.SplitN(keyAndValue, "=", 2)
+		if len(kv) != 2 || strings.TrimSpace(kv[0]) == "" {
+			return nil, fmt.Errorf("invalid metadata %q, it must be key=value pairs separated by ';'", keyAndValue)
+		}
+		metadata[strings.TrimSpace(kv[0])] = kv[1]
+	}
+	return metadata, nil
+}
+
+// parseDefaultACL checks the entries of a default ACL, e.g. "user::rwx,group::r-x,other::---", and returns them with
+// the default: prefix, which makes them the ACL that's inherited by what's created in the directory
+func parseDefaultACL(acl string) (string, error) {
+	var entries []string
+	for _, entry := range strings.Split(acl, ",") {
+		entry = strings.TrimPrefix(strings.TrimSpace(entry), "default:")
+		if !makeACLEntryRegex.MatchString(entry) {
+			return "", fmt.Errorf("invalid default-acl entry %q, it must be in the form [user|group|mask|other]:[id]:[rwx], e.g. group::r-x", entry)
+		}
+		entries = append(entries, "default:"+entry)
+	}
+	return strings.Join(entries, ","), nil
+}
+
+var makeACLEntryRegex = regexp.MustCompile(`^(user|group|mask|other):[^:,]*:[r-][w-][x-]$`)
+
+// parseMakeFileAttributes parses SMB attributes separated by ';', e.g. "Hidden;ReadOnly"
+func parseMakeFileAttributes(attributes string) (azfile.FileAttributeFlags, error) {
+	var names []string
+	for _, name := range strings.Split(attributes, ";") {
+		name = strings.TrimSpace(name)
+		switch strings.ToLower(name) {
+		case "none", "readonly", "hidden", "system", "archive", "temporary", "offline", "notcontentindexed", "noscrubdata":
+			names = append(names, name)
+		default:
+			return azfile.FileAttributeNone, fmt.Errorf("invalid file attribute %q, it must be one of None, ReadOnly, Hidden, System, Archive, Temporary, Offline, NotContentIndexed or NoScrubData", name)
+		}
+	}
+	return azfile.ParseFileAttributeFlagsString(strings.Join(names, "|")), nil
 }
 
 // holds processed/actionable args
@@ -67,84 +247,251 @@ type cookedMakeCmdArgs struct {
 	resourceURL      url.URL
 	resourceLocation common.Location
 	quota            int32 // quota is in GB
+	ifNotExists      bool
+
+	// the directories to make in the file system or share, if any, e.g. "dir/subdir"
+	directoryPath string
+	metadata      common.Metadata
+
+	publicAccess                   azblob.PublicAccessType
+	defaultEncryptionScope         string
+	preventEncryptionScopeOverride bool
+
+	accessTier string
+
+	permissions string
+	defaultACL  string
+
+	smbProperties azfile.SMBProperties
 }
 
-func (cookedArgs cookedMakeCmdArgs) process() (err error) {
+// process makes the resource. It tells whether the resource already existed, which is only the case with ifNotExists,
+// since otherwise that's an error.
+func (cookedArgs cookedMakeCmdArgs) process() (alreadyExists bool, err error) {
 	ctx := context.WithValue(context.TODO(), ste.ServiceAPIVersionOverride, ste.DefaultServiceApiVersion)
 
 	resourceStringParts, err := SplitResourceString(cookedArgs.resourceURL.String(), cookedArgs.resourceLocation)
 	if err != nil {
-		return err
+		return false, err
 	}
 
-	credentialInfo, _, err := GetCredentialInfoForLocation(ctx, cookedArgs.resourceLocation, resourceStringParts.Value, resourceStringParts.SAS, false, common.CpkOptions{})
+	credentialInfo, _, err := GetCredentialInfoForLocation(ctx, glcm, cookedArgs.resourceLocation, resourceStringParts.Value, resourceStringParts.SAS, false, common.CpkOptions{})
 	if err != nil {
-		return err
+		return false, err
 	}
 
 	switch cookedArgs.resourceLocation {
 	case common.ELocation.BlobFS():
-		p, err := createBlobFSPipeline(ctx, credentialInfo, pipeline2.LogNone)
+		p, err := createBlobFSPipeline(ctx, glcm, credentialInfo, pipeline2.LogNone)
 		if err != nil {
-			return err
+			return false, err
 		}
-		// here we assume the resourceURL is a proper file system URL
-		fsURL := azbfs.NewFileSystemURL(cookedArgs.resourceURL, p)
-		if _, err = fsURL.Create(ctx); err != nil {
-			// print a nicer error message if file system already exists
-			if storageErr, ok := err.(azbfs.StorageError); ok {
-				if storageErr.ServiceCode() == azbfs.ServiceCodeFileSystemAlreadyExists {
-					return fmt.Errorf("the file system already exists")
-				} else if storageErr.ServiceCode() == azbfs.ServiceCodeResourceNotFound {
-					return fmt.Errorf("please specify a valid file system URL with corresponding credentials")
-				}
+		return cookedArgs.makeBlobFS(ctx, p)
+	case common.ELocation.Blob():
+		p, err := createBlobPipeline(ctx, glcm, credentialInfo, pipeline2.LogNone)
+		if err != nil {
+			return false, err
+		}
+		return cookedArgs.makeContainer(ctx, p)
+	case common.ELocation.File():
+		p, err := createFilePipeline(ctx, glcm, credentialInfo, pipeline2.LogNone)
+		if err != nil {
+			return false, err
+		}
+		return cookedArgs.makeFile(ctx, p)
+	default:
+		return false, fmt.Errorf("operation not supported, cannot create resource %s type at the moment", cookedArgs.resourceURL.String())
+	}
+}
+
+// exists is what's returned when the resource to make exists already, which is an error unless ifNotExists is set.
+// The resource is left as it is either way.
+func (cookedArgs cookedMakeCmdArgs) exists(resource string) (bool, error) {
+	if cookedArgs.ifNotExists {
+		return true, nil
+	}
+	return false, fmt.Errorf("the %s already exists", resource)
+}
+
+// makeBlobFS makes the file system, if it doesn't exist yet, and then the directory, if one is given, which makes
+// any of its parents that don't exist yet too
+func (cookedArgs cookedMakeCmdArgs) makeBlobFS(ctx context.Context, p pipeline2.Pipeline) (bool, error) {
+	bfsURLParts := azbfs.NewBfsURLParts(cookedArgs.resourceURL)
+	bfsURLParts.DirectoryOrFilePath = ""
+	fsURL := azbfs.NewFileSystemURL(bfsURLParts.URL(), p)
+
+	fsExists := false
+	if _, err := fsURL.Create(ctx); err != nil {
+		// print a nicer error message if file system already exists
+		if storageErr, ok := err.(azbfs.StorageError); ok {
+			if storageErr.ServiceCode() == azbfs.ServiceCodeFileSystemAlreadyExists {
+				fsExists = true
+			} else if storageErr.ServiceCode() == azbfs.ServiceCodeResourceNotFound {
+				return false, fmt.Errorf("please specify a valid file system URL with corresponding credentials")
 			}
+		}
 
-			// print the ugly error if unexpected
-			return err
+		// print the ugly error if unexpected
+		if !fsExists {
+			return false, err
 		}
-	case common.ELocation.Blob():
-		p, err := createBlobPipeline(ctx, credentialInfo, pipeline2.LogNone)
-		if err != nil {
-			return err
+	}
+
+	dirURL := fsURL.NewRootDirectoryURL()
+	if cookedArgs.directoryPath == "" {
+		if fsExists {
+			return cookedArgs.exists("file system")
 		}
-		containerURL := azblob.NewContainerURL(cookedArgs.resourceURL, p)
-		if _, err = containerURL.Create(ctx, nil, azblob.PublicAccessNone); err != nil {
-			// print a nicer error message if container already exists
-			if storageErr, ok := err.(azblob.StorageError); ok {
-				if storageErr.ServiceCode() == azblob.ServiceCodeContainerAlreadyExists {
-					return fmt.Errorf("the container already exists")
-				} else if storageErr.ServiceCode() == azblob.ServiceCodeResourceNotFound {
-					return fmt.Errorf("please specify a valid container URL with account SAS")
-				}
+	} else {
+		dirURL = fsURL.NewDirectoryURL(cookedArgs.directoryPath)
+		if _, err := dirURL.CreateWithOptions(ctx, azbfs.CreateDirectoryOptions{Metadata: cookedArgs.metadata}); err != nil {
+			if storageErr, ok := err.(azbfs.StorageError); ok && storageErr.ServiceCode() == azbfs.ServiceCodePathAlreadyExists {
+				return cookedArgs.exists("directory")
 			}
+			return false, err
+		}
+	}
+
+	return false, cookedArgs.setAccessControl(ctx, dirURL)
+}
 
-			// print the ugly error if unexpected
+// setAccessControl sets the permissions of the directory, and then replaces the default entries of its ACL with
+// the default ACL, keeping the entries that are its own
+func (cookedArgs cookedMakeCmdArgs) setAccessControl(ctx context.Context, dirURL azbfs.DirectoryURL) error {
+	if cookedArgs.permissions != "" {
+		if _, err := dirURL.SetAccessControl(ctx, azbfs.BlobFSAccessControl{Permissions: cookedArgs.permissions}); err != nil {
 			return err
 		}
-	case common.ELocation.File():
-		p, err := createFilePipeline(ctx, credentialInfo, pipeline2.LogNone)
-		if err != nil {
-			return err
+	}
+
+	if cookedArgs.defaultACL == "" {
+		return nil
+	}
+
+	// the ACL is got after the permissions are set, since they're part of it
+	accessControl, err := dirURL.GetAccessControl(ctx)
+	if err != nil {
+		return err
+	}
+	var entries []string
+	for _, entry := range strings.Split(accessControl.ACL, ",") {
+		if entry != "" && !strings.HasPrefix(entry, "default:") {
+			entries = append(entries, entry)
 		}
-		shareURL := azfile.NewShareURL(cookedArgs.resourceURL, p)
-		if _, err = shareURL.Create(ctx, nil, cookedArgs.quota); err != nil {
-			// print a nicer error message if share already exists
-			if storageErr, ok := err.(azfile.StorageError); ok {
-				if storageErr.ServiceCode() == azfile.ServiceCodeShareAlreadyExists {
-					return fmt.Errorf("the file share already exists")
-				} else if storageErr.ServiceCode() == azfile.ServiceCodeResourceNotFound {
-					return fmt.Errorf("please specify a valid share URL with account SAS")
-				}
+	}
+	entries = append(entries, cookedArgs.defaultACL)
+
+	_, err = dirURL.SetAccessControl(ctx, azbfs.BlobFSAccessControl{ACL: strings.Join(entries, ",")})
+	return err
+}
+
+func (cookedArgs cookedMakeCmdArgs) makeContainer(ctx context.Context, p pipeline2.Pipeline) (bool, error) {
+	// ContainerURL.Create has no parameters for the encryption scope, so its headers are added to the request
+	if cookedArgs.defaultEncryptionScope != "" {
+		headers := map[string]string{"x-ms-default-encryption-scope": cookedArgs.defaultEncryptionScope}
+		if cookedArgs.preventEncryptionScopeOverride {
+			headers["x-ms-deny-encryption-scope-override"] = "true"
+		}
+		p = headerPipeline{Pipeline: p, headers: headers}
+	}
+
+	containerURL := azblob.NewContainerURL(cookedArgs.resourceURL, p)
+	if _, err := containerURL.Create(ctx, cookedArgs.metadata.ToAzBlobMetadata(), cookedArgs.publicAccess); err != nil {
+		// print a nicer error message if container already exists
+		if storageErr, ok := err.(azblob.StorageError); ok {
+			if storageErr.ServiceCode() == azblob.ServiceCodeContainerAlreadyExists {
+				return cookedArgs.exists("container")
+			} else if storageErr.ServiceCode() == azblob.ServiceCodeResourceNotFound {
+				return false, fmt.Errorf("please specify a valid container URL with account SAS")
 			}
+		}
 
-			// print the ugly error if unexpected
-			return err
+		// print the ugly error if unexpected
+		return false, err
+	}
+	return false, nil
+}
+
+// makeFile makes the share, if it doesn't exist yet, and then the directory, if one is given, and any of its parents
+// that don't exist yet. The metadata and SMB properties are those of the directory, rather than of its parents.
+func (cookedArgs cookedMakeCmdArgs) makeFile(ctx context.Context, p pipeline2.Pipeline) (bool, error) {
+	fileURLParts := azfile.NewFileURLParts(cookedArgs.resourceURL)
+	fileURLParts.DirectoryOrFilePath = ""
+	shareURL := azfile.NewShareURL(fileURLParts.URL(), p)
+
+	// ShareURL.Create has no parameter for the access tier, so its header is added to the request
+	createShareURL := shareURL
+	if cookedArgs.accessTier != "" {
+		createShareURL = shareURL.WithPipeline(headerPipeline{Pipeline: p, headers: map[string]string{"x-ms-access-tier": cookedArgs.accessTier}})
+	}
+
+	var shareMetadata common.Metadata
+	if cookedArgs.directoryPath == "" {
+		shareMetadata = cookedArgs.metadata
+	}
+
+	shareExists := false
+	if _, err := createShareURL.Create(ctx, shareMetadata.ToAzFileMetadata(), cookedArgs.quota); err != nil {
+		// print a nicer error message if share already exists
+		if storageErr, ok := err.(azfile.StorageError); ok {
+			if storageErr.ServiceCode() == azfile.ServiceCodeShareAlreadyExists {
+				shareExists = true
+			} else if storageErr.ServiceCode() == azfile.ServiceCodeResourceNotFound {
+				return false, fmt.Errorf("please specify a valid share URL with account SAS")
+			}
+		}
+
+		// print the ugly error if unexpected
+		if !shareExists {
+			return false, err
 		}
-	default:
-		return fmt.Errorf("operation not supported, cannot create resource %s type at the moment", cookedArgs.resourceURL.String())
 	}
-	return nil
+
+	if cookedArgs.directoryPath == "" {
+		if shareExists {
+			return cookedArgs.exists("file share")
+		}
+		return false, nil
+	}
+
+	// a directory can only be made in one that exists, so each is made in turn
+	dirURL := shareURL.NewRootDirectoryURL()
+	names := strings.Split(cookedArgs.directoryPath, "/")
+	for i, name := range names {
+		dirURL = dirURL.NewDirectoryURL(name)
+
+		var metadata common.Metadata
+		var properties azfile.SMBProperties
+		last := i == len(names)-1
+		if last {
+			metadata, properties = cookedArgs.metadata, cookedArgs.smbProperties
+		}
+
+		if _, err := dirURL.Create(ctx, metadata.ToAzFileMetadata(), properties); err != nil {
+			if storageErr, ok := err.(azfile.StorageError); ok && storageErr.ServiceCode() == azfile.ServiceCodeResourceAlreadyExists {
+				if last {
+					return cookedArgs.exists("directory")
+				}
+				continue
+			}
+			return false, err
+		}
+	}
+	return false, nil
+}
+
+// headerPipeline sets the given headers on each request that's sent through it, for the options of the requests
+// that the SDKs don't have parameters for
+type headerPipeline struct {
+	pipeline2.Pipeline
+	headers map[string]string
+}
+
+func (p headerPipeline) Do(ctx context.Context, methodFactory pipeline2.Factory, request pipeline2.Request) (pipeline2.Response, error) {
+	for key, value := range p.headers {
+		request.Header.Set(key, value)
+	}
+	return p.Pipeline.Do(ctx, methodFactory, request)
 }
 
 func init() {
@@ -173,17 +520,33 @@ func init() {
 				glcm.Error(err.Error())
 			}
 
-			err = cookedArgs.process()
+			alreadyExists, err := cookedArgs.process()
 			if err != nil {
 				glcm.Error(err.Error())
 			}
 
 			glcm.Exit(func(format common.OutputFormat) string {
+				if alreadyExists {
+					return "The resource already exists, so it was left as it is."
+				}
 				return "Successfully created the resource."
 			}, common.EExitCode.Success())
 		},
 	}
 
 	makeCmd.PersistentFlags().Uint32Var(&rawArgs.quota, "quota-gb", 0, "Specifies the maximum size of the share in gigabytes (GiB), 0 means you accept the file service's default quota.")
+	makeCmd.PersistentFlags().BoolVar(&rawArgs.ifNotExists, "if-not-exists", false, "Succeed, leaving the resource as it is, if it already exists. By default, that's an error.")
+	makeCmd.PersistentFlags().StringVar(&rawArgs.metadata, "metadata", "", "Set the metadata of the container, share or directory to these key-value pairs. For example: project=alpha;owner=data-team")
+
+	makeCmd.PersistentFlags().StringVar(&rawArgs.publicAccess, "public-access", "", "Specifies whether the container's data can be read anonymously: none, blob (only the blobs can be read) or container (the blobs can be listed too). (default 'none')")
+	makeCmd.PersistentFlags().StringVar(&rawArgs.defaultEncryptionScope, "default-encryption-scope", "", "The encryption scope that the blobs in the container are encrypted with, unless another is given when they're written.")
+	makeCmd.PersistentFlags().BoolVar(&rawArgs.preventEncryptionScopeOverride, "prevent-encryption-scope-override", false, "Encrypt all of the blobs in the container with the default encryption scope, rejecting writes that give another.")
+	makeCmd.PersistentFlags().StringVar(&rawArgs.accessTier, "access-tier", "", "The access tier of the share: TransactionOptimized, Hot or Cool for standard accounts, or Premium for premium ones.")
+
+	makeCmd.PersistentFlags().StringVar(&rawArgs.permissions, "permissions", "", "The POSIX permissions of the ADLS Gen2 directory, or of the root of the file system, in octal (e.g. 0750) or symbolic (e.g. rwxr-x---) notation.")
+	makeCmd.PersistentFlags().StringVar(&rawArgs.defaultACL, "default-acl", "", "The default ACL of the ADLS Gen2 directory, or of the root of the file system, which is inherited by what's created in it. "+
+		"For example: user::rwx,group::r-x,other::---,user:[object-id]:rwx")
+	makeCmd.PersistentFlags().StringVar(&rawArgs.fileAttributes, "file-attributes", "", "The SMB attributes of the Azure Files directory. For example: Hidden;ReadOnly")
+	makeCmd.PersistentFlags().StringVar(&rawArgs.filePermission, "file-permission", "", "The permissions of the Azure Files directory, as an SDDL string. By default, they're inherited from the directory it's made in.")
 	rootCmd.AddCommand(makeCmd)
 }
@@ -0,0 +1,61 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/Azure/azure-pipeline-go/pipeline"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/Azure/azure-storage-azcopy/v10/jobsAdmin"
+	"github.com/Azure/azure-storage-azcopy/v10/ste"
+)
+
+var metricsListenAddress string
+
+const metricsPath = "/metrics"
+
+// newMetricsHandler serves the metrics of the STE to Prometheus
+func newMetricsHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle(metricsPath, promhttp.HandlerFor(ste.Metrics, promhttp.HandlerOpts{}))
+	return mux
+}
+
+// serveMetrics starts serving the metrics on the given address, e.g. ":9090", for as long as the process runs.
+// It listens before it returns, so that a bad or busy address fails the command, rather than going unnoticed.
+func serveMetrics(address string) error {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return fmt.Errorf("cannot serve metrics on %s: %w", address, err)
+	}
+
+	server := &http.Server{Handler: newMetricsHandler()}
+	go func() {
+		_ = server.Serve(listener)
+	}()
+
+	jobsAdmin.JobsAdmin.LogToJobLog(fmt.Sprintf("Serving Prometheus metrics at http://%s%s", listener.Addr().String(), metricsPath), pipeline.LogInfo)
+	return nil
+}
@@ -0,0 +1,444 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/Azure/azure-pipeline-go/pipeline"
+	"github.com/Azure/azure-storage-file-go/azfile"
+	"github.com/spf13/cobra"
+
+	"github.com/Azure/azure-storage-azcopy/v10/azbfs"
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+	"github.com/Azure/azure-storage-azcopy/v10/ste"
+)
+
+func init() {
+	raw := rawCopyCmdArgs{deleteSourceOnSuccess: true, preserveOwner: common.PreserveOwnerDefault}
+	// moveCmd represents the move command
+	moveCmd := &cobra.Command{
+		Use:     "move [source] [destination]",
+		Aliases: []string{"mv"},
+		Short:   moveCmdShortDescription,
+		Long:    moveCmdLongDescription,
+		Example: moveCmdExample,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 2 {
+				return fmt.Errorf("move command only takes 2 arguments. Passed %d arguments", len(args))
+			}
+			raw.src = args[0]
+			raw.dst = args[1]
+
+			// as with copy, we may ask the user questions such as whether to overwrite a file
+			glcm.EnableInputWatcher()
+			if cancelFromStdin {
+				glcm.EnableCancelFromStdIn()
+			}
+			return nil
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			rename, err := newServerSideRename(raw)
+			if err != nil {
+				glcm.Error("failed to parse user input due to error: " + err.Error())
+			}
+			if rename != nil {
+				ctx := context.WithValue(context.TODO(), ste.ServiceAPIVersionOverride, ste.DefaultServiceApiVersion)
+				message, err := rename.do(ctx)
+				if err != nil {
+					glcm.Error("failed to perform move command due to error: " + err.Error())
+				}
+				rename.exit(message)
+				return // explicitly return, since in our tests Exit might be mocked away
+			}
+
+			cooked, err := raw.cook()
+			if err != nil {
+				glcm.Error("failed to parse user input due to error: " + err.Error())
+			}
+
+			glcm.Info("Scanning...")
+
+			cooked.commandString = copyHandlerUtil{}.ConstructCommandStringFromArgs()
+			err = cooked.process()
+			if err != nil {
+				glcm.Error("failed to perform move command due to error: " + err.Error())
+			}
+
+			if cooked.dryrunMode {
+				glcm.Exit(nil, common.EExitCode.Success())
+			}
+
+			glcm.SurrenderControl()
+		},
+	}
+	rootCmd.AddCommand(moveCmd)
+
+	// filters change which files get moved, and stop the source from being renamed
+	moveCmd.PersistentFlags().BoolVar(&raw.recursive, "recursive", false, "Look into sub-directories recursively, and move whole directories.")
+	moveCmd.PersistentFlags().StringVar(&raw.include, "include-pattern", "", "Include only these files when moving. This option supports wildcard characters (*). Separate files by using a ';'.")
+	moveCmd.PersistentFlags().StringVar(&raw.includePath, "include-path", "", "Include only these paths when moving. "+
+		"This option does not support wildcard characters (*). Checks relative path prefix (For example: myFolder;myFolder/subDirName/file.pdf).")
+	moveCmd.PersistentFlags().StringVar(&raw.exclude, "exclude-pattern", "", "Exclude these files when moving. This option supports wildcard characters (*)")
+	moveCmd.PersistentFlags().StringVar(&raw.excludePath, "exclude-path", "", "Exclude these paths when moving. "+
+		"This option does not support wildcard characters (*). Checks relative path prefix (For example: myFolder;myFolder/subDirName/file.pdf).")
+	moveCmd.PersistentFlags().StringVar(&raw.includeRegex, "include-regex", "", "Include only the relative path of the files that align with regular expressions. Separate regular expressions with ';'.")
+	moveCmd.PersistentFlags().StringVar(&raw.excludeRegex, "exclude-regex", "", "Exclude all the relative path of the files that align with regular expressions. Separate regular expressions with ';'.")
+	moveCmd.PersistentFlags().StringVar(&raw.includeBefore, common.IncludeBeforeFlagName, "", "Include only those files modified before or on the given date/time. The value should be in ISO8601 format. If no timezone is specified, the value is assumed to be in the local timezone of the machine running AzCopy. E.g. '2020-08-19T15:04:00Z' for a UTC time, or '2020-08-19' for midnight (00:00) in the local timezone.")
+	moveCmd.PersistentFlags().StringVar(&raw.includeAfter, common.IncludeAfterFlagName, "", "Include only those files modified on or after the given date/time. The value should be in ISO8601 format. If no timezone is specified, the value is assumed to be in the local timezone of the machine running AzCopy. E.g. '2020-08-19T15:04:00Z' for a UTC time, or '2020-08-19' for midnight (00:00) in the local timezone.")
+	moveCmd.PersistentFlags().StringVar(&raw.excludeBlobType, "exclude-blob-type", "", "Optionally specifies the type of blob (BlockBlob/ PageBlob/ AppendBlob) to exclude when moving blobs. More than one blob should be separated by ';'. ")
+	moveCmd.PersistentFlags().StringVar(&raw.listOfFilesToCopy, "list-of-files", "", "Defines the location of text file which has the list of only files to be moved.")
+
+	// options change how the files are transferred
+	moveCmd.PersistentFlags().StringVar(&raw.forceWrite, "overwrite", "true", "Overwrite the conflicting files and blobs at the destination if this flag is set to true. (default 'true') Possible values include 'true', 'false', 'prompt', and 'ifSourceNewer'. Sources that aren't transferred, because they conflict, aren't deleted.")
+	moveCmd.PersistentFlags().BoolVar(&raw.forceIfReadOnly, "force-if-read-only", false, "When overwriting an existing file on Windows or Azure Files, force the overwrite to work even if the existing file has its read-only attribute set")
+	moveCmd.PersistentFlags().StringVar(&raw.fromTo, "from-to", "", "Optionally specifies the source destination combination. For Example: LocalBlob, BlobLocal, BlobBlob. Giving it means the source isn't renamed.")
+	moveCmd.PersistentFlags().StringVar(&raw.logVerbosity, "log-level", "INFO", "Define the log verbosity for the log file, available levels: INFO(all requests/responses), WARNING(slow responses), ERROR(only failed requests), and NONE(no output logs). (default 'INFO').")
+	moveCmd.PersistentFlags().Float64Var(&raw.blockSizeMB, "block-size-mb", 0, "Use this block size (specified in MiB) when uploading to Azure Storage, and downloading from Azure Storage. The default value is automatically calculated based on file size. Decimal fractions are allowed (For example: 0.25).")
+	moveCmd.PersistentFlags().StringVar(&raw.blobType, "blob-type", "Detect", "Defines the type of blob at the destination. (default 'Detect'). Valid values include 'Detect', 'BlockBlob', 'PageBlob', and 'AppendBlob'.")
+	moveCmd.PersistentFlags().StringVar(&raw.blockBlobTier, "block-blob-tier", "None", "Move block blobs to Azure Storage using this blob tier.")
+	moveCmd.PersistentFlags().StringVar(&raw.pageBlobTier, "page-blob-tier", "None", "Move page blobs to Azure Storage using this blob tier. (default 'None').")
+	moveCmd.PersistentFlags().BoolVar(&raw.putMd5, "put-md5", false, "Create an MD5 hash of each file, and save the hash as the Content-MD5 property of the destination blob or file. Only available when uploading.")
+	moveCmd.PersistentFlags().StringVar(&raw.md5ValidationOption, "check-md5", common.DefaultHashValidationOption.String(), "Specifies how strictly MD5 hashes should be validated when downloading. Only available when downloading. Available options: NoCheck, LogOnly, FailIfDifferent, FailIfDifferentOrMissing. (default 'FailIfDifferent')")
+	moveCmd.PersistentFlags().BoolVar(&raw.preserveSMBPermissions, "preserve-smb-permissions", false, "False by default. Preserves SMB ACLs between aware resources (Windows and Azure Files).")
+	moveCmd.PersistentFlags().BoolVar(&raw.preserveSMBInfo, "preserve-smb-info", true, "For SMB-aware locations, flag will be set to true by default. Preserves SMB property info (last write time, creation time, attribute bits) between SMB-aware resources (Windows and Azure Files).")
+	moveCmd.PersistentFlags().BoolVar(&raw.asSubdir, "as-subdir", true, "True by default. Places folder sources as subdirectories under the destination.")
+	moveCmd.PersistentFlags().BoolVar(&raw.s2sPreserveProperties, "s2s-preserve-properties", true, "Preserve full properties during service to service moves.")
+	moveCmd.PersistentFlags().BoolVar(&raw.s2sPreserveAccessTier, "s2s-preserve-access-tier", true, "Preserve access tier during service to service moves.")
+	moveCmd.PersistentFlags().BoolVar(&raw.s2sPreserveBlobTags, "s2s-preserve-blob-tags", false, "Preserve index tags during service to service moves from one blob storage to another")
+	moveCmd.PersistentFlags().BoolVar(&raw.s2sSourceChangeValidation, "s2s-detect-source-changed", false, "Detect if the source file/blob changes while it is being read. (This parameter only applies to service to service moves, because the corresponding check is permanently enabled for uploads and downloads.)")
+	moveCmd.PersistentFlags().StringVar(&raw.s2sInvalidMetadataHandleOption, "s2s-handle-invalid-metadata", common.DefaultInvalidMetadataHandleOption.String(), "Specifies how invalid metadata keys are handled. Available options: ExcludeIfInvalid, FailIfInvalid, RenameIfInvalid. (default 'ExcludeIfInvalid').")
+	moveCmd.PersistentFlags().BoolVar(&raw.s2sGetPropertiesInBackend, "s2s-get-properties-in-backend", true, "get S3 objects' or Azure files' properties in backend, if properties need to be accessed.")
+	moveCmd.PersistentFlags().StringVar(&raw.jobPriority, "job-priority", "Normal", "Share of the transfer engine this job gets when other jobs run in the same process (e.g. in 'azcopy daemon'): Low, Normal or High.")
+	moveCmd.PersistentFlags().StringVar(&raw.transferEvents, "transfer-events", "", "Writes an event, as a line of JSON, as each transfer completes, fails or is skipped: to the standard output if this option is set to 'stdout', or else to the given file or named pipe.")
+	moveCmd.PersistentFlags().StringVar(&raw.hookURL, "hook-url", "", "URL to POST a JSON description of the job to, as it starts and ends and as transfers fail. See --hook-events.")
+	moveCmd.PersistentFlags().StringVar(&raw.hookCommand, "hook-command", "", "Command to run, with a shell, as the job starts and ends and as transfers fail. "+
+		"It's given the JSON that --hook-url is sent on its standard input, and the gist of it in environment variables such as AZCOPY_HOOK_EVENT, AZCOPY_JOB_ID and AZCOPY_JOB_STATUS.")
+	moveCmd.PersistentFlags().StringVar(&raw.hookEvents, "hook-events", "", "Comma-separated list of the events that the hooks are run for: JobStarted, JobCompleted, JobCancelled and TransferFailed. By default, they are run for all of them.")
+	moveCmd.PersistentFlags().BoolVar(&raw.dryrun, "dry-run", false, "Prints the paths that would be moved, or renamed, by this command. This flag does not move them.")
+
+	// permanently hidden, as they are for copy
+	moveCmd.PersistentFlags().MarkHidden("list-of-files")
+	moveCmd.PersistentFlags().MarkHidden("s2s-get-properties-in-backend")
+}
+
+// validateMove checks that the sources of a move can be deleted, once they've been transferred
+func validateMove(cooked CookedCopyCmdArgs, listsVersions bool) error {
+	switch from := cooked.FromTo.From(); from {
+	case common.ELocation.Local(), common.ELocation.Blob(), common.ELocation.File(), common.ELocation.BlobFS():
+	default:
+		return fmt.Errorf("cannot move from %s, since AzCopy doesn't delete the sources there", from)
+	}
+
+	if cooked.isRedirection() || cooked.Destination.Value == common.Dev_Null {
+		return errors.New("cannot move to a pipe or to " + common.Dev_Null)
+	}
+	if listsVersions {
+		return errors.New("cannot move the versions of a blob, only the blob itself")
+	}
+	return nil
+}
+
+// serverSideRename is a move that's done by renaming the source, which the service does for a whole directory at once.
+// It's possible within an ADLS Gen2 filesystem or an Azure Files share.
+type serverSideRename struct {
+	location        common.Location
+	source          common.ResourceString
+	destination     common.ResourceString
+	recursive       bool
+	overwrite       common.OverwriteOption
+	forceIfReadOnly bool
+	logLevel        common.LogLevel
+	dryrun          bool
+}
+
+// newServerSideRename returns the rename that does the move, or nil if the move must be done by copying
+func newServerSideRename(raw rawCopyCmdArgs) (*serverSideRename, error) {
+	location := InferArgumentLocation(raw.src)
+	if raw.fromTo != "" || location != InferArgumentLocation(raw.dst) ||
+		(location != common.ELocation.BlobFS() && location != common.ELocation.File()) {
+		return nil, nil
+	}
+
+	// there are no BlobFS to BlobFS copies, so the paths in a filesystem can only be moved by renaming them
+	unrenamable := func() (*serverSideRename, error) {
+		if location == common.ELocation.BlobFS() {
+			return nil, errors.New("ADLS Gen2 paths given by their dfs endpoints can only be moved within a filesystem, without filters. " +
+				"To move them elsewhere, give their blob endpoints (e.g. https://[account].blob.core.windows.net/[filesystem]/[path])")
+		}
+		return nil, nil
+	}
+
+	// filters pick some of the files out of a directory, which can't be done by renaming it, and prompting and comparing
+	// modification times are done file by file, by copy
+	if raw.include != "" || raw.exclude != "" || raw.includePath != "" || raw.excludePath != "" || raw.includeRegex != "" ||
+		raw.excludeRegex != "" || raw.includeBefore != "" || raw.includeAfter != "" || raw.excludeBlobType != "" ||
+		raw.listOfFilesToCopy != "" || strings.Contains(raw.src, "*") {
+		return unrenamable()
+	}
+	var overwrite common.OverwriteOption
+	if err := overwrite.Parse(raw.forceWrite); err != nil {
+		return nil, err
+	}
+	if overwrite != common.EOverwriteOption.True() && overwrite != common.EOverwriteOption.False() {
+		return unrenamable()
+	}
+
+	source, err := SplitResourceString(raw.src, location)
+	if err != nil {
+		return nil, err
+	}
+	destination, err := SplitResourceString(raw.dst, location)
+	if err != nil {
+		return nil, err
+	}
+	srcURL, err := source.FullURL()
+	if err != nil {
+		return nil, err
+	}
+	dstURL, err := destination.FullURL()
+	if err != nil {
+		return nil, err
+	}
+
+	switch location {
+	case common.ELocation.BlobFS():
+		s, d := azbfs.NewBfsURLParts(*srcURL), azbfs.NewBfsURLParts(*dstURL)
+		if !strings.EqualFold(s.Host, d.Host) || s.FileSystemName != d.FileSystemName || s.DirectoryOrFilePath == "" {
+			return unrenamable()
+		}
+	case common.ELocation.File():
+		s, d := azfile.NewFileURLParts(*srcURL), azfile.NewFileURLParts(*dstURL)
+		if !strings.EqualFold(s.Host, d.Host) || s.ShareName != d.ShareName || s.ShareSnapshot != "" || s.DirectoryOrFilePath == "" {
+			return unrenamable()
+		}
+	}
+
+	rename := &serverSideRename{
+		location:        location,
+		source:          source,
+		destination:     destination,
+		recursive:       raw.recursive,
+		overwrite:       overwrite,
+		forceIfReadOnly: raw.forceIfReadOnly,
+		dryrun:          raw.dryrun,
+	}
+	if err = rename.logLevel.Parse(raw.logVerbosity); err != nil {
+		return nil, err
+	}
+	return rename, nil
+}
+
+// do renames the source, unless it's a dry run, and returns what it did
+func (r *serverSideRename) do(ctx context.Context) (string, error) {
+	srcURL, err := r.source.FullURL()
+	if err != nil {
+		return "", err
+	}
+	dstURL, err := r.destination.FullURL()
+	if err != nil {
+		return "", err
+	}
+
+	var renamedTo url.URL
+	if r.location == common.ELocation.BlobFS() {
+		renamedTo, err = r.renameBlobFS(ctx, *srcURL, *dstURL)
+	} else {
+		renamedTo, err = r.renameFile(ctx, *srcURL, *dstURL)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s %s to %s", common.IffString(r.dryrun, "DRYRUN: rename", "Renamed"),
+		withoutQuery(*srcURL), withoutQuery(renamedTo)), nil
+}
+
+func (r *serverSideRename) renameBlobFS(ctx context.Context, srcURL, dstURL url.URL) (url.URL, error) {
+	credInfo, _, err := GetCredentialInfoForLocation(ctx, glcm, common.ELocation.BlobFS(), r.source.Value, r.source.SAS, false, common.CpkOptions{})
+	if err != nil {
+		return url.URL{}, err
+	}
+	p, err := createBlobFSPipeline(ctx, glcm, credInfo, r.logLevel.ToPipelineLogLevel())
+	if err != nil {
+		return url.URL{}, err
+	}
+
+	isDir, err := azbfs.NewDirectoryURL(srcURL, p).IsDirectory(ctx)
+	if err != nil {
+		return url.URL{}, fmt.Errorf("cannot get the properties of the source: %w", err)
+	}
+	if isDir && !r.recursive {
+		return url.URL{}, errors.New("cannot move a directory without --recursive")
+	}
+
+	// as with copy, a source that's moved to an existing directory goes into it
+	srcParts, dstParts := azbfs.NewBfsURLParts(srcURL), azbfs.NewBfsURLParts(dstURL)
+	if dstIsDir, _ := azbfs.NewDirectoryURL(dstURL, p).IsDirectory(ctx); dstIsDir {
+		dstParts.DirectoryOrFilePath = path.Join(dstParts.DirectoryOrFilePath, path.Base(strings.TrimSuffix(srcParts.DirectoryOrFilePath, "/")))
+	}
+	renamedTo := dstParts.URL()
+	failIfExists := r.overwrite == common.EOverwriteOption.False()
+
+	if r.dryrun {
+		if _, err = azbfs.NewFileURL(renamedTo, p).GetProperties(ctx); err == nil && failIfExists {
+			return url.URL{}, errDestinationExists
+		}
+		return renamedTo, nil
+	}
+	var destinationSas *string
+	if r.destination.SAS != "" {
+		destinationSas = &r.destination.SAS
+	}
+	// with --overwrite=false, the service checks that the destination doesn't exist, in the same request as the rename
+	if isDir {
+		_, err = azbfs.NewDirectoryURL(srcURL, p).Rename(ctx, azbfs.RenameDirectoryOptions{DestinationPath: dstParts.DirectoryOrFilePath, DestinationSas: destinationSas, FailIfExists: failIfExists})
+	} else {
+		_, err = azbfs.NewFileURL(srcURL, p).Rename(ctx, azbfs.RenameFileOptions{DestinationPath: dstParts.DirectoryOrFilePath, DestinationSas: destinationSas, FailIfExists: failIfExists})
+	}
+	if stgErr, ok := err.(azbfs.StorageError); ok && failIfExists &&
+		(stgErr.ServiceCode() == "PathAlreadyExists" || stgErr.Response().StatusCode == http.StatusPreconditionFailed) {
+		return url.URL{}, errDestinationExists
+	}
+	return renamedTo, err
+}
+
+func (r *serverSideRename) renameFile(ctx context.Context, srcURL, dstURL url.URL) (url.URL, error) {
+	p, err := createFilePipeline(ctx, glcm, common.CredentialInfo{}, r.logLevel.ToPipelineLogLevel())
+	if err != nil {
+		return url.URL{}, err
+	}
+
+	_, err = azfile.NewDirectoryURL(srcURL, p).GetProperties(ctx)
+	isDir := err == nil
+	if !isDir {
+		if _, err = azfile.NewFileURL(srcURL, p).GetProperties(ctx); err != nil {
+			return url.URL{}, fmt.Errorf("cannot get the properties of the source: %w", err)
+		}
+	}
+	if isDir && !r.recursive {
+		return url.URL{}, errors.New("cannot move a directory without --recursive")
+	}
+
+	// as with copy, a source that's moved to an existing directory goes into it
+	srcParts, dstParts := azfile.NewFileURLParts(srcURL), azfile.NewFileURLParts(dstURL)
+	if _, err = azfile.NewDirectoryURL(dstURL, p).GetProperties(ctx); err == nil {
+		dstParts.DirectoryOrFilePath = path.Join(dstParts.DirectoryOrFilePath, path.Base(strings.TrimSuffix(srcParts.DirectoryOrFilePath, "/")))
+	}
+	renamedTo := dstParts.URL()
+	failIfExists := r.overwrite == common.EOverwriteOption.False()
+
+	if r.dryrun {
+		if _, err = azfile.NewFileURL(renamedTo, p).GetProperties(ctx); err == nil && failIfExists {
+			return url.URL{}, errDestinationExists
+		}
+		return renamedTo, nil
+	}
+	// with --overwrite=false, the service checks that the destination doesn't exist, in the same request as the rename
+	return renamedTo, renameAzureFile(ctx, p, srcURL, renamedTo, isDir, !failIfExists, r.forceIfReadOnly)
+}
+
+var errDestinationExists = errors.New("the destination already exists, and --overwrite is false")
+
+// fileRenameServiceVersion is the first version of the Azure Files REST API with Rename File and Rename Directory,
+// which the version of azfile that we use doesn't have
+const fileRenameServiceVersion = "2021-04-10"
+
+// renameAzureFile renames a file or directory within its share, by calling Rename File or Rename Directory
+func renameAzureFile(ctx context.Context, p pipeline.Pipeline, source, destination url.URL, isDirectory, replaceIfExists, ignoreReadOnly bool) error {
+	query := destination.Query()
+	if isDirectory {
+		query.Set("restype", "directory")
+	}
+	query.Set("comp", "rename")
+	destination.RawQuery = query.Encode()
+
+	req, err := pipeline.NewRequest(http.MethodPut, destination, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("x-ms-file-rename-source", source.String())
+	req.Header.Set("x-ms-file-rename-replace-if-exists", strconv.FormatBool(replaceIfExists))
+	if ignoreReadOnly {
+		req.Header.Set("x-ms-file-rename-ignore-readonly", "true")
+	}
+
+	// the version policy of the pipeline sets the version that's in the context
+	resp, err := p.Do(context.WithValue(ctx, ste.ServiceAPIVersionOverride, fileRenameServiceVersion), nil, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Response().Body.Close()
+
+	if resp.Response().StatusCode == http.StatusConflict && !replaceIfExists &&
+		resp.Response().Header.Get("x-ms-error-code") == "ResourceAlreadyExists" {
+		return errDestinationExists
+	}
+	if resp.Response().StatusCode != http.StatusOK {
+		return fmt.Errorf("the rename failed with status %s and error code %s", resp.Response().Status, resp.Response().Header.Get("x-ms-error-code"))
+	}
+	return nil
+}
+
+// exit reports the rename as a job with a single transfer, as removeBfsResources does
+func (r *serverSideRename) exit(message string) {
+	if r.dryrun {
+		glcm.Dryrun(func(format common.OutputFormat) string {
+			if format == common.EOutputFormat.Json() {
+				jsonOutput, err := json.Marshal(struct{ Rename string }{message})
+				common.PanicIfErr(err)
+				return string(jsonOutput)
+			}
+			return message
+		})
+		glcm.Exit(nil, common.EExitCode.Success())
+		return
+	}
+
+	glcm.Exit(func(format common.OutputFormat) string {
+		if format == common.EOutputFormat.Json() {
+			summary := common.ListJobSummaryResponse{
+				JobStatus:          common.EJobStatus.Completed(),
+				TotalTransfers:     1,
+				TransfersCompleted: 1,
+				PercentComplete:    100,
+			}
+			jsonOutput, err := json.Marshal(summary)
+			common.PanicIfErr(err)
+			return string(jsonOutput)
+		}
+		return message
+	}, common.EExitCode.Success())
+}
+
+// withoutQuery returns the URL without its query, and so without its SAS
+func withoutQuery(u url.URL) string {
+	u.RawQuery = ""
+	return u.String()
+}
@@ -100,6 +100,7 @@ func GetResourceRoot(resource string, location common.Location) (resourceBase st
 	// todo: reduce code-delicateness, maybe?
 	switch location {
 	case common.ELocation.Unknown(),
+		common.ELocation.None(),
 		common.ELocation.Benchmark(): // do nothing
 		return resource, nil
 	case common.ELocation.Local():
@@ -221,7 +222,8 @@ func splitAuthTokenFromResource(resource string, location common.Location) (reso
 	case common.ELocation.GCP():
 		return resource, "", nil
 	case common.ELocation.Benchmark(), // cover for benchmark as we generate data for that
-		common.ELocation.Unknown(): // cover for unknown as we treat that as garbage
+		common.ELocation.Unknown(), // cover for unknown as we treat that as garbage
+		common.ELocation.None():    // and there's nothing at none
 		// Local and S3 don't feature URL-embedded tokens
 		return resource, "", nil
 
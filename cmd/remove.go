@@ -27,6 +27,34 @@ import (
 	"strings"
 )
 
+// setRemoveDefaults infers the from-to of a removal from its target, and sets the options that remove always uses
+func (raw *rawCopyCmdArgs) setRemoveDefaults() error {
+	srcLocationType := InferArgumentLocation(raw.src)
+	if raw.fromTo == "" {
+		switch srcLocationType {
+		case common.ELocation.Blob():
+			raw.fromTo = common.EFromTo.BlobTrash().String()
+		case common.ELocation.File():
+			raw.fromTo = common.EFromTo.FileTrash().String()
+		case common.ELocation.BlobFS():
+			raw.fromTo = common.EFromTo.BlobFSTrash().String()
+		default:
+			return fmt.Errorf("invalid source type %s to delete. azcopy support removing blobs/files/adls gen2", srcLocationType.String())
+		}
+	} else if raw.fromTo != "" {
+		err := strings.Contains(raw.fromTo, "Trash")
+		if !err {
+			return fmt.Errorf("Invalid destination. Please enter a valid destination, i.e. BlobTrash, FileTrash, BlobFSTrash")
+		}
+	}
+	raw.setMandatoryDefaults()
+
+	// in the case of remove, we are fairly certain that the user wants all the blobs to be removed
+	// and this includes the stubs that represent directories (with metadata 'hdi_isfolder = true')
+	raw.includeDirectoryStubs = true
+	return nil
+}
+
 func init() {
 	raw := rawCopyCmdArgs{}
 	// deleteCmd represents the delete command
@@ -44,32 +72,7 @@ func init() {
 
 			// the resource to delete is set as the source
 			raw.src = args[0]
-
-			srcLocationType := InferArgumentLocation(raw.src)
-			if raw.fromTo == "" {
-				switch srcLocationType {
-				case common.ELocation.Blob():
-					raw.fromTo = common.EFromTo.BlobTrash().String()
-				case common.ELocation.File():
-					raw.fromTo = common.EFromTo.FileTrash().String()
-				case common.ELocation.BlobFS():
-					raw.fromTo = common.EFromTo.BlobFSTrash().String()
-				default:
-					return fmt.Errorf("invalid source type %s to delete. azcopy support removing blobs/files/adls gen2", srcLocationType.String())
-				}
-			} else if raw.fromTo != "" {
-				err := strings.Contains(raw.fromTo, "Trash")
-				if !err {
-					return fmt.Errorf("Invalid destination. Please enter a valid destination, i.e. BlobTrash, FileTrash, BlobFSTrash")
-				}
-			}
-			raw.setMandatoryDefaults()
-
-			// in the case of remove, we are fairly certain that the user wants all the blobs to be removed
-			// and this includes the stubs that represent directories (with metadata 'hdi_isfolder = true')
-			raw.includeDirectoryStubs = true
-
-			return nil
+			return raw.setRemoveDefaults()
 		},
 		Run: func(cmd *cobra.Command, args []string) {
 			glcm.EnableInputWatcher()
@@ -112,8 +115,23 @@ func init() {
 	deleteCmd.PersistentFlags().BoolVar(&raw.forceIfReadOnly, "force-if-read-only", false, "When deleting an Azure Files file or folder, force the deletion to work even if the existing object is has its read-only attribute set")
 	deleteCmd.PersistentFlags().StringVar(&raw.listOfFilesToCopy, "list-of-files", "", "Defines the location of a file which contains the list of files and directories to be deleted. The relative paths should be delimited by line breaks, and the paths should NOT be URL-encoded.")
 	deleteCmd.PersistentFlags().StringVar(&raw.deleteSnapshotsOption, "delete-snapshots", "", "By default, the delete operation fails if a blob has snapshots. Specify 'include' to remove the root blob and all its snapshots; alternatively specify 'only' to remove only the snapshots but keep the root blob.")
+	deleteCmd.PersistentFlags().StringVar(&raw.includeBefore, common.IncludeBeforeFlagName, "", "Remove only those files modified before or on the given date/time. The value should be in ISO8601 format. If no timezone is specified, the value is assumed to be in the local timezone of the machine running AzCopy. E.g. '2020-08-19T15:04:00Z' for a UTC time, or '2020-08-19' for midnight (00:00) in the local timezone.")
+	deleteCmd.PersistentFlags().StringVar(&raw.includeAfter, common.IncludeAfterFlagName, "", "Remove only those files modified on or after the given date/time. The value should be in ISO8601 format. If no timezone is specified, the value is assumed to be in the local timezone of the machine running AzCopy. E.g. '2020-08-19T15:04:00Z' for a UTC time, or '2020-08-19' for midnight (00:00) in the local timezone.")
+	deleteCmd.PersistentFlags().StringVar(&raw.olderThan, "older-than", "", "Remove only those files modified at least this long ago, given in days (e.g. 30d), weeks (e.g. 2w) or hours (e.g. 36h). "+
+		"With --purge, it's the age of each version or snapshot instead.")
+	deleteCmd.PersistentFlags().StringVar(&raw.purge, "purge", "", "Remove the earlier versions ('versions'), or the snapshots ('snapshots'), of the blobs instead of the blobs themselves. "+
+		"The current version of each blob is always kept. Combine it with --older-than or --include-before to purge only those taken before then, and with --keep-latest to keep the latest of them regardless of their age.")
+	deleteCmd.PersistentFlags().IntVar(&raw.keepLatest, "keep-latest", 0, "With --purge, keep this many of the latest earlier versions, or snapshots, of each blob.")
 	deleteCmd.PersistentFlags().StringVar(&raw.listOfVersionIDs, "list-of-versions", "", "Specifies a file where each version id is listed on a separate line. Ensure that the source must point to a single blob and all the version ids specified in the file using this flag must belong to the source blob only. Specified version ids of the given blob will get deleted from Azure Storage.")
-	deleteCmd.PersistentFlags().BoolVar(&raw.dryrun, "dry-run", false, "Prints the path files that would be removed by the command. This flag does not trigger the removal of the files.")
+	deleteCmd.PersistentFlags().StringVar(&raw.transferEvents, "transfer-events", "", "Writes an event, as a line of JSON, as each removal completes, fails or is skipped: to the standard output if this option is set to 'stdout', or else to the given file or named pipe.")
+	deleteCmd.PersistentFlags().StringVar(&raw.hookURL, "hook-url", "", "URL to POST a JSON description of the job to, as it starts and ends and as transfers fail. See --hook-events.")
+	deleteCmd.PersistentFlags().StringVar(&raw.hookCommand, "hook-command", "", "Command to run, with a shell, as the job starts and ends and as transfers fail. "+
+		"It's given the JSON that --hook-url is sent on its standard input, and the gist of it in environment variables such as AZCOPY_HOOK_EVENT, AZCOPY_JOB_ID and AZCOPY_JOB_STATUS.")
+	deleteCmd.PersistentFlags().StringVar(&raw.hookEvents, "hook-events", "", "Comma-separated list of the events that the hooks are run for: JobStarted, JobCompleted, JobCancelled and TransferFailed. By default, they are run for all of them.")
+	deleteCmd.PersistentFlags().BoolVar(&raw.dryrun, "dry-run", false, "Prints the path files that would be removed by the command, followed by how many would be removed and kept when filtering by age or purging. This flag does not trigger the removal of the files.")
 	deleteCmd.PersistentFlags().StringVar(&raw.fromTo, "from-to", "", "Optionally specifies the source destination combination. For Example: BlobTrash, FileTrash, BlobFSTrash")
 	deleteCmd.PersistentFlags().StringVar(&raw.permanentDeleteOption, "permanent-delete", "none", "This is a preview feature that PERMANENTLY deletes soft-deleted snapshots/versions. Possible values include 'snapshots', 'versions', 'snapshotsandversions', 'none'.")
+
+	// the defaults of the flags, for the removals that are run in-process
+	rawRemoveDefaults = raw
 }
@@ -26,13 +26,14 @@ import (
 	"errors"
 	"fmt"
 	"github.com/Azure/azure-storage-azcopy/v10/jobsAdmin"
+	"net/url"
 	"strings"
+	"time"
 
 	"github.com/Azure/azure-pipeline-go/pipeline"
 
 	"github.com/Azure/azure-storage-azcopy/v10/azbfs"
 	"github.com/Azure/azure-storage-azcopy/v10/common"
-	"github.com/Azure/azure-storage-azcopy/v10/ste"
 )
 
 var NothingToRemoveError = errors.New("nothing found to remove")
@@ -41,14 +42,15 @@ var NothingToRemoveError = errors.New("nothing found to remove")
 // and schedule delete transfers to remove them
 // TODO: Make this merge into the other copy refactor code
 // TODO: initEnumerator is significantly more verbose at this point, evaluate the impact of switching over
-func newRemoveEnumerator(cca *CookedCopyCmdArgs) (enumerator *CopyEnumerator, err error) {
+func newRemoveEnumerator(ctx context.Context, cca *CookedCopyCmdArgs) (enumerator *CopyEnumerator, err error) {
 	var sourceTraverser ResourceTraverser
 
-	ctx := context.WithValue(context.TODO(), ste.ServiceAPIVersionOverride, ste.DefaultServiceApiVersion)
+	// the date filters need the last modified times, which Azure Files only gives with the rest of the properties
+	filtersByDate := cca.IncludeBefore != nil || cca.IncludeAfter != nil
 
 	// Include-path is handled by ListOfFilesChannel.
 	sourceTraverser, err = InitResourceTraverser(cca.Source, cca.FromTo.From(), &ctx, &cca.credentialInfo,
-		nil, cca.ListOfFilesChannel, cca.Recursive, false, cca.IncludeDirectoryStubs,
+		nil, cca.ListOfFilesChannel, cca.Recursive, filtersByDate, cca.IncludeDirectoryStubs,
 		cca.permanentDeleteOption, func(common.EntityType) {}, cca.ListOfVersionIDs, false,
 		cca.LogVerbosity.ToPipelineLogLevel(), cca.CpkOptions)
 
@@ -57,6 +59,17 @@ func newRemoveEnumerator(cca *CookedCopyCmdArgs) (enumerator *CopyEnumerator, er
 		return nil, err
 	}
 
+	if cca.purge != "" {
+		// the versions, or snapshots, of each blob must be listed together, which only the flat listing does
+		blobTraverser, ok := sourceTraverser.(*blobTraverser)
+		if !ok || !blobTraverser.IsDirectory(true) {
+			return nil, errors.New("purge removes the versions or snapshots of the blobs in a container or virtual directory, so the source must be one of those")
+		}
+		blobTraverser.includeVersion = cca.purge == purgeVersions
+		blobTraverser.includeSnapshot = cca.purge == purgeSnapshots
+		blobTraverser.parallelListing = false
+	}
+
 	includeFilters := buildIncludeFilters(cca.IncludePatterns)
 	excludeFilters := buildExcludeFilters(cca.ExcludePatterns, false)
 	excludePathFilters := buildExcludeFilters(cca.ExcludePathPatterns, true)
@@ -67,22 +80,49 @@ func newRemoveEnumerator(cca *CookedCopyCmdArgs) (enumerator *CopyEnumerator, er
 	filters = append(filters, excludePathFilters...)
 	filters = append(filters, includeSoftDelete...)
 
+	// the date filters are applied as each object is removed, or not, since when purging, it's the ages of the versions
+	// or snapshots that they're applied to. They're still filters, as far as the folders are concerned.
+	folderFilters := filters
+	if cca.IncludeBefore != nil {
+		folderFilters = append(folderFilters, &IncludeBeforeDateFilter{Threshold: *cca.IncludeBefore})
+	}
+	if cca.IncludeAfter != nil {
+		folderFilters = append(folderFilters, &IncludeAfterDateFilter{Threshold: *cca.IncludeAfter})
+	}
+
 	// decide our folder transfer strategy
 	// (Must enumerate folders when deleting from a folder-aware location. Can't do folder deletion just based on file
 	// deletion, because that would not handle folders that were empty at the start of the job).
 	// isHNStoHNS is IGNORED here, because BlobFS locations don't take this route currently.
-	fpo, message := newFolderPropertyOption(cca.FromTo, cca.Recursive, cca.StripTopDir, filters, false, false, false, false)
+	fpo, message := newFolderPropertyOption(cca.FromTo, cca.Recursive, cca.StripTopDir, folderFilters, false, false, false, false)
 	// do not print Info message if in dry run mode
 	if !cca.dryrunMode {
-		glcm.Info(message)
+		cca.lcm.Info(message)
 	}
 	if jobsAdmin.JobsAdmin != nil {
 		jobsAdmin.JobsAdmin.LogToJobLog(message, pipeline.LogInfo)
 	}
 
 	transferScheduler := newRemoveTransferProcessor(cca, NumOfFilesPerDispatchJobPart, fpo)
+	processor := transferScheduler.scheduleCopyTransfer
+
+	var purger *purgeProcessor
+	if cca.purge != "" || filtersByDate {
+		purger = newPurgeProcessor(processor, cca.purge, cca.keepLatest, cca.IncludeBefore, cca.IncludeAfter)
+		processor = purger.process
+	}
 
 	finalize := func() error {
+		if purger != nil {
+			err := purger.flush()
+			if err != nil {
+				return err
+			}
+			if cca.dryrunMode {
+				cca.lcm.Dryrun(purger.report.output)
+			}
+		}
+
 		jobInitiated, err := transferScheduler.dispatchFinalPart()
 		if err != nil {
 			if cca.dryrunMode {
@@ -98,29 +138,22 @@ func newRemoveEnumerator(cca *CookedCopyCmdArgs) (enumerator *CopyEnumerator, er
 		// TODO: this appears to be obsolete due to the above err == NothingScheduledError. Review/discuss.
 		if !jobInitiated {
 			if cca.isCleanupJob {
-				glcm.Error("Cleanup completed (nothing needed to be deleted)")
+				cca.lcm.Error("Cleanup completed (nothing needed to be deleted)")
 			} else {
-				glcm.Error("Nothing to delete. Please verify that recursive flag is set properly if targeting a directory.")
+				cca.lcm.Error("Nothing to delete. Please verify that recursive flag is set properly if targeting a directory.")
 			}
 		}
 
 		return nil
 	}
 
-	return NewCopyEnumerator(sourceTraverser, filters, transferScheduler.scheduleCopyTransfer, finalize), nil
+	return NewCopyEnumerator(sourceTraverser, filters, processor, finalize), nil
 }
 
 // TODO move after ADLS/Blob interop goes public
 // TODO this simple remove command is only here to support the scenario temporarily
 // Ultimately, this code can be merged into the newRemoveEnumerator
-func removeBfsResources(cca *CookedCopyCmdArgs) (err error) {
-	ctx := context.WithValue(context.Background(), ste.ServiceAPIVersionOverride, ste.DefaultServiceApiVersion)
-
-	// return an error if the unsupported options are passed in
-	if len(cca.InitModularFilters()) > 0 {
-		return errors.New("filter options, such as include/exclude, are not supported for this destination")
-		// because we just ignore them and delete the root
-	}
+func removeBfsResources(ctx context.Context, cca *CookedCopyCmdArgs) (err error) {
 
 	// patterns are not supported
 	if strings.Contains(cca.Source.Value, "*") {
@@ -128,7 +161,7 @@ func removeBfsResources(cca *CookedCopyCmdArgs) (err error) {
 	}
 
 	// create bfs pipeline
-	p, err := createBlobFSPipeline(ctx, cca.credentialInfo, cca.LogVerbosity.ToPipelineLogLevel())
+	p, err := createBlobFSPipeline(ctx, cca.lcm, cca.credentialInfo, cca.LogVerbosity.ToPipelineLogLevel())
 	if err != nil {
 		return err
 	}
@@ -142,13 +175,25 @@ func removeBfsResources(cca *CookedCopyCmdArgs) (err error) {
 	// parse the given source URL into parts, which separates the filesystem name and directory/file path
 	urlParts := azbfs.NewBfsURLParts(*sourceURL)
 
+	// with filters, only the paths that pass them are removed, so each is removed by itself.
+	// Otherwise, the whole directory is removed by the service, in one recursive delete.
+	if filters := cca.InitModularFilters(); len(filters) > 0 {
+		if cca.ListOfFilesChannel != nil {
+			return errors.New("filter options, such as include/exclude, can't be combined with include-path or list-of-files for this destination")
+		}
+		if urlParts.DirectoryOrFilePath == "" {
+			return errors.New("filter options, such as include/exclude, can't be used to remove a whole filesystem")
+		}
+		return removeFilteredBfsResources(ctx, cca, sourceURL, urlParts, p, filters)
+	}
+
 	if cca.ListOfFilesChannel == nil {
-		successMsg, err := removeSingleBfsResource(ctx, urlParts, p, cca.Recursive, cca.dryrunMode)
+		successMsg, err := removeSingleBfsResource(ctx, cca.lcm, urlParts, p, cca.Recursive, cca.dryrunMode)
 		if err != nil {
 			return err
 		}
 		if !cca.dryrunMode {
-			glcm.Exit(func(format common.OutputFormat) string {
+			cca.lcm.Exit(func(format common.OutputFormat) string {
 				if format == common.EOutputFormat.Json() {
 					summary := common.ListJobSummaryResponse{
 						JobStatus:      common.EJobStatus.Completed(),
@@ -165,7 +210,7 @@ func removeBfsResources(cca *CookedCopyCmdArgs) (err error) {
 			}, common.EExitCode.Success())
 
 		} else {
-			glcm.Exit(nil, common.EExitCode.Success())
+			cca.lcm.Exit(nil, common.EExitCode.Success())
 		}
 		// explicitly exit, since in our tests Exit might be mocked away
 		return nil
@@ -181,55 +226,103 @@ func removeBfsResources(cca *CookedCopyCmdArgs) (err error) {
 	for ; ok; childPath, ok = <-cca.ListOfFilesChannel {
 		// remove the child path
 		urlParts.DirectoryOrFilePath = common.GenerateFullPath(parentPath, childPath)
-		successMessage, err := removeSingleBfsResource(ctx, urlParts, p, cca.Recursive, cca.dryrunMode)
+		successMessage, err := removeSingleBfsResource(ctx, cca.lcm, urlParts, p, cca.Recursive, cca.dryrunMode)
 		if err != nil {
 			// the specific error is not included in the details, since it doesn't have a field for full error message
 			failedTransfers = append(failedTransfers, common.TransferDetail{Src: childPath, TransferStatus: common.ETransferStatus.Failed()})
-			glcm.Info(fmt.Sprintf("Skipping %s due to error %s", childPath, err))
+			cca.lcm.Info(fmt.Sprintf("Skipping %s due to error %s", childPath, err))
 		} else {
-			glcm.Info(successMessage)
+			cca.lcm.Info(successMessage)
 			successCount += 1
 		}
 	}
 
-	if cca.dryrunMode {
-		glcm.Exit(nil, common.EExitCode.Success())
-	} else {
-		glcm.Exit(func(format common.OutputFormat) string {
-			if format == common.EOutputFormat.Json() {
-				status := common.EJobStatus.Completed()
-				if len(failedTransfers) > 0 {
-					status = common.EJobStatus.CompletedWithErrors()
-
-					// if nothing got deleted
-					if successCount == 0 {
-						status = common.EJobStatus.Failed()
-					}
-				}
+	exitWithBfsRemovalSummary(cca.lcm, cca.dryrunMode, successCount, failedTransfers)
+	return nil
+}
 
-				summary := common.ListJobSummaryResponse{
-					JobStatus:          status,
-					TotalTransfers:     successCount + uint32(len(failedTransfers)),
-					TransfersCompleted: successCount,
-					TransfersFailed:    uint32(len(failedTransfers)),
-					PercentComplete:    100,
-					FailedTransfers:    failedTransfers,
-				}
-				jsonOutput, err := json.Marshal(summary)
-				common.PanicIfErr(err)
-				return string(jsonOutput)
-			}
+// removeFilteredBfsResources lists the given directory, and removes each file that passes the filters, one at a time.
+// Directories are left in place, since they may still hold files that didn't pass.
+func removeFilteredBfsResources(ctx context.Context, cca *CookedCopyCmdArgs, sourceURL *url.URL, urlParts azbfs.BfsURLParts, p pipeline.Pipeline, filters []ObjectFilter) error {
+	parentPath := urlParts.DirectoryOrFilePath
+	successCount := uint32(0)
+	failedTransfers := make([]common.TransferDetail, 0)
 
-			return fmt.Sprintf("Successfully removed %v entities.", successCount)
-		}, common.EExitCode.Success())
+	traverser := newBlobFSTraverser(sourceURL, p, ctx, cca.Recursive, nil)
+	err := traverser.Traverse(noPreProccessor, func(object StoredObject) error {
+		if object.entityType != common.EEntityType.File() {
+			return nil
+		}
+
+		urlParts.DirectoryOrFilePath = common.GenerateFullPath(parentPath, object.relativePath)
+		if cca.dryrunMode {
+			cca.lcm.Dryrun(func(_ common.OutputFormat) string {
+				return fmt.Sprintf("DRYRUN: remove file %s", urlParts.DirectoryOrFilePath)
+			})
+			return nil
+		}
+
+		_, err := azbfs.NewFileURL(urlParts.URL(), p).Delete(ctx)
+		if err != nil {
+			failedTransfers = append(failedTransfers, common.TransferDetail{Src: object.relativePath, TransferStatus: common.ETransferStatus.Failed()})
+			cca.lcm.Info(fmt.Sprintf("Skipping %s due to error %s", object.relativePath, err))
+		} else {
+			cca.lcm.Info("Successfully removed file: " + urlParts.DirectoryOrFilePath)
+			successCount += 1
+		}
+		return nil
+	}, filters)
+	if err != nil {
+		return err
 	}
 
+	if successCount == 0 && len(failedTransfers) == 0 && !cca.dryrunMode {
+		return NothingToRemoveError
+	}
+
+	exitWithBfsRemovalSummary(cca.lcm, cca.dryrunMode, successCount, failedTransfers)
 	return nil
 }
 
+// exitWithBfsRemovalSummary reports the removal of several paths as a job with a transfer for each
+func exitWithBfsRemovalSummary(lcm common.LifecycleMgr, dryrunMode bool, successCount uint32, failedTransfers []common.TransferDetail) {
+	if dryrunMode {
+		lcm.Exit(nil, common.EExitCode.Success())
+		return
+	}
+
+	lcm.Exit(func(format common.OutputFormat) string {
+		if format == common.EOutputFormat.Json() {
+			status := common.EJobStatus.Completed()
+			if len(failedTransfers) > 0 {
+				status = common.EJobStatus.CompletedWithErrors()
+
+				// if nothing got deleted
+				if successCount == 0 {
+					status = common.EJobStatus.Failed()
+				}
+			}
+
+			summary := common.ListJobSummaryResponse{
+				JobStatus:          status,
+				TotalTransfers:     successCount + uint32(len(failedTransfers)),
+				TransfersCompleted: successCount,
+				TransfersFailed:    uint32(len(failedTransfers)),
+				PercentComplete:    100,
+				FailedTransfers:    failedTransfers,
+			}
+			jsonOutput, err := json.Marshal(summary)
+			common.PanicIfErr(err)
+			return string(jsonOutput)
+		}
+
+		return fmt.Sprintf("Successfully removed %v entities.", successCount)
+	}, common.EExitCode.Success())
+}
+
 // TODO move after ADLS/Blob interop goes public
 // TODO this simple remove command is only here to support the scenario temporarily
-func removeSingleBfsResource(ctx context.Context, urlParts azbfs.BfsURLParts, p pipeline.Pipeline, recursive bool, dryrunMode bool) (successMessage string, err error) {
+func removeSingleBfsResource(ctx context.Context, lcm common.LifecycleMgr, urlParts azbfs.BfsURLParts, p pipeline.Pipeline, recursive bool, dryrunMode bool) (successMessage string, err error) {
 	// deleting a filesystem
 	if urlParts.DirectoryOrFilePath == "" {
 		fsURL := azbfs.NewFileSystemURL(urlParts.URL(), p)
@@ -240,7 +333,7 @@ func removeSingleBfsResource(ctx context.Context, urlParts azbfs.BfsURLParts, p
 			}
 			return "", err
 		} else {
-			glcm.Dryrun(func(_ common.OutputFormat) string {
+			lcm.Dryrun(func(_ common.OutputFormat) string {
 				return fmt.Sprintf("DRYRUN: remove filesystem %s", urlParts.FileSystemName)
 			})
 			return "", nil
@@ -269,7 +362,7 @@ func removeSingleBfsResource(ctx context.Context, urlParts azbfs.BfsURLParts, p
 
 			return "", err
 		} else {
-			glcm.Dryrun(func(_ common.OutputFormat) string {
+			lcm.Dryrun(func(_ common.OutputFormat) string {
 				return fmt.Sprintf("DRYRUN: remove file %s", urlParts.DirectoryOrFilePath)
 			})
 			return "", nil
@@ -282,8 +375,10 @@ func removeSingleBfsResource(ctx context.Context, urlParts azbfs.BfsURLParts, p
 
 	if !dryrunMode {
 		// remove the directory
-		// loop will continue until the marker received in the response is empty
-		for {
+		// loop will continue until the marker received in the response is empty.
+		// The service removes as much of the tree as it can in each call, so a large tree takes many calls.
+		startTime := time.Now()
+		for calls := 1; ; calls++ {
 			removeResp, err := directoryURL.Delete(ctx, &marker, recursive)
 			if err != nil {
 				return "", fmt.Errorf("cannot remove the given resource due to error: %s", err)
@@ -296,6 +391,7 @@ func removeSingleBfsResource(ctx context.Context, urlParts azbfs.BfsURLParts, p
 			if marker == "" {
 				break
 			}
+			reportBfsDirectoryRemovalProgress(lcm, urlParts.DirectoryOrFilePath, calls, time.Since(startTime))
 		}
 
 		return "Successfully removed directory: " + urlParts.DirectoryOrFilePath, nil
@@ -313,7 +409,7 @@ func removeSingleBfsResource(ctx context.Context, urlParts azbfs.BfsURLParts, p
 					entityType = "file"
 				}
 
-				glcm.Dryrun(func(_ common.OutputFormat) string {
+				lcm.Dryrun(func(_ common.OutputFormat) string {
 					return fmt.Sprintf("DRYRUN: remove %s %s", entityType, *v.Name)
 				})
 			}
@@ -326,3 +422,19 @@ func removeSingleBfsResource(ctx context.Context, urlParts azbfs.BfsURLParts, p
 		return "", nil
 	}
 }
+
+// reportBfsDirectoryRemovalProgress reports how far a recursive delete has got. The service doesn't say how many paths
+// each call removed, so it's the number of calls so far, and the time they've taken, that are reported.
+func reportBfsDirectoryRemovalProgress(lcm common.LifecycleMgr, directoryPath string, calls int, elapsed time.Duration) {
+	lcm.Progress(func(format common.OutputFormat) string {
+		if format == common.EOutputFormat.Json() {
+			jsonOutput, err := json.Marshal(common.ListJobSummaryResponse{
+				JobStatus:      common.EJobStatus.InProgress(),
+				TotalTransfers: 1,
+			})
+			common.PanicIfErr(err)
+			return string(jsonOutput)
+		}
+		return fmt.Sprintf("Removing directory %s: %d requests completed, %s elapsed", directoryPath, calls, elapsed.Round(time.Second))
+	})
+}
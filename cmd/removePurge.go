@@ -0,0 +1,256 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+)
+
+const (
+	purgeVersions  = "versions"
+	purgeSnapshots = "snapshots"
+)
+
+// parseOlderThan returns the time that objects must have been modified before to be older than the given age,
+// which is a number of days or weeks, e.g. 30d or 2w, or else a duration such as 36h
+func parseOlderThan(age string, now time.Time) (time.Time, error) {
+	age = strings.TrimSpace(age)
+	invalid := fmt.Errorf("invalid age %q for older-than, it must be a positive number of days (e.g. 30d), weeks (e.g. 2w), or hours (e.g. 36h)", age)
+
+	if age == "" {
+		return time.Time{}, invalid
+	}
+
+	var d time.Duration
+	var err error
+	switch unit := strings.ToLower(age[len(age)-1:]); unit {
+	case "d", "w":
+		var n int
+		n, err = strconv.Atoi(age[:len(age)-1])
+		d = time.Duration(n) * 24 * time.Hour
+		if unit == "w" {
+			d *= 7
+		}
+	default:
+		d, err = time.ParseDuration(age)
+	}
+	if err != nil || d <= 0 {
+		return time.Time{}, invalid
+	}
+	return now.Add(-d), nil
+}
+
+// validatePurge checks that --purge and --keep-latest are given together, for blobs, and without the flags that
+// choose what's removed in other ways. It returns what's to be purged.
+func validatePurge(raw rawCopyCmdArgs, fromTo common.FromTo, deleteSnapshots common.DeleteSnapshotsOption) (string, error) {
+	purge := strings.ToLower(raw.purge)
+	switch purge {
+	case purgeVersions, purgeSnapshots:
+	case "":
+		return "", errors.New("keep-latest can only be used with purge")
+	default:
+		return "", fmt.Errorf("invalid value %q for purge, it must be versions or snapshots", raw.purge)
+	}
+
+	if fromTo != common.EFromTo.BlobTrash() {
+		return "", errors.New("purge is only supported when removing blobs, since only they have versions and snapshots")
+	}
+	if raw.keepLatest < 0 {
+		return "", errors.New("keep-latest cannot be negative")
+	}
+	if raw.listOfVersionIDs != "" {
+		return "", errors.New("purge cannot be used with list-of-versions, since it chooses the versions to remove itself")
+	}
+	if raw.listOfFilesToCopy != "" || raw.includePath != "" {
+		return "", errors.New("purge cannot be used with list-of-files or include-path, since it lists each blob's versions or snapshots together")
+	}
+	if deleteSnapshots != common.EDeleteSnapshotsOption.None() {
+		return "", errors.New("purge cannot be used with delete-snapshots, since the blobs themselves are kept")
+	}
+	if raw.permanentDeleteOption != "" && !strings.EqualFold(raw.permanentDeleteOption, common.EPermanentDeleteOption.None().String()) {
+		return "", errors.New("purge cannot be used with permanent-delete")
+	}
+	return purge, nil
+}
+
+// purgeProcessor decides which of the objects listed for removal are old enough to be removed.
+// When purging, it's only the earlier versions, or the snapshots, of each blob that are removed, and the latest of
+// them are kept regardless of their age. Their ages are those of the versions or snapshots, rather than the times
+// that the blob was last modified before they were taken.
+// Since those of a blob are listed together, they're held until the listing moves on to the next blob.
+type purgeProcessor struct {
+	next        objectProcessor
+	purge       string
+	keepLatest  int
+	dateFilters []ObjectFilter
+
+	// the path of the blob whose versions or snapshots are being listed, and those listed so far
+	groupPath string
+	group     []StoredObject
+
+	report purgeReport
+}
+
+func newPurgeProcessor(next objectProcessor, purge string, keepLatest int, includeBefore, includeAfter *time.Time) *purgeProcessor {
+	p := &purgeProcessor{next: next, purge: purge, keepLatest: keepLatest}
+	if includeBefore != nil {
+		p.dateFilters = append(p.dateFilters, &IncludeBeforeDateFilter{Threshold: *includeBefore})
+	}
+	if includeAfter != nil {
+		p.dateFilters = append(p.dateFilters, &IncludeAfterDateFilter{Threshold: *includeAfter})
+	}
+	return p
+}
+
+func (p *purgeProcessor) process(object StoredObject) error {
+	// the folders are removed, or not, as they'd be without it
+	if object.entityType != common.EEntityType.File() {
+		return p.next(object)
+	}
+
+	if p.purge == "" {
+		return p.removeIfOldEnough(object, object)
+	}
+
+	if object.relativePath != p.groupPath || len(p.group) == 0 {
+		if err := p.flush(); err != nil {
+			return err
+		}
+		p.groupPath = object.relativePath
+	}
+	p.group = append(p.group, object)
+	return nil
+}
+
+// flush decides what to remove of the blob listed last. It must be called once the listing is done.
+func (p *purgeProcessor) flush() error {
+	group := p.group
+	p.group = nil
+
+	candidates := make([]StoredObject, 0, len(group))
+	for _, object := range group {
+		if p.isCandidate(object) {
+			candidates = append(candidates, object)
+		} else {
+			p.report.Kept.add(object)
+		}
+	}
+
+	// newest first, so that the latest are kept
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return versionTimeOf(candidates[i]).After(versionTimeOf(candidates[j]))
+	})
+
+	for i, object := range candidates {
+		if i < p.keepLatest {
+			p.report.Kept.add(object)
+			continue
+		}
+
+		aged := object
+		aged.lastModifiedTime = versionTimeOf(object)
+		if err := p.removeIfOldEnough(object, aged); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isCandidate tells whether the object is one of those being purged, as opposed to the blob itself
+func (p *purgeProcessor) isCandidate(object StoredObject) bool {
+	if p.purge == purgeSnapshots {
+		return object.blobSnapshotID != ""
+	}
+	return object.blobVersionID != "" && !object.blobIsCurrentVersion
+}
+
+// removeIfOldEnough passes the object on to be removed if its age, as given by aged, passes the date filters
+func (p *purgeProcessor) removeIfOldEnough(object, aged StoredObject) error {
+	for _, filter := range p.dateFilters {
+		if !filter.DoesPass(aged) {
+			p.report.Kept.add(object)
+			return nil
+		}
+	}
+
+	p.report.Purged.add(object)
+	return p.next(object)
+}
+
+// versionTimeOf returns when the version or snapshot was taken, which is given by its ID
+func versionTimeOf(object StoredObject) time.Time {
+	for _, id := range []string{object.blobSnapshotID, object.blobVersionID} {
+		if id == "" {
+			continue
+		}
+		if t, err := time.Parse(time.RFC3339Nano, id); err == nil {
+			return t
+		}
+	}
+	return object.lastModifiedTime
+}
+
+// purgeReport sums up what a remove would purge, and what it would keep, by the kind of object
+type purgeReport struct {
+	Purged purgeTally
+	Kept   purgeTally
+}
+
+type purgeTally struct {
+	Blobs     usageTally
+	Versions  usageTally
+	Snapshots usageTally
+}
+
+func (t *purgeTally) add(object StoredObject) {
+	tally := &t.Blobs
+	if object.blobSnapshotID != "" {
+		tally = &t.Snapshots
+	} else if object.blobVersionID != "" && !object.blobIsCurrentVersion {
+		tally = &t.Versions
+	}
+	tally.Count++
+	tally.Bytes += object.size
+}
+
+func (t purgeTally) String() string {
+	return fmt.Sprintf("%d blobs (%s), %d versions (%s), %d snapshots (%s)",
+		t.Blobs.Count, byteSizeToString(t.Blobs.Bytes),
+		t.Versions.Count, byteSizeToString(t.Versions.Bytes),
+		t.Snapshots.Count, byteSizeToString(t.Snapshots.Bytes))
+}
+
+func (r purgeReport) output(format common.OutputFormat) string {
+	if format == common.EOutputFormat.Json() {
+		jsonOutput, err := json.Marshal(r)
+		common.PanicIfErr(err)
+		return string(jsonOutput)
+	}
+	return fmt.Sprintf("DRYRUN: would purge %s\nDRYRUN: would keep %s", r.Purged, r.Kept)
+}
@@ -0,0 +1,154 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+)
+
+const (
+	restoreModeUndelete = "undelete"
+	restoreModeVersion  = "version"
+)
+
+func init() {
+	raw := rawCopyCmdArgs{}
+	var mode, asOf string
+	// restoreCmd represents the restore command
+	var restoreCmd = &cobra.Command{
+		Use:     "restore [resourceURL]",
+		Aliases: []string{"undelete"},
+		Short:   restoreCmdShortDescription,
+		Long:    restoreCmdLongDescription,
+		Example: restoreCmdExample,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return fmt.Errorf("restore command only takes 1 arguments. Passed %d arguments", len(args))
+			}
+
+			// the blobs to restore are the source, and there's no destination
+			raw.src = args[0]
+
+			if raw.fromTo == "" {
+				srcLocationType := InferArgumentLocation(raw.src)
+				if srcLocationType != common.ELocation.Blob() {
+					return fmt.Errorf("invalid source type %s to restore. azcopy supports restoring blobs", srcLocationType.String())
+				}
+				raw.fromTo = common.EFromTo.BlobNone().String()
+			} else if !strings.EqualFold(raw.fromTo, common.EFromTo.BlobNone().String()) {
+				return fmt.Errorf("invalid from-to %s. Please enter a valid one, i.e. BlobNone", raw.fromTo)
+			}
+			raw.setMandatoryDefaults()
+
+			// the stubs that represent directories are restored along with the blobs in them
+			raw.includeDirectoryStubs = true
+
+			return nil
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			glcm.EnableInputWatcher()
+			if cancelFromStdin {
+				glcm.EnableCancelFromStdIn()
+			}
+
+			cooked, err := raw.cook()
+			if err != nil {
+				glcm.Error("failed to parse user input due to error: " + err.Error())
+			}
+
+			err = cookRestore(&cooked, mode, asOf, time.Now())
+			if err != nil {
+				glcm.Error("failed to parse user input due to error: " + err.Error())
+			}
+
+			cooked.commandString = copyHandlerUtil{}.ConstructCommandStringFromArgs()
+			err = cooked.process()
+			if err != nil {
+				glcm.Error("failed to perform restore command due to error: " + err.Error())
+			}
+
+			if cooked.dryrunMode {
+				glcm.Exit(nil, common.EExitCode.Success())
+			}
+
+			glcm.SurrenderControl()
+		},
+	}
+	rootCmd.AddCommand(restoreCmd)
+
+	restoreCmd.PersistentFlags().StringVar(&mode, "mode", restoreModeUndelete, "How the blobs are restored: 'undelete' undeletes the soft-deleted blobs, while 'version' makes an earlier version of each blob its current version.")
+	restoreCmd.PersistentFlags().StringVar(&asOf, "as-of", "", "The time to restore the blobs to, either in ISO8601 format (e.g. '2020-08-19T15:04:00Z'), or as an age, in days (e.g. 7d), weeks (e.g. 2w) or hours (e.g. 36h). "+
+		"When undeleting, only the blobs deleted at or after then are undeleted.")
+	restoreCmd.PersistentFlags().BoolVar(&raw.recursive, "recursive", false, "Look into sub-directories recursively when restoring the blobs in a virtual directory.")
+	restoreCmd.PersistentFlags().StringVar(&raw.logVerbosity, "log-level", "INFO", "Define the log verbosity for the log file. Available levels include: INFO(all requests/responses), WARNING(slow responses), ERROR(only failed requests), and NONE(no output logs). (default 'INFO')")
+
+	// which blobs to restore
+	restoreCmd.PersistentFlags().StringVar(&raw.include, "include-pattern", "", "Include only blobs where the name matches the pattern list. For example: *.jpg;*.pdf;exactName")
+	restoreCmd.PersistentFlags().StringVar(&raw.includeRegex, "include-regex", "", "Include only the relative paths of the blobs that match with the regular expressions. Separate regular expressions with ';'.")
+	restoreCmd.PersistentFlags().StringVar(&raw.exclude, "exclude-pattern", "", "Exclude blobs where the name matches the pattern list. For example: *.jpg;*.pdf;exactName")
+	restoreCmd.PersistentFlags().StringVar(&raw.excludePath, "exclude-path", "", "Exclude these paths when restoring. "+
+		"This option does not support wildcard characters (*). Checks relative path prefix. For example: myFolder;myFolder/subDirName/file.pdf")
+	restoreCmd.PersistentFlags().StringVar(&raw.excludeRegex, "exclude-regex", "", "Exclude all the relative paths of the blobs that match with the regular expressions. Separate regular expressions with ';'.")
+	restoreCmd.PersistentFlags().StringVar(&raw.excludeBlobType, "exclude-blob-type", "", "Optionally specifies the type of blob (BlockBlob/ PageBlob/ AppendBlob) to exclude when restoring. Separate the blob types with ';'.")
+
+	restoreCmd.PersistentFlags().StringVar(&raw.transferEvents, "transfer-events", "", "Writes an event, as a line of JSON, as each blob is restored, or fails to be restored: to the standard output if this option is set to 'stdout', or else to the given file or named pipe.")
+	restoreCmd.PersistentFlags().StringVar(&raw.hookURL, "hook-url", "", "URL to POST a JSON description of the job to, as it starts and ends and as transfers fail. See --hook-events.")
+	restoreCmd.PersistentFlags().StringVar(&raw.hookCommand, "hook-command", "", "Command to run, with a shell, as the job starts and ends and as transfers fail. "+
+		"It's given the JSON that --hook-url is sent on its standard input, and the gist of it in environment variables such as AZCOPY_HOOK_EVENT, AZCOPY_JOB_ID and AZCOPY_JOB_STATUS.")
+	restoreCmd.PersistentFlags().StringVar(&raw.hookEvents, "hook-events", "", "Comma-separated list of the events that the hooks are run for: JobStarted, JobCompleted, JobCancelled and TransferFailed. By default, they are run for all of them.")
+	restoreCmd.PersistentFlags().BoolVar(&raw.dryrun, "dry-run", false, "Prints the paths of the blobs that would be restored by the command, and the versions they'd be restored to. This flag does not restore them.")
+	restoreCmd.PersistentFlags().StringVar(&raw.fromTo, "from-to", "", "Optionally specifies the source destination combination. For Example: BlobNone")
+}
+
+// cookRestore works out how the job restores the blobs, and as of when. Restore jobs are set-properties jobs, which
+// undelete the blobs, or make earlier versions of them current, instead of setting their properties.
+func cookRestore(cooked *CookedCopyCmdArgs, mode string, asOf string, now time.Time) error {
+	switch strings.ToLower(mode) {
+	case restoreModeUndelete:
+		cooked.propertiesToSet = common.ESetPropertiesFlags.Undelete()
+	case restoreModeVersion:
+		cooked.propertiesToSet = common.ESetPropertiesFlags.PromoteVersion()
+	default:
+		return fmt.Errorf("invalid mode %q for restore, it must be undelete or version", mode)
+	}
+
+	if asOf == "" {
+		return nil
+	}
+
+	// it's an age, or else a time
+	t, err := parseOlderThan(asOf, now)
+	if err != nil {
+		// if there's an ambiguous local time, the earlier of the two is chosen
+		t, err = IncludeAfterDateFilter{}.ParseISO8601(asOf, true)
+		if err != nil {
+			return fmt.Errorf("invalid as-of %q, it must be an ISO8601 time, or an age such as 7d", asOf)
+		}
+	}
+	cooked.restoreAsOf = &t
+	return nil
+}
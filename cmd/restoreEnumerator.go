@@ -0,0 +1,176 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+)
+
+var NothingToRestoreError = errors.New("nothing found to restore")
+
+// provide an enumerator that lists the soft-deleted blobs, or the versions of the blobs, at the given URL
+// and schedules transfers that undelete them, or make the right versions current
+func newRestoreEnumerator(ctx context.Context, cca *CookedCopyCmdArgs) (enumerator *CopyEnumerator, err error) {
+	var sourceTraverser ResourceTraverser
+
+	sourceTraverser, err = InitResourceTraverser(cca.Source, cca.FromTo.From(), &ctx, &cca.credentialInfo,
+		nil, nil, cca.Recursive, false, cca.IncludeDirectoryStubs,
+		common.EPermanentDeleteOption.None(), func(common.EntityType) {}, nil, false,
+		cca.LogVerbosity.ToPipelineLogLevel(), cca.CpkOptions)
+
+	// report failure to create traverser
+	if err != nil {
+		return nil, err
+	}
+
+	blobTraverser, ok := sourceTraverser.(*blobTraverser)
+	if !ok {
+		return nil, errors.New("only blobs can be restored")
+	}
+
+	// A URL that isn't that of a container, and doesn't end with a slash, is that of a single blob, which may not exist
+	// anymore. The deleted blobs are listed along with the others, which also lists the blobs whose names start with
+	// the blob's name, rather than the blobs in a virtual directory of that name.
+	singleBlob := !copyHandlerUtil{}.urlIsContainerOrVirtualDirectory(blobTraverser.rawURL)
+	undelete := cca.propertiesToSet.ShouldUndelete()
+	blobTraverser.includeDeleted = undelete || singleBlob
+	blobTraverser.includeVersion = !undelete
+	// the versions of each blob must be listed together, which only the flat listing does
+	blobTraverser.parallelListing = false
+
+	var restorer objectProcessor
+	transferScheduler := newSetPropertiesTransferProcessor(cca, NumOfFilesPerDispatchJobPart, common.EFolderPropertiesOption.NoFolders())
+	var versionRestorer *restoreVersionProcessor
+	if undelete {
+		restorer = newUndeleteProcessor(transferScheduler.scheduleCopyTransfer, cca.restoreAsOf)
+	} else {
+		versionRestorer = &restoreVersionProcessor{next: transferScheduler.scheduleCopyTransfer, asOf: cca.restoreAsOf}
+		restorer = versionRestorer.process
+	}
+
+	processor := restorer
+	if singleBlob {
+		processor = func(object StoredObject) error {
+			if object.relativePath != "" {
+				return nil // another blob, whose name starts with the one that's being restored
+			}
+			return restorer(object)
+		}
+	}
+
+	// the same filters as set-properties, e.g. include/exclude patterns and paths, regexes and blob types
+	filters := cca.InitModularFilters()
+
+	finalize := func() error {
+		if versionRestorer != nil {
+			err := versionRestorer.flush()
+			if err != nil {
+				return err
+			}
+		}
+
+		_, err := transferScheduler.dispatchFinalPart()
+		if err != nil {
+			if cca.dryrunMode {
+				return nil
+			} else if err == NothingScheduledError {
+				// No log file needed. Logging begins as a part of awaiting job completion.
+				return NothingToRestoreError
+			}
+
+			return err
+		}
+
+		return nil
+	}
+
+	return NewCopyEnumerator(sourceTraverser, filters, processor, finalize), nil
+}
+
+// newUndeleteProcessor passes on the soft-deleted blobs, or just those that were deleted at or after asOf
+func newUndeleteProcessor(next objectProcessor, asOf *time.Time) objectProcessor {
+	return func(object StoredObject) error {
+		if !object.blobDeleted {
+			return nil
+		}
+		if asOf != nil && !object.blobDeletedTime.IsZero() && object.blobDeletedTime.Before(*asOf) {
+			return nil
+		}
+		return next(object)
+	}
+}
+
+// restoreVersionProcessor passes on the version that each blob is to be restored to.
+// With asOf, that's the version that was written last before then, unless it's the current version already.
+// Without it, only the blobs that have no current version, because they were deleted, are restored, to their latest version.
+// Since the versions of a blob are listed together, they're held until the listing moves on to the next blob.
+type restoreVersionProcessor struct {
+	next objectProcessor
+	asOf *time.Time
+
+	// the path of the blob whose versions are being listed, and those listed so far
+	groupPath string
+	group     []StoredObject
+}
+
+func (p *restoreVersionProcessor) process(object StoredObject) error {
+	// soft-deleted versions are undeleted, rather than restored
+	if object.entityType != common.EEntityType.File() || object.blobVersionID == "" || object.blobDeleted {
+		return nil
+	}
+
+	if object.relativePath != p.groupPath || len(p.group) == 0 {
+		if err := p.flush(); err != nil {
+			return err
+		}
+		p.groupPath = object.relativePath
+	}
+	p.group = append(p.group, object)
+	return nil
+}
+
+// flush passes on the version to restore the blob listed last to, if there is one. It must be called once the listing is done.
+func (p *restoreVersionProcessor) flush() error {
+	group := p.group
+	p.group = nil
+
+	var target *StoredObject
+	hasCurrentVersion := false
+	for i := range group {
+		version := &group[i]
+		hasCurrentVersion = hasCurrentVersion || version.blobIsCurrentVersion
+		if p.asOf != nil && versionTimeOf(*version).After(*p.asOf) {
+			continue
+		}
+		if target == nil || versionTimeOf(*version).After(versionTimeOf(*target)) {
+			target = version
+		}
+	}
+
+	if target == nil || target.blobIsCurrentVersion || (p.asOf == nil && hasCurrentVersion) {
+		return nil
+	}
+	return p.next(*target)
+}
@@ -42,6 +42,7 @@ import (
 
 var azcopyAppPathFolder string
 var azcopyLogPathFolder string
+var azcopyLogSettings common.JobLogSettings
 var azcopyMaxFileAndSocketHandles int
 var outputFormatRaw string
 var cancelFromStdin bool
@@ -110,13 +111,20 @@ var rootCmd = &cobra.Command{
 		providePerformanceAdvice := cmd == benchCmd
 
 		// startup of the STE happens here, so that the startup can access the values of command line parameters that are defined for "root" command
-		concurrencySettings := ste.NewConcurrencySettings(azcopyMaxFileAndSocketHandles, preferToAutoTuneGRs)
-		err = jobsAdmin.MainSTE(concurrencySettings, float64(cmdLineCapMegaBitsPerSecond), common.AzcopyJobPlanFolder, azcopyLogPathFolder, providePerformanceAdvice)
+		err = startSTE(cmdLineCapMegaBitsPerSecond, preferToAutoTuneGRs, providePerformanceAdvice)
 		if err != nil {
 			return err
 		}
-		EnumerationParallelism = concurrencySettings.EnumerationPoolSize.Value
-		EnumerationParallelStatFiles = concurrencySettings.ParallelStatFiles.Value
+
+		if metricsListenAddress != "" {
+			if err = serveMetrics(metricsListenAddress); err != nil {
+				return err
+			}
+		}
+
+		if err = initTracing(); err != nil {
+			return err
+		}
 
 		// Log a clear ISO 8601-formatted start time, so it can be read and use in the --include-after parameter
 		// Subtract a few seconds, to ensure that this date DEFINITELY falls before the LMT of any file changed while this
@@ -149,6 +157,18 @@ var rootCmd = &cobra.Command{
 	},
 }
 
+// startSTE starts the in-process STE, and sizes the enumeration to match it
+func startSTE(capMbps float64, preferToAutoTuneGRs bool, providePerformanceAdvice bool) error {
+	concurrencySettings := ste.NewConcurrencySettings(azcopyMaxFileAndSocketHandles, preferToAutoTuneGRs)
+	err := jobsAdmin.MainSTE(concurrencySettings, capMbps, common.AzcopyJobPlanFolder, azcopyLogPathFolder, providePerformanceAdvice)
+	if err != nil {
+		return err
+	}
+	EnumerationParallelism = concurrencySettings.EnumerationPoolSize.Value
+	EnumerationParallelStatFiles = concurrencySettings.ParallelStatFiles.Value
+	return nil
+}
+
 // hold a pointer to the global lifecycle controller so that commands could output messages and exit properly
 var glcm = common.GetLifecycleMgr()
 var glcmSwapOnce = &sync.Once{}
@@ -161,9 +181,22 @@ func Execute(azsAppPathFolder, logPathFolder string, jobPlanFolder string, maxFi
 	common.AzcopyJobPlanFolder = jobPlanFolder
 	azcopyMaxFileAndSocketHandles = maxFileAndSocketHandles
 	azcopyCurrentJobID = jobID
-	common.AzcopyCurrentJobLogger = common.NewJobLogger(jobID, common.ELogLevel.Debug(), logPathFolder, "")
+
+	// the settings must be known before the log is opened, which is before the flags are parsed, so they're only set by the environment
+	var err error
+	if azcopyLogSettings, err = common.JobLogSettingsFromEnvironment(glcm.GetEnvironmentVariable); err != nil {
+		glcm.Error(err.Error())
+	}
+	common.AzcopyCurrentJobLogger = common.NewJobLogger(jobID, common.ELogLevel.Debug(), logPathFolder, "", azcopyLogSettings)
 	common.AzcopyCurrentJobLogger.OpenLog()
 
+	// the log of this job was only just opened, so it's never old enough to be removed.
+	// Failing to clean up after old jobs is no reason to fail this one, so it's only logged.
+	// (It isn't printed, since the output format isn't known until the flags have been parsed.)
+	if err := removeExpiredJobs(); err != nil {
+		common.AzcopyCurrentJobLogger.Log(pipeline.LogWarning, "Failed to remove the files of old jobs: "+err.Error())
+	}
+
 	if err := rootCmd.Execute(); err != nil {
 		glcm.Error(err.Error())
 	} else {
@@ -184,7 +217,10 @@ func init() {
 	rootCmd.SetUsageTemplate(strings.Replace((&cobra.Command{}).UsageTemplate(), "Global Flags", "Flags Applying to All Commands", -1))
 
 	rootCmd.PersistentFlags().Float64Var(&cmdLineCapMegaBitsPerSecond, "cap-mbps", 0, "Caps the transfer rate, in megabits per second. Moment-by-moment throughput might vary slightly from the cap. If this option is set to zero, or it is omitted, the throughput isn't capped.")
-	rootCmd.PersistentFlags().StringVar(&outputFormatRaw, "output-type", "text", "Format of the command's output. The choices include: text, json. The default value is 'text'.")
+	rootCmd.PersistentFlags().StringVar(&outputFormatRaw, "output-type", "text", "Format of the command's output. The choices include: text, json, csv. The default value is 'text'. "+
+		"With csv, only the results of the list and du commands are printed, as rows with a header.")
+	rootCmd.PersistentFlags().StringVar(&metricsListenAddress, "metrics-listen", "", "Serves Prometheus metrics at /metrics on the given address, e.g. ':9090', while AzCopy runs. "+
+		"The metrics include the bytes and transfers of each job, the retries by status code, the requests in flight, their latency, the concurrency, the pacer's target rate and the RAM used by chunks.")
 
 	rootCmd.PersistentFlags().StringVar(&cmdLineExtraSuffixesAAD, trustedSuffixesNameAAD, "", "Specifies additional domain suffixes where Azure Active Directory login tokens may be sent.  The default is '"+
 		trustedSuffixesAAD+"'. Any listed here are added to the default. For security, you should only put Microsoft Azure domains here. Separate multiple entries with semi-colons.")
@@ -223,7 +259,7 @@ func beginDetectNewVersion() chan struct{} {
 		}
 
 		// step 1: initialize pipeline
-		p, err := createBlobPipeline(context.TODO(), common.CredentialInfo{CredentialType: common.ECredentialType.Anonymous()}, pipeline.LogNone)
+		p, err := createBlobPipeline(context.TODO(), glcm, common.CredentialInfo{CredentialType: common.ECredentialType.Anonymous()}, pipeline.LogNone)
 		if err != nil {
 			return
 		}
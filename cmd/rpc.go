@@ -22,21 +22,64 @@ package cmd
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"github.com/Azure/azure-storage-azcopy/v10/jobsAdmin"
 	"io/ioutil"
+	"net"
 	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 
 	"github.com/Azure/azure-storage-azcopy/v10/common"
+	"github.com/Azure/azure-storage-azcopy/v10/jobsAdmin"
 )
 
 // Global singleton for sending RPC requests from the frontend to the STE
+// If AZCOPY_DAEMON_ENDPOINT is set, the requests are sent to a running 'azcopy daemon' instead of the in-process STE
 var Rpc = func(cmd common.RpcCmd, request interface{}, response interface{}) {
+	if client := getDaemonClient(); client != nil {
+		if err := client.send(cmd, request, response); err != nil {
+			glcm.Error(fmt.Sprintf("failed to send %s request to the AzCopy daemon at %s: %s", cmd.String(), client.url, err.Error()))
+		}
+		return
+	}
+
 	err := inprocSend(cmd, request, response)
 	common.PanicIfErr(err)
 }
 
+var daemonClient *HTTPClient
+var daemonClientOnce = &sync.Once{}
+
+// getDaemonClient returns the client for the daemon named by AZCOPY_DAEMON_ENDPOINT, or nil if the STE runs in process
+func getDaemonClient() *HTTPClient {
+	daemonClientOnce.Do(func() {
+		endpoint := glcm.GetEnvironmentVariable(common.EEnvironmentVariable.DaemonEndpoint())
+		if endpoint == "" {
+			return
+		}
+
+		token := glcm.GetEnvironmentVariable(common.EEnvironmentVariable.DaemonToken())
+		if token == "" {
+			// fall back to the connection file written by a daemon on this machine
+			if info, err := readDaemonConnectionFile(); err == nil && info.Endpoint == endpoint {
+				token = info.Token
+			}
+		}
+
+		client, err := newDaemonHttpClient(endpoint, token)
+		if err != nil {
+			glcm.Error(fmt.Sprintf("invalid %s: %s", common.EEnvironmentVariable.DaemonEndpoint().Name, err.Error()))
+		}
+		daemonClient = client
+	})
+	return daemonClient
+}
+
 // Send method on HttpClient sends the data passed in the interface for given command type to the client url
 func inprocSend(rpcCmd common.RpcCmd, requestData interface{}, responseData interface{}) error {
 	switch rpcCmd {
@@ -55,9 +98,8 @@ func inprocSend(rpcCmd common.RpcCmd, requestData interface{}, responseData inte
 	case common.ERpcCmd.ListJobTransfers():
 		*(responseData.(*common.ListJobTransfersResponse)) = jobsAdmin.ListJobTransfers(requestData.(common.ListJobTransfersRequest))
 
-	
 	case common.ERpcCmd.PauseJob():
-		responseData = jobsAdmin.CancelPauseJobOrder(requestData.(common.JobID), common.EJobStatus.Paused())
+		*(responseData.(*common.CancelPauseResumeResponse)) = jobsAdmin.CancelPauseJobOrder(requestData.(common.JobID), common.EJobStatus.Paused())
 
 	case common.ERpcCmd.CancelJob():
 		*(responseData.(*common.CancelPauseResumeResponse)) = jobsAdmin.CancelPauseJobOrder(requestData.(common.JobID), common.EJobStatus.Cancelling())
@@ -84,24 +126,67 @@ func NewHttpClient(url string) *HTTPClient {
 	}
 }
 
+// newDaemonHttpClient returns a client for the RPC endpoint of 'azcopy daemon'.
+// The endpoint is either http://host:port or unix:///path/to/socket
+func newDaemonHttpClient(endpoint string, token string) (*HTTPClient, error) {
+	if socketPath := strings.TrimPrefix(endpoint, "unix://"); socketPath != endpoint {
+		if socketPath == "" {
+			return nil, errors.New("the unix socket path is missing")
+		}
+		dialer := net.Dialer{}
+		return &HTTPClient{
+			client: &http.Client{Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					return dialer.DialContext(ctx, "unix", socketPath)
+				},
+			}},
+			url:   "http://unix" + daemonRpcPath,
+			token: token,
+		}, nil
+	}
+
+	if !strings.HasPrefix(endpoint, "http://") {
+		return nil, fmt.Errorf("%q is neither an http:// nor a unix:// endpoint", endpoint)
+	}
+	return &HTTPClient{
+		client: &http.Client{},
+		url:    strings.TrimSuffix(endpoint, "/") + daemonRpcPath,
+		token:  token,
+	}, nil
+}
+
 // todo : use url in case of string
 type HTTPClient struct {
 	client *http.Client
 	url    string
+	token  string // bearer token presented to the daemon, if any
 }
 
 // Send method on HttpClient sends the data passed in the interface for given command type to the client url
 func (httpClient *HTTPClient) send(rpcCmd common.RpcCmd, requestData interface{}, responseData interface{}) error {
+	if rpcCmd == common.ERpcCmd.GetJobLCMWrapper() {
+		// the lifecycle manager cannot leave this process, so the job's messages are reported by our own
+		*(responseData.(*common.LifecycleMgr)) = glcm
+		return nil
+	}
+
 	// Create HTTP request with command in query parameter & request data as JSON payload
 	requestJson, err := json.Marshal(requestData)
 	if err != nil {
 		return fmt.Errorf("error marshalling request payload for command type %q", rpcCmd.String())
 	}
 	request, err := http.NewRequest("POST", httpClient.url, bytes.NewReader(requestJson))
+	if err != nil {
+		return err
+	}
 	// adding the commandType as a query param
 	q := request.URL.Query()
 	q.Add("commandType", rpcCmd.String())
 	request.URL.RawQuery = q.Encode()
+	request.Header.Set("Content-Type", "application/json")
+	if httpClient.token != "" {
+		request.Header.Set("Authorization", "Bearer "+httpClient.token)
+	}
 
 	response, err := httpClient.client.Do(request)
 	if err != nil {
@@ -114,9 +199,42 @@ func (httpClient *HTTPClient) send(rpcCmd common.RpcCmd, requestData interface{}
 	if err != nil {
 		return fmt.Errorf("error reading response for the request")
 	}
-	err = json.Unmarshal(responseJson, responseData)
-	common.PanicIfErr(err)
-	return nil
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: %s", response.Status, strings.TrimSpace(string(responseJson)))
+	}
+	return json.Unmarshal(responseJson, responseData)
 }
 
 ////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// daemonConnectionInfo is persisted by 'azcopy daemon', so that other processes of the same user can find and authenticate to it
+type daemonConnectionInfo struct {
+	Endpoint string
+	Token    string
+}
+
+func daemonConnectionFilePath() string {
+	return filepath.Join(azcopyAppPathFolder, "daemon.json")
+}
+
+func readDaemonConnectionFile() (daemonConnectionInfo, error) {
+	var info daemonConnectionInfo
+	raw, err := ioutil.ReadFile(daemonConnectionFilePath())
+	if err != nil {
+		return info, err
+	}
+	err = json.Unmarshal(raw, &info)
+	return info, err
+}
+
+func writeDaemonConnectionFile(info daemonConnectionInfo) error {
+	raw, err := json.Marshal(info)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(daemonConnectionFilePath(), raw, 0600)
+}
+
+func removeDaemonConnectionFile() {
+	_ = os.Remove(daemonConnectionFilePath())
+}
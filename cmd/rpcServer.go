@@ -0,0 +1,393 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+)
+
+// daemonRpcPath receives the same RPCs that the CLI otherwise makes to the in-process STE
+const daemonRpcPath = "/rpc"
+
+// daemonProgressInterval is how often the progress stream reports a job's summary
+const daemonProgressInterval = 2 * time.Second
+
+// rpcServer exposes the STE of 'azcopy daemon' over HTTP. There are two APIs:
+//   - POST /rpc?commandType=<RpcCmd> accepts the RPCs of the CLI, so that the regular commands can run against the daemon
+//   - /jobs is a REST API for orchestration: submit, list, inspect, follow, pause, resume and cancel jobs
+//
+// Every request must present the daemon's token as a bearer token.
+type rpcServer struct {
+	token string
+
+	// send executes an RPC against the STE. It is inprocSend, except in tests
+	send func(cmd common.RpcCmd, request interface{}, response interface{}) error
+
+	// submit starts a new job from the command line arguments of copy, sync or remove, and returns its ID
+	submit func(args []string) (common.JobID, error)
+
+	progressInterval time.Duration
+
+	// The job admin was designed for a single CLI, which never changes a job from two places at once. So the changes to
+	// each job are made one at a time, under its lock in jobLocks, while reads and changes to other jobs go ahead.
+	stateLock *sync.Mutex
+	jobLocks  map[common.JobID]*sync.Mutex
+	mux       *http.ServeMux
+}
+
+func newRpcServer(token string, submit func(args []string) (common.JobID, error)) *rpcServer {
+	s := &rpcServer{
+		token:            token,
+		send:             inprocSend,
+		submit:           submit,
+		progressInterval: daemonProgressInterval,
+		stateLock:        &sync.Mutex{},
+		jobLocks:         map[common.JobID]*sync.Mutex{},
+		mux:              http.NewServeMux(),
+	}
+	s.mux.HandleFunc(daemonRpcPath, s.handleRpc)
+	s.mux.HandleFunc("/jobs", s.handleJobs)
+	s.mux.HandleFunc("/jobs/", s.handleJob)
+	return s
+}
+
+func (s *rpcServer) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	if !s.isAuthorized(request) {
+		writer.Header().Set("WWW-Authenticate", "Bearer")
+		http.Error(writer, "missing or invalid bearer token", http.StatusUnauthorized)
+		return
+	}
+	s.mux.ServeHTTP(writer, request)
+}
+
+func (s *rpcServer) isAuthorized(request *http.Request) bool {
+	if s.token == "" {
+		return false
+	}
+	presented := strings.TrimPrefix(request.Header.Get("Authorization"), "Bearer ")
+	return subtle.ConstantTimeCompare([]byte(presented), []byte(s.token)) == 1
+}
+
+func (s *rpcServer) execute(cmd common.RpcCmd, request interface{}, response interface{}) error {
+	if jobID, changesJob := jobChangedBy(cmd, request); changesJob {
+		jobLock := s.lockOf(jobID)
+		jobLock.Lock()
+		defer jobLock.Unlock()
+	}
+	return s.send(cmd, request, response)
+}
+
+// lockOf returns the lock that's held while the job is changed
+func (s *rpcServer) lockOf(jobID common.JobID) *sync.Mutex {
+	s.stateLock.Lock()
+	defer s.stateLock.Unlock()
+
+	jobLock, ok := s.jobLocks[jobID]
+	if !ok {
+		jobLock = &sync.Mutex{}
+		s.jobLocks[jobID] = jobLock
+	}
+	return jobLock
+}
+
+// jobChangedBy returns the job that the RPC changes, if it changes one
+func jobChangedBy(cmd common.RpcCmd, request interface{}) (common.JobID, bool) {
+	switch cmd {
+	case common.ERpcCmd.CopyJobPartOrder():
+		return request.(*common.CopyJobPartOrderRequest).JobID, true
+	case common.ERpcCmd.PauseJob(), common.ERpcCmd.CancelJob():
+		return request.(common.JobID), true
+	case common.ERpcCmd.ResumeJob():
+		return request.(*common.ResumeJobRequest).JobID, true
+	}
+	return common.JobID{}, false
+}
+
+// handleRpc decodes the request into the same shape that the CLI passes to Rpc for the given command
+func (s *rpcServer) handleRpc(writer http.ResponseWriter, request *http.Request) {
+	if request.Method != http.MethodPost {
+		http.Error(writer, "RPCs must be POSTed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var rpcCmd common.RpcCmd
+	if err := rpcCmd.Parse(request.URL.Query().Get("commandType")); err != nil {
+		http.Error(writer, "unknown commandType", http.StatusBadRequest)
+		return
+	}
+
+	body, err := ioutil.ReadAll(request.Body)
+	if err != nil {
+		http.Error(writer, "error reading request body", http.StatusBadRequest)
+		return
+	}
+
+	var rpcRequest, rpcResponse interface{}
+	switch rpcCmd {
+	case common.ERpcCmd.CopyJobPartOrder():
+		order := &common.CopyJobPartOrderRequest{}
+		if err = json.Unmarshal(body, order); err == nil && order.ForceWrite == common.EOverwriteOption.Prompt() {
+			err = errors.New("the daemon cannot prompt; use --overwrite=true, false or ifSourceNewer")
+		}
+		rpcRequest, rpcResponse = order, &common.CopyJobPartOrderResponse{}
+	case common.ERpcCmd.ListJobs():
+		var status common.JobStatus
+		err = json.Unmarshal(body, &status)
+		rpcRequest, rpcResponse = status, &common.ListJobsResponse{}
+	case common.ERpcCmd.ListJobSummary():
+		jobID := &common.JobID{}
+		err = json.Unmarshal(body, jobID)
+		rpcRequest, rpcResponse = jobID, &common.ListJobSummaryResponse{}
+	case common.ERpcCmd.ListJobTransfers():
+		var transfersRequest common.ListJobTransfersRequest
+		err = json.Unmarshal(body, &transfersRequest)
+		rpcRequest, rpcResponse = transfersRequest, &common.ListJobTransfersResponse{}
+	case common.ERpcCmd.PauseJob(), common.ERpcCmd.CancelJob():
+		var jobID common.JobID
+		err = json.Unmarshal(body, &jobID)
+		rpcRequest, rpcResponse = jobID, &common.CancelPauseResumeResponse{}
+	case common.ERpcCmd.ResumeJob():
+		resumeRequest := &common.ResumeJobRequest{}
+		err = json.Unmarshal(body, resumeRequest)
+		rpcRequest, rpcResponse = resumeRequest, &common.CancelPauseResumeResponse{}
+	case common.ERpcCmd.GetJobFromTo():
+		fromToRequest := &common.GetJobFromToRequest{}
+		err = json.Unmarshal(body, fromToRequest)
+		rpcRequest, rpcResponse = fromToRequest, &common.GetJobFromToResponse{}
+	default:
+		http.Error(writer, fmt.Sprintf("%s is not supported by the daemon", rpcCmd.String()), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(writer, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.respond(writer, rpcCmd, rpcRequest, rpcResponse)
+}
+
+func (s *rpcServer) respond(writer http.ResponseWriter, rpcCmd common.RpcCmd, rpcRequest interface{}, rpcResponse interface{}) {
+	if err := s.execute(rpcCmd, rpcRequest, rpcResponse); err != nil {
+		http.Error(writer, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJson(writer, http.StatusOK, rpcResponse)
+}
+
+func writeJson(writer http.ResponseWriter, statusCode int, v interface{}) {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		http.Error(writer, "error serializing response", http.StatusInternalServerError)
+		return
+	}
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(statusCode)
+	_, _ = writer.Write(payload)
+}
+
+// SubmitJobRequest is the body of POST /jobs. Args are the arguments of an azcopy command line, e.g.
+// ["copy", "/data", "https://account.blob.core.windows.net/container?<SAS>", "--recursive"]
+type SubmitJobRequest struct {
+	Args []string
+}
+
+// SubmitJobResponse is returned by POST /jobs once the first part of the job has reached the daemon
+type SubmitJobResponse struct {
+	JobID common.JobID
+}
+
+// handleJobs serves GET /jobs[?status=<JobStatus>] and POST /jobs
+func (s *rpcServer) handleJobs(writer http.ResponseWriter, request *http.Request) {
+	switch request.Method {
+	case http.MethodGet:
+		status := common.EJobStatus.All()
+		if raw := request.URL.Query().Get("status"); raw != "" {
+			if err := status.Parse(raw); err != nil {
+				http.Error(writer, "invalid status: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+		s.respond(writer, common.ERpcCmd.ListJobs(), status, &common.ListJobsResponse{})
+
+	case http.MethodPost:
+		var submitRequest SubmitJobRequest
+		if err := json.NewDecoder(request.Body).Decode(&submitRequest); err != nil {
+			http.Error(writer, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := validateDaemonJobArgs(submitRequest.Args); err != nil {
+			http.Error(writer, err.Error(), http.StatusBadRequest)
+			return
+		}
+		jobID, err := s.submit(submitRequest.Args)
+		if err != nil {
+			http.Error(writer, err.Error(), http.StatusUnprocessableEntity)
+			return
+		}
+		writeJson(writer, http.StatusAccepted, SubmitJobResponse{JobID: jobID})
+
+	default:
+		http.Error(writer, "use GET or POST", http.StatusMethodNotAllowed)
+	}
+}
+
+// daemonJobFlags are the flags of copy, sync and remove that a submitted job may use. They only shape the job itself.
+// The others are left out because they would act on the daemon's host rather than the job: running hooks, writing
+// transfer events, serving metrics, reading stdin or trusting other domains with tokens. So are those that the daemon
+// sets itself, like --output-type.
+var daemonJobFlags = map[string]bool{
+	"as-subdir": true, "backup": true, "blob-tags": true, "blob-type": true, "block-blob-tier": true, "block-size-mb": true,
+	"cache-control": true, "cap-mbps": true, "check-length": true, "check-md5": true, "content-disposition": true,
+	"content-encoding": true, "content-language": true, "content-type": true, "cpk-by-name": true, "cpk-by-value": true,
+	"decompress": true, "delete-destination": true, "delete-snapshots": true, "disable-auto-decoding": true,
+	"disk-image-format": true, "dry-run": true, "exclude": true, "exclude-attributes": true, "exclude-blob-type": true,
+	"exclude-path": true, "exclude-pattern": true, "exclude-regex": true, "flush-threshold": true, "follow": true,
+	"follow-symlinks": true, "force-if-read-only": true, "from-to": true, "include": true, "include-after": true,
+	"include-attributes": true, "include-before": true, "include-directory-stub": true, "include-path": true,
+	"include-pattern": true, "include-regex": true, "incremental-from": true, "job-cap-mbps": true,
+	"job-max-concurrency": true, "job-priority": true, "keep-latest": true, "list-of-files": true,
+	"list-of-versions": true, "log-level": true, "metadata": true, "mirror-mode": true, "no-guess-mime-type": true,
+	"older-than": true, "overwrite": true, "page-blob-tier": true, "permanent-delete": true,
+	"preserve-last-modified-time": true, "preserve-owner": true, "preserve-permissions": true,
+	"preserve-smb-info": true, "preserve-smb-permissions": true, "purge": true, "put-md5": true, "recursive": true,
+	"s2s-detect-source-changed": true, "s2s-get-properties-in-backend": true, "s2s-handle-invalid-metadata": true,
+	"s2s-preserve-access-tier": true, "s2s-preserve-blob-tags": true, "s2s-preserve-properties": true,
+}
+
+// validateDaemonJobArgs only admits the commands that create jobs, with the flags in daemonJobFlags
+func validateDaemonJobArgs(args []string) error {
+	if len(args) == 0 {
+		return errors.New("Args must start with copy, sync or remove")
+	}
+	switch args[0] {
+	case "copy", "cp", "sync", "remove", "rm":
+	default:
+		return fmt.Errorf("%q cannot be submitted to the daemon; Args must start with copy, sync or remove", args[0])
+	}
+	for _, a := range args[1:] {
+		if a == "--" {
+			break // the rest are positional arguments
+		}
+		if !strings.HasPrefix(a, "-") {
+			continue
+		}
+		name := strings.SplitN(strings.TrimPrefix(a, "--"), "=", 2)[0]
+		if !strings.HasPrefix(a, "--") || !daemonJobFlags[name] {
+			return fmt.Errorf("%s cannot be used with jobs that are submitted to the daemon (values that start with - must be given as --flag=value)", strings.SplitN(a, "=", 2)[0])
+		}
+	}
+	return nil
+}
+
+// handleJob serves /jobs/{id}, /jobs/{id}/progress, /jobs/{id}/transfers and POST /jobs/{id}/{pause,cancel,resume}
+func (s *rpcServer) handleJob(writer http.ResponseWriter, request *http.Request) {
+	segments := strings.Split(strings.Trim(strings.TrimPrefix(request.URL.Path, "/jobs/"), "/"), "/")
+	jobID, err := common.ParseJobID(segments[0])
+	if err != nil || len(segments) > 2 {
+		http.NotFound(writer, request)
+		return
+	}
+	action := ""
+	if len(segments) == 2 {
+		action = segments[1]
+	}
+
+	wantMethod := http.MethodGet
+	if action == "pause" || action == "cancel" || action == "resume" {
+		wantMethod = http.MethodPost
+	}
+	if request.Method != wantMethod {
+		http.Error(writer, "use "+wantMethod, http.StatusMethodNotAllowed)
+		return
+	}
+
+	switch action {
+	case "":
+		s.respond(writer, common.ERpcCmd.ListJobSummary(), &jobID, &common.ListJobSummaryResponse{})
+	case "progress":
+		s.streamProgress(writer, request, jobID)
+	case "transfers":
+		transfersRequest := common.ListJobTransfersRequest{JobID: jobID, OfStatus: common.ETransferStatus.All()}
+		if raw := request.URL.Query().Get("status"); raw != "" {
+			if err := transfersRequest.OfStatus.Parse(raw); err != nil {
+				http.Error(writer, "invalid status: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+		s.respond(writer, common.ERpcCmd.ListJobTransfers(), transfersRequest, &common.ListJobTransfersResponse{})
+	case "pause":
+		s.respond(writer, common.ERpcCmd.PauseJob(), jobID, &common.CancelPauseResumeResponse{})
+	case "cancel":
+		s.respond(writer, common.ERpcCmd.CancelJob(), jobID, &common.CancelPauseResumeResponse{})
+	case "resume":
+		// the body is optional, and carries fresh SAS tokens or credentials if the job needs them
+		resumeRequest := &common.ResumeJobRequest{}
+		if err := json.NewDecoder(request.Body).Decode(resumeRequest); err != nil && err != io.EOF {
+			http.Error(writer, err.Error(), http.StatusBadRequest)
+			return
+		}
+		resumeRequest.JobID = jobID
+		s.respond(writer, common.ERpcCmd.ResumeJob(), resumeRequest, &common.CancelPauseResumeResponse{})
+	default:
+		http.NotFound(writer, request)
+	}
+}
+
+// streamProgress writes one JSON summary per line until the job is done or the client goes away
+func (s *rpcServer) streamProgress(writer http.ResponseWriter, request *http.Request, jobID common.JobID) {
+	flusher, _ := writer.(http.Flusher)
+	writer.Header().Set("Content-Type", "application/x-ndjson")
+	writer.WriteHeader(http.StatusOK)
+
+	encoder := json.NewEncoder(writer)
+	for {
+		var summary common.ListJobSummaryResponse
+		if err := s.execute(common.ERpcCmd.ListJobSummary(), &jobID, &summary); err != nil {
+			return
+		}
+		if encoder.Encode(summary) != nil {
+			return
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		if summary.ErrorMsg != "" || summary.JobStatus.IsJobDone() {
+			return
+		}
+
+		select {
+		case <-request.Context().Done():
+			return
+		case <-time.After(s.progressInterval):
+		}
+	}
+}
@@ -0,0 +1,166 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+)
+
+func init() {
+	raw := rawCopyCmdArgs{}
+	// setPropertiesCmd represents the set-properties command
+	var setPropertiesCmd = &cobra.Command{
+		Use:     "set-properties [resourceURL]",
+		Aliases: []string{"set-props", "sp"},
+		Short:   setPropertiesCmdShortDescription,
+		Long:    setPropertiesCmdLongDescription,
+		Example: setPropertiesCmdExample,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return fmt.Errorf("set-properties command only takes 1 arguments. Passed %d arguments", len(args))
+			}
+
+			// the blobs to change are the source, and there's no destination
+			raw.src = args[0]
+
+			if raw.fromTo == "" {
+				srcLocationType := InferArgumentLocation(raw.src)
+				if srcLocationType != common.ELocation.Blob() {
+					return fmt.Errorf("invalid source type %s to set the properties of. azcopy supports setting the properties of blobs", srcLocationType.String())
+				}
+				raw.fromTo = common.EFromTo.BlobNone().String()
+			} else if !strings.EqualFold(raw.fromTo, common.EFromTo.BlobNone().String()) {
+				return fmt.Errorf("invalid from-to %s. Please enter a valid one, i.e. BlobNone", raw.fromTo)
+			}
+
+			// unlike remove, this command has tier flags, which setMandatoryDefaults would overwrite
+			blockBlobTier, pageBlobTier := raw.blockBlobTier, raw.pageBlobTier
+			raw.setMandatoryDefaults()
+			if blockBlobTier != "" {
+				raw.blockBlobTier = blockBlobTier
+			}
+			if pageBlobTier != "" {
+				raw.pageBlobTier = pageBlobTier
+			}
+			return nil
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			glcm.EnableInputWatcher()
+			if cancelFromStdin {
+				glcm.EnableCancelFromStdIn()
+			}
+
+			cooked, err := raw.cook()
+			if err != nil {
+				glcm.Error("failed to parse user input due to error: " + err.Error())
+			}
+
+			cooked.propertiesToSet, err = propertiesToSet(&cooked, cmd.Flags().Changed("metadata"), cmd.Flags().Changed("blob-tags"))
+			if err != nil {
+				glcm.Error("failed to parse user input due to error: " + err.Error())
+			}
+
+			cooked.commandString = copyHandlerUtil{}.ConstructCommandStringFromArgs()
+			err = cooked.process()
+			if err != nil {
+				glcm.Error("failed to perform set-properties command due to error: " + err.Error())
+			}
+
+			if cooked.dryrunMode {
+				glcm.Exit(nil, common.EExitCode.Success())
+			}
+
+			glcm.SurrenderControl()
+		},
+	}
+	rootCmd.AddCommand(setPropertiesCmd)
+
+	setPropertiesCmd.PersistentFlags().BoolVar(&raw.recursive, "recursive", false, "Look into sub-directories recursively when setting the properties of the blobs in a virtual directory.")
+	setPropertiesCmd.PersistentFlags().StringVar(&raw.logVerbosity, "log-level", "INFO", "Define the log verbosity for the log file. Available levels include: INFO(all requests/responses), WARNING(slow responses), ERROR(only failed requests), and NONE(no output logs). (default 'INFO')")
+
+	// which blobs to set the properties of
+	setPropertiesCmd.PersistentFlags().StringVar(&raw.include, "include-pattern", "", "Include only blobs where the name matches the pattern list. For example: *.jpg;*.pdf;exactName")
+	setPropertiesCmd.PersistentFlags().StringVar(&raw.includePath, "include-path", "", "Include only these paths when setting properties. "+
+		"This option does not support wildcard characters (*). Checks relative path prefix. For example: myFolder;myFolder/subDirName/file.pdf")
+	setPropertiesCmd.PersistentFlags().StringVar(&raw.includeRegex, "include-regex", "", "Include only the relative paths of the blobs that match with the regular expressions. Separate regular expressions with ';'.")
+	setPropertiesCmd.PersistentFlags().StringVar(&raw.exclude, "exclude-pattern", "", "Exclude blobs where the name matches the pattern list. For example: *.jpg;*.pdf;exactName")
+	setPropertiesCmd.PersistentFlags().StringVar(&raw.excludePath, "exclude-path", "", "Exclude these paths when setting properties. "+
+		"This option does not support wildcard characters (*). Checks relative path prefix. For example: myFolder;myFolder/subDirName/file.pdf")
+	setPropertiesCmd.PersistentFlags().StringVar(&raw.excludeRegex, "exclude-regex", "", "Exclude all the relative paths of the blobs that match with the regular expressions. Separate regular expressions with ';'.")
+	setPropertiesCmd.PersistentFlags().StringVar(&raw.excludeBlobType, "exclude-blob-type", "", "Optionally specifies the type of blob (BlockBlob/ PageBlob/ AppendBlob) to exclude when setting properties. Separate the blob types with ';'.")
+	setPropertiesCmd.PersistentFlags().StringVar(&raw.includeBefore, "include-before", "", "Include only those blobs modified before or on the given date/time. The value should be in ISO8601 format. If no timezone is specified, the value is assumed to be in the local timezone of the machine running AzCopy. E.g. '2020-08-19T15:04:00Z' for a UTC time, or '2020-08-19' for midnight (00:00) in the local timezone.")
+	setPropertiesCmd.PersistentFlags().StringVar(&raw.includeAfter, "include-after", "", "Include only those blobs modified on or after the given date/time. The value should be in ISO8601 format. If no timezone is specified, the value is assumed to be in the local timezone of the machine running AzCopy. E.g. '2020-08-19T15:04:00Z' for a UTC time, or '2020-08-19' for midnight (00:00) in the local timezone.")
+	setPropertiesCmd.PersistentFlags().StringVar(&raw.listOfFilesToCopy, "list-of-files", "", "Defines the location of a file which contains the list of blobs and virtual directories to set the properties of. The relative paths should be delimited by line breaks, and the paths should NOT be URL-encoded.")
+	setPropertiesCmd.PersistentFlags().StringVar(&raw.listOfVersionIDs, "list-of-versions", "", "Specifies a file where each version id is listed on a separate line. Ensure that the source must point to a single blob and all the version ids specified in the file using this flag must belong to the source blob only. The tier of the specified versions will be set.")
+
+	// which properties to set
+	setPropertiesCmd.PersistentFlags().StringVar(&raw.blockBlobTier, "block-blob-tier", "", "Sets the access tier of the block blobs. Available values: Hot, Cool, Archive.")
+	setPropertiesCmd.PersistentFlags().StringVar(&raw.pageBlobTier, "page-blob-tier", "", "Sets the access tier of the page blobs, for premium accounts. Available values: P10, P15, P20, P30, P4, P40, P50, P6.")
+	setPropertiesCmd.PersistentFlags().StringVar(&raw.rehydratePriority, "rehydrate-priority", "", "How soon archived blobs are rehydrated, when their tier is set: Standard or High. By default, it's Standard.")
+	setPropertiesCmd.PersistentFlags().StringVar(&raw.metadata, "metadata", "", "Replaces the metadata of the blobs with these key-value pairs. For example: key1=value1;key2=value2. An empty value clears the metadata.")
+	setPropertiesCmd.PersistentFlags().StringVar(&raw.blobTags, "blob-tags", "", "Replaces the blob index tags of the blobs with these key-value pairs. For example: key1=value1&key2=value2. An empty value clears the tags.")
+	setPropertiesCmd.PersistentFlags().StringVar(&raw.contentType, "content-type", "", "Sets the content type of the blobs.")
+	setPropertiesCmd.PersistentFlags().StringVar(&raw.contentEncoding, "content-encoding", "", "Sets the content encoding of the blobs.")
+	setPropertiesCmd.PersistentFlags().StringVar(&raw.contentLanguage, "content-language", "", "Sets the content language of the blobs.")
+	setPropertiesCmd.PersistentFlags().StringVar(&raw.contentDisposition, "content-disposition", "", "Sets the content disposition of the blobs.")
+	setPropertiesCmd.PersistentFlags().StringVar(&raw.cacheControl, "cache-control", "", "Sets the cache control of the blobs.")
+
+	setPropertiesCmd.PersistentFlags().StringVar(&raw.transferEvents, "transfer-events", "", "Writes an event, as a line of JSON, as the properties of each blob are set, or fail to be set: to the standard output if this option is set to 'stdout', or else to the given file or named pipe.")
+	setPropertiesCmd.PersistentFlags().StringVar(&raw.hookURL, "hook-url", "", "URL to POST a JSON description of the job to, as it starts and ends and as transfers fail. See --hook-events.")
+	setPropertiesCmd.PersistentFlags().StringVar(&raw.hookCommand, "hook-command", "", "Command to run, with a shell, as the job starts and ends and as transfers fail. "+
+		"It's given the JSON that --hook-url is sent on its standard input, and the gist of it in environment variables such as AZCOPY_HOOK_EVENT, AZCOPY_JOB_ID and AZCOPY_JOB_STATUS.")
+	setPropertiesCmd.PersistentFlags().StringVar(&raw.hookEvents, "hook-events", "", "Comma-separated list of the events that the hooks are run for: JobStarted, JobCompleted, JobCancelled and TransferFailed. By default, they are run for all of them.")
+	setPropertiesCmd.PersistentFlags().BoolVar(&raw.dryrun, "dry-run", false, "Prints the paths of the blobs whose properties would be set by the command. This flag does not set them.")
+	setPropertiesCmd.PersistentFlags().StringVar(&raw.fromTo, "from-to", "", "Optionally specifies the source destination combination. For Example: BlobNone")
+}
+
+// propertiesToSet works out which properties the job sets. Metadata and tags are set when their flags are given, even if
+// they're empty, so that they may be cleared, while a header is only set when it's given a value.
+func propertiesToSet(cooked *CookedCopyCmdArgs, metadataGiven bool, blobTagsGiven bool) (common.SetPropertiesFlags, error) {
+	flags := common.ESetPropertiesFlags.None()
+	if cooked.blockBlobTier != common.EBlockBlobTier.None() || cooked.pageBlobTier != common.EPageBlobTier.None() {
+		flags |= common.ESetPropertiesFlags.SetTier()
+	} else if cooked.rehydratePriority != common.ERehydratePriorityType.None() {
+		return flags, errors.New("rehydrate-priority requires block-blob-tier or page-blob-tier")
+	}
+	if metadataGiven {
+		flags |= common.ESetPropertiesFlags.SetMetadata()
+	}
+	if blobTagsGiven {
+		flags |= common.ESetPropertiesFlags.SetBlobTags()
+	}
+	if cooked.contentType != "" || cooked.contentEncoding != "" || cooked.contentLanguage != "" ||
+		cooked.contentDisposition != "" || cooked.cacheControl != "" {
+		flags |= common.ESetPropertiesFlags.SetHTTPHeaders()
+	}
+
+	if flags == common.ESetPropertiesFlags.None() {
+		return flags, errors.New("no properties to set. Please specify at least one of block-blob-tier, page-blob-tier, metadata, blob-tags, " +
+			"content-type, content-encoding, content-language, content-disposition or cache-control")
+	}
+	return flags, nil
+}
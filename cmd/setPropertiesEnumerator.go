@@ -0,0 +1,71 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+)
+
+var NothingToSetPropertiesOfError = errors.New("nothing found to set the properties of")
+
+// provide an enumerator that lists the given blobs
+// and schedules transfers that set their properties in place
+func newSetPropertiesEnumerator(ctx context.Context, cca *CookedCopyCmdArgs) (enumerator *CopyEnumerator, err error) {
+	var sourceTraverser ResourceTraverser
+
+	// Include-path is handled by ListOfFilesChannel.
+	sourceTraverser, err = InitResourceTraverser(cca.Source, cca.FromTo.From(), &ctx, &cca.credentialInfo,
+		nil, cca.ListOfFilesChannel, cca.Recursive, false, cca.IncludeDirectoryStubs,
+		common.EPermanentDeleteOption.None(), func(common.EntityType) {}, cca.ListOfVersionIDs, false,
+		cca.LogVerbosity.ToPipelineLogLevel(), cca.CpkOptions)
+
+	// report failure to create traverser
+	if err != nil {
+		return nil, err
+	}
+
+	// the same filters as copy, e.g. include/exclude patterns and paths, regexes, blob types and dates
+	filters := cca.InitModularFilters()
+
+	// blobs have no folders, and so no folder properties, to set
+	transferScheduler := newSetPropertiesTransferProcessor(cca, NumOfFilesPerDispatchJobPart, common.EFolderPropertiesOption.NoFolders())
+
+	finalize := func() error {
+		_, err := transferScheduler.dispatchFinalPart()
+		if err != nil {
+			if cca.dryrunMode {
+				return nil
+			} else if err == NothingScheduledError {
+				// No log file needed. Logging begins as a part of awaiting job completion.
+				return NothingToSetPropertiesOfError
+			}
+
+			return err
+		}
+
+		return nil
+	}
+
+	return NewCopyEnumerator(sourceTraverser, filters, transferScheduler.scheduleCopyTransfer, finalize), nil
+}
@@ -72,6 +72,19 @@ type rawSyncCmdArgs struct {
 	// this flag is to disable comparator and overwrite files at destination irrespective
 	mirrorMode bool
 
+	// Optional scheduling limits, applied when several jobs share this process's transfer engine
+	jobPriority       string
+	jobMaxConcurrency int
+	jobCapMbps        float64
+
+	// where to write an event as each transfer is done: stdout, or a file or named pipe
+	transferEvents string
+
+	// what to notify, or run, as the job starts and ends and as transfers fail
+	hookURL     string
+	hookCommand string
+	hookEvents  string
+
 	s2sPreserveAccessTier bool
 	// Opt-in flag to preserve the blob index tags during service to service transfer.
 	s2sPreserveBlobTags bool
@@ -120,7 +133,16 @@ func (raw *rawSyncCmdArgs) validateURLIsNotServiceLevel(url string, location com
 
 // validates and transform raw input into cooked input
 func (raw *rawSyncCmdArgs) cook() (cookedSyncCmdArgs, error) {
-	cooked := cookedSyncCmdArgs{}
+	cooked, err := raw.cookWith(glcm, azcopyCurrentJobID)
+	if cooked.scanningLogger != nil {
+		azcopyScanningLogger = cooked.scanningLogger
+	}
+	return cooked, err
+}
+
+// cookWith cooks the job with the given ID, whose output goes to lcm (rather than to glcm, e.g. when it's run in-process)
+func (raw *rawSyncCmdArgs) cookWith(lcm common.LifecycleMgr, jobID common.JobID) (cookedSyncCmdArgs, error) {
+	cooked := cookedSyncCmdArgs{lcm: lcm}
 
 	err := cooked.logVerbosity.Parse(raw.logVerbosity)
 	if err != nil {
@@ -128,11 +150,12 @@ func (raw *rawSyncCmdArgs) cook() (cookedSyncCmdArgs, error) {
 	}
 
 	// set up the front end scanning logger
-	azcopyScanningLogger = common.NewJobLogger(azcopyCurrentJobID, cooked.logVerbosity, azcopyLogPathFolder, "-scanning")
-	azcopyScanningLogger.OpenLog()
-	glcm.RegisterCloseFunc(func() {
-		azcopyScanningLogger.CloseLog()
+	scanningLogger := common.NewJobLogger(jobID, cooked.logVerbosity, azcopyLogPathFolder, "-scanning", azcopyLogSettings)
+	scanningLogger.OpenLog()
+	lcm.RegisterCloseFunc(func() {
+		scanningLogger.CloseLog()
 	})
+	cooked.scanningLogger = scanningLogger
 
 	// this if statement ladder remains instead of being separated to help determine valid combinations for sync
 	// consider making a map of valid source/dest combos and consolidating this to generic source/dest setups, akin to the lower if statement
@@ -142,13 +165,13 @@ func (raw *rawSyncCmdArgs) cook() (cookedSyncCmdArgs, error) {
 	srcHNS, dstHNS := false, false
 	if loc := InferArgumentLocation(raw.src); loc == common.ELocation.BlobFS() {
 		raw.src = strings.Replace(raw.src, ".dfs", ".blob", 1)
-		glcm.Info("Sync operates only on blob endpoint. Switching to use blob endpoint on source account.")
+		lcm.Info("Sync operates only on blob endpoint. Switching to use blob endpoint on source account.")
 		srcHNS = true
 	}
 
 	if loc := InferArgumentLocation(raw.dst); loc == common.ELocation.BlobFS() {
 		raw.dst = strings.Replace(raw.dst, ".dfs", ".blob", 1)
-		glcm.Info("Sync operates only on blob endpoint. Switching to use blob endpoint on destination account.")
+		lcm.Info("Sync operates only on blob endpoint. Switching to use blob endpoint on destination account.")
 		dstHNS = true
 	}
 
@@ -200,8 +223,7 @@ func (raw *rawSyncCmdArgs) cook() (cookedSyncCmdArgs, error) {
 		}
 	}
 
-	// use the globally generated JobID
-	cooked.jobID = azcopyCurrentJobID
+	cooked.jobID = jobID
 
 	cooked.blockSize, err = blockSizeInBytes(raw.blockSizeMB)
 	if err != nil {
@@ -255,7 +277,7 @@ func (raw *rawSyncCmdArgs) cook() (cookedSyncCmdArgs, error) {
 
 	isUserPersistingPermissions := raw.preserveSMBPermissions || raw.preservePermissions
 	if cooked.preserveSMBInfo && !isUserPersistingPermissions {
-		glcm.Info("Please note: the preserve-permissions flag is set to false, thus AzCopy will not copy SMB ACLs between the source and destination. To learn more: https://aka.ms/AzCopyandAzureFiles.")
+		lcm.Info("Please note: the preserve-permissions flag is set to false, thus AzCopy will not copy SMB ACLs between the source and destination. To learn more: https://aka.ms/AzCopyandAzureFiles.")
 	}
 
 	if err = validatePreserveSMBPropertyOption(isUserPersistingPermissions, cooked.fromTo, nil, PreservePermissionsFlag); err != nil {
@@ -271,7 +293,7 @@ func (raw *rawSyncCmdArgs) cook() (cookedSyncCmdArgs, error) {
 	}
 
 	cooked.putMd5 = raw.putMd5
-	if err = validatePutMd5(cooked.putMd5, cooked.fromTo); err != nil {
+	if err = validatePutMd5(lcm, cooked.putMd5, cooked.fromTo); err != nil {
 		return cooked, err
 	}
 
@@ -315,7 +337,7 @@ func (raw *rawSyncCmdArgs) cook() (cookedSyncCmdArgs, error) {
 	// We only support transfer from source encrypted by user key when user wishes to download.
 	// Due to service limitation, S2S transfer is not supported for source encrypted by user key.
 	if cooked.fromTo.IsDownload() && (cpkOptions.CpkScopeInfo != "" || cpkOptions.CpkInfo) {
-		glcm.Info("Client Provided Key for encryption/decryption is provided for download scenario. " +
+		lcm.Info("Client Provided Key for encryption/decryption is provided for download scenario. " +
 			"Assuming source is encrypted.")
 		cpkOptions.IsSourceEncrypted = true
 	}
@@ -324,6 +346,20 @@ func (raw *rawSyncCmdArgs) cook() (cookedSyncCmdArgs, error) {
 
 	cooked.mirrorMode = raw.mirrorMode
 
+	cooked.jobPriority, cooked.jobMaxConcurrency, cooked.jobMaxBytesPerSecond, err = validateJobLimits(raw.jobPriority, raw.jobMaxConcurrency, raw.jobCapMbps)
+	if err != nil {
+		return cooked, err
+	}
+
+	if !raw.dryrun {
+		if cooked.transferEvents, err = openTransferEvents(lcm, raw.transferEvents); err != nil {
+			return cooked, err
+		}
+		if cooked.hooks, err = newJobHooks(lcm, raw.hookURL, raw.hookCommand, raw.hookEvents); err != nil {
+			return cooked, err
+		}
+	}
+
 	cooked.includeRegex = raw.parsePatterns(raw.includeRegex)
 	cooked.excludeRegex = raw.parsePatterns(raw.excludeRegex)
 
@@ -384,6 +420,10 @@ type cookedSyncCmdArgs struct {
 	// generated
 	jobID common.JobID
 
+	// where the output of the job goes, and the log of its enumeration
+	lcm            common.LifecycleMgr
+	scanningLogger common.ILoggerResetable
+
 	// variables used to calculate progress
 	// intervalStartTime holds the last time value when the progress summary was fetched
 	// the value of this variable is used to calculate the throughput
@@ -412,6 +452,17 @@ type cookedSyncCmdArgs struct {
 
 	mirrorMode bool
 
+	// how this job shares the transfer engine with other jobs running in the same process
+	jobPriority          common.JobPriority
+	jobMaxConcurrency    int32
+	jobMaxBytesPerSecond int64
+
+	// receives an event as each transfer is done, if --transfer-events is set
+	transferEvents *common.TransferEventWriter
+
+	// run as the job starts and ends and as transfers fail, if --hook-url or --hook-command is set
+	hooks *common.JobHooks
+
 	dryrunMode bool
 }
 
@@ -448,7 +499,7 @@ func (cca *cookedSyncCmdArgs) scanningComplete() bool {
 // if blocking is specified to false, then another goroutine spawns and wait out the job
 func (cca *cookedSyncCmdArgs) waitUntilJobCompletion(blocking bool) {
 	// print initial message to indicate that the job is starting
-	glcm.Init(common.GetStandardInitOutputBuilder(cca.jobID.String(), fmt.Sprintf("%s%s%s.log", azcopyLogPathFolder, common.OS_PATH_SEPARATOR, cca.jobID), false, ""))
+	cca.lcm.Init(common.GetStandardInitOutputBuilder(cca.jobID.String(), fmt.Sprintf("%s%s%s.log", azcopyLogPathFolder, common.OS_PATH_SEPARATOR, cca.jobID), false, ""))
 
 	// initialize the times necessary to track progress
 	cca.jobStartTime = time.Now()
@@ -457,11 +508,11 @@ func (cca *cookedSyncCmdArgs) waitUntilJobCompletion(blocking bool) {
 
 	// hand over control to the lifecycle manager if blocking
 	if blocking {
-		glcm.InitiateProgressReporting(cca)
-		glcm.SurrenderControl()
+		cca.lcm.InitiateProgressReporting(cca)
+		cca.lcm.SurrenderControl()
 	} else {
 		// non-blocking, return after spawning a go routine to watch the job
-		glcm.InitiateProgressReporting(cca)
+		cca.lcm.InitiateProgressReporting(cca)
 	}
 }
 
@@ -577,6 +628,7 @@ func (cca *cookedSyncCmdArgs) ReportProgressOrExit(lcm common.LifecycleMgr) (tot
 	})
 
 	if jobDone {
+		flushTransferEvents(lcm, cca.transferEvents)
 		exitCode := common.EExitCode.Success()
 		if summary.TransfersFailed > 0 {
 			exitCode = common.EExitCode.Error()
@@ -634,6 +686,12 @@ Final Job Status: %v%s%s
 
 func (cca *cookedSyncCmdArgs) process() (err error) {
 	ctx := context.WithValue(context.TODO(), ste.ServiceAPIVersionOverride, ste.DefaultServiceApiVersion)
+	ctx, span := startEnumerationSpan(ctx, cca.jobID)
+	defer func() { endEnumerationSpan(span, err) }()
+	if cca.scanningLogger != nil {
+		// so that the requests of the enumeration are logged with their fields, in the JSON log format
+		ctx = common.WithLogContext(ctx, cca.scanningLogger, common.LogFields{})
+	}
 
 	err = common.SetBackupMode(cca.backupMode, cca.fromTo)
 	if err != nil {
@@ -642,13 +700,13 @@ func (cca *cookedSyncCmdArgs) process() (err error) {
 
 	// Verifies credential type and initializes credential info.
 	// Note that this is for the destination.
-	cca.credentialInfo, _, err = GetCredentialInfoForLocation(ctx, cca.fromTo.To(), cca.destination.Value, cca.destination.SAS, false, cca.cpkOptions)
+	cca.credentialInfo, _, err = GetCredentialInfoForLocation(ctx, cca.lcm, cca.fromTo.To(), cca.destination.Value, cca.destination.SAS, false, cca.cpkOptions)
 
 	if err != nil {
 		return err
 	}
 
-	srcCredInfo, _, err := GetCredentialInfoForLocation(ctx, cca.fromTo.From(), cca.source.Value, cca.source.SAS, true, cca.cpkOptions)
+	srcCredInfo, _, err := GetCredentialInfoForLocation(ctx, cca.lcm, cca.fromTo.From(), cca.source.Value, cca.source.SAS, true, cca.cpkOptions)
 
 	if err != nil {
 		return err
@@ -770,6 +828,14 @@ func init() {
 	syncCmd.PersistentFlags().StringVar(&raw.cpkScopeInfo, "cpk-by-name", "", "Client provided key by name let clients making requests against Azure Blob storage an option to provide an encryption key on a per-request basis. Provided key name will be fetched from Azure Key Vault and will be used to encrypt the data")
 	syncCmd.PersistentFlags().BoolVar(&raw.cpkInfo, "cpk-by-value", false, "Client provided key by name let clients making requests against Azure Blob storage an option to provide an encryption key on a per-request basis. Provided key and its hash will be fetched from environment variables")
 	syncCmd.PersistentFlags().BoolVar(&raw.mirrorMode, "mirror-mode", false, "Disable last-modified-time based comparison and overwrites the conflicting files and blobs at the destination if this flag is set to true. Default is false")
+	syncCmd.PersistentFlags().StringVar(&raw.jobPriority, "job-priority", "Normal", "Share of the transfer engine this job gets when other jobs run in the same process (e.g. in 'azcopy daemon'): Low, Normal or High.")
+	syncCmd.PersistentFlags().IntVar(&raw.jobMaxConcurrency, "job-max-concurrency", 0, "Caps the number of concurrent requests made for this job, without limiting other jobs running in the same process. If this option is set to zero, or it is omitted, the job isn't capped.")
+	syncCmd.PersistentFlags().Float64Var(&raw.jobCapMbps, "job-cap-mbps", 0, "Caps the transfer rate of this job, in megabits per second. It applies within the overall limit set by --cap-mbps. If this option is set to zero, or it is omitted, the job isn't capped.")
+	syncCmd.PersistentFlags().StringVar(&raw.transferEvents, "transfer-events", "", "Writes an event, as a line of JSON, as each transfer completes, fails or is skipped: to the standard output if this option is set to 'stdout', or else to the given file or named pipe.")
+	syncCmd.PersistentFlags().StringVar(&raw.hookURL, "hook-url", "", "URL to POST a JSON description of the job to, as it starts and ends and as transfers fail. See --hook-events.")
+	syncCmd.PersistentFlags().StringVar(&raw.hookCommand, "hook-command", "", "Command to run, with a shell, as the job starts and ends and as transfers fail. "+
+		"It's given the JSON that --hook-url is sent on its standard input, and the gist of it in environment variables such as AZCOPY_HOOK_EVENT, AZCOPY_JOB_ID and AZCOPY_JOB_STATUS.")
+	syncCmd.PersistentFlags().StringVar(&raw.hookEvents, "hook-events", "", "Comma-separated list of the events that the hooks are run for: JobStarted, JobCompleted, JobCancelled and TransferFailed. By default, they are run for all of them.")
 	syncCmd.PersistentFlags().BoolVar(&raw.dryrun, "dry-run", false, "Prints the path of files that would be copied or removed by the sync command. This flag does not copy or remove the actual files.")
 
 	// temp, to assist users with change in param names, by providing a clearer message when these obsolete ones are accidentally used
@@ -786,4 +852,7 @@ func init() {
 	// Deprecate the old persist-smb-permissions flag
 	syncCmd.PersistentFlags().MarkHidden("preserve-smb-permissions")
 	syncCmd.PersistentFlags().BoolVar(&raw.preservePermissions, PreservePermissionsFlag, false, "False by default. Preserves ACLs between aware resources (Windows and Azure Files, or ADLS Gen 2 to ADLS Gen 2). For Hierarchical Namespace accounts, you will need a container SAS or OAuth token with Modify Ownership and Modify Permissions permissions. For downloads, you will also need the --backup flag to restore permissions where the new Owner will not be the user running AzCopy. This flag applies to both files and folders, unless a file-only filter is specified (e.g. include-pattern).")
+
+	// the defaults of the flags, for the syncs that are run in-process
+	rawSyncDefaults = raw
 }
@@ -38,7 +38,7 @@ import (
 
 func (cca *cookedSyncCmdArgs) initEnumerator(ctx context.Context) (enumerator *syncEnumerator, err error) {
 
-	srcCredInfo, srcIsPublic, err := GetCredentialInfoForLocation(ctx, cca.fromTo.From(), cca.source.Value, cca.source.SAS, true, cca.cpkOptions)
+	srcCredInfo, srcIsPublic, err := GetCredentialInfoForLocation(ctx, cca.lcm, cca.fromTo.From(), cca.source.Value, cca.source.SAS, true, cca.cpkOptions)
 
 	if err != nil {
 		return nil, err
@@ -70,7 +70,7 @@ func (cca *cookedSyncCmdArgs) initEnumerator(ctx context.Context) (enumerator *s
 	}
 
 	// Because we can't trust cca.credinfo, given that it's for the overall job, not the individual traversers, we get cred info again here.
-	dstCredInfo, _, err := GetCredentialInfoForLocation(ctx, cca.fromTo.To(), cca.destination.Value,
+	dstCredInfo, _, err := GetCredentialInfoForLocation(ctx, cca.lcm, cca.fromTo.To(), cca.destination.Value,
 		cca.destination.SAS, false, cca.cpkOptions)
 
 	if err != nil {
@@ -126,7 +126,7 @@ func (cca *cookedSyncCmdArgs) initEnumerator(ctx context.Context) (enumerator *s
 	// decide our folder transfer strategy
 	fpo, folderMessage := newFolderPropertyOption(cca.fromTo, cca.recursive, true, filters, cca.preserveSMBInfo, cca.preservePermissions.IsTruthy(), cca.isHNSToHNS, strings.EqualFold(cca.destination.Value, common.Dev_Null)) // sync always acts like stripTopDir=true
 	if !cca.dryrunMode {
-		glcm.Info(folderMessage)
+		cca.lcm.Info(folderMessage)
 	}
 	if jobsAdmin.JobsAdmin != nil {
 		jobsAdmin.JobsAdmin.LogToJobLog(folderMessage, pipeline.LogInfo)
@@ -155,6 +155,9 @@ func (cca *cookedSyncCmdArgs) initEnumerator(ctx context.Context) (enumerator *s
 		// so as soon as we see a remote destination object we can know whether it exists in the local source
 		comparator = newSyncDestinationComparator(indexer, transferScheduler.scheduleCopyTransfer, destCleanerFunc, cca.mirrorMode).processIfNecessary
 		finalize = func() error {
+			// delete the extra blobs that are still held back to be deleted in a batch
+			destinationCleaner.finish()
+
 			// schedule every local file that doesn't exist at the destination
 			err = indexer.traverse(transferScheduler.scheduleCopyTransfer, filters)
 			if err != nil {
@@ -183,22 +186,23 @@ func (cca *cookedSyncCmdArgs) initEnumerator(ctx context.Context) (enumerator *s
 			// remove the extra files at the destination that were not present at the source
 			// we can only know what needs to be deleted when we have FINISHED traversing the remote source
 			// since only then can we know which local files definitely don't exist remotely
-			var deleteScheduler objectProcessor
+			var deleter *interactiveDeleteProcessor
 			switch cca.fromTo.To() {
 			case common.ELocation.Blob(), common.ELocation.File():
-				deleter, err := newSyncDeleteProcessor(cca)
+				deleter, err = newSyncDeleteProcessor(cca)
 				if err != nil {
 					return err
 				}
-				deleteScheduler = newFpoAwareProcessor(fpo, deleter.removeImmediately)
 			default:
-				deleteScheduler = newFpoAwareProcessor(fpo, newSyncLocalDeleteProcessor(cca).removeImmediately)
+				deleter = newSyncLocalDeleteProcessor(cca)
 			}
+			deleteScheduler := newFpoAwareProcessor(fpo, deleter.removeImmediately)
 
 			err = indexer.traverse(deleteScheduler, nil)
 			if err != nil {
 				return err
 			}
+			deleter.finish()
 
 			// let the deletions happen first
 			// otherwise if the final part is executed too quickly, we might quit before deletions could finish
@@ -227,14 +231,14 @@ func IsDestinationCaseInsensitive(fromTo common.FromTo) bool {
 
 func quitIfInSync(transferJobInitiated, anyDestinationFileDeleted bool, cca *cookedSyncCmdArgs) {
 	if !transferJobInitiated && !anyDestinationFileDeleted {
-		cca.reportScanningProgress(glcm, 0)
-		glcm.Exit(func(format common.OutputFormat) string {
+		cca.reportScanningProgress(cca.lcm, 0)
+		cca.lcm.Exit(func(format common.OutputFormat) string {
 			return "The source and destination are already in sync."
 		}, common.EExitCode.Success())
 	} else if !transferJobInitiated && anyDestinationFileDeleted {
 		// some files were deleted but no transfer scheduled
-		cca.reportScanningProgress(glcm, 0)
-		glcm.Exit(func(format common.OutputFormat) string {
+		cca.reportScanningProgress(cca.lcm, 0)
+		cca.lcm.Exit(func(format common.OutputFormat) string {
 			return "The source and destination are now in sync."
 		}, common.EExitCode.Success())
 	}
@@ -29,6 +29,7 @@ import (
 	"github.com/Azure/azure-storage-azcopy/v10/ste"
 	"github.com/Azure/azure-storage-blob-go/azblob"
 	"github.com/Azure/azure-storage-file-go/azfile"
+	"net/http"
 	"net/url"
 	"os"
 	"path"
@@ -66,6 +67,12 @@ func newSyncTransferProcessor(cca *cookedSyncCmdArgs, numOfTransfersPerPart int,
 		S2SPreserveBlobTags:            cca.s2sPreserveBlobTags,
 
 		S2SSourceCredentialType: cca.s2sSourceCredentialType,
+
+		Priority:          cca.jobPriority,
+		MaxConcurrency:    cca.jobMaxConcurrency,
+		MaxBytesPerSecond: cca.jobMaxBytesPerSecond,
+		TransferEvents:    cca.transferEvents,
+		Hooks:             cca.hooks,
 	}
 
 	reportFirstPart := func(jobStarted bool) { cca.setFirstPartOrdered() } // for compatibility with the way sync has always worked, we don't check jobStarted here
@@ -101,6 +108,12 @@ type interactiveDeleteProcessor struct {
 
 	// dryrunMode
 	dryrunMode bool
+
+	// sends the deletions that the deleter has held back to send in a batch, if it batches them
+	flushDeleter func()
+
+	// where the prompts and the dry run's output go
+	lcm common.LifecycleMgr
 }
 
 func newDeleteTransfer(object StoredObject) (newDeleteTransfer common.CopyTransfer) {
@@ -131,7 +144,7 @@ func (d *interactiveDeleteProcessor) removeImmediately(object StoredObject) (err
 	}
 
 	if d.dryrunMode {
-		glcm.Dryrun(func(format common.OutputFormat) string {
+		d.lcm.Dryrun(func(format common.OutputFormat) string {
 			if format == common.EOutputFormat.Json() {
 				jsonOutput, err := json.Marshal(newDeleteTransfer(object))
 				common.PanicIfErr(err)
@@ -156,7 +169,7 @@ func (d *interactiveDeleteProcessor) removeImmediately(object StoredObject) (err
 
 	err = d.deleter(object)
 	if err != nil {
-		glcm.Info(fmt.Sprintf("error %s deleting the object %s", err.Error(), object.relativePath))
+		d.lcm.Info(fmt.Sprintf("error %s deleting the object %s", err.Error(), object.relativePath))
 	}
 
 	if d.incrementDeletionCount != nil {
@@ -165,8 +178,15 @@ func (d *interactiveDeleteProcessor) removeImmediately(object StoredObject) (err
 	return
 }
 
+// finish sends the deletions that are still held back, once every object has been processed
+func (d *interactiveDeleteProcessor) finish() {
+	if d.flushDeleter != nil {
+		d.flushDeleter()
+	}
+}
+
 func (d *interactiveDeleteProcessor) promptForConfirmation(object StoredObject) (shouldDelete bool, keepPrompting bool) {
-	answer := glcm.Prompt(fmt.Sprintf("The %s '%s' does not exist at the source. "+
+	answer := d.lcm.Prompt(fmt.Sprintf("The %s '%s' does not exist at the source. "+
 		"Do you wish to delete it from the destination(%s)?",
 		d.objectTypeToDisplay, object.relativePath, d.objectLocationToDisplay),
 		common.PromptDetails{
@@ -185,24 +205,25 @@ func (d *interactiveDeleteProcessor) promptForConfirmation(object StoredObject)
 		// print nothing, since the deleter is expected to log the message when the delete happens
 		return true, true
 	case common.EResponseOption.YesForAll():
-		glcm.Info(fmt.Sprintf("Confirmed. All the extra %ss will be deleted.", d.objectTypeToDisplay))
+		d.lcm.Info(fmt.Sprintf("Confirmed. All the extra %ss will be deleted.", d.objectTypeToDisplay))
 		return true, false
 	case common.EResponseOption.No():
-		glcm.Info(fmt.Sprintf("Keeping extra %s: %s", d.objectTypeToDisplay, object.relativePath))
+		d.lcm.Info(fmt.Sprintf("Keeping extra %s: %s", d.objectTypeToDisplay, object.relativePath))
 		return false, true
 	case common.EResponseOption.NoForAll():
-		glcm.Info("No deletions will happen from now onwards.")
+		d.lcm.Info("No deletions will happen from now onwards.")
 		return false, false
 	default:
-		glcm.Info(fmt.Sprintf("Unrecognizable answer, keeping extra %s: %s.", d.objectTypeToDisplay, object.relativePath))
+		d.lcm.Info(fmt.Sprintf("Unrecognizable answer, keeping extra %s: %s.", d.objectTypeToDisplay, object.relativePath))
 		return false, true
 	}
 }
 
-func newInteractiveDeleteProcessor(deleter objectProcessor, deleteDestination common.DeleteDestination,
+func newInteractiveDeleteProcessor(lcm common.LifecycleMgr, deleter objectProcessor, deleteDestination common.DeleteDestination,
 	objectTypeToDisplay string, objectLocationToDisplay common.ResourceString, incrementDeletionCounter func(), dryrun bool) *interactiveDeleteProcessor {
 
 	return &interactiveDeleteProcessor{
+		lcm:                     lcm,
 		deleter:                 deleter,
 		objectTypeToDisplay:     objectTypeToDisplay,
 		objectLocationToDisplay: objectLocationToDisplay.Value,
@@ -214,12 +235,13 @@ func newInteractiveDeleteProcessor(deleter objectProcessor, deleteDestination co
 }
 
 func newSyncLocalDeleteProcessor(cca *cookedSyncCmdArgs) *interactiveDeleteProcessor {
-	localDeleter := localFileDeleter{rootPath: cca.destination.ValueLocal()}
-	return newInteractiveDeleteProcessor(localDeleter.deleteFile, cca.deleteDestination, "local file", cca.destination, cca.incrementDeletionCount, cca.dryrunMode)
+	localDeleter := localFileDeleter{rootPath: cca.destination.ValueLocal(), lcm: cca.lcm}
+	return newInteractiveDeleteProcessor(cca.lcm, localDeleter.deleteFile, cca.deleteDestination, "local file", cca.destination, cca.incrementDeletionCount, cca.dryrunMode)
 }
 
 type localFileDeleter struct {
 	rootPath string
+	lcm      common.LifecycleMgr
 }
 
 // As at version 10.4.0, we intentionally don't delete directories in sync,
@@ -240,7 +262,7 @@ func shouldSyncRemoveFolders() bool {
 
 func (l *localFileDeleter) deleteFile(object StoredObject) error {
 	if object.entityType == common.EEntityType.File() {
-		glcm.Info("Deleting extra file: " + object.relativePath)
+		l.lcm.Info("Deleting extra file: " + object.relativePath)
 		return os.Remove(common.GenerateFullPath(l.rootPath, object.relativePath))
 	}
 	if shouldSyncRemoveFolders() {
@@ -257,24 +279,41 @@ func newSyncDeleteProcessor(cca *cookedSyncCmdArgs) (*interactiveDeleteProcessor
 
 	ctx := context.WithValue(context.TODO(), ste.ServiceAPIVersionOverride, ste.DefaultServiceApiVersion)
 
-	p, err := InitPipeline(ctx, cca.fromTo.To(), cca.credentialInfo, cca.logVerbosity.ToPipelineLogLevel())
+	if cca.fromTo.To() == common.ELocation.Blob() {
+		// blobs are deleted in batches, whose sub-requests are authorized with the same credential as the pipeline
+		credential := createBlobCredential(ctx, cca.lcm, cca.credentialInfo)
+		deleter := newRemoteResourceDeleter(cca.lcm, rawURL, createBlobPipelineWithCredential(cca.lcm, credential, cca.logVerbosity.ToPipelineLogLevel()), ctx, common.ELocation.Blob())
+		deleter.batchSigner = ste.NewBlobBatchSigner(credential)
+
+		processor := newInteractiveDeleteProcessor(cca.lcm, deleter.delete, cca.deleteDestination, cca.fromTo.To().String(), cca.destination, cca.incrementDeletionCount, cca.dryrunMode)
+		processor.flushDeleter = deleter.flush
+		return processor, nil
+	}
+
+	p, err := InitPipeline(ctx, cca.lcm, cca.fromTo.To(), cca.credentialInfo, cca.logVerbosity.ToPipelineLogLevel())
 	if err != nil {
 		return nil, err
 	}
 
-	return newInteractiveDeleteProcessor(newRemoteResourceDeleter(rawURL, p, ctx, cca.fromTo.To()).delete,
+	return newInteractiveDeleteProcessor(cca.lcm, newRemoteResourceDeleter(cca.lcm, rawURL, p, ctx, cca.fromTo.To()).delete,
 		cca.deleteDestination, cca.fromTo.To().String(), cca.destination, cca.incrementDeletionCount, cca.dryrunMode), nil
 }
 
 type remoteResourceDeleter struct {
+	lcm            common.LifecycleMgr
 	rootURL        *url.URL
 	p              pipeline.Pipeline
 	ctx            context.Context
 	targetLocation common.Location
+
+	// authorizes the sub-requests of the batches that blobs are deleted in. Nil if they're deleted one at a time.
+	batchSigner pipeline.Pipeline
+	batch       []StoredObject
 }
 
-func newRemoteResourceDeleter(rawRootURL *url.URL, p pipeline.Pipeline, ctx context.Context, targetLocation common.Location) *remoteResourceDeleter {
+func newRemoteResourceDeleter(lcm common.LifecycleMgr, rawRootURL *url.URL, p pipeline.Pipeline, ctx context.Context, targetLocation common.Location) *remoteResourceDeleter {
 	return &remoteResourceDeleter{
+		lcm:            lcm,
 		rootURL:        rawRootURL,
 		p:              p,
 		ctx:            ctx,
@@ -285,14 +324,17 @@ func newRemoteResourceDeleter(rawRootURL *url.URL, p pipeline.Pipeline, ctx cont
 func (b *remoteResourceDeleter) delete(object StoredObject) error {
 	if object.entityType == common.EEntityType.File() {
 		// TODO: use b.targetLocation.String() in the next line, instead of "object", if we can make it come out as string
-		glcm.Info("Deleting extra object: " + object.relativePath)
+		b.lcm.Info("Deleting extra object: " + object.relativePath)
 		switch b.targetLocation {
 		case common.ELocation.Blob():
-			blobURLParts := azblob.NewBlobURLParts(*b.rootURL)
-			blobURLParts.BlobName = path.Join(blobURLParts.BlobName, object.relativePath)
-			blobURL := azblob.NewBlobURL(blobURLParts.URL(), b.p)
-			_, err := blobURL.Delete(b.ctx, azblob.DeleteSnapshotsOptionInclude, azblob.BlobAccessConditions{})
-			return err
+			if b.batchSigner != nil {
+				b.batch = append(b.batch, object)
+				if len(b.batch) == ste.BlobBatchMaxSubRequests {
+					b.flush()
+				}
+				return nil
+			}
+			return b.deleteBlob(object)
 		case common.ELocation.File():
 			fileURLParts := azfile.NewFileURLParts(*b.rootURL)
 			fileURLParts.DirectoryOrFilePath = path.Join(fileURLParts.DirectoryOrFilePath, object.relativePath)
@@ -309,3 +351,52 @@ func (b *remoteResourceDeleter) delete(object StoredObject) error {
 		return nil
 	}
 }
+
+func (b *remoteResourceDeleter) blobURLOf(object StoredObject) url.URL {
+	blobURLParts := azblob.NewBlobURLParts(*b.rootURL)
+	blobURLParts.BlobName = path.Join(blobURLParts.BlobName, object.relativePath)
+	return blobURLParts.URL()
+}
+
+func (b *remoteResourceDeleter) deleteBlob(object StoredObject) error {
+	_, err := azblob.NewBlobURL(b.blobURLOf(object), b.p).Delete(b.ctx, azblob.DeleteSnapshotsOptionInclude, azblob.BlobAccessConditions{})
+	return err
+}
+
+// flush deletes the blobs that are held back, in one batch. Since their deletion was already counted, each
+// failure is reported here, in the same way as the failures of blobs that are deleted one at a time.
+func (b *remoteResourceDeleter) flush() {
+	batch := b.batch
+	b.batch = nil
+	if len(batch) == 0 {
+		return
+	}
+
+	subRequests := make([]*http.Request, len(batch))
+	for i, object := range batch {
+		subRequests[i] = ste.NewBlobBatchDeleteRequest(b.blobURLOf(object), azblob.DeleteSnapshotsOptionInclude)
+	}
+	containerURLParts := azblob.NewBlobURLParts(*b.rootURL)
+	containerURLParts.BlobName = ""
+
+	results, err := ste.SubmitBlobBatch(b.ctx, b.p, b.batchSigner, containerURLParts.URL(), subRequests)
+	if err != nil {
+		// e.g. the destination doesn't support batches, so blobs are deleted one at a time from now on
+		b.batchSigner = nil
+		results = make([]error, len(batch))
+		for i, object := range batch {
+			results[i] = b.deleteBlob(object)
+		}
+	}
+
+	for i, object := range batch {
+		err := results[i]
+		// the single request is retried, which the sub-request can't be
+		if storageErr, ok := err.(azblob.StorageError); ok && (storageErr.Temporary() || storageErr.Response().StatusCode == http.StatusBadRequest) {
+			err = b.deleteBlob(object)
+		}
+		if err != nil {
+			b.lcm.Info(fmt.Sprintf("error %s deleting the object %s", err.Error(), object.relativePath))
+		}
+	}
+}
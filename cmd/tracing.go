@@ -0,0 +1,71 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Azure/azure-pipeline-go/pipeline"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+	"github.com/Azure/azure-storage-azcopy/v10/jobsAdmin"
+)
+
+// initTracing starts exporting traces of the jobs, their transfers and their requests, if an OTLP endpoint is given
+// in the standard OpenTelemetry environment variables. Traces are exported with OTLP over HTTP, so that they can be
+// sent to any OpenTelemetry collector. The trace ID of each job is its job ID, so that its trace is easy to find.
+func initTracing() error {
+	settings, enabled, err := common.TracingSettingsFromEnvironment(glcm.GetEnvironmentVariable)
+	if err != nil {
+		return err
+	}
+	if !enabled {
+		return nil
+	}
+
+	if err := common.InitTracing(settings); err != nil {
+		return err
+	}
+	// OpenTelemetry would otherwise print the failures of the export, in the middle of the job's output
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		jobsAdmin.JobsAdmin.LogToJobLog("Failed to export traces: "+err.Error(), pipeline.LogWarning)
+	}))
+	jobsAdmin.JobsAdmin.LogToJobLog(fmt.Sprintf("Exporting traces to %s as %s", settings.Endpoint, settings.ServiceName), pipeline.LogInfo)
+	return nil
+}
+
+// startEnumerationSpan starts the span of the enumeration of a job. It goes in the trace of the job, next to the span of the
+// job itself, since the job is only created once the first part has been enumerated. The listing requests are its children
+func startEnumerationSpan(ctx context.Context, jobID common.JobID) (context.Context, trace.Span) {
+	return common.StartJobSpan(ctx, jobID, "enumeration")
+}
+
+// endEnumerationSpan records the outcome of the enumeration in its span, and ends it
+func endEnumerationSpan(span trace.Span, err error) {
+	if err != nil && err != NothingToRemoveError && err != NothingScheduledError {
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -83,6 +83,10 @@ type StoredObject struct {
 	blobTags       common.BlobTags
 	blobSnapshotID string
 	blobDeleted    bool
+	// when it was deleted, if it's soft-deleted
+	blobDeletedTime time.Time
+	// whether this is the current version, when the versions are listed
+	blobIsCurrentVersion bool
 
 	// Lease information
 	leaseState    azblob.LeaseStateType
@@ -257,6 +261,24 @@ func newStoredObject(morpher objectMorpher, name string, relativePath string, en
 	return obj
 }
 
+// newVirtualDirectoryStoredObject represents a virtual directory, i.e. an object name prefix, as a folder.
+// The blob, S3 and GCP traversers list them when they aren't recursive, if asked to, as the list command does.
+func newVirtualDirectoryStoredObject(preprocessor objectMorpher, relativePath string, containerName string) StoredObject {
+	relativePath = strings.TrimSuffix(relativePath, common.AZCOPY_PATH_SEPARATOR_STRING)
+	return newStoredObject(
+		preprocessor,
+		getObjectNameOnly(relativePath),
+		relativePath,
+		common.EEntityType.Folder(),
+		time.Time{},
+		0,
+		noContentProps,
+		noBlobProps,
+		noMetdata,
+		containerName,
+	)
+}
+
 // capable of traversing a structured resource like container or local directory
 // pass each StoredObject to the given objectProcessor if it passes all the filters
 type ResourceTraverser interface {
@@ -335,7 +357,7 @@ func InitResourceTraverser(resource common.ResourceString, location common.Locat
 
 	// Initialize the pipeline if creds and ctx is provided
 	if ctx != nil && credential != nil {
-		tmppipe, err := InitPipeline(*ctx, location, *credential, logLevel)
+		tmppipe, err := InitPipeline(*ctx, glcm, location, *credential, logLevel)
 
 		if err != nil {
 			return nil, err
@@ -33,6 +33,132 @@ import (
 	"github.com/Azure/azure-storage-azcopy/v10/common"
 )
 
+// parsePatterns splits a list of patterns given to a flag, which are separated by semicolons, skipping the empty ones
+func parsePatterns(pattern string) (cookedPatterns []string) {
+	cookedPatterns = make([]string, 0)
+	rawPatterns := strings.Split(pattern, ";")
+	for _, pattern := range rawPatterns {
+
+		// skip the empty patterns
+		if len(pattern) != 0 {
+			cookedPatterns = append(cookedPatterns, pattern)
+		}
+	}
+
+	return
+}
+
+// parseIncludeDates parses the values of include-before and include-after, either of which may be empty
+func parseIncludeDates(includeBefore string, includeAfter string) (before *time.Time, after *time.Time, err error) {
+	if includeBefore != "" {
+		// must set chooseEarliest = false, so that if there's an ambiguous local date, the latest will be returned
+		// (since that's safest for includeBefore.  Better to choose the later time and do more work, than the earlier one and fail to pick up a changed file
+		parsedIncludeBefore, err := IncludeBeforeDateFilter{}.ParseISO8601(includeBefore, false)
+		if err != nil {
+			return nil, nil, err
+		}
+		before = &parsedIncludeBefore
+	}
+
+	if includeAfter != "" {
+		// must set chooseEarliest = true, so that if there's an ambiguous local date, the earliest will be returned
+		// (since that's safest for includeAfter.  Better to choose the earlier time and do more work, than the later one and fail to pick up a changed file
+		parsedIncludeAfter, err := IncludeAfterDateFilter{}.ParseISO8601(includeAfter, true)
+		if err != nil {
+			return nil, nil, err
+		}
+		after = &parsedIncludeAfter
+	}
+	return before, after, nil
+}
+
+// parseExcludeBlobTypes parses the value of exclude-blob-type, which is a list of blob types separated by semicolons
+func parseExcludeBlobTypes(excludeBlobType string) ([]azblob.BlobType, error) {
+	var blobTypes []azblob.BlobType
+	for _, blobType := range parsePatterns(excludeBlobType) {
+		var eBlobType common.BlobType
+		if err := eBlobType.Parse(blobType); err != nil {
+			return nil, fmt.Errorf("error parsing the exclude-blob-type %s provided with exclude-blob-type flag ", blobType)
+		}
+		blobTypes = append(blobTypes, eBlobType.ToAzBlobType())
+	}
+	return blobTypes, nil
+}
+
+// modularFilterOptions are the parsed values of the filter flags that copy and list have in common
+type modularFilterOptions struct {
+	includeBefore         *time.Time
+	includeAfter          *time.Time
+	includePatterns       []string
+	excludePatterns       []string
+	excludePathPatterns   []string
+	includeRegex          []string
+	excludeRegex          []string
+	excludeBlobType       []azblob.BlobType
+	includeFileAttributes []string
+	excludeFileAttributes []string
+	localSource           string // the files whose attributes are checked are under this path
+}
+
+func (o modularFilterOptions) build() []ObjectFilter {
+	filters := make([]ObjectFilter, 0) // same as []ObjectFilter{} under the hood
+
+	if o.includeBefore != nil {
+		filters = append(filters, &IncludeBeforeDateFilter{Threshold: *o.includeBefore})
+	}
+
+	if o.includeAfter != nil {
+		filters = append(filters, &IncludeAfterDateFilter{Threshold: *o.includeAfter})
+	}
+
+	if len(o.includePatterns) != 0 {
+		filters = append(filters, &IncludeFilter{patterns: o.includePatterns}) // TODO should this call buildIncludeFilters?
+	}
+
+	if len(o.excludePatterns) != 0 {
+		for _, v := range o.excludePatterns {
+			filters = append(filters, &excludeFilter{pattern: v})
+		}
+	}
+
+	// include-path is not a filter, therefore it does not get handled here.
+	// Check up in cook() around the list-of-files implementation as include-path gets included in the same way.
+
+	if len(o.excludePathPatterns) != 0 {
+		for _, v := range o.excludePathPatterns {
+			filters = append(filters, &excludeFilter{pattern: v, targetsPath: true})
+		}
+	}
+
+	if len(o.includeRegex) != 0 {
+		filters = append(filters, &regexFilter{patterns: o.includeRegex, isIncluded: true})
+	}
+
+	if len(o.excludeRegex) != 0 {
+		filters = append(filters, &regexFilter{patterns: o.excludeRegex, isIncluded: false})
+	}
+
+	if len(o.excludeBlobType) != 0 {
+		excludeSet := map[azblob.BlobType]bool{}
+
+		for _, v := range o.excludeBlobType {
+			excludeSet[v] = true
+		}
+
+		filters = append(filters, &excludeBlobTypeFilter{blobTypes: excludeSet})
+	}
+
+	if len(o.includeFileAttributes) != 0 {
+		filters = append(filters, buildAttrFilters(o.includeFileAttributes, o.localSource, true)...)
+	}
+
+	if len(o.excludeFileAttributes) != 0 {
+		filters = append(filters, buildAttrFilters(o.excludeFileAttributes, o.localSource, false)...)
+	}
+
+	return filters
+}
+
 // Design explanation:
 /*
 Blob type exclusion is required as a part of the copy enumerators refactor. This would be used in Download and S2S scenarios.
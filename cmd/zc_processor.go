@@ -93,13 +93,35 @@ func (s *copyTransferProcessor) scheduleCopyTransfer(storedObject StoredObject)
 			} else {
 				// if remove then To() will equal to common.ELocation.Unknown()
 				if s.copyJobTemplate.FromTo.To() == common.ELocation.Unknown() { //remove
-					return fmt.Sprintf("DRYRUN: remove %v/%v",
+					dryrunValue := fmt.Sprintf("DRYRUN: remove %v/%v",
 						s.copyJobTemplate.SourceRoot.Value,
 						srcRelativePath)
-				} else { //copy for sync
+					if copyTransfer.BlobVersionID != "" {
+						dryrunValue += fmt.Sprintf(" (version %s)", copyTransfer.BlobVersionID)
+					} else if copyTransfer.BlobSnapshotID != "" {
+						dryrunValue += fmt.Sprintf(" (snapshot %s)", copyTransfer.BlobSnapshotID)
+					}
+					return dryrunValue
+				} else if s.copyJobTemplate.FromTo.To() == common.ELocation.None() { // set-properties, or restore
+					switch flags := s.copyJobTemplate.BlobAttributes.SetPropertiesFlags; {
+					case flags.ShouldUndelete():
+						return fmt.Sprintf("DRYRUN: undelete %v/%v",
+							s.copyJobTemplate.SourceRoot.Value,
+							srcRelativePath)
+					case flags.ShouldPromoteVersion():
+						return fmt.Sprintf("DRYRUN: restore %v/%v (version %s)",
+							s.copyJobTemplate.SourceRoot.Value,
+							srcRelativePath,
+							copyTransfer.BlobVersionID)
+					}
+					return fmt.Sprintf("DRYRUN: set properties of %v/%v",
+						s.copyJobTemplate.SourceRoot.Value,
+						srcRelativePath)
+				} else { //copy for sync, or move
+					verb := common.IffString(s.copyJobTemplate.DeleteSourceOnSuccess, "move", "copy")
 					if s.copyJobTemplate.FromTo.From() == common.ELocation.Local() {
 						// formatting from local source
-						dryrunValue := fmt.Sprintf("DRYRUN: copy %v", common.ToShortPath(s.copyJobTemplate.SourceRoot.Value))
+						dryrunValue := fmt.Sprintf("DRYRUN: %v %v", verb, common.ToShortPath(s.copyJobTemplate.SourceRoot.Value))
 						if runtime.GOOS == "windows" {
 							dryrunValue += "\\" + strings.ReplaceAll(srcRelativePath, "/", "\\")
 						} else { //linux and mac
@@ -109,7 +131,7 @@ func (s *copyTransferProcessor) scheduleCopyTransfer(storedObject StoredObject)
 						return dryrunValue
 					} else if s.copyJobTemplate.FromTo.To() == common.ELocation.Local() {
 						// formatting to local source
-						dryrunValue := fmt.Sprintf("DRYRUN: copy %v/%v to %v",
+						dryrunValue := fmt.Sprintf("DRYRUN: %v %v/%v to %v", verb,
 							strings.Trim(s.copyJobTemplate.SourceRoot.Value, "/"), srcRelativePath,
 							common.ToShortPath(s.copyJobTemplate.DestinationRoot.Value))
 						if runtime.GOOS == "windows" {
@@ -119,7 +141,7 @@ func (s *copyTransferProcessor) scheduleCopyTransfer(storedObject StoredObject)
 						}
 						return dryrunValue
 					} else {
-						return fmt.Sprintf("DRYRUN: copy %v/%v to %v/%v",
+						return fmt.Sprintf("DRYRUN: %v %v/%v to %v/%v", verb,
 							s.copyJobTemplate.SourceRoot.Value,
 							srcRelativePath,
 							s.copyJobTemplate.DestinationRoot.Value,
@@ -61,6 +61,9 @@ type blobTraverser struct {
 	includeSnapshot bool
 
 	includeVersion bool
+
+	// whether to list the virtual directories, as folders, when not recursive
+	includeVirtualDirectories bool
 }
 
 func (t *blobTraverser) IsDirectory(isSource bool) bool {
@@ -287,6 +290,10 @@ func (t *blobTraverser) parallelList(containerURL azblob.ContainerURL, container
 						}
 					}
 				}
+			} else if t.includeVirtualDirectories {
+				for _, virtualDir := range lResp.Segment.BlobPrefixes {
+					enqueueOutput(newVirtualDirectoryStoredObject(preprocessor, strings.TrimPrefix(virtualDir.Name, searchPrefix), containerName), nil)
+				}
 			}
 
 			// process the blobs returned in this result segment
@@ -377,10 +384,14 @@ func (t *blobTraverser) createStoredObjectForBlob(preprocessor objectMorpher, bl
 	)
 
 	object.blobDeleted = blobInfo.Deleted
-	if t.includeDeleted && t.includeSnapshot {
+	if blobInfo.Properties.DeletedTime != nil {
+		object.blobDeletedTime = *blobInfo.Properties.DeletedTime
+	}
+	if t.includeSnapshot {
 		object.blobSnapshotID = blobInfo.Snapshot
-	} else if t.includeDeleted && t.includeVersion && blobInfo.VersionID != nil {
+	} else if t.includeVersion && blobInfo.VersionID != nil {
 		object.blobVersionID = *blobInfo.VersionID
+		object.blobIsCurrentVersion = blobInfo.IsCurrentVersion != nil && *blobInfo.IsCurrentVersion
 	}
 	return object
 }
@@ -392,6 +403,8 @@ func (t *blobTraverser) doesBlobRepresentAFolder(metadata azblob.Metadata) bool
 
 func (t *blobTraverser) serialList(containerURL azblob.ContainerURL, containerName string, searchPrefix string,
 	extraSearchPrefix string, preprocessor objectMorpher, processor objectProcessor, filters []ObjectFilter) error {
+	// the virtual directories already listed, when not recursive, since a flat listing gives each of them once per blob in it
+	listedDirectories := make(map[string]bool)
 
 	for marker := (azblob.Marker{}); marker.NotDone(); {
 		// see the TO DO in GetEnumerationPreFilter if/when we make this more directory-aware
@@ -415,6 +428,15 @@ func (t *blobTraverser) serialList(containerURL azblob.ContainerURL, containerNa
 			relativePath := strings.TrimPrefix(blobInfo.Name, searchPrefix)
 			// if recursive
 			if !t.recursive && strings.Contains(relativePath, common.AZCOPY_PATH_SEPARATOR_STRING) {
+				directory := relativePath[:strings.Index(relativePath, common.AZCOPY_PATH_SEPARATOR_STRING)]
+				if t.includeVirtualDirectories && directory != "" && !listedDirectories[directory] {
+					listedDirectories[directory] = true
+					processErr := processIfPassedFilters(filters, newVirtualDirectoryStoredObject(preprocessor, directory, containerName), processor)
+					_, processErr = getProcessingError(processErr)
+					if processErr != nil {
+						return processErr
+					}
+				}
 				continue
 			}
 
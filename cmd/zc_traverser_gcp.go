@@ -18,6 +18,9 @@ type gcpTraverser struct {
 	recursive     bool
 	getProperties bool
 
+	// whether to list the virtual directories, as folders, when not recursive
+	includeVirtualDirectories bool
+
 	gcpURLParts common.GCPURLParts
 	gcpClient   *gcpUtils.Client
 
@@ -90,6 +93,18 @@ func (t *gcpTraverser) Traverse(preprocessor objectMorpher, processor objectProc
 			return nil
 		}
 		if err == nil {
+			// the prefixes are returned, without names, when not recursive
+			if attrs.Name == "" && attrs.Prefix != "" && t.includeVirtualDirectories {
+				err = processIfPassedFilters(filters,
+					newVirtualDirectoryStoredObject(preprocessor, strings.TrimPrefix(attrs.Prefix, searchPrefix), t.gcpURLParts.BucketName),
+					processor)
+				_, err = getProcessingError(err)
+				if err != nil {
+					return err
+				}
+				continue
+			}
+
 			//Virtual directories alone have "/" as suffix and size as 0
 			if strings.HasSuffix(attrs.Name, "/") || attrs.Name == "" {
 				continue
@@ -38,6 +38,9 @@ type s3Traverser struct {
 	recursive     bool
 	getProperties bool
 
+	// whether to list the virtual directories, as folders, when not recursive
+	includeVirtualDirectories bool
+
 	s3URLParts s3URLPartsExtension
 	s3Client   *minio.Client
 
@@ -132,6 +135,15 @@ func (t *s3Traverser) Traverse(preprocessor objectMorpher, processor objectProce
 
 		if objectInfo.StorageClass == "" {
 			// Directories are the only objects without storage classes.
+			if t.includeVirtualDirectories && !t.recursive && objectInfo.Key != searchPrefix {
+				err = processIfPassedFilters(filters,
+					newVirtualDirectoryStoredObject(preprocessor, strings.TrimPrefix(objectInfo.Key, searchPrefix), t.s3URLParts.BucketName),
+					processor)
+				_, err = getProcessingError(err)
+				if err != nil {
+					return
+				}
+			}
 			continue
 		}
 
@@ -0,0 +1,181 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+	chk "gopkg.in/check.v1"
+)
+
+// fakeSTE records the RPCs that reach it, and answers them the way the in-process STE would
+type fakeSTE struct {
+	cmds     []common.RpcCmd
+	requests []interface{}
+}
+
+func (f *fakeSTE) send(cmd common.RpcCmd, request interface{}, response interface{}) error {
+	f.cmds = append(f.cmds, cmd)
+	f.requests = append(f.requests, request)
+	switch r := response.(type) {
+	case *common.ListJobsResponse:
+		r.JobIDDetails = []common.JobIDDetails{{JobId: common.NewJobID(), CommandString: "copy"}}
+	case *common.ListJobSummaryResponse:
+		r.JobID = *request.(*common.JobID)
+		r.JobStatus = common.EJobStatus.Completed()
+	case *common.CancelPauseResumeResponse:
+		r.CancelledPauseResumed = true
+	}
+	return nil
+}
+
+func newTestDaemon(ste *fakeSTE) *httptest.Server {
+	server := newRpcServer("secret", func(args []string) (common.JobID, error) {
+		return common.JobID{}, nil
+	})
+	server.send = ste.send
+	return httptest.NewServer(server)
+}
+
+func (s *cmdIntegrationSuite) TestDaemonRejectsMissingToken(c *chk.C) {
+	ste := &fakeSTE{}
+	daemon := newTestDaemon(ste)
+	defer daemon.Close()
+
+	for _, token := range []string{"", "wrong"} {
+		request, _ := http.NewRequest(http.MethodGet, daemon.URL+"/jobs", nil)
+		if token != "" {
+			request.Header.Set("Authorization", "Bearer "+token)
+		}
+		response, err := http.DefaultClient.Do(request)
+		c.Assert(err, chk.IsNil)
+		response.Body.Close()
+		c.Assert(response.StatusCode, chk.Equals, http.StatusUnauthorized)
+	}
+	c.Assert(ste.cmds, chk.HasLen, 0)
+}
+
+func (s *cmdIntegrationSuite) TestDaemonRpcRoundTrip(c *chk.C) {
+	ste := &fakeSTE{}
+	daemon := newTestDaemon(ste)
+	defer daemon.Close()
+
+	client, err := newDaemonHttpClient(daemon.URL, "secret")
+	c.Assert(err, chk.IsNil)
+
+	// the request reaches the STE in the same shape as from the in-process CLI
+	jobID := common.NewJobID()
+	var summary common.ListJobSummaryResponse
+	c.Assert(client.send(common.ERpcCmd.ListJobSummary(), &jobID, &summary), chk.IsNil)
+	c.Assert(summary.JobID, chk.Equals, jobID)
+	c.Assert(summary.JobStatus, chk.Equals, common.EJobStatus.Completed())
+	c.Assert(*ste.requests[0].(*common.JobID), chk.Equals, jobID)
+
+	var pauseResponse common.CancelPauseResumeResponse
+	c.Assert(client.send(common.ERpcCmd.PauseJob(), jobID, &pauseResponse), chk.IsNil)
+	c.Assert(pauseResponse.CancelledPauseResumed, chk.Equals, true)
+	c.Assert(ste.requests[1].(common.JobID), chk.Equals, jobID)
+
+	// the daemon cannot ask the user whether to overwrite
+	order := &common.CopyJobPartOrderRequest{JobID: jobID, ForceWrite: common.EOverwriteOption.Prompt()}
+	var orderResponse common.CopyJobPartOrderResponse
+	c.Assert(client.send(common.ERpcCmd.CopyJobPartOrder(), order, &orderResponse), chk.NotNil)
+	c.Assert(ste.cmds, chk.HasLen, 2)
+}
+
+func (s *cmdIntegrationSuite) TestDaemonRestJobs(c *chk.C) {
+	ste := &fakeSTE{}
+	daemon := newTestDaemon(ste)
+	defer daemon.Close()
+
+	do := func(method string, path string, body string) int {
+		request, _ := http.NewRequest(method, daemon.URL+path, strings.NewReader(body))
+		request.Header.Set("Authorization", "Bearer secret")
+		response, err := http.DefaultClient.Do(request)
+		c.Assert(err, chk.IsNil)
+		response.Body.Close()
+		return response.StatusCode
+	}
+
+	jobID := common.NewJobID()
+	c.Assert(do(http.MethodGet, "/jobs?status=InProgress", ""), chk.Equals, http.StatusOK)
+	c.Assert(ste.requests[0].(common.JobStatus), chk.Equals, common.EJobStatus.InProgress())
+
+	c.Assert(do(http.MethodPost, "/jobs/"+jobID.String()+"/cancel", ""), chk.Equals, http.StatusOK)
+	c.Assert(ste.cmds[1], chk.Equals, common.ERpcCmd.CancelJob())
+
+	c.Assert(do(http.MethodPost, "/jobs/"+jobID.String()+"/resume", `{"SourceSAS": "sv=2020"}`), chk.Equals, http.StatusOK)
+	c.Assert(ste.requests[2].(*common.ResumeJobRequest).JobID, chk.Equals, jobID)
+	c.Assert(ste.requests[2].(*common.ResumeJobRequest).SourceSAS, chk.Equals, "sv=2020")
+
+	// the progress stream ends once the job is done
+	c.Assert(do(http.MethodGet, "/jobs/"+jobID.String()+"/progress", ""), chk.Equals, http.StatusOK)
+	c.Assert(ste.cmds[3], chk.Equals, common.ERpcCmd.ListJobSummary())
+
+	c.Assert(do(http.MethodGet, "/jobs/"+jobID.String()+"/cancel", ""), chk.Equals, http.StatusMethodNotAllowed)
+	c.Assert(do(http.MethodGet, "/jobs/not-a-job", ""), chk.Equals, http.StatusNotFound)
+	c.Assert(do(http.MethodPost, "/jobs", `{"Args": ["login"]}`), chk.Equals, http.StatusBadRequest)
+	c.Assert(do(http.MethodPost, "/jobs", `{"Args": ["copy", "a", "b"]}`), chk.Equals, http.StatusAccepted)
+	c.Assert(do(http.MethodPost, "/jobs", `{"Args": ["copy", "a", "b", "--recursive", "--include-pattern=*.log"]}`), chk.Equals, http.StatusAccepted)
+
+	// only the flags that shape the job itself can be used
+	c.Assert(do(http.MethodPost, "/jobs", `{"Args": ["copy", "a", "b", "--hook-command", "touch x"]}`), chk.Equals, http.StatusBadRequest)
+	c.Assert(do(http.MethodPost, "/jobs", `{"Args": ["copy", "a", "b", "--transfer-events=/etc/x"]}`), chk.Equals, http.StatusBadRequest)
+	c.Assert(do(http.MethodPost, "/jobs", `{"Args": ["sync", "a", "b", "--output-type=text"]}`), chk.Equals, http.StatusBadRequest)
+	c.Assert(do(http.MethodPost, "/jobs", `{"Args": ["remove", "a", "-h"]}`), chk.Equals, http.StatusBadRequest)
+}
+
+func (s *cmdIntegrationSuite) TestDaemonAwaitSubmittedJob(c *chk.C) {
+	jobID := common.NewJobID()
+	output := `{"TimeStamp":"2022-01-01T00:00:00Z","MessageType":"Info","MessageContent":"scanning"}
+{"TimeStamp":"2022-01-01T00:00:00Z","MessageType":"Init","MessageContent":"{\"LogFileLocation\":\"x.log\",\"JobID\":\"` + jobID.String() + `\",\"IsCleanupJob\":false}"}
+{"TimeStamp":"2022-01-01T00:00:01Z","MessageType":"Progress","MessageContent":"{}"}
+`
+	reaped := make(chan struct{})
+	submitted, err := awaitSubmittedJob(strings.NewReader(output), func() { close(reaped) })
+	c.Assert(err, chk.IsNil)
+	c.Assert(submitted, chk.Equals, jobID)
+	<-reaped
+
+	output = `{"TimeStamp":"2022-01-01T00:00:00Z","MessageType":"Error","MessageContent":"cannot find source"}
+`
+	_, err = awaitSubmittedJob(strings.NewReader(output), func() {})
+	c.Assert(err, chk.ErrorMatches, "cannot find source")
+}
+
+func (s *cmdIntegrationSuite) TestDaemonListenAddress(c *chk.C) {
+	_, _, err := listenDaemon("0.0.0.0:0")
+	c.Assert(err, chk.NotNil)
+
+	listener, endpoint, err := listenDaemon("127.0.0.1:0")
+	c.Assert(err, chk.IsNil)
+	defer listener.Close()
+	c.Assert(strings.HasPrefix(endpoint, "http://127.0.0.1:"), chk.Equals, true)
+
+	_, err = newDaemonHttpClient("ftp://localhost", "")
+	c.Assert(err, chk.NotNil)
+	client, err := newDaemonHttpClient("unix:///tmp/azcopy.sock", "")
+	c.Assert(err, chk.IsNil)
+	c.Assert(client.url, chk.Equals, "http://unix"+daemonRpcPath)
+}
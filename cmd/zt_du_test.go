@@ -0,0 +1,102 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"encoding/csv"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-storage-blob-go/azblob"
+	chk "gopkg.in/check.v1"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+)
+
+type duSuite struct{}
+
+var _ = chk.Suite(&duSuite{})
+
+func (s *duSuite) TestUsageIsBrokenDown(c *chk.C) {
+	now := time.Now()
+	report := newUsageReport(1, []int{7, 30}, false, now)
+
+	blob := func(path string, size int64, tier azblob.AccessTierType, age time.Duration) StoredObject {
+		return StoredObject{relativePath: path, entityType: common.EEntityType.File(), size: size,
+			blobType: azblob.BlobBlockBlob, blobAccessTier: tier, lastModifiedTime: now.Add(-age)}
+	}
+	report.add(blob("top.txt", 1, azblob.AccessTierHot, time.Hour))
+	report.add(blob("a/b/c.txt", 10, azblob.AccessTierCool, 10*24*time.Hour))
+	report.add(blob("a/d.txt", 100, azblob.AccessTierHot, 100*24*time.Hour))
+	old := blob("a/d.txt", 1000, azblob.AccessTierArchive, 100*24*time.Hour)
+	old.blobVersionID = "v0"
+	report.add(old)
+	report.add(StoredObject{relativePath: "a", entityType: common.EEntityType.Folder()})
+
+	c.Assert(report.Total, chk.Equals, usageTally{Count: 4, Bytes: 1111})
+	c.Assert(*report.ByDirectory["/"], chk.Equals, usageTally{Count: 1, Bytes: 1})
+	c.Assert(*report.ByDirectory["a/"], chk.Equals, usageTally{Count: 3, Bytes: 1110})
+	c.Assert(*report.ByAccessTier["Hot"], chk.Equals, usageTally{Count: 2, Bytes: 101})
+	c.Assert(*report.ByBlobType["BlockBlob"], chk.Equals, usageTally{Count: 4, Bytes: 1111})
+	c.Assert(*report.ByAge["0-7d"], chk.Equals, usageTally{Count: 1, Bytes: 1})
+	c.Assert(*report.ByAge["7-30d"], chk.Equals, usageTally{Count: 1, Bytes: 10})
+	c.Assert(*report.ByAge[">=30d"], chk.Equals, usageTally{Count: 2, Bytes: 1100})
+	c.Assert(*report.ByKind["Version"], chk.Equals, usageTally{Count: 1, Bytes: 1000})
+	c.Assert(*report.ByKind["Base"], chk.Equals, usageTally{Count: 3, Bytes: 111})
+}
+
+func (s *duSuite) TestUsageOfLocalDirectoryAsCsv(c *chk.C) {
+	dirPath := scenarioHelper{}.generateLocalDirectory(c)
+	defer os.RemoveAll(dirPath)
+	scenarioHelper{}.generateLocalFilesFromList(c, dirPath, []string{"a.txt", "sub/b.txt", "sub/deeper/c.txt"})
+
+	cooked, err := rawDuCmdArgs{sourcePath: dirPath, depth: 1, ageBuckets: "7d"}.cook()
+	c.Assert(err, chk.IsNil)
+	report, err := cooked.process()
+	c.Assert(err, chk.IsNil)
+
+	records, err := csv.NewReader(strings.NewReader(report.csv())).ReadAll()
+	c.Assert(err, chk.IsNil)
+	c.Assert(records, chk.DeepEquals, [][]string{
+		{"Dimension", "Key", "Count", "Bytes"},
+		{"Total", "", "3", "3072"},
+		{"Directory", "sub/", "2", "2048"},
+		{"Directory", "/", "1", "1024"},
+		{"Age", "0-7d", "3", "3072"},
+		{"Kind", "Base", "3", "3072"},
+	})
+}
+
+func (s *duSuite) TestDuArgumentsAreValidated(c *chk.C) {
+	_, err := rawDuCmdArgs{sourcePath: "https://account.blob.core.windows.net/container", ageBuckets: "soon"}.cook()
+	c.Assert(err, chk.NotNil)
+
+	_, err = rawDuCmdArgs{sourcePath: "https://account.blob.core.windows.net/container", depth: -1}.cook()
+	c.Assert(err, chk.NotNil)
+
+	_, err = rawDuCmdArgs{sourcePath: "https://s3.amazonaws.com/bucket", includeSnapshots: true}.cook()
+	c.Assert(err, chk.NotNil)
+
+	cooked, err := rawDuCmdArgs{sourcePath: "https://account.blob.core.windows.net/container", ageBuckets: "365,7d"}.cook()
+	c.Assert(err, chk.IsNil)
+	c.Assert(cooked.ageBuckets, chk.DeepEquals, []int{7, 365})
+}
@@ -0,0 +1,141 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"time"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+	chk "gopkg.in/check.v1"
+)
+
+func (s *cmdIntegrationSuite) TestInProcessLcmRecordsOutcome(c *chk.C) {
+	var messages []string
+	lcm := newInProcessLcm(&mockedLifecycleManager{infoLog: make(chan string, 5)}, func(msg string) { messages = append(messages, msg) })
+
+	// the job isn't ended by its errors, and only the first outcome counts
+	lcm.Info("scanning")
+	lcm.Error("cannot find source")
+	lcm.Exit(func(common.OutputFormat) string { return "already in sync" }, common.EExitCode.Success())
+	jobStarted, message, err := lcm.outcome()
+	c.Assert(err, chk.ErrorMatches, "cannot find source")
+	c.Assert(message, chk.Equals, "")
+	c.Assert(jobStarted, chk.Equals, false)
+	c.Assert(messages, chk.DeepEquals, []string{"scanning"})
+	c.Assert(lcm.Prompt("overwrite?", common.PromptDetails{}), chk.Equals, common.EResponseOption.No())
+
+	// without onMessage, the messages go to the lifecycle manager that runs the job
+	runner := &mockedLifecycleManager{infoLog: make(chan string, 5)}
+	lcm = newInProcessLcm(runner, nil)
+	lcm.Init(func(common.OutputFormat) string { return "job started" })
+	lcm.Exit(func(common.OutputFormat) string { return "done" }, common.EExitCode.Success())
+	jobStarted, message, err = lcm.outcome()
+	c.Assert(err, chk.IsNil)
+	c.Assert(message, chk.Equals, "done")
+	c.Assert(jobStarted, chk.Equals, true)
+	c.Assert(runner.GatherAllLogs(runner.infoLog), chk.DeepEquals, []string{"job started"})
+
+	// without either, the messages are dropped, rather than printed by the lifecycle manager of the process
+	lcm = newInProcessLcm(nil, nil)
+	lcm.Info("scanning")
+	c.Assert(lcm.LifecycleMgr, chk.Equals, common.GetLifecycleMgr())
+
+	// what the job keeps open is closed once, when it's done
+	closed := 0
+	lcm.RegisterCloseFunc(func() { closed++ })
+	InProcessJob{lcm: lcm}.Close()
+	InProcessJob{lcm: lcm}.Close()
+	c.Assert(closed, chk.Equals, 1)
+}
+
+func (s *cmdIntegrationSuite) TestInProcessCopyAppliesToFlagDefaults(c *chk.C) {
+	after := time.Date(2022, 3, 4, 5, 6, 7, 0, time.UTC)
+	raw, err := InProcessCopy{
+		Source:              "/data",
+		Destination:         "https://account.blob.core.windows.net/container",
+		InProcessJobOptions: InProcessJobOptions{LogLevel: common.ELogLevel.Info(), HookEvents: []common.JobHookEvent{common.EJobHookEvent.JobCompleted()}},
+		InProcessScheduling: InProcessScheduling{Priority: common.EJobPriority.High(), MaxConcurrency: 8},
+		InProcessFilters:    InProcessFilters{IncludePatterns: []string{"*.jpg", "*.png"}, IncludeAfter: after},
+		Recursive:           true,
+		Overwrite:           common.EOverwriteOption.IfSourceNewer(),
+		Metadata:            map[string]string{"b": "2", "a": "1"},
+	}.raw()
+	c.Assert(err, chk.IsNil)
+	c.Assert(raw.src, chk.Equals, "/data")
+	c.Assert(raw.logVerbosity, chk.Equals, "INFO")
+	c.Assert(raw.hookEvents, chk.Equals, "JobCompleted")
+	c.Assert(raw.jobPriority, chk.Equals, "High")
+	c.Assert(raw.jobMaxConcurrency, chk.Equals, 8)
+	c.Assert(raw.include, chk.Equals, "*.jpg;*.png")
+	c.Assert(raw.includeAfter, chk.Equals, "2022-03-04T05:06:07Z")
+	c.Assert(raw.forceWrite, chk.Equals, "IfSourceNewer")
+	c.Assert(raw.blobType, chk.Equals, "Detect")
+	c.Assert(raw.metadata, chk.Equals, "a=1;b=2")
+	c.Assert(raw.fromTo, chk.Equals, "")
+
+	// the options that the job doesn't have keep the defaults of their flags
+	c.Assert(raw.CheckLength, chk.Equals, true)
+	c.Assert(raw.s2sPreserveAccessTier, chk.Equals, true)
+
+	// nobody can answer the prompt
+	_, err = InProcessCopy{Overwrite: common.EOverwriteOption.Prompt()}.raw()
+	c.Assert(err, chk.NotNil)
+}
+
+func (s *cmdIntegrationSuite) TestInProcessSyncAndRemoveApplyToFlagDefaults(c *chk.C) {
+	raw := InProcessSync{Source: "/data", Destination: "https://account.blob.core.windows.net/container", DeleteDestination: true, FromTo: common.EFromTo.LocalBlob()}.raw()
+	c.Assert(raw.recursive, chk.Equals, false)
+	c.Assert(raw.fromTo, chk.Equals, "LocalBlob")
+	c.Assert(raw.deleteDestination, chk.Equals, "true")
+	c.Assert(raw.logVerbosity, chk.Equals, "NONE")
+	c.Assert(raw.preserveSMBInfo, chk.Equals, true)
+
+	removal, err := InProcessRemove{Target: "https://account.blob.core.windows.net/container/dir", Recursive: true, InProcessFilters: InProcessFilters{ExcludePaths: []string{"keep"}}}.raw()
+	c.Assert(err, chk.IsNil)
+	c.Assert(removal.fromTo, chk.Equals, "BlobTrash")
+	c.Assert(removal.excludePath, chk.Equals, "keep")
+	c.Assert(removal.includeDirectoryStubs, chk.Equals, true)
+	c.Assert(removal.permanentDeleteOption, chk.Equals, "none")
+
+	_, err = InProcessRemove{Target: "/data"}.raw()
+	c.Assert(err, chk.NotNil)
+}
+
+func (s *cmdIntegrationSuite) TestInProcessListCooksLikeTheListCommand(c *chk.C) {
+	after := time.Date(2022, 3, 4, 5, 6, 7, 0, time.UTC)
+	cooked, err := InProcessList{
+		Resource:        "https://account.blob.core.windows.net/container",
+		IncludePatterns: []string{"*.jpg"},
+		ExcludePaths:    []string{"keep"},
+		IncludeAfter:    after,
+		IncludeVersions: true,
+	}.raw().cook()
+	c.Assert(err, chk.IsNil)
+	c.Assert(cooked.location, chk.Equals, common.ELocation.Blob())
+	c.Assert(cooked.recursive, chk.Equals, false)
+	c.Assert(cooked.includeVersions, chk.Equals, true)
+	c.Assert(cooked.hasProperty(versionId), chk.Equals, true)
+	c.Assert(cooked.filters, chk.HasLen, 3)
+
+	// as with the list command, only blobs have versions
+	_, err = InProcessList{Resource: "/data", IncludeVersions: true}.raw().cook()
+	c.Assert(err, chk.NotNil)
+}
@@ -79,6 +79,7 @@ type mockedLifecycleManager struct {
 	progressLog  chan string
 	exitLog      chan string
 	dryrunLog    chan string
+	outputLog    chan string
 	outputFormat common.OutputFormat
 }
 
@@ -108,6 +109,12 @@ func (m *mockedLifecycleManager) Dryrun(o common.OutputBuilder) {
 	default:
 	}
 }
+func (m *mockedLifecycleManager) Output(o common.OutputBuilder) {
+	select {
+	case m.outputLog <- o(m.outputFormat):
+	default:
+	}
+}
 func (*mockedLifecycleManager) Prompt(message string, details common.PromptDetails) common.ResponseOption {
 	return common.EResponseOption.Default()
 }
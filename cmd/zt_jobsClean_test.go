@@ -0,0 +1,74 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	chk "gopkg.in/check.v1"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+)
+
+type jobsCleanSuite struct{}
+
+var _ = chk.Suite(&jobsCleanSuite{})
+
+func (s *jobsCleanSuite) TestOnlyJobsWithNoRecentFilesAreOld(c *chk.C) {
+	planFolder, logFolder := c.MkDir(), c.MkDir()
+	oldPlanFolder, oldLogFolder := common.AzcopyJobPlanFolder, azcopyLogPathFolder
+	common.AzcopyJobPlanFolder, azcopyLogPathFolder = planFolder, logFolder
+	defer func() { common.AzcopyJobPlanFolder, azcopyLogPathFolder = oldPlanFolder, oldLogFolder }()
+
+	longAgo := time.Now().Add(-10 * 24 * time.Hour)
+	createFile := func(folder, name string, modified time.Time) string {
+		p := filepath.Join(folder, name)
+		c.Assert(ioutil.WriteFile(p, nil, common.DEFAULT_FILE_PERM), chk.IsNil)
+		c.Assert(os.Chtimes(p, modified, modified), chk.IsNil)
+		return p
+	}
+
+	oldJob, resumedJob := common.NewJobID(), common.NewJobID()
+	oldFiles := []string{
+		createFile(planFolder, oldJob.String()+"--00000.steV17", longAgo),
+		createFile(logFolder, oldJob.String()+".log", longAgo),
+		createFile(logFolder, oldJob.String()+".log.1.gz", longAgo),
+	}
+	createFile(planFolder, resumedJob.String()+"--00000.steV17", longAgo)
+	createFile(logFolder, resumedJob.String()+".log", time.Now())
+	createFile(logFolder, "not-a-job.log", longAgo)
+
+	jobs, err := listJobFiles()
+	c.Assert(err, chk.IsNil)
+	old := jobs.olderThan(time.Now().Add(-5 * 24 * time.Hour))
+	c.Assert(old, chk.HasLen, 1)
+	c.Assert(old[oldJob], chk.DeepEquals, oldFiles)
+
+	count, err := removeJobFiles(old)
+	c.Assert(err, chk.IsNil)
+	c.Assert(count, chk.Equals, 3)
+	for _, p := range oldFiles {
+		_, err = os.Stat(p)
+		c.Assert(os.IsNotExist(err), chk.Equals, true)
+	}
+}
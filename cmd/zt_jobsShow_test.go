@@ -0,0 +1,77 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+
+	chk "gopkg.in/check.v1"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+)
+
+type jobsShowSuite struct{}
+
+var _ = chk.Suite(&jobsShowSuite{})
+
+func (s *jobsShowSuite) TestExportFormatIsInferredFromTheFileName(c *chk.C) {
+	export, err := parseJobTransfersExport("failed.JSON", "", "Failed")
+	c.Assert(err, chk.IsNil)
+	c.Assert(export.format, chk.Equals, "json")
+
+	export, err = parseJobTransfersExport("failed.txt", "", "Failed")
+	c.Assert(err, chk.IsNil)
+	c.Assert(export.format, chk.Equals, "csv")
+
+	_, err = parseJobTransfersExport("failed.csv", "", "")
+	c.Assert(err, chk.NotNil)
+	_, err = parseJobTransfersExport("failed.csv", "xml", "Failed")
+	c.Assert(err, chk.NotNil)
+	_, err = parseJobTransfersExport("", "csv", "Failed")
+	c.Assert(err, chk.NotNil)
+}
+
+func (s *jobsShowSuite) TestFailedTransfersAreExported(c *chk.C) {
+	details := []common.TransferDetail{
+		{Src: "/data/a,b.txt", Dst: "https://a.blob.core.windows.net/c/a,b.txt", TransferStatus: common.ETransferStatus.Failed(),
+			ErrorCode: 404, ErrorServiceCode: "BlobNotFound", ErrorMessage: "The specified blob does not exist."},
+		{Src: "/data/dir", Dst: "https://a.blob.core.windows.net/c/dir", IsFolderProperties: true, TransferStatus: common.ETransferStatus.Failed()},
+	}
+	folder := c.MkDir()
+
+	csvPath := filepath.Join(folder, "failed.csv")
+	c.Assert(exportJobTransfers(details, jobTransfersExport{path: csvPath, format: "csv"}), chk.IsNil)
+	raw, err := ioutil.ReadFile(csvPath)
+	c.Assert(err, chk.IsNil)
+	c.Assert(string(raw), chk.Equals, "Source,Destination,IsFolder,Status,HTTPStatus,ErrorCode,ErrorMessage\n"+
+		"\"/data/a,b.txt\",\"https://a.blob.core.windows.net/c/a,b.txt\",false,Failed,404,BlobNotFound,The specified blob does not exist.\n"+
+		"/data/dir,https://a.blob.core.windows.net/c/dir,true,Failed,,,\n")
+
+	jsonPath := filepath.Join(folder, "failed.json")
+	c.Assert(exportJobTransfers(details, jobTransfersExport{path: jsonPath, format: "json"}), chk.IsNil)
+	raw, err = ioutil.ReadFile(jsonPath)
+	c.Assert(err, chk.IsNil)
+	var exported []common.TransferDetail
+	c.Assert(json.Unmarshal(raw, &exported), chk.IsNil)
+	c.Assert(exported, chk.DeepEquals, details)
+}
@@ -0,0 +1,161 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"sort"
+	"strings"
+
+	chk "gopkg.in/check.v1"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+)
+
+type listSuite struct{}
+
+var _ = chk.Suite(&listSuite{})
+
+// mockListOutput replaces glcm with a mock that collects the output in the given format, until restored
+func mockListOutput(format common.OutputFormat) (mockedLcm *mockedLifecycleManager, restore func()) {
+	mockedLcm = &mockedLifecycleManager{outputLog: make(chan string, 50)}
+	mockedLcm.SetOutputFormat(format)
+	processLcm := glcm
+	glcm = mockedLcm
+	return mockedLcm, func() { glcm = processLcm }
+}
+
+func (s *listSuite) TestListJsonWithFiltersAndTally(c *chk.C) {
+	dirPath := scenarioHelper{}.generateLocalDirectory(c)
+	defer os.RemoveAll(dirPath)
+	scenarioHelper{}.generateLocalFilesFromList(c, dirPath, []string{"a.txt", "b.pdf", "sub/c.txt"})
+
+	raw := rawListCmdArgs{include: "*.txt", RunningTally: true}
+	filters, err := raw.buildFilters()
+	c.Assert(err, chk.IsNil)
+	cooked := cookedListCmdArgs{RunningTally: true, filters: filters, properties: []validProperty{lastModifiedTime}}
+
+	mockedLcm, restore := mockListOutput(common.EOutputFormat.Json())
+	defer restore()
+	err = cooked.listObjects(newLocalTraverser(dirPath, true, false, nil), ELocationLevel.Container())
+	c.Assert(err, chk.IsNil)
+
+	lines := mockedLcm.GatherAllLogs(mockedLcm.outputLog)
+
+	// the name filters only apply to files, so the folders are listed too
+	paths := make([]string, 0)
+	for _, line := range lines[:len(lines)-1] {
+		var listed listedObject
+		c.Assert(json.Unmarshal([]byte(line), &listed), chk.IsNil)
+		c.Assert(listed.LastModifiedTime, chk.NotNil)
+		if listed.EntityType == common.EEntityType.File().String() {
+			c.Assert(listed.ContentLength, chk.Equals, int64(defaultFileSize))
+			paths = append(paths, listed.Path)
+		}
+	}
+	sort.Strings(paths)
+	c.Assert(paths, chk.DeepEquals, []string{"a.txt", "sub/c.txt"})
+
+	var tally listTally
+	c.Assert(json.Unmarshal([]byte(lines[len(lines)-1]), &tally), chk.IsNil)
+	c.Assert(tally, chk.Equals, listTally{FileCount: 2, TotalFileSize: 2 * defaultFileSize})
+}
+
+func (s *listSuite) TestListCsvHasHeaderAndProperties(c *chk.C) {
+	output := &listObjectOutput{cooked: cookedListCmdArgs{properties: []validProperty{metadata, versionId}}, level: ELocationLevel.Container()}
+
+	object := StoredObject{relativePath: "dir/blob", entityType: common.EEntityType.File(), size: 7,
+		Metadata: common.Metadata{"b": "2", "a": "1"}, blobVersionID: "v1"}
+	first := output.builder(object)(common.EOutputFormat.Csv())
+	object.relativePath = "dir/with, comma"
+	second := output.builder(object)(common.EOutputFormat.Csv())
+
+	// only the first object's output has the header row
+	records, err := csv.NewReader(strings.NewReader(first + "\n" + second)).ReadAll()
+	c.Assert(err, chk.IsNil)
+	c.Assert(records, chk.DeepEquals, [][]string{
+		{"Path", "ContainerName", "EntityType", "ContentLength", "Metadata", "VersionId"},
+		{"dir/blob", "", "File", "7", "a=1;b=2", "v1"},
+		{"dir/with, comma", "", "File", "7", "a=1;b=2", "v1"},
+	})
+}
+
+func (s *listSuite) TestListOutputTypeAndIncludeFlagsAreValidated(c *chk.C) {
+	defer func(format common.OutputFormat) { azcopyOutputFormat = format }(azcopyOutputFormat)
+	azcopyOutputFormat = common.EOutputFormat.Csv()
+	_, err := rawListCmdArgs{sourcePath: "https://account.blob.core.windows.net/container", RunningTally: true}.cook()
+	c.Assert(err, chk.NotNil)
+	azcopyOutputFormat = common.EOutputFormat.Text()
+
+	_, err = rawListCmdArgs{sourcePath: "https://account.file.core.windows.net/share", includeVersions: true}.cook()
+	c.Assert(err, chk.NotNil)
+
+	// the version IDs are listed whenever the versions are
+	cooked, err := rawListCmdArgs{sourcePath: "https://account.blob.core.windows.net/container", includeVersions: true, recursive: true}.cook()
+	c.Assert(err, chk.IsNil)
+	c.Assert(cooked.hasProperty(versionId), chk.Equals, true)
+}
+
+func (s *listSuite) TestListAcceptsS3GCPAndLocal(c *chk.C) {
+	for _, source := range []string{"https://s3.amazonaws.com/bucket", "https://storage.cloud.google.com/bucket", "/tmp/dir"} {
+		_, err := rawListCmdArgs{sourcePath: source, recursive: true}.cook()
+		c.Assert(err, chk.IsNil)
+	}
+
+	// only the blob traverser lists versions
+	_, err := rawListCmdArgs{sourcePath: "https://s3.amazonaws.com/bucket", includeVersions: true}.cook()
+	c.Assert(err, chk.NotNil)
+
+	// the properties of S3 objects are got separately, but only when they can't be had from the listing
+	cooked, err := rawListCmdArgs{sourcePath: "https://s3.amazonaws.com/bucket", Properties: "LastModifiedTime"}.cook()
+	c.Assert(err, chk.IsNil)
+	c.Assert(cooked.needsObjectProperties(), chk.Equals, false)
+	cooked, err = rawListCmdArgs{sourcePath: "https://s3.amazonaws.com/bucket", Properties: "ContentType"}.cook()
+	c.Assert(err, chk.IsNil)
+	c.Assert(cooked.needsObjectProperties(), chk.Equals, true)
+}
+
+func (s *listSuite) TestListLocalDirectory(c *chk.C) {
+	dirPath := scenarioHelper{}.generateLocalDirectory(c)
+	defer os.RemoveAll(dirPath)
+	scenarioHelper{}.generateLocalFilesFromList(c, dirPath, []string{"a.txt", "sub/b.txt"})
+
+	cooked, err := rawListCmdArgs{sourcePath: dirPath}.cook()
+	c.Assert(err, chk.IsNil)
+	traverser, level, err := newListCmdTraverser(context.Background(), glcm, cooked)
+	c.Assert(err, chk.IsNil)
+
+	mockedLcm, restore := mockListOutput(common.EOutputFormat.Csv())
+	defer restore()
+	c.Assert(cooked.listObjects(traverser, level), chk.IsNil)
+	lines := mockedLcm.GatherAllLogs(mockedLcm.outputLog)
+	records, err := csv.NewReader(strings.NewReader(strings.Join(lines, "\n"))).ReadAll()
+	c.Assert(err, chk.IsNil)
+
+	// not recursive, so only the file at the top is listed
+	c.Assert(records, chk.DeepEquals, [][]string{
+		{"Path", "ContainerName", "EntityType", "ContentLength"},
+		{"a.txt", "", "File", "1024"},
+	})
+}
@@ -0,0 +1,82 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"github.com/Azure/azure-storage-blob-go/azblob"
+	"github.com/Azure/azure-storage-file-go/azfile"
+	chk "gopkg.in/check.v1"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+)
+
+type makeSuite struct{}
+
+var _ = chk.Suite(&makeSuite{})
+
+func (s *makeSuite) TestMakeDirectories(c *chk.C) {
+	cooked, err := rawMakeCmdArgs{resourceToCreate: "https://account.dfs.core.windows.net/fs/dir/subdir/", permissions: "rwxr-x---",
+		defaultACL: "user::rwx,default:group::r-x,other::---", metadata: "project=alpha;owner=a=b"}.cook()
+	c.Assert(err, chk.IsNil)
+	c.Assert(cooked.directoryPath, chk.Equals, "dir/subdir")
+	c.Assert(cooked.defaultACL, chk.Equals, "default:user::rwx,default:group::r-x,default:other::---")
+	c.Assert(cooked.metadata, chk.DeepEquals, common.Metadata{"project": "alpha", "owner": "a=b"})
+
+	cooked, err = rawMakeCmdArgs{resourceToCreate: "https://account.file.core.windows.net/share/dir/subdir?sig=x",
+		fileAttributes: "Hidden;readonly", filePermission: "O:BAG:SYD:(A;;FA;;;SY)"}.cook()
+	c.Assert(err, chk.IsNil)
+	c.Assert(cooked.directoryPath, chk.Equals, "dir/subdir")
+	c.Assert(*cooked.smbProperties.FileAttributes, chk.Equals, azfile.FileAttributeHidden|azfile.FileAttributeReadonly)
+	c.Assert(*cooked.smbProperties.PermissionString, chk.Equals, "O:BAG:SYD:(A;;FA;;;SY)")
+
+	// containers have no directories
+	_, err = rawMakeCmdArgs{resourceToCreate: "https://account.blob.core.windows.net/container/dir"}.cook()
+	c.Assert(err, chk.NotNil)
+}
+
+func (s *makeSuite) TestMakeOptionsAreValidated(c *chk.C) {
+	cooked, err := rawMakeCmdArgs{resourceToCreate: "https://account.blob.core.windows.net/container", publicAccess: "Blob",
+		defaultEncryptionScope: "scope", preventEncryptionScopeOverride: true}.cook()
+	c.Assert(err, chk.IsNil)
+	c.Assert(cooked.publicAccess, chk.Equals, azblob.PublicAccessBlob)
+	c.Assert(cooked.defaultEncryptionScope, chk.Equals, "scope")
+
+	cooked, err = rawMakeCmdArgs{resourceToCreate: "https://account.file.core.windows.net/share", accessTier: "transactionoptimized"}.cook()
+	c.Assert(err, chk.IsNil)
+	c.Assert(cooked.accessTier, chk.Equals, "TransactionOptimized")
+
+	for _, raw := range []rawMakeCmdArgs{
+		{resourceToCreate: "https://account.blob.core.windows.net/container", publicAccess: "everyone"},
+		{resourceToCreate: "https://account.blob.core.windows.net/container", preventEncryptionScopeOverride: true},
+		{resourceToCreate: "https://account.blob.core.windows.net/container", accessTier: "Hot"},
+		{resourceToCreate: "https://account.blob.core.windows.net/container", metadata: "project"},
+		{resourceToCreate: "https://account.file.core.windows.net/share", accessTier: "Archive"},
+		{resourceToCreate: "https://account.file.core.windows.net/share", fileAttributes: "Hidden"},
+		{resourceToCreate: "https://account.file.core.windows.net/share/dir", fileAttributes: "Invisible"},
+		{resourceToCreate: "https://account.file.core.windows.net/share/dir", permissions: "0750"},
+		{resourceToCreate: "https://account.dfs.core.windows.net/fs", metadata: "project=alpha"},
+		{resourceToCreate: "https://account.dfs.core.windows.net/fs/dir", permissions: "rwxrwxrwz"},
+		{resourceToCreate: "https://account.dfs.core.windows.net/fs/dir", defaultACL: "everyone::rwx"},
+	} {
+		_, err = raw.cook()
+		c.Assert(err, chk.NotNil, chk.Commentf("%+v", raw))
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"os"
+
+	chk "gopkg.in/check.v1"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+)
+
+type moveSuite struct{}
+
+var _ = chk.Suite(&moveSuite{})
+
+func getDefaultMoveRawInput(src string, dst string) rawCopyCmdArgs {
+	raw := getDefaultCopyRawInput(src, dst)
+	raw.deleteSourceOnSuccess = true
+	return raw
+}
+
+func (s *moveSuite) TestMoveRenamesWithinFilesystemOrShare(c *chk.C) {
+	// within a filesystem, or a share, the source is renamed
+	rename, err := newServerSideRename(getDefaultMoveRawInput("https://account.dfs.core.windows.net/fs/dir?sv=1", "https://account.dfs.core.windows.net/fs/newdir?sv=2"))
+	c.Assert(err, chk.IsNil)
+	c.Assert(rename, chk.NotNil)
+	c.Assert(rename.location, chk.Equals, common.ELocation.BlobFS())
+	c.Assert(rename.destination.SAS, chk.Equals, "sv=2")
+
+	rename, err = newServerSideRename(getDefaultMoveRawInput("https://account.file.core.windows.net/share/dir/file?sv=1", "https://account.file.core.windows.net/share/other?sv=1"))
+	c.Assert(err, chk.IsNil)
+	c.Assert(rename, chk.NotNil)
+	c.Assert(rename.location, chk.Equals, common.ELocation.File())
+
+	// otherwise, Azure Files are copied and deleted
+	for _, raw := range []rawCopyCmdArgs{
+		getDefaultMoveRawInput("https://account.file.core.windows.net/share/dir?sv=1", "https://account.file.core.windows.net/othershare/dir?sv=1"),
+		getDefaultMoveRawInput("https://account.file.core.windows.net/share?sv=1", "https://account.file.core.windows.net/share/dir?sv=1"),
+		getDefaultMoveRawInput("https://account.file.core.windows.net/share/dir/*?sv=1", "https://account.file.core.windows.net/share/other?sv=1"),
+		getDefaultMoveRawInput("https://account.blob.core.windows.net/container/dir", "https://account.blob.core.windows.net/container/other"),
+		getDefaultMoveRawInput("/tmp/dir", "https://account.file.core.windows.net/share/dir?sv=1"),
+	} {
+		rename, err = newServerSideRename(raw)
+		c.Assert(err, chk.IsNil)
+		c.Assert(rename, chk.IsNil)
+	}
+
+	filtered := getDefaultMoveRawInput("https://account.file.core.windows.net/share/dir?sv=1", "https://account.file.core.windows.net/share/other?sv=1")
+	filtered.include = "*.txt"
+	rename, err = newServerSideRename(filtered)
+	c.Assert(err, chk.IsNil)
+	c.Assert(rename, chk.IsNil)
+
+	prompted := getDefaultMoveRawInput("https://account.file.core.windows.net/share/dir?sv=1", "https://account.file.core.windows.net/share/other?sv=1")
+	prompted.forceWrite = common.EOverwriteOption.Prompt().String()
+	rename, err = newServerSideRename(prompted)
+	c.Assert(err, chk.IsNil)
+	c.Assert(rename, chk.IsNil)
+
+	// but paths given by their dfs endpoints can't be copied, so they must be renamed
+	_, err = newServerSideRename(getDefaultMoveRawInput("https://account.dfs.core.windows.net/fs/dir", "https://account.dfs.core.windows.net/otherfs/dir"))
+	c.Assert(err, chk.NotNil)
+}
+
+func (s *moveSuite) TestMoveSourceMustBeDeletable(c *chk.C) {
+	dirPath := scenarioHelper{}.generateLocalDirectory(c)
+	defer os.RemoveAll(dirPath)
+
+	// cooking opens the scanning log
+	oldLogFolder := azcopyLogPathFolder
+	azcopyLogPathFolder = c.MkDir()
+	defer func() { azcopyLogPathFolder = oldLogFolder }()
+
+	raw := getDefaultMoveRawInput(dirPath, "https://account.blob.core.windows.net/container")
+	raw.recursive = true
+	raw.CheckLength = false
+	cooked, err := raw.cook()
+	c.Assert(err, chk.IsNil)
+	c.Assert(cooked.deleteSourceOnSuccess, chk.Equals, true)
+	// the destination is always verified before the source is deleted
+	c.Assert(cooked.CheckLength, chk.Equals, true)
+
+	// AzCopy doesn't delete S3 objects
+	_, err = getDefaultMoveRawInput("https://s3.amazonaws.com/bucket/dir", "https://account.blob.core.windows.net/container").cook()
+	c.Assert(err, chk.NotNil)
+
+	// and copy never deletes its sources
+	cooked, err = getDefaultCopyRawInput(dirPath, "https://account.blob.core.windows.net/container").cook()
+	c.Assert(err, chk.IsNil)
+	c.Assert(cooked.deleteSourceOnSuccess, chk.Equals, false)
+}
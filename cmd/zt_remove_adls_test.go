@@ -362,3 +362,38 @@ func (s *cmdIntegrationSuite) TestRemoveListOfALDSFilesAndDirectoriesWithFromTo(
 		c.Assert(err, chk.IsNil)
 	})
 }
+
+func (s *cmdIntegrationSuite) TestRemoveDirectoryWithFilters(c *chk.C) {
+	// invoke the interceptor so lifecycle manager does not shut down the tests
+	mockedRPC := interceptor{}
+	mockedRPC.init()
+	ctx := context.Background()
+
+	serviceURLWithSAS, _, fsName, dirName, dirURL := createFileSystem(c)
+
+	// set up a file that passes the filter, and one that doesn't
+	removedURL := dirURL.NewFileURL(generateName("file", 0) + ".jpg")
+	_, err := removedURL.Create(ctx, azbfs.BlobFSHTTPHeaders{}, azbfs.BlobFSAccessControl{})
+	c.Assert(err, chk.IsNil)
+	keptURL := dirURL.NewFileURL(generateName("file", 0) + ".pdf")
+	_, err = keptURL.Create(ctx, azbfs.BlobFSHTTPHeaders{}, azbfs.BlobFSAccessControl{})
+	c.Assert(err, chk.IsNil)
+
+	// with a filter, only the files that pass it are removed
+	dirURLWithSAS := serviceURLWithSAS.NewFileSystemURL(fsName).NewDirectoryURL(dirName)
+	raw := getDefaultRemoveRawInput(dirURLWithSAS.String())
+	raw.fromTo = "BlobFSTrash"
+	raw.include = "*.jpg"
+	runCopyAndVerify(c, raw, func(err error) {
+		c.Assert(err, chk.IsNil)
+
+		_, err = removedURL.GetProperties(ctx)
+		c.Assert(err, chk.NotNil)
+
+		// the other file, and the directory, are left alone
+		_, err = keptURL.GetProperties(ctx)
+		c.Assert(err, chk.IsNil)
+		_, err = dirURL.GetProperties(ctx)
+		c.Assert(err, chk.IsNil)
+	})
+}
@@ -0,0 +1,148 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"time"
+
+	chk "gopkg.in/check.v1"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+)
+
+type removePurgeSuite struct{}
+
+var _ = chk.Suite(&removePurgeSuite{})
+
+func (s *removePurgeSuite) TestOlderThan(c *chk.C) {
+	now := time.Date(2021, 3, 31, 12, 0, 0, 0, time.UTC)
+
+	for age, expected := range map[string]time.Time{
+		"30d": now.AddDate(0, 0, -30),
+		"2W":  now.AddDate(0, 0, -14),
+		"36h": now.Add(-36 * time.Hour),
+	} {
+		threshold, err := parseOlderThan(age, now)
+		c.Assert(err, chk.IsNil)
+		c.Assert(threshold.Equal(expected), chk.Equals, true, chk.Commentf(age))
+	}
+
+	for _, age := range []string{"", "d", "0d", "-1d", "30", "thirty days"} {
+		_, err := parseOlderThan(age, now)
+		c.Assert(err, chk.NotNil, chk.Commentf(age))
+	}
+}
+
+func (s *removePurgeSuite) TestPurgeIsOnlyForBlobs(c *chk.C) {
+	none := common.EDeleteSnapshotsOption.None()
+
+	purge, err := validatePurge(rawCopyCmdArgs{purge: "Versions", keepLatest: 3}, common.EFromTo.BlobTrash(), none)
+	c.Assert(err, chk.IsNil)
+	c.Assert(purge, chk.Equals, purgeVersions)
+
+	_, err = validatePurge(rawCopyCmdArgs{keepLatest: 3}, common.EFromTo.BlobTrash(), none)
+	c.Assert(err, chk.NotNil)
+	_, err = validatePurge(rawCopyCmdArgs{purge: "blobs"}, common.EFromTo.BlobTrash(), none)
+	c.Assert(err, chk.NotNil)
+	_, err = validatePurge(rawCopyCmdArgs{purge: "snapshots"}, common.EFromTo.FileTrash(), none)
+	c.Assert(err, chk.NotNil)
+	_, err = validatePurge(rawCopyCmdArgs{purge: "snapshots"}, common.EFromTo.BlobTrash(), common.EDeleteSnapshotsOption.Include())
+	c.Assert(err, chk.NotNil)
+	_, err = validatePurge(rawCopyCmdArgs{purge: "versions", permanentDeleteOption: "versions"}, common.EFromTo.BlobTrash(), none)
+	c.Assert(err, chk.NotNil)
+}
+
+func (s *removePurgeSuite) TestLatestVersionsAreKept(c *chk.C) {
+	now := time.Date(2021, 3, 31, 12, 0, 0, 0, time.UTC)
+	version := func(path string, daysAgo int, current bool) StoredObject {
+		return StoredObject{relativePath: path, entityType: common.EEntityType.File(), size: 10,
+			blobVersionID: now.AddDate(0, 0, -daysAgo).Format(time.RFC3339Nano), blobIsCurrentVersion: current,
+			lastModifiedTime: now}
+	}
+
+	var removed []StoredObject
+	threshold := now.AddDate(0, 0, -30)
+	p := newPurgeProcessor(func(object StoredObject) error {
+		removed = append(removed, object)
+		return nil
+	}, purgeVersions, 2, &threshold, nil)
+
+	// a.txt has versions from 100, 90, 60, 40 and 10 days ago, besides the current one.
+	// The latest two are kept, and of the rest, only those older than 30 days are removed.
+	for _, object := range []StoredObject{
+		version("a.txt", 100, false),
+		version("a.txt", 40, false),
+		version("a.txt", 90, false),
+		version("a.txt", 10, false),
+		version("a.txt", 60, false),
+		version("a.txt", 0, true),
+		// b.txt isn't versioned, and its directory isn't a blob
+		{relativePath: "b.txt", entityType: common.EEntityType.File(), size: 1, lastModifiedTime: now.AddDate(-1, 0, 0)},
+		{relativePath: "dir", entityType: common.EEntityType.Folder()},
+		// c.txt has one old version, which is kept as one of the latest two
+		version("c.txt", 365, false),
+		version("c.txt", 0, true),
+	} {
+		c.Assert(p.process(object), chk.IsNil)
+	}
+	c.Assert(p.flush(), chk.IsNil)
+
+	c.Assert(removed, chk.HasLen, 4)
+	c.Assert(removed[0].blobVersionID, chk.Equals, version("a.txt", 60, false).blobVersionID)
+	c.Assert(removed[1].blobVersionID, chk.Equals, version("a.txt", 90, false).blobVersionID)
+	c.Assert(removed[2].blobVersionID, chk.Equals, version("a.txt", 100, false).blobVersionID)
+	c.Assert(removed[3].entityType, chk.Equals, common.EEntityType.Folder())
+
+	c.Assert(p.report.Purged.Versions, chk.Equals, usageTally{Count: 3, Bytes: 30})
+	c.Assert(p.report.Kept.Versions, chk.Equals, usageTally{Count: 3, Bytes: 30})
+	c.Assert(p.report.Kept.Blobs, chk.Equals, usageTally{Count: 3, Bytes: 21})
+	c.Assert(p.report.Purged.Blobs, chk.Equals, usageTally{})
+}
+
+func (s *removePurgeSuite) TestOldSnapshotsArePurged(c *chk.C) {
+	now := time.Date(2021, 3, 31, 12, 0, 0, 0, time.UTC)
+	var removed []StoredObject
+	threshold := now.AddDate(0, 0, -7)
+	p := newPurgeProcessor(func(object StoredObject) error {
+		removed = append(removed, object)
+		return nil
+	}, purgeSnapshots, 0, &threshold, nil)
+
+	// it's the time of the snapshot that counts, not when the blob was last modified before it
+	snapshot := func(daysAgo int) StoredObject {
+		return StoredObject{relativePath: "a.txt", entityType: common.EEntityType.File(), size: 5,
+			blobSnapshotID: now.AddDate(0, 0, -daysAgo).Format(time.RFC3339Nano), lastModifiedTime: now.AddDate(-1, 0, 0)}
+	}
+	for _, object := range []StoredObject{
+		{relativePath: "a.txt", entityType: common.EEntityType.File(), size: 5, lastModifiedTime: now.AddDate(-1, 0, 0)},
+		snapshot(30),
+		snapshot(1),
+	} {
+		c.Assert(p.process(object), chk.IsNil)
+	}
+	c.Assert(p.flush(), chk.IsNil)
+
+	c.Assert(removed, chk.HasLen, 1)
+	c.Assert(removed[0].blobSnapshotID, chk.Equals, snapshot(30).blobSnapshotID)
+	c.Assert(p.report.Purged.Snapshots.Count, chk.Equals, int64(1))
+	c.Assert(p.report.Kept.Snapshots.Count, chk.Equals, int64(1))
+	c.Assert(p.report.Kept.Blobs.Count, chk.Equals, int64(1))
+}
@@ -0,0 +1,120 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"time"
+
+	chk "gopkg.in/check.v1"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+)
+
+type restoreSuite struct{}
+
+var _ = chk.Suite(&restoreSuite{})
+
+func (s *restoreSuite) TestRestoreModeAndTime(c *chk.C) {
+	now := time.Date(2021, 3, 31, 12, 0, 0, 0, time.UTC)
+
+	cooked := CookedCopyCmdArgs{}
+	c.Assert(cookRestore(&cooked, "undelete", "", now), chk.IsNil)
+	c.Assert(cooked.propertiesToSet, chk.Equals, common.ESetPropertiesFlags.Undelete())
+	c.Assert(cooked.restoreAsOf, chk.IsNil)
+
+	c.Assert(cookRestore(&cooked, "Version", "2d", now), chk.IsNil)
+	c.Assert(cooked.propertiesToSet, chk.Equals, common.ESetPropertiesFlags.PromoteVersion())
+	c.Assert(cooked.propertiesToSet.IsRestore(), chk.Equals, true)
+	c.Assert(cooked.restoreAsOf.Equal(now.AddDate(0, 0, -2)), chk.Equals, true)
+
+	c.Assert(cookRestore(&cooked, "version", "2021-03-01T00:00:00Z", now), chk.IsNil)
+	c.Assert(cooked.restoreAsOf.Equal(time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)), chk.Equals, true)
+
+	c.Assert(cookRestore(&cooked, "rollback", "", now), chk.NotNil)
+	c.Assert(cookRestore(&cooked, "undelete", "last week", now), chk.NotNil)
+	c.Assert(common.ESetPropertiesFlags.SetTier().IsRestore(), chk.Equals, false)
+}
+
+func (s *restoreSuite) TestOnlyBlobsDeletedSinceAreUndeleted(c *chk.C) {
+	now := time.Date(2021, 3, 31, 12, 0, 0, 0, time.UTC)
+	asOf := now.AddDate(0, 0, -1)
+
+	var undeleted []string
+	p := newUndeleteProcessor(func(object StoredObject) error {
+		undeleted = append(undeleted, object.relativePath)
+		return nil
+	}, &asOf)
+
+	for _, object := range []StoredObject{
+		{relativePath: "live.txt"},
+		{relativePath: "recent.txt", blobDeleted: true, blobDeletedTime: now.Add(-time.Hour)},
+		{relativePath: "old.txt", blobDeleted: true, blobDeletedTime: now.AddDate(0, 0, -3)},
+	} {
+		c.Assert(p(object), chk.IsNil)
+	}
+	c.Assert(undeleted, chk.DeepEquals, []string{"recent.txt"})
+}
+
+func (s *restoreSuite) TestBlobsAreRestoredToTheRightVersion(c *chk.C) {
+	now := time.Date(2021, 3, 31, 12, 0, 0, 0, time.UTC)
+	version := func(path string, daysAgo int, current bool) StoredObject {
+		return StoredObject{relativePath: path, entityType: common.EEntityType.File(),
+			blobVersionID: now.AddDate(0, 0, -daysAgo).Format(time.RFC3339Nano), blobIsCurrentVersion: current}
+	}
+	listing := []StoredObject{
+		// changed since, so it goes back to the version from 10 days ago
+		version("changed.txt", 20, false),
+		version("changed.txt", 10, false),
+		version("changed.txt", 1, true),
+		// unchanged since, so it's left as it is
+		version("unchanged.txt", 30, false),
+		version("unchanged.txt", 8, true),
+		// deleted since, so there's no current version
+		version("deleted.txt", 9, false),
+		version("deleted.txt", 6, false),
+		// created since, so there's nothing to restore it to
+		version("new.txt", 2, true),
+	}
+
+	restore := func(asOf *time.Time) []StoredObject {
+		var restored []StoredObject
+		p := &restoreVersionProcessor{next: func(object StoredObject) error {
+			restored = append(restored, object)
+			return nil
+		}, asOf: asOf}
+		for _, object := range listing {
+			c.Assert(p.process(object), chk.IsNil)
+		}
+		c.Assert(p.flush(), chk.IsNil)
+		return restored
+	}
+
+	asOf := now.AddDate(0, 0, -7)
+	restored := restore(&asOf)
+	c.Assert(restored, chk.HasLen, 2)
+	c.Assert(restored[0].blobVersionID, chk.Equals, version("changed.txt", 10, false).blobVersionID)
+	c.Assert(restored[1].blobVersionID, chk.Equals, version("deleted.txt", 9, false).blobVersionID)
+
+	// without a time, only the deleted blobs are restored, to their latest versions
+	restored = restore(nil)
+	c.Assert(restored, chk.HasLen, 1)
+	c.Assert(restored[0].blobVersionID, chk.Equals, version("deleted.txt", 6, false).blobVersionID)
+}
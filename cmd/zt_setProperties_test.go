@@ -0,0 +1,55 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	chk "gopkg.in/check.v1"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+)
+
+type setPropertiesSuite struct{}
+
+var _ = chk.Suite(&setPropertiesSuite{})
+
+func (s *setPropertiesSuite) TestOnlyTheGivenPropertiesAreSet(c *chk.C) {
+	cooked := CookedCopyCmdArgs{blockBlobTier: common.EBlockBlobTier.Cool(), contentType: "text/plain"}
+	flags, err := propertiesToSet(&cooked, false, false)
+	c.Assert(err, chk.IsNil)
+	c.Assert(flags.ShouldSetTier(), chk.Equals, true)
+	c.Assert(flags.ShouldSetHTTPHeaders(), chk.Equals, true)
+	c.Assert(flags.ShouldSetMetadata(), chk.Equals, false)
+	c.Assert(flags.ShouldSetBlobTags(), chk.Equals, false)
+
+	// empty metadata and tags clear them, so they're set whenever their flags are given
+	flags, err = propertiesToSet(&CookedCopyCmdArgs{}, true, true)
+	c.Assert(err, chk.IsNil)
+	c.Assert(flags, chk.Equals, common.ESetPropertiesFlags.SetMetadata()|common.ESetPropertiesFlags.SetBlobTags())
+}
+
+func (s *setPropertiesSuite) TestSomethingMustBeSet(c *chk.C) {
+	_, err := propertiesToSet(&CookedCopyCmdArgs{}, false, false)
+	c.Assert(err, chk.NotNil)
+
+	// a rehydrate priority is meaningless without a tier
+	_, err = propertiesToSet(&CookedCopyCmdArgs{rehydratePriority: common.ERehydratePriorityType.High()}, true, false)
+	c.Assert(err, chk.NotNil)
+}
@@ -43,6 +43,7 @@ func (s *syncProcessorSuite) TestLocalDeleter(c *chk.C) {
 
 	// construct the cooked input to simulate user input
 	cca := &cookedSyncCmdArgs{
+		lcm:               glcm,
 		destination:       newLocalRes(dstDirName),
 		deleteDestination: common.EDeleteDestination.True(),
 	}
@@ -80,6 +81,7 @@ func (s *syncProcessorSuite) TestBlobDeleter(c *chk.C) {
 	// construct the cooked input to simulate user input
 	rawContainerURL := scenarioHelper{}.getRawContainerURLWithSAS(c, containerName)
 	cca := &cookedSyncCmdArgs{
+		lcm:               glcm,
 		destination:       newRemoteRes(rawContainerURL.String()),
 		credentialInfo:    common.CredentialInfo{CredentialType: common.ECredentialType.Anonymous()},
 		deleteDestination: common.EDeleteDestination.True(),
@@ -116,6 +118,7 @@ func (s *syncProcessorSuite) TestFileDeleter(c *chk.C) {
 	// construct the cooked input to simulate user input
 	rawShareSAS := scenarioHelper{}.getRawShareURLWithSAS(c, shareName)
 	cca := &cookedSyncCmdArgs{
+		lcm:               glcm,
 		destination:       newRemoteRes(rawShareSAS.String()),
 		credentialInfo:    common.CredentialInfo{CredentialType: common.ECredentialType.Anonymous()},
 		deleteDestination: common.EDeleteDestination.True(),
@@ -21,6 +21,7 @@
 package cmd
 
 import (
+	"github.com/Azure/azure-storage-azcopy/v10/common"
 	chk "gopkg.in/check.v1"
 )
 
@@ -56,3 +57,87 @@ func (s *cmdIntegrationSuite) TestCPKEncryptionInputTest(c *chk.C) {
 	_, err := raw2.cook()
 	c.Assert(err, chk.IsNil)
 }
+
+func (s *cmdIntegrationSuite) TestIncrementalFromInputTest(c *chk.C) {
+	overwrite := common.EOverwriteOption.True()
+	srcSnapshot := common.ResourceString{Value: "https://acct.blob.core.windows.net/c/disk", ExtraQuery: "snapshot=2022-02-01T00:00:00.0000000Z"}
+	srcDisk := common.ResourceString{Value: "https://md-def.blob.core.windows.net/disk/abcd", SAS: "sv=2020-01-01&sig=secret2"}
+
+	// not used
+	baseline, baselineSAS, err := validateIncrementalFrom("", common.ResourceString{}, common.EFromTo.LocalBlob(), overwrite, true)
+	c.Assert(err, chk.IsNil)
+	c.Assert(baseline, chk.Equals, "")
+	c.Assert(baselineSAS, chk.Equals, "")
+
+	// snapshot timestamp
+	baseline, _, err = validateIncrementalFrom("2022-01-01T00:00:00.0000000Z", srcSnapshot, common.EFromTo.BlobBlob(), overwrite, false)
+	c.Assert(err, chk.IsNil)
+	c.Assert(baseline, chk.Equals, "2022-01-01T00:00:00.0000000Z")
+
+	// snapshot URL, from which the SAS must be removed since it will be persisted, but which is kept in memory
+	baseline, baselineSAS, err = validateIncrementalFrom("https://md-abc.blob.core.windows.net/disk/abcd?sv=2020-01-01&sig=secret", srcDisk, common.EFromTo.BlobBlob(), overwrite, false)
+	c.Assert(err, chk.IsNil)
+	c.Assert(baseline, chk.Equals, "https://md-abc.blob.core.windows.net/disk/abcd")
+	c.Assert(baselineSAS, chk.Equals, "sv=2020-01-01&sig=secret")
+
+	// invalid cases
+	_, _, err = validateIncrementalFrom("yesterday", srcSnapshot, common.EFromTo.BlobBlob(), overwrite, false)
+	c.Assert(err, chk.NotNil)
+	_, _, err = validateIncrementalFrom("2022-01-01T00:00:00.0000000Z", srcSnapshot, common.EFromTo.LocalBlob(), overwrite, false)
+	c.Assert(err, chk.NotNil)
+	_, _, err = validateIncrementalFrom("2022-01-01T00:00:00.0000000Z", srcSnapshot, common.EFromTo.BlobBlob(), overwrite, true)
+	c.Assert(err, chk.NotNil)
+	_, _, err = validateIncrementalFrom("2022-01-01T00:00:00.0000000Z", srcSnapshot, common.EFromTo.BlobBlob(), common.EOverwriteOption.False(), false)
+	c.Assert(err, chk.NotNil)
+
+	// the live source would change after the copy, so couldn't be the next baseline
+	_, _, err = validateIncrementalFrom("2022-01-01T00:00:00.0000000Z", common.ResourceString{Value: "https://acct.blob.core.windows.net/c/disk"}, common.EFromTo.BlobBlob(), overwrite, false)
+	c.Assert(err, chk.NotNil)
+}
+
+func (s *cmdIntegrationSuite) TestDiskImageFormatInputTest(c *chk.C) {
+	var format common.DiskImageFormat
+	c.Assert(format.Parse("qcow2"), chk.IsNil)
+	c.Assert(format, chk.Equals, common.EDiskImageFormat.QCOW2())
+
+	c.Assert(validateDiskImageFormat(common.EDiskImageFormat.None(), common.EFromTo.BlobLocal(), common.EBlobType.Detect()), chk.IsNil)
+	c.Assert(validateDiskImageFormat(format, common.EFromTo.LocalBlob(), common.EBlobType.Detect()), chk.IsNil)
+	c.Assert(validateDiskImageFormat(format, common.EFromTo.LocalBlob(), common.EBlobType.PageBlob()), chk.IsNil)
+
+	// invalid cases
+	c.Assert(validateDiskImageFormat(format, common.EFromTo.LocalBlob(), common.EBlobType.BlockBlob()), chk.NotNil)
+	c.Assert(validateDiskImageFormat(format, common.EFromTo.LocalFile(), common.EBlobType.Detect()), chk.NotNil)
+	c.Assert(format.Parse("vdi"), chk.NotNil)
+}
+
+func (s *cmdIntegrationSuite) TestFollowInputTest(c *chk.C) {
+	appendBlob := common.EBlobType.AppendBlob()
+
+	c.Assert(validateFollow(false, common.EFromTo.BlobLocal(), common.EBlobType.Detect(), true), chk.IsNil)
+	c.Assert(validateFollow(true, common.EFromTo.LocalBlob(), appendBlob, false), chk.IsNil)
+
+	// invalid cases
+	c.Assert(validateFollow(true, common.EFromTo.LocalBlob(), common.EBlobType.Detect(), false), chk.NotNil)
+	c.Assert(validateFollow(true, common.EFromTo.LocalFile(), appendBlob, false), chk.NotNil)
+	c.Assert(validateFollow(true, common.EFromTo.LocalBlob(), appendBlob, true), chk.NotNil)
+}
+
+func (s *cmdIntegrationSuite) TestJobLimitsInputTest(c *chk.C) {
+	priority, maxConcurrency, maxBytesPerSecond, err := validateJobLimits("high", 16, 80)
+	c.Assert(err, chk.IsNil)
+	c.Assert(priority, chk.Equals, common.EJobPriority.High())
+	c.Assert(maxConcurrency, chk.Equals, int32(16))
+	c.Assert(maxBytesPerSecond, chk.Equals, int64(10*1000*1000))
+
+	priority, _, _, err = validateJobLimits("", 0, 0)
+	c.Assert(err, chk.IsNil)
+	c.Assert(priority, chk.Equals, common.EJobPriority.Normal())
+
+	// invalid cases
+	_, _, _, err = validateJobLimits("urgent", 0, 0)
+	c.Assert(err, chk.NotNil)
+	_, _, _, err = validateJobLimits("Low", -1, 0)
+	c.Assert(err, chk.NotNil)
+	_, _, _, err = validateJobLimits("Low", 0, -5)
+	c.Assert(err, chk.NotNil)
+}
@@ -39,6 +39,9 @@ type CacheLimiter interface {
 	WaitUntilAdd(ctx context.Context, count int64, useRelaxedLimit Predicate) error
 	Remove(count int64)
 	Limit() int64
+
+	// Value is the amount that is currently added
+	Value() int64
 }
 
 type cacheLimiter struct {
@@ -109,3 +112,7 @@ func (c *cacheLimiter) Remove(count int64) {
 func (c *cacheLimiter) Limit() int64 {
 	return c.limit
 }
+
+func (c *cacheLimiter) Value() int64 {
+	return atomic.LoadInt64(&c.value)
+}
@@ -27,6 +27,7 @@ import (
 	"hash"
 	"io"
 	"math"
+	"os"
 	"sync/atomic"
 	"time"
 )
@@ -46,6 +47,10 @@ type ChunkedFileWriter interface {
 	// After the chunk is written to disk, its reserved memory byte allocation is automatically subtracted from the CacheLimiter.
 	EnqueueChunk(ctx context.Context, id ChunkID, chunkSize int64, chunkContents io.Reader, retryable bool) error
 
+	// EnqueueZeroChunk is like EnqueueChunk, but for a chunk that is known to be entirely zeros (e.g. an empty range in a page blob).
+	// Where the local file system supports it, that range of the file is left as a hole, rather than being written.
+	EnqueueZeroChunk(ctx context.Context, id ChunkID, chunkSize int64) error
+
 	// Flush will block until all the chunks have been written to disk.  err will be non-nil if and only in any chunk failed to write.
 	// Flush must be called exactly once, after all chunks have been enqueued with EnqueueChunk.
 	Flush(ctx context.Context) (md5HashOfFileAsWritten []byte, err error)
@@ -96,15 +101,40 @@ type chunkedFileWriter struct {
 
 	currentReservedCapacity int64
 
+	// offset at which writing starts. Non-zero when resuming a partially-downloaded file
+	startOffset int64
+
+	// content already in the file, before startOffset. Only read if we need to include it in the hash
+	existingContent io.Reader
+
+	// optional callback, told the new contiguous end of the saved data after each chunk is saved
+	reportSavedOffset func(nextOffset int64)
+
 	err error //This field should be set only by workerRoutine
 }
 
 type fileChunk struct {
-	id   ChunkID
-	data []byte
+	id      ChunkID
+	data    []byte
+	isZeros bool // if true, data is nil, since we know the whole chunk is zeros without needing a buffer for it
+}
+
+func (c fileChunk) length() int64 {
+	if c.isZeros {
+		return c.id.length
+	}
+	return int64(len(c.data))
 }
 
 func NewChunkedFileWriter(ctx context.Context, slicePool ByteSlicePooler, cacheLimiter CacheLimiter, chunkLogger ChunkStatusLogger, file io.WriteCloser, numChunks uint32, maxBodyRetries int, md5ValidationOption HashValidationOption, sourceMd5Exists bool) ChunkedFileWriter {
+	return NewResumableChunkedFileWriter(ctx, slicePool, cacheLimiter, chunkLogger, file, numChunks, maxBodyRetries, md5ValidationOption, sourceMd5Exists, 0, nil, nil)
+}
+
+// NewResumableChunkedFileWriter is like NewChunkedFileWriter, but the file is assumed to already hold valid content
+// up to startOffset (and to be positioned there). If a hash is required, existingContent is read to include
+// that prefix in it. reportSavedOffset, if not nil, is called each time the contiguous saved region grows.
+func NewResumableChunkedFileWriter(ctx context.Context, slicePool ByteSlicePooler, cacheLimiter CacheLimiter, chunkLogger ChunkStatusLogger, file io.WriteCloser, numChunks uint32, maxBodyRetries int, md5ValidationOption HashValidationOption, sourceMd5Exists bool,
+	startOffset int64, existingContent io.Reader, reportSavedOffset func(nextOffset int64)) ChunkedFileWriter {
 	// Set max size for buffered channel. The upper limit here is believed to be generous, given worker routine drains it constantly.
 	// Use num chunks in file if lower than the upper limit, to prevent allocating RAM for lots of large channel buffers when dealing with
 	// very large numbers of very small files.
@@ -122,6 +152,9 @@ func NewChunkedFileWriter(ctx context.Context, slicePool ByteSlicePooler, cacheL
 		md5ValidationOption:     md5ValidationOption,
 		sourceMd5Exists:         sourceMd5Exists,
 		currentReservedCapacity: 0,
+		startOffset:             startOffset,
+		existingContent:         existingContent,
+		reportSavedOffset:       reportSavedOffset,
 	}
 	go w.workerRoutine(ctx)
 	return w
@@ -202,6 +235,27 @@ func (w *chunkedFileWriter) EnqueueChunk(ctx context.Context, id ChunkID, chunkS
 	}
 }
 
+// Threadsafe method to enqueue a chunk that is all zeros. No RAM is needed for it beyond what was reserved in WaitToScheduleChunk,
+// and that is released as soon as it's saved.
+func (w *chunkedFileWriter) EnqueueZeroChunk(ctx context.Context, id ChunkID, chunkSize int64) error {
+	atomic.AddInt32(&w.totalReceivedChunkCount, 1)
+
+	w.chunkLogger.LogChunkStatus(id, EWaitReason.Sorting())
+	select {
+	case <-w.chunkWriterDone:
+		w.cacheLimiter.Remove(chunkSize)
+		atomic.AddInt64(&w.currentReservedCapacity, -chunkSize)
+		atomic.AddInt32(&w.activeChunkCount, -1)
+		w.chunkLogger.LogChunkStatus(id, EWaitReason.ChunkDone())
+		if w.err != nil {
+			return w.err
+		}
+		return ChunkWriterAlreadyFailed
+	case w.newUnorderedChunks <- fileChunk{id: id, isZeros: true}:
+		return nil
+	}
+}
+
 // Flush waits until all chunks have been flush to disk, then returns the MD5 has of the file's bytes-as-we-saved-them
 func (w *chunkedFileWriter) Flush(ctx context.Context) ([]byte, error) {
 	// let worker know that no more will be coming
@@ -246,7 +300,7 @@ func (w *chunkedFileWriter) MaxRetryPerDownloadBody() int {
 // resorting to the likes of SetFileValidData (https://docs.microsoft.com/en-us/windows/desktop/api/fileapi/nf-fileapi-setfilevaliddata)
 // and (b) we can compute MD5 hashes - which can only be computed when moving through the data sequentially
 func (w *chunkedFileWriter) workerRoutine(ctx context.Context) {
-	nextOffsetToSave := int64(0)
+	nextOffsetToSave := w.startOffset
 	unsavedChunksByFileOffset := make(map[int64]fileChunk)
 	md5Hasher := md5.New()
 	if w.md5ValidationOption == EHashValidationOption.NoCheck() || !w.sourceMd5Exists {
@@ -259,7 +313,9 @@ func (w *chunkedFileWriter) workerRoutine(ctx context.Context) {
 		for _, chunk := range unsavedChunksByFileOffset {
 			w.cacheLimiter.Remove(int64(chunk.id.length)) // remove this from the tally of scheduled-but-unsaved bytes
 			atomic.AddInt64(&w.currentReservedCapacity, -chunk.id.length)
-			w.slicePool.ReturnSlice(chunk.data)
+			if !chunk.isZeros {
+				w.slicePool.ReturnSlice(chunk.data)
+			}
 			atomic.AddInt32(&w.activeChunkCount, -1)
 			w.chunkLogger.LogChunkStatus(chunk.id, EWaitReason.ChunkDone()) // this chunk is all finished
 		}
@@ -267,6 +323,18 @@ func (w *chunkedFileWriter) workerRoutine(ctx context.Context) {
 		unsavedChunksByFileOffset = nil
 	}()
 
+	if _, isNull := md5Hasher.(*nullHasher); !isNull && w.startOffset > 0 {
+		// the hash must cover the whole file, so include what was saved before we resumed
+		if w.existingContent == nil {
+			w.err = errors.New("cannot compute hash of resumed file, because its existing content is not available")
+			return
+		}
+		if _, err := io.CopyN(md5Hasher, w.existingContent, w.startOffset); err != nil {
+			w.err = err
+			return
+		}
+	}
+
 	for {
 		var newChunk fileChunk
 		var channelIsOpen bool
@@ -316,13 +384,16 @@ func (w *chunkedFileWriter) sequentiallyProcessAvailableChunks(unsavedChunksByFi
 			return nil //its not there yet. That's OK.
 		}
 		delete(unsavedChunksByFileOffset, *nextOffsetToSave)      // remove it
-		*nextOffsetToSave += int64(len(nextChunkInSequence.data)) // update immediately so we won't forget!
+		*nextOffsetToSave += nextChunkInSequence.length() // update immediately so we won't forget!
 
 		// Save it (hashing exactly what we save)
 		err := w.saveOneChunk(nextChunkInSequence, md5Hasher)
 		if err != nil {
 			return err
 		}
+		if w.reportSavedOffset != nil {
+			w.reportSavedOffset(*nextOffsetToSave)
+		}
 	}
 }
 
@@ -341,7 +412,7 @@ func (w *chunkedFileWriter) setStatusForContiguousAvailableChunks(unsavedChunksB
 		if !exists {
 			return //its not there yet, so no need to touch anything AFTER it. THEY are still waiting for prior chunk
 		}
-		nextOffsetToSave += int64(len(nextChunkInSequence.data))
+		nextOffsetToSave += nextChunkInSequence.length()
 		w.chunkLogger.LogChunkStatus(nextChunkInSequence.id, EWaitReason.QueueToWrite()) // we WILL write this. Just may have to write others before it
 	}
 }
@@ -349,8 +420,10 @@ func (w *chunkedFileWriter) setStatusForContiguousAvailableChunks(unsavedChunksB
 // Saves one chunk to its destination
 func (w *chunkedFileWriter) saveOneChunk(chunk fileChunk, md5Hasher hash.Hash) error {
 	defer func() {
-		w.cacheLimiter.Remove(int64(len(chunk.data))) // remove this from the tally of scheduled-but-unsaved bytes
-		w.slicePool.ReturnSlice(chunk.data)
+		w.cacheLimiter.Remove(chunk.length()) // remove this from the tally of scheduled-but-unsaved bytes
+		if !chunk.isZeros {
+			w.slicePool.ReturnSlice(chunk.data)
+		}
 		atomic.AddInt32(&w.activeChunkCount, -1)
 		atomic.AddInt64(&w.currentReservedCapacity, -chunk.id.length)
 		w.chunkLogger.LogChunkStatus(chunk.id, EWaitReason.ChunkDone()) // this chunk is all finished
@@ -360,6 +433,11 @@ func (w *chunkedFileWriter) saveOneChunk(chunk fileChunk, md5Hasher hash.Hash) e
 
 	w.chunkLogger.LogChunkStatus(chunk.id, EWaitReason.DiskIO())
 
+	if chunk.isZeros {
+		hashZeros(md5Hasher, chunk.id.length)
+		return w.saveZeros(chunk.id.length)
+	}
+
 	// in some cases, e.g. Storage Spaces in Azure VMs, chopping up the writes helps perf. TODO: look into the reasons why it helps
 	for i := 0; i < len(chunk.data); i += maxWriteSize {
 		slice := chunk.data[i:]
@@ -378,6 +456,21 @@ func (w *chunkedFileWriter) saveOneChunk(chunk fileChunk, md5Hasher hash.Hash) e
 	return nil
 }
 
+// Saves a range of zeros. If we can, we just skip over it, leaving a hole in the file. We punch the hole
+// (rather than just skipping) because, if resuming, we may not be the first to write there.
+func (w *chunkedFileWriter) saveZeros(length int64) error {
+	if f, ok := w.file.(*os.File); ok && SparseLocalFilesSupported {
+		offset, err := f.Seek(0, io.SeekCurrent)
+		if err == nil && PunchHole(f, offset, length) == nil {
+			_, err = f.Seek(length, io.SeekCurrent)
+			return err
+		}
+		// else, just write them out in full
+	}
+
+	return WriteZeros(w.file, length)
+}
+
 // We use a less strict cache limit
 // if we have relatively few chunks in progress for THIS file. Why? To try to spread
 // the work in progress across a larger number of files, instead of having it
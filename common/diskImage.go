@@ -0,0 +1,277 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package common
+
+import (
+	"crypto/md5"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// Azure only accepts fixed VHDs as page blobs (and managed disks). Such a VHD is just the raw content of the disk, followed
+// by a 512 byte footer. The size of the disk must also be a whole number of MiB. So, rather than converting the image to
+// a VHD before uploading it (which needs as much free space as the disk itself), we present the image to the upload
+// pipeline as if it already was a fixed VHD.
+const (
+	vhdFooterSize    = 512
+	vhdSizeAlignment = 1024 * 1024
+)
+
+// vhdEpoch is the zero point for the timestamps in VHD footers
+var vhdEpoch = time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// diskImage is a virtual disk, stored in some image format. ReadAt reads the disk as the VM would see it
+type diskImage interface {
+	io.ReaderAt
+	io.Closer
+
+	// VirtualSize is the size of the disk as seen by the VM (which, for formats other than raw, is not the size of the file)
+	VirtualSize() int64
+}
+
+func openDiskImage(path string, format DiskImageFormat) (diskImage, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var image diskImage
+	switch format {
+	case EDiskImageFormat.Raw():
+		image, err = newRawDiskImage(f)
+	case EDiskImageFormat.QCOW2():
+		image, err = newQcow2DiskImage(f)
+	case EDiskImageFormat.VMDK():
+		image, err = newVmdkDiskImage(f)
+	default:
+		err = fmt.Errorf("unsupported disk image format %s", format)
+	}
+	if err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("cannot read %s as a %s disk image: %w", path, format, err)
+	}
+	return image, nil
+}
+
+// FixedVHDSize returns the size of the fixed VHD that holds a disk of the given size.
+// The disk is padded out to a whole number of MiB, and followed by the footer.
+func FixedVHDSize(virtualSize int64) int64 {
+	return alignedVHDDataSize(virtualSize) + vhdFooterSize
+}
+
+func alignedVHDDataSize(virtualSize int64) int64 {
+	return (virtualSize + vhdSizeAlignment - 1) / vhdSizeAlignment * vhdSizeAlignment
+}
+
+// DiskImageAsFixedVHDSize returns the size of the VHD that OpenDiskImageAsFixedVHD will present for the given image
+func DiskImageAsFixedVHDSize(path string, format DiskImageFormat) (int64, error) {
+	image, err := openDiskImage(path, format)
+	if err != nil {
+		return 0, err
+	}
+	defer image.Close()
+
+	return FixedVHDSize(image.VirtualSize()), nil
+}
+
+// OpenDiskImageAsFixedVHD opens the disk image at path, and returns a reader that reads it as a fixed VHD.
+// The footer is generated from the size and the last modified time of the image, so every reader opened on an unchanged
+// image returns exactly the same bytes. That matters because chunks may be re-read (e.g. on retry) through a new reader.
+func OpenDiskImageAsFixedVHD(path string, format DiskImageFormat) (CloseableReaderAt, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	image, err := openDiskImage(path, format)
+	if err != nil {
+		return nil, err
+	}
+
+	virtualSize := image.VirtualSize()
+	dataSize := alignedVHDDataSize(virtualSize)
+
+	// a name-based (MD5) UUID, so that it is stable for a given image
+	id := md5.Sum([]byte(fmt.Sprintf("%s|%d|%d", path, virtualSize, fi.ModTime().UnixNano())))
+	id[6] = (id[6] & 0x0f) | 0x30
+	id[8] = (id[8] & 0x3f) | 0x80
+
+	return &fixedVHDReader{
+		disk:        image,
+		virtualSize: virtualSize,
+		dataSize:    dataSize,
+		footer:      newFixedVHDFooter(dataSize, fi.ModTime(), id),
+	}, nil
+}
+
+// fixedVHDReader reads a disk image as a fixed VHD: the disk, then zeros up to the next MiB boundary, then the footer
+type fixedVHDReader struct {
+	disk        diskImage
+	virtualSize int64 // the size of the disk in the image
+	dataSize    int64 // virtualSize, rounded up to a whole number of MiB. This is the disk size recorded in the footer
+	footer      [vhdFooterSize]byte
+}
+
+func (r *fixedVHDReader) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, errors.New("negative offset")
+	}
+
+	totalSize := r.dataSize + vhdFooterSize
+	n := 0
+	for n < len(p) && off < totalSize {
+		var count int
+		switch {
+		case off < r.virtualSize:
+			count = len(p) - n
+			if remaining := r.virtualSize - off; int64(count) > remaining {
+				count = int(remaining)
+			}
+			read, err := r.disk.ReadAt(p[n:n+count], off)
+			if read < count {
+				if err == nil || err == io.EOF {
+					err = io.ErrUnexpectedEOF
+				}
+				return n + read, err
+			}
+		case off < r.dataSize:
+			count = len(p) - n
+			if remaining := r.dataSize - off; int64(count) > remaining {
+				count = int(remaining)
+			}
+			fillZeros(p[n : n+count])
+		default:
+			count = copy(p[n:], r.footer[off-r.dataSize:])
+		}
+		n += count
+		off += int64(count)
+	}
+
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func (r *fixedVHDReader) Close() error {
+	return r.disk.Close()
+}
+
+func fillZeros(p []byte) {
+	for len(p) > 0 {
+		p = p[copy(p, zeros):]
+	}
+}
+
+// newFixedVHDFooter returns the footer of a fixed VHD, as described in the Virtual Hard Disk Image Format Specification
+func newFixedVHDFooter(diskSize int64, timestamp time.Time, uniqueID [16]byte) [vhdFooterSize]byte {
+	var f [vhdFooterSize]byte
+	be := binary.BigEndian
+
+	copy(f[0:8], "conectix")
+	be.PutUint32(f[8:12], 2)                   // features: just the "reserved" bit, which must always be set
+	be.PutUint32(f[12:16], 0x00010000)         // file format version 1.0
+	be.PutUint64(f[16:24], 0xFFFFFFFFFFFFFFFF) // data offset. Fixed disks have no dynamic header
+	be.PutUint32(f[24:28], uint32(timestamp.Sub(vhdEpoch)/time.Second))
+	copy(f[28:32], "azcp")                   // creator application
+	be.PutUint32(f[32:36], 0x000A0000)       // creator version
+	copy(f[36:40], "Wi2k")                   // creator host OS
+	be.PutUint64(f[40:48], uint64(diskSize)) // original size
+	be.PutUint64(f[48:56], uint64(diskSize)) // current size
+	cylinders, heads, sectorsPerTrack := vhdGeometry(diskSize)
+	be.PutUint16(f[56:58], cylinders)
+	f[58] = heads
+	f[59] = sectorsPerTrack
+	be.PutUint32(f[60:64], 2) // disk type: fixed
+	copy(f[68:84], uniqueID[:])
+	// saved state (byte 84) and the reserved bytes after it are all zero
+
+	be.PutUint32(f[64:68], vhdChecksum(f[:]))
+	return f
+}
+
+// vhdChecksum is the one's complement of the sum of all the bytes in the footer, excluding the checksum field itself
+func vhdChecksum(footer []byte) uint32 {
+	var sum uint32
+	for i, b := range footer {
+		if i >= 64 && i < 68 {
+			continue
+		}
+		sum += uint32(b)
+	}
+	return ^sum
+}
+
+// vhdGeometry computes the CHS geometry of a disk, using the algorithm from appendix A of the VHD specification
+func vhdGeometry(diskSize int64) (cylinders uint16, heads uint8, sectorsPerTrack uint8) {
+	totalSectors := diskSize / 512
+	if totalSectors > 65535*16*255 {
+		totalSectors = 65535 * 16 * 255
+	}
+
+	var h, spt, cylinderTimesHeads int64
+	if totalSectors >= 65535*16*63 {
+		spt = 255
+		h = 16
+		cylinderTimesHeads = totalSectors / spt
+	} else {
+		spt = 17
+		cylinderTimesHeads = totalSectors / spt
+		h = (cylinderTimesHeads + 1023) / 1024
+		if h < 4 {
+			h = 4
+		}
+		if cylinderTimesHeads >= h*1024 || h > 16 {
+			spt = 31
+			h = 16
+			cylinderTimesHeads = totalSectors / spt
+		}
+		if cylinderTimesHeads >= h*1024 {
+			spt = 63
+			h = 16
+			cylinderTimesHeads = totalSectors / spt
+		}
+	}
+
+	return uint16(cylinderTimesHeads / h), uint8(h), uint8(spt)
+}
+
+// rawDiskImage is an image that is just the content of the disk, byte for byte
+type rawDiskImage struct {
+	*os.File
+	size int64
+}
+
+func newRawDiskImage(f *os.File) (diskImage, error) {
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return &rawDiskImage{File: f, size: fi.Size()}, nil
+}
+
+func (r *rawDiskImage) VirtualSize() int64 {
+	return r.size
+}
@@ -0,0 +1,259 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package common
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// Reads QCOW2 images, as described in docs/interop/qcow2.txt in the QEMU source.
+// Only standalone images are supported. I.e. no backing files, encryption or external data files.
+const (
+	qcow2Magic = 0x514649fb // "QFI\xfb"
+
+	qcow2IncompatibleDirty            = 1 << 0
+	qcow2IncompatibleCorrupt          = 1 << 1
+	qcow2IncompatibleExternalDataFile = 1 << 2
+	qcow2IncompatibleCompressionType  = 1 << 3
+	qcow2IncompatibleExtendedL2       = 1 << 4
+
+	qcow2OffsetMask     = 0x00fffffffffffe00 // bits 9-55 of L1 and (uncompressed) L2 entries
+	qcow2CompressedFlag = 1 << 62
+	qcow2ZeroFlag       = 1 << 0
+
+	// Each L2 table is one cluster in size, and we only need a handful of them at a time since we read sequentially
+	qcow2MaxCachedL2Tables = 64
+)
+
+type qcow2DiskImage struct {
+	f           *os.File
+	virtualSize int64
+	clusterBits uint32
+	clusterSize int64
+	l2Entries   int64 // number of entries in each L2 table
+	l1Table     []uint64
+
+	mu               sync.Mutex
+	l2Cache          map[uint64][]uint64
+	lastCompressed   uint64 // the L2 entry of the compressed cluster in lastDecompressed
+	lastDecompressed []byte
+}
+
+func newQcow2DiskImage(f *os.File) (diskImage, error) {
+	header := make([]byte, 105)
+	n, err := f.ReadAt(header, 0)
+	if n < 72 {
+		if err == nil || err == io.EOF {
+			err = errors.New("file is too small to be a qcow2 image")
+		}
+		return nil, err
+	}
+
+	be := binary.BigEndian
+	if be.Uint32(header[0:4]) != qcow2Magic {
+		return nil, errors.New("qcow2 magic number not found")
+	}
+	version := be.Uint32(header[4:8])
+	if version != 2 && version != 3 {
+		return nil, fmt.Errorf("qcow2 version %d is not supported", version)
+	}
+	if be.Uint64(header[8:16]) != 0 {
+		return nil, errors.New("qcow2 images with backing files are not supported. Please flatten the image first")
+	}
+	clusterBits := be.Uint32(header[20:24])
+	if clusterBits < 9 || clusterBits > 21 {
+		return nil, fmt.Errorf("invalid qcow2 cluster size (2^%d bytes)", clusterBits)
+	}
+	if be.Uint32(header[32:36]) != 0 {
+		return nil, errors.New("encrypted qcow2 images are not supported")
+	}
+
+	if version == 3 {
+		if n < 104 {
+			return nil, errors.New("qcow2 header is truncated")
+		}
+		incompatible := be.Uint64(header[72:80])
+		switch {
+		case incompatible&qcow2IncompatibleCorrupt != 0:
+			return nil, errors.New("qcow2 image is marked as corrupt")
+		case incompatible&qcow2IncompatibleExternalDataFile != 0:
+			return nil, errors.New("qcow2 images with external data files are not supported")
+		case incompatible&qcow2IncompatibleExtendedL2 != 0:
+			return nil, errors.New("qcow2 images with extended L2 entries are not supported")
+		case incompatible&^(qcow2IncompatibleDirty|qcow2IncompatibleCompressionType) != 0:
+			return nil, fmt.Errorf("qcow2 image uses unknown incompatible features %#x", incompatible)
+		}
+		headerLength := be.Uint32(header[100:104])
+		if incompatible&qcow2IncompatibleCompressionType != 0 && (headerLength <= 104 || n < 105 || header[104] != 0) {
+			return nil, errors.New("qcow2 images with compression types other than zlib are not supported")
+		}
+	}
+
+	l1Size := int64(be.Uint32(header[36:40]))
+	l1Bytes := make([]byte, l1Size*8)
+	if _, err := f.ReadAt(l1Bytes, int64(be.Uint64(header[40:48]))); err != nil {
+		return nil, fmt.Errorf("reading qcow2 L1 table: %w", err)
+	}
+	l1Table := make([]uint64, l1Size)
+	for i := range l1Table {
+		l1Table[i] = be.Uint64(l1Bytes[i*8:])
+	}
+
+	clusterSize := int64(1) << clusterBits
+	return &qcow2DiskImage{
+		f:           f,
+		virtualSize: int64(be.Uint64(header[24:32])),
+		clusterBits: clusterBits,
+		clusterSize: clusterSize,
+		l2Entries:   clusterSize / 8,
+		l1Table:     l1Table,
+		l2Cache:     make(map[uint64][]uint64),
+	}, nil
+}
+
+func (q *qcow2DiskImage) VirtualSize() int64 {
+	return q.virtualSize
+}
+
+func (q *qcow2DiskImage) Close() error {
+	return q.f.Close()
+}
+
+func (q *qcow2DiskImage) ReadAt(p []byte, off int64) (int, error) {
+	n := 0
+	for n < len(p) {
+		if off >= q.virtualSize {
+			return n, io.EOF
+		}
+
+		offsetInCluster := off & (q.clusterSize - 1)
+		count := q.clusterSize - offsetInCluster
+		if remaining := int64(len(p) - n); count > remaining {
+			count = remaining
+		}
+		if remaining := q.virtualSize - off; count > remaining {
+			count = remaining
+		}
+
+		if err := q.readFromCluster(p[n:n+int(count)], off>>q.clusterBits, offsetInCluster); err != nil {
+			return n, err
+		}
+		n += int(count)
+		off += count
+	}
+	return n, nil
+}
+
+// readFromCluster fills p with data from the given guest cluster, starting at offsetInCluster
+func (q *qcow2DiskImage) readFromCluster(p []byte, cluster int64, offsetInCluster int64) error {
+	entry, err := q.l2Entry(cluster)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case entry&qcow2CompressedFlag != 0:
+		data, err := q.decompressCluster(entry)
+		if err != nil {
+			return err
+		}
+		copy(p, data[offsetInCluster:])
+	case entry&qcow2ZeroFlag != 0 || entry&qcow2OffsetMask == 0:
+		// zero cluster, or one that has never been allocated (which, without a backing file, also reads as zeros)
+		fillZeros(p)
+	default:
+		if _, err := q.f.ReadAt(p, int64(entry&qcow2OffsetMask)+offsetInCluster); err != nil {
+			return fmt.Errorf("reading qcow2 cluster: %w", err)
+		}
+	}
+	return nil
+}
+
+// l2Entry returns the L2 table entry that describes the given guest cluster, or zero if no L2 table has been allocated for it
+func (q *qcow2DiskImage) l2Entry(cluster int64) (uint64, error) {
+	l1Index := cluster / q.l2Entries
+	if l1Index >= int64(len(q.l1Table)) {
+		return 0, fmt.Errorf("qcow2 cluster %d is beyond the end of the L1 table", cluster)
+	}
+	l2Offset := q.l1Table[l1Index] & qcow2OffsetMask
+	if l2Offset == 0 {
+		return 0, nil
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	table, ok := q.l2Cache[l2Offset]
+	if !ok {
+		raw := make([]byte, q.clusterSize)
+		if _, err := q.f.ReadAt(raw, int64(l2Offset)); err != nil {
+			return 0, fmt.Errorf("reading qcow2 L2 table: %w", err)
+		}
+		table = make([]uint64, q.l2Entries)
+		for i := range table {
+			table[i] = binary.BigEndian.Uint64(raw[i*8:])
+		}
+		if len(q.l2Cache) >= qcow2MaxCachedL2Tables {
+			q.l2Cache = make(map[uint64][]uint64)
+		}
+		q.l2Cache[l2Offset] = table
+	}
+	return table[cluster%q.l2Entries], nil
+}
+
+// decompressCluster returns the content of the compressed cluster described by the given L2 entry
+func (q *qcow2DiskImage) decompressCluster(entry uint64) ([]byte, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.lastDecompressed != nil && q.lastCompressed == entry {
+		return q.lastDecompressed, nil
+	}
+
+	// The host offset is in the low x bits, and the number of additional 512-byte sectors is in the bits above it
+	x := 62 - (q.clusterBits - 8)
+	hostOffset := int64(entry & (1<<x - 1))
+	sectors := int64((entry&(qcow2CompressedFlag-1))>>x) + 1
+	compressedSize := sectors*512 - (hostOffset & 511)
+
+	compressed := make([]byte, compressedSize)
+	n, err := q.f.ReadAt(compressed, hostOffset)
+	if err != nil && err != io.EOF { // the last compressed cluster may be shorter than its sector count suggests
+		return nil, fmt.Errorf("reading qcow2 compressed cluster: %w", err)
+	}
+
+	data := make([]byte, q.clusterSize)
+	_, err = io.ReadFull(flate.NewReader(bytes.NewReader(compressed[:n])), data)
+	if err != nil {
+		return nil, fmt.Errorf("decompressing qcow2 cluster: %w", err)
+	}
+
+	q.lastCompressed = entry
+	q.lastDecompressed = data
+	return data, nil
+}
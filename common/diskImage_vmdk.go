@@ -0,0 +1,302 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package common
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// Reads VMDK images that consist of a single hosted sparse extent, as described in VMware's Virtual Disk Format 5.0
+// specification. That covers the monolithicSparse and streamOptimized types, which are the usual ones for images
+// that are being moved between machines. Flat and split extents, which have a separate text descriptor, are not supported.
+const (
+	vmdkMagic      = 0x564d444b // "KDMV"
+	vmdkSectorSize = 512
+	vmdkGDAtEnd    = 0xffffffffffffffff
+
+	vmdkFlagZeroedGTE      = 1 << 2
+	vmdkFlagCompressed     = 1 << 16
+	vmdkCompressionDeflate = 1
+
+	vmdkGrainMarkerSize = 12 // the LBA (uint64) and compressed size (uint32) that precede each compressed grain
+
+	vmdkMaxCachedGrainTables = 256
+)
+
+type vmdkDiskImage struct {
+	f          *os.File
+	capacity   int64 // in bytes
+	grainSize  int64 // in bytes
+	gtEntries  int64 // number of entries in each grain table
+	compressed bool
+	zeroedGTE  bool
+	grainDir   []uint32
+
+	mu               sync.Mutex
+	gtCache          map[uint32][]uint32
+	lastGrain        uint32 // the sector offset of the compressed grain in lastDecompressed
+	lastDecompressed []byte
+}
+
+// vmdkHeader is the on-disk layout of a sparse extent header
+type vmdkHeader struct {
+	MagicNumber        uint32
+	Version            uint32
+	Flags              uint32
+	Capacity           uint64
+	GrainSize          uint64
+	DescriptorOffset   uint64
+	DescriptorSize     uint64
+	NumGTEsPerGT       uint32
+	RgdOffset          uint64
+	GdOffset           uint64
+	OverHead           uint64
+	UncleanShutdown    uint8
+	SingleEndLineChar  byte
+	NonEndLineChar     byte
+	DoubleEndLineChar1 byte
+	DoubleEndLineChar2 byte
+	CompressAlgorithm  uint16
+}
+
+func readVmdkHeader(f *os.File, offset int64) (vmdkHeader, error) {
+	h := vmdkHeader{}
+	err := binary.Read(io.NewSectionReader(f, offset, vmdkSectorSize), binary.LittleEndian, &h)
+	return h, err
+}
+
+func newVmdkDiskImage(f *os.File) (diskImage, error) {
+	h, err := readVmdkHeader(f, 0)
+	if err != nil {
+		return nil, err
+	}
+	if h.MagicNumber != vmdkMagic {
+		start := make([]byte, 21)
+		_, _ = f.ReadAt(start, 0)
+		if bytes.HasPrefix(start, []byte("# Disk DescriptorFile")) {
+			return nil, errors.New("VMDK descriptor files are not supported. Please convert the image to a monolithicSparse or streamOptimized VMDK")
+		}
+		return nil, errors.New("VMDK sparse extent magic number not found")
+	}
+
+	if h.GdOffset == vmdkGDAtEnd {
+		// streamOptimized images are written in one pass, so the location of the grain directory is only known at the end.
+		// It's in the footer, which is a copy of the header that sits just before the end-of-stream marker
+		fi, err := f.Stat()
+		if err != nil {
+			return nil, err
+		}
+		h, err = readVmdkHeader(f, fi.Size()-2*vmdkSectorSize)
+		if err != nil {
+			return nil, fmt.Errorf("reading VMDK footer: %w", err)
+		}
+		if h.MagicNumber != vmdkMagic || h.GdOffset == vmdkGDAtEnd {
+			return nil, errors.New("VMDK footer not found")
+		}
+	}
+
+	if h.Version < 1 || h.Version > 3 {
+		return nil, fmt.Errorf("VMDK version %d is not supported", h.Version)
+	}
+	if h.GrainSize == 0 || h.GrainSize&(h.GrainSize-1) != 0 || h.GrainSize > 1<<16 {
+		return nil, fmt.Errorf("invalid VMDK grain size (%d sectors)", h.GrainSize)
+	}
+	if h.NumGTEsPerGT == 0 {
+		return nil, errors.New("invalid VMDK grain table size")
+	}
+	compressed := h.Flags&vmdkFlagCompressed != 0
+	if compressed && h.CompressAlgorithm != vmdkCompressionDeflate {
+		return nil, fmt.Errorf("VMDK compression algorithm %d is not supported", h.CompressAlgorithm)
+	}
+	if err := checkVmdkHasNoParent(f, h); err != nil {
+		return nil, err
+	}
+
+	sectorsInGTs := int64(h.GrainSize) * int64(h.NumGTEsPerGT)
+	gdEntries := (int64(h.Capacity) + sectorsInGTs - 1) / sectorsInGTs
+	gdBytes := make([]byte, gdEntries*4)
+	if _, err := f.ReadAt(gdBytes, int64(h.GdOffset)*vmdkSectorSize); err != nil {
+		return nil, fmt.Errorf("reading VMDK grain directory: %w", err)
+	}
+	grainDir := make([]uint32, gdEntries)
+	for i := range grainDir {
+		grainDir[i] = binary.LittleEndian.Uint32(gdBytes[i*4:])
+	}
+
+	return &vmdkDiskImage{
+		f:          f,
+		capacity:   int64(h.Capacity) * vmdkSectorSize,
+		grainSize:  int64(h.GrainSize) * vmdkSectorSize,
+		gtEntries:  int64(h.NumGTEsPerGT),
+		compressed: compressed,
+		zeroedGTE:  h.Flags&vmdkFlagZeroedGTE != 0,
+		grainDir:   grainDir,
+		gtCache:    make(map[uint32][]uint32),
+	}, nil
+}
+
+// checkVmdkHasNoParent rejects delta disks (e.g. snapshots), since their content only makes sense on top of their parent
+func checkVmdkHasNoParent(f *os.File, h vmdkHeader) error {
+	if h.DescriptorOffset == 0 || h.DescriptorSize == 0 || h.DescriptorSize > 128 {
+		return nil
+	}
+	descriptor := make([]byte, h.DescriptorSize*vmdkSectorSize)
+	if _, err := f.ReadAt(descriptor, int64(h.DescriptorOffset)*vmdkSectorSize); err != nil && err != io.EOF {
+		return fmt.Errorf("reading VMDK descriptor: %w", err)
+	}
+	if bytes.Contains(descriptor, []byte("parentFileNameHint")) {
+		return errors.New("VMDK delta disks are not supported. Please consolidate the disk with its parent first")
+	}
+	return nil
+}
+
+func (v *vmdkDiskImage) VirtualSize() int64 {
+	return v.capacity
+}
+
+func (v *vmdkDiskImage) Close() error {
+	return v.f.Close()
+}
+
+func (v *vmdkDiskImage) ReadAt(p []byte, off int64) (int, error) {
+	n := 0
+	for n < len(p) {
+		if off >= v.capacity {
+			return n, io.EOF
+		}
+
+		offsetInGrain := off % v.grainSize
+		count := v.grainSize - offsetInGrain
+		if remaining := int64(len(p) - n); count > remaining {
+			count = remaining
+		}
+		if remaining := v.capacity - off; count > remaining {
+			count = remaining
+		}
+
+		if err := v.readFromGrain(p[n:n+int(count)], off/v.grainSize, offsetInGrain); err != nil {
+			return n, err
+		}
+		n += int(count)
+		off += count
+	}
+	return n, nil
+}
+
+// readFromGrain fills p with data from the given grain, starting at offsetInGrain
+func (v *vmdkDiskImage) readFromGrain(p []byte, grain int64, offsetInGrain int64) error {
+	entry, err := v.grainTableEntry(grain)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case entry == 0 || (entry == 1 && v.zeroedGTE):
+		// never written, or explicitly zeroed. Either way, without a parent disk, it reads as zeros
+		fillZeros(p)
+	case v.compressed:
+		data, err := v.decompressGrain(entry)
+		if err != nil {
+			return err
+		}
+		copy(p, data[offsetInGrain:])
+	default:
+		if _, err := v.f.ReadAt(p, int64(entry)*vmdkSectorSize+offsetInGrain); err != nil {
+			return fmt.Errorf("reading VMDK grain: %w", err)
+		}
+	}
+	return nil
+}
+
+// grainTableEntry returns the sector offset of the given grain, or zero if it has not been allocated
+func (v *vmdkDiskImage) grainTableEntry(grain int64) (uint32, error) {
+	gdIndex := grain / v.gtEntries
+	if gdIndex >= int64(len(v.grainDir)) {
+		return 0, fmt.Errorf("VMDK grain %d is beyond the end of the grain directory", grain)
+	}
+	gtSector := v.grainDir[gdIndex]
+	if gtSector == 0 {
+		return 0, nil
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	table, ok := v.gtCache[gtSector]
+	if !ok {
+		raw := make([]byte, v.gtEntries*4)
+		if _, err := v.f.ReadAt(raw, int64(gtSector)*vmdkSectorSize); err != nil {
+			return 0, fmt.Errorf("reading VMDK grain table: %w", err)
+		}
+		table = make([]uint32, v.gtEntries)
+		for i := range table {
+			table[i] = binary.LittleEndian.Uint32(raw[i*4:])
+		}
+		if len(v.gtCache) >= vmdkMaxCachedGrainTables {
+			v.gtCache = make(map[uint32][]uint32)
+		}
+		v.gtCache[gtSector] = table
+	}
+	return table[grain%v.gtEntries], nil
+}
+
+// decompressGrain returns the content of the compressed grain at the given sector
+func (v *vmdkDiskImage) decompressGrain(sector uint32) ([]byte, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.lastDecompressed != nil && v.lastGrain == sector {
+		return v.lastDecompressed, nil
+	}
+
+	marker := make([]byte, vmdkGrainMarkerSize)
+	offset := int64(sector) * vmdkSectorSize
+	if _, err := v.f.ReadAt(marker, offset); err != nil {
+		return nil, fmt.Errorf("reading VMDK grain marker: %w", err)
+	}
+	compressedSize := int64(binary.LittleEndian.Uint32(marker[8:12]))
+	if compressedSize == 0 || compressedSize > 2*v.grainSize {
+		return nil, fmt.Errorf("invalid VMDK compressed grain size %d", compressedSize)
+	}
+
+	zr, err := zlib.NewReader(io.NewSectionReader(v.f, offset+vmdkGrainMarkerSize, compressedSize))
+	if err != nil {
+		return nil, fmt.Errorf("decompressing VMDK grain: %w", err)
+	}
+	defer zr.Close()
+
+	// the last grain of the disk may be short, in which case the rest of it reads as zeros
+	data := make([]byte, v.grainSize)
+	if _, err := io.ReadFull(zr, data); err != nil && err != io.ErrUnexpectedEOF {
+		return nil, fmt.Errorf("decompressing VMDK grain: %w", err)
+	}
+
+	v.lastGrain = sector
+	v.lastDecompressed = data
+	return data, nil
+}
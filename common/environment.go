@@ -37,6 +37,10 @@ type EnvironmentVariable struct {
 // 2. They are authentication secrets, which we do not accept on the command line
 var VisibleEnvironmentVariables = []EnvironmentVariable{
 	EEnvironmentVariable.LogLocation(),
+	EEnvironmentVariable.LogFormat(),
+	EEnvironmentVariable.LogMaxSizeMB(),
+	EEnvironmentVariable.LogMaxTotalSizeMB(),
+	EEnvironmentVariable.JobRetentionDays(),
 	EEnvironmentVariable.JobPlanLocation(),
 	EEnvironmentVariable.ConcurrencyValue(),
 	EEnvironmentVariable.TransferInitiationPoolSize(),
@@ -70,6 +74,12 @@ var VisibleEnvironmentVariables = []EnvironmentVariable{
 	EEnvironmentVariable.CPKEncryptionKeySHA256(),
 	EEnvironmentVariable.DisableSyslog(),
 	EEnvironmentVariable.MimeMapping(),
+	EEnvironmentVariable.DaemonEndpoint(),
+	EEnvironmentVariable.DaemonToken(),
+	EEnvironmentVariable.OtlpEndpoint(),
+	EEnvironmentVariable.OtlpTracesEndpoint(),
+	EEnvironmentVariable.OtlpHeaders(),
+	EEnvironmentVariable.OtelServiceName(),
 }
 
 var EEnvironmentVariable = EnvironmentVariable{}
@@ -224,6 +234,35 @@ func (EnvironmentVariable) LogLocation() EnvironmentVariable {
 	}
 }
 
+func (EnvironmentVariable) LogFormat() EnvironmentVariable {
+	return EnvironmentVariable{
+		Name:         "AZCOPY_LOG_FORMAT",
+		DefaultValue: "text",
+		Description:  "Format of the log files. Can be 'text' (default), or 'json' to write one JSON object per line, with fields such as the job ID, the transfer, and the HTTP status and request ID.",
+	}
+}
+
+func (EnvironmentVariable) LogMaxSizeMB() EnvironmentVariable {
+	return EnvironmentVariable{
+		Name:        "AZCOPY_LOG_MAX_SIZE_MB",
+		Description: "Size, in megabytes, at which a log file is rotated. The full segments are compressed with gzip, and named after the log, with .<n>.gz appended. By default, logs aren't rotated.",
+	}
+}
+
+func (EnvironmentVariable) LogMaxTotalSizeMB() EnvironmentVariable {
+	return EnvironmentVariable{
+		Name:        "AZCOPY_LOG_MAX_TOTAL_SIZE_MB",
+		Description: "Maximum size, in megabytes, of a rotated log and its compressed segments together. The oldest segments are deleted to stay under it. By default, there's no maximum.",
+	}
+}
+
+func (EnvironmentVariable) JobRetentionDays() EnvironmentVariable {
+	return EnvironmentVariable{
+		Name:        "AZCOPY_JOB_RETENTION_DAYS",
+		Description: "When AzCopy starts, it removes the log and plan files of the jobs that haven't changed for this many days, as 'azcopy jobs clean --older-than-days' does. By default, they're kept until they are removed with 'azcopy jobs clean' or 'azcopy jobs rm'.",
+	}
+}
+
 func (EnvironmentVariable) JobPlanLocation() EnvironmentVariable {
 	return EnvironmentVariable{
 		Name:        "AZCOPY_JOB_PLAN_LOCATION",
@@ -369,3 +408,47 @@ func (EnvironmentVariable) DownloadToTempPath() EnvironmentVariable {
 		Description:  "Configures azcopy to download to a temp path before actual download. Allowed values are true/false",
 	}
 }
+
+func (EnvironmentVariable) DaemonEndpoint() EnvironmentVariable {
+	return EnvironmentVariable{
+		Name:        "AZCOPY_DAEMON_ENDPOINT",
+		Description: "Send jobs to a running 'azcopy daemon' instead of executing them in process. Use http://127.0.0.1:<port> or unix:///path/to/socket.",
+	}
+}
+
+func (EnvironmentVariable) DaemonToken() EnvironmentVariable {
+	return EnvironmentVariable{
+		Name:        "AZCOPY_DAEMON_TOKEN",
+		Description: "The bearer token used to authenticate to 'azcopy daemon'. If not set, the token is read from the connection file written by a daemon listening on AZCOPY_DAEMON_ENDPOINT.",
+		Hidden:      true,
+	}
+}
+
+func (EnvironmentVariable) OtlpEndpoint() EnvironmentVariable {
+	return EnvironmentVariable{
+		Name:        "OTEL_EXPORTER_OTLP_ENDPOINT",
+		Description: "Exports OpenTelemetry traces of jobs, transfers and HTTP requests to the OTLP/HTTP collector at this URL, e.g. http://localhost:4318. Traces are sent to its /v1/traces path, in the JSON encoding. Each job's trace ID is its job ID.",
+	}
+}
+
+func (EnvironmentVariable) OtlpTracesEndpoint() EnvironmentVariable {
+	return EnvironmentVariable{
+		Name:        "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT",
+		Description: "The full URL to which OpenTelemetry traces are exported, e.g. http://localhost:4318/v1/traces. Overrides OTEL_EXPORTER_OTLP_ENDPOINT.",
+	}
+}
+
+func (EnvironmentVariable) OtlpHeaders() EnvironmentVariable {
+	return EnvironmentVariable{
+		Name:        "OTEL_EXPORTER_OTLP_HEADERS",
+		Description: "Headers sent with the exported traces, as comma-separated key=value pairs with URL-encoded values, e.g. for the collector's authentication.",
+	}
+}
+
+func (EnvironmentVariable) OtelServiceName() EnvironmentVariable {
+	return EnvironmentVariable{
+		Name:         "OTEL_SERVICE_NAME",
+		DefaultValue: "azcopy",
+		Description:  "The service name of the exported traces.",
+	}
+}
@@ -182,6 +182,119 @@ func (p PermanentDeleteOption) ToPermanentDeleteOptionType() azblob.BlobDeleteTy
 	return azblob.BlobDeletePermanent
 }
 
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+var ESetPropertiesFlags = SetPropertiesFlags(0)
+
+// SetPropertiesFlags says which of a blob's properties a set-properties job sets. They are bit flags, so that any combination may be set at once.
+type SetPropertiesFlags uint32
+
+func (SetPropertiesFlags) None() SetPropertiesFlags           { return SetPropertiesFlags(0) }
+func (SetPropertiesFlags) SetTier() SetPropertiesFlags        { return SetPropertiesFlags(1) }
+func (SetPropertiesFlags) SetMetadata() SetPropertiesFlags    { return SetPropertiesFlags(2) }
+func (SetPropertiesFlags) SetBlobTags() SetPropertiesFlags    { return SetPropertiesFlags(4) }
+func (SetPropertiesFlags) SetHTTPHeaders() SetPropertiesFlags { return SetPropertiesFlags(8) }
+
+// Restore jobs are set-properties jobs too, which undelete each blob, or make the given version of it the current one
+func (SetPropertiesFlags) Undelete() SetPropertiesFlags       { return SetPropertiesFlags(16) }
+func (SetPropertiesFlags) PromoteVersion() SetPropertiesFlags { return SetPropertiesFlags(32) }
+
+func (f SetPropertiesFlags) ShouldSetTier() bool {
+	return f&ESetPropertiesFlags.SetTier() != 0
+}
+
+func (f SetPropertiesFlags) ShouldSetMetadata() bool {
+	return f&ESetPropertiesFlags.SetMetadata() != 0
+}
+
+func (f SetPropertiesFlags) ShouldSetBlobTags() bool {
+	return f&ESetPropertiesFlags.SetBlobTags() != 0
+}
+
+func (f SetPropertiesFlags) ShouldSetHTTPHeaders() bool {
+	return f&ESetPropertiesFlags.SetHTTPHeaders() != 0
+}
+
+func (f SetPropertiesFlags) ShouldUndelete() bool {
+	return f&ESetPropertiesFlags.Undelete() != 0
+}
+
+func (f SetPropertiesFlags) ShouldPromoteVersion() bool {
+	return f&ESetPropertiesFlags.PromoteVersion() != 0
+}
+
+// IsRestore tells whether the job restores the blobs, rather than setting their properties
+func (f SetPropertiesFlags) IsRestore() bool {
+	return f.ShouldUndelete() || f.ShouldPromoteVersion()
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+var ERehydratePriorityType = RehydratePriorityType(0) // Default to "None"
+
+// RehydratePriorityType is how soon a blob is rehydrated, when its tier is changed from archive
+type RehydratePriorityType uint8
+
+func (RehydratePriorityType) None() RehydratePriorityType     { return RehydratePriorityType(0) }
+func (RehydratePriorityType) Standard() RehydratePriorityType { return RehydratePriorityType(1) }
+func (RehydratePriorityType) High() RehydratePriorityType     { return RehydratePriorityType(2) }
+
+func (rpt RehydratePriorityType) String() string {
+	return enum.StringInt(rpt, reflect.TypeOf(rpt))
+}
+
+func (rpt *RehydratePriorityType) Parse(s string) error {
+	// allow empty to mean "None"
+	if s == "" {
+		*rpt = ERehydratePriorityType.None()
+		return nil
+	}
+
+	val, err := enum.ParseInt(reflect.TypeOf(rpt), s, true, true)
+	if err == nil {
+		*rpt = val.(RehydratePriorityType)
+	}
+	return err
+}
+
+func (rpt RehydratePriorityType) ToRehydratePriorityType() azblob.RehydratePriorityType {
+	switch rpt {
+	case ERehydratePriorityType.Standard():
+		return azblob.RehydratePriorityStandard
+	case ERehydratePriorityType.High():
+		return azblob.RehydratePriorityHigh
+	default:
+		return azblob.RehydratePriorityNone
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+var EDiskImageFormat = DiskImageFormat(0) // Default to "None"
+
+// DiskImageFormat is the format of a local disk image that is converted to a fixed VHD as it is uploaded
+type DiskImageFormat uint8
+
+func (DiskImageFormat) None() DiskImageFormat  { return DiskImageFormat(0) }
+func (DiskImageFormat) Raw() DiskImageFormat   { return DiskImageFormat(1) }
+func (DiskImageFormat) QCOW2() DiskImageFormat { return DiskImageFormat(2) }
+func (DiskImageFormat) VMDK() DiskImageFormat  { return DiskImageFormat(3) }
+
+func (d DiskImageFormat) String() string {
+	return enum.StringInt(d, reflect.TypeOf(d))
+}
+
+func (d *DiskImageFormat) Parse(s string) error {
+	// allow empty to mean "None"
+	if s == "" {
+		*d = EDiskImageFormat.None()
+		return nil
+	}
+
+	val, err := enum.ParseInt(reflect.TypeOf(d), s, true, true)
+	if err == nil {
+		*d = val.(DiskImageFormat)
+	}
+	return err
+}
+
 ////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 
 type DeleteDestination uint32
@@ -272,6 +385,7 @@ var EOutputFormat = OutputFormat(0)
 func (OutputFormat) None() OutputFormat { return OutputFormat(0) }
 func (OutputFormat) Text() OutputFormat { return OutputFormat(1) }
 func (OutputFormat) Json() OutputFormat { return OutputFormat(2) }
+func (OutputFormat) Csv() OutputFormat  { return OutputFormat(3) } // only the results of list and du are printed, as rows
 
 func (of *OutputFormat) Parse(s string) error {
 	val, err := enum.Parse(reflect.TypeOf(of), s, true)
@@ -350,6 +464,30 @@ func (ll LogLevel) ToPipelineLogLevel() pipeline.LogLevel {
 
 ////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 
+// LogFormat is the format of the lines of the job and scanning logs
+type LogFormat uint8
+
+var ELogFormat = LogFormat(0)
+
+func (LogFormat) Text() LogFormat { return LogFormat(0) }
+
+// Json writes each line as a JSON object (i.e. as JSON Lines), for log pipelines that can't parse the free-form text
+func (LogFormat) Json() LogFormat { return LogFormat(1) }
+
+func (lf *LogFormat) Parse(s string) error {
+	val, err := enum.ParseInt(reflect.TypeOf(lf), s, true, true)
+	if err == nil {
+		*lf = val.(LogFormat)
+	}
+	return err
+}
+
+func (lf LogFormat) String() string {
+	return enum.StringInt(lf, reflect.TypeOf(lf))
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
 var EJobPriority = JobPriority(0)
 
 // JobPriority defines the transfer priorities supported by the Storage Transfer Engine's channels
@@ -358,8 +496,17 @@ type JobPriority uint8
 
 func (JobPriority) Normal() JobPriority { return JobPriority(0) }
 func (JobPriority) Low() JobPriority    { return JobPriority(1) }
+func (JobPriority) High() JobPriority   { return JobPriority(2) }
 func (jp JobPriority) String() string {
-	return enum.StringInt(uint8(jp), reflect.TypeOf(jp))
+	return enum.StringInt(jp, reflect.TypeOf(jp))
+}
+
+func (jp *JobPriority) Parse(s string) error {
+	val, err := enum.ParseInt(reflect.TypeOf(jp), s, true, true)
+	if err == nil {
+		*jp = val.(JobPriority)
+	}
+	return err
 }
 
 ////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -451,6 +598,7 @@ func (Location) BlobFS() Location    { return Location(5) }
 func (Location) S3() Location        { return Location(6) }
 func (Location) Benchmark() Location { return Location(7) }
 func (Location) GCP() Location       { return Location(8) }
+func (Location) None() Location      { return Location(9) } // the "destination" of jobs that act on their source in place, such as set-properties
 
 func (l Location) String() string {
 	return enum.StringInt(l, reflect.TypeOf(l))
@@ -479,7 +627,7 @@ func (l Location) IsRemote() bool {
 	switch l {
 	case ELocation.BlobFS(), ELocation.Blob(), ELocation.File(), ELocation.S3(), ELocation.GCP():
 		return true
-	case ELocation.Local(), ELocation.Benchmark(), ELocation.Pipe(), ELocation.Unknown():
+	case ELocation.Local(), ELocation.Benchmark(), ELocation.Pipe(), ELocation.Unknown(), ELocation.None():
 		return false
 	default:
 		panic("unexpected location, please specify if it is remote")
@@ -487,7 +635,7 @@ func (l Location) IsRemote() bool {
 }
 
 func (l Location) IsLocal() bool {
-	if l == ELocation.Unknown() {
+	if l == ELocation.Unknown() || l == ELocation.None() {
 		return false
 	} else {
 		return !l.IsRemote()
@@ -500,7 +648,7 @@ func (l Location) IsFolderAware() bool {
 	switch l {
 	case ELocation.BlobFS(), ELocation.File(), ELocation.Local():
 		return true
-	case ELocation.Blob(), ELocation.S3(), ELocation.GCP(), ELocation.Benchmark(), ELocation.Pipe(), ELocation.Unknown():
+	case ELocation.Blob(), ELocation.S3(), ELocation.GCP(), ELocation.Benchmark(), ELocation.Pipe(), ELocation.Unknown(), ELocation.None():
 		return false
 	default:
 		panic("unexpected location, please specify if it is folder-aware")
@@ -530,6 +678,7 @@ func (FromTo) FileTrash() FromTo { return FromTo(fromToValue(ELocation.File(), E
 func (FromTo) BlobFSTrash() FromTo {
 	return FromTo(fromToValue(ELocation.BlobFS(), ELocation.Unknown()))
 }
+func (FromTo) BlobNone() FromTo    { return FromTo(fromToValue(ELocation.Blob(), ELocation.None())) }
 func (FromTo) LocalBlobFS() FromTo { return FromTo(fromToValue(ELocation.Local(), ELocation.BlobFS())) }
 func (FromTo) BlobFSLocal() FromTo { return FromTo(fromToValue(ELocation.BlobFS(), ELocation.Local())) }
 func (FromTo) BlobBlob() FromTo    { return FromTo(fromToValue(ELocation.Blob(), ELocation.Blob())) }
@@ -696,6 +845,11 @@ func (ts TransferStatus) ShouldTransfer() bool {
 	return ts == ETransferStatus.NotStarted() || ts == ETransferStatus.Started() || ts == ETransferStatus.FolderCreated()
 }
 
+// DidFail says whether the transfer failed, as opposed to e.g. being skipped
+func (ts TransferStatus) DidFail() bool {
+	return ts == ETransferStatus.Failed() || ts == ETransferStatus.BlobTierFailure() || ts == ETransferStatus.TierAvailabilityCheckFailure()
+}
+
 // Transfer is any of the three possible state (InProgress, Completer or Failed)
 func (TransferStatus) All() TransferStatus { return TransferStatus(math.MaxInt8) }
 func (ts TransferStatus) String() string {
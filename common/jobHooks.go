@@ -0,0 +1,259 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package common
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-pipeline-go/pipeline"
+	"github.com/JeffreyRichter/enum/enum"
+)
+
+var EJobHookEvent = JobHookEvent(0)
+
+// JobHookEvent is a point in the life of a job at which its hooks are run
+type JobHookEvent uint8
+
+func (JobHookEvent) JobStarted() JobHookEvent     { return JobHookEvent(0) }
+func (JobHookEvent) JobCompleted() JobHookEvent   { return JobHookEvent(1) }
+func (JobHookEvent) JobCancelled() JobHookEvent   { return JobHookEvent(2) }
+func (JobHookEvent) TransferFailed() JobHookEvent { return JobHookEvent(3) }
+
+func (e JobHookEvent) String() string {
+	return enum.StringInt(e, reflect.TypeOf(e))
+}
+
+func (e *JobHookEvent) Parse(s string) error {
+	val, err := enum.ParseInt(reflect.TypeOf(e), s, true, true)
+	if err == nil {
+		*e = val.(JobHookEvent)
+	}
+	return err
+}
+
+func (e JobHookEvent) MarshalJSON() ([]byte, error) {
+	return json.Marshal(e.String())
+}
+
+// ParseJobHookEvents parses a comma-separated list of events, such as the value of --hook-events. An empty list means all of them.
+func ParseJobHookEvents(s string) ([]JobHookEvent, error) {
+	if strings.TrimSpace(s) == "" {
+		return []JobHookEvent{EJobHookEvent.JobStarted(), EJobHookEvent.JobCompleted(), EJobHookEvent.JobCancelled(), EJobHookEvent.TransferFailed()}, nil
+	}
+	var events []JobHookEvent
+	for _, name := range strings.Split(s, ",") {
+		var e JobHookEvent
+		if err := e.Parse(strings.TrimSpace(name)); err != nil {
+			return nil, fmt.Errorf("invalid hook event %q, it must be JobStarted, JobCompleted, JobCancelled or TransferFailed", name)
+		}
+		events = append(events, e)
+	}
+	return events, nil
+}
+
+// JobHookPayload is what a hook is told about the event. It's the body of the POST to the hook URL, and the standard input of the hook command.
+type JobHookPayload struct {
+	Event     JobHookEvent
+	Timestamp time.Time
+	JobID     JobID
+	JobStatus JobStatus
+
+	// Summary is that of the job as it started or ended. It's not set for TransferFailed, which has the Transfer instead
+	Summary  *ListJobSummaryResponse `json:",omitempty"`
+	Transfer *TransferDetail         `json:",omitempty"`
+}
+
+const (
+	jobHookRequestTimeout = 30 * time.Second
+	jobHookCommandTimeout = 5 * time.Minute
+	jobHookMaxTries       = 3
+)
+
+// JobHooks notify a URL, or run a command, at the chosen points in the life of a job.
+// They run one at a time, in the order of their events, on a goroutine of their own, so that the job doesn't wait for them.
+type JobHooks struct {
+	url     string
+	command string
+	events  map[JobHookEvent]bool
+	client  *http.Client
+
+	lock   *sync.Mutex
+	closed bool
+	queue  chan jobHookRun
+	done   chan struct{}
+}
+
+type jobHookRun struct {
+	payload JobHookPayload
+	logger  ILogger
+}
+
+// NewJobHooks returns the hooks, or nil if there's neither a URL nor a command
+func NewJobHooks(url string, command string, events []JobHookEvent) *JobHooks {
+	if url == "" && command == "" {
+		return nil
+	}
+	h := &JobHooks{
+		url:     url,
+		command: command,
+		events:  map[JobHookEvent]bool{},
+		client:  &http.Client{Timeout: jobHookRequestTimeout},
+		queue:   make(chan jobHookRun, 1000),
+		lock:    &sync.Mutex{},
+		done:    make(chan struct{}),
+	}
+	for _, e := range events {
+		h.events[e] = true
+	}
+	go h.run()
+	return h
+}
+
+// Fire queues the hooks of the event, if it's one of the chosen ones. Their failures are logged to logger.
+func (h *JobHooks) Fire(payload JobHookPayload, logger ILogger) {
+	if h == nil || !h.events[payload.Event] {
+		return
+	}
+	if payload.Timestamp.IsZero() {
+		payload.Timestamp = time.Now().UTC()
+	}
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	if h.closed {
+		return
+	}
+	select {
+	case h.queue <- jobHookRun{payload: payload, logger: logger}:
+	default:
+		// e.g. a URL that's so slow to respond that thousands of failed transfers are waiting for it. Rather than hold up the job, drop the event
+		logger.Log(pipeline.LogWarning, fmt.Sprintf("Hook for %s of job %s was dropped, since too many hooks are waiting to run", payload.Event, payload.JobID))
+	}
+}
+
+// Close waits for the queued hooks to run. Those that are fired after it are dropped.
+func (h *JobHooks) Close() {
+	if h == nil {
+		return
+	}
+	h.lock.Lock()
+	if !h.closed {
+		h.closed = true
+		close(h.queue)
+	}
+	h.lock.Unlock()
+	<-h.done
+}
+
+func (h *JobHooks) run() {
+	defer close(h.done)
+	for r := range h.queue {
+		body, err := json.Marshal(r.payload)
+		if err != nil {
+			r.logger.Log(pipeline.LogWarning, "Failed to describe the hook event: "+err.Error())
+			continue
+		}
+		if h.url != "" {
+			if err := h.post(body); err != nil {
+				r.logger.Log(pipeline.LogWarning, fmt.Sprintf("Hook URL for %s of job %s failed: %s", r.payload.Event, r.payload.JobID, err))
+			}
+		}
+		if h.command != "" {
+			if output, err := h.runCommand(r.payload, body); err != nil {
+				r.logger.Log(pipeline.LogWarning, fmt.Sprintf("Hook command for %s of job %s failed: %s. Output: %s", r.payload.Event, r.payload.JobID, err, output))
+			}
+		}
+	}
+}
+
+// post sends the payload to the URL, retrying after errors that may be transient
+func (h *JobHooks) post(body []byte) error {
+	var err error
+	for try := 1; try <= jobHookMaxTries; try++ {
+		if try > 1 {
+			time.Sleep(time.Duration(try-1) * time.Second)
+		}
+		var resp *http.Response
+		resp, err = h.client.Post(h.url, "application/json", bytes.NewReader(body))
+		if err != nil {
+			err = fmt.Errorf("%s", URLStringExtension(err.Error()).RedactSecretQueryParamForLogging())
+			continue
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode < 300 {
+			return nil
+		}
+		err = fmt.Errorf("the response was %s", resp.Status)
+		if resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests {
+			return err // retrying won't help
+		}
+	}
+	return err
+}
+
+// runCommand runs the command with a shell, giving it the payload on its standard input, and its gist in environment variables
+func (h *JobHooks) runCommand(payload JobHookPayload, body []byte) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), jobHookCommandTimeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", h.command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", h.command)
+	}
+	cmd.Stdin = bytes.NewReader(body)
+	cmd.Env = append(os.Environ(), jobHookEnvironment(payload)...)
+	return cmd.CombinedOutput()
+}
+
+func jobHookEnvironment(payload JobHookPayload) []string {
+	env := []string{
+		"AZCOPY_HOOK_EVENT=" + payload.Event.String(),
+		"AZCOPY_JOB_ID=" + payload.JobID.String(),
+		"AZCOPY_JOB_STATUS=" + payload.JobStatus.String(),
+	}
+	if payload.Summary != nil {
+		env = append(env,
+			"AZCOPY_TRANSFERS_COMPLETED="+strconv.FormatUint(uint64(payload.Summary.TransfersCompleted), 10),
+			"AZCOPY_TRANSFERS_FAILED="+strconv.FormatUint(uint64(payload.Summary.TransfersFailed), 10),
+			"AZCOPY_TRANSFERS_SKIPPED="+strconv.FormatUint(uint64(payload.Summary.TransfersSkipped), 10))
+	}
+	if t := payload.Transfer; t != nil {
+		env = append(env,
+			"AZCOPY_TRANSFER_SOURCE="+t.Src,
+			"AZCOPY_TRANSFER_DESTINATION="+t.Dst,
+			"AZCOPY_TRANSFER_STATUS="+t.TransferStatus.String(),
+			"AZCOPY_TRANSFER_ERROR_CODE="+strconv.Itoa(int(t.ErrorCode)))
+	}
+	return env
+}
@@ -54,6 +54,7 @@ type LifecycleMgr interface {
 	Exit(OutputBuilder, ExitCode)                                // indicates successful execution exit after printing, allow user to specify exit code
 	Info(string)                                                 // simple print, allowed to float up
 	Dryrun(OutputBuilder)                                        // print files for dry run mode
+	Output(OutputBuilder)                                        // print a result of the command, such as a listed object, in the output format
 	Error(string)                                                // indicates fatal error, exit after printing, exit code is always Failed (1)
 	Prompt(message string, details PromptDetails) ResponseOption // ask the user a question(after erasing the progress), then return the response
 	SurrenderControl()                                           // give up control, this should never return
@@ -317,6 +318,13 @@ func (lcm *lifecycleMgr) Dryrun(o OutputBuilder) {
 	}
 }
 
+func (lcm *lifecycleMgr) Output(o OutputBuilder) {
+	lcm.msgQueue <- outputMessage{
+		msgContent: o(lcm.outputFormat),
+		msgType:    eOutputMessageType.Output(),
+	}
+}
+
 // TODO minor: consider merging with Exit
 func (lcm *lifecycleMgr) Error(msg string) {
 
@@ -328,6 +336,9 @@ func (lcm *lifecycleMgr) Error(msg string) {
 	// Check if there is ongoing CPU profiling, and stop CPU profiling.
 	lcm.checkAndStopCPUProfiling()
 
+	// Export the spans that are still queued, since the process is about to exit
+	FlushTracing(traceFlushTimeout)
+
 	lcm.msgQueue <- outputMessage{
 		msgContent: msg,
 		msgType:    eOutputMessageType.Error(),
@@ -345,6 +356,9 @@ func (lcm *lifecycleMgr) Exit(o OutputBuilder, applicationExitCode ExitCode) {
 
 		// Check if there is ongoing CPU profiling, and stop CPU profiling.
 		lcm.checkAndStopCPUProfiling()
+
+		// Export the spans that are still queued, since the process is about to exit
+		FlushTracing(traceFlushTimeout)
 	}
 
 	messageContent := ""
@@ -390,8 +404,13 @@ func (lcm *lifecycleMgr) SurrenderControl() {
 	select {}
 }
 
+// RegisterCloseFunc adds closeFunc to those that are called before exiting, in the order in which they were registered
 func (lcm *lifecycleMgr) RegisterCloseFunc(closeFunc func()) {
-	lcm.closeFunc = closeFunc
+	previous := lcm.closeFunc
+	lcm.closeFunc = func() {
+		previous()
+		closeFunc()
+	}
 }
 
 func (lcm *lifecycleMgr) processOutputMessage() {
@@ -405,6 +424,8 @@ func (lcm *lifecycleMgr) processOutputMessage() {
 			lcm.processJSONOutput(msgToPrint)
 		case EOutputFormat.Text():
 			lcm.processTextOutput(msgToPrint)
+		case EOutputFormat.Csv():
+			lcm.processCsvOutput(msgToPrint)
 		case EOutputFormat.None():
 			lcm.processNoneOutput(msgToPrint)
 		default:
@@ -445,6 +466,19 @@ func (lcm *lifecycleMgr) processJSONOutput(msgToOutput outputMessage) {
 	}
 }
 
+// the results are the rows of the CSV, so the messages that would break them up are left out,
+// and the rest are printed as with text
+func (lcm *lifecycleMgr) processCsvOutput(msgToOutput outputMessage) {
+	switch msgToOutput.msgType {
+	case eOutputMessageType.Output():
+		fmt.Println(msgToOutput.msgContent)
+	case eOutputMessageType.Init(), eOutputMessageType.Info(), eOutputMessageType.Progress(), eOutputMessageType.Response():
+		return
+	default:
+		lcm.processTextOutput(msgToOutput)
+	}
+}
+
 func (lcm *lifecycleMgr) processTextOutput(msgToOutput outputMessage) {
 	// when a new line needs to overwrite the current line completely
 	// we need to make sure that if the new line is shorter, we properly erase everything from the current line
@@ -478,7 +512,7 @@ func (lcm *lifecycleMgr) processTextOutput(msgToOutput outputMessage) {
 
 		lcm.progressCache = msgToOutput.msgContent
 
-	case eOutputMessageType.Init(), eOutputMessageType.Info(), eOutputMessageType.Dryrun(), eOutputMessageType.Response():
+	case eOutputMessageType.Init(), eOutputMessageType.Info(), eOutputMessageType.Dryrun(), eOutputMessageType.Response(), eOutputMessageType.Output():
 		if lcm.progressCache != "" { // a progress status is already on the last line
 			// print the info from the beginning on current line
 			fmt.Print("\r")
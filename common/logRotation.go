@@ -0,0 +1,260 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package common
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// rotatingLogFile is the file of a log, which is started afresh whenever it reaches its maximum size.
+// The full segments are renamed to <name>.<n>, where n counts up from 1, and compressed to <name>.<n>.gz in the background.
+// The oldest segments are deleted, so that the log and its segments take no more than their maximum total size.
+type rotatingLogFile struct {
+	path         string
+	maxSize      int64 // zero means that the log is never rotated
+	maxTotalSize int64 // zero means that segments are never deleted
+
+	lock        *sync.Mutex
+	file        *os.File
+	size        int64
+	nextSegment int
+
+	compressions *sync.WaitGroup
+}
+
+// openRotatingLogFile opens the log for appending. If it was rotated before, e.g. by an earlier run of a resumed job,
+// the numbering of its segments carries on from there.
+func openRotatingLogFile(path string, maxSize int64, maxTotalSize int64) (*rotatingLogFile, error) {
+	f := &rotatingLogFile{
+		path:         path,
+		maxSize:      maxSize,
+		maxTotalSize: maxTotalSize,
+		lock:         &sync.Mutex{},
+		nextSegment:  1,
+		compressions: &sync.WaitGroup{},
+	}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+
+	segments, err := f.segments()
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range segments {
+		if s.number >= f.nextSegment {
+			f.nextSegment = s.number + 1
+		}
+		if !s.compressed {
+			// left behind by a run that ended before it could compress it
+			f.compress(s.path)
+		}
+	}
+	return f, nil
+}
+
+func (f *rotatingLogFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_RDWR|os.O_CREATE|os.O_APPEND, DEFAULT_FILE_PERM)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+	f.file, f.size = file, info.Size()
+	return nil
+}
+
+// Write appends p to the log, after rotating it if p would take it over its maximum size.
+// The log.Logger that writes to it gives it whole lines, so lines are never split between segments.
+func (f *rotatingLogFile) Write(p []byte) (int, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.maxSize > 0 && f.size > 0 && f.size+int64(len(p)) > f.maxSize {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+func (f *rotatingLogFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return err
+	}
+	segmentPath := f.path + "." + strconv.Itoa(f.nextSegment)
+	f.nextSegment++
+	if err := os.Rename(f.path, segmentPath); err != nil {
+		return err
+	}
+	if err := f.open(); err != nil {
+		return err
+	}
+	f.compress(segmentPath)
+	return nil
+}
+
+// compress replaces the segment with a gzipped copy, and then deletes the oldest segments if the log is too big.
+// It's done in the background, so that logging doesn't wait for it.
+func (f *rotatingLogFile) compress(segmentPath string) {
+	f.compressions.Add(1)
+	go func() {
+		defer f.compressions.Done()
+		if err := gzipFile(segmentPath); err == nil {
+			_ = os.Remove(segmentPath)
+		}
+		f.enforceMaxTotalSize()
+	}()
+}
+
+func gzipFile(path string) error {
+	source, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	// write to a temporary name, so that a half-written file is never mistaken for a segment
+	tempPath := path + ".gz.tmp"
+	destination, err := os.OpenFile(tempPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, DEFAULT_FILE_PERM)
+	if err != nil {
+		return err
+	}
+	writer := gzip.NewWriter(destination)
+	_, err = io.Copy(writer, source)
+	if err == nil {
+		err = writer.Close()
+	}
+	if closeErr := destination.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(tempPath)
+		return err
+	}
+	return os.Rename(tempPath, path+".gz")
+}
+
+// enforceMaxTotalSize deletes the oldest segments until the log fits in maxTotalSize. Only the size of the file
+// being written is read under the lock, so that logging doesn't wait while the directory is listed and the segments are deleted.
+func (f *rotatingLogFile) enforceMaxTotalSize() {
+	if f.maxTotalSize <= 0 {
+		return
+	}
+	f.lock.Lock()
+	total := f.size
+	f.lock.Unlock()
+
+	segments, err := f.segments()
+	if err != nil {
+		return
+	}
+	for _, s := range segments {
+		total += s.size
+	}
+	// the segments are oldest first, and the one being written is never deleted
+	for _, s := range segments {
+		if total <= f.maxTotalSize {
+			break
+		}
+		if !s.compressed {
+			continue // it's still being compressed, and will be counted once it has been
+		}
+		if os.Remove(s.path) == nil {
+			total -= s.size
+		}
+	}
+}
+
+type logSegment struct {
+	path       string
+	number     int
+	compressed bool
+	size       int64
+}
+
+// segments lists the rotated segments of the log, oldest first
+func (f *rotatingLogFile) segments() ([]logSegment, error) {
+	infos, err := ioutil.ReadDir(filepath.Dir(f.path))
+	if err != nil {
+		return nil, err
+	}
+	prefix := filepath.Base(f.path) + "."
+
+	var segments []logSegment
+	for _, info := range infos {
+		name := info.Name()
+		if info.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		rest := strings.TrimPrefix(name, prefix)
+		compressed := strings.HasSuffix(rest, ".gz")
+		number, err := strconv.Atoi(strings.TrimSuffix(rest, ".gz"))
+		if err != nil || number < 1 {
+			continue // e.g. a temporary file of a compression
+		}
+		segments = append(segments, logSegment{path: filepath.Join(filepath.Dir(f.path), name), number: number, compressed: compressed, size: info.Size()})
+	}
+	sort.Slice(segments, func(i, j int) bool { return segments[i].number < segments[j].number })
+	return segments, nil
+}
+
+// Close closes the log, after waiting for the compression of its segments
+func (f *rotatingLogFile) Close() error {
+	f.lock.Lock()
+	err := f.file.Close()
+	f.lock.Unlock()
+
+	f.compressions.Wait()
+	return err
+}
+
+// IsLogFileName says whether a file in the log folder is a log, or a rotated segment of one
+func IsLogFileName(name string) bool {
+	return strings.HasSuffix(name, ".log") || strings.Contains(name, ".log.")
+}
+
+// ParseMegabytes parses the value of an environment variable that is a size in megabytes, such as AZCOPY_LOG_MAX_SIZE_MB.
+// An empty value means zero.
+func ParseMegabytes(env EnvironmentVariable, value string) (int64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	mb, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || mb < 0 {
+		return 0, fmt.Errorf("%s must be a whole number of megabytes, not %q", env.Name, value)
+	}
+	return mb * 1024 * 1024, nil
+}
@@ -21,6 +21,8 @@
 package common
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"log"
 	"net/url"
@@ -50,6 +52,54 @@ type ILoggerResetable interface {
 	ILoggerCloser
 }
 
+// LogFields are what a log entry is about, besides its message. The JSON format records them as fields of their own,
+// so that the entries can be filtered by them. The text format leaves them out, since its messages already say what matters.
+type LogFields struct {
+	PartNumber    *uint32 `json:"partNumber,omitempty"`
+	TransferIndex *uint32 `json:"transferIndex,omitempty"`
+	Source        string  `json:"source,omitempty"`
+	Destination   string  `json:"destination,omitempty"`
+	HTTPMethod    string  `json:"httpMethod,omitempty"`
+	HTTPStatus    int     `json:"httpStatus,omitempty"`
+	RequestID     string  `json:"requestId,omitempty"`
+	RetryCount    int     `json:"retryCount,omitempty"`
+	ErrorCode     string  `json:"errorCode,omitempty"`
+}
+
+// IStructuredLogger is implemented by the loggers that can record LogFields.
+// The prefix is only written in the text format, since in JSON the fields say the same thing.
+type IStructuredLogger interface {
+	LogWithFields(level pipeline.LogLevel, prefix string, msg string, fields LogFields)
+}
+
+// LogWithFields logs the message with its fields, if the logger can record them, or as text otherwise
+func LogWithFields(logger ILogger, level pipeline.LogLevel, prefix string, msg string, fields LogFields) {
+	if sl, ok := logger.(IStructuredLogger); ok {
+		sl.LogWithFields(level, prefix, msg, fields)
+	} else {
+		logger.Log(level, prefix+msg)
+	}
+}
+
+type logContextKey struct{}
+
+type logContext struct {
+	logger ILogger
+	fields LogFields
+}
+
+// WithLogContext returns a context, the HTTP requests of which are logged to the given logger, with the given fields.
+// It lets the request log policy tell which transfer a request is for.
+func WithLogContext(ctx context.Context, logger ILogger, fields LogFields) context.Context {
+	return context.WithValue(ctx, logContextKey{}, logContext{logger: logger, fields: fields})
+}
+
+// LogContextFromContext returns the logger and the fields given to WithLogContext, if any
+func LogContextFromContext(ctx context.Context) (ILogger, LogFields, bool) {
+	lc, ok := ctx.Value(logContextKey{}).(logContext)
+	return lc.logger, lc.fields, ok
+}
+
 ////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 
 func NewAppLogger(minimumLevelToLog pipeline.LogLevel, logFileFolder string) ILoggerCloser {
@@ -107,34 +157,86 @@ type jobLogger struct {
 	// any message with severity higher than this will be ignored.
 	jobID             JobID
 	minimumLevelToLog pipeline.LogLevel // The maximum customer-desired log level for this job
-	file              *os.File          // The job's log file
+	file              *rotatingLogFile  // The job's log file
 	logFileFolder     string            // The log file's parent folder, needed for opening the file at the right place
 	logger            *log.Logger       // The Job's logger
 	sanitizer         pipeline.LogSanitizer
 	logFileNameSuffix string // Used to allow more than 1 log per job, ex: front-end and back-end logs should be separate
+	format            LogFormat
+	maxSize           int64
+	maxTotalSize      int64
 }
 
-func NewJobLogger(jobID JobID, minimumLevelToLog LogLevel, logFileFolder string, logFileNameSuffix string) ILoggerResetable {
+// JobLogSettings are the settings of the job and scanning logs, which come from environment variables,
+// since the log of the process is opened before the command line is parsed
+type JobLogSettings struct {
+	Format LogFormat
+
+	// MaxSize is the size at which a log is rotated, and its full segment compressed. Zero means that it never is.
+	MaxSize int64
+
+	// MaxTotalSize caps the size of a log and its segments together, by deleting the oldest segments. Zero means no cap.
+	MaxTotalSize int64
+}
+
+// JobLogSettingsFromEnvironment reads AZCOPY_LOG_FORMAT, AZCOPY_LOG_MAX_SIZE_MB and AZCOPY_LOG_MAX_TOTAL_SIZE_MB
+func JobLogSettingsFromEnvironment(getenv func(EnvironmentVariable) string) (JobLogSettings, error) {
+	var settings JobLogSettings
+	var err error
+	if err = settings.Format.Parse(getenv(EEnvironmentVariable.LogFormat())); err != nil {
+		return settings, fmt.Errorf("invalid %s: %w", EEnvironmentVariable.LogFormat().Name, err)
+	}
+	if settings.MaxSize, err = ParseMegabytes(EEnvironmentVariable.LogMaxSizeMB(), getenv(EEnvironmentVariable.LogMaxSizeMB())); err != nil {
+		return settings, err
+	}
+	if settings.MaxTotalSize, err = ParseMegabytes(EEnvironmentVariable.LogMaxTotalSizeMB(), getenv(EEnvironmentVariable.LogMaxTotalSizeMB())); err != nil {
+		return settings, err
+	}
+	return settings, nil
+}
+
+func NewJobLogger(jobID JobID, minimumLevelToLog LogLevel, logFileFolder string, logFileNameSuffix string, settings JobLogSettings) ILoggerResetable {
 	return &jobLogger{
 		jobID:             jobID,
 		minimumLevelToLog: minimumLevelToLog.ToPipelineLogLevel(),
 		logFileFolder:     logFileFolder,
 		sanitizer:         NewAzCopyLogSanitizer(),
 		logFileNameSuffix: logFileNameSuffix,
+		format:            settings.Format,
+		maxSize:           settings.MaxSize,
+		maxTotalSize:      settings.MaxTotalSize,
 	}
 }
 
+// jsonLogEntry is a line of a log in the JSON format. Its fields are part of what we support, so they must not be renamed
+type jsonLogEntry struct {
+	Timestamp string `json:"timestamp"`
+	Level     string `json:"level"`
+	JobID     string `json:"jobId"`
+	LogFields
+	Message string `json:"message"`
+}
+
 func (jl *jobLogger) OpenLog() {
 	if jl.minimumLevelToLog == pipeline.LogNone {
 		return
 	}
 
-	file, err := os.OpenFile(path.Join(jl.logFileFolder, jl.jobID.String()+jl.logFileNameSuffix+".log"),
-		os.O_RDWR|os.O_CREATE|os.O_APPEND, DEFAULT_FILE_PERM)
+	file, err := openRotatingLogFile(path.Join(jl.logFileFolder, jl.jobID.String()+jl.logFileNameSuffix+".log"),
+		jl.maxSize, jl.maxTotalSize)
 	PanicIfErr(err)
 
 	jl.file = file
 
+	if jl.format == ELogFormat.Json() {
+		// the time is a field of each entry
+		jl.logger = log.New(jl.file, "", 0)
+		jl.writeJsonEntry(pipeline.LogInfo, "AzcopyVersion "+AzcopyVersion, LogFields{})
+		jl.writeJsonEntry(pipeline.LogInfo, "OS-Environment "+runtime.GOOS, LogFields{})
+		jl.writeJsonEntry(pipeline.LogInfo, "OS-Architecture "+runtime.GOARCH, LogFields{})
+		return
+	}
+
 	flags := log.LstdFlags | log.LUTC
 	utcMessage := fmt.Sprintf("Log times are in UTC. Local time is " + time.Now().Format("2 Jan 2006 15:04:05"))
 
@@ -163,14 +265,27 @@ func (jl *jobLogger) CloseLog() {
 		return
 	}
 
-	jl.logger.Println("Closing Log")
+	if jl.format == ELogFormat.Json() {
+		jl.writeJsonEntry(pipeline.LogInfo, "Closing Log", LogFields{})
+	} else {
+		jl.logger.Println("Closing Log")
+	}
 	err := jl.file.Close()
 	PanicIfErr(err)
 }
 
 func (jl jobLogger) Log(loglevel pipeline.LogLevel, msg string) {
-	// If the logger for Job is not initialized i.e file is not open
-	// or logger instance is not initialized, then initialize it
+	jl.LogWithFields(loglevel, "", msg, LogFields{})
+}
+
+func (jl jobLogger) LogWithFields(loglevel pipeline.LogLevel, prefix string, msg string, fields LogFields) {
+	if jl.format == ELogFormat.Json() {
+		if jl.ShouldLog(loglevel) {
+			jl.writeJsonEntry(loglevel, msg, fields)
+		}
+		return
+	}
+	msg = prefix + msg
 
 	// ensure all secrets are redacted
 	msg = jl.sanitizer.SanitizeLogMessage(msg)
@@ -185,8 +300,29 @@ func (jl jobLogger) Log(loglevel pipeline.LogLevel, msg string) {
 	}
 }
 
+// writeJsonEntry writes one line of the JSON format. JSON escapes the line endings of the message, so there are none to replace
+func (jl jobLogger) writeJsonEntry(loglevel pipeline.LogLevel, msg string, fields LogFields) {
+	// ensure all secrets are redacted, including those of the URLs in the fields
+	fields.Source = jl.sanitizer.SanitizeLogMessage(fields.Source)
+	fields.Destination = jl.sanitizer.SanitizeLogMessage(fields.Destination)
+	entry := jsonLogEntry{
+		Timestamp: time.Now().UTC().Format(time.RFC3339Nano),
+		Level:     LogLevel(loglevel).String(),
+		JobID:     jl.jobID.String(),
+		LogFields: fields,
+		Message:   jl.sanitizer.SanitizeLogMessage(msg),
+	}
+	line, _ := json.Marshal(entry) // can't fail, since the fields are all strings and numbers
+	jl.logger.Println(string(line))
+}
+
 func (jl jobLogger) Panic(err error) {
-	jl.logger.Println(err) // We do NOT panic here as the app would terminate; we just log it
+	// We do NOT panic here as the app would terminate; we just log it
+	if jl.format == ELogFormat.Json() {
+		jl.writeJsonEntry(pipeline.LogPanic, err.Error(), LogFields{})
+	} else {
+		jl.logger.Println(err)
+	}
 	panic(err)
 	// We should never reach this line of code!
 }
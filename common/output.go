@@ -31,6 +31,8 @@ func (outputMessageType) Prompt() outputMessageType { return outputMessageType(5
 func (outputMessageType) Response() outputMessageType { return outputMessageType(7) } /* Response to LCMMsg (like PerformanceAdjustment)
 //Json with determined fields for output-type json, INFO for other o/p types. */
 
+func (outputMessageType) Output() outputMessageType { return outputMessageType(8) } // a result of the command, such as a listed object, printed as is
+
 func (o outputMessageType) String() string {
 	return enum.StringInt(o, reflect.TypeOf(o))
 }
@@ -3,6 +3,7 @@ package common
 import (
 	"net/url"
 	"reflect"
+	"sort"
 	"strings"
 	"time"
 
@@ -130,6 +131,10 @@ type CopyJobPartOrderRequest struct {
 	Fpo             FolderPropertyOption // passed in from front-end to ensure that front-end and STE agree on the desired behaviour for the job
 	// list of blobTypes to exclude.
 	ExcludeBlobType []azblob.BlobType
+	// MaxConcurrency, if non-zero, caps the number of network operations that the job may have in flight at once
+	MaxConcurrency int32
+	// MaxBytesPerSecond, if non-zero, caps the throughput of the job, within the cap of the whole process
+	MaxBytesPerSecond int64
 
 	SourceRoot      ResourceString
 	DestinationRoot ResourceString
@@ -145,6 +150,7 @@ type CopyJobPartOrderRequest struct {
 	S2SGetPropertiesInBackend      bool
 	S2SSourceChangeValidation      bool
 	DestLengthValidation           bool
+	DeleteSourceOnSuccess          bool // for move, delete each source once it's been transferred and verified
 	S2SInvalidMetadataHandleOption InvalidMetadataHandleOption
 	S2SPreserveBlobTags            bool
 	CpkOptions                     CpkOptions
@@ -153,6 +159,13 @@ type CopyJobPartOrderRequest struct {
 	// As a result, CredentialInfo.OAuthTokenInfo may end up being fulfilled even _if_ CredentialInfo.CredentialType is _not_ OAuth.
 	// This may not always be the case (for instance, if we opt to use multiple OAuth tokens). At that point, this will likely be it's own CredentialInfo.
 	S2SSourceCredentialType CredentialType // Only Anonymous and OAuth will really be used in response to this, but S3 and GCP will come along too...
+
+	// TransferEvents, if not nil, receives an event as each transfer is done. Like CredentialInfo, it is kept in memory, not in the plan file.
+	TransferEvents *TransferEventWriter `json:"-"`
+	// Hooks, if not nil, are run as the job starts and ends, and as transfers fail. They are also kept in memory only.
+	Hooks *JobHooks `json:"-"`
+	// PageDiffBaselineSAS is the SAS of the BlobAttributes.PageDiffBaseline URL, if it has one. Like the SASs of the roots, it's kept in memory only.
+	PageDiffBaselineSAS string
 }
 
 // CredentialInfo contains essential credential info which need be transited between modules,
@@ -218,6 +231,11 @@ type BlobTransferAttributes struct {
 	DeleteSnapshotsOption    DeleteSnapshotsOption // when deleting, specify what to do with the snapshots
 	BlobTagsString           string                // when user explicitly provides blob tags
 	PermanentDeleteOption    PermanentDeleteOption // Permanently deletes soft-deleted snapshots when indicated by user
+	PageDiffBaseline         string                // when copying page blobs, only copy the pages that changed since this snapshot
+	DiskImageFormat          DiskImageFormat       // when uploading, convert disk images in this format to fixed VHDs
+	FollowSource             bool                  // when uploading to an append blob, keep appending whatever is added to the source file
+	SetPropertiesFlags       SetPropertiesFlags    // when setting properties, which of them to set
+	RehydratePriority        RehydratePriorityType // when setting the tier of archived blobs, how soon to rehydrate them
 }
 
 type JobIDDetails struct {
@@ -304,12 +322,13 @@ type ListJobTransfersRequest struct {
 }
 
 type ResumeJobRequest struct {
-	JobID           JobID
-	SourceSAS       string
-	DestinationSAS  string
-	IncludeTransfer map[string]int
-	ExcludeTransfer map[string]int
-	CredentialInfo  CredentialInfo
+	JobID               JobID
+	SourceSAS           string
+	DestinationSAS      string
+	PageDiffBaselineSAS string
+	IncludeTransfer     map[string]int
+	ExcludeTransfer     map[string]int
+	CredentialInfo      CredentialInfo
 }
 
 // represents the Details and details of a single transfer
@@ -319,7 +338,11 @@ type TransferDetail struct {
 	IsFolderProperties bool
 	TransferStatus     TransferStatus
 	TransferSize       uint64
-	ErrorCode          int32 `json:",string"`
+	ErrorCode          int32 `json:",string"` // the HTTP status code of the failure
+
+	// ErrorServiceCode (e.g. BlobNotFound) and ErrorMessage say why a failed transfer failed, when that's known
+	ErrorServiceCode string `json:",omitempty"`
+	ErrorMessage     string `json:",omitempty"`
 }
 
 type CancelPauseResumeResponse struct {
@@ -332,6 +355,44 @@ type ListJobTransfersResponse struct {
 	ErrorMsg string
 	JobID    JobID
 	Details  []TransferDetail
+
+	// FailuresByError counts the failed transfers among the Details by why they failed, most common first
+	FailuresByError []TransferErrorGroup `json:",omitempty"`
+}
+
+// TransferErrorGroup is the number of transfers that failed with an error
+type TransferErrorGroup struct {
+	ErrorCode        int32 `json:",string"` // the HTTP status code
+	ErrorServiceCode string
+	Count            uint32 `json:",string"`
+}
+
+// GroupFailuresByError counts the failed transfers by their HTTP status and service error code, most common first
+func GroupFailuresByError(details []TransferDetail) []TransferErrorGroup {
+	type key struct {
+		status int32
+		code   string
+	}
+	counts := map[key]uint32{}
+	for _, d := range details {
+		if d.TransferStatus.DidFail() {
+			counts[key{d.ErrorCode, d.ErrorServiceCode}]++
+		}
+	}
+	groups := make([]TransferErrorGroup, 0, len(counts))
+	for k, n := range counts {
+		groups = append(groups, TransferErrorGroup{ErrorCode: k.status, ErrorServiceCode: k.code, Count: n})
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Count != groups[j].Count {
+			return groups[i].Count > groups[j].Count
+		}
+		if groups[i].ErrorCode != groups[j].ErrorCode {
+			return groups[i].ErrorCode < groups[j].ErrorCode
+		}
+		return groups[i].ErrorServiceCode < groups[j].ErrorServiceCode
+	})
+	return groups
 }
 
 // GetJobFromToRequest indicates request to get job's FromTo info from job part plan header
@@ -0,0 +1,89 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package common
+
+import (
+	"hash"
+	"io"
+	"sort"
+)
+
+// FileRange is a contiguous range of bytes in a file
+type FileRange struct {
+	Offset int64
+	Length int64
+}
+
+func (r FileRange) end() int64 {
+	return r.Offset + r.Length
+}
+
+// SparseFileMap records where the data is in a sparse local file. Everything else is a hole, which reads as zeros.
+// A nil map means we don't know where the holes are (if any), so must assume there is data everywhere.
+type SparseFileMap struct {
+	dataRanges []FileRange // sorted, and non-overlapping
+}
+
+func NewSparseFileMap(dataRanges []FileRange) *SparseFileMap {
+	return &SparseFileMap{dataRanges: dataRanges}
+}
+
+// IsHole returns true if there is no data at all in the given range
+func (m *SparseFileMap) IsHole(offset int64, length int64) bool {
+	if m == nil {
+		return false
+	}
+
+	// find the first data range that ends after the start of the given range
+	i := sort.Search(len(m.dataRanges), func(i int) bool { return m.dataRanges[i].end() > offset })
+	return i == len(m.dataRanges) || m.dataRanges[i].Offset >= offset+length
+}
+
+// DataSize is the total number of bytes in the file that are not in holes
+func (m *SparseFileMap) DataSize() int64 {
+	total := int64(0)
+	for _, r := range m.dataRanges {
+		total += r.Length
+	}
+	return total
+}
+
+// zeros is shared, read-only, source of zero bytes for writing and hashing ranges that are known to be empty
+var zeros = make([]byte, 1024*1024)
+
+// WriteZeros writes count zero bytes to w, without needing a buffer of that size
+func WriteZeros(w io.Writer, count int64) error {
+	for count > 0 {
+		n := int64(len(zeros))
+		if count < n {
+			n = count
+		}
+		if _, err := w.Write(zeros[:n]); err != nil {
+			return err
+		}
+		count -= n
+	}
+	return nil
+}
+
+func hashZeros(h hash.Hash, count int64) {
+	_ = WriteZeros(h, count) // hashes never return errors
+}
@@ -0,0 +1,69 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package common
+
+import (
+	"errors"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// SparseLocalFilesSupported is true if we know how to find, and make, holes in local files on this OS
+const SparseLocalFilesSupported = true
+
+// GetSparseFileMap uses SEEK_DATA and SEEK_HOLE to find where the data is in f, which is size bytes long.
+// Returns an error if the file system does not support those.
+func GetSparseFileMap(f *os.File, size int64) (*SparseFileMap, error) {
+	fd := int(f.Fd())
+	defer func() {
+		_, _ = unix.Seek(fd, 0, unix.SEEK_SET) // leave the file as we found it
+	}()
+
+	dataRanges := make([]FileRange, 0)
+	offset := int64(0)
+	for offset < size {
+		dataStart, err := unix.Seek(fd, offset, unix.SEEK_DATA)
+		if errors.Is(err, unix.ENXIO) {
+			break // no more data, so the rest of the file is a hole
+		} else if err != nil {
+			return nil, err
+		}
+
+		holeStart, err := unix.Seek(fd, dataStart, unix.SEEK_HOLE) // there is always a hole at EOF, so this will succeed unless SEEK_HOLE is unsupported
+		if err != nil {
+			return nil, err
+		}
+		if holeStart > size {
+			holeStart = size // file has grown since we got its size
+		}
+
+		dataRanges = append(dataRanges, FileRange{Offset: dataStart, Length: holeStart - dataStart})
+		offset = holeStart
+	}
+
+	return NewSparseFileMap(dataRanges), nil
+}
+
+// PunchHole deallocates the given range of f, which will read as zeros. The file's size is not changed.
+func PunchHole(f *os.File, offset int64, length int64) error {
+	return unix.Fallocate(int(f.Fd()), unix.FALLOC_FL_PUNCH_HOLE|unix.FALLOC_FL_KEEP_SIZE, offset, length)
+}
@@ -0,0 +1,43 @@
+// +build !linux
+
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package common
+
+import (
+	"errors"
+	"os"
+)
+
+// SparseLocalFilesSupported is true if we know how to find, and make, holes in local files on this OS
+const SparseLocalFilesSupported = false
+
+var errSparseFilesNotSupported = errors.New("sparse files are not supported on this OS")
+
+// GetSparseFileMap is not supported on this OS. Callers must treat the whole file as data.
+func GetSparseFileMap(f *os.File, size int64) (*SparseFileMap, error) {
+	return nil, errSparseFilesNotSupported
+}
+
+// PunchHole is not supported on this OS. Callers must write zeros instead.
+func PunchHole(f *os.File, offset int64, length int64) error {
+	return errSparseFilesNotSupported
+}
@@ -0,0 +1,210 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package common
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Tracing records spans of the work of AzCopy with OpenTelemetry, and exports them to a collector with OTLP over HTTP.
+// It is enabled by setting the standard OTEL_EXPORTER_OTLP_ENDPOINT (or OTEL_EXPORTER_OTLP_TRACES_ENDPOINT) environment variable.
+// When it isn't enabled, the tracer is a no-op one, whose spans aren't recorded.
+//
+// Each job has a trace of its own, whose ID is the ID of the job. So the spans of a job can be found by its ID,
+// and those of all the runs of a job (e.g. after it is resumed) are in the same trace.
+
+const (
+	tracerName = "github.com/Azure/azure-storage-azcopy"
+
+	// maxSpanEvents caps the events of a span, so that a long transfer can't accumulate them without bound
+	maxSpanEvents = 128
+
+	traceExportBatchSize = 512
+	traceExportInterval  = 5 * time.Second
+	traceQueueSize       = 8192
+
+	// traceFlushTimeout is how long the export of the last spans may delay the exit of the process
+	traceFlushTimeout = 5 * time.Second
+)
+
+// TracingSettings configure the export of spans
+type TracingSettings struct {
+	// Endpoint is the URL to which spans are posted, e.g. http://localhost:4318/v1/traces
+	Endpoint    string
+	Headers     map[string]string
+	ServiceName string
+}
+
+// TracingSettingsFromEnvironment reads the standard OpenTelemetry environment variables.
+// It returns false if no endpoint is set, in which case tracing should stay disabled.
+func TracingSettingsFromEnvironment(getenv func(EnvironmentVariable) string) (TracingSettings, bool, error) {
+	settings := TracingSettings{ServiceName: getenv(EEnvironmentVariable.OtelServiceName())}
+	if settings.ServiceName == "" {
+		settings.ServiceName = "azcopy"
+	}
+
+	// as the spec says, the signal-specific endpoint is used as-is, while the general one gets the path of the signal
+	if e := getenv(EEnvironmentVariable.OtlpTracesEndpoint()); e != "" {
+		settings.Endpoint = e
+	} else if e := getenv(EEnvironmentVariable.OtlpEndpoint()); e != "" {
+		settings.Endpoint = strings.TrimSuffix(e, "/") + "/v1/traces"
+	} else {
+		return settings, false, nil
+	}
+	if u, err := url.Parse(settings.Endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return settings, false, fmt.Errorf("the OTLP endpoint %q must be an http or https URL", settings.Endpoint)
+	}
+
+	// headers are a comma-separated list of key=value pairs, with URL-encoded values
+	settings.Headers = map[string]string{}
+	for _, pair := range strings.Split(getenv(EEnvironmentVariable.OtlpHeaders()), ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 {
+			return settings, false, fmt.Errorf("the OTLP header %q must have the form key=value", pair)
+		}
+		value, err := url.QueryUnescape(strings.TrimSpace(kv[1]))
+		if err != nil {
+			return settings, false, fmt.Errorf("the value of the OTLP header %q is not URL-encoded: %w", kv[0], err)
+		}
+		settings.Headers[strings.TrimSpace(kv[0])] = value
+	}
+	return settings, true, nil
+}
+
+// the provider of AzCopy's tracer. It's kept apart from the global one of OpenTelemetry, which belongs to the program
+// when AzCopy is run in-process
+var globalTracerProvider atomic.Value // *sdktrace.TracerProvider
+
+var noopTracer = trace.NewNoopTracerProvider().Tracer(tracerName)
+
+// Tracer returns the tracer of AzCopy. Until tracing is enabled, it is a no-op one.
+func Tracer() trace.Tracer {
+	if tp, _ := globalTracerProvider.Load().(*sdktrace.TracerProvider); tp != nil {
+		return tp.Tracer(tracerName, trace.WithInstrumentationVersion(AzcopyVersion))
+	}
+	return noopTracer
+}
+
+// InitTracing enables tracing for the rest of the life of the process
+func InitTracing(settings TracingSettings) error {
+	if tp, _ := globalTracerProvider.Load().(*sdktrace.TracerProvider); tp != nil {
+		return nil
+	}
+	exporter, err := newOtlpExporter(settings)
+	if err != nil {
+		return err
+	}
+	globalTracerProvider.Store(newTracerProvider(exporter, settings.ServiceName))
+	return nil
+}
+
+// newOtlpExporter returns an exporter that posts spans to the endpoint of the settings. They override
+// the environment variables that the exporter would otherwise read by itself.
+func newOtlpExporter(settings TracingSettings) (sdktrace.SpanExporter, error) {
+	u, err := url.Parse(settings.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	options := []otlptracehttp.Option{
+		otlptracehttp.WithEndpoint(u.Host),
+		otlptracehttp.WithURLPath(u.Path),
+		otlptracehttp.WithHeaders(settings.Headers),
+	}
+	if u.Scheme == "http" {
+		options = append(options, otlptracehttp.WithInsecure())
+	}
+	return otlptracehttp.New(context.Background(), options...)
+}
+
+// newTracerProvider returns a provider that exports its spans in batches. If the exporter can't keep up,
+// spans are dropped rather than slowing the transfers down.
+func newTracerProvider(exporter sdktrace.SpanExporter, serviceName string) *sdktrace.TracerProvider {
+	limits := sdktrace.NewSpanLimits()
+	limits.EventCountLimit = maxSpanEvents
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter,
+			sdktrace.WithMaxQueueSize(traceQueueSize),
+			sdktrace.WithMaxExportBatchSize(traceExportBatchSize),
+			sdktrace.WithBatchTimeout(traceExportInterval)),
+		sdktrace.WithResource(resource.NewSchemaless(
+			attribute.String("service.name", serviceName),
+			attribute.String("service.version", AzcopyVersion))),
+		sdktrace.WithIDGenerator(jobTraceIDGenerator{}),
+		sdktrace.WithSpanLimits(limits))
+}
+
+// FlushTracing exports the spans that have ended, waiting at most for the given time.
+// It is called before the process exits, since spans are otherwise exported in batches.
+func FlushTracing(timeout time.Duration) {
+	if tp, _ := globalTracerProvider.Load().(*sdktrace.TracerProvider); tp != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		_ = tp.ForceFlush(ctx) // there's nowhere to report the failure to, without disturbing the job's output
+	}
+}
+
+type jobTraceIDContextKey struct{}
+
+// StartJobSpan starts a span in the trace of the given job
+func StartJobSpan(ctx context.Context, jobID JobID, name string) (context.Context, trace.Span) {
+	ctx = context.WithValue(ctx, jobTraceIDContextKey{}, jobTraceID(jobID))
+	return Tracer().Start(ctx, name, trace.WithNewRoot(), trace.WithAttributes(attribute.String("azcopy.job_id", jobID.String())))
+}
+
+// jobTraceID is the trace ID of a job: the hex digits of the job ID, so that one can be told from the other at a glance
+func jobTraceID(jobID JobID) (id trace.TraceID) {
+	_, _ = hex.Decode(id[:], []byte(strings.Replace(jobID.String(), "-", "", -1)))
+	return
+}
+
+// jobTraceIDGenerator gives the spans that are started by StartJobSpan the trace ID of their job, and the others random IDs
+type jobTraceIDGenerator struct{}
+
+func (jobTraceIDGenerator) NewIDs(ctx context.Context) (traceID trace.TraceID, spanID trace.SpanID) {
+	if id, ok := ctx.Value(jobTraceIDContextKey{}).(trace.TraceID); ok {
+		traceID = id
+	} else {
+		_, _ = rand.Read(traceID[:])
+	}
+	_, _ = rand.Read(spanID[:])
+	return
+}
+
+func (jobTraceIDGenerator) NewSpanID(ctx context.Context, traceID trace.TraceID) (spanID trace.SpanID) {
+	_, _ = rand.Read(spanID[:])
+	return
+}
@@ -0,0 +1,244 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package common
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// TransferEventsToStdout is the value of --transfer-events that writes the events to the standard output
+const TransferEventsToStdout = "stdout"
+
+// TransferEvent is one line of the stream of transfer events, written as each transfer completes, fails or is skipped
+type TransferEvent struct {
+	Timestamp   time.Time `json:"timestamp"`
+	JobID       JobID     `json:"jobId"`
+	Event       string    `json:"event"`  // completed, failed or skipped
+	Status      string    `json:"status"` // the TransferStatus, which says e.g. why it was skipped
+	Source      string    `json:"source"`
+	Destination string    `json:"destination"`
+	IsFolder    bool      `json:"isFolder,omitempty"`
+	Size        uint64    `json:"size"`
+	DurationMs  int64     `json:"durationMs"`
+
+	// ContentMD5 is the base64 MD5 hash of the content, when one was computed or is known from the source
+	ContentMD5 string `json:"contentMd5,omitempty"`
+	// VersionID is that of the source blob, and DestinationVersionID that of the blob the transfer created, when versioning is on
+	VersionID            string `json:"versionId,omitempty"`
+	DestinationVersionID string `json:"destinationVersionId,omitempty"`
+
+	// ErrorCode is the HTTP status code of the failure, if any
+	ErrorCode int32 `json:"errorCode,omitempty"`
+}
+
+// transferEventQueueSize is the number of events that can wait to be written. Beyond it, events are dropped
+const transferEventQueueSize = 10000
+
+// transferEventCloseTimeout is how long Close waits for the queued events to be written
+const transferEventCloseTimeout = 5 * time.Second
+
+// TransferEventWriter writes transfer events as JSON Lines, i.e. one JSON object per line.
+// The events are queued, and written by a goroutine of their own, so that a slow reader never holds up the job.
+// When the queue is full, events are dropped and counted. Once a write has failed, e.g. because the reader of
+// a named pipe has gone, the writer drops the rest of the events, so that the job's log isn't flooded by the failure.
+type TransferEventWriter struct {
+	dropped uint64 // accessed atomically, so it goes first to be 64-bit aligned on 32-bit platforms
+
+	lock   *sync.RWMutex // guards closed, so that no event is queued once the queue is closed
+	closed bool
+	queue  chan queuedTransferEvent
+	done   chan struct{}
+
+	write  func(line []byte) error
+	closer io.Closer
+
+	// writeErr is the error of the failed write, which is reported once by the next call to Write, as is the first drop
+	writeErr         atomic.Value
+	failed           int32
+	failureReported  int32
+	droppingReported int32
+}
+
+// queuedTransferEvent is either the line of an event, or a marker that is closed once the events before it have been written
+type queuedTransferEvent struct {
+	line    []byte
+	flushed chan struct{}
+}
+
+// NewTransferEventWriter writes the events to w, e.g. to a caller's own stream when running in-process
+func NewTransferEventWriter(w io.Writer) *TransferEventWriter {
+	return newTransferEventWriter(func(line []byte) error {
+		_, err := w.Write(line)
+		return err
+	}, nil)
+}
+
+func newTransferEventWriter(write func(line []byte) error, closer io.Closer) *TransferEventWriter {
+	w := &TransferEventWriter{
+		lock:   &sync.RWMutex{},
+		queue:  make(chan queuedTransferEvent, transferEventQueueSize),
+		done:   make(chan struct{}),
+		write:  write,
+		closer: closer,
+	}
+	go w.writeQueuedEvents()
+	return w
+}
+
+// OpenTransferEventWriter opens the target of --transfer-events: a file or named pipe, which is appended to, or the standard output.
+// The events for the standard output go through the lcm, so that they come out whole, and in between the progress reports.
+// Opening a named pipe waits until there's a reader of it.
+func OpenTransferEventWriter(lcm LifecycleMgr, target string) (*TransferEventWriter, error) {
+	if strings.EqualFold(target, TransferEventsToStdout) {
+		return newTransferEventWriter(func(line []byte) error {
+			event := string(bytes.TrimSuffix(line, []byte{'\n'}))
+			lcm.Output(func(OutputFormat) string { return event })
+			return nil
+		}, nil), nil
+	}
+	f, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_APPEND, DEFAULT_FILE_PERM)
+	if err != nil {
+		return nil, err
+	}
+	return newTransferEventWriter(func(line []byte) error {
+		_, err := f.Write(line)
+		return err
+	}, f), nil
+}
+
+// Write queues the event to be written as a line of its own. It doesn't wait for it to be written.
+// It returns the error of the first failed write, and that of the first dropped event, once each.
+func (w *TransferEventWriter) Write(e TransferEvent) error {
+	if w == nil {
+		return nil
+	}
+	if atomic.LoadInt32(&w.failed) == 1 {
+		if atomic.CompareAndSwapInt32(&w.failureReported, 0, 1) {
+			return w.writeErr.Load().(error)
+		}
+		return nil
+	}
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	w.lock.RLock()
+	defer w.lock.RUnlock()
+	if w.closed {
+		return nil
+	}
+	select {
+	case w.queue <- queuedTransferEvent{line: line}:
+		return nil
+	default:
+		atomic.AddUint64(&w.dropped, 1)
+		if atomic.CompareAndSwapInt32(&w.droppingReported, 0, 1) {
+			return errors.New("transfer events are being dropped, because they can't be written as fast as transfers are done")
+		}
+		return nil
+	}
+}
+
+// Dropped returns the number of events that were dropped because the queue was full
+func (w *TransferEventWriter) Dropped() uint64 {
+	if w == nil {
+		return 0
+	}
+	return atomic.LoadUint64(&w.dropped)
+}
+
+// Flush waits until the events that have been queued so far have been written. It's called as the job ends, before
+// AzCopy says so and exits, so that the stream has all the events of the job by then.
+func (w *TransferEventWriter) Flush() {
+	if w == nil {
+		return
+	}
+	flushed := make(chan struct{})
+	w.lock.RLock()
+	if w.closed {
+		w.lock.RUnlock()
+		return
+	}
+	w.queue <- queuedTransferEvent{flushed: flushed}
+	w.lock.RUnlock()
+	<-flushed
+}
+
+func (w *TransferEventWriter) writeQueuedEvents() {
+	defer close(w.done)
+	for e := range w.queue {
+		if e.flushed != nil {
+			close(e.flushed)
+			continue
+		}
+		if atomic.LoadInt32(&w.failed) == 1 {
+			continue // drain the queue, so that Close doesn't wait on it
+		}
+		// a single write per line, so that lines aren't interleaved with other output
+		if err := w.write(e.line); err != nil {
+			w.writeErr.Store(fmt.Errorf("failed to write to the stream of transfer events, so no more will be written: %w", err))
+			atomic.StoreInt32(&w.failed, 1)
+		}
+	}
+}
+
+// Close writes the events that are queued, waiting for up to transferEventCloseTimeout, then closes the file or named pipe.
+// Events that are written after it has been closed are dropped. It returns an error if any events were dropped along the way.
+// It doesn't wait any longer, since it's called as AzCopy exits, when the lcm may no longer take the events for the standard output.
+func (w *TransferEventWriter) Close() error {
+	if w == nil {
+		return nil
+	}
+	w.lock.Lock()
+	if w.closed {
+		w.lock.Unlock()
+		return nil
+	}
+	w.closed = true
+	close(w.queue)
+	w.lock.Unlock()
+
+	select {
+	case <-w.done:
+	case <-time.After(transferEventCloseTimeout):
+		return errors.New("timed out writing the last transfer events")
+	}
+	if w.closer != nil {
+		if err := w.closer.Close(); err != nil {
+			return err
+		}
+	}
+	if dropped := w.Dropped(); dropped > 0 {
+		return fmt.Errorf("%d transfer events were dropped, because they couldn't be written as fast as transfers were done", dropped)
+	}
+	return nil
+}
@@ -21,6 +21,8 @@
 package common
 
 import (
+	"fmt"
+	"io"
 	"os"
 	"regexp"
 	"strings"
@@ -90,3 +92,27 @@ func CreateDirectoryIfNotExist(directory string, tracker FolderCreationTracker)
 		return nil
 	}
 }
+
+// OpenFileForResume re-opens a partially written file, without truncating it, so that writing can continue from resumeOffset.
+// The file must already be of the expected (pre-allocated) size; if it is not, it is not safe to resume into it.
+// Like CreateFileOfSizeWithWriteThroughOption, it opens the file for write-through if writeThrough is set.
+func OpenFileForResume(destinationPath string, expectedSize int64, resumeOffset int64, writeThrough bool) (*os.File, error) {
+	f, err := openExistingFileWithWriteThroughOption(destinationPath, writeThrough)
+	if err != nil {
+		return nil, err
+	}
+
+	fi, err := f.Stat()
+	if err == nil && fi.Size() != expectedSize {
+		err = fmt.Errorf("existing file is %d bytes, but %d were expected", fi.Size(), expectedSize)
+	}
+	if err == nil {
+		_, err = f.Seek(resumeOffset, io.SeekStart)
+	}
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+
+	return f, nil
+}
@@ -61,6 +61,15 @@ func CreateFileOfSizeWithWriteThroughOption(destinationPath string, fileSize int
 	return f, nil
 }
 
+// openExistingFileWithWriteThroughOption opens a file for reading and writing, without creating or truncating it
+func openExistingFileWithWriteThroughOption(destinationPath string, writeThrough bool) (*os.File, error) {
+	flags := os.O_RDWR
+	if writeThrough {
+		flags = flags | os.O_SYNC // as in CreateFileOfSizeWithWriteThroughOption
+	}
+	return os.OpenFile(destinationPath, flags, DEFAULT_FILE_PERM)
+}
+
 func SetBackupMode(enable bool, fromTo FromTo) error {
 	// n/a on this platform
 	return nil
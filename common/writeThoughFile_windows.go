@@ -157,6 +157,19 @@ func CreateFileOfSizeWithWriteThroughOption(destinationPath string, fileSize int
 	return f, nil
 }
 
+// openExistingFileWithWriteThroughOption opens a file for reading and writing, without creating or truncating it
+func openExistingFileWithWriteThroughOption(destinationPath string, writeThrough bool) (*os.File, error) {
+	fd, err := OpenWithWriteThroughSetting(destinationPath, os.O_RDWR, DEFAULT_FILE_PERM, writeThrough)
+	if err != nil {
+		return nil, err
+	}
+	f := os.NewFile(uintptr(fd), destinationPath)
+	if f == nil {
+		return nil, os.ErrInvalid
+	}
+	return f, nil
+}
+
 func makeInheritSa() *windows.SecurityAttributes {
 	var sa windows.SecurityAttributes
 	sa.Length = uint32(unsafe.Sizeof(sa))
@@ -53,6 +53,12 @@ func CreateFileOfSizeWithWriteThroughOption(destinationPath string, fileSize int
 	return f, nil
 }
 
+// openExistingFileWithWriteThroughOption opens a file for reading and writing, without creating or truncating it
+func openExistingFileWithWriteThroughOption(destinationPath string, writeThrough bool) (*os.File, error) {
+	// writeThrough is ignored here on darwin, as it is by CreateFileOfSizeWithWriteThroughOption
+	return os.OpenFile(destinationPath, os.O_RDWR, DEFAULT_FILE_PERM)
+}
+
 func SetBackupMode(enable bool, fromTo FromTo) error {
 	// n/a on this platform
 	return nil
@@ -0,0 +1,93 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package common
+
+import (
+	"errors"
+	"hash"
+	"io"
+)
+
+// zeroChunkReader satisfies the SingleChunkReader interface for chunks that are known, without reading them, to be
+// entirely zeros. E.g. those that fall in a hole of a sparse file.
+type zeroChunkReader struct {
+	length   int64
+	position int64
+}
+
+func NewZeroChunkReader(length int64) SingleChunkReader {
+	return &zeroChunkReader{length: length}
+}
+
+func (cr *zeroChunkReader) BlockingPrefetch(fileReader io.ReaderAt, isRetry bool) error {
+	return nil // nothing to fetch
+}
+
+func (cr *zeroChunkReader) Seek(offset int64, whence int) (int64, error) {
+	newPosition := cr.position
+	switch whence {
+	case io.SeekStart:
+		newPosition = offset
+	case io.SeekCurrent:
+		newPosition += offset
+	case io.SeekEnd:
+		newPosition = cr.length + offset
+	}
+	if newPosition < 0 {
+		return 0, errors.New("cannot seek to before beginning")
+	}
+	cr.position = newPosition
+	return newPosition, nil
+}
+
+func (cr *zeroChunkReader) Read(p []byte) (n int, err error) {
+	if cr.position >= cr.length {
+		return 0, io.EOF
+	}
+	if remaining := cr.length - cr.position; int64(len(p)) > remaining {
+		p = p[:remaining]
+	}
+	for i := range p {
+		p[i] = 0
+	}
+	cr.position += int64(len(p))
+	return len(p), nil
+}
+
+func (cr *zeroChunkReader) Close() error {
+	return nil
+}
+
+func (cr *zeroChunkReader) GetPrologueState() PrologueState {
+	return PrologueState{} // we have no leading bytes that are worth sniffing
+}
+
+func (cr *zeroChunkReader) HasPrefetchedEntirelyZeros() bool {
+	return true
+}
+
+func (cr *zeroChunkReader) Length() int64 {
+	return cr.length
+}
+
+func (cr *zeroChunkReader) WriteBufferTo(h hash.Hash) {
+	hashZeros(h, cr.length)
+}
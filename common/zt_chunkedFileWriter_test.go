@@ -0,0 +1,84 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package common
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"math/rand"
+	"sync"
+
+	chk "gopkg.in/check.v1"
+)
+
+type chunkedFileWriterSuite struct{}
+
+var _ = chk.Suite(&chunkedFileWriterSuite{})
+
+type nullChunkStatusLogger struct{}
+
+func (nullChunkStatusLogger) LogChunkStatus(id ChunkID, reason WaitReason) {}
+func (nullChunkStatusLogger) IsWaitingOnFinalBodyReads() bool              { return false }
+
+func (s *chunkedFileWriterSuite) TestResumableChunkedFileWriter_HashesWholeFileAndReportsOffsets(c *chk.C) {
+	const chunkSize = 1024
+	const numChunks = 8
+	const resumeChunk = 3
+
+	// given:
+	// a file of which the first few chunks were saved by a previous run
+	original := make([]byte, chunkSize*numChunks)
+	rand.Read(original)
+	resumeOffset := int64(chunkSize * resumeChunk)
+	dest := &closeableBuffer{Buffer: &bytes.Buffer{}} // only receives the chunks written after resumption
+
+	var mu sync.Mutex
+	var reported []int64
+	report := func(nextOffset int64) {
+		mu.Lock()
+		defer mu.Unlock()
+		reported = append(reported, nextOffset)
+	}
+
+	// when:
+	// we resume, enqueuing the remaining chunks in reverse order
+	ctx := context.Background()
+	w := NewResumableChunkedFileWriter(ctx, NewMultiSizeSlicePool(chunkSize), NewCacheLimiter(chunkSize*numChunks), nullChunkStatusLogger{},
+		dest, numChunks-resumeChunk, 0, EHashValidationOption.FailIfDifferent(), true,
+		resumeOffset, bytes.NewReader(original[:resumeOffset]), report)
+	for i := numChunks - 1; i >= resumeChunk; i-- {
+		offset := int64(i * chunkSize)
+		id := NewChunkID("test", offset, chunkSize)
+		c.Assert(w.WaitToScheduleChunk(ctx, id, chunkSize), chk.IsNil)
+		c.Assert(w.EnqueueChunk(ctx, id, chunkSize, bytes.NewReader(original[offset:offset+chunkSize]), false), chk.IsNil)
+	}
+	hash, err := w.Flush(ctx)
+
+	// then:
+	// only the new content is written, but the hash covers the whole file, and we were told of each new contiguous end
+	c.Assert(err, chk.IsNil)
+	c.Assert(dest.Bytes(), chk.DeepEquals, original[resumeOffset:])
+	expectedHash := md5.Sum(original)
+	c.Assert(hash, chk.DeepEquals, expectedHash[:])
+	c.Assert(reported, chk.HasLen, numChunks-resumeChunk)
+	c.Assert(reported[len(reported)-1], chk.Equals, int64(len(original)))
+}
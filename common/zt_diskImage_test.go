@@ -0,0 +1,214 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package common
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/zlib"
+	"encoding/binary"
+	"io/ioutil"
+	"path/filepath"
+
+	chk "gopkg.in/check.v1"
+)
+
+type diskImageSuite struct{}
+
+var _ = chk.Suite(&diskImageSuite{})
+
+func (s *diskImageSuite) TestFixedVHDSizeIsMiBAligned(c *chk.C) {
+	c.Assert(FixedVHDSize(1), chk.Equals, int64(vhdSizeAlignment+vhdFooterSize))
+	c.Assert(FixedVHDSize(vhdSizeAlignment), chk.Equals, int64(vhdSizeAlignment+vhdFooterSize))
+	c.Assert(FixedVHDSize(vhdSizeAlignment+512), chk.Equals, int64(2*vhdSizeAlignment+vhdFooterSize))
+}
+
+func (s *diskImageSuite) TestVHDFooter(c *chk.C) {
+	diskSize := int64(1024 * 1024 * 1024)
+	footer := newFixedVHDFooter(diskSize, vhdEpoch.Add(100e9), [16]byte{1, 2, 3})
+	be := binary.BigEndian
+
+	c.Assert(string(footer[0:8]), chk.Equals, "conectix")
+	c.Assert(be.Uint32(footer[24:28]), chk.Equals, uint32(100))
+	c.Assert(be.Uint64(footer[40:48]), chk.Equals, uint64(diskSize))
+	c.Assert(be.Uint64(footer[48:56]), chk.Equals, uint64(diskSize))
+	c.Assert(be.Uint32(footer[60:64]), chk.Equals, uint32(2)) // fixed
+	c.Assert(be.Uint32(footer[64:68]), chk.Equals, vhdChecksum(footer[:]))
+	c.Assert(footer[68], chk.Equals, byte(1))
+
+	// the geometry of a 1 GiB disk, as computed by the algorithm in the VHD spec
+	c.Assert(be.Uint16(footer[56:58]), chk.Equals, uint16(2080))
+	c.Assert(footer[58], chk.Equals, byte(16))
+	c.Assert(footer[59], chk.Equals, byte(63))
+}
+
+func (s *diskImageSuite) TestRawImageAsFixedVHD(c *chk.C) {
+	content := bytes.Repeat([]byte{0xAB}, 1000)
+	path := filepath.Join(c.MkDir(), "disk.raw")
+	c.Assert(ioutil.WriteFile(path, content, 0644), chk.IsNil)
+
+	vhd := readDiskImageAsFixedVHD(c, path, EDiskImageFormat.Raw())
+	c.Assert(int64(len(vhd)), chk.Equals, int64(vhdSizeAlignment+vhdFooterSize))
+	c.Assert(vhd[:1000], chk.DeepEquals, content)
+	c.Assert(bytes.Count(vhd[1000:vhdSizeAlignment], []byte{0}), chk.Equals, vhdSizeAlignment-1000)
+	c.Assert(string(vhd[vhdSizeAlignment:vhdSizeAlignment+8]), chk.Equals, "conectix")
+
+	// the footer must be the same every time the image is opened, since chunks may be read through different readers
+	c.Assert(readDiskImageAsFixedVHD(c, path, EDiskImageFormat.Raw()), chk.DeepEquals, vhd)
+}
+
+func (s *diskImageSuite) TestQcow2Image(c *chk.C) {
+	const clusterSize = 512
+	be := binary.BigEndian
+	cluster := func(b byte) []byte { return bytes.Repeat([]byte{b}, clusterSize) }
+
+	// layout: header, L1 table, L2 table, one data cluster, and then a compressed cluster
+	image := make([]byte, 5*clusterSize)
+	be.PutUint32(image[0:], qcow2Magic)
+	be.PutUint32(image[4:], 3)
+	be.PutUint32(image[20:], 9) // cluster bits
+	be.PutUint64(image[24:], 4*clusterSize)
+	be.PutUint32(image[36:], 1) // L1 size
+	be.PutUint64(image[40:], 1*clusterSize)
+	be.PutUint32(image[100:], 104) // header length
+
+	be.PutUint64(image[1*clusterSize:], 2*clusterSize) // L1 -> L2 table
+
+	l2 := image[2*clusterSize:]
+	be.PutUint64(l2[0:], 3*clusterSize)                              // guest cluster 0: data
+	be.PutUint64(l2[8:], 0)                                          // guest cluster 1: unallocated
+	be.PutUint64(l2[16:], qcow2CompressedFlag|uint64(4*clusterSize)) // guest cluster 2: compressed, in one sector
+	be.PutUint64(l2[24:], 3*clusterSize|qcow2ZeroFlag)               // guest cluster 3: zero, despite having an offset
+
+	copy(image[3*clusterSize:], cluster(0x11))
+
+	var compressed bytes.Buffer
+	w, _ := flate.NewWriter(&compressed, flate.BestCompression)
+	_, _ = w.Write(cluster(0x22))
+	_ = w.Close()
+	copy(image[4*clusterSize:], compressed.Bytes())
+
+	path := filepath.Join(c.MkDir(), "disk.qcow2")
+	c.Assert(ioutil.WriteFile(path, image, 0644), chk.IsNil)
+
+	vhd := readDiskImageAsFixedVHD(c, path, EDiskImageFormat.QCOW2())
+	expected := bytes.Join([][]byte{cluster(0x11), cluster(0), cluster(0x22), cluster(0)}, nil)
+	c.Assert(vhd[:4*clusterSize], chk.DeepEquals, expected)
+	c.Assert(int64(len(vhd)), chk.Equals, int64(vhdSizeAlignment+vhdFooterSize))
+}
+
+func (s *diskImageSuite) TestQcow2ImageWithBackingFileIsRejected(c *chk.C) {
+	image := make([]byte, 512)
+	binary.BigEndian.PutUint32(image[0:], qcow2Magic)
+	binary.BigEndian.PutUint32(image[4:], 2)
+	binary.BigEndian.PutUint64(image[8:], 256) // backing file offset
+	path := filepath.Join(c.MkDir(), "delta.qcow2")
+	c.Assert(ioutil.WriteFile(path, image, 0644), chk.IsNil)
+
+	_, err := DiskImageAsFixedVHDSize(path, EDiskImageFormat.QCOW2())
+	c.Assert(err, chk.NotNil)
+}
+
+func (s *diskImageSuite) TestVmdkMonolithicSparseImage(c *chk.C) {
+	const grainSize = 8 * vmdkSectorSize
+	grain := func(b byte) []byte { return bytes.Repeat([]byte{b}, grainSize) }
+
+	// layout: header in sector 0, grain directory in sector 1, grain table in sectors 2-5, grains from sector 8
+	image := make([]byte, 24*vmdkSectorSize)
+	putVmdkHeader(image, 0, 1, 0)
+	binary.LittleEndian.PutUint32(image[1*vmdkSectorSize:], 2)
+	gt := image[2*vmdkSectorSize:]
+	binary.LittleEndian.PutUint32(gt[0:], 8)
+	binary.LittleEndian.PutUint32(gt[8:], 16) // the second grain is unallocated
+	copy(image[8*vmdkSectorSize:], grain(0x33))
+	copy(image[16*vmdkSectorSize:], grain(0x44))
+
+	path := filepath.Join(c.MkDir(), "disk.vmdk")
+	c.Assert(ioutil.WriteFile(path, image, 0644), chk.IsNil)
+
+	vhd := readDiskImageAsFixedVHD(c, path, EDiskImageFormat.VMDK())
+	c.Assert(vhd[:3*grainSize], chk.DeepEquals, bytes.Join([][]byte{grain(0x33), grain(0), grain(0x44)}, nil))
+}
+
+func (s *diskImageSuite) TestVmdkStreamOptimizedImage(c *chk.C) {
+	const grainSize = 8 * vmdkSectorSize
+	grain := func(b byte) []byte { return bytes.Repeat([]byte{b}, grainSize) }
+
+	// layout: header (with the grain directory "at end"), a compressed grain in sector 1, grain table in sectors 2-5,
+	// grain directory in sector 6, then the footer marker, the footer, and the end-of-stream marker
+	image := make([]byte, 10*vmdkSectorSize)
+	putVmdkHeader(image, 0, vmdkGDAtEnd, vmdkFlagCompressed)
+	var compressed bytes.Buffer
+	w := zlib.NewWriter(&compressed)
+	_, _ = w.Write(grain(0x55))
+	_ = w.Close()
+	binary.LittleEndian.PutUint64(image[1*vmdkSectorSize:], 0) // LBA
+	binary.LittleEndian.PutUint32(image[1*vmdkSectorSize+8:], uint32(compressed.Len()))
+	copy(image[1*vmdkSectorSize+vmdkGrainMarkerSize:], compressed.Bytes())
+	binary.LittleEndian.PutUint32(image[2*vmdkSectorSize:], 1) // grain 0 is in sector 1
+	binary.LittleEndian.PutUint32(image[6*vmdkSectorSize:], 2) // grain table is in sector 2
+	putVmdkHeader(image, 8*vmdkSectorSize, 6, vmdkFlagCompressed)
+
+	path := filepath.Join(c.MkDir(), "disk.vmdk")
+	c.Assert(ioutil.WriteFile(path, image, 0644), chk.IsNil)
+
+	vhd := readDiskImageAsFixedVHD(c, path, EDiskImageFormat.VMDK())
+	c.Assert(vhd[:3*grainSize], chk.DeepEquals, bytes.Join([][]byte{grain(0x55), grain(0), grain(0)}, nil))
+}
+
+func (s *diskImageSuite) TestVmdkDescriptorFileIsRejected(c *chk.C) {
+	path := filepath.Join(c.MkDir(), "disk.vmdk")
+	c.Assert(ioutil.WriteFile(path, []byte("# Disk DescriptorFile\nversion=1\ncreateType=\"monolithicFlat\"\n"), 0644), chk.IsNil)
+
+	_, err := DiskImageAsFixedVHDSize(path, EDiskImageFormat.VMDK())
+	c.Assert(err, chk.NotNil)
+}
+
+// putVmdkHeader writes the header of a three grain disk, with 8 sector grains
+func putVmdkHeader(image []byte, offset int64, gdOffset uint64, flags uint32) {
+	var h bytes.Buffer
+	_ = binary.Write(&h, binary.LittleEndian, vmdkHeader{
+		MagicNumber:       vmdkMagic,
+		Version:           3,
+		Flags:             flags,
+		Capacity:          24,
+		GrainSize:         8,
+		NumGTEsPerGT:      512,
+		GdOffset:          gdOffset,
+		CompressAlgorithm: vmdkCompressionDeflate,
+	})
+	copy(image[offset:], h.Bytes())
+}
+
+func readDiskImageAsFixedVHD(c *chk.C, path string, format DiskImageFormat) []byte {
+	size, err := DiskImageAsFixedVHDSize(path, format)
+	c.Assert(err, chk.IsNil)
+
+	r, err := OpenDiskImageAsFixedVHD(path, format)
+	c.Assert(err, chk.IsNil)
+	defer r.Close()
+
+	vhd := make([]byte, size)
+	n, err := r.ReadAt(vhd, 0)
+	c.Assert(err, chk.IsNil)
+	c.Assert(int64(n), chk.Equals, size)
+	return vhd
+}
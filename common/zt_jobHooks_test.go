@@ -0,0 +1,143 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package common
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/Azure/azure-pipeline-go/pipeline"
+	chk "gopkg.in/check.v1"
+)
+
+type jobHooksSuite struct{}
+
+var _ = chk.Suite(&jobHooksSuite{})
+
+// hookTestLogger keeps what's logged, to check that failures are
+type hookTestLogger struct {
+	lock     sync.Mutex
+	messages []string
+}
+
+func (l *hookTestLogger) ShouldLog(level pipeline.LogLevel) bool { return true }
+func (l *hookTestLogger) Log(level pipeline.LogLevel, msg string) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.messages = append(l.messages, msg)
+}
+func (l *hookTestLogger) Panic(err error) { panic(err) }
+
+func (s *jobHooksSuite) TestChosenEventsArePostedInOrder(c *chk.C) {
+	var lock sync.Mutex
+	var received []JobHookPayload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.Method, chk.Equals, http.MethodPost)
+		c.Check(r.Header.Get("Content-Type"), chk.Equals, "application/json")
+		var p struct {
+			Event     string
+			JobStatus string
+			JobID     JobID
+			Summary   *ListJobSummaryResponse
+			Transfer  *TransferDetail
+		}
+		c.Check(json.NewDecoder(r.Body).Decode(&p), chk.IsNil)
+		var e JobHookEvent
+		c.Check(e.Parse(p.Event), chk.IsNil)
+		var status JobStatus
+		c.Check(status.Parse(p.JobStatus), chk.IsNil)
+		lock.Lock()
+		received = append(received, JobHookPayload{Event: e, JobStatus: status, JobID: p.JobID, Summary: p.Summary, Transfer: p.Transfer})
+		lock.Unlock()
+	}))
+	defer server.Close()
+
+	events, err := ParseJobHookEvents("JobStarted, JobCompleted")
+	c.Assert(err, chk.IsNil)
+	hooks := NewJobHooks(server.URL, "", events)
+	logger := &hookTestLogger{}
+	jobID := NewJobID()
+
+	hooks.Fire(JobHookPayload{Event: EJobHookEvent.JobStarted(), JobID: jobID, JobStatus: EJobStatus.InProgress(), Summary: &ListJobSummaryResponse{}}, logger)
+	hooks.Fire(JobHookPayload{Event: EJobHookEvent.TransferFailed(), JobID: jobID, Transfer: &TransferDetail{Src: "a"}}, logger) // not chosen
+	hooks.Fire(JobHookPayload{Event: EJobHookEvent.JobCompleted(), JobID: jobID, JobStatus: EJobStatus.CompletedWithErrors(),
+		Summary: &ListJobSummaryResponse{TransfersCompleted: 2, TransfersFailed: 1}}, logger)
+	hooks.Close()
+
+	c.Assert(received, chk.HasLen, 2)
+	c.Assert(received[0].Event, chk.Equals, EJobHookEvent.JobStarted())
+	c.Assert(received[0].JobID, chk.Equals, jobID)
+	c.Assert(received[1].Event, chk.Equals, EJobHookEvent.JobCompleted())
+	c.Assert(received[1].JobStatus, chk.Equals, EJobStatus.CompletedWithErrors())
+	c.Assert(received[1].Summary.TransfersFailed, chk.Equals, uint32(1))
+	c.Assert(logger.messages, chk.HasLen, 0)
+
+	// fired too late
+	hooks.Fire(JobHookPayload{Event: EJobHookEvent.JobStarted(), JobID: jobID}, logger)
+}
+
+func (s *jobHooksSuite) TestFailedPostIsLogged(c *chk.C) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	hooks := NewJobHooks(server.URL, "", []JobHookEvent{EJobHookEvent.JobCancelled()})
+	logger := &hookTestLogger{}
+	hooks.Fire(JobHookPayload{Event: EJobHookEvent.JobCancelled(), JobID: NewJobID()}, logger)
+	hooks.Close()
+
+	c.Assert(logger.messages, chk.HasLen, 1)
+	c.Assert(strings.Contains(logger.messages[0], "403"), chk.Equals, true)
+}
+
+func (s *jobHooksSuite) TestCommandGetsPayloadAndEnvironment(c *chk.C) {
+	if runtime.GOOS == "windows" {
+		c.Skip("the command is written for sh")
+	}
+	out := filepath.Join(c.MkDir(), "out")
+	events, err := ParseJobHookEvents("")
+	c.Assert(err, chk.IsNil)
+	c.Assert(events, chk.HasLen, 4) // all of them
+	hooks := NewJobHooks("", `echo "$AZCOPY_HOOK_EVENT $AZCOPY_TRANSFER_SOURCE $AZCOPY_TRANSFER_ERROR_CODE" > `+out+`; cat >> `+out, events)
+
+	hooks.Fire(JobHookPayload{Event: EJobHookEvent.TransferFailed(), JobID: NewJobID(),
+		Transfer: &TransferDetail{Src: "/data/a.txt", TransferStatus: ETransferStatus.Failed(), ErrorCode: 404}}, &hookTestLogger{})
+	hooks.Close()
+
+	b, err := ioutil.ReadFile(out)
+	c.Assert(err, chk.IsNil)
+	lines := strings.SplitN(string(b), "\n", 2)
+	c.Assert(lines[0], chk.Equals, "TransferFailed /data/a.txt 404")
+	c.Assert(strings.Contains(lines[1], `"Src":"/data/a.txt"`), chk.Equals, true)
+}
+
+func (s *jobHooksSuite) TestNoHooksWithoutURLOrCommand(c *chk.C) {
+	c.Assert(NewJobHooks("", "", nil), chk.IsNil)
+	_, err := ParseJobHookEvents("JobStarted,Finished")
+	c.Assert(err, chk.NotNil)
+}
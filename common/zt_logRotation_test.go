@@ -0,0 +1,115 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package common
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	chk "gopkg.in/check.v1"
+)
+
+type logRotationSuite struct{}
+
+var _ = chk.Suite(&logRotationSuite{})
+
+func readGzipFile(c *chk.C, path string) string {
+	f, err := os.Open(path)
+	c.Assert(err, chk.IsNil)
+	defer f.Close()
+	r, err := gzip.NewReader(f)
+	c.Assert(err, chk.IsNil)
+	b, err := ioutil.ReadAll(r)
+	c.Assert(err, chk.IsNil)
+	return string(b)
+}
+
+func writeLines(c *chk.C, f *rotatingLogFile, lines ...string) {
+	for _, line := range lines {
+		_, err := f.Write([]byte(line + "\n"))
+		c.Assert(err, chk.IsNil)
+	}
+}
+
+func (s *logRotationSuite) TestRotatedSegmentsAreCompressed(c *chk.C) {
+	path := filepath.Join(c.MkDir(), "job.log")
+	f, err := openRotatingLogFile(path, 10, 0)
+	c.Assert(err, chk.IsNil)
+
+	// each line is 6 bytes, so a segment holds only one of them
+	writeLines(c, f, "line1", "line2", "line3")
+	c.Assert(f.Close(), chk.IsNil)
+
+	c.Assert(readGzipFile(c, path+".1.gz"), chk.Equals, "line1\n")
+	c.Assert(readGzipFile(c, path+".2.gz"), chk.Equals, "line2\n")
+	current, err := ioutil.ReadFile(path)
+	c.Assert(err, chk.IsNil)
+	c.Assert(string(current), chk.Equals, "line3\n")
+
+	_, err = os.Stat(path + ".1")
+	c.Assert(os.IsNotExist(err), chk.Equals, true)
+}
+
+func (s *logRotationSuite) TestOldestSegmentsAreDeletedOverMaxTotalSize(c *chk.C) {
+	path := filepath.Join(c.MkDir(), "job.log")
+	line := strings.Repeat("x", 99)
+	f, err := openRotatingLogFile(path, 100, 150)
+	c.Assert(err, chk.IsNil)
+
+	for i := 0; i < 6; i++ {
+		writeLines(c, f, line)
+		f.compressions.Wait() // so that the segments are deleted in a predictable order
+	}
+	c.Assert(f.Close(), chk.IsNil)
+
+	segments, err := f.segments()
+	c.Assert(err, chk.IsNil)
+	c.Assert(len(segments) > 0, chk.Equals, true)
+	c.Assert(segments[len(segments)-1].number, chk.Equals, 5)
+	total := int64(100)
+	for _, segment := range segments {
+		total += segment.size
+	}
+	c.Assert(total <= 150, chk.Equals, true)
+	_, err = os.Stat(path + ".1.gz")
+	c.Assert(os.IsNotExist(err), chk.Equals, true)
+}
+
+func (s *logRotationSuite) TestReopenedLogCarriesOnNumbering(c *chk.C) {
+	path := filepath.Join(c.MkDir(), "job.log")
+	f, err := openRotatingLogFile(path, 10, 0)
+	c.Assert(err, chk.IsNil)
+	writeLines(c, f, "line1", "line2")
+	c.Assert(f.Close(), chk.IsNil)
+
+	// a segment that an earlier run didn't get to compress
+	c.Assert(ioutil.WriteFile(path+".2", []byte("line2b\n"), DEFAULT_FILE_PERM), chk.IsNil)
+
+	f, err = openRotatingLogFile(path, 10, 0)
+	c.Assert(err, chk.IsNil)
+	writeLines(c, f, "line3")
+	c.Assert(f.Close(), chk.IsNil)
+
+	c.Assert(readGzipFile(c, path+".2.gz"), chk.Equals, "line2b\n")
+	c.Assert(readGzipFile(c, path+".3.gz"), chk.Equals, "line2\n")
+}
@@ -0,0 +1,137 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package common
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Azure/azure-pipeline-go/pipeline"
+	chk "gopkg.in/check.v1"
+)
+
+type loggerSuite struct{}
+
+var _ = chk.Suite(&loggerSuite{})
+
+func readLogLines(c *chk.C, folder string, jobID JobID) []string {
+	f, err := os.Open(filepath.Join(folder, jobID.String()+".log"))
+	c.Assert(err, chk.IsNil)
+	defer f.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	c.Assert(scanner.Err(), chk.IsNil)
+	return lines
+}
+
+func (s *loggerSuite) TestJsonLogHasOneRedactedEntryPerLine(c *chk.C) {
+	folder := c.MkDir()
+	jobID := NewJobID()
+	logger := NewJobLogger(jobID, ELogLevel.Info(), folder, "", JobLogSettings{Format: ELogFormat.Json()})
+	logger.OpenLog()
+
+	partNum, transferIndex := uint32(0), uint32(7)
+	LogWithFields(logger, pipeline.LogError, "ERR: [P#0-T#7] ", "upload failed\nat https://a/b?sig=secret", LogFields{
+		PartNumber:    &partNum,
+		TransferIndex: &transferIndex,
+		Source:        "/data/f.txt",
+		Destination:   "https://a/b?sig=secret",
+		HTTPMethod:    "PUT",
+		HTTPStatus:    503,
+		RequestID:     "rid",
+		RetryCount:    2,
+		ErrorCode:     "ServerBusy",
+	})
+	logger.Log(pipeline.LogDebug, "too verbose to be logged")
+	logger.CloseLog()
+
+	lines := readLogLines(c, folder, jobID)
+	c.Assert(lines, chk.HasLen, 5) // 3 lines about AzCopy and the OS, our entry, and the closing line
+
+	var entry map[string]interface{}
+	c.Assert(json.Unmarshal([]byte(lines[3]), &entry), chk.IsNil)
+	c.Assert(entry["timestamp"], chk.Not(chk.Equals), "")
+	delete(entry, "timestamp")
+	c.Assert(entry, chk.DeepEquals, map[string]interface{}{
+		"level":         "ERR",
+		"jobId":         jobID.String(),
+		"partNumber":    float64(0),
+		"transferIndex": float64(7),
+		"source":        "/data/f.txt",
+		"destination":   "https://a/b?sig=-REDACTED-",
+		"httpMethod":    "PUT",
+		"httpStatus":    float64(503),
+		"requestId":     "rid",
+		"retryCount":    float64(2),
+		"errorCode":     "ServerBusy",
+		"message":       "upload failed\nat https://a/b?sig=-REDACTED-", // the prefix is only for the text format
+	})
+
+	for _, line := range lines {
+		c.Assert(json.Valid([]byte(line)), chk.Equals, true)
+	}
+}
+
+func (s *loggerSuite) TestTextLogIsUnchangedByFields(c *chk.C) {
+	folder := c.MkDir()
+	jobID := NewJobID()
+	logger := NewJobLogger(jobID, ELogLevel.Info(), folder, "", JobLogSettings{Format: ELogFormat.Text()})
+	logger.OpenLog()
+
+	LogWithFields(logger, pipeline.LogError, "ERR: [P#0-T#7] ", "failed https://a/b?sig=secret", LogFields{HTTPStatus: 503})
+	logger.CloseLog()
+
+	lines := readLogLines(c, folder, jobID)
+	c.Assert(lines, chk.HasLen, 6)
+	c.Assert(strings.HasSuffix(lines[4], " ERR: [P#0-T#7] failed https://a/b?sig=-REDACTED-"), chk.Equals, true)
+}
+
+type unstructuredLogger struct {
+	messages []string
+}
+
+func (l *unstructuredLogger) ShouldLog(level pipeline.LogLevel) bool { return true }
+func (l *unstructuredLogger) Log(level pipeline.LogLevel, msg string) {
+	l.messages = append(l.messages, msg)
+}
+func (l *unstructuredLogger) Panic(err error) { panic(err) }
+
+func (s *loggerSuite) TestLogWithFieldsFallsBackToText(c *chk.C) {
+	logger := &unstructuredLogger{}
+	LogWithFields(logger, pipeline.LogInfo, "INFO: ", "hello", LogFields{HTTPStatus: 200})
+	c.Assert(logger.messages, chk.DeepEquals, []string{"INFO: hello"})
+}
+
+func (s *loggerSuite) TestLogFormatParsing(c *chk.C) {
+	var format LogFormat
+	c.Assert(format.Parse("json"), chk.IsNil)
+	c.Assert(format, chk.Equals, ELogFormat.Json())
+	c.Assert(format.Parse("TEXT"), chk.IsNil)
+	c.Assert(format, chk.Equals, ELogFormat.Text())
+	c.Assert(format.Parse("xml"), chk.NotNil)
+}
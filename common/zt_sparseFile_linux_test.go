@@ -0,0 +1,53 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package common
+
+import (
+	"os"
+	"path/filepath"
+
+	chk "gopkg.in/check.v1"
+)
+
+func (s *sparseFileSuite) TestGetSparseFileMap(c *chk.C) {
+	const size = 16 * 1024 * 1024
+
+	// given: a sparse file with data only in the middle
+	path := filepath.Join(c.MkDir(), "sparse")
+	f, err := os.Create(path)
+	c.Assert(err, chk.IsNil)
+	defer f.Close()
+	c.Assert(f.Truncate(size), chk.IsNil)
+	_, err = f.WriteAt([]byte("some data"), 8*1024*1024)
+	c.Assert(err, chk.IsNil)
+
+	// when
+	m, err := GetSparseFileMap(f, size)
+	if err != nil {
+		c.Skip("file system does not support SEEK_DATA/SEEK_HOLE: " + err.Error())
+	}
+
+	// then: the start and end are holes, but the middle isn't
+	c.Assert(m.IsHole(0, 4*1024*1024), chk.Equals, true)
+	c.Assert(m.IsHole(8*1024*1024, 4096), chk.Equals, false)
+	c.Assert(m.IsHole(12*1024*1024, 4*1024*1024), chk.Equals, true)
+	c.Assert(m.DataSize() < size, chk.Equals, true)
+}
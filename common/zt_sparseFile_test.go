@@ -0,0 +1,108 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package common
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"io"
+
+	chk "gopkg.in/check.v1"
+)
+
+type sparseFileSuite struct{}
+
+var _ = chk.Suite(&sparseFileSuite{})
+
+func (s *sparseFileSuite) TestSparseFileMap_IsHole(c *chk.C) {
+	m := NewSparseFileMap([]FileRange{{Offset: 1024, Length: 1024}, {Offset: 8192, Length: 512}})
+
+	c.Assert(m.IsHole(0, 1024), chk.Equals, true)     // before all data
+	c.Assert(m.IsHole(0, 1025), chk.Equals, false)    // overlaps start of first range
+	c.Assert(m.IsHole(2047, 10), chk.Equals, false)   // overlaps end of first range
+	c.Assert(m.IsHole(2048, 6144), chk.Equals, true)  // exactly between the ranges
+	c.Assert(m.IsHole(4096, 8192), chk.Equals, false) // spans the second range
+	c.Assert(m.IsHole(8704, 4096), chk.Equals, true)  // after all data
+	c.Assert(m.DataSize(), chk.Equals, int64(1536))
+
+	// a nil map knows nothing, so there must be data everywhere
+	var unknown *SparseFileMap
+	c.Assert(unknown.IsHole(0, 1024), chk.Equals, false)
+}
+
+func (s *sparseFileSuite) TestZeroChunkReader(c *chk.C) {
+	const length = 3*1024*1024 + 7 // bigger than our buffer of zeros, and not a multiple of it
+	r := NewZeroChunkReader(length)
+
+	c.Assert(r.HasPrefetchedEntirelyZeros(), chk.Equals, true)
+	c.Assert(r.Length(), chk.Equals, int64(length))
+
+	data, err := io.ReadAll(r)
+	c.Assert(err, chk.IsNil)
+	c.Assert(data, chk.DeepEquals, make([]byte, length))
+
+	// can be re-read after seeking, as it would be on a retry
+	_, err = r.Seek(0, io.SeekStart)
+	c.Assert(err, chk.IsNil)
+	data, err = io.ReadAll(r)
+	c.Assert(err, chk.IsNil)
+	c.Assert(len(data), chk.Equals, length)
+
+	// hashes the same as real zeros
+	h := md5.New()
+	r.WriteBufferTo(h)
+	expected := md5.Sum(make([]byte, length))
+	c.Assert(h.Sum(nil), chk.DeepEquals, expected[:])
+}
+
+func (s *sparseFileSuite) TestChunkedFileWriter_ZeroChunks(c *chk.C) {
+	const chunkSize = 1024
+	const numChunks = 4
+
+	// given: a file where only chunk 1 has data
+	original := make([]byte, chunkSize*numChunks)
+	for i := chunkSize; i < 2*chunkSize; i++ {
+		original[i] = byte(i)
+	}
+	dest := &closeableBuffer{Buffer: &bytes.Buffer{}} // not a real file, so zeros will be written in full
+
+	// when: we enqueue the other chunks as zero chunks, out of order
+	ctx := context.Background()
+	w := NewChunkedFileWriter(ctx, NewMultiSizeSlicePool(chunkSize), NewCacheLimiter(chunkSize*numChunks), nullChunkStatusLogger{},
+		dest, numChunks, 0, EHashValidationOption.FailIfDifferent(), true)
+	for _, i := range []int64{3, 1, 0, 2} {
+		id := NewChunkID("test", i*chunkSize, chunkSize)
+		c.Assert(w.WaitToScheduleChunk(ctx, id, chunkSize), chk.IsNil)
+		if i == 1 {
+			c.Assert(w.EnqueueChunk(ctx, id, chunkSize, bytes.NewReader(original[chunkSize:2*chunkSize]), false), chk.IsNil)
+		} else {
+			c.Assert(w.EnqueueZeroChunk(ctx, id, chunkSize), chk.IsNil)
+		}
+	}
+	hash, err := w.Flush(ctx)
+
+	// then: the file and hash are just as if the zeros had been downloaded
+	c.Assert(err, chk.IsNil)
+	c.Assert(dest.Bytes(), chk.DeepEquals, original)
+	expectedHash := md5.Sum(original)
+	c.Assert(hash, chk.DeepEquals, expectedHash[:])
+}
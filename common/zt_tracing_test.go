@@ -0,0 +1,167 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package common
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	chk "gopkg.in/check.v1"
+)
+
+type tracingSuite struct{}
+
+var _ = chk.Suite(&tracingSuite{})
+
+// withTestTracerProvider enables tracing, with the given provider, for as long as the test runs
+func withTestTracerProvider(tp *sdktrace.TracerProvider, test func()) {
+	globalTracerProvider.Store(tp)
+	defer globalTracerProvider.Store((*sdktrace.TracerProvider)(nil))
+	test()
+}
+
+func (s *tracingSuite) TestSpansAreInTheTraceOfTheJob(c *chk.C) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := newTracerProvider(exporter, "test")
+	jobID := NewJobID()
+
+	withTestTracerProvider(tp, func() {
+		ctx, job := StartJobSpan(context.Background(), jobID, "job")
+		_, transfer := Tracer().Start(ctx, "transfer")
+		transfer.SetAttributes(attribute.Int64("azcopy.size", 1024))
+		transfer.SetStatus(codes.Error, "boom")
+		transfer.End()
+		job.End()
+
+		// a span that isn't started in the trace of a job is in a trace of its own
+		_, other := Tracer().Start(context.Background(), "other")
+		other.End()
+	})
+	c.Assert(tp.ForceFlush(context.Background()), chk.IsNil)
+
+	spans := map[string]tracetest.SpanStub{}
+	for _, span := range exporter.GetSpans() {
+		spans[span.Name] = span
+	}
+	c.Assert(spans, chk.HasLen, 3)
+
+	// the trace ID is the job ID, and the transfer is a child of the job
+	c.Assert(spans["job"].SpanContext.TraceID().String(), chk.Equals, strings.Replace(jobID.String(), "-", "", -1))
+	c.Assert(spans["job"].Parent.IsValid(), chk.Equals, false)
+	c.Assert(spans["transfer"].SpanContext.TraceID(), chk.Equals, spans["job"].SpanContext.TraceID())
+	c.Assert(spans["transfer"].Parent.SpanID(), chk.Equals, spans["job"].SpanContext.SpanID())
+	c.Assert(spans["other"].SpanContext.TraceID(), chk.Not(chk.Equals), spans["job"].SpanContext.TraceID())
+
+	c.Assert(spans["transfer"].Status.Code, chk.Equals, codes.Error)
+	c.Assert(spans["transfer"].Status.Description, chk.Equals, "boom")
+	c.Assert(spans["job"].Attributes, chk.DeepEquals, []attribute.KeyValue{attribute.String("azcopy.job_id", jobID.String())})
+	name, _ := spans["job"].Resource.Set().Value("service.name")
+	c.Assert(name.AsString(), chk.Equals, "test")
+}
+
+// testCollector stands in for an OpenTelemetry collector, and keeps the headers of what it's sent
+type testCollector struct {
+	lock    sync.Mutex
+	paths   []string
+	headers []http.Header
+}
+
+func (tc *testCollector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	tc.lock.Lock()
+	defer tc.lock.Unlock()
+	tc.paths = append(tc.paths, r.URL.Path)
+	tc.headers = append(tc.headers, r.Header.Clone())
+}
+
+func (s *tracingSuite) TestSpansAreExportedToTheEndpoint(c *chk.C) {
+	collector := &testCollector{}
+	server := httptest.NewServer(collector)
+	defer server.Close()
+
+	exporter, err := newOtlpExporter(TracingSettings{Endpoint: server.URL + "/custom/traces", Headers: map[string]string{"Authorization": "Bearer x"}, ServiceName: "test"})
+	c.Assert(err, chk.IsNil)
+	tp := newTracerProvider(exporter, "test")
+	_, span := tp.Tracer(tracerName).Start(context.Background(), "job")
+	span.End()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	c.Assert(tp.ForceFlush(ctx), chk.IsNil)
+
+	collector.lock.Lock()
+	defer collector.lock.Unlock()
+	c.Assert(collector.paths, chk.DeepEquals, []string{"/custom/traces"})
+	c.Assert(collector.headers[0].Get("Authorization"), chk.Equals, "Bearer x")
+}
+
+func (s *tracingSuite) TestSpansAreNotRecordedWhenTracingIsOff(c *chk.C) {
+	_, span := StartJobSpan(context.Background(), NewJobID(), "job")
+	c.Assert(span.IsRecording(), chk.Equals, false)
+	c.Assert(trace.SpanFromContext(context.Background()).IsRecording(), chk.Equals, false)
+	span.End()
+}
+
+func (s *tracingSuite) TestTracingSettingsFromEnvironment(c *chk.C) {
+	getenv := func(vars map[string]string) func(EnvironmentVariable) string {
+		return func(v EnvironmentVariable) string { return vars[v.Name] }
+	}
+
+	_, enabled, err := TracingSettingsFromEnvironment(getenv(nil))
+	c.Assert(err, chk.IsNil)
+	c.Assert(enabled, chk.Equals, false)
+
+	settings, enabled, err := TracingSettingsFromEnvironment(getenv(map[string]string{
+		"OTEL_EXPORTER_OTLP_ENDPOINT": "http://localhost:4318/",
+		"OTEL_EXPORTER_OTLP_HEADERS":  "api-key=a%3Db, x-tenant = t1",
+	}))
+	c.Assert(err, chk.IsNil)
+	c.Assert(enabled, chk.Equals, true)
+	c.Assert(settings.Endpoint, chk.Equals, "http://localhost:4318/v1/traces")
+	c.Assert(settings.ServiceName, chk.Equals, "azcopy")
+	c.Assert(settings.Headers, chk.DeepEquals, map[string]string{"api-key": "a=b", "x-tenant": "t1"})
+
+	// the endpoint that is specific to traces is used as it is, and wins over the general one
+	settings, _, err = TracingSettingsFromEnvironment(getenv(map[string]string{
+		"OTEL_EXPORTER_OTLP_ENDPOINT":        "http://localhost:4318",
+		"OTEL_EXPORTER_OTLP_TRACES_ENDPOINT": "https://collector/traces",
+		"OTEL_SERVICE_NAME":                  "backup",
+	}))
+	c.Assert(err, chk.IsNil)
+	c.Assert(settings.Endpoint, chk.Equals, "https://collector/traces")
+	c.Assert(settings.ServiceName, chk.Equals, "backup")
+
+	_, _, err = TracingSettingsFromEnvironment(getenv(map[string]string{"OTEL_EXPORTER_OTLP_ENDPOINT": "localhost:4318"}))
+	c.Assert(err, chk.NotNil)
+	_, _, err = TracingSettingsFromEnvironment(getenv(map[string]string{
+		"OTEL_EXPORTER_OTLP_ENDPOINT": "http://localhost:4318",
+		"OTEL_EXPORTER_OTLP_HEADERS":  "novalue",
+	}))
+	c.Assert(err, chk.NotNil)
+}
@@ -0,0 +1,46 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package common
+
+import (
+	chk "gopkg.in/check.v1"
+)
+
+type transferErrorGroupsSuite struct{}
+
+var _ = chk.Suite(&transferErrorGroupsSuite{})
+
+func (s *transferErrorGroupsSuite) TestFailuresAreGroupedByError(c *chk.C) {
+	details := []TransferDetail{
+		{TransferStatus: ETransferStatus.Failed(), ErrorCode: 403, ErrorServiceCode: "AuthorizationFailure"},
+		{TransferStatus: ETransferStatus.Success()},
+		{TransferStatus: ETransferStatus.Failed(), ErrorCode: 404, ErrorServiceCode: "BlobNotFound"},
+		{TransferStatus: ETransferStatus.BlobTierFailure(), ErrorCode: 404, ErrorServiceCode: "BlobNotFound"},
+		{TransferStatus: ETransferStatus.Failed()},
+	}
+
+	c.Assert(GroupFailuresByError(details), chk.DeepEquals, []TransferErrorGroup{
+		{ErrorCode: 404, ErrorServiceCode: "BlobNotFound", Count: 2},
+		{ErrorCode: 0, ErrorServiceCode: "", Count: 1},
+		{ErrorCode: 403, ErrorServiceCode: "AuthorizationFailure", Count: 1},
+	})
+	c.Assert(GroupFailuresByError(details[1:2]), chk.HasLen, 0)
+}
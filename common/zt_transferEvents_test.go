@@ -0,0 +1,95 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package common
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+
+	chk "gopkg.in/check.v1"
+)
+
+type transferEventsSuite struct{}
+
+var _ = chk.Suite(&transferEventsSuite{})
+
+type failingWriter struct {
+	writes int
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	w.writes++
+	return 0, errors.New("broken pipe")
+}
+
+func (s *transferEventsSuite) TestWriterStopsAfterFailure(c *chk.C) {
+	out := &failingWriter{}
+	w := NewTransferEventWriter(out)
+
+	c.Assert(w.Write(TransferEvent{Event: "completed"}), chk.IsNil)
+	w.Flush()
+	// the failure is reported by the next write, only once, and nothing more is written
+	c.Assert(w.Write(TransferEvent{Event: "completed"}), chk.ErrorMatches, ".*broken pipe")
+	c.Assert(w.Write(TransferEvent{Event: "completed"}), chk.IsNil)
+	c.Assert(w.Close(), chk.IsNil)
+	c.Assert(out.writes, chk.Equals, 1)
+
+	var nilWriter *TransferEventWriter
+	c.Assert(nilWriter.Write(TransferEvent{}), chk.IsNil)
+	nilWriter.Flush()
+	c.Assert(nilWriter.Close(), chk.IsNil)
+}
+
+// blockedWriter doesn't write anything until it's unblocked
+type blockedWriter struct {
+	unblock chan struct{}
+	out     bytes.Buffer
+}
+
+func (w *blockedWriter) Write(p []byte) (int, error) {
+	<-w.unblock
+	return w.out.Write(p)
+}
+
+func (s *transferEventsSuite) TestEventsAreDroppedRatherThanHoldingUpTheJob(c *chk.C) {
+	out := &blockedWriter{unblock: make(chan struct{})}
+	w := NewTransferEventWriter(out)
+
+	// the writer takes one event off the queue, and waits on it, so one more than the queue holds fits
+	errs := 0
+	for i := 0; i < transferEventQueueSize+11; i++ {
+		if w.Write(TransferEvent{Event: "completed"}) != nil {
+			errs++
+		}
+	}
+	c.Assert(w.Dropped() >= 10 && w.Dropped() <= 11, chk.Equals, true)
+	c.Assert(errs, chk.Equals, 1) // the dropping is only reported once
+
+	close(out.unblock)
+	c.Assert(w.Close(), chk.ErrorMatches, ".* transfer events were dropped.*")
+	lines := strings.Count(out.out.String(), "\n")
+	c.Assert(uint64(lines)+w.Dropped(), chk.Equals, uint64(transferEventQueueSize+11))
+
+	// nothing is written once it's closed
+	c.Assert(w.Write(TransferEvent{Event: "completed"}), chk.IsNil)
+	c.Assert(strings.Count(out.out.String(), "\n"), chk.Equals, lines)
+}
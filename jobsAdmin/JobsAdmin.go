@@ -149,7 +149,11 @@ func initJobsAdmin(appCtx context.Context, concurrency ste.ConcurrencySettings,
 	// the tuning results and, in the worst case, leads to "completion" of tuning before any traffic has been sent.
 	ja.concurrencyTuner = ja.createConcurrencyTuner()
 
+	// all jobs share one scheduler, so that they share the workers, the RAM and the bandwidth fairly
+	ja.scheduler = ste.NewJobScheduler(concurrency, ja.concurrencyTuner, cpuMon, pacer, ja.cacheLimiter, ja.logger)
+
 	JobsAdmin = ja
+	ja.registerMetrics(ste.Metrics)
 
 	// Spin up slice pool pruner
 	go ja.slicePoolPruneLoop()
@@ -236,7 +240,6 @@ type jobsAdmin struct {
 	atomicSuccessfulBytesInActiveFiles int64
 	atomicBytesTransferredWhileTuning  int64
 	atomicTuningEndSeconds             int64
-	atomicCurrentMainPoolSize          int32 // align 64 bit integers for 32 bit arch
 	concurrency                        ste.ConcurrencySettings
 	logger                             common.ILoggerCloser
 	jobIDToJobMgr                      jobIDToJobMgr // Thread-safe map from each JobID to its JobInfo
@@ -249,6 +252,7 @@ type jobsAdmin struct {
 	cacheLimiter                common.CacheLimiter
 	fileCountLimiter            common.CacheLimiter
 	concurrencyTuner   ste.ConcurrencyTuner
+	scheduler          *ste.JobScheduler
 	commandLineMbpsCap float64
 	provideBenchmarkResults bool
 	cpuMonitor              common.CPUMonitor
@@ -292,7 +296,7 @@ func (ja *jobsAdmin) JobMgrEnsureExists(jobID common.JobID,
 	return ja.jobIDToJobMgr.EnsureExists(jobID,
 		func() ste.IJobMgr {
 			// Return existing or new IJobMgr to caller
-			return ste.NewJobMgr(ja.concurrency, jobID, ja.appCtx, ja.cpuMonitor, level, commandString, ja.logDir, ja.concurrencyTuner, ja.pacer, ja.slicePool, ja.cacheLimiter, ja.fileCountLimiter, ja.jobLogger, false)
+			return ste.NewJobMgr(ja.concurrency, jobID, ja.appCtx, ja.cpuMonitor, level, commandString, ja.logDir, ja.concurrencyTuner, ja.pacer, ja.slicePool, ja.fileCountLimiter, ja.jobLogger, ja.scheduler, false)
 		})
 }
 
@@ -442,7 +446,7 @@ func (ja *jobsAdmin) Panic(err error)                         { ja.logger.Panic(
 func (ja *jobsAdmin) CloseLog()                               { ja.logger.CloseLog() }
 
 func (ja *jobsAdmin) CurrentMainPoolSize() int {
-	return int(atomic.LoadInt32(&ja.atomicCurrentMainPoolSize))
+	return ja.scheduler.CurrentMainPoolSize()
 }
 
 func (ja *jobsAdmin) slicePoolPruneLoop() {
@@ -178,6 +178,9 @@ func ExecuteNewCopyJobPartOrder(order common.CopyJobPartOrderRequest) common.Cop
 		ste.InMemoryTransitJobState{
 			CredentialInfo:          order.CredentialInfo,
 			S2SSourceCredentialType: order.S2SSourceCredentialType,
+			TransferEvents:          order.TransferEvents,
+			Hooks:                   order.Hooks,
+			PageDiffBaselineSAS:     order.PageDiffBaselineSAS,
 		})
 	// Supply no plan MMF because we don't have one, and AddJobPart will create one on its own.
 	jm.AddJobPart(order.PartNum, jppfn, nil, order.SourceRoot.SAS, order.DestinationRoot.SAS, true, nil) // Add this part to the Job and schedule its transfers
@@ -397,7 +400,8 @@ func ResumeJobOrder(req common.ResumeJobRequest) common.CancelPauseResumeRespons
 		// Get credential info from RPC request, and set in InMemoryTransitJobState.
 		jm.SetInMemoryTransitJobState(
 			ste.InMemoryTransitJobState{
-				CredentialInfo: req.CredentialInfo,
+				CredentialInfo:      req.CredentialInfo,
+				PageDiffBaselineSAS: req.PageDiffBaselineSAS,
 			})
 
 		jpp0.SetJobStatus(common.EJobStatus.InProgress())
@@ -685,6 +689,11 @@ func ListJobTransfers(r common.ListJobTransfersRequest) common.ListJobTransfersR
 		JobID:   r.JobID,
 		Details: []common.TransferDetail{},
 	}
+	// why the transfers failed is kept apart from the plan, since it's only known once they have
+	transferErrors, err := ste.ReadTransferErrors(r.JobID)
+	if err != nil {
+		jm.Log(pipeline.LogWarning, "Failed to read the errors of the job's failed transfers: "+err.Error())
+	}
 	for partNum := ste.PartNumber(0); true; partNum++ {
 		jpm, found := jm.JobPartMgr(partNum)
 		if !found {
@@ -712,10 +721,16 @@ func ListJobTransfers(r common.ListJobTransfersRequest) common.ListJobTransfersR
 			}
 			// getting source and destination of a transfer at index index for given jobId and part number.
 			src, dst, isFolder := jpp.TransferSrcDstStrings(t)
-			ljt.Details = append(ljt.Details,
-				common.TransferDetail{Src: src, Dst: dst, IsFolderProperties: isFolder, TransferStatus: transferEntry.TransferStatus(), ErrorCode: transferEntry.ErrorCode()})
+			detail := common.TransferDetail{Src: src, Dst: dst, IsFolderProperties: isFolder, TransferStatus: transferEntry.TransferStatus(), ErrorCode: transferEntry.ErrorCode()}
+			if detail.TransferStatus.DidFail() {
+				if e, ok := transferErrors[ste.TransferErrorKey{PartNumber: partNum, TransferIndex: t}]; ok {
+					detail.ErrorServiceCode, detail.ErrorMessage = e.ErrorCode, e.Message
+				}
+			}
+			ljt.Details = append(ljt.Details, detail)
 		}
 	}
+	ljt.FailuresByError = common.GroupFailuresByError(ljt.Details)
 	return ljt
 }
 
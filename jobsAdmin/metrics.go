@@ -0,0 +1,114 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package jobsAdmin
+
+import (
+	"sort"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+)
+
+// registerMetrics adds the metrics that describe the jobs and the engine to the registry.
+// They are computed from the current state when they are scraped, so they cost nothing when nobody scrapes them.
+func (ja *jobsAdmin) registerMetrics(registry prometheus.Registerer) {
+	registry.MustRegister(
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "azcopy_concurrency",
+			Help: "Current size of the pool of workers that send the chunks of all jobs to the network.",
+		}, func() float64 { return float64(ja.CurrentMainPoolSize()) }),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "azcopy_pacer_target_bytes_per_second",
+			Help: "Throughput that the pacer is aiming for, in bytes per second. Zero means that the throughput isn't capped.",
+		}, func() float64 { return float64(ja.pacer.TargetBytesPerSecond()) }),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "azcopy_chunk_ram_bytes",
+			Help: "RAM used by the chunks that are being read, sent or written.",
+		}, func() float64 { return float64(ja.cacheLimiter.Value()) }),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "azcopy_chunk_ram_limit_bytes",
+			Help: "Maximum RAM that may be used by chunks. It can be set with the AZCOPY_BUFFER_GB environment variable.",
+		}, func() float64 { return float64(ja.cacheLimiter.Limit()) }),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Name: "azcopy_bytes_over_wire_total",
+			Help: "Bytes sent or received over the network by all jobs, including the retries.",
+		}, func() float64 { return float64(ja.BytesOverWire()) }),
+		&jobMetricsCollector{ja: ja},
+	)
+}
+
+var (
+	jobBytesTransferredDesc = prometheus.NewDesc("azcopy_job_bytes_transferred_total",
+		"Bytes of the files of the job that have been transferred successfully.", []string{"job_id"}, nil)
+	jobBytesExpectedDesc = prometheus.NewDesc("azcopy_job_bytes_expected",
+		"Bytes of the files of the job that have been scanned so far, and are expected to be transferred.", []string{"job_id"}, nil)
+	jobTransfersDesc = prometheus.NewDesc("azcopy_job_transfers",
+		"Transfers of the job, by status. Those that aren't done yet are pending.", []string{"job_id", "status"}, nil)
+)
+
+// jobMetricsCollector computes the metrics of each job that this process knows of, when they are scraped
+type jobMetricsCollector struct {
+	ja *jobsAdmin
+}
+
+func (c *jobMetricsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- jobBytesTransferredDesc
+	ch <- jobBytesExpectedDesc
+	ch <- jobTransfersDesc
+}
+
+func (c *jobMetricsCollector) Collect(ch chan<- prometheus.Metric) {
+	jobIDs := c.ja.JobIDs()
+	sort.Slice(jobIDs, func(i, j int) bool { return jobIDs[i].String() < jobIDs[j].String() })
+
+	for _, jobID := range jobIDs {
+		jm, found := c.ja.JobMgr(jobID)
+		if !found {
+			continue
+		}
+		// peek, so as not to take the failed and skipped transfers from the progress reporting
+		summary := jm.PeekJobSummary()
+		summary.TotalBytesTransferred += jm.SuccessfulBytesInActiveFiles()
+		collectJobMetrics(ch, jobID.String(), summary)
+	}
+}
+
+func collectJobMetrics(ch chan<- prometheus.Metric, jobID string, s common.ListJobSummaryResponse) {
+	ch <- prometheus.MustNewConstMetric(jobBytesTransferredDesc, prometheus.CounterValue, float64(s.TotalBytesTransferred), jobID)
+	ch <- prometheus.MustNewConstMetric(jobBytesExpectedDesc, prometheus.GaugeValue, float64(s.TotalBytesExpected), jobID)
+
+	pending := int64(s.TotalTransfers) - int64(s.TransfersCompleted) - int64(s.TransfersFailed) - int64(s.TransfersSkipped)
+	if pending < 0 {
+		pending = 0
+	}
+	for _, t := range []struct {
+		status string
+		count  float64
+	}{
+		{"completed", float64(s.TransfersCompleted)},
+		{"failed", float64(s.TransfersFailed)},
+		{"skipped", float64(s.TransfersSkipped)},
+		{"pending", float64(pending)},
+	} {
+		ch <- prometheus.MustNewConstMetric(jobTransfersDesc, prometheus.GaugeValue, t.count, jobID, t.status)
+	}
+}
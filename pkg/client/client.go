@@ -0,0 +1,318 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package client runs AzCopy's transfers inside a Go program, without shelling out to the azcopy binary.
+//
+// Each call runs a job in the transfer engine of the current process, as the equivalent azcopy command would,
+// and returns once the job is done. Jobs may run concurrently, and share the engine's connections, memory and
+// bandwidth according to their priority.
+//
+// Authentication works as in the CLI: with SAS tokens in the URLs, with the AZCOPY_AUTO_LOGIN_TYPE environment
+// variable and its companions, or with the cached credentials of 'azcopy login'. The process is never exited;
+// the messages that the CLI would print go to the lifecycle manager of the client, or to the OnMessage callback of a job.
+package client
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Azure/azure-storage-azcopy/v10/cmd"
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+	"github.com/Azure/azure-storage-azcopy/v10/jobsAdmin"
+)
+
+// Options configure the transfer engine. Since there is one engine per process, all the clients of a process must
+// use the same options.
+type Options struct {
+	// AppPathFolder holds the files that AzCopy keeps between runs, like the cached login.
+	// Defaults to .azcopy in the user's home directory, like the CLI.
+	AppPathFolder string
+
+	// LogPathFolder and JobPlanFolder default to AppPathFolder and its plans subfolder
+	LogPathFolder string
+	JobPlanFolder string
+
+	// CapMbps caps the throughput of all jobs together, in megabits per second. Zero means no cap.
+	CapMbps float64
+
+	// ProgressInterval is how often OnProgress is called. Defaults to 2 seconds.
+	ProgressInterval time.Duration
+
+	// MetricsListenAddress, if set, is where the engine serves Prometheus metrics, e.g. ":9090".
+	// They are served at /metrics, for as long as the process runs.
+	MetricsListenAddress string
+
+	// LogFormat of the logs. Json writes one JSON object per line, for log pipelines that can't parse the text format.
+	LogFormat common.LogFormat
+
+	// LogMaxSize is the size, in bytes, at which a log is rotated, and its full segment compressed with gzip.
+	// LogMaxTotalSize caps the size of a log and its segments together. Zero means no rotation, and no cap.
+	LogMaxSize      int64
+	LogMaxTotalSize int64
+
+	// LifecycleMgr receives the messages of the client's jobs and listings, such as warnings. It's never asked to exit.
+	// If it's nil, the messages are dropped, unless OnMessage is set. The warnings that the scanning of a source gives
+	// regardless of the job, such as those about skipped files, go to the lifecycle manager of the process, common.GetLifecycleMgr().
+	LifecycleMgr common.LifecycleMgr
+}
+
+// Client runs jobs in the transfer engine of the current process
+type Client struct {
+	progressInterval time.Duration
+	lcm              common.LifecycleMgr
+}
+
+const defaultProgressInterval = 2 * time.Second
+
+// New starts the transfer engine, if it isn't running yet
+func New(options Options) (*Client, error) {
+	if options.AppPathFolder == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+		options.AppPathFolder = filepath.Join(home, ".azcopy")
+	}
+	if options.LogPathFolder == "" {
+		options.LogPathFolder = options.AppPathFolder
+	}
+	if options.JobPlanFolder == "" {
+		options.JobPlanFolder = filepath.Join(options.AppPathFolder, "plans")
+	}
+	for _, folder := range []string{options.AppPathFolder, options.LogPathFolder, options.JobPlanFolder} {
+		if err := os.MkdirAll(folder, os.ModeDir|os.ModePerm); err != nil {
+			return nil, err
+		}
+	}
+	if options.ProgressInterval <= 0 {
+		options.ProgressInterval = defaultProgressInterval
+	}
+
+	err := cmd.InitInProcess(cmd.InProcessSettings{
+		AppPathFolder:        options.AppPathFolder,
+		LogPathFolder:        options.LogPathFolder,
+		JobPlanFolder:        options.JobPlanFolder,
+		CapMbps:              options.CapMbps,
+		MetricsListenAddress: options.MetricsListenAddress,
+		LogSettings: common.JobLogSettings{
+			Format:       options.LogFormat,
+			MaxSize:      options.LogMaxSize,
+			MaxTotalSize: options.LogMaxTotalSize,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Client{progressInterval: options.ProgressInterval, lcm: options.LifecycleMgr}, nil
+}
+
+// Progress is a snapshot of a running job
+type Progress struct {
+	JobID  common.JobID
+	Status common.JobStatus
+
+	TotalTransfers     uint32
+	TransfersCompleted uint32
+	TransfersFailed    uint32
+	TransfersSkipped   uint32
+
+	// BytesTransferred doesn't count retries and failed transfers, whereas BytesOverWire does
+	BytesTransferred uint64
+	BytesExpected    uint64
+	BytesOverWire    uint64
+	PercentComplete  float32
+}
+
+// TransferResult is the outcome of one file, blob or folder of a job
+type TransferResult struct {
+	Source      string
+	Destination string
+	IsFolder    bool
+	Status      common.TransferStatus
+
+	// ErrorCode is the HTTP status code of a failed transfer, if it failed on a request,
+	// and ErrorServiceCode is the error code given by the service, e.g. AuthorizationPermissionMismatch
+	ErrorCode        int32
+	ErrorServiceCode string
+
+	// ErrorMessage says why the transfer failed
+	ErrorMessage string
+}
+
+// JobResult is the outcome of a job
+type JobResult struct {
+	// JobID is empty if nothing needed to be done, e.g. when a sync finds that the destination is already in sync
+	JobID    common.JobID
+	Status   common.JobStatus
+	Progress Progress
+
+	// Transfers lists each of the job's transfers, successful or not
+	Transfers []TransferResult
+
+	// Message is the final message that the CLI would have printed, when there's no job
+	Message string
+}
+
+// JobOptions apply to all the calls that run a job
+type JobOptions struct {
+	// LogLevel of the job's log. The default, None, doesn't write a log.
+	LogLevel common.LogLevel
+
+	// OnProgress, if set, is called periodically while the job runs, and once it's done
+	OnProgress func(Progress)
+
+	// OnMessage, if set, receives the messages that the CLI would have printed, such as warnings
+	OnMessage func(string)
+
+	// TransferEvents, if set, is a file or named pipe to which a line of JSON is appended as each transfer is done, like --transfer-events
+	TransferEvents string
+
+	// HookURL and HookCommand, if set, are notified or run as the job starts and ends and as transfers fail, like --hook-url and --hook-command.
+	// HookEvents chooses the events, and defaults to all of them.
+	HookURL     string
+	HookCommand string
+	HookEvents  []common.JobHookEvent
+}
+
+// SchedulingOptions set how a job shares the transfer engine with other jobs of the process
+type SchedulingOptions struct {
+	// Priority defaults to Normal. A High priority job gets twice the share of a Normal one, which gets twice that of a Low one.
+	Priority common.JobPriority
+
+	// MaxConcurrency caps the concurrent requests of the job. Zero means no cap.
+	MaxConcurrency int
+
+	// CapMbps caps the throughput of the job, in megabits per second. Zero means no cap.
+	CapMbps float64
+}
+
+// Copy copies source to destination, like 'azcopy copy'
+func (c *Client) Copy(ctx context.Context, source string, destination string, options CopyOptions) (JobResult, error) {
+	return c.run(ctx, options.JobOptions, func() (cmd.InProcessJob, error) {
+		return cmd.RunCopy(c.lcm, options.job(source, destination))
+	})
+}
+
+// Sync makes destination match source, like 'azcopy sync'
+func (c *Client) Sync(ctx context.Context, source string, destination string, options SyncOptions) (JobResult, error) {
+	return c.run(ctx, options.JobOptions, func() (cmd.InProcessJob, error) {
+		return cmd.RunSync(c.lcm, options.job(source, destination))
+	})
+}
+
+// Remove deletes the target, like 'azcopy remove'
+func (c *Client) Remove(ctx context.Context, target string, options RemoveOptions) (JobResult, error) {
+	return c.run(ctx, options.JobOptions, func() (cmd.InProcessJob, error) {
+		return cmd.RunRemove(c.lcm, options.job(target))
+	})
+}
+
+// ListedObject is a file, blob or folder found by List
+type ListedObject = cmd.ListedObject
+
+// List calls handler with each object in a container, directory or account, like 'azcopy list'.
+// If handler returns an error, the listing stops with that error.
+func (c *Client) List(ctx context.Context, resource string, options ListOptions, handler func(ListedObject) error) error {
+	return cmd.ListObjects(ctx, c.lcm, options.list(resource), handler)
+}
+
+// run enumerates the job, then follows it in the STE until it is done. If ctx is done first, the job is cancelled.
+// (Since the enumeration isn't cancellable, a job that is cancelled while it is being enumerated is cancelled afterwards.)
+func (c *Client) run(ctx context.Context, options JobOptions, start func() (cmd.InProcessJob, error)) (JobResult, error) {
+	started, err := start()
+	if err != nil {
+		return JobResult{}, err
+	}
+	defer started.Close()
+	if !started.JobStarted {
+		return JobResult{Status: common.EJobStatus.Completed(), Message: started.Message}, ctx.Err()
+	}
+
+	ticker := time.NewTicker(c.progressInterval)
+	defer ticker.Stop()
+	var cancelErr error
+	for {
+		summary := jobsAdmin.GetJobSummary(started.JobID)
+		if summary.ErrorMsg != "" {
+			return JobResult{JobID: started.JobID}, errors.New(summary.ErrorMsg)
+		}
+		progress := progressFromSummary(summary)
+		if options.OnProgress != nil {
+			options.OnProgress(progress)
+		}
+		if summary.JobStatus.IsJobDone() {
+			return JobResult{
+				JobID:     started.JobID,
+				Status:    summary.JobStatus,
+				Progress:  progress,
+				Transfers: listTransfers(started.JobID),
+			}, cancelErr
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			if cancelErr == nil {
+				cancelErr = ctx.Err()
+				response := jobsAdmin.CancelPauseJobOrder(started.JobID, common.EJobStatus.Cancelling())
+				if !response.CancelledPauseResumed {
+					return JobResult{JobID: started.JobID}, errors.New(response.ErrorMsg)
+				}
+			}
+			// from now on, just wait for the cancellation to complete
+			ctx = context.Background()
+		}
+	}
+}
+
+func progressFromSummary(summary common.ListJobSummaryResponse) Progress {
+	return Progress{
+		JobID:              summary.JobID,
+		Status:             summary.JobStatus,
+		TotalTransfers:     summary.TotalTransfers,
+		TransfersCompleted: summary.TransfersCompleted,
+		TransfersFailed:    summary.TransfersFailed,
+		TransfersSkipped:   summary.TransfersSkipped,
+		BytesTransferred:   summary.TotalBytesTransferred,
+		BytesExpected:      summary.TotalBytesExpected,
+		BytesOverWire:      summary.BytesOverWire,
+		PercentComplete:    summary.PercentComplete,
+	}
+}
+
+func listTransfers(jobID common.JobID) []TransferResult {
+	response := jobsAdmin.ListJobTransfers(common.ListJobTransfersRequest{JobID: jobID, OfStatus: common.ETransferStatus.All()})
+	results := make([]TransferResult, 0, len(response.Details))
+	for _, d := range response.Details {
+		results = append(results, TransferResult{
+			Source:           d.Src,
+			Destination:      d.Dst,
+			IsFolder:         d.IsFolderProperties,
+			Status:           d.TransferStatus,
+			ErrorCode:        d.ErrorCode,
+			ErrorServiceCode: d.ErrorServiceCode,
+			ErrorMessage:     d.ErrorMessage,
+		})
+	}
+	return results
+}
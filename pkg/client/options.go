@@ -0,0 +1,230 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package client
+
+import (
+	"time"
+
+	"github.com/Azure/azure-storage-azcopy/v10/cmd"
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+)
+
+// Filters choose which files, blobs and folders a job applies to
+type Filters struct {
+	// IncludePatterns and ExcludePatterns match names, and may contain wildcards (*)
+	IncludePatterns []string
+	ExcludePatterns []string
+
+	// IncludePaths and ExcludePaths match the start of relative paths, without wildcards
+	IncludePaths []string
+	ExcludePaths []string
+
+	// IncludeAfter and IncludeBefore, if set, only include files modified on or after, or before, the given time
+	IncludeAfter  time.Time
+	IncludeBefore time.Time
+}
+
+// CopyOptions are the options of Copy. Their defaults are those of 'azcopy copy', except where noted.
+type CopyOptions struct {
+	JobOptions
+	SchedulingOptions
+	Filters
+
+	Recursive bool
+
+	// FromTo is inferred from the source and destination, if not set
+	FromTo common.FromTo
+
+	// Overwrite defaults to True. Prompt isn't supported, since there's nobody to ask.
+	Overwrite common.OverwriteOption
+
+	// BlobType defaults to Detect
+	BlobType    common.BlobType
+	BlockSizeMB float64
+
+	// Metadata is set on the uploaded blobs and files
+	Metadata    map[string]string
+	ContentType string
+
+	PutMd5                   bool
+	PreserveLastModifiedTime bool
+}
+
+// SyncOptions are the options of Sync
+type SyncOptions struct {
+	JobOptions
+	SchedulingOptions
+
+	// Recursive is false by default, unlike in 'azcopy sync'
+	Recursive bool
+
+	// FromTo is inferred from the source and destination, if not set
+	FromTo common.FromTo
+
+	// IncludePatterns and ExcludePatterns match names, and may contain wildcards (*). ExcludePaths match the start of relative paths.
+	IncludePatterns []string
+	ExcludePatterns []string
+	ExcludePaths    []string
+
+	// DeleteDestination deletes the files at the destination that aren't at the source
+	DeleteDestination bool
+
+	// MirrorMode overwrites the destination regardless of the last modified times
+	MirrorMode  bool
+	PutMd5      bool
+	BlockSizeMB float64
+}
+
+// RemoveOptions are the options of Remove
+type RemoveOptions struct {
+	JobOptions
+
+	// IncludePatterns and ExcludePatterns match names, and may contain wildcards (*).
+	// IncludePaths and ExcludePaths match the start of relative paths.
+	IncludePatterns []string
+	ExcludePatterns []string
+	IncludePaths    []string
+	ExcludePaths    []string
+
+	Recursive bool
+
+	// FromTo is inferred from the target, if not set
+	FromTo common.FromTo
+}
+
+// ListOptions are the options of List
+type ListOptions struct {
+	// OnMessage, if set, receives the messages that the CLI would have printed, such as warnings
+	OnMessage func(string)
+
+	// Recursive is false by default, unlike in 'azcopy list'. When false, the directories, and the virtual directories
+	// of blob containers, are listed instead of their contents.
+	Recursive bool
+
+	// IncludePatterns and ExcludePatterns match names, and may contain wildcards (*). ExcludePaths match the start of relative paths.
+	IncludePatterns []string
+	ExcludePatterns []string
+	ExcludePaths    []string
+
+	// IncludeAfter and IncludeBefore, if set, only include files modified on or after, or before, the given time
+	IncludeAfter  time.Time
+	IncludeBefore time.Time
+
+	// IncludeVersions, IncludeSnapshots and IncludeDeleted also list the previous versions, the snapshots
+	// and the soft-deleted blobs of a container. Versions and snapshots cannot be listed together.
+	IncludeVersions  bool
+	IncludeSnapshots bool
+	IncludeDeleted   bool
+}
+
+func (o JobOptions) job() cmd.InProcessJobOptions {
+	return cmd.InProcessJobOptions{
+		LogLevel:       o.LogLevel,
+		TransferEvents: o.TransferEvents,
+		HookURL:        o.HookURL,
+		HookCommand:    o.HookCommand,
+		HookEvents:     o.HookEvents,
+		OnMessage:      o.OnMessage,
+	}
+}
+
+func (o SchedulingOptions) scheduling() cmd.InProcessScheduling {
+	return cmd.InProcessScheduling{Priority: o.Priority, MaxConcurrency: o.MaxConcurrency, CapMbps: o.CapMbps}
+}
+
+func (f Filters) filters() cmd.InProcessFilters {
+	return cmd.InProcessFilters{
+		IncludePatterns: f.IncludePatterns,
+		ExcludePatterns: f.ExcludePatterns,
+		IncludePaths:    f.IncludePaths,
+		ExcludePaths:    f.ExcludePaths,
+		IncludeAfter:    f.IncludeAfter,
+		IncludeBefore:   f.IncludeBefore,
+	}
+}
+
+func (o CopyOptions) job(source string, destination string) cmd.InProcessCopy {
+	return cmd.InProcessCopy{
+		Source:                   source,
+		Destination:              destination,
+		InProcessJobOptions:      o.JobOptions.job(),
+		InProcessScheduling:      o.SchedulingOptions.scheduling(),
+		InProcessFilters:         o.Filters.filters(),
+		Recursive:                o.Recursive,
+		FromTo:                   o.FromTo,
+		Overwrite:                o.Overwrite,
+		BlobType:                 o.BlobType,
+		BlockSizeMB:              o.BlockSizeMB,
+		Metadata:                 o.Metadata,
+		ContentType:              o.ContentType,
+		PutMd5:                   o.PutMd5,
+		PreserveLastModifiedTime: o.PreserveLastModifiedTime,
+	}
+}
+
+func (o SyncOptions) job(source string, destination string) cmd.InProcessSync {
+	return cmd.InProcessSync{
+		Source:              source,
+		Destination:         destination,
+		InProcessJobOptions: o.JobOptions.job(),
+		InProcessScheduling: o.SchedulingOptions.scheduling(),
+		Recursive:           o.Recursive,
+		FromTo:              o.FromTo,
+		IncludePatterns:     o.IncludePatterns,
+		ExcludePatterns:     o.ExcludePatterns,
+		ExcludePaths:        o.ExcludePaths,
+		DeleteDestination:   o.DeleteDestination,
+		MirrorMode:          o.MirrorMode,
+		PutMd5:              o.PutMd5,
+		BlockSizeMB:         o.BlockSizeMB,
+	}
+}
+
+func (o RemoveOptions) job(target string) cmd.InProcessRemove {
+	return cmd.InProcessRemove{
+		Target:              target,
+		InProcessJobOptions: o.JobOptions.job(),
+		InProcessFilters: cmd.InProcessFilters{
+			IncludePatterns: o.IncludePatterns,
+			ExcludePatterns: o.ExcludePatterns,
+			IncludePaths:    o.IncludePaths,
+			ExcludePaths:    o.ExcludePaths,
+		},
+		Recursive: o.Recursive,
+		FromTo:    o.FromTo,
+	}
+}
+
+func (o ListOptions) list(resource string) cmd.InProcessList {
+	return cmd.InProcessList{
+		Resource:         resource,
+		OnMessage:        o.OnMessage,
+		Recursive:        o.Recursive,
+		IncludePatterns:  o.IncludePatterns,
+		ExcludePatterns:  o.ExcludePatterns,
+		ExcludePaths:     o.ExcludePaths,
+		IncludeAfter:     o.IncludeAfter,
+		IncludeBefore:    o.IncludeBefore,
+		IncludeVersions:  o.IncludeVersions,
+		IncludeSnapshots: o.IncludeSnapshots,
+		IncludeDeleted:   o.IncludeDeleted,
+	}
+}
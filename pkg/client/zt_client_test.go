@@ -0,0 +1,85 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package client
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-storage-azcopy/v10/cmd"
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+	chk "gopkg.in/check.v1"
+)
+
+// Hookup to the testing framework
+func Test(t *testing.T) { chk.TestingT(t) }
+
+type clientSuite struct{}
+
+var _ = chk.Suite(&clientSuite{})
+
+func (s *clientSuite) TestCopyJob(c *chk.C) {
+	after := time.Date(2022, 3, 4, 5, 6, 7, 0, time.UTC)
+	onMessage := func(string) {}
+	job := CopyOptions{
+		JobOptions:        JobOptions{LogLevel: common.ELogLevel.Info(), OnMessage: onMessage},
+		SchedulingOptions: SchedulingOptions{Priority: common.EJobPriority.High(), MaxConcurrency: 8},
+		Filters:           Filters{IncludePatterns: []string{"*.jpg", "*.png"}, IncludeAfter: after},
+		Recursive:         true,
+		Overwrite:         common.EOverwriteOption.IfSourceNewer(),
+		Metadata:          map[string]string{"a": "1"},
+	}.job("/data", "https://account.blob.core.windows.net/container")
+
+	c.Assert(job.Source, chk.Equals, "/data")
+	c.Assert(job.Destination, chk.Equals, "https://account.blob.core.windows.net/container")
+	c.Assert(job.LogLevel, chk.Equals, common.ELogLevel.Info())
+	c.Assert(job.OnMessage, chk.NotNil)
+	c.Assert(job.InProcessScheduling, chk.DeepEquals, cmd.InProcessScheduling{Priority: common.EJobPriority.High(), MaxConcurrency: 8})
+	c.Assert(job.IncludePatterns, chk.DeepEquals, []string{"*.jpg", "*.png"})
+	c.Assert(job.IncludeAfter, chk.Equals, after)
+	c.Assert(job.Recursive, chk.Equals, true)
+	c.Assert(job.Overwrite, chk.Equals, common.EOverwriteOption.IfSourceNewer())
+	c.Assert(job.Metadata, chk.DeepEquals, map[string]string{"a": "1"})
+}
+
+func (s *clientSuite) TestSyncAndRemoveJobs(c *chk.C) {
+	sync := SyncOptions{DeleteDestination: true, FromTo: common.EFromTo.LocalBlob(), SchedulingOptions: SchedulingOptions{CapMbps: 2.5}}.
+		job("/data", "https://account.blob.core.windows.net/container")
+	c.Assert(sync.DeleteDestination, chk.Equals, true)
+	c.Assert(sync.FromTo, chk.Equals, common.EFromTo.LocalBlob())
+	c.Assert(sync.CapMbps, chk.Equals, 2.5)
+	c.Assert(sync.Recursive, chk.Equals, false)
+
+	remove := RemoveOptions{Recursive: true, ExcludePaths: []string{"keep"}}.job("https://account.blob.core.windows.net/container/dir")
+	c.Assert(remove.Target, chk.Equals, "https://account.blob.core.windows.net/container/dir")
+	c.Assert(remove.ExcludePaths, chk.DeepEquals, []string{"keep"})
+	c.Assert(remove.Recursive, chk.Equals, true)
+}
+
+func (s *clientSuite) TestListOptions(c *chk.C) {
+	list := ListOptions{Recursive: true, ExcludePatterns: []string{"*.tmp"}, IncludeSnapshots: true}.list("https://account.blob.core.windows.net/container")
+	c.Assert(list.Resource, chk.Equals, "https://account.blob.core.windows.net/container")
+	c.Assert(list.Recursive, chk.Equals, true)
+	c.Assert(list.ExcludePatterns, chk.DeepEquals, []string{"*.tmp"})
+	c.Assert(list.IncludeSnapshots, chk.Equals, true)
+
+	c.Assert(ListOptions{}.list("/data").Recursive, chk.Equals, false)
+}
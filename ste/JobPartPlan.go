@@ -13,12 +13,14 @@ import (
 // dataSchemaVersion defines the data schema version of JobPart order files supported by
 // current version of azcopy
 // To be Incremented every time when we release azcopy with changed dataSchema
-const DataSchemaVersion common.Version = 17
+const DataSchemaVersion common.Version = 24
 
 const (
 	CustomHeaderMaxBytes = 256
 	MetadataMaxBytes     = 1000 // If > 65536, then jobPartPlanBlobData's MetadataLength field's type must change
 	BlobTagsMaxByte      = 4000
+
+	PageDiffBaselineMaxBytes = 2048 // long enough for the URL of a managed disk snapshot (without its SAS)
 )
 
 // //////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -54,6 +56,8 @@ type JobPartPlanHeader struct {
 	ForceIfReadOnly        bool                        // Supplements ForceWrite with an additional setting for Azure Files. If true, the read-only attribute will be cleared before we overwrite
 	AutoDecompress         bool                        // if true, source data with encodings that represent compression are automatically decompressed when downloading
 	Priority               common.JobPriority          // The Job Part's priority
+	MaxConcurrency         int32                       // If non-zero, the most network operations that the job may have in flight at once
+	MaxBytesPerSecond      int64                       // If non-zero, the most bytes per second that the job may transfer
 	TTLAfterCompletion     uint32                      // Time to live after completion is used to persists the file on disk of specified time after the completion of JobPartOrder
 	FromTo                 common.FromTo               // The location of the transfer's source & destination
 	Fpo                    common.FolderPropertyOption // option specifying how folders will be handled
@@ -88,6 +92,13 @@ type JobPartPlanHeader struct {
 
 	// Determine what to do with soft-deleted snapshots
 	PermanentDeleteOption common.PermanentDeleteOption
+
+	// For set-properties operation, which properties to set (from DstBlobData), and how soon to rehydrate archived blobs
+	SetPropertiesFlags common.SetPropertiesFlags
+	RehydratePriority  common.RehydratePriorityType
+
+	// For move operation, whether each source is deleted once it's been transferred and verified
+	DeleteSourceOnSuccess bool
 }
 
 // Status returns the job status stored in JobPartPlanHeader in thread-safe manner
@@ -324,6 +335,19 @@ type JobPartPlanDstBlob struct {
 
 	// Specifies the maximum size of block which determines the number of chunks and chunk size of a transfer
 	BlockSize int64
+
+	// Specifies the length of the page diff baseline
+	PageDiffBaselineLength uint16
+
+	// For incremental page blob copies, the snapshot (a timestamp, or the URL of a managed disk snapshot) that the
+	// destination already matches. Only the pages that changed since this snapshot are copied
+	PageDiffBaseline [PageDiffBaselineMaxBytes]byte
+
+	// When uploading a disk image in this format, it is converted to a fixed VHD on the fly
+	DiskImageFormat common.DiskImageFormat
+
+	// Specifies whether the (append blob) destination keeps following the local source file, as it grows, until cancelled
+	FollowSource bool
 }
 
 // //////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -389,6 +413,24 @@ type JobPartPlanTransfer struct {
 	// atomicErrorCode has a default value (0) which means either there was no error or transfer failed because some non storageError.
 	// atomicErrorCode should not be directly accessed anywhere except by transferStatus and setTransferStatus
 	atomicErrorCode int32
+
+	// atomicChunksPersisted counts the chunks of this transfer that have been durably sent to the destination, across
+	// all runs of the job. A non-zero value tells a resumed job that it's worth looking for partial progress to re-use.
+	// atomicChunksPersisted should not be directly accessed anywhere except by ChunksPersisted and AddChunkPersisted
+	atomicChunksPersisted uint32
+
+	// atomicResumeOffset is, for downloads, the length of the prefix of the temporary destination file that
+	// is known to be completely written. A resumed job continues the download from here.
+	// When following a local file, it is the offset in that file up to which everything has been appended.
+	// atomicResumeOffset should not be directly accessed anywhere except by ResumeOffset and SetResumeOffset
+	atomicResumeOffset int64
+
+	// atomicFollowFileID and atomicFollowBlobOffset identify the local file that is being followed, and the length that
+	// the destination append blob had when we started on that file. Together they let a resumed job tell whether the file
+	// has been rotated in the meantime, and if not, where in it to continue.
+	// They should not be directly accessed anywhere except by FollowState and SetFollowState
+	atomicFollowFileID     uint64
+	atomicFollowBlobOffset int64
 }
 
 // TransferStatus returns the transfer's status
@@ -433,3 +475,42 @@ func (jppt *JobPartPlanTransfer) SetErrorCode(errorCode int32, overwrite bool) {
 		atomic.StoreInt32(&jppt.atomicErrorCode, errorCode)
 	}
 }
+
+// ChunksPersisted returns the number of chunks recorded as sent to the destination, by this or any prior run of the job.
+func (jppt *JobPartPlanTransfer) ChunksPersisted() uint32 {
+	return atomic.LoadUint32(&jppt.atomicChunksPersisted)
+}
+
+// AddChunkPersisted records that one more chunk has been durably sent to the destination.
+func (jppt *JobPartPlanTransfer) AddChunkPersisted() {
+	atomic.AddUint32(&jppt.atomicChunksPersisted, 1)
+}
+
+// ResumeOffset returns the offset from which a partially completed download can continue.
+func (jppt *JobPartPlanTransfer) ResumeOffset() int64 {
+	return atomic.LoadInt64(&jppt.atomicResumeOffset)
+}
+
+// SetResumeOffset records the offset from which a partially completed download can continue.
+func (jppt *JobPartPlanTransfer) SetResumeOffset(offset int64) {
+	atomic.StoreInt64(&jppt.atomicResumeOffset, offset)
+}
+
+// FollowState returns the ID of the local file being followed (zero if none yet), and the length of the destination
+// append blob at the time the file was first opened.
+func (jppt *JobPartPlanTransfer) FollowState() (fileID uint64, blobOffset int64) {
+	return atomic.LoadUint64(&jppt.atomicFollowFileID), atomic.LoadInt64(&jppt.atomicFollowBlobOffset)
+}
+
+// SetFollowState records the local file that is being followed, and where its content starts in the destination.
+func (jppt *JobPartPlanTransfer) SetFollowState(fileID uint64, blobOffset int64) {
+	atomic.StoreInt64(&jppt.atomicFollowBlobOffset, blobOffset)
+	atomic.StoreUint64(&jppt.atomicFollowFileID, fileID)
+}
+
+// ResetResumeState forgets any partial progress, so that the next attempt at this transfer starts from scratch.
+func (jppt *JobPartPlanTransfer) ResetResumeState() {
+	atomic.StoreUint32(&jppt.atomicChunksPersisted, 0)
+	atomic.StoreInt64(&jppt.atomicResumeOffset, 0)
+	jppt.SetFollowState(0, 0)
+}
@@ -178,6 +178,8 @@ func (jpfn JobPartPlanFileName) Create(order common.CopyJobPartOrderRequest) {
 		ForceIfReadOnly:        order.ForceIfReadOnly,
 		AutoDecompress:         order.AutoDecompress,
 		Priority:               order.Priority,
+		MaxConcurrency:         order.MaxConcurrency,
+		MaxBytesPerSecond:      order.MaxBytesPerSecond,
 		TTLAfterCompletion:     uint32(time.Time{}.Nanosecond()),
 		FromTo:                 order.FromTo,
 		Fpo:                    order.Fpo,
@@ -201,6 +203,9 @@ func (jpfn JobPartPlanFileName) Create(order common.CopyJobPartOrderRequest) {
 			CpkInfo:                  order.CpkOptions.CpkInfo,
 			CpkScopeInfoLength:       uint16(len(order.CpkOptions.CpkScopeInfo)),
 			IsSourceEncrypted:        order.CpkOptions.IsSourceEncrypted,
+			PageDiffBaselineLength:   uint16(len(order.BlobAttributes.PageDiffBaseline)),
+			DiskImageFormat:          order.BlobAttributes.DiskImageFormat,
+			FollowSource:             order.BlobAttributes.FollowSource,
 		},
 		DstLocalData: JobPartPlanDstLocal{
 			PreserveLastModifiedTime: order.BlobAttributes.PreserveLastModifiedTime,
@@ -216,6 +221,9 @@ func (jpfn JobPartPlanFileName) Create(order common.CopyJobPartOrderRequest) {
 		atomicJobStatus:                common.EJobStatus.InProgress(), // We default to InProgress
 		DeleteSnapshotsOption:          order.BlobAttributes.DeleteSnapshotsOption,
 		PermanentDeleteOption:          order.BlobAttributes.PermanentDeleteOption,
+		SetPropertiesFlags:             order.BlobAttributes.SetPropertiesFlags,
+		RehydratePriority:              order.BlobAttributes.RehydratePriority,
+		DeleteSourceOnSuccess:          order.DeleteSourceOnSuccess,
 	}
 
 	// Copy any strings into their respective fields
@@ -232,6 +240,7 @@ func (jpfn JobPartPlanFileName) Create(order common.CopyJobPartOrderRequest) {
 	copy(jpph.DstBlobData.Metadata[:], order.BlobAttributes.Metadata)
 	copy(jpph.DstBlobData.BlobTags[:], order.BlobAttributes.BlobTagsString)
 	copy(jpph.DstBlobData.CpkScopeInfo[:], order.CpkOptions.CpkScopeInfo)
+	copy(jpph.DstBlobData.PageDiffBaseline[:], order.BlobAttributes.PageDiffBaseline)
 
 	eof += writeValue(file, &jpph)
 
@@ -0,0 +1,274 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ste
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-pipeline-go/pipeline"
+	"github.com/Azure/azure-storage-blob-go/azblob"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+)
+
+// BlobBatchMaxSubRequests is the most operations the service accepts in one batch request
+const BlobBatchMaxSubRequests = 256
+
+// NewBlobBatchSigner returns a pipeline that authorizes the sub-requests of a batch with the given credential,
+// without sending them anywhere. Each sub-request must be authorized by itself, as well as the batch that holds it.
+func NewBlobBatchSigner(credential azblob.Credential) pipeline.Pipeline {
+	return pipeline.NewPipeline([]pipeline.Factory{credential}, pipeline.Options{
+		HTTPSender: pipeline.FactoryFunc(func(next pipeline.Policy, po *pipeline.PolicyOptions) pipeline.PolicyFunc {
+			return func(ctx context.Context, request pipeline.Request) (pipeline.Response, error) {
+				return pipeline.NewHTTPResponse(&http.Response{StatusCode: http.StatusOK, Request: request.Request}), nil
+			}
+		}),
+	})
+}
+
+// NewBlobBatchDeleteRequest returns the sub-request that deletes a blob (or, given its query, a snapshot or version of it) in a batch
+func NewBlobBatchDeleteRequest(blobURL url.URL, deleteSnapshots azblob.DeleteSnapshotsOptionType) *http.Request {
+	req := newBlobBatchSubRequest(http.MethodDelete, blobURL)
+	if deleteSnapshots != azblob.DeleteSnapshotsOptionNone {
+		req.Header.Set("x-ms-delete-snapshots", string(deleteSnapshots))
+	}
+	return req
+}
+
+// NewBlobBatchSetTierRequest returns the sub-request that sets the tier of a blob in a batch
+func NewBlobBatchSetTierRequest(blobURL url.URL, tier azblob.AccessTierType, rehydratePriority azblob.RehydratePriorityType) *http.Request {
+	query := blobURL.Query()
+	query.Set("comp", "tier")
+	blobURL.RawQuery = query.Encode()
+
+	req := newBlobBatchSubRequest(http.MethodPut, blobURL)
+	req.Header.Set("x-ms-access-tier", string(tier))
+	if rehydratePriority != azblob.RehydratePriorityNone {
+		req.Header.Set("x-ms-rehydrate-priority", string(rehydratePriority))
+	}
+	return req
+}
+
+func newBlobBatchSubRequest(method string, u url.URL) *http.Request {
+	return &http.Request{
+		Method: method,
+		URL:    &u,
+		Host:   u.Host,
+		Header: http.Header{"Content-Length": []string{"0"}},
+	}
+}
+
+// SubmitBlobBatch sends the sub-requests, which must all be for blobs in the given container, as one batch request.
+// It returns the outcome of each sub-request, in the same order: nil if it succeeded, and otherwise an
+// azblob.StorageError, so that callers can handle it just as they would handle the error of the single request.
+// The error that's returned if the batch as a whole fails means that none of the sub-requests were done.
+func SubmitBlobBatch(ctx context.Context, p pipeline.Pipeline, signer pipeline.Pipeline, containerURL url.URL, subRequests []*http.Request) ([]error, error) {
+	if len(subRequests) == 0 || len(subRequests) > BlobBatchMaxSubRequests {
+		return nil, fmt.Errorf("a batch must hold between 1 and %d operations, not %d", BlobBatchMaxSubRequests, len(subRequests))
+	}
+
+	date := time.Now().UTC().Format(http.TimeFormat)
+	for _, sub := range subRequests {
+		sub.Header.Set("x-ms-date", date)
+		_, err := signer.Do(ctx, nil, pipeline.Request{Request: sub})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	boundary := "batch_" + common.NewUUID().String()
+	body := &bytes.Buffer{}
+	err := writeBlobBatchBody(body, boundary, subRequests)
+	if err != nil {
+		return nil, err
+	}
+
+	query := containerURL.Query()
+	query.Set("restype", "container")
+	query.Set("comp", "batch")
+	containerURL.RawQuery = query.Encode()
+
+	req, err := pipeline.NewRequest(http.MethodPost, containerURL, bytes.NewReader(body.Bytes()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "multipart/mixed; boundary="+boundary)
+	req.ContentLength = int64(body.Len())
+
+	resp, err := p.Do(ctx, nil, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Response().Body.Close()
+
+	if resp.Response().StatusCode != http.StatusAccepted {
+		return nil, fmt.Errorf("the batch request failed with status %s and error code %s", resp.Response().Status, resp.Response().Header.Get("x-ms-error-code"))
+	}
+	return parseBlobBatchResponse(resp.Response().Header.Get("Content-Type"), resp.Response().Body, len(subRequests))
+}
+
+// writeBlobBatchBody writes each sub-request, as it would go on the wire, in a part of a multipart/mixed body
+func writeBlobBatchBody(w io.Writer, boundary string, subRequests []*http.Request) error {
+	mw := multipart.NewWriter(w)
+	err := mw.SetBoundary(boundary)
+	if err != nil {
+		return err
+	}
+
+	for i, sub := range subRequests {
+		// the keys are set directly, since the service expects Content-ID rather than its canonical form
+		part, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              []string{"application/http"},
+			"Content-Transfer-Encoding": []string{"binary"},
+			"Content-ID":                []string{strconv.Itoa(i)},
+		})
+		if err != nil {
+			return err
+		}
+
+		_, err = fmt.Fprintf(part, "%s %s HTTP/1.1\r\n", sub.Method, sub.URL.RequestURI())
+		if err != nil {
+			return err
+		}
+		err = sub.Header.Write(part)
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(part, "\r\n")
+		if err != nil {
+			return err
+		}
+	}
+	return mw.Close()
+}
+
+// parseBlobBatchResponse maps each part of the multipart/mixed response back to the sub-request with the same Content-ID
+func parseBlobBatchResponse(contentType string, body io.Reader, count int) ([]error, error) {
+	_, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, err
+	}
+	if params["boundary"] == "" {
+		return nil, errors.New("the batch response has no boundary")
+	}
+
+	results := make([]error, count)
+	answered := make([]bool, count)
+	reader := multipart.NewReader(body, params["boundary"])
+	for i := 0; ; i++ {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		// parts are in the order of the sub-requests, but the Content-ID says which one each is for, if it's there
+		index := i
+		if id := part.Header.Get("Content-ID"); id != "" {
+			index, err = strconv.Atoi(id)
+			if err != nil {
+				return nil, fmt.Errorf("the batch response has an invalid Content-ID %q", id)
+			}
+		}
+		if index < 0 || index >= count {
+			return nil, fmt.Errorf("the batch response has a part for operation %d, but only %d were sent", index, count)
+		}
+
+		subResponse, err := http.ReadResponse(bufio.NewReader(part), nil)
+		if err != nil {
+			return nil, err
+		}
+		content, err := ioutil.ReadAll(subResponse.Body)
+		if err != nil {
+			return nil, err
+		}
+		subResponse.Body = ioutil.NopCloser(bytes.NewReader(content))
+
+		answered[index] = true
+		if subResponse.StatusCode < 200 || subResponse.StatusCode > 299 {
+			results[index] = newBlobBatchSubResponseError(subResponse, content)
+		}
+	}
+
+	for i := range answered {
+		if !answered[i] {
+			return nil, fmt.Errorf("the batch response has no part for operation %d", i)
+		}
+	}
+	return results, nil
+}
+
+// blobBatchSubResponseError is the failure of one operation in a batch. It's an azblob.StorageError, like the failure
+// of the same operation on its own.
+type blobBatchSubResponseError struct {
+	response *http.Response
+	message  string
+}
+
+func newBlobBatchSubResponseError(response *http.Response, content []byte) blobBatchSubResponseError {
+	message := response.Status
+	if start, end := bytes.Index(content, []byte("<Message>")), bytes.Index(content, []byte("</Message>")); start >= 0 && end > start {
+		message = strings.TrimSpace(string(content[start+len("<Message>") : end]))
+	}
+	return blobBatchSubResponseError{response: response, message: message}
+}
+
+func (e blobBatchSubResponseError) Error() string {
+	return fmt.Sprintf("===== RESPONSE ERROR (ServiceCode=%s) =====\nDescription=%s, Details: (batch operation)\n   %s %s",
+		e.ServiceCode(), e.message, e.response.Proto, e.response.Status)
+}
+
+func (e blobBatchSubResponseError) Timeout() bool {
+	return false
+}
+
+// Temporary reports whether the operation may succeed if it's tried again, which the retry policy would have done
+// if it had been sent on its own
+func (e blobBatchSubResponseError) Temporary() bool {
+	switch e.response.StatusCode {
+	case http.StatusInternalServerError, http.StatusServiceUnavailable, http.StatusRequestTimeout, http.StatusTooManyRequests:
+		return true
+	}
+	return false
+}
+
+func (e blobBatchSubResponseError) Response() *http.Response {
+	return e.response
+}
+
+func (e blobBatchSubResponseError) ServiceCode() azblob.ServiceCodeType {
+	return azblob.ServiceCodeType(e.response.Header.Get("x-ms-error-code"))
+}
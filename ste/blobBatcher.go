@@ -0,0 +1,180 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ste
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Azure/azure-pipeline-go/pipeline"
+	"github.com/Azure/azure-storage-blob-go/azblob"
+)
+
+// how long a batch that isn't full waits for more operations before it's sent anyway.
+// Transfers are started much faster than this, so it's only the last batch of each container that waits.
+const blobBatchFlushDelay = 250 * time.Millisecond
+
+// blobBatchOperation is the part that one transfer plays in a batch
+type blobBatchOperation struct {
+	jptm    IJobPartTransferMgr
+	request *http.Request
+
+	// done reports the outcome of the operation, and completes the transfer
+	done func(err error)
+
+	// fallback does the operation on its own instead, e.g. when the batch as a whole failed.
+	// It runs on the worker that sent the batch.
+	fallback func()
+}
+
+// blobBatcher collects the deletes, or tier changes, of a job part's transfers into batches of up to
+// BlobBatchMaxSubRequests operations, one container at a time, and sends each batch on the chunk pool
+type blobBatcher struct {
+	ctx    context.Context
+	p      pipeline.Pipeline
+	signer pipeline.Pipeline
+
+	mu      sync.Mutex
+	pending map[string]*pendingBlobBatch // by container URL
+
+	// set once a batch has failed as a whole, after which every operation is done by itself
+	atomicUnusable int32
+}
+
+type pendingBlobBatch struct {
+	containerURL url.URL
+	operations   []blobBatchOperation
+	timer        *time.Timer
+}
+
+func newBlobBatcher(ctx context.Context, p pipeline.Pipeline, credential azblob.Credential) *blobBatcher {
+	return &blobBatcher{
+		ctx:     ctx,
+		p:       p,
+		signer:  NewBlobBatchSigner(credential),
+		pending: make(map[string]*pendingBlobBatch),
+	}
+}
+
+// usable tells whether operations should still be added, i.e. whether batches work against this account
+func (b *blobBatcher) usable() bool {
+	return b != nil && atomic.LoadInt32(&b.atomicUnusable) == 0
+}
+
+// add puts the operation in the batch of its container, and sends that batch if it's full
+func (b *blobBatcher) add(op blobBatchOperation) {
+	containerURL := containerURLOf(*op.request.URL)
+	key := containerURL.String()
+
+	b.mu.Lock()
+	batch, ok := b.pending[key]
+	if !ok {
+		batch = &pendingBlobBatch{containerURL: containerURL}
+		b.pending[key] = batch
+		batch.timer = time.AfterFunc(blobBatchFlushDelay, func() { b.flush(key, batch) })
+	}
+
+	batch.operations = append(batch.operations, op)
+	full := len(batch.operations) == BlobBatchMaxSubRequests
+	if full {
+		batch.timer.Stop()
+		delete(b.pending, key)
+	}
+	b.mu.Unlock()
+
+	if full {
+		b.schedule(batch)
+	}
+}
+
+// flush sends the batch, if it's still waiting for more operations
+func (b *blobBatcher) flush(key string, batch *pendingBlobBatch) {
+	b.mu.Lock()
+	waiting := b.pending[key] == batch // otherwise, it filled up, and was sent already
+	if waiting {
+		delete(b.pending, key)
+	}
+	b.mu.Unlock()
+
+	if waiting {
+		b.schedule(batch)
+	}
+}
+
+// schedule sends the batch on the chunk pool, along with the transfers' other work
+func (b *blobBatcher) schedule(batch *pendingBlobBatch) {
+	batch.operations[0].jptm.ScheduleChunks(func(workerId int) { b.submit(batch) })
+}
+
+func (b *blobBatcher) submit(batch *pendingBlobBatch) {
+	// the transfers that were cancelled while they waited are done already
+	live := make([]blobBatchOperation, 0, len(batch.operations))
+	for _, op := range batch.operations {
+		if op.jptm.WasCanceled() {
+			op.jptm.ReportTransferDone()
+		} else {
+			live = append(live, op)
+		}
+	}
+	if len(live) == 0 {
+		return
+	}
+
+	subRequests := make([]*http.Request, len(live))
+	for i, op := range live {
+		subRequests[i] = op.request
+	}
+
+	results, err := SubmitBlobBatch(b.ctx, b.p, b.signer, batch.containerURL, subRequests)
+	if err != nil {
+		// e.g. the service, or the account's emulator, doesn't support batches, so each operation is done by itself
+		live[0].jptm.Log(pipeline.LogWarning, fmt.Sprintf("Batch of %d operations failed, so each will be done by itself from now on: %s", len(live), err))
+		atomic.StoreInt32(&b.atomicUnusable, 1)
+		for _, op := range live {
+			op.fallback()
+		}
+		return
+	}
+
+	for i, op := range live {
+		// the single request is retried, which the sub-request can't be, and isn't subject to the limits of batches,
+		// e.g. those of accounts with a hierarchical namespace
+		if subErr, ok := results[i].(blobBatchSubResponseError); ok && (subErr.Temporary() || subErr.Response().StatusCode == http.StatusBadRequest) {
+			op.fallback()
+			continue
+		}
+		op.done(results[i])
+	}
+}
+
+// containerURLOf returns the URL of the container that the blob is in, with the same SAS
+func containerURLOf(blobURL url.URL) url.URL {
+	parts := azblob.NewBlobURLParts(blobURL)
+	parts.BlobName = ""
+	parts.Snapshot = ""
+	parts.VersionID = ""
+	return parts.URL()
+}
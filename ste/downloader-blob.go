@@ -67,11 +67,12 @@ func (bd *blobDownloader) GenerateDownloadFunc(jptm IJobPartTransferMgr, srcPipe
 	return createDownloadChunkFunc(jptm, id, func() {
 
 		// If the range does not contain any data, write out empty data to disk without performing download
+		// (or, where possible, leave a hole in the file)
 		if bd.pageRangeOptimizer != nil && !bd.pageRangeOptimizer.doesRangeContainData(
 			azblob.PageRange{Start: id.OffsetInFile(), End: id.OffsetInFile() + length - 1}) {
 
 			// queue an empty chunk
-			err := destWriter.EnqueueChunk(jptm.Context(), id, length, dummyReader{}, false)
+			err := destWriter.EnqueueZeroChunk(jptm.Context(), id, length)
 			if err != nil {
 				jptm.FailActiveDownload("Enqueuing chunk", err)
 			}
@@ -137,9 +138,3 @@ func (bd *blobDownloader) GenerateDownloadFunc(jptm IJobPartTransferMgr, srcPipe
 		}
 	})
 }
-
-type dummyReader struct{}
-
-func (dummyReader) Read(p []byte) (n int, err error) {
-	return len(p), nil
-}
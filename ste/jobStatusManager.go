@@ -21,8 +21,12 @@
 package ste
 
 import (
+	"encoding/base64"
+	"sync"
 	"time"
 
+	"github.com/Azure/azure-pipeline-go/pipeline"
+
 	"github.com/Azure/azure-storage-azcopy/v10/common"
 )
 
@@ -34,11 +38,22 @@ type JobPartCreatedMsg struct {
 	FolderTransfer uint32
 }
 
-type xferDoneMsg = common.TransferDetail
+// xferDoneMsg is sent as each transfer is done. Beyond the TransferDetail, which is kept for the summary,
+// it has the details that only the stream of transfer events needs
+type xferDoneMsg struct {
+	common.TransferDetail
+	Duration             time.Duration
+	ContentMD5           []byte
+	SourceVersionID      string
+	DestinationVersionID string
+}
+
 type jobStatusManager struct {
 	js          common.ListJobSummaryResponse
 	respChan    chan common.ListJobSummaryResponse
-	listReq     chan bool
+	listReq     chan bool // true if the lists of failed and skipped transfers are to be reset, once they have been sent
+	// listLock pairs each request with its response, since the summary is asked for by the front end, the metrics and the hooks at once
+	listLock sync.Mutex
 	partCreated chan JobPartCreatedMsg
 	xferDone    chan xferDoneMsg
 }
@@ -53,10 +68,21 @@ func (jm *jobMgr) SendXferDoneMsg(msg xferDoneMsg) {
 }
 
 func (jm *jobMgr) ListJobSummary() common.ListJobSummaryResponse {
+	jm.jstm.listLock.Lock()
+	defer jm.jstm.listLock.Unlock()
 	jm.jstm.listReq <- true
 	return <-jm.jstm.respChan
 }
 
+// PeekJobSummary returns the summary without consuming its lists of failed and skipped transfers,
+// so that it can be called without taking them from the caller of ListJobSummary, e.g. to serve metrics
+func (jm *jobMgr) PeekJobSummary() common.ListJobSummaryResponse {
+	jm.jstm.listLock.Lock()
+	defer jm.jstm.listLock.Unlock()
+	jm.jstm.listReq <- false
+	return <-jm.jstm.respChan
+}
+
 func (jm *jobMgr) ResurrectSummary(js common.ListJobSummaryResponse) {
 	jm.jstm.js = js
 }
@@ -79,33 +105,90 @@ func (jm *jobMgr) handleStatusUpdateMessage() {
 			js.TotalBytesExpected += msg.TotalBytesEnumerated
 
 		case msg := <-jstm.xferDone:
-			msg.Src = common.URLStringExtension(msg.Src).RedactSecretQueryParamForLogging()
-			msg.Dst = common.URLStringExtension(msg.Dst).RedactSecretQueryParamForLogging()
-
-			switch msg.TransferStatus {
-			case common.ETransferStatus.Success():
-				js.TransfersCompleted++
-				js.TotalBytesTransferred += msg.TransferSize
-			case common.ETransferStatus.Failed(),
-				common.ETransferStatus.TierAvailabilityCheckFailure(),
-				common.ETransferStatus.BlobTierFailure():
-				js.TransfersFailed++
-				js.FailedTransfers = append(js.FailedTransfers, msg)
-			case common.ETransferStatus.SkippedEntityAlreadyExists(),
-				common.ETransferStatus.SkippedBlobHasSnapshots():
-				js.TransfersSkipped++
-				js.SkippedTransfers = append(js.SkippedTransfers, msg)
+			jm.handleXferDone(msg)
+
+		case reset := <-jstm.listReq:
+			// take in the transfers that were done before the summary was asked for, since select picks among the ready channels at random.
+			// Otherwise, a summary that says the job is complete could miss some of its transfers.
+			for drained := false; !drained; {
+				select {
+				case msg := <-jstm.xferDone:
+					jm.handleXferDone(msg)
+				default:
+					drained = true
+				}
 			}
 
-		case <-jstm.listReq:
 			/* Display stats */
 			js.Timestamp = time.Now().UTC()
 			jstm.respChan <- *js
 
 			// Reset the lists so that they don't keep accumulating and take up excessive memory
 			// There is no need to keep sending the same items over and over again
-			js.FailedTransfers = []common.TransferDetail{}
-			js.SkippedTransfers = []common.TransferDetail{}
+			if reset {
+				js.FailedTransfers = []common.TransferDetail{}
+				js.SkippedTransfers = []common.TransferDetail{}
+			}
 		}
 	}
 }
+
+// handleXferDone counts the transfer in the summary, and queues its event for the stream of transfer events, if there is one
+func (jm *jobMgr) handleXferDone(msg xferDoneMsg) {
+	js := &jm.jstm.js
+	msg.Src = common.URLStringExtension(msg.Src).RedactSecretQueryParamForLogging()
+	msg.Dst = common.URLStringExtension(msg.Dst).RedactSecretQueryParamForLogging()
+
+	var event string
+	switch msg.TransferStatus {
+	case common.ETransferStatus.Success():
+		js.TransfersCompleted++
+		js.TotalBytesTransferred += msg.TransferSize
+		event = "completed"
+	case common.ETransferStatus.Failed(),
+		common.ETransferStatus.TierAvailabilityCheckFailure(),
+		common.ETransferStatus.BlobTierFailure():
+		js.TransfersFailed++
+		js.FailedTransfers = append(js.FailedTransfers, msg.TransferDetail)
+		event = "failed"
+		transfer := msg.TransferDetail
+		jm.getInMemoryTransitJobState().Hooks.Fire(common.JobHookPayload{
+			Event:     common.EJobHookEvent.TransferFailed(),
+			JobID:     jm.jobID,
+			JobStatus: common.EJobStatus.InProgress(),
+			Transfer:  &transfer,
+		}, jm)
+	case common.ETransferStatus.SkippedEntityAlreadyExists(),
+		common.ETransferStatus.SkippedBlobHasSnapshots():
+		js.TransfersSkipped++
+		js.SkippedTransfers = append(js.SkippedTransfers, msg.TransferDetail)
+		event = "skipped"
+	default:
+		return
+	}
+
+	events := jm.getInMemoryTransitJobState().TransferEvents
+	if events == nil {
+		return
+	}
+	e := common.TransferEvent{
+		Timestamp:            time.Now().UTC(),
+		JobID:                jm.jobID,
+		Event:                event,
+		Status:               msg.TransferStatus.String(),
+		Source:               msg.Src,
+		Destination:          msg.Dst,
+		IsFolder:             msg.IsFolderProperties,
+		Size:                 msg.TransferSize,
+		DurationMs:           msg.Duration.Milliseconds(),
+		VersionID:            msg.SourceVersionID,
+		DestinationVersionID: msg.DestinationVersionID,
+		ErrorCode:            msg.ErrorCode,
+	}
+	if len(msg.ContentMD5) > 0 {
+		e.ContentMD5 = base64.StdEncoding.EncodeToString(msg.ContentMD5)
+	}
+	if err := events.Write(e); err != nil {
+		jm.Log(pipeline.LogWarning, "Transfer events: "+err.Error())
+	}
+}
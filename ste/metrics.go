@@ -0,0 +1,115 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ste
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/Azure/azure-pipeline-go/pipeline"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Metrics holds the metrics of the STE, which are served to Prometheus when --metrics-listen is used.
+// The metrics of the network requests are recorded here, as the requests happen. Those that describe
+// the state of the jobs and of the scheduler are registered by the JobsAdmin, which computes them when they are scraped.
+var Metrics = prometheus.NewRegistry()
+
+// requestDurationBuckets are the upper bounds, in seconds, of the request latency histogram.
+// They go up to the largest try timeout that we'd expect to see
+var requestDurationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300, 900}
+
+var (
+	metricRequestsInFlight = promauto.With(Metrics).NewGauge(prometheus.GaugeOpts{
+		Name: "azcopy_http_requests_in_flight",
+		Help: "Number of HTTP requests that have been sent and are awaiting their response.",
+	})
+	metricRequestDuration = promauto.With(Metrics).NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "azcopy_http_request_duration_seconds",
+		Help:    "Duration of each try of each HTTP request, from sending it to receiving the headers of its response.",
+		Buckets: requestDurationBuckets,
+	}, []string{"method"})
+	metricResponses = promauto.With(Metrics).NewCounterVec(prometheus.CounterOpts{
+		Name: "azcopy_http_responses_total",
+		Help: "Number of tries of HTTP requests, by the status code of their response. The code is 'network_error' when there was no response.",
+	}, []string{"status_code"})
+	metricRetries = promauto.With(Metrics).NewCounterVec(prometheus.CounterOpts{
+		Name: "azcopy_http_retries_total",
+		Help: "Number of HTTP requests that were retried, by the status code of the response that caused the retry. The code is 'network_error' when there was no response.",
+	}, []string{"status_code"})
+)
+
+// networkErrorStatusCode labels the tries that got no response at all
+const networkErrorStatusCode = "network_error"
+
+// tryTracker follows the tries of one operation, so that the xferStatsPolicy, which sees each try, can tell a retry from a
+// first try, and know why the operation was retried. The tries of an operation are sequential, so it needs no locking.
+type tryTracker struct {
+	tries             int
+	lastTryStatusCode string
+}
+
+var tryTrackerContextKey = contextKey{"tryTracker"}
+
+// startTry is called as each try is sent. The ones after the first are retries, caused by the outcome of the previous one
+func (t *tryTracker) startTry() {
+	if t.tries > 0 {
+		metricRetries.WithLabelValues(t.lastTryStatusCode).Inc()
+	}
+	t.tries++
+}
+
+// recordTryMetrics records the outcome of one try of a request
+func recordTryMetrics(ctx context.Context, request pipeline.Request, resp pipeline.Response, err error, seconds float64) {
+	if err != nil && isContextCancelledError(err) {
+		return // it didn't fail, we just stopped waiting for it
+	}
+
+	statusCode := networkErrorStatusCode
+	if resp != nil {
+		if rr := resp.Response(); rr != nil {
+			statusCode = strconv.Itoa(rr.StatusCode)
+		}
+	}
+	metricResponses.WithLabelValues(statusCode).Inc()
+	metricRequestDuration.WithLabelValues(request.Method).Observe(seconds)
+
+	if t, ok := ctx.Value(tryTrackerContextKey).(*tryTracker); ok {
+		t.lastTryStatusCode = statusCode
+	}
+}
+
+type tryTrackingPolicy struct {
+	next pipeline.Policy
+}
+
+// Do gives the operation a tryTracker. It goes before the retry policy, so that it is only called once per operation
+func (p *tryTrackingPolicy) Do(ctx context.Context, request pipeline.Request) (pipeline.Response, error) {
+	return p.next.Do(context.WithValue(ctx, tryTrackerContextKey, &tryTracker{}), request)
+}
+
+func newTryTrackingPolicyFactory() pipeline.Factory {
+	return pipeline.FactoryFunc(func(next pipeline.Policy, po *pipeline.PolicyOptions) pipeline.PolicyFunc {
+		r := tryTrackingPolicy{next: next}
+		return r.Do
+	})
+}
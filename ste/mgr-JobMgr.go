@@ -28,9 +28,11 @@ import (
 	"strings"
 	"sync"
 	"sync/atomic"
-	"time"
 
 	"github.com/Azure/azure-pipeline-go/pipeline"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/Azure/azure-storage-azcopy/v10/common"
 )
@@ -48,6 +50,12 @@ type InMemoryTransitJobState struct {
 	CredentialInfo common.CredentialInfo
 	// S2SSourceCredentialType can override the CredentialInfo.CredentialType when being used for the source (e.g. Source Info Provider and when using GetS2SSourceBlobTokenCredential)
 	S2SSourceCredentialType common.CredentialType
+	// TransferEvents, if not nil, receives an event as each transfer completes, fails or is skipped
+	TransferEvents *common.TransferEventWriter
+	// Hooks, if not nil, are run as the job starts and ends, and as transfers fail
+	Hooks *common.JobHooks
+	// PageDiffBaselineSAS authorizes the page diff baseline, when that's the URL of a snapshot with a SAS of its own
+	PageDiffBaselineSAS string
 }
 
 type IJobMgr interface {
@@ -78,6 +86,7 @@ type IJobMgr interface {
 	// Close()
 	getInMemoryTransitJobState() InMemoryTransitJobState      // get in memory transit job state saved in this job.
 	SetInMemoryTransitJobState(state InMemoryTransitJobState) // set in memory transit job state saved in this job.
+	recordTransferError(partNum PartNumber, transferIndex uint32, e TransferError) error
 	ChunkStatusLogger() common.ChunkStatusLogger
 	HttpClient() *http.Client
 	PipelineNetworkStats() *PipelineNetworkStats
@@ -88,11 +97,12 @@ type IJobMgr interface {
 	SendJobPartCreatedMsg(msg JobPartCreatedMsg)
 	SendXferDoneMsg(msg xferDoneMsg)
 	ListJobSummary() common.ListJobSummaryResponse
+	PeekJobSummary() common.ListJobSummaryResponse
 	ResurrectSummary(js common.ListJobSummaryResponse)
 
 	/* Ported from jobsAdmin() */
-	ScheduleTransfer(priority common.JobPriority, jptm IJobPartTransferMgr)
-	ScheduleChunk(priority common.JobPriority, chunkFunc chunkFunc)
+	ScheduleTransfer(jptm IJobPartTransferMgr)
+	ScheduleChunk(chunkFunc chunkFunc)
 
 	/* Some comment */
 	IterateJobParts(readonly bool, f func(k common.PartNumber, v IJobPartMgr))
@@ -107,8 +117,8 @@ type IJobMgr interface {
 
 func NewJobMgr(concurrency ConcurrencySettings, jobID common.JobID, appCtx context.Context, cpuMon common.CPUMonitor, level common.LogLevel,
 	commandString string, logFileFolder string, tuner ConcurrencyTuner,
-	pacer PacerAdmin, slicePool common.ByteSlicePooler, cacheLimiter common.CacheLimiter, fileCountLimiter common.CacheLimiter,
-	jobLogger common.ILoggerResetable, daemonMode bool) IJobMgr {
+	pacer PacerAdmin, slicePool common.ByteSlicePooler, fileCountLimiter common.CacheLimiter,
+	jobLogger common.ILoggerResetable, scheduler *JobScheduler, daemonMode bool) IJobMgr {
 	const channelSize = 100000
 	// PartsChannelSize defines the number of JobParts which can be placed into the
 	// parts channel. Any JobPart which comes from FE and partChannel is full,
@@ -123,9 +133,8 @@ func NewJobMgr(concurrency ConcurrencySettings, jobID common.JobID, appCtx conte
 	// from which each part is picked up one by one
 	// and transfers of that JobPart are scheduled
 	partsCh := make(chan IJobPartMgr, PartsChannelSize)
-	// Create normal & low transfer/chunk channels
-	normalTransferCh, normalChunkCh := make(chan IJobPartTransferMgr, channelSize), make(chan chunkFunc, channelSize)
-	lowTransferCh, lowChunkCh := make(chan IJobPartTransferMgr, channelSize), make(chan chunkFunc, channelSize)
+	// The job's transfers and chunks are queued in the scheduler, which shares the workers between all jobs
+	scheduled := scheduler.newScheduledJob(channelSize)
 
 	// atomicAllTransfersScheduled is set to 1 since this api is also called when new job part is ordered.
 	enableChunkLogOutput := level.ToPipelineLogLevel() == pipeline.LogDebug
@@ -141,6 +150,7 @@ func NewJobMgr(concurrency ConcurrencySettings, jobID common.JobID, appCtx conte
 	jm := jobMgr{jobID: jobID, jobPartMgrs: newJobPartToJobPartMgr(), include: map[string]int{}, exclude: map[string]int{},
 		httpClient:           NewAzcopyHTTPClient(concurrency.MaxIdleConnections),
 		logger:               jobLogger,
+		transferErrors:       newTransferErrorLog(jobID),
 		chunkStatusLogger:    common.NewChunkStatusLogger(jobID, cpuMon, logFileFolder, enableChunkLogOutput),
 		concurrency:          concurrency,
 		overwritePrompter:    newOverwritePrompter(),
@@ -148,43 +158,26 @@ func NewJobMgr(concurrency ConcurrencySettings, jobID common.JobID, appCtx conte
 		initMu:               &sync.Mutex{},
 		jobPartProgress:      jobPartProgressCh,
 		coordinatorChannels: CoordinatorChannels{
-			partsChannel:     partsCh,
-			normalTransferCh: normalTransferCh,
-			lowTransferCh:    lowTransferCh,
+			partsChannel: partsCh,
 		},
 		xferChannels: XferChannels{
-			partsChannel:     partsCh,
-			normalTransferCh: normalTransferCh,
-			lowTransferCh:    lowTransferCh,
-			normalChunckCh:   normalChunkCh,
-			lowChunkCh:       lowChunkCh,
-		},
-		poolSizingChannels: poolSizingChannels{ // all deliberately unbuffered, because pool sizer routine works in lock-step with these - processing them as they happen, never catching up on populated buffer later
-			entryNotificationCh: make(chan struct{}),
-			exitNotificationCh:  make(chan struct{}),
-			scalebackRequestCh:  make(chan struct{}),
-			requestSlowTuneCh:   make(chan struct{}),
+			partsChannel: partsCh,
 		},
 		concurrencyTuner: tuner,
 		pacer:            pacer,
 		slicePool:        slicePool,
-		cacheLimiter:     cacheLimiter,
+		cacheLimiter:     &jobCacheLimiter{scheduler: scheduler, job: scheduled},
 		fileCountLimiter: fileCountLimiter,
 		cpuMon:           cpuMon,
 		jstm:             &jstm,
+		scheduler:        scheduler,
+		scheduled:        scheduled,
 		isDaemon:         daemonMode,
 		/*Other fields remain zero-value until this job is scheduled */}
 	jm.Reset(appCtx, commandString)
 	// One routine constantly monitors the partsChannel.  It takes the JobPartManager from
 	// the Channel and schedules the transfers of that JobPart.
 	go jm.scheduleJobParts()
-	// In addition to the main pool (which is governed ja.poolSizer), we spin up a separate set of workers to process initiation of transfers
-	// (so that transfer initiation can't starve out progress on already-scheduled chunks.
-	// (Not sure whether that can really happen, but this protects against it anyway.)
-	// Perhaps MORE importantly, doing this separately gives us more CONTROL over how we interact with the file system.
-	for cc := 0; cc < concurrency.TransferInitiationPoolSize.Value; cc++ {
-		go jm.transferProcessor(cc)
-	}
 
 	go jm.reportJobPartDoneHandler()
 	go jm.handleStatusUpdateMessage()
@@ -206,12 +199,38 @@ func (jm *jobMgr) Reset(appCtx context.Context, commandString string) IJobMgr {
 	}
 	jm.logConcurrencyParameters()
 	jm.ctx, jm.cancel = context.WithCancel(appCtx)
+	jm.ctx, jm.span = common.StartJobSpan(jm.ctx, jm.jobID, "job")
 	atomic.StoreUint64(&jm.atomicNumberOfBytesCovered, 0)
 	atomic.StoreUint64(&jm.atomicTotalBytesToXfer, 0)
 	jm.partsDone = 0
 	return jm
 }
 
+// endSpan records the outcome of the job in its span, and ends it. A resumed job gets a new span, in the same trace
+func (jm *jobMgr) endSpan(status common.JobStatus, progress jobPartProgressInfo) {
+	jm.span.SetAttributes(
+		attribute.String("azcopy.job_status", status.String()),
+		attribute.Int64("azcopy.transfers_completed", int64(progress.transfersCompleted)),
+		attribute.Int64("azcopy.transfers_skipped", int64(progress.transfersSkipped)),
+		attribute.Int64("azcopy.transfers_failed", int64(progress.transfersFailed)))
+	if progress.transfersFailed > 0 {
+		jm.span.SetStatus(codes.Error, fmt.Sprintf("%d transfers failed", progress.transfersFailed))
+	}
+	jm.span.End()
+}
+
+// fireHook runs the job's hooks for the event, with the summary of the job
+func (jm *jobMgr) fireHook(event common.JobHookEvent, status common.JobStatus) {
+	hooks := jm.getInMemoryTransitJobState().Hooks
+	if hooks == nil {
+		return
+	}
+	summary := jm.PeekJobSummary()
+	summary.JobStatus = status
+	summary.FailedTransfers, summary.SkippedTransfers = nil, nil // they're only those since the last summary, so would mislead
+	hooks.Fire(common.JobHookPayload{Event: event, JobID: jm.jobID, JobStatus: status, Summary: &summary}, jm)
+}
+
 func (jm *jobMgr) logConcurrencyParameters() {
 	level := pipeline.LogWarning // log all this stuff at warning level, so that it can still be see it when running at that level. (It won't have the WARN prefix, because we don't add that)
 
@@ -269,7 +288,6 @@ type jobMgr struct {
 	atomicCurrentConcurrentConnections int64
 	/* Pool sizer related values */
 	atomicSuccessfulBytesInActiveFiles int64 // atomic 64-bit values should always be at the start of a struct to ensure alignment
-	atomicCurrentMainPoolSize          int32
 	// atomicAllTransfersScheduled defines whether all job parts have been iterated and resumed or not
 	atomicAllTransfersScheduled     int32
 	atomicFinalPartOrderedIndicator int32
@@ -281,6 +299,7 @@ type jobMgr struct {
 	jobID                common.JobID // The Job's unique ID
 	ctx                  context.Context
 	cancel               context.CancelFunc
+	span                 trace.Span // the span of the job, which those of its transfers are children of. Not recorded when tracing is off
 	pipelineNetworkStats *PipelineNetworkStats
 
 	// Share the same HTTP Client across all job parts, so that the we maximize re-use of
@@ -311,14 +330,20 @@ type jobMgr struct {
 
 	coordinatorChannels CoordinatorChannels
 	xferChannels        XferChannels
-	poolSizingChannels  poolSizingChannels
 	concurrencyTuner    ConcurrencyTuner
 	cpuMon              common.CPUMonitor
 	pacer               PacerAdmin
+	jobPacer            pacer // the shared pacer, or one that also applies the job's own cap
 	slicePool           common.ByteSlicePooler
 	cacheLimiter        common.CacheLimiter
 	fileCountLimiter    common.CacheLimiter
 	jstm                *jobStatusManager
+	// keeps why transfers failed, for 'jobs show'
+	transferErrors *transferErrorLog
+
+	// the engine is shared with the other jobs in the process
+	scheduler *JobScheduler
+	scheduled *scheduledJob
 
 	isDaemon bool /* is it running as service */
 }
@@ -402,7 +427,7 @@ func (jm *jobMgr) logPerfInfo(displayStrings []string, constraint common.PerfCon
 func (jm *jobMgr) AddJobPart(partNum PartNumber, planFile JobPartPlanFileName, existingPlanMMF *JobPartPlanMMF, sourceSAS string,
 	destinationSAS string, scheduleTransfers bool, completionChan chan struct{}) IJobPartMgr {
 	jpm := &jobPartMgr{jobMgr: jm, filename: planFile, sourceSAS: sourceSAS,
-		destinationSAS: destinationSAS, pacer: jm.pacer,
+		destinationSAS: destinationSAS,
 		slicePool:         jm.slicePool,
 		cacheLimiter:      jm.cacheLimiter,
 		fileCountLimiter:  jm.fileCountLimiter,
@@ -418,6 +443,10 @@ func (jm *jobMgr) AddJobPart(partNum PartNumber, planFile JobPartPlanFileName, e
 	jm.jobPartMgrs.Set(partNum, jpm)
 	jm.setFinalPartOrdered(partNum, jpm.planMMF.Plan().IsFinalPart)
 	jm.setDirection(jpm.Plan().FromTo)
+	if partNum == 0 && scheduleTransfers {
+		// a new job, rather than one that is being resurrected
+		jm.fireHook(common.EJobHookEvent.JobStarted(), common.EJobStatus.InProgress())
+	}
 
 	jm.initMu.Lock()
 	defer jm.initMu.Unlock()
@@ -430,7 +459,9 @@ func (jm *jobMgr) AddJobPart(partNum PartNumber, planFile JobPartPlanFileName, e
 			exclusiveDestinationMapHolder:  &atomic.Value{},
 		}
 		jm.initState.exclusiveDestinationMapHolder.Store(common.NewExclusiveStringMap(jpm.Plan().FromTo, runtime.GOOS))
+		jm.applySchedulingLimits(jpm.Plan())
 	}
+	jpm.pacer = jm.jobPacer
 	jpm.jobMgrInitState = jm.initState // so jpm can use it as much as desired without locking (since the only mutation is the init in jobManager. As far as jobPartManager is concerned, the init state is read-only
 	jpm.exclusiveDestinationMap = jm.getExclusiveDestinationMap(partNum, jpm.Plan().FromTo)
 
@@ -454,7 +485,6 @@ func (jm *jobMgr) AddJobOrder(order common.CopyJobPartOrderRequest) IJobPartMgr
 		filename:         jppfn,
 		sourceSAS:        order.SourceRoot.SAS,
 		destinationSAS:   order.DestinationRoot.SAS,
-		pacer:            jm.pacer,
 		slicePool:        jm.slicePool,
 		cacheLimiter:     jm.cacheLimiter,
 		fileCountLimiter: jm.fileCountLimiter,
@@ -476,7 +506,9 @@ func (jm *jobMgr) AddJobOrder(order common.CopyJobPartOrderRequest) IJobPartMgr
 			exclusiveDestinationMapHolder:  &atomic.Value{},
 		}
 		jm.initState.exclusiveDestinationMapHolder.Store(common.NewExclusiveStringMap(jpm.Plan().FromTo, runtime.GOOS))
+		jm.applySchedulingLimits(jpm.Plan())
 	}
+	jpm.pacer = jm.jobPacer
 	jpm.jobMgrInitState = jm.initState // so jpm can use it as much as desired without locking (since the only mutation is the init in jobManager. As far as jobPartManager is concerned, the init state is read-only
 	jpm.exclusiveDestinationMap = jm.getExclusiveDestinationMap(order.PartNum, jpm.Plan().FromTo)
 
@@ -617,16 +649,38 @@ func (jm *jobMgr) reportJobPartDoneHandler() {
 				jm.Log(pipeline.LogInfo, fmt.Sprintf("%s %s successfully completed, cancelled or paused", partDescription, jm.jobID.String()))
 			}
 
-			switch part0Plan.JobStatus() {
+			finalStatus := part0Plan.JobStatus()
+			switch finalStatus {
 			case common.EJobStatus.Cancelling():
-				part0Plan.SetJobStatus(common.EJobStatus.Cancelled())
-				if shouldLog {
-					jm.Log(pipeline.LogInfo, fmt.Sprintf("%s %v successfully cancelled", partDescription, jm.jobID))
-				}
+				finalStatus = common.EJobStatus.Cancelled()
 			case common.EJobStatus.InProgress():
-				part0Plan.SetJobStatus((common.EJobStatus).EnhanceJobStatusInfo(jobProgressInfo.transfersSkipped > 0,
+				finalStatus = (common.EJobStatus).EnhanceJobStatusInfo(jobProgressInfo.transfersSkipped > 0,
 					jobProgressInfo.transfersFailed > 0,
-					jobProgressInfo.transfersCompleted > 0))
+					jobProgressInfo.transfersCompleted > 0)
+			}
+
+			// The hooks are queued before the final status is set, since the front end may exit as soon as it sees
+			// that status, and closing the hooks as it does only waits for those that have been queued.
+			jm.endSpan(finalStatus, jobProgressInfo)
+			switch finalStatus {
+			case common.EJobStatus.Cancelled():
+				jm.fireHook(common.EJobHookEvent.JobCancelled(), finalStatus)
+			case common.EJobStatus.Paused():
+				// it's neither done nor cancelled, since it may be resumed
+			default:
+				jm.fireHook(common.EJobHookEvent.JobCompleted(), finalStatus)
+			}
+
+			if finalStatus != part0Plan.JobStatus() {
+				part0Plan.SetJobStatus(finalStatus)
+				if finalStatus == common.EJobStatus.Cancelled() && shouldLog {
+					jm.Log(pipeline.LogInfo, fmt.Sprintf("%s %v successfully cancelled", partDescription, jm.jobID))
+				}
+			}
+
+			// the job's own cap stops pacing until the job is resumed, if it ever is
+			if p, ok := jm.jobPacer.(*jobPacer); ok {
+				_ = p.Close()
 			}
 
 			// reset counters
@@ -656,10 +710,18 @@ func (jm *jobMgr) SetInMemoryTransitJobState(state InMemoryTransitJobState) {
 	jm.inMemoryTransitJobState = state
 }
 
+// recordTransferError keeps why a transfer failed, in the job's file of transfer errors
+func (jm *jobMgr) recordTransferError(partNum PartNumber, transferIndex uint32, e TransferError) error {
+	return jm.transferErrors.record(partNum, transferIndex, e)
+}
+
 func (jm *jobMgr) Context() context.Context                { return jm.ctx }
 func (jm *jobMgr) Cancel()                                 { jm.cancel() }
 func (jm *jobMgr) ShouldLog(level pipeline.LogLevel) bool  { return jm.logger.ShouldLog(level) }
 func (jm *jobMgr) Log(level pipeline.LogLevel, msg string) { jm.logger.Log(level, msg) }
+func (jm *jobMgr) LogWithFields(level pipeline.LogLevel, prefix string, msg string, fields common.LogFields) {
+	common.LogWithFields(jm.logger, level, prefix, msg, fields)
+}
 func (jm *jobMgr) PipelineLogInfo() pipeline.LogOptions {
 	return pipeline.LogOptions{
 		Log:       jm.Log,
@@ -686,17 +748,11 @@ func (jm *jobMgr) ChunkStatusLogger() common.ChunkStatusLogger {
 /* Infra ported to jobManager from JobsAdmin */
 
 type CoordinatorChannels struct {
-	partsChannel     chan<- IJobPartMgr         // Write Only
-	normalTransferCh chan<- IJobPartTransferMgr // Write-only
-	lowTransferCh    chan<- IJobPartTransferMgr // Write-only
+	partsChannel chan<- IJobPartMgr // Write Only
 }
 
 type XferChannels struct {
-	partsChannel     <-chan IJobPartMgr         // Read only
-	normalTransferCh <-chan IJobPartTransferMgr // Read-only
-	lowTransferCh    <-chan IJobPartTransferMgr // Read-only
-	normalChunckCh   chan chunkFunc             // Read-write
-	lowChunkCh       chan chunkFunc             // Read-write
+	partsChannel <-chan IJobPartMgr // Read only
 }
 
 type poolSizingChannels struct {
@@ -710,31 +766,30 @@ type poolSizingChannels struct {
 /* These functions are to integrate above into JobManager */
 
 func (jm *jobMgr) CurrentMainPoolSize() int {
-	return int(atomic.LoadInt32(&jm.atomicCurrentMainPoolSize))
-}
-
-func (jm *jobMgr) ScheduleTransfer(priority common.JobPriority, jptm IJobPartTransferMgr) {
-	switch priority { // priority determines which channel handles the job part's transfers
-	case common.EJobPriority.Normal():
-		// jptm.SetChunkChannel(ja.xferChannels.normalChunckCh)
-		jm.coordinatorChannels.normalTransferCh <- jptm
-	case common.EJobPriority.Low():
-		// jptm.SetChunkChannel(ja.xferChannels.lowChunkCh)
-		jm.coordinatorChannels.lowTransferCh <- jptm
-	default:
-		jm.Panic(fmt.Errorf("invalid priority: %q", priority))
-	}
+	return jm.scheduler.CurrentMainPoolSize()
 }
 
-func (jm *jobMgr) ScheduleChunk(priority common.JobPriority, chunkFunc chunkFunc) {
-	switch priority { // priority determines which channel handles the job part's transfers
-	case common.EJobPriority.Normal():
-		jm.xferChannels.normalChunckCh <- chunkFunc
-	case common.EJobPriority.Low():
-		jm.xferChannels.lowChunkCh <- chunkFunc
-	default:
-		jm.Panic(fmt.Errorf("invalid priority: %q", priority))
+// applySchedulingLimits gives the job the priority and caps that it was ordered with
+func (jm *jobMgr) applySchedulingLimits(plan *JobPartPlanHeader) {
+	jm.scheduler.setLimits(jm.scheduled, plan.Priority, plan.MaxConcurrency)
+
+	jm.jobPacer = jm.pacer
+	if plan.MaxBytesPerSecond > 0 {
+		jm.jobPacer = newJobPacer(jm.pacer, plan.MaxBytesPerSecond)
 	}
+
+	if plan.Priority != common.EJobPriority.Normal() || plan.MaxConcurrency > 0 || plan.MaxBytesPerSecond > 0 {
+		jm.Log(pipeline.LogWarning, fmt.Sprintf("Job priority: %s. Max concurrent network operations of this job: %d. Max bytes per second of this job: %d (zero means no cap)",
+			plan.Priority, plan.MaxConcurrency, plan.MaxBytesPerSecond))
+	}
+}
+
+func (jm *jobMgr) ScheduleTransfer(jptm IJobPartTransferMgr) {
+	jm.scheduler.scheduleTransfer(jm.scheduled, jptm)
+}
+
+func (jm *jobMgr) ScheduleChunk(chunkFunc chunkFunc) {
+	jm.scheduler.scheduleChunk(jm.scheduled, chunkFunc)
 }
 
 // QueueJobParts puts the given JobPartManager into the partChannel
@@ -744,180 +799,22 @@ func (jm *jobMgr) QueueJobParts(jpm IJobPartMgr) {
 	jm.coordinatorChannels.partsChannel <- jpm
 }
 
-// worker that sizes the chunkProcessor pool, dynamically if necessary
-func (jm *jobMgr) poolSizer() {
-
-	logConcurrency := func(targetConcurrency int, reason string) {
-		switch reason {
-		case ConcurrencyReasonNone,
-			concurrencyReasonFinished,
-			ConcurrencyReasonTunerDisabled:
-			return
-		default:
-			msg := fmt.Sprintf("Trying %d concurrent connections (%s)", targetConcurrency, reason)
-			common.GetLifecycleMgr().Info(msg)
-			jm.Log(pipeline.LogWarning, msg)
-		}
-	}
-
-	nextWorkerId := 0
-	actualConcurrency := 0
-	lastBytesOnWire := int64(0)
-	lastBytesTime := time.Now()
-	hasHadTimeToStablize := false
-	initialMonitoringInterval := time.Duration(4 * time.Second)
-	expandedMonitoringInterval := time.Duration(8 * time.Second)
-	throughputMonitoringInterval := initialMonitoringInterval
-	slowTuneCh := jm.poolSizingChannels.requestSlowTuneCh
-
-	// get initial pool size
-	targetConcurrency, reason := jm.concurrencyTuner.GetRecommendedConcurrency(-1, jm.cpuMon.CPUContentionExists())
-	logConcurrency(targetConcurrency, reason)
-
-	// loop for ever, driving the actual concurrency towards the most up-to-date target
-	for {
-		// add or remove a worker if necessary
-		if actualConcurrency < targetConcurrency {
-			hasHadTimeToStablize = false
-			nextWorkerId++
-			go jm.chunkProcessor(nextWorkerId) // TODO: make sure this numbering is OK, even if we grow and shrink the pool (the id values don't matter right?)
-		} else if actualConcurrency > targetConcurrency {
-			hasHadTimeToStablize = false
-			jm.poolSizingChannels.scalebackRequestCh <- struct{}{}
-		}
-
-		// wait for something to happen (maybe ack from the worker of the change, else a timer interval)
-		select {
-		case <-jm.poolSizingChannels.entryNotificationCh:
-			// new worker has started
-			actualConcurrency++
-			atomic.StoreInt32(&jm.atomicCurrentMainPoolSize, int32(actualConcurrency))
-		case <-jm.poolSizingChannels.exitNotificationCh:
-			// worker has exited
-			actualConcurrency--
-			atomic.StoreInt32(&jm.atomicCurrentMainPoolSize, int32(actualConcurrency))
-		case <-slowTuneCh:
-			// we've been asked to tune more slowly
-			// TODO: confirm we don't need this: expandedMonitoringInterval *= 2
-			throughputMonitoringInterval = expandedMonitoringInterval
-			slowTuneCh = nil // so we won't keep running this case at the expense of others)
-		case <-time.After(throughputMonitoringInterval):
-			if actualConcurrency == targetConcurrency { // scalebacks can take time. Don't want to do any tuning if actual is not yet aligned to target
-				bytesOnWire := jm.pacer.GetTotalTraffic()
-				if hasHadTimeToStablize {
-					// throughput has had time to stabilize since last change, so we can meaningfully measure and act on throughput
-					elapsedSeconds := time.Since(lastBytesTime).Seconds()
-					bytes := bytesOnWire - lastBytesOnWire
-					megabitsPerSec := (8 * float64(bytes) / elapsedSeconds) / (1000 * 1000)
-					if megabitsPerSec > 4000 {
-						throughputMonitoringInterval = expandedMonitoringInterval // start averaging throughputs over longer time period, since in some tests it takes a little longer to get a good average
-					}
-					targetConcurrency, reason = jm.concurrencyTuner.GetRecommendedConcurrency(int(megabitsPerSec), jm.cpuMon.CPUContentionExists())
-					logConcurrency(targetConcurrency, reason)
-				} else {
-					// we weren't in steady state before, but given that throughputMonitoringInterval has now elapsed,
-					// we'll deem that we are in steady state now (so can start measuring throughput from now)
-					hasHadTimeToStablize = true
-				}
-				lastBytesOnWire = bytesOnWire
-				lastBytesTime = time.Now()
-			}
-		}
-	}
-}
-
 // RequestTuneSlowly is used to ask for a slower rate of auto-concurrency tuning.
-// Necessary because if there's a download or S2S transfer going on, we need to measure throughputs over longer intervals to make
-// the auto tuning work.
 func (jm *jobMgr) RequestTuneSlowly() {
-	select {
-	case jm.poolSizingChannels.requestSlowTuneCh <- struct{}{}:
-	default:
-		return // channel already full, so don't need to add our signal there too, it already has one
-	}
+	jm.scheduler.RequestTuneSlowly()
 }
 
 func (jm *jobMgr) scheduleJobParts() {
-	startedPoolSizer := false
 	for {
 		jobPart := <-jm.xferChannels.partsChannel
 
-		if !startedPoolSizer {
-			// spin up a GR to co-ordinate dynamic sizing of the main pool
-			// It will automatically spin up the right number of chunk processors
-			go jm.poolSizer()
-			startedPoolSizer = true
-		}
+		// spin up the main pool, if no job has done so yet.
+		// It will automatically spin up the right number of chunk processors
+		jm.scheduler.startPoolSizer()
 		jobPart.ScheduleTransfers(jm.Context())
 	}
 }
 
-// general purpose worker that reads in schedules chunk jobs, and executes chunk jobs
-func (jm *jobMgr) chunkProcessor(workerID int) {
-	jm.poolSizingChannels.entryNotificationCh <- struct{}{}                   // say we have started
-	defer func() { jm.poolSizingChannels.exitNotificationCh <- struct{}{} }() // say we have exited
-
-	for {
-		// We check for scalebacks first to shrink goroutine pool
-		// Then, we check chunks: normal & low priority
-		select {
-		case <-jm.poolSizingChannels.scalebackRequestCh:
-			return
-		default:
-			select {
-			case chunkFunc := <-jm.xferChannels.normalChunckCh:
-				chunkFunc(workerID)
-			default:
-				select {
-				case chunkFunc := <-jm.xferChannels.lowChunkCh:
-					chunkFunc(workerID)
-				default:
-					time.Sleep(100 * time.Millisecond) // Sleep before looping around
-					// TODO: Question: In order to safely support high goroutine counts,
-					// do we need to review sleep duration, or find an approach that does not require waking every x milliseconds
-					// For now, duration has been increased substantially from the previous 1 ms, to reduce cost of
-					// the wake-ups.
-				}
-			}
-		}
-	}
-}
-
-// separate from the chunkProcessor, this dedicated worker that reads in and executes transfer initiation jobs
-// (which in turn schedule chunks that get picked up by chunkProcessor)
-func (jm *jobMgr) transferProcessor(workerID int) {
-	startTransfer := func(jptm IJobPartTransferMgr) {
-		if jptm.WasCanceled() {
-			if jptm.ShouldLog(pipeline.LogInfo) {
-				jptm.Log(pipeline.LogInfo, fmt.Sprintf(" is not picked up worked %d because transfer was cancelled", workerID))
-			}
-			jptm.SetStatus(common.ETransferStatus.Cancelled())
-			jptm.ReportTransferDone()
-		} else {
-			// TODO fix preceding space
-			if jptm.ShouldLog(pipeline.LogInfo) {
-				jptm.Log(pipeline.LogInfo, fmt.Sprintf("has worker %d which is processing TRANSFER %d", workerID, jptm.(*jobPartTransferMgr).transferIndex))
-			}
-			jptm.StartJobXfer()
-		}
-	}
-
-	for {
-		// No scaleback check here, because this routine runs only in a small number of goroutines, so no need to kill them off
-		select {
-		case jptm := <-jm.xferChannels.normalTransferCh:
-			startTransfer(jptm)
-		default:
-			select {
-			case jptm := <-jm.xferChannels.lowTransferCh:
-				startTransfer(jptm)
-			default:
-				time.Sleep(10 * time.Millisecond) // Sleep before looping around
-			}
-		}
-	}
-}
-
 func (jm *jobMgr) IterateJobParts(readonly bool, f func(k common.PartNumber, v IJobPartMgr)) {
 	jm.jobPartMgrs.Iterate(readonly, f)
 }
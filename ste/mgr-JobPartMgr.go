@@ -10,6 +10,7 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -60,6 +61,9 @@ type IJobPartMgr interface {
 	CpkInfo() common.CpkInfo
 	CpkScopeInfo() common.CpkScopeInfo
 	IsSourceEncrypted() bool
+	PageDiffBaseline() string
+	DiskImageFormat() common.DiskImageFormat
+	ShouldFollowSource() bool
 	/* Status Manager Updates */
 	SendXferDoneMsg(msg xferDoneMsg)
 }
@@ -166,8 +170,10 @@ func NewBlobPipeline(c azblob.Credential, o azblob.PipelineOptions, r XferRetryO
 	f := []pipeline.Factory{
 		azblob.NewTelemetryPolicyFactory(o.Telemetry),
 		azblob.NewUniqueRequestIDPolicyFactory(),
+		newTryTrackingPolicyFactory(),       // let the stats policy tell retries from first tries
 		NewBlobXferRetryPolicyFactory(r),    // actually retry the operation
 		newRetryNotificationPolicyFactory(), // record that a retry status was returned
+		newTracePolicyFactory(),             // trace each try, and propagate the trace context to the service
 		c,
 		pipeline.MethodFactoryMarker(), // indicates at what stage in the pipeline the method factory is invoked
 		// NewPacerPolicyFactory(p),
@@ -191,8 +197,10 @@ func NewBlobFSPipeline(c azbfs.Credential, o azbfs.PipelineOptions, r XferRetryO
 	f := []pipeline.Factory{
 		azbfs.NewTelemetryPolicyFactory(o.Telemetry),
 		azbfs.NewUniqueRequestIDPolicyFactory(),
+		newTryTrackingPolicyFactory(),       // let the stats policy tell retries from first tries
 		NewBFSXferRetryPolicyFactory(r),     // actually retry the operation
 		newRetryNotificationPolicyFactory(), // record that a retry status was returned
+		newTracePolicyFactory(),             // trace each try, and propagate the trace context to the service
 	}
 
 	f = append(f, c)
@@ -217,8 +225,10 @@ func NewFilePipeline(c azfile.Credential, o azfile.PipelineOptions, r azfile.Ret
 	f := []pipeline.Factory{
 		azfile.NewTelemetryPolicyFactory(o.Telemetry),
 		azfile.NewUniqueRequestIDPolicyFactory(),
+		newTryTrackingPolicyFactory(),       // let the stats policy tell retries from first tries
 		azfile.NewRetryPolicyFactory(r),     // actually retry the operation
 		newRetryNotificationPolicyFactory(), // record that a retry status was returned
+		newTracePolicyFactory(),             // trace each try, and propagate the trace context to the service
 		c,
 		pipeline.MethodFactoryMarker(), // indicates at what stage in the pipeline the method factory is invoked
 		NewVersionPolicyFactory(),
@@ -300,6 +310,9 @@ type jobPartMgr struct {
 	// TODO: Ditto
 	secondarySourceProviderPipeline pipeline.Pipeline
 
+	// batches the deletes and tier changes of blobs. Nil if the job doesn't delete blobs or change their tiers.
+	blobBatcher *blobBatcher
+
 	// used defensively to protect double init
 	atomicPipelinesInitedIndicator uint32
 
@@ -313,6 +326,15 @@ type jobPartMgr struct {
 
 	cpkOptions common.CpkOptions
 
+	// snapshot from which page blobs are incrementally copied. Empty if copies are not incremental
+	pageDiffBaseline string
+
+	// format of the disk images that are converted to fixed VHDs as they are uploaded. None if no conversion is needed
+	diskImageFormat common.DiskImageFormat
+
+	// if true, uploads to append blobs keep following their source files until the job is cancelled
+	followSource bool
+
 	closeOnCompletion chan struct{}
 }
 
@@ -392,6 +414,10 @@ func (jpm *jobPartMgr) ScheduleTransfers(jobCtx context.Context) {
 		IsSourceEncrypted: dstData.IsSourceEncrypted,
 	}
 
+	jpm.pageDiffBaseline = string(dstData.PageDiffBaseline[:dstData.PageDiffBaselineLength])
+	jpm.diskImageFormat = dstData.DiskImageFormat
+	jpm.followSource = dstData.FollowSource
+
 	jpm.preserveLastModifiedTime = plan.DstLocalData.PreserveLastModifiedTime
 
 	jpm.blobTypeOverride = plan.DstBlobData.BlobType
@@ -440,6 +466,8 @@ func (jpm *jobPartMgr) ScheduleTransfers(jobCtx context.Context) {
 			transferIndex:       t,
 			ctx:                 transferCtx,
 			cancel:              transferCancel,
+			logFieldsOnce:       &sync.Once{},
+			resultLock:          &sync.Mutex{},
 			// TODO: insert the factory func interface in jptm.
 			// numChunks will be set by the transfer's prologue method
 		}
@@ -478,7 +506,7 @@ func (jpm *jobPartMgr) ScheduleTransfers(jobCtx context.Context) {
 			}
 		}
 		// ===== TEST KNOB
-		jpm.jobMgr.ScheduleTransfer(jpm.priority, jptm)
+		jpm.jobMgr.ScheduleTransfer(jptm)
 
 		// This sets the atomic variable atomicAllTransfersScheduled to 1
 		// atomicAllTransfersScheduled variables is used in case of resume job
@@ -495,11 +523,11 @@ func (jpm *jobPartMgr) ScheduleTransfers(jobCtx context.Context) {
 }
 
 func (jpm *jobPartMgr) ScheduleChunks(chunkFunc chunkFunc) {
-	jpm.jobMgr.ScheduleChunk(jpm.priority, chunkFunc)
+	jpm.jobMgr.ScheduleChunk(chunkFunc)
 }
 
 func (jpm *jobPartMgr) RescheduleTransfer(jptm IJobPartTransferMgr) {
-	jpm.jobMgr.ScheduleTransfer(jpm.priority, jptm)
+	jpm.jobMgr.ScheduleTransfer(jptm)
 }
 
 func (jpm *jobPartMgr) createPipelines(ctx context.Context) {
@@ -608,7 +636,7 @@ func (jpm *jobPartMgr) createPipelines(ctx context.Context) {
 
 	// Create pipeline for data transfer.
 	switch fromTo {
-	case common.EFromTo.BlobTrash(), common.EFromTo.BlobLocal(), common.EFromTo.LocalBlob(), common.EFromTo.BenchmarkBlob(),
+	case common.EFromTo.BlobTrash(), common.EFromTo.BlobNone(), common.EFromTo.BlobLocal(), common.EFromTo.LocalBlob(), common.EFromTo.BenchmarkBlob(),
 		common.EFromTo.BlobBlob(), common.EFromTo.FileBlob(), common.EFromTo.S3Blob(), common.EFromTo.GCPBlob():
 		credential := common.CreateBlobCredential(ctx, credInfo, credOption)
 		jpm.Log(pipeline.LogInfo, fmt.Sprintf("JobID=%v, credential type: %v", jpm.Plan().JobID, credInfo.CredentialType))
@@ -625,6 +653,10 @@ func (jpm *jobPartMgr) createPipelines(ctx context.Context) {
 			jpm.jobMgr.HttpClient(),
 			jpm.jobMgr.PipelineNetworkStats())
 
+		if fromTo == common.EFromTo.BlobTrash() || fromTo == common.EFromTo.BlobNone() {
+			jpm.blobBatcher = newBlobBatcher(ctx, jpm.pipeline, credential)
+		}
+
 		// Consider the ADLSG2->ADLSG2 ACLs case
 		if fromTo == common.EFromTo.BlobBlob() && jpm.Plan().PreservePermissions.IsTruthy() {
 			credential := common.CreateBlobFSCredential(ctx, credInfo, credOption)
@@ -795,6 +827,18 @@ func (jpm *jobPartMgr) IsSourceEncrypted() bool {
 	return jpm.cpkOptions.IsSourceEncrypted
 }
 
+func (jpm *jobPartMgr) PageDiffBaseline() string {
+	return jpm.pageDiffBaseline
+}
+
+func (jpm *jobPartMgr) DiskImageFormat() common.DiskImageFormat {
+	return jpm.diskImageFormat
+}
+
+func (jpm *jobPartMgr) ShouldFollowSource() bool {
+	return jpm.followSource
+}
+
 func (jpm *jobPartMgr) ShouldPutMd5() bool {
 	return jpm.putMd5
 }
@@ -831,6 +875,18 @@ func (jpm *jobPartMgr) permanentDeleteOption() common.PermanentDeleteOption {
 	return jpm.Plan().PermanentDeleteOption
 }
 
+func (jpm *jobPartMgr) setPropertiesFlags() common.SetPropertiesFlags {
+	return jpm.Plan().SetPropertiesFlags
+}
+
+func (jpm *jobPartMgr) rehydratePriority() common.RehydratePriorityType {
+	return jpm.Plan().RehydratePriority
+}
+
+func (jpm *jobPartMgr) deleteSourceOnSuccess() bool {
+	return jpm.Plan().DeleteSourceOnSuccess
+}
+
 func (jpm *jobPartMgr) updateJobPartProgress(status common.TransferStatus) {
 	switch status {
 	case common.ETransferStatus.Success():
@@ -898,6 +954,9 @@ func (jpm *jobPartMgr) ReleaseAConnection() {
 func (jpm *jobPartMgr) ShouldLog(level pipeline.LogLevel) bool  { return jpm.jobMgr.ShouldLog(level) }
 func (jpm *jobPartMgr) Log(level pipeline.LogLevel, msg string) { jpm.jobMgr.Log(level, msg) }
 func (jpm *jobPartMgr) Panic(err error)                         { jpm.jobMgr.Panic(err) }
+func (jpm *jobPartMgr) LogWithFields(level pipeline.LogLevel, prefix string, msg string, fields common.LogFields) {
+	common.LogWithFields(jpm.jobMgr, level, prefix, msg, fields)
+}
 func (jpm *jobPartMgr) ChunkStatusLogger() common.ChunkStatusLogger {
 	return jpm.jobMgr.ChunkStatusLogger()
 }
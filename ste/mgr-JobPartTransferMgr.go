@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -15,6 +16,9 @@ import (
 	"github.com/Azure/azure-storage-azcopy/v10/common"
 	"github.com/Azure/azure-storage-blob-go/azblob"
 	"github.com/Azure/azure-storage-file-go/azfile"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type IJobPartTransferMgr interface {
@@ -44,6 +48,8 @@ type IJobPartTransferMgr interface {
 	TransferStatusIgnoringCancellation() common.TransferStatus
 	SetStatus(status common.TransferStatus)
 	SetErrorCode(errorCode int32)
+	SetContentMD5(md5 []byte)
+	SetDestinationVersionID(versionID string)
 	SetNumberOfChunks(numChunks uint32)
 	SetActionAfterLastChunk(f func())
 	ReportTransferDone() uint32
@@ -84,6 +90,10 @@ type IJobPartTransferMgr interface {
 	common.ILogger
 	DeleteSnapshotsOption() common.DeleteSnapshotsOption
 	PermanentDeleteOption() common.PermanentDeleteOption
+	SetPropertiesFlags() common.SetPropertiesFlags
+	RehydratePriority() common.RehydratePriorityType
+	DeleteSourceOnSuccess() bool
+	BlobBatcher() *blobBatcher
 	SecurityInfoPersistenceManager() *securityInfoPersistenceManager
 	FolderDeletionManager() common.FolderDeletionManager
 	GetDestinationRoot() string
@@ -91,7 +101,19 @@ type IJobPartTransferMgr interface {
 	CpkInfo() common.CpkInfo
 	CpkScopeInfo() common.CpkScopeInfo
 	IsSourceEncrypted() bool
+	PageDiffBaseline() string
+	PageDiffBaselineSAS() string
+	DiskImageFormat() common.DiskImageFormat
 	GetS2SSourceBlobTokenCredential() azblob.TokenCredential
+	TransferIndex() (partNum common.PartNumber, transferIndex uint32)
+	ChunksPersisted() uint32
+	ReportChunkPersisted()
+	ResumeOffset() int64
+	SetResumeOffset(offset int64)
+	ResetResumeState()
+	ShouldFollowSource() bool
+	FollowState() (fileID uint64, blobOffset int64)
+	SetFollowState(fileID uint64, blobOffset int64)
 }
 
 type TransferInfo struct {
@@ -115,6 +137,9 @@ type TransferInfo struct {
 	SrcBlobType    azblob.BlobType       // used for both S2S and for downloads to local from blob
 	S2SSrcBlobTier azblob.AccessTierType // AccessTierType (string) is used to accommodate service-side support matrix change.
 
+	// SrcBlobVersionID is also in the query of Source, when the source is a version of a blob
+	SrcBlobVersionID string
+
 	// NumChunks is the number of chunks in which transfer will be split into while uploading the transfer.
 	// NumChunks is not used in case of AppendBlob transfer.
 	NumChunks uint16
@@ -189,10 +214,24 @@ type jobPartTransferMgr struct {
 	// Call cancel to cancel the transfer
 	cancel context.CancelFunc
 
+	// the span of the transfer, which is the parent of those of its phases and requests. Nil when tracing is off
+	span trace.Span
+
+	// the fields that say which transfer a log entry is about, in the JSON log format
+	logFieldsOnce *sync.Once
+	logFields     common.LogFields
+
 	numChunks uint32
 
 	transferInfo *TransferInfo
 
+	// when the transfer was started, and what's known of its result, for the stream of transfer events
+	startTime            time.Time
+	resultLock           *sync.Mutex
+	contentMD5           []byte
+	destinationVersionID string
+	lastError            TransferError
+
 	actionAfterLastChunk func()
 
 	/*
@@ -231,9 +270,44 @@ func (jptm *jobPartTransferMgr) FromTo() common.FromTo {
 }
 
 func (jptm *jobPartTransferMgr) StartJobXfer() {
+	jptm.startTime = time.Now()
+	jptm.startSpan()
+	// so that the request log policy can tell which transfer each request is for
+	jptm.ctx = common.WithLogContext(jptm.ctx, jptm.jobPartMgr, jptm.transferLogFields())
 	jptm.jobPartMgr.StartJobXfer(jptm)
 }
 
+// startSpan starts the span of the transfer, as a child of the span of the job.
+// It's done here, rather than when the transfer is scheduled, so that the time spent waiting for a worker isn't included
+func (jptm *jobPartTransferMgr) startSpan() {
+	jptm.ctx, jptm.span = common.Tracer().Start(jptm.ctx, "transfer", trace.WithSpanKind(trace.SpanKindInternal))
+	if !jptm.span.IsRecording() {
+		return
+	}
+	info := jptm.Info()
+	partNum, transferIndex := jptm.TransferIndex()
+	jptm.span.SetAttributes(
+		attribute.String("azcopy.source", common.URLStringExtension(info.Source).RedactSecretQueryParamForLogging()),
+		attribute.String("azcopy.destination", common.URLStringExtension(info.Destination).RedactSecretQueryParamForLogging()),
+		attribute.Int64("azcopy.size", info.SourceSize),
+		attribute.Int64("azcopy.part_number", int64(partNum)),
+		attribute.Int64("azcopy.transfer_index", int64(transferIndex)))
+}
+
+// endSpan records the outcome of the transfer in its span, and ends it
+func (jptm *jobPartTransferMgr) endSpan(status common.TransferStatus) {
+	if jptm.span == nil {
+		return // the transfer never started
+	}
+	jptm.span.SetAttributes(attribute.String("azcopy.transfer_status", status.String()))
+	switch status {
+	case common.ETransferStatus.Failed(), common.ETransferStatus.BlobTierFailure():
+		jptm.span.SetAttributes(attribute.Int("azcopy.error_code", int(jptm.ErrorCode())))
+		jptm.span.SetStatus(codes.Error, fmt.Sprintf("transfer %s with status code %d", status.String(), jptm.ErrorCode()))
+	}
+	jptm.span.End()
+}
+
 func (jptm *jobPartTransferMgr) GetOverwriteOption() common.OverwriteOption {
 	return jptm.jobPartMgr.GetOverwriteOption()
 }
@@ -303,6 +377,7 @@ func (jptm *jobPartTransferMgr) Info() TransferInfo {
 		src = sUrl.String()
 	}
 
+	srcBlobVersionID := versionID
 	if versionID != "" {
 		versionID = "versionId=" + versionID
 		sURL, e := url.Parse(src)
@@ -380,8 +455,9 @@ func (jptm *jobPartTransferMgr) Info() TransferInfo {
 			SrcMetadata:    srcMetadata,
 			SrcBlobTags:    srcBlobTags,
 		},
-		SrcBlobType:    srcBlobType,
-		S2SSrcBlobTier: srcBlobTier,
+		SrcBlobType:      srcBlobType,
+		S2SSrcBlobTier:   srcBlobTier,
+		SrcBlobVersionID: srcBlobVersionID,
 	}
 
 	return *jptm.transferInfo
@@ -514,6 +590,22 @@ func (jptm *jobPartTransferMgr) PermanentDeleteOption() common.PermanentDeleteOp
 	return jptm.jobPartMgr.(*jobPartMgr).permanentDeleteOption()
 }
 
+func (jptm *jobPartTransferMgr) SetPropertiesFlags() common.SetPropertiesFlags {
+	return jptm.jobPartMgr.(*jobPartMgr).setPropertiesFlags()
+}
+
+func (jptm *jobPartTransferMgr) RehydratePriority() common.RehydratePriorityType {
+	return jptm.jobPartMgr.(*jobPartMgr).rehydratePriority()
+}
+
+func (jptm *jobPartTransferMgr) DeleteSourceOnSuccess() bool {
+	return jptm.jobPartMgr.(*jobPartMgr).deleteSourceOnSuccess()
+}
+
+func (jptm *jobPartTransferMgr) BlobBatcher() *blobBatcher {
+	return jptm.jobPartMgr.(*jobPartMgr).blobBatcher
+}
+
 func (jptm *jobPartTransferMgr) BlobTypeOverride() common.BlobType {
 	return jptm.jobPartMgr.BlobTypeOverride()
 }
@@ -534,6 +626,19 @@ func (jptm *jobPartTransferMgr) IsSourceEncrypted() bool {
 	return jptm.jobPartMgr.IsSourceEncrypted()
 }
 
+func (jptm *jobPartTransferMgr) PageDiffBaseline() string {
+	return jptm.jobPartMgr.PageDiffBaseline()
+}
+
+// PageDiffBaselineSAS returns the SAS of the page diff baseline's URL, which isn't kept in the plan
+func (jptm *jobPartTransferMgr) PageDiffBaselineSAS() string {
+	return jptm.jobPartMgr.(*jobPartMgr).jobMgr.getInMemoryTransitJobState().PageDiffBaselineSAS
+}
+
+func (jptm *jobPartTransferMgr) DiskImageFormat() common.DiskImageFormat {
+	return jptm.jobPartMgr.DiskImageFormat()
+}
+
 // JobHasLowFileCount returns an estimate of whether we only have a very small number of files in the overall job
 // (An "estimate" because it actually only looks at the current job part)
 func (jptm *jobPartTransferMgr) JobHasLowFileCount() bool {
@@ -545,6 +650,35 @@ func (jptm *jobPartTransferMgr) JobHasLowFileCount() bool {
 	return jptm.jobPartMgr.Plan().NumTransfers < lowFileCountThreshold
 }
 
+// noteError keeps the error that was logged last for the transfer, which is what's recorded as why it failed, if it does
+func (jptm *jobPartTransferMgr) noteError(e TransferError) {
+	jptm.resultLock.Lock()
+	jptm.lastError = e
+	jptm.resultLock.Unlock()
+}
+
+// recordError keeps why the transfer failed in the job's file of transfer errors, so that it outlives the log
+func (jptm *jobPartTransferMgr) recordError(e TransferError) {
+	partNum, transferIndex := jptm.TransferIndex()
+	if err := jptm.jobPartMgr.(*jobPartMgr).jobMgr.recordTransferError(partNum, transferIndex, e); err != nil {
+		jptm.Log(pipeline.LogWarning, "Failed to record the error of the transfer for 'jobs show': "+err.Error())
+	}
+}
+
+// SetContentMD5 records the hash that was computed for the content, e.g. as it was uploaded
+func (jptm *jobPartTransferMgr) SetContentMD5(md5 []byte) {
+	jptm.resultLock.Lock()
+	defer jptm.resultLock.Unlock()
+	jptm.contentMD5 = md5
+}
+
+// SetDestinationVersionID records the version ID of the blob that the transfer created, when the destination has versioning on
+func (jptm *jobPartTransferMgr) SetDestinationVersionID(versionID string) {
+	jptm.resultLock.Lock()
+	defer jptm.resultLock.Unlock()
+	jptm.destinationVersionID = versionID
+}
+
 func (jptm *jobPartTransferMgr) SetNumberOfChunks(numChunks uint32) {
 	jptm.numChunks = numChunks
 }
@@ -603,6 +737,52 @@ func (jptm *jobPartTransferMgr) SetStatus(status common.TransferStatus) {
 	jptm.jobPartPlanTransfer.SetTransferStatus(status, false)
 }
 
+// TransferIndex returns the position of this transfer within its job, which (together with the job ID) uniquely
+// identifies it across runs of the job.
+func (jptm *jobPartTransferMgr) TransferIndex() (partNum common.PartNumber, transferIndex uint32) {
+	return jptm.jobPartMgr.Plan().PartNum, jptm.transferIndex
+}
+
+// ChunksPersisted returns the number of chunks of this transfer that have been sent to the destination
+// by this run or by any earlier run of the job.
+func (jptm *jobPartTransferMgr) ChunksPersisted() uint32 {
+	return jptm.jobPartPlanTransfer.ChunksPersisted()
+}
+
+// ReportChunkPersisted records, in the plan file, that a chunk has been durably sent to the destination
+func (jptm *jobPartTransferMgr) ReportChunkPersisted() {
+	jptm.jobPartPlanTransfer.AddChunkPersisted()
+}
+
+// ResumeOffset returns the offset from which an interrupted download of this transfer can continue
+func (jptm *jobPartTransferMgr) ResumeOffset() int64 {
+	return jptm.jobPartPlanTransfer.ResumeOffset()
+}
+
+// SetResumeOffset records, in the plan file, how much of the destination file is known to be completely written
+func (jptm *jobPartTransferMgr) SetResumeOffset(offset int64) {
+	jptm.jobPartPlanTransfer.SetResumeOffset(offset)
+}
+
+func (jptm *jobPartTransferMgr) ShouldFollowSource() bool {
+	return jptm.jobPartMgr.ShouldFollowSource()
+}
+
+// FollowState returns the local file that this transfer is following (if any), and where its content starts in the destination
+func (jptm *jobPartTransferMgr) FollowState() (fileID uint64, blobOffset int64) {
+	return jptm.jobPartPlanTransfer.FollowState()
+}
+
+// SetFollowState records, in the plan file, which local file this transfer is following
+func (jptm *jobPartTransferMgr) SetFollowState(fileID uint64, blobOffset int64) {
+	jptm.jobPartPlanTransfer.SetFollowState(fileID, blobOffset)
+}
+
+// ResetResumeState discards any record of partial progress, so that the next run starts this transfer from scratch
+func (jptm *jobPartTransferMgr) ResetResumeState() {
+	jptm.jobPartPlanTransfer.ResetResumeState()
+}
+
 // SetErrorCode updates the errorcode of transfer for given jobId and partNumber.
 func (jptm *jobPartTransferMgr) ErrorCode() int32 {
 	return jptm.jobPartPlanTransfer.ErrorCode()
@@ -778,6 +958,7 @@ func (jptm *jobPartTransferMgr) failActiveTransfer(typ transferErrorCode, descri
 		jptm.logTransferError(typ, jptm.Info().Source, jptm.Info().Destination, fullMsg, status)
 		jptm.SetStatus(failureStatus)
 		jptm.SetErrorCode(int32(status)) // TODO: what are the rules about when this needs to be set, and doesn't need to be (e.g. for earlier failures)?
+		jptm.noteError(TransferError{HTTPStatus: status, ErrorCode: serviceCode, Message: fmt.Sprintf("%s. When %s", msg, descriptionOfWhereErrorOccurred)})
 		// If the status code was 403, it means there was an authentication error and we exit.
 		// User can resume the job if completely ordered with a new sas.
 		if status == http.StatusForbidden &&
@@ -808,8 +989,33 @@ func (jptm *jobPartTransferMgr) PipelineLogInfo() pipeline.LogOptions {
 }
 
 func (jptm *jobPartTransferMgr) Log(level pipeline.LogLevel, msg string) {
+	jptm.logWithFields(level, msg, common.LogFields{})
+}
+
+// logWithFields logs a message about this transfer. The text format starts it with the level and the transfer,
+// while the JSON format has fields for the transfer, its source and its destination.
+func (jptm *jobPartTransferMgr) logWithFields(level pipeline.LogLevel, msg string, fields common.LogFields) {
 	plan := jptm.jobPartMgr.Plan()
-	jptm.jobPartMgr.Log(level, fmt.Sprintf("%s: [P#%d-T#%d] ", common.LogLevel(level), plan.PartNum, jptm.transferIndex)+msg)
+	transfer := jptm.transferLogFields()
+	fields.PartNumber, fields.TransferIndex = transfer.PartNumber, transfer.TransferIndex
+	fields.Source, fields.Destination = transfer.Source, transfer.Destination
+	common.LogWithFields(jptm.jobPartMgr, level, fmt.Sprintf("%s: [P#%d-T#%d] ", common.LogLevel(level), plan.PartNum, jptm.transferIndex), msg, fields)
+}
+
+// transferLogFields are the fields that say which transfer a log entry is about
+func (jptm *jobPartTransferMgr) transferLogFields() common.LogFields {
+	jptm.logFieldsOnce.Do(func() {
+		partNum, transferIndex := jptm.TransferIndex()
+		p := uint32(partNum)
+		info := jptm.Info()
+		jptm.logFields = common.LogFields{
+			PartNumber:    &p,
+			TransferIndex: &transferIndex,
+			Source:        common.URLStringExtension(info.Source).RedactSecretQueryParamForLogging(),
+			Destination:   common.URLStringExtension(info.Destination).RedactSecretQueryParamForLogging(),
+		}
+	})
+	return jptm.logFields
 }
 
 func (jptm *jobPartTransferMgr) ErrorCodeAndString(err error) (int, string) {
@@ -848,7 +1054,8 @@ func (jptm *jobPartTransferMgr) logTransferError(errorCode transferErrorCode, so
 	info := jptm.Info() // TODO we are getting a lot of Info calls and its (presumably) not well-optimized.  Profile that?
 	msg := fmt.Sprintf("%v: %v", errorCode, info.entityTypeLogIndicator()) + common.URLStringExtension(source).RedactSecretQueryParamForLogging() +
 		fmt.Sprintf(" : %03d : %s\n   Dst: ", status, errorMsg) + common.URLStringExtension(destination).RedactSecretQueryParamForLogging()
-	jptm.Log(pipeline.LogError, msg)
+	jptm.logWithFields(pipeline.LogError, msg, common.LogFields{HTTPStatus: status, ErrorCode: string(errorCode)})
+	jptm.noteError(TransferError{HTTPStatus: status, Message: strings.TrimSpace(errorMsg)})
 }
 
 func (jptm *jobPartTransferMgr) LogUploadError(source, destination, errorMsg string, status int) {
@@ -877,10 +1084,12 @@ func (jptm *jobPartTransferMgr) LogSendError(source, destination, errorMsg strin
 }
 
 func (jptm *jobPartTransferMgr) LogError(resource, context string, err error) {
-	_, status, msg := ErrorEx{err}.ErrorCodeAndString()
+	serviceCode, status, msg := ErrorEx{err}.ErrorCodeAndString()
 	MSRequestID := ErrorEx{err}.MSRequestID()
-	jptm.Log(pipeline.LogError,
-		fmt.Sprintf("%s: %d: %s-%s. X-Ms-Request-Id:%s\n", common.URLStringExtension(resource).RedactSecretQueryParamForLogging(), status, context, msg, MSRequestID))
+	jptm.logWithFields(pipeline.LogError,
+		fmt.Sprintf("%s: %d: %s-%s. X-Ms-Request-Id:%s\n", common.URLStringExtension(resource).RedactSecretQueryParamForLogging(), status, context, msg, MSRequestID),
+		common.LogFields{HTTPStatus: status, RequestID: MSRequestID, ErrorCode: serviceCode})
+	jptm.noteError(TransferError{HTTPStatus: status, ErrorCode: serviceCode, Message: msg})
 }
 
 func (jptm *jobPartTransferMgr) LogTransferStart(source, destination, description string) {
@@ -919,13 +1128,38 @@ func (jptm *jobPartTransferMgr) ReportTransferDone() uint32 {
 	}
 
 	// Update Status Manager
-	jptm.jobPartMgr.SendXferDoneMsg(xferDoneMsg{Src: jptm.Info().Source,
-		Dst:                jptm.Info().Destination,
-		IsFolderProperties: jptm.Info().IsFolderPropertiesTransfer(),
-		TransferStatus:     jptm.jobPartPlanTransfer.TransferStatus(),
-		TransferSize:       uint64(jptm.Info().SourceSize),
-		ErrorCode:          jptm.ErrorCode(),
-	})
+	info := jptm.Info()
+	msg := xferDoneMsg{
+		TransferDetail: common.TransferDetail{Src: info.Source,
+			Dst:                info.Destination,
+			IsFolderProperties: info.IsFolderPropertiesTransfer(),
+			TransferStatus:     jptm.jobPartPlanTransfer.TransferStatus(),
+			TransferSize:       uint64(info.SourceSize),
+			ErrorCode:          jptm.ErrorCode(),
+		},
+		SourceVersionID: info.SrcBlobVersionID,
+	}
+	if !jptm.startTime.IsZero() {
+		msg.Duration = time.Since(jptm.startTime)
+	}
+	jptm.resultLock.Lock()
+	msg.ContentMD5, msg.DestinationVersionID = jptm.contentMD5, jptm.destinationVersionID
+	lastError := jptm.lastError
+	jptm.resultLock.Unlock()
+	if msg.TransferStatus.DidFail() {
+		// every way of failing a transfer logs why, and it's the error that was logged last that's kept
+		if lastError.Message == "" {
+			lastError = TransferError{HTTPStatus: int(msg.ErrorCode), Message: "the transfer failed with status " + msg.TransferStatus.String() + ". See the job's log for why"}
+		}
+		msg.ErrorServiceCode, msg.ErrorMessage = lastError.ErrorCode, lastError.Message
+		jptm.recordError(lastError)
+	}
+	if len(msg.ContentMD5) == 0 {
+		// e.g. a download, which checks against the hash of the source, or a copy, which copies it
+		msg.ContentMD5 = info.SrcHTTPHeaders.ContentMD5
+	}
+	jptm.jobPartMgr.SendXferDoneMsg(msg)
+	jptm.endSpan(jptm.jobPartPlanTransfer.TransferStatus())
 
 	return jptm.jobPartMgr.ReportTransferDone(jptm.jobPartPlanTransfer.TransferStatus())
 }
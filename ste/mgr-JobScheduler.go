@@ -0,0 +1,576 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ste
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Azure/azure-pipeline-go/pipeline"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+)
+
+// JobScheduler shares the engine between all the jobs of the process. It owns the pool of transfer initiation workers,
+// and the (auto-tuned) main pool of chunk workers, and hands out their time to the jobs by weighted fair queuing.
+// It also divides the RAM for chunks between the jobs that need it, in proportion to their weights.
+// The pacer is shared too: a job with a bandwidth cap is paced by its own pacer first, and then by the shared one.
+type JobScheduler struct {
+	atomicCurrentMainPoolSize int32
+
+	concurrency  ConcurrencySettings
+	tuner        ConcurrencyTuner
+	cpuMon       common.CPUMonitor
+	pacer        PacerAdmin
+	cacheLimiter common.CacheLimiter
+	logger       common.ILogger
+
+	transfers          *fairQueue
+	chunks             *fairQueue
+	poolSizingChannels poolSizingChannels
+	startPoolSizerOnce *sync.Once
+
+	// RAM accounting of all jobs
+	ramLock         *sync.Mutex
+	ramActiveWeight int64 // sum of the weights of the jobs that hold, or are waiting for, RAM
+}
+
+func NewJobScheduler(concurrency ConcurrencySettings, tuner ConcurrencyTuner, cpuMon common.CPUMonitor, pacer PacerAdmin,
+	cacheLimiter common.CacheLimiter, logger common.ILogger) *JobScheduler {
+	s := &JobScheduler{
+		concurrency:  concurrency,
+		tuner:        tuner,
+		cpuMon:       cpuMon,
+		pacer:        pacer,
+		cacheLimiter: cacheLimiter,
+		logger:       logger,
+		transfers:    newFairQueue(false),
+		chunks:       newFairQueue(true),
+		poolSizingChannels: poolSizingChannels{ // all deliberately unbuffered, because pool sizer routine works in lock-step with these - processing them as they happen, never catching up on populated buffer later
+			entryNotificationCh: make(chan struct{}),
+			exitNotificationCh:  make(chan struct{}),
+			scalebackRequestCh:  make(chan struct{}),
+			requestSlowTuneCh:   make(chan struct{}),
+		},
+		startPoolSizerOnce: &sync.Once{},
+		ramLock:            &sync.Mutex{},
+	}
+
+	// In addition to the main pool (which is governed by the poolSizer), we spin up a separate set of workers to process initiation of transfers
+	// (so that transfer initiation can't starve out progress on already-scheduled chunks.
+	// (Not sure whether that can really happen, but this protects against it anyway.)
+	// Perhaps MORE importantly, doing this separately gives us more CONTROL over how we interact with the file system.
+	for cc := 0; cc < concurrency.TransferInitiationPoolSize.Value; cc++ {
+		go s.transferProcessor(cc)
+	}
+	return s
+}
+
+// priorityWeight is the share of the engine that a job gets, relative to the other jobs that have work queued
+func priorityWeight(priority common.JobPriority) int64 {
+	switch priority {
+	case common.EJobPriority.Low():
+		return 1
+	case common.EJobPriority.High():
+		return 4
+	default:
+		return 2
+	}
+}
+
+// scheduledJob is a job's state in the scheduler
+type scheduledJob struct {
+	atomicWeight         int64
+	atomicMaxConcurrency int32 // zero means the job is only limited by the size of the main pool
+	atomicActiveChunks   int32
+
+	transfers *fairQueueMember
+	chunks    *fairQueueMember
+
+	// protected by JobScheduler.ramLock
+	ramInUse   int64
+	ramWaiters int
+}
+
+func (s *JobScheduler) newScheduledJob(channelSize int) *scheduledJob {
+	job := &scheduledJob{atomicWeight: priorityWeight(common.EJobPriority.Normal())}
+	job.transfers = &fairQueueMember{job: job, items: make(chan interface{}, channelSize)}
+	job.chunks = &fairQueueMember{job: job, items: make(chan interface{}, channelSize)}
+	return job
+}
+
+// setLimits applies the priority and concurrency cap of the job, as ordered in its plan
+func (s *JobScheduler) setLimits(job *scheduledJob, priority common.JobPriority, maxConcurrency int32) {
+	s.ramLock.Lock()
+	defer s.ramLock.Unlock()
+
+	newWeight := priorityWeight(priority)
+	if job.ramInUse > 0 || job.ramWaiters > 0 {
+		s.ramActiveWeight += newWeight - atomic.LoadInt64(&job.atomicWeight)
+	}
+	atomic.StoreInt64(&job.atomicWeight, newWeight)
+	atomic.StoreInt32(&job.atomicMaxConcurrency, maxConcurrency)
+}
+
+func (s *JobScheduler) scheduleTransfer(job *scheduledJob, jptm IJobPartTransferMgr) {
+	s.transfers.enqueue(job.transfers, jptm)
+}
+
+func (s *JobScheduler) scheduleChunk(job *scheduledJob, chunkFunc chunkFunc) {
+	s.chunks.enqueue(job.chunks, chunkFunc)
+}
+
+// startPoolSizer spins up the main pool, the first time that any job has work for it.
+// Why not earlier? So that we don't start tuning with no traffic to process, since doing so skews the tuning results.
+func (s *JobScheduler) startPoolSizer() {
+	s.startPoolSizerOnce.Do(func() {
+		go s.poolSizer()
+	})
+}
+
+func (s *JobScheduler) CurrentMainPoolSize() int {
+	return int(atomic.LoadInt32(&s.atomicCurrentMainPoolSize))
+}
+
+// RequestTuneSlowly is used to ask for a slower rate of auto-concurrency tuning.
+// Necessary because if there's a download or S2S transfer going on, we need to measure throughputs over longer intervals to make
+// the auto tuning work.
+func (s *JobScheduler) RequestTuneSlowly() {
+	select {
+	case s.poolSizingChannels.requestSlowTuneCh <- struct{}{}:
+	default:
+		return // channel already full, so don't need to add our signal there too, it already has one
+	}
+}
+
+// worker that sizes the chunkProcessor pool, dynamically if necessary
+func (s *JobScheduler) poolSizer() {
+
+	logConcurrency := func(targetConcurrency int, reason string) {
+		switch reason {
+		case ConcurrencyReasonNone,
+			concurrencyReasonFinished,
+			ConcurrencyReasonTunerDisabled:
+			return
+		default:
+			msg := fmt.Sprintf("Trying %d concurrent connections (%s)", targetConcurrency, reason)
+			common.GetLifecycleMgr().Info(msg)
+			s.logger.Log(pipeline.LogWarning, msg)
+		}
+	}
+
+	nextWorkerId := 0
+	actualConcurrency := 0
+	lastBytesOnWire := int64(0)
+	lastBytesTime := time.Now()
+	hasHadTimeToStablize := false
+	initialMonitoringInterval := time.Duration(4 * time.Second)
+	expandedMonitoringInterval := time.Duration(8 * time.Second)
+	throughputMonitoringInterval := initialMonitoringInterval
+	slowTuneCh := s.poolSizingChannels.requestSlowTuneCh
+
+	// get initial pool size
+	targetConcurrency, reason := s.tuner.GetRecommendedConcurrency(-1, s.cpuMon.CPUContentionExists())
+	logConcurrency(targetConcurrency, reason)
+
+	// loop for ever, driving the actual concurrency towards the most up-to-date target
+	for {
+		// add or remove a worker if necessary
+		if actualConcurrency < targetConcurrency {
+			hasHadTimeToStablize = false
+			nextWorkerId++
+			go s.chunkProcessor(nextWorkerId) // TODO: make sure this numbering is OK, even if we grow and shrink the pool (the id values don't matter right?)
+		} else if actualConcurrency > targetConcurrency {
+			hasHadTimeToStablize = false
+			s.poolSizingChannels.scalebackRequestCh <- struct{}{}
+		}
+
+		// wait for something to happen (maybe ack from the worker of the change, else a timer interval)
+		select {
+		case <-s.poolSizingChannels.entryNotificationCh:
+			// new worker has started
+			actualConcurrency++
+			atomic.StoreInt32(&s.atomicCurrentMainPoolSize, int32(actualConcurrency))
+		case <-s.poolSizingChannels.exitNotificationCh:
+			// worker has exited
+			actualConcurrency--
+			atomic.StoreInt32(&s.atomicCurrentMainPoolSize, int32(actualConcurrency))
+		case <-slowTuneCh:
+			// we've been asked to tune more slowly
+			// TODO: confirm we don't need this: expandedMonitoringInterval *= 2
+			throughputMonitoringInterval = expandedMonitoringInterval
+			slowTuneCh = nil // so we won't keep running this case at the expense of others)
+		case <-time.After(throughputMonitoringInterval):
+			if actualConcurrency == targetConcurrency { // scalebacks can take time. Don't want to do any tuning if actual is not yet aligned to target
+				bytesOnWire := s.pacer.GetTotalTraffic()
+				if hasHadTimeToStablize {
+					// throughput has had time to stabilize since last change, so we can meaningfully measure and act on throughput
+					elapsedSeconds := time.Since(lastBytesTime).Seconds()
+					bytes := bytesOnWire - lastBytesOnWire
+					megabitsPerSec := (8 * float64(bytes) / elapsedSeconds) / (1000 * 1000)
+					if megabitsPerSec > 4000 {
+						throughputMonitoringInterval = expandedMonitoringInterval // start averaging throughputs over longer time period, since in some tests it takes a little longer to get a good average
+					}
+					targetConcurrency, reason = s.tuner.GetRecommendedConcurrency(int(megabitsPerSec), s.cpuMon.CPUContentionExists())
+					logConcurrency(targetConcurrency, reason)
+				} else {
+					// we weren't in steady state before, but given that throughputMonitoringInterval has now elapsed,
+					// we'll deem that we are in steady state now (so can start measuring throughput from now)
+					hasHadTimeToStablize = true
+				}
+				lastBytesOnWire = bytesOnWire
+				lastBytesTime = time.Now()
+			}
+		}
+	}
+}
+
+// general purpose worker that reads in schedules chunk jobs, and executes chunk jobs
+func (s *JobScheduler) chunkProcessor(workerID int) {
+	s.poolSizingChannels.entryNotificationCh <- struct{}{}                   // say we have started
+	defer func() { s.poolSizingChannels.exitNotificationCh <- struct{}{} }() // say we have exited
+
+	for {
+		// We check for scalebacks first to shrink goroutine pool
+		// Then, we take the next chunk of whichever job is due one
+		select {
+		case <-s.poolSizingChannels.scalebackRequestCh:
+			return
+		default:
+			if item, member, ok := s.chunks.dequeue(); ok {
+				item.(chunkFunc)(workerID)
+				atomic.AddInt32(&member.job.atomicActiveChunks, -1)
+			} else {
+				time.Sleep(100 * time.Millisecond) // Sleep before looping around
+				// TODO: Question: In order to safely support high goroutine counts,
+				// do we need to review sleep duration, or find an approach that does not require waking every x milliseconds
+				// For now, duration has been increased substantially from the previous 1 ms, to reduce cost of
+				// the wake-ups.
+			}
+		}
+	}
+}
+
+// separate from the chunkProcessor, this dedicated worker that reads in and executes transfer initiation jobs
+// (which in turn schedule chunks that get picked up by chunkProcessor)
+func (s *JobScheduler) transferProcessor(workerID int) {
+	startTransfer := func(jptm IJobPartTransferMgr) {
+		if jptm.WasCanceled() {
+			if jptm.ShouldLog(pipeline.LogInfo) {
+				jptm.Log(pipeline.LogInfo, fmt.Sprintf(" is not picked up worked %d because transfer was cancelled", workerID))
+			}
+			jptm.SetStatus(common.ETransferStatus.Cancelled())
+			jptm.ReportTransferDone()
+		} else {
+			// TODO fix preceding space
+			if jptm.ShouldLog(pipeline.LogInfo) {
+				jptm.Log(pipeline.LogInfo, fmt.Sprintf("has worker %d which is processing TRANSFER %d", workerID, jptm.(*jobPartTransferMgr).transferIndex))
+			}
+			jptm.StartJobXfer()
+		}
+	}
+
+	for {
+		// No scaleback check here, because this routine runs only in a small number of goroutines, so no need to kill them off
+		if item, _, ok := s.transfers.dequeue(); ok {
+			startTransfer(item.(IJobPartTransferMgr))
+		} else {
+			time.Sleep(10 * time.Millisecond) // Sleep before looping around
+		}
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// fairQueue hands out the items of its members by stride scheduling: each member has a pass value, which advances
+// in inverse proportion to its job's weight whenever one of its items is taken, and the next item always comes from
+// the member with the lowest pass. So, when several jobs have work queued, each gets a share proportional to its weight.
+// Only members with queued items take part, and a member that (re)joins starts at the current pass of the queue,
+// so that a job that had nothing to do does not get to catch up on the share it did not use.
+type fairQueue struct {
+	lock        *sync.Mutex
+	members     []*fairQueueMember
+	virtualTime uint64
+
+	// if true, the job's cap on concurrency applies, and taking an item counts as one of the job's active chunks
+	capConcurrency bool
+}
+
+// each job is a member of the transfer queue and of the chunk queue
+type fairQueueMember struct {
+	job          *scheduledJob
+	items        chan interface{}
+	atomicJoined int32
+	pass         uint64 // protected by the queue's lock
+}
+
+// the pass of a member advances by fairQueueStride/weight for each item that it is given
+const fairQueueStride = 1 << 20
+
+func newFairQueue(capConcurrency bool) *fairQueue {
+	return &fairQueue{lock: &sync.Mutex{}, capConcurrency: capConcurrency}
+}
+
+// enqueue blocks while the member's channel is full, just as sending directly to it would
+func (q *fairQueue) enqueue(m *fairQueueMember, item interface{}) {
+	m.items <- item
+
+	if atomic.LoadInt32(&m.atomicJoined) == 0 {
+		q.lock.Lock()
+		if atomic.LoadInt32(&m.atomicJoined) == 0 {
+			if m.pass < q.virtualTime {
+				m.pass = q.virtualTime
+			}
+			q.members = append(q.members, m)
+			atomic.StoreInt32(&m.atomicJoined, 1)
+		}
+		q.lock.Unlock()
+	}
+}
+
+// dequeue returns the next item that is due, or false if no member has an item that may be started now
+func (q *fairQueue) dequeue() (interface{}, *fairQueueMember, bool) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	var next *fairQueueMember
+	for i := 0; i < len(q.members); {
+		m := q.members[i]
+		if len(m.items) == 0 {
+			// leave the queue, unless an item arrived while we were leaving.
+			// (Since enqueue adds the item before it checks whether the member has joined, any item that we
+			// don't see here is followed by a check in enqueue that sees that we left.)
+			atomic.StoreInt32(&m.atomicJoined, 0)
+			if len(m.items) == 0 {
+				last := len(q.members) - 1
+				q.members[i] = q.members[last]
+				q.members[last] = nil
+				q.members = q.members[:last]
+				continue
+			}
+			atomic.StoreInt32(&m.atomicJoined, 1)
+		}
+
+		if q.mayStart(m) && (next == nil || m.pass < next.pass) {
+			next = m
+		}
+		i++
+	}
+	if next == nil {
+		return nil, nil, false
+	}
+
+	// only dequeue receives from the channels, and always under the lock, so there is certainly an item to receive
+	item := <-next.items
+	if q.capConcurrency {
+		atomic.AddInt32(&next.job.atomicActiveChunks, 1)
+	}
+	q.virtualTime = next.pass
+	next.pass += uint64(fairQueueStride / atomic.LoadInt64(&next.job.atomicWeight))
+	return item, next, true
+}
+
+func (q *fairQueue) mayStart(m *fairQueueMember) bool {
+	if !q.capConcurrency {
+		return true
+	}
+	maxConcurrency := atomic.LoadInt32(&m.job.atomicMaxConcurrency)
+	return maxConcurrency <= 0 || atomic.LoadInt32(&m.job.atomicActiveChunks) < maxConcurrency
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// updateRamUsage is called with the ramLock held. A job counts towards the shares of RAM while it holds some, or waits for some
+func (s *JobScheduler) updateRamUsage(job *scheduledJob, deltaInUse int64, deltaWaiters int) {
+	wasActive := job.ramInUse > 0 || job.ramWaiters > 0
+	job.ramInUse += deltaInUse
+	job.ramWaiters += deltaWaiters
+	isActive := job.ramInUse > 0 || job.ramWaiters > 0
+
+	if wasActive != isActive {
+		weight := atomic.LoadInt64(&job.atomicWeight)
+		if isActive {
+			s.ramActiveWeight += weight
+		} else {
+			s.ramActiveWeight -= weight
+		}
+	}
+}
+
+// tryReserveRam adds to the job's RAM usage if there's room in the shared limit, and the job is within its share of it.
+// A job that holds no RAM may always try for some, so that a chunk that is bigger than the share can still make progress.
+func (s *JobScheduler) tryReserveRam(job *scheduledJob, count int64, useRelaxedLimit bool) bool {
+	s.ramLock.Lock()
+	defer s.ramLock.Unlock()
+
+	if job.ramInUse > 0 && s.ramActiveWeight > 0 {
+		share := s.cacheLimiter.Limit() * atomic.LoadInt64(&job.atomicWeight) / s.ramActiveWeight
+		if job.ramInUse+count > share {
+			return false
+		}
+	}
+
+	if !s.cacheLimiter.TryAdd(count, useRelaxedLimit) {
+		return false
+	}
+	s.updateRamUsage(job, count, 0)
+	return true
+}
+
+func (s *JobScheduler) releaseRam(job *scheduledJob, count int64) {
+	s.ramLock.Lock()
+	defer s.ramLock.Unlock()
+
+	s.cacheLimiter.Remove(count)
+	s.updateRamUsage(job, -count, 0)
+}
+
+func (s *JobScheduler) setWaitingForRam(job *scheduledJob, waiting bool) {
+	s.ramLock.Lock()
+	defer s.ramLock.Unlock()
+
+	if waiting {
+		s.updateRamUsage(job, 0, 1)
+	} else {
+		s.updateRamUsage(job, 0, -1)
+	}
+}
+
+// jobCacheLimiter is the view of the shared RAM limit that is given to a job
+type jobCacheLimiter struct {
+	scheduler *JobScheduler
+	job       *scheduledJob
+}
+
+func (l *jobCacheLimiter) TryAdd(count int64, useRelaxedLimit bool) (added bool) {
+	return l.scheduler.tryReserveRam(l.job, count, useRelaxedLimit)
+}
+
+// WaitUntilAdd blocks until it completes a successful call to TryAdd. Like the shared limiter, it polls with a
+// randomized delay, and while it waits the job counts towards the division of the RAM, so that it gets its share as
+// other jobs release theirs
+func (l *jobCacheLimiter) WaitUntilAdd(ctx context.Context, count int64, useRelaxedLimit common.Predicate) error {
+	if l.TryAdd(count, useRelaxedLimit()) {
+		return nil
+	}
+
+	l.scheduler.setWaitingForRam(l.job, true)
+	defer l.scheduler.setWaitingForRam(l.job, false)
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Duration(2 * float32(time.Second) * rand.Float32())):
+		}
+
+		if l.TryAdd(count, useRelaxedLimit()) {
+			return nil
+		}
+	}
+}
+
+func (l *jobCacheLimiter) Remove(count int64) {
+	l.scheduler.releaseRam(l.job, count)
+}
+
+func (l *jobCacheLimiter) Limit() int64 {
+	return l.scheduler.cacheLimiter.Limit()
+}
+
+// Value is the RAM held by the job
+func (l *jobCacheLimiter) Value() int64 {
+	l.scheduler.ramLock.Lock()
+	defer l.scheduler.ramLock.Unlock()
+	return l.job.ramInUse
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// jobPacer caps the throughput of one job, within the shared pacer of the process.
+// Its token bucket is closed when the job is done or paused. Since the job's pipelines keep the jobPacer,
+// a new bucket is started when a resumed job asks for traffic again.
+type jobPacer struct {
+	lock           *sync.Mutex
+	job            pacer // nil while the job isn't running
+	bytesPerSecond int64
+	shared         pacer
+}
+
+func newJobPacer(shared pacer, bytesPerSecond int64) *jobPacer {
+	return &jobPacer{lock: &sync.Mutex{}, bytesPerSecond: bytesPerSecond, shared: shared}
+}
+
+// bucket gives the job's token bucket, starting it if the job was done or paused
+func (p *jobPacer) bucket() pacer {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.job == nil {
+		p.job = NewTokenBucketPacer(p.bytesPerSecond, 0)
+	}
+	return p.job
+}
+
+func (p *jobPacer) RequestTrafficAllocation(ctx context.Context, byteCount int64) error {
+	job := p.bucket()
+	if err := job.RequestTrafficAllocation(ctx, byteCount); err != nil {
+		return err
+	}
+	if err := p.shared.RequestTrafficAllocation(ctx, byteCount); err != nil {
+		job.UndoRequest(byteCount)
+		return err
+	}
+	return nil
+}
+
+// UpdateTargetBytesPerSecond changes the job's cap. The shared one belongs to the process
+func (p *jobPacer) UpdateTargetBytesPerSecond(newTarget int64) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.bytesPerSecond = newTarget
+	if p.job != nil {
+		p.job.UpdateTargetBytesPerSecond(newTarget)
+	}
+}
+
+func (p *jobPacer) UndoRequest(byteCount int64) {
+	p.lock.Lock()
+	if p.job != nil {
+		p.job.UndoRequest(byteCount)
+	}
+	p.lock.Unlock()
+	p.shared.UndoRequest(byteCount)
+}
+
+// Close stops the job's token bucket. The shared pacer belongs to the process, and is left running
+func (p *jobPacer) Close() error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.job == nil {
+		return nil
+	}
+	err := p.job.Close()
+	p.job = nil
+	return err
+}
@@ -0,0 +1,197 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ste
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-pipeline-go/pipeline"
+	"github.com/Azure/azure-storage-blob-go/azblob"
+	"github.com/Azure/azure-storage-file-go/azfile"
+
+	"github.com/Azure/azure-storage-azcopy/v10/azbfs"
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+)
+
+// deleteSourceIfMoving deletes the source of a file that a move has transferred, once the transfer has been verified
+// (i.e. its length and, for downloads, its MD5 have been checked), and before the transfer is marked as successful.
+// That way, if the deletion fails, or the job is stopped before it happens, the transfer isn't successful, and
+// resuming the job transfers it again and retries the deletion. Folders are left alone, since their contents are
+// transferred after them.
+func deleteSourceIfMoving(jptm IJobPartTransferMgr, fail func(where string, err error)) {
+	info := jptm.Info()
+	if !jptm.IsLive() || !jptm.DeleteSourceOnSuccess() || info.EntityType != common.EEntityType.File() {
+		return
+	}
+
+	err := deleteSourceOfMove(jptm, info)
+	if err != nil {
+		fail("Deleting source after move", err)
+		return
+	}
+	jptm.Log(pipeline.LogInfo, fmt.Sprintf("MOVE: deleted source %s", strings.Split(info.Source, "?")[0]))
+}
+
+// errSourceChangedSinceMove is returned instead of deleting a source that has been changed since it was transferred
+var errSourceChangedSinceMove = errors.New("the source has been modified since it was transferred, so it has not been deleted")
+
+// deleteSourceOfMove deletes the source, unless it has been modified since the transfer began (i.e. its last modified
+// time is no longer the one that was enumerated). Where the service can, that's checked in the same request as the delete.
+func deleteSourceOfMove(jptm IJobPartTransferMgr, info TransferInfo) error {
+	fromTo := jptm.FromTo()
+	from := fromTo.From()
+	lmt := jptm.LastModifiedTime()
+	if from == common.ELocation.Local() {
+		// the file system has no conditional delete, so this is the best we can do
+		fi, err := common.OSStat(info.Source)
+		if os.IsNotExist(err) {
+			return nil // deleted already, before the job was resumed
+		} else if err != nil {
+			return err
+		}
+		if !lmt.IsZero() && !fi.ModTime().Equal(lmt) {
+			return errSourceChangedSinceMove
+		}
+		err = os.Remove(info.Source)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	u, err := url.Parse(info.Source)
+	if err != nil {
+		return err
+	}
+	p := sourcePipelineOf(jptm)
+
+	switch from {
+	case common.ELocation.Blob():
+		// don't delete a blob that's been changed since it was transferred
+		ac := azblob.BlobAccessConditions{}
+		if !lmt.IsZero() {
+			ac.ModifiedAccessConditions.IfUnmodifiedSince = lmt
+		}
+		_, err = azblob.NewBlobURL(*u, p).Delete(jptm.Context(), azblob.DeleteSnapshotsOptionNone, ac)
+	case common.ELocation.File():
+		if lmt.IsZero() {
+			_, err = azfile.NewFileURL(*u, p).Delete(jptm.Context())
+		} else {
+			err = deleteAzureFileIfUnmodifiedSince(jptm.Context(), p, *u, lmt)
+		}
+	case common.ELocation.BlobFS():
+		_, err = azbfs.NewFileURL(*u, p).DeleteWithOptions(jptm.Context(), azbfs.DeleteFileOptions{IfUnmodifiedSince: lmt})
+	default:
+		return fmt.Errorf("the sources of moves from %s can't be deleted", from)
+	}
+
+	if resp, ok := err.(pipeline.Response); ok && resp.Response() != nil {
+		switch resp.Response().StatusCode {
+		case http.StatusNotFound:
+			return nil // deleted already, before the job was resumed
+		case http.StatusPreconditionFailed:
+			return errSourceChangedSinceMove
+		}
+	}
+	return err
+}
+
+// deleteAzureFileIfUnmodifiedSince deletes the Azure file at u, if it hasn't been modified since lmt.
+// Azure Files has no conditional delete, so the file is leased, which stops anything else from changing it, while
+// its last modified time is checked and it's deleted. The version of azfile that we use can't lease files, so this
+// makes the requests itself.
+func deleteAzureFileIfUnmodifiedSince(ctx context.Context, p pipeline.Pipeline, u url.URL, lmt time.Time) error {
+	leaseID := common.NewUUID().String()
+	resp, err := doAzureFileRequest(ctx, p, u, http.MethodPut, "lease", map[string]string{
+		"x-ms-lease-action":      "acquire",
+		"x-ms-lease-duration":    "-1",
+		"x-ms-proposed-lease-id": leaseID,
+	})
+	if err != nil {
+		return err
+	}
+	switch resp.StatusCode {
+	case http.StatusCreated:
+	case http.StatusNotFound:
+		return nil // deleted already, before the job was resumed
+	default:
+		return fmt.Errorf("could not lease the source to delete it: status %s, error code %s", resp.Status, resp.Header.Get("x-ms-error-code"))
+	}
+
+	modified, err := time.Parse(http.TimeFormat, resp.Header.Get("Last-Modified"))
+	if err == nil && modified.After(lmt) {
+		err = errSourceChangedSinceMove
+	} else {
+		resp, err = doAzureFileRequest(ctx, p, u, http.MethodDelete, "", map[string]string{"x-ms-lease-id": leaseID})
+		if err == nil && resp.StatusCode == http.StatusAccepted {
+			return nil // the lease went with the file
+		} else if err == nil {
+			err = fmt.Errorf("could not delete the source: status %s, error code %s", resp.Status, resp.Header.Get("x-ms-error-code"))
+		}
+	}
+
+	_, _ = doAzureFileRequest(ctx, p, u, http.MethodPut, "lease", map[string]string{
+		"x-ms-lease-action": "release",
+		"x-ms-lease-id":     leaseID,
+	})
+	return err
+}
+
+// doAzureFileRequest makes a request to the Azure file at u, and returns its response, whatever its status
+func doAzureFileRequest(ctx context.Context, p pipeline.Pipeline, u url.URL, method string, comp string, headers map[string]string) (*http.Response, error) {
+	if comp != "" {
+		query := u.Query()
+		query.Set("comp", comp)
+		u.RawQuery = query.Encode()
+	}
+	req, err := pipeline.NewRequest(method, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := p.Do(ctx, nil, req)
+	if err != nil {
+		return nil, err
+	}
+	_, _ = io.Copy(io.Discard, resp.Response().Body)
+	_ = resp.Response().Body.Close()
+	return resp.Response(), nil
+}
+
+// sourcePipelineOf returns the pipeline with which the source is accessed: the source provider's for service to
+// service copies, and the main one for downloads
+func sourcePipelineOf(jptm IJobPartTransferMgr) pipeline.Pipeline {
+	if p := jptm.SourceProviderPipeline(); p != nil {
+		return p
+	}
+	return jptm.(*jobPartTransferMgr).jobPartMgr.(*jobPartMgr).pipeline
+}
@@ -56,6 +56,10 @@ func (a *nullAutoPacer) GetTotalTraffic() int64 {
 	return atomic.LoadInt64(&a.atomicGrandTotal)
 }
 
+func (a *nullAutoPacer) TargetBytesPerSecond() int64 {
+	return 0
+}
+
 func (a *nullAutoPacer) UpdateTargetBytesPerSecond(_ int64) {
 	
 }
@@ -50,6 +50,9 @@ type PacerAdmin interface {
 
 	// GetTotalTraffic returns the cumulative count of all traffic that has been processed
 	GetTotalTraffic() int64
+
+	// TargetBytesPerSecond returns the rate that the pacer is aiming for. Zero means it isn't pacing.
+	TargetBytesPerSecond() int64
 }
 
 const (
@@ -201,6 +204,10 @@ func (p *tokenBucketPacer) UpdateTargetBytesPerSecond(value int64) {
 	p.newTargetBytesPerSecond <- value
 }
 
+func (p *tokenBucketPacer) TargetBytesPerSecond() int64 {
+	return p.targetBytesPerSecond()
+}
+
 func (p *tokenBucketPacer) GetTotalTraffic() int64 {
 	return atomic.LoadInt64(&p.atomicGrandTotal)
 }
@@ -173,7 +173,8 @@ func (s *appendBlobSenderBase) Epilogue() {
 func (s *appendBlobSenderBase) Cleanup() {
 	jptm := s.jptm
 	// Cleanup
-	if jptm.IsDeadInflight() {
+	// (A followed blob is kept, since resuming the job carries on appending to it from wherever it ends.)
+	if jptm.IsDeadInflight() && !jptm.ShouldFollowSource() {
 		// There is a possibility that some uncommitted blocks will be there
 		// Delete the uncommitted blobs
 		// TODO: particularly, given that this is an APPEND blob, do we really need to delete it?  But if we don't delete it,
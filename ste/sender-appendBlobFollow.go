@@ -0,0 +1,308 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ste
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/Azure/azure-pipeline-go/pipeline"
+	"github.com/Azure/azure-storage-blob-go/azblob"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+)
+
+// how often we look for new data in, or rotation of, a followed file
+const followPollInterval = time.Second
+
+var errFollowedBlobChanged = errors.New("the destination append blob is shorter than what has already been appended to it, so it must have been replaced or modified by something else")
+
+// localFileFollower is implemented by senders that can keep sending a local file as it grows, rather than just sending what
+// it contains now
+type localFileFollower interface {
+	followLocalFile(srcPath string)
+}
+
+// followLocalFile appends everything that is written to the file at srcPath to the destination, until the transfer is cancelled.
+// If the file is rotated (i.e. a new file appears under its name) we finish off the old file and then continue with the new one.
+// Progress is checkpointed in the plan file, so that if the job is resumed we continue where we left off.
+// The whole of the following is the transfer's one and only chunk, so reporting it done runs the epilogue.
+func (u *appendBlobUploader) followLocalFile(srcPath string) {
+	jptm := u.jptm
+	ctx := jptm.Context()
+	defer jptm.ReportChunkDone(common.NewChunkID(srcPath, 0, 0))
+
+	props, err := u.destAppendBlobURL.GetProperties(ctx, azblob.BlobAccessConditions{}, u.cpkToApply)
+	exists, _, err := remoteObjectExists(props, err)
+	if err != nil {
+		jptm.FailActiveUpload("Checking destination", err)
+		return
+	}
+	destLength := int64(0)
+	if exists {
+		destLength = props.ContentLength()
+	}
+
+	f := &fileFollower{
+		path:      srcPath,
+		chunkSize: u.chunkSize,
+		appendData: func(data []byte, blobOffset int64) error {
+			return u.appendFollowedData(data, blobOffset)
+		},
+		saveState: func(fileID uint64, blobOffset int64, fileOffset int64) {
+			jptm.SetFollowState(fileID, blobOffset)
+			jptm.SetResumeOffset(fileOffset)
+		},
+		log: func(msg string) {
+			jptm.LogAtLevelForCurrentTransfer(pipeline.LogInfo, msg)
+		},
+	}
+	defer f.close()
+
+	fileID, blobOffset := jptm.FollowState()
+	if exists && fileID != 0 {
+		// a previous run was following this file, so carry on from where the destination ends.
+		// (That's where the previous run got to, even if it stopped before it could checkpoint its last append.)
+		if destLength < blobOffset+jptm.ResumeOffset() {
+			jptm.FailActiveUpload("Resuming follow", errFollowedBlobChanged)
+			return
+		}
+		err = f.resume(fileID, blobOffset, destLength)
+	} else {
+		// first run, so create the destination, just like we would for a normal upload
+		u.Prologue(common.PrologueState{LeadingBytes: readLeadingBytes(srcPath)})
+		if !jptm.IsLive() {
+			return // the prologue has already reported the failure
+		}
+		jptm.SetDestinationIsModified()
+		err = f.open(0)
+	}
+	if err != nil {
+		jptm.FailActiveUpload("Opening source", err)
+		return
+	}
+
+	jptm.Log(pipeline.LogInfo, fmt.Sprintf("Following %s, from offset %d", srcPath, f.fileOffset))
+	for {
+		if err := f.poll(); err != nil {
+			jptm.FailActiveUpload("Following source", err)
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			// This is how following normally ends. The epilogue leaves the transfer as cancelled, rather than successful,
+			// so that resuming the job will carry on following the file.
+			jptm.Log(pipeline.LogInfo, fmt.Sprintf("Stopped following %s at offset %d", srcPath, f.fileOffset))
+			return
+		case <-time.After(followPollInterval):
+		}
+	}
+}
+
+// appendFollowedData appends one block, failing if anything else has appended to the blob in the meantime
+func (u *appendBlobUploader) appendFollowedData(data []byte, blobOffset int64) error {
+	body := newPacedRequestBody(u.jptm.Context(), bytes.NewReader(data), u.pacer)
+	return appendBlockAt(u.jptm.Context(), u.destAppendBlobURL, body, data, blobOffset, u.cpkToApply)
+}
+
+// appendBlockAt appends data (which body reads) at blobOffset, using an append position condition so that it fails if
+// the blob is any other length.
+// If the condition is not met, it may be because this very append succeeded, but its response was lost and it was retried.
+// It's only treated as that duplicate if the blob ends where the block does and that range of it holds exactly the block's
+// content. Otherwise something else must have appended to the blob.
+func appendBlockAt(ctx context.Context, blobURL azblob.AppendBlobURL, body io.ReadSeeker, data []byte, blobOffset int64, cpk azblob.ClientProvidedKeyOptions) error {
+	length := int64(len(data))
+	_, err := blobURL.AppendBlock(ctx, body,
+		azblob.AppendBlobAccessConditions{
+			AppendPositionAccessConditions: azblob.AppendPositionAccessConditions{IfAppendPositionEqual: blobOffset},
+		}, nil, cpk)
+	if stgErr, ok := err.(azblob.StorageError); !ok || stgErr.ServiceCode() != azblob.ServiceCodeAppendPositionConditionNotMet {
+		return err
+	}
+
+	errAppendedByOther := fmt.Errorf("the destination append blob no longer ends at offset %d, where this block was to be appended, so something else must have appended to it", blobOffset)
+	props, propsErr := blobURL.GetProperties(ctx, azblob.BlobAccessConditions{}, cpk)
+	if propsErr != nil {
+		return propsErr
+	}
+	if props.ContentLength() != blobOffset+length {
+		return errAppendedByOther
+	}
+
+	resp, downloadErr := blobURL.Download(ctx, blobOffset, length, azblob.BlobAccessConditions{}, false, cpk)
+	if downloadErr != nil {
+		return downloadErr
+	}
+	appended := resp.Body(azblob.RetryReaderOptions{MaxRetryRequests: 3})
+	defer appended.Close()
+	existing, readErr := io.ReadAll(appended)
+	if readErr != nil {
+		return readErr
+	}
+	if !bytes.Equal(existing, data) {
+		return errAppendedByOther
+	}
+	return nil
+}
+
+// readLeadingBytes returns the start of the file, for content type detection. Or nil, if there is nothing we can read yet
+func readLeadingBytes(path string) []byte {
+	f, err := common.OSOpenFile(path, os.O_RDONLY, 0)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	buf := make([]byte, 512)
+	n, _ := io.ReadFull(f, buf)
+	if n == 0 {
+		return nil
+	}
+	return buf[:n]
+}
+
+// fileFollower tails a local file, and hands each range that is written to it to appendData.
+// Rotation is detected by the file at path having a different ID to the one we have open. Truncation (e.g. by copytruncate)
+// is detected by the file becoming shorter than what we have already read. Either way, we start again at the beginning of
+// the (new) file, and append its content after everything that came from the old one.
+type fileFollower struct {
+	path       string
+	chunkSize  int64
+	appendData func(data []byte, blobOffset int64) error
+	saveState  func(fileID uint64, blobOffset int64, fileOffset int64)
+	log        func(msg string)
+
+	file       *os.File
+	fileID     uint64
+	blobOffset int64 // where the content of the current file starts in the destination
+	fileOffset int64 // how much of the current file has been appended
+	buffer     []byte
+}
+
+// open starts following whatever file is currently at the path, appending its content from blobOffset onwards
+func (f *fileFollower) open(blobOffset int64) error {
+	file, err := common.OSOpenFile(f.path, os.O_RDONLY, 0)
+	if err != nil {
+		return err
+	}
+	fileID, err := common.GetFileID(f.path)
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+
+	f.close()
+	f.file = file
+	f.fileID = fileID
+	f.blobOffset = blobOffset
+	f.fileOffset = 0
+	f.saveState(f.fileID, f.blobOffset, f.fileOffset)
+	return nil
+}
+
+// resume picks up from a previous run, which was following the file with the given ID
+func (f *fileFollower) resume(fileID uint64, blobOffset int64, destLength int64) error {
+	if currentID, err := common.GetFileID(f.path); err != nil || currentID != fileID {
+		f.log(fmt.Sprintf("%s was rotated while it was not being followed. Anything written to the old file after the last checkpoint will not be appended", f.path))
+		return f.open(destLength)
+	}
+
+	if err := f.open(blobOffset); err != nil {
+		return err
+	}
+	f.fileOffset = destLength - blobOffset
+	f.saveState(f.fileID, f.blobOffset, f.fileOffset)
+	return nil
+}
+
+// poll appends whatever has been written since the last poll, and moves on to the new file if the current one has been rotated
+func (f *fileFollower) poll() error {
+	if err := f.appendAvailable(); err != nil {
+		return err
+	}
+
+	fi, err := common.OSStat(f.path)
+	if os.IsNotExist(err) {
+		return nil // rotated away, and the new file has not been created yet
+	} else if err != nil {
+		return err
+	}
+	fileID, err := common.GetFileID(f.path)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case fileID != f.fileID:
+		// The old file may have been written to after our last read, but before it was rotated. Since we still have it
+		// open, we can get that data before moving on.
+		if err := f.appendAvailable(); err != nil {
+			return err
+		}
+		f.log(fmt.Sprintf("%s has been rotated, so following the new file", f.path))
+	case fi.Size() < f.fileOffset:
+		f.log(fmt.Sprintf("%s has been truncated, so following it from the start", f.path))
+	default:
+		return nil
+	}
+
+	if err := f.open(f.blobOffset + f.fileOffset); err != nil {
+		return err
+	}
+	return f.appendAvailable()
+}
+
+// appendAvailable appends everything between our current offset and the end of the file
+func (f *fileFollower) appendAvailable() error {
+	if f.buffer == nil {
+		f.buffer = make([]byte, f.chunkSize)
+	}
+
+	for {
+		n, err := f.file.ReadAt(f.buffer, f.fileOffset)
+		if n > 0 {
+			if appendErr := f.appendData(f.buffer[:n], f.blobOffset+f.fileOffset); appendErr != nil {
+				return appendErr
+			}
+			f.fileOffset += int64(n)
+			f.saveState(f.fileID, f.blobOffset, f.fileOffset)
+		}
+
+		if err == io.EOF || (err == nil && n < len(f.buffer)) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
+
+func (f *fileFollower) close() {
+	if f.file != nil {
+		_ = f.file.Close()
+		f.file = nil
+	}
+}
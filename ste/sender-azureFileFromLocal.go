@@ -45,6 +45,10 @@ func (u *azureFileUploader) Md5Channel() chan<- []byte {
 	return u.md5Channel
 }
 
+func (u *azureFileUploader) SkipsZeroChunks() bool {
+	return true
+}
+
 func (u *azureFileUploader) GenerateUploadFunc(id common.ChunkID, blockIndex int32, reader common.SingleChunkReader, chunkIsWholeFile bool) chunkFunc {
 
 	return createSendToRemoteChunkFunc(u.jptm, id, func() {
@@ -74,6 +74,7 @@ func (u *blobFSUploader) Epilogue() {
 		ss := jptm.Info().SourceSize
 		md5Hash, ok := <-u.md5Channel
 		if ok {
+			jptm.SetContentMD5(md5Hash)
 			// Flush incrementally to avoid timeouts on a full flush
 			for i := int64(math.Min(float64(ss), float64(u.flushThreshold))); ; i = int64(math.Min(float64(ss), float64(i+u.flushThreshold))) {
 				// Close only at the end of the file, keep all uncommitted data before then.
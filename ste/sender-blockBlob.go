@@ -22,6 +22,7 @@ package ste
 
 import (
 	"context"
+	"crypto/md5"
 	"encoding/base64"
 	"errors"
 	"fmt"
@@ -61,6 +62,10 @@ type blockBlobSenderBase struct {
 	atomicChunksWritten    int32
 	atomicPutListIndicator int32
 	muBlockIDs             *sync.Mutex
+
+	// blocks left staged (but uncommitted) at the destination by an earlier run of this job, keyed by encoded block ID
+	stagedBlocks     map[string]int64
+	stagedBlocksOnce *sync.Once
 }
 
 func getVerifiedChunkParams(transferInfo TransferInfo, memLimit int64) (chunkSize int64, numChunks uint32, err error) {
@@ -151,7 +156,8 @@ func newBlockBlobSenderBase(jptm IJobPartTransferMgr, destination string, p pipe
 		blobTagsToApply:  props.SrcBlobTags.ToAzBlobTagsMap(),
 		destBlobTier:     destBlobTier,
 		cpkToApply:       cpkToApply,
-		muBlockIDs:       &sync.Mutex{}}, nil
+		muBlockIDs:       &sync.Mutex{},
+		stagedBlocksOnce: &sync.Once{}}, nil
 }
 
 func (s *blockBlobSenderBase) SendableEntityType() common.EntityType {
@@ -211,10 +217,12 @@ func (s *blockBlobSenderBase) Epilogue() {
 			destBlobTier = azblob.AccessTierNone
 		}
 
-		if _, err := s.destBlockBlobURL.CommitBlockList(jptm.Context(), blockIDs, s.headersToApply, s.metadataToApply, azblob.BlobAccessConditions{}, destBlobTier, blobTags, s.cpkToApply, azblob.ImmutabilityPolicyOptions{}); err != nil {
+		resp, err := s.destBlockBlobURL.CommitBlockList(jptm.Context(), blockIDs, s.headersToApply, s.metadataToApply, azblob.BlobAccessConditions{}, destBlobTier, blobTags, s.cpkToApply, azblob.ImmutabilityPolicyOptions{})
+		if err != nil {
 			jptm.FailActiveSend("Committing block list", err)
 			return
 		}
+		jptm.SetDestinationVersionID(resp.VersionID())
 
 		if separateSetTagsRequired {
 			if _, err := s.destBlockBlobURL.SetTags(jptm.Context(), nil, nil, nil, s.blobTagsToApply); err != nil {
@@ -254,6 +262,14 @@ func (s *blockBlobSenderBase) Cleanup() {
 		deletionContext, cancelFn := context.WithTimeout(context.WithValue(context.Background(), ServiceAPIVersionOverride, DefaultServiceApiVersion), 30*time.Second)
 		defer cancelFn()
 		if jptm.WasCanceled() {
+			if getPutListNeed(&s.atomicPutListIndicator) == putListNeeded {
+				// Our block IDs are derived from the job and the transfer, so if the job is resumed it will recognise
+				// the blocks that are already staged and won't send them again. So leave them in place. If the job is never
+				// resumed, the service garbage-collects uncommitted blocks after a week.
+				jptm.LogAtLevelForCurrentTransfer(pipeline.LogDebug, "Leaving staged blocks in place, for re-use if the job is resumed")
+				return
+			}
+
 			// If we cancelled, and the only blocks that exist are uncommitted, then clean them up.
 			// This prevents customer paying for their storage for a week until they get garbage collected, and it
 			// also prevents any issues with "too many uncommitted blocks" if user tries to upload the blob again in future.
@@ -269,6 +285,7 @@ func (s *blockBlobSenderBase) Cleanup() {
 			// TODO: review (one last time) should we really do this?  Or should we just give better error messages on "too many uncommitted blocks" errors
 			jptm.LogAtLevelForCurrentTransfer(pipeline.LogDebug, "Deleting destination blob due to failure")
 			_, _ = s.destBlockBlobURL.Delete(deletionContext, azblob.DeleteSnapshotsOptionNone, azblob.BlobAccessConditions{})
+			jptm.ResetResumeState() // the staged blocks are gone, so there's nothing for a resumed job to re-use
 		}
 	}
 }
@@ -282,7 +299,55 @@ func (s *blockBlobSenderBase) setBlockID(index int32, value string) {
 	s.blockIDs[index] = value
 }
 
-func (s *blockBlobSenderBase) generateEncodedBlockID() string {
-	blockID := common.NewUUID().String()
+// generateEncodedBlockID returns the ID of the block at the given index.
+// The ID is derived from the job ID and the transfer's position in the job, rather than being random, so that
+// a resumed job computes exactly the same IDs as the run that was interrupted, and can therefore recognise blocks
+// which are already staged. The raw ID has the shape of a UUID string, just like the random IDs we used to use,
+// since the service requires all the blocks of one blob to have IDs of the same length.
+func (s *blockBlobSenderBase) generateEncodedBlockID(index int32) string {
+	partNum, transferIndex := s.jptm.TransferIndex()
+	h := md5.Sum([]byte(fmt.Sprintf("%s-%d-%d-%d", s.jptm.Info().JobID.String(), partNum, transferIndex, index)))
+	blockID := fmt.Sprintf("%x-%x-%x-%x-%x", h[0:4], h[4:6], h[6:8], h[8:10], h[10:16])
 	return base64.StdEncoding.EncodeToString([]byte(blockID))
 }
+
+// ChunkAlreadySent returns true if an earlier run of this job has already staged the block at the given index,
+// with the expected size. Such blocks do not need to be sent again.
+func (s *blockBlobSenderBase) ChunkAlreadySent(blockIndex int32, chunkSize int64) bool {
+	s.stagedBlocksOnce.Do(s.loadStagedBlocks)
+	if len(s.stagedBlocks) == 0 {
+		return false
+	}
+	size, ok := s.stagedBlocks[s.generateEncodedBlockID(blockIndex)]
+	return ok && size == chunkSize
+}
+
+// loadStagedBlocks finds out which blocks were left uncommitted at the destination by an interrupted run of this job.
+// We only ask the service when the plan file says that some blocks were staged, so fresh transfers pay nothing for this.
+func (s *blockBlobSenderBase) loadStagedBlocks() {
+	if s.numChunks <= 1 || s.jptm.ChunksPersisted() == 0 {
+		return
+	}
+
+	blockList, err := s.destBlockBlobURL.GetBlockList(s.jptm.Context(), azblob.BlockListUncommitted, azblob.LeaseAccessConditions{})
+	if err != nil {
+		// not fatal. We just have to send all the blocks again
+		s.jptm.LogAtLevelForCurrentTransfer(pipeline.LogDebug, "Could not list previously staged blocks, so will send all blocks. "+err.Error())
+		return
+	}
+
+	s.stagedBlocks = make(map[string]int64, len(blockList.UncommittedBlocks))
+	for _, b := range blockList.UncommittedBlocks {
+		s.stagedBlocks[b.Name] = b.Size
+	}
+	s.jptm.LogAtLevelForCurrentTransfer(pipeline.LogInfo, fmt.Sprintf("Found %d previously staged blocks which may be re-used", len(s.stagedBlocks)))
+}
+
+// generateAlreadySentBlock generates a func for a block that an earlier run of the job has already staged.
+// It sends nothing, but records the block ID so that the block is included when we commit the block list.
+func (s *blockBlobSenderBase) generateAlreadySentBlock(id common.ChunkID, blockIndex int32) chunkFunc {
+	return createSendToRemoteChunkFunc(s.jptm, id, func() {
+		s.setBlockID(blockIndex, s.generateEncodedBlockID(blockIndex))
+		atomic.AddInt32(&s.atomicChunksWritten, 1)
+	})
+}
@@ -59,6 +59,13 @@ func (u *blockBlobUploader) GenerateUploadFunc(id common.ChunkID, blockIndex int
 		return u.generatePutWholeBlob(id, blockIndex, reader)
 	} else {
 		setPutListNeed(&u.atomicPutListIndicator, putListNeeded)
+		if u.ChunkAlreadySent(blockIndex, id.Length()) {
+			// If there is a reader, it was only needed so that the chunk could be hashed. We won't be sending it.
+			if reader != nil {
+				_ = reader.Close()
+			}
+			return u.generateAlreadySentBlock(id, blockIndex)
+		}
 		return u.generatePutBlock(id, blockIndex, reader)
 	}
 }
@@ -67,7 +74,7 @@ func (u *blockBlobUploader) GenerateUploadFunc(id common.ChunkID, blockIndex int
 func (u *blockBlobUploader) generatePutBlock(id common.ChunkID, blockIndex int32, reader common.SingleChunkReader) chunkFunc {
 	return createSendToRemoteChunkFunc(u.jptm, id, func() {
 		// step 1: generate block ID
-		encodedBlockID := u.generateEncodedBlockID()
+		encodedBlockID := u.generateEncodedBlockID(blockIndex)
 
 		// step 2: save the block ID into the list of block IDs
 		u.setBlockID(blockIndex, encodedBlockID)
@@ -82,6 +89,7 @@ func (u *blockBlobUploader) generatePutBlock(id common.ChunkID, blockIndex int32
 		}
 
 		atomic.AddInt32(&u.atomicChunksWritten, 1)
+		u.jptm.ReportChunkPersisted()
 	})
 }
 
@@ -110,8 +118,9 @@ func (u *blockBlobUploader) generatePutWholeBlob(id common.ChunkID, blockIndex i
 			destBlobTier = azblob.AccessTierNone
 		}
 
+		var resp *azblob.BlockBlobUploadResponse
 		if jptm.Info().SourceSize == 0 {
-			_, err = u.destBlockBlobURL.Upload(jptm.Context(), bytes.NewReader(nil), u.headersToApply, u.metadataToApply, azblob.BlobAccessConditions{}, destBlobTier, blobTags, u.cpkToApply, azblob.ImmutabilityPolicyOptions{})
+			resp, err = u.destBlockBlobURL.Upload(jptm.Context(), bytes.NewReader(nil), u.headersToApply, u.metadataToApply, azblob.BlobAccessConditions{}, destBlobTier, blobTags, u.cpkToApply, azblob.ImmutabilityPolicyOptions{})
 		} else {
 			// File with content
 
@@ -122,10 +131,11 @@ func (u *blockBlobUploader) generatePutWholeBlob(id common.ChunkID, blockIndex i
 				return
 			}
 			u.headersToApply.ContentMD5 = md5Hash
+			jptm.SetContentMD5(md5Hash)
 
 			// Upload the file
 			body := newPacedRequestBody(jptm.Context(), reader, u.pacer)
-			_, err = u.destBlockBlobURL.Upload(jptm.Context(), body, u.headersToApply, u.metadataToApply,
+			resp, err = u.destBlockBlobURL.Upload(jptm.Context(), body, u.headersToApply, u.metadataToApply,
 				azblob.BlobAccessConditions{}, u.destBlobTier, blobTags, u.cpkToApply, azblob.ImmutabilityPolicyOptions{})
 		}
 
@@ -134,6 +144,7 @@ func (u *blockBlobUploader) generatePutWholeBlob(id common.ChunkID, blockIndex i
 			jptm.FailActiveUpload("Uploading blob", err)
 			return
 		}
+		jptm.SetDestinationVersionID(resp.VersionID())
 
 		atomic.AddInt32(&u.atomicChunksWritten, 1)
 
@@ -155,6 +166,7 @@ func (u *blockBlobUploader) Epilogue() {
 		md5Hash, ok := <-u.md5Channel
 		if ok {
 			u.headersToApply.ContentMD5 = md5Hash
+			jptm.SetContentMD5(md5Hash)
 		} else {
 			jptm.FailActiveSend("Getting hash", errNoHash)
 			return
@@ -80,6 +80,9 @@ func (c *urlToBlockBlobCopier) GenerateCopyFunc(id common.ChunkID, blockIndex in
 
 	}
 	setPutListNeed(&c.atomicPutListIndicator, putListNeeded)
+	if c.ChunkAlreadySent(blockIndex, adjustedChunkSize) {
+		return c.generateAlreadySentBlock(id, blockIndex)
+	}
 	return c.generatePutBlockFromURL(id, blockIndex, adjustedChunkSize)
 }
 
@@ -107,10 +110,12 @@ func (c *urlToBlockBlobCopier) generateCreateEmptyBlob(id common.ChunkID) chunkF
 			destBlobTier = azblob.AccessTierNone
 		}
 
-		if _, err := c.destBlockBlobURL.Upload(c.jptm.Context(), bytes.NewReader(nil), c.headersToApply, c.metadataToApply, azblob.BlobAccessConditions{}, destBlobTier, blobTags, c.cpkToApply, azblob.ImmutabilityPolicyOptions{}); err != nil {
+		resp, err := c.destBlockBlobURL.Upload(c.jptm.Context(), bytes.NewReader(nil), c.headersToApply, c.metadataToApply, azblob.BlobAccessConditions{}, destBlobTier, blobTags, c.cpkToApply, azblob.ImmutabilityPolicyOptions{})
+		if err != nil {
 			jptm.FailActiveSend("Creating empty blob", err)
 			return
 		}
+		jptm.SetDestinationVersionID(resp.VersionID())
 
 		atomic.AddInt32(&c.atomicChunksWritten, 1)
 
@@ -126,7 +131,7 @@ func (c *urlToBlockBlobCopier) generateCreateEmptyBlob(id common.ChunkID) chunkF
 func (c *urlToBlockBlobCopier) generatePutBlockFromURL(id common.ChunkID, blockIndex int32, adjustedChunkSize int64) chunkFunc {
 	return createSendToRemoteChunkFunc(c.jptm, id, func() {
 		// step 1: generate block ID
-		encodedBlockID := c.generateEncodedBlockID()
+		encodedBlockID := c.generateEncodedBlockID(blockIndex)
 
 		// step 2: save the block ID into the list of block IDs
 		c.setBlockID(blockIndex, encodedBlockID)
@@ -145,6 +150,7 @@ func (c *urlToBlockBlobCopier) generatePutBlockFromURL(id common.ChunkID, blockI
 		}
 
 		atomic.AddInt32(&c.atomicChunksWritten, 1)
+		c.jptm.ReportChunkPersisted()
 	})
 }
 
@@ -174,7 +180,7 @@ func (c *urlToBlockBlobCopier) generateStartPutBlobFromURL(id common.ChunkID, bl
 			c.jptm.FailActiveUpload("Pacing block", err)
 		}
 
-		_, err := c.destBlockBlobURL.PutBlobFromURL(c.jptm.Context(), c.headersToApply, c.srcURL, c.metadataToApply,
+		resp, err := c.destBlockBlobURL.PutBlobFromURL(c.jptm.Context(), c.headersToApply, c.srcURL, c.metadataToApply,
 			azblob.ModifiedAccessConditions{}, azblob.BlobAccessConditions{}, nil, nil, destBlobTier, blobTags,
 			c.cpkToApply, c.jptm.GetS2SSourceBlobTokenCredential())
 
@@ -182,6 +188,7 @@ func (c *urlToBlockBlobCopier) generateStartPutBlobFromURL(id common.ChunkID, bl
 			c.jptm.FailActiveSend("Put Blob from URL", err)
 			return
 		}
+		c.jptm.SetDestinationVersionID(resp.VersionID())
 
 		atomic.AddInt32(&c.atomicChunksWritten, 1)
 
@@ -47,6 +47,10 @@ func (u *pageBlobUploader) Md5Channel() chan<- []byte {
 	return u.md5Channel
 }
 
+func (u *pageBlobUploader) SkipsZeroChunks() bool {
+	return true
+}
+
 func (u *pageBlobUploader) GenerateUploadFunc(id common.ChunkID, blockIndex int32, reader common.SingleChunkReader, chunkIsWholeFile bool) chunkFunc {
 
 	return createSendToRemoteChunkFunc(u.jptm, id, func() {
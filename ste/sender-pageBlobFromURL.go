@@ -22,6 +22,8 @@ package ste
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/url"
 	"strings"
 
@@ -36,6 +38,7 @@ type urlToPageBlobCopier struct {
 
 	srcURL                   url.URL
 	sourcePageRangeOptimizer *pageRangeOptimizer // nil if src is not a page blob
+	sourcePageRangeDiff      *pageRangeDiff      // nil unless this is an incremental copy
 }
 
 func newURLToPageBlobCopier(jptm IJobPartTransferMgr, destination string, p pipeline.Pipeline, pacer pacer, srcInfoProvider IRemoteSourceInfoProvider) (s2sCopier, error) {
@@ -46,6 +49,7 @@ func newURLToPageBlobCopier(jptm IJobPartTransferMgr, destination string, p pipe
 
 	destBlobTier := azblob.AccessTierNone
 	var pageRangeOptimizer *pageRangeOptimizer
+	var pageRangeDiff *pageRangeDiff
 	if blobSrcInfoProvider, ok := srcInfoProvider.(IBlobSourceInfoProvider); ok {
 		if blobSrcInfoProvider.BlobType() == azblob.BlobPageBlob {
 			// if the source is page blob, preserve source's blob tier.
@@ -53,8 +57,15 @@ func newURLToPageBlobCopier(jptm IJobPartTransferMgr, destination string, p pipe
 
 			// capture the necessary info so that we can perform optimizations later
 			pageRangeOptimizer = newPageRangeOptimizer(azblob.NewPageBlobURL(*srcURL, p), jptm.Context())
+
+			if baseline := jptm.PageDiffBaseline(); baseline != "" {
+				pageRangeDiff = newPageRangeDiff(azblob.NewPageBlobURL(*srcURL, p), jptm.Context(), baseline, jptm.PageDiffBaselineSAS())
+			}
 		}
 	}
+	if jptm.PageDiffBaseline() != "" && pageRangeDiff == nil {
+		return nil, errIncrementalCopyRequiresPageBlob
+	}
 
 	senderBase, err := newPageBlobSenderBase(jptm, destination, p, pacer, srcInfoProvider, destBlobTier)
 	if err != nil {
@@ -64,10 +75,20 @@ func newURLToPageBlobCopier(jptm IJobPartTransferMgr, destination string, p pipe
 	return &urlToPageBlobCopier{
 		pageBlobSenderBase:       *senderBase,
 		srcURL:                   *srcURL,
-		sourcePageRangeOptimizer: pageRangeOptimizer}, nil
+		sourcePageRangeOptimizer: pageRangeOptimizer,
+		sourcePageRangeDiff:      pageRangeDiff}, nil
 }
 
+var errIncrementalCopyRequiresPageBlob = errors.New("incremental copy is only supported when the source is a page blob")
+
+// the metadata key under which we record, on the destination's snapshot, which source snapshot it matches
+const incrementalBaselineMetadataKey = "azcopyincrementalbaseline"
+
 func (c *urlToPageBlobCopier) Prologue(ps common.PrologueState) (destinationModified bool) {
+	if c.sourcePageRangeDiff != nil {
+		return c.incrementalPrologue()
+	}
+
 	destinationModified = c.pageBlobSenderBase.Prologue(ps)
 
 	if c.sourcePageRangeOptimizer != nil {
@@ -77,6 +98,49 @@ func (c *urlToPageBlobCopier) Prologue(ps common.PrologueState) (destinationModi
 	return
 }
 
+// incrementalPrologue prepares to copy only the changed pages. Unlike the normal prologue, it never
+// creates the destination, since the destination must already hold the content of the baseline snapshot.
+func (c *urlToPageBlobCopier) incrementalPrologue() (destinationModified bool) {
+	c.filePacer = newPageBlobAutoPacer(pageBlobInitialBytesPerSecond, c.ChunkSize(), false, c.jptm.(common.ILogger))
+
+	p, err := c.destPageBlobURL.GetProperties(c.jptm.Context(), azblob.BlobAccessConditions{}, c.cpkToApply)
+	if err != nil {
+		c.jptm.FailActiveS2SCopy("Checking destination of incremental copy", err)
+		return
+	}
+	if p.BlobType() != azblob.BlobPageBlob {
+		c.jptm.FailActiveS2SCopy("Checking destination of incremental copy", errors.New("destination of an incremental copy must be an existing page blob"))
+		return
+	}
+
+	if p.ContentLength() != c.srcSize {
+		if c.isInManagedDiskImportExportAccount() {
+			c.jptm.FailActiveS2SCopy("Checking size of managed disk blob", fmt.Errorf("source is %d bytes but destination is %d bytes", c.srcSize, p.ContentLength()))
+			return
+		}
+		// the disk has been resized since the baseline. Any pages in the new area will be listed in the diff
+		if _, err := c.destPageBlobURL.Resize(c.jptm.Context(), c.srcSize, azblob.BlobAccessConditions{}, c.cpkToApply); err != nil {
+			c.jptm.FailActiveS2SCopy("Resizing destination of incremental copy", err)
+			return
+		}
+		destinationModified = true
+	}
+
+	if err := c.sourcePageRangeDiff.fetchDiff(); err != nil {
+		c.jptm.FailActiveS2SCopy("Getting page ranges changed since "+c.sourcePageRangeDiff.baselineForLogging(), err)
+		return
+	}
+
+	if c.sourcePageRangeDiff.diff == nil {
+		c.jptm.Log(pipeline.LogWarning, fmt.Sprintf("Incremental copy: the service didn't list all the page ranges changed since %s, so the whole blob will be copied",
+			c.sourcePageRangeDiff.baselineForLogging()))
+		return
+	}
+	c.jptm.Log(pipeline.LogInfo, fmt.Sprintf("Incremental copy: %d changed and %d cleared page ranges since %s",
+		len(c.sourcePageRangeDiff.diff.PageRange), len(c.sourcePageRangeDiff.diff.ClearRange), c.sourcePageRangeDiff.baselineForLogging()))
+	return
+}
+
 // Returns a chunk-func for blob copies
 func (c *urlToPageBlobCopier) GenerateCopyFunc(id common.ChunkID, blockIndex int32, adjustedChunkSize int64, chunkIsWholeFile bool) chunkFunc {
 
@@ -86,9 +150,20 @@ func (c *urlToPageBlobCopier) GenerateCopyFunc(id common.ChunkID, blockIndex int
 			return
 		}
 
-		// if there's no data at the source (and the destination for managed disks), skip this chunk
 		pageRange := azblob.PageRange{Start: id.OffsetInFile(), End: id.OffsetInFile() + adjustedChunkSize - 1}
-		if c.sourcePageRangeOptimizer != nil && !c.sourcePageRangeOptimizer.doesRangeContainData(pageRange) {
+
+		// if this is an incremental copy, only touch what has changed since the baseline
+		if c.sourcePageRangeDiff != nil {
+			dataChanged, clearedRanges := c.sourcePageRangeDiff.changesIn(pageRange)
+			if !dataChanged {
+				c.clearPages(id, clearedRanges)
+				return
+			}
+			// else, copy the whole chunk. Its unchanged pages are already the same at the destination, so copying them is harmless
+		}
+
+		// if there's no data at the source (and the destination for managed disks), skip this chunk
+		if c.sourcePageRangeDiff == nil && c.sourcePageRangeOptimizer != nil && !c.sourcePageRangeOptimizer.doesRangeContainData(pageRange) {
 			var destContainsData bool
 
 			if c.destPageRangeOptimizer != nil {
@@ -130,6 +205,69 @@ func (c *urlToPageBlobCopier) GenerateCopyFunc(id common.ChunkID, blockIndex int
 	})
 }
 
+// clearPages frees, at the destination, the pages that were cleared at the source since the baseline
+func (c *urlToPageBlobCopier) clearPages(id common.ChunkID, clearedRanges []azblob.PageRange) {
+	for _, r := range clearedRanges {
+		c.jptm.LogChunkStatus(id, common.EWaitReason.S2SCopyOnWire())
+		_, err := c.destPageBlobURL.ClearPages(c.jptm.Context(), r.Start, r.End-r.Start+1, azblob.PageBlobAccessConditions{}, c.cpkToApply)
+		if err != nil {
+			c.jptm.FailActiveS2SCopy("Clearing pages", err)
+			return
+		}
+	}
+}
+
+func (c *urlToPageBlobCopier) Epilogue() {
+	if c.sourcePageRangeDiff != nil && c.jptm.IsLive() {
+		c.recordBaseline()
+	}
+
+	c.pageBlobSenderBase.Epilogue()
+}
+
+// recordBaseline snapshots the destination, so that it has a point-in-time copy that matches the source as it is now,
+// and records on that snapshot what it matches. That is the baseline to use for the next incremental copy.
+func (c *urlToPageBlobCopier) recordBaseline() {
+	newBaseline := azblob.NewBlobURLParts(c.srcURL).Snapshot
+	if newBaseline == "" {
+		if !isInManagedDiskImportExportAccount(c.srcURL) {
+			// the live source may already have changed since we copied it, so it can't be the baseline of anything
+			c.jptm.FailActiveS2SCopy("Recording baseline for next incremental copy", errors.New("the source of an incremental copy must be a snapshot"))
+			return
+		}
+		// the source isn't a snapshot of a page blob, so it's a managed disk snapshot, which is identified by its URL
+		u := c.srcURL
+		u.RawQuery = ""
+		newBaseline = u.String()
+	}
+
+	if c.isInManagedDiskImportExportAccount() {
+		// can't snapshot these, so just tell the user
+		c.jptm.Log(pipeline.LogInfo, "Incremental copy complete. Baseline for the next incremental copy is "+newBaseline)
+		return
+	}
+
+	resp, err := c.destPageBlobURL.CreateSnapshot(c.jptm.Context(), azblob.Metadata{incrementalBaselineMetadataKey: newBaseline}, azblob.BlobAccessConditions{}, c.cpkToApply)
+	if err != nil {
+		c.jptm.FailActiveS2SCopy("Recording baseline for next incremental copy", err)
+		return
+	}
+	c.jptm.Log(pipeline.LogInfo, fmt.Sprintf("Incremental copy complete. Baseline for the next incremental copy is %s. Recorded on destination snapshot %s", newBaseline, resp.Snapshot()))
+}
+
+func (c *urlToPageBlobCopier) Cleanup() {
+	if c.sourcePageRangeDiff != nil {
+		// Never delete the destination of an incremental copy. It existed before we started, and
+		// the pages we didn't get to are just as they were. The user can simply run the copy again.
+		if c.jptm.IsDeadInflight() {
+			c.jptm.LogAtLevelForCurrentTransfer(pipeline.LogWarning, "Incremental copy did not complete. The destination is only partially updated, so run the copy again before relying on it")
+		}
+		return
+	}
+
+	c.pageBlobSenderBase.Cleanup()
+}
+
 // GetDestinationLength gets the destination length.
 func (c *urlToPageBlobCopier) GetDestinationLength() (int64, error) {
 	properties, err := c.destPageBlobURL.GetProperties(c.jptm.Context(), azblob.BlobAccessConditions{}, c.cpkToApply)
@@ -157,8 +295,7 @@ func newPageRangeOptimizer(srcPageBlobURL azblob.PageBlobURL, ctx context.Contex
 func (p *pageRangeOptimizer) fetchPages() {
 	// don't fetch page blob list if optimizations are not desired,
 	// the lack of page list indicates that there's data everywhere
-	if !strings.EqualFold(common.GetLifecycleMgr().GetEnvironmentVariable(
-		common.EEnvironmentVariable.OptimizeSparsePageBlobTransfers()), "true") {
+	if !sparsePageBlobOptimizationsEnabled() {
 		return
 	}
 
@@ -175,6 +312,12 @@ func (p *pageRangeOptimizer) fetchPages() {
 	}
 }
 
+// sparse page blob optimizations are opt-in, since listing the page ranges can be slow for highly fragmented blobs
+func sparsePageBlobOptimizationsEnabled() bool {
+	return strings.EqualFold(common.GetLifecycleMgr().GetEnvironmentVariable(
+		common.EEnvironmentVariable.OptimizeSparsePageBlobTransfers()), "true")
+}
+
 // check whether a particular given range is worth transferring, i.e. whether there's data at the source
 func (p *pageRangeOptimizer) doesRangeContainData(givenRange azblob.PageRange) bool {
 	// if we have no page list stored, then assume there's data everywhere
@@ -207,3 +350,111 @@ func (p *pageRangeOptimizer) doesRangeContainData(givenRange azblob.PageRange) b
 	// went through all srcRanges, but nothing overlapped
 	return false
 }
+
+// pageRangeDiff finds the pages of a page blob that have changed since a previous snapshot of it.
+// Used for incremental copies, where the destination already matches that previous snapshot.
+type pageRangeDiff struct {
+	srcPageBlobURL azblob.PageBlobURL
+	ctx            context.Context
+	baseline       string // snapshot timestamp, or the URL of a previous managed disk snapshot
+	baselineSAS    string // the SAS of the baseline's URL, if it has one
+	diff           *azblob.PageList // nil if the service didn't list all the changes
+}
+
+func newPageRangeDiff(srcPageBlobURL azblob.PageBlobURL, ctx context.Context, baseline string, baselineSAS string) *pageRangeDiff {
+	return &pageRangeDiff{srcPageBlobURL: srcPageBlobURL, ctx: ctx, baseline: baseline, baselineSAS: baselineSAS}
+}
+
+func isPageDiffBaselineURL(baseline string) bool {
+	return strings.HasPrefix(strings.ToLower(baseline), "https://") || strings.HasPrefix(strings.ToLower(baseline), "http://")
+}
+
+// fetchDiff gets the page ranges that changed since the baseline. The service may list them over several pages,
+// but the Track 1 SDK can't pass the marker of the next one, so the ranges that follow the last one listed are asked for instead.
+// If that doesn't get any further, the diff is left unknown, and the whole blob is copied.
+func (d *pageRangeDiff) fetchDiff() error {
+	diff := &azblob.PageList{}
+	offset := int64(0)
+	for {
+		pageList, err := d.fetchDiffFrom(offset)
+		if err != nil {
+			return err
+		}
+		diff.PageRange = append(diff.PageRange, pageList.PageRange...)
+		diff.ClearRange = append(diff.ClearRange, pageList.ClearRange...)
+		if !pageList.NextMarker.NotDone() {
+			d.diff = diff
+			return nil
+		}
+
+		next := offset
+		if n := len(pageList.PageRange); n > 0 && pageList.PageRange[n-1].End+1 > next {
+			next = pageList.PageRange[n-1].End + 1
+		}
+		if n := len(pageList.ClearRange); n > 0 && pageList.ClearRange[n-1].End+1 > next {
+			next = pageList.ClearRange[n-1].End + 1
+		}
+		if next == offset {
+			d.diff = nil
+			return nil
+		}
+		offset = next
+	}
+}
+
+// fetchDiffFrom gets the page ranges that changed since the baseline, from the given offset to the end of the blob
+func (d *pageRangeDiff) fetchDiffFrom(offset int64) (*azblob.PageList, error) {
+	if isPageDiffBaselineURL(d.baseline) {
+		prevSnapshotURL := d.prevSnapshotURL()
+		return d.srcPageBlobURL.GetManagedDiskPageRangesDiff(d.ctx, offset, azblob.CountToEnd, nil, &prevSnapshotURL, azblob.BlobAccessConditions{})
+	}
+	return d.srcPageBlobURL.GetPageRangesDiff(d.ctx, offset, azblob.CountToEnd, d.baseline, azblob.BlobAccessConditions{})
+}
+
+// prevSnapshotURL returns the URL of the baseline snapshot, with its own SAS, which is only kept in memory.
+// If it has none, e.g. because the job was resumed without it, it is authorized in the same way as the source.
+func (d *pageRangeDiff) prevSnapshotURL() string {
+	prevURL, err := url.Parse(d.baseline)
+	if err != nil {
+		return d.baseline
+	}
+	srcURL := d.srcPageBlobURL.URL()
+	if d.baselineSAS != "" {
+		prevURL.RawQuery = d.baselineSAS
+	} else if prevURL.RawQuery == "" && strings.EqualFold(prevURL.Host, srcURL.Host) {
+		prevURL.RawQuery = srcURL.RawQuery
+	}
+	return prevURL.String()
+}
+
+func (d *pageRangeDiff) baselineForLogging() string {
+	return strings.Split(d.baseline, "?")[0]
+}
+
+// changesIn says whether any data in the given range changed since the baseline. If not, it also returns
+// the parts of the given range which were cleared since the baseline (if any).
+// If the diff is unknown, every range is taken to have changed.
+func (d *pageRangeDiff) changesIn(givenRange azblob.PageRange) (dataChanged bool, clearedRanges []azblob.PageRange) {
+	if d.diff == nil {
+		return true, nil
+	}
+	for _, r := range d.diff.PageRange {
+		if givenRange.End < r.Start {
+			break // the list is sorted, so nothing later can overlap
+		} else if r.End >= givenRange.Start {
+			return true, nil
+		}
+	}
+
+	for _, r := range d.diff.ClearRange {
+		if givenRange.End < r.Start {
+			break
+		} else if r.End >= givenRange.Start {
+			clearedRanges = append(clearedRanges, azblob.PageRange{
+				Start: common.Iffint64(r.Start > givenRange.Start, r.Start, givenRange.Start),
+				End:   common.Iffint64(r.End < givenRange.End, r.End, givenRange.End),
+			})
+		}
+	}
+	return false, clearedRanges
+}
@@ -75,6 +75,23 @@ type folderSender interface {
 	DirUrlToString() string // This is only used in folder tracking, so this should trim the SAS token.
 }
 
+/////////////////////////////////////////////////////////////////////////////////////////////////
+// resumableSender is a sender that can recognise chunks which were already sent by an earlier,
+// interrupted, run of the same job. Such chunks don't need to be sent again.
+/////////////////////////////////////////////////////////////////////////////////////////////////
+type resumableSender interface {
+	ChunkAlreadySent(chunkIndex int32, chunkSize int64) bool
+}
+
+/////////////////////////////////////////////////////////////////////////////////////////////////
+// zeroSkippingUploader is an uploader which never needs to send ranges that are entirely zeros
+// (because its destination is sparse). For such uploaders, we don't even read the holes in sparse local files.
+/////////////////////////////////////////////////////////////////////////////////////////////////
+type zeroSkippingUploader interface {
+	uploader
+	SkipsZeroChunks() bool
+}
+
 type senderFactory func(jptm IJobPartTransferMgr, destination string, p pipeline.Pipeline, pacer pacer, sip ISourceInfoProvider) (sender, error)
 
 /////////////////////////////////////////////////////////////////////////////////////////////////
@@ -119,6 +136,7 @@ func newMd5Channel() chan []byte {
 func tryPutMd5Hash(jptm IJobPartTransferMgr, md5Channel <-chan []byte, worker func(hash []byte) error) {
 	md5Hash, ok := <-md5Channel
 	if ok {
+		jptm.SetContentMD5(md5Hash)
 		err := worker(md5Hash)
 		if err != nil {
 			jptm.FailActiveUpload("Setting hash", err)
@@ -0,0 +1,192 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ste
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/Azure/azure-pipeline-go/pipeline"
+	"github.com/Azure/azure-storage-blob-go/azblob"
+	chk "gopkg.in/check.v1"
+)
+
+type appendBlobFollowSuite struct{}
+
+var _ = chk.Suite(&appendBlobFollowSuite{})
+
+// newTestFileFollower returns a follower that "appends" to dest, checking append positions just like the service does
+func newTestFileFollower(path string, dest *bytes.Buffer) *fileFollower {
+	return &fileFollower{
+		path:      path,
+		chunkSize: 4,
+		appendData: func(data []byte, blobOffset int64) error {
+			if blobOffset != int64(dest.Len()) {
+				return fmt.Errorf("append position %d does not match blob length %d", blobOffset, dest.Len())
+			}
+			dest.Write(data)
+			return nil
+		},
+		saveState: func(uint64, int64, int64) {},
+		log:       func(string) {},
+	}
+}
+
+func appendToFile(c *chk.C, path string, data string) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	c.Assert(err, chk.IsNil)
+	_, err = f.WriteString(data)
+	c.Assert(err, chk.IsNil)
+	c.Assert(f.Close(), chk.IsNil)
+}
+
+func (s *appendBlobFollowSuite) TestFollowerAppendsNewData(c *chk.C) {
+	path := filepath.Join(c.MkDir(), "app.log")
+	appendToFile(c, path, "first line\n")
+
+	dest := &bytes.Buffer{}
+	f := newTestFileFollower(path, dest)
+	defer f.close()
+	c.Assert(f.open(0), chk.IsNil)
+
+	c.Assert(f.poll(), chk.IsNil)
+	c.Assert(dest.String(), chk.Equals, "first line\n")
+
+	c.Assert(f.poll(), chk.IsNil) // nothing new
+	appendToFile(c, path, "second line\n")
+	c.Assert(f.poll(), chk.IsNil)
+	c.Assert(dest.String(), chk.Equals, "first line\nsecond line\n")
+	c.Assert(f.fileOffset, chk.Equals, int64(dest.Len()))
+}
+
+func (s *appendBlobFollowSuite) TestFollowerHandlesRotation(c *chk.C) {
+	dir := c.MkDir()
+	path := filepath.Join(dir, "app.log")
+	appendToFile(c, path, "old\n")
+
+	dest := &bytes.Buffer{}
+	f := newTestFileFollower(path, dest)
+	defer f.close()
+	c.Assert(f.open(0), chk.IsNil)
+	c.Assert(f.poll(), chk.IsNil)
+
+	// the old file gets one last write as it is rotated, and then the new file appears
+	c.Assert(os.Rename(path, filepath.Join(dir, "app.log.1")), chk.IsNil)
+	appendToFile(c, filepath.Join(dir, "app.log.1"), "last old\n")
+	c.Assert(f.poll(), chk.IsNil) // no file at the path yet
+	appendToFile(c, path, "new\n")
+	c.Assert(f.poll(), chk.IsNil)
+
+	c.Assert(dest.String(), chk.Equals, "old\nlast old\nnew\n")
+	c.Assert(f.blobOffset, chk.Equals, int64(len("old\nlast old\n")))
+	c.Assert(f.fileOffset, chk.Equals, int64(len("new\n")))
+}
+
+func (s *appendBlobFollowSuite) TestFollowerHandlesTruncation(c *chk.C) {
+	path := filepath.Join(c.MkDir(), "app.log")
+	appendToFile(c, path, "before truncation\n")
+
+	dest := &bytes.Buffer{}
+	f := newTestFileFollower(path, dest)
+	defer f.close()
+	c.Assert(f.open(0), chk.IsNil)
+	c.Assert(f.poll(), chk.IsNil)
+
+	c.Assert(os.Truncate(path, 0), chk.IsNil)
+	appendToFile(c, path, "after\n")
+	c.Assert(f.poll(), chk.IsNil)
+
+	c.Assert(dest.String(), chk.Equals, "before truncation\nafter\n")
+}
+
+func (s *appendBlobFollowSuite) TestFollowerResumesFromDestinationLength(c *chk.C) {
+	path := filepath.Join(c.MkDir(), "app.log")
+	appendToFile(c, path, "0123456789")
+	dest := bytes.NewBufferString("earlier file\n01234")
+
+	f := newTestFileFollower(path, dest)
+	defer f.close()
+	c.Assert(f.open(0), chk.IsNil)
+	fileID := f.fileID
+
+	// as if the previous run had started on this file when the blob held just the earlier file
+	c.Assert(f.resume(fileID, int64(len("earlier file\n")), int64(dest.Len())), chk.IsNil)
+	c.Assert(f.poll(), chk.IsNil)
+	c.Assert(dest.String(), chk.Equals, "earlier file\n0123456789")
+}
+
+func (s *appendBlobFollowSuite) TestAppendWhoseResponseWasLostIsDone(c *chk.C) {
+	// the append is rejected, since the blob already holds the given content, as it would on a retry
+	appendBlobHolding := func(content string) azblob.AppendBlobURL {
+		u, _ := url.Parse("https://account.blob.core.windows.net/container/app.log")
+		return azblob.NewAppendBlobURL(*u, pipeline.NewPipeline([]pipeline.Factory{pipeline.MethodFactoryMarker()}, pipeline.Options{
+			HTTPSender: pipeline.FactoryFunc(func(next pipeline.Policy, po *pipeline.PolicyOptions) pipeline.PolicyFunc {
+				return func(ctx context.Context, request pipeline.Request) (pipeline.Response, error) {
+					switch request.Method {
+					case http.MethodHead:
+						return pipeline.NewHTTPResponse(&http.Response{
+							StatusCode:    http.StatusOK,
+							Header:        http.Header{"Content-Length": []string{strconv.Itoa(len(content))}},
+							ContentLength: int64(len(content)),
+							Body:          http.NoBody,
+						}), nil
+					case http.MethodGet:
+						var start, end int
+						_, err := fmt.Sscanf(request.Header.Get("x-ms-range"), "bytes=%d-%d", &start, &end)
+						c.Assert(err, chk.IsNil)
+						part := content[start : end+1]
+						return pipeline.NewHTTPResponse(&http.Response{
+							StatusCode:    http.StatusPartialContent,
+							Header:        http.Header{"Content-Length": []string{strconv.Itoa(len(part))}},
+							ContentLength: int64(len(part)),
+							Body:          io.NopCloser(strings.NewReader(part)),
+						}), nil
+					}
+					return pipeline.NewHTTPResponse(&http.Response{
+						StatusCode: http.StatusPreconditionFailed,
+						Header:     http.Header{"X-Ms-Error-Code": []string{string(azblob.ServiceCodeAppendPositionConditionNotMet)}},
+						Body:       http.NoBody,
+					}), nil
+				}
+			}),
+		}))
+	}
+
+	data := []byte("0123")
+	err := appendBlockAt(context.Background(), appendBlobHolding("abcdefghij0123"), bytes.NewReader(data), data, 10, azblob.ClientProvidedKeyOptions{})
+	c.Assert(err, chk.IsNil)
+
+	// something else appended to the blob
+	err = appendBlockAt(context.Background(), appendBlobHolding("abcdefghij0123456789"), bytes.NewReader(data), data, 10, azblob.ClientProvidedKeyOptions{})
+	c.Assert(err, chk.NotNil)
+
+	// something else appended the same amount to the blob
+	err = appendBlockAt(context.Background(), appendBlobHolding("abcdefghijklmn"), bytes.NewReader(data), data, 10, azblob.ClientProvidedKeyOptions{})
+	c.Assert(err, chk.NotNil)
+}
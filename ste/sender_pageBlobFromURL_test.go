@@ -21,6 +21,14 @@
 package ste
 
 import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/Azure/azure-pipeline-go/pipeline"
 	"github.com/Azure/azure-storage-blob-go/azblob"
 
 	chk "gopkg.in/check.v1"
@@ -56,3 +64,101 @@ func (s *pageBlobFromURLSuite) TestRangeWorthTransferring(c *chk.C) {
 		c.Assert(doesContainData, chk.Equals, expectedResult)
 	}
 }
+
+func (s *pageBlobFromURLSuite) TestPageRangeDiffChangesIn(c *chk.C) {
+	// Arrange
+	diff := pageRangeDiff{}
+	diff.diff = &azblob.PageList{
+		PageRange: []azblob.PageRange{
+			{Start: 512, End: 1023},
+			{Start: 7168, End: 8191},
+		},
+		ClearRange: []azblob.ClearRange{
+			{Start: 2048, End: 4095},
+			{Start: 5120, End: 5631},
+		},
+	}
+
+	testCases := []struct {
+		given           azblob.PageRange
+		expectedChanged bool
+		expectedCleared []azblob.PageRange
+	}{
+		{azblob.PageRange{Start: 0, End: 1023}, true, nil},                                                                        // has changed data
+		{azblob.PageRange{Start: 1024, End: 2047}, false, nil},                                                                    // untouched since the baseline
+		{azblob.PageRange{Start: 3072, End: 6143}, false, []azblob.PageRange{{Start: 3072, End: 4095}, {Start: 5120, End: 5631}}}, // only cleared, so clipped to the given range
+		{azblob.PageRange{Start: 4096, End: 8191}, true, nil},                                                                     // changed data wins over cleared
+		{azblob.PageRange{Start: 8192, End: 9215}, false, nil},                                                                    // beyond all ranges
+	}
+
+	// Action & Assert
+	for _, tc := range testCases {
+		changed, cleared := diff.changesIn(tc.given)
+		c.Assert(changed, chk.Equals, tc.expectedChanged)
+		c.Assert(cleared, chk.DeepEquals, tc.expectedCleared)
+	}
+}
+
+func (s *pageBlobFromURLSuite) TestPageRangeDiffReusesSourceSASForBaselineURL(c *chk.C) {
+	src, _ := url.Parse("https://md-abc.blob.core.windows.net/disk/abcd?sv=2020&sig=xyz")
+	srcBlob := azblob.NewPageBlobURL(*src, azblob.NewPipeline(azblob.NewAnonymousCredential(), azblob.PipelineOptions{}))
+
+	// same host, no SAS of its own, so gets the source's
+	diff := newPageRangeDiff(srcBlob, context.Background(), "https://md-abc.blob.core.windows.net/disk/prev", "")
+	c.Assert(diff.prevSnapshotURL(), chk.Equals, "https://md-abc.blob.core.windows.net/disk/prev?sv=2020&sig=xyz")
+
+	// different host, so never gets the source's SAS
+	diff = newPageRangeDiff(srcBlob, context.Background(), "https://md-other.blob.core.windows.net/disk/prev", "")
+	c.Assert(diff.prevSnapshotURL(), chk.Equals, "https://md-other.blob.core.windows.net/disk/prev")
+
+	// its own SAS, e.g. that of a managed disk snapshot, is used in preference to the source's
+	diff = newPageRangeDiff(srcBlob, context.Background(), "https://md-abc.blob.core.windows.net/disk/prev", "sv=2021&sig=own")
+	c.Assert(diff.prevSnapshotURL(), chk.Equals, "https://md-abc.blob.core.windows.net/disk/prev?sv=2021&sig=own")
+}
+
+// pageBlobListingDiff returns a page blob whose diffs are listed as the given pages, each depending on the offset asked for
+func pageBlobListingDiff(c *chk.C, pages map[string]string) azblob.PageBlobURL {
+	u, _ := url.Parse("https://account.blob.core.windows.net/container/disk?snapshot=2022-01-02T00:00:00.0000000Z")
+	return azblob.NewPageBlobURL(*u, pipeline.NewPipeline([]pipeline.Factory{pipeline.MethodFactoryMarker()}, pipeline.Options{
+		HTTPSender: pipeline.FactoryFunc(func(next pipeline.Policy, po *pipeline.PolicyOptions) pipeline.PolicyFunc {
+			return func(ctx context.Context, request pipeline.Request) (pipeline.Response, error) {
+				body, ok := pages[request.Header.Get("x-ms-range")]
+				c.Assert(ok, chk.Equals, true, chk.Commentf("unexpected range %q", request.Header.Get("x-ms-range")))
+				return pipeline.NewHTTPResponse(&http.Response{
+					StatusCode: http.StatusOK,
+					Header:     http.Header{},
+					Body:       io.NopCloser(strings.NewReader(`<?xml version="1.0" encoding="utf-8"?><PageList>` + body + `</PageList>`)),
+				}), nil
+			}
+		}),
+	}))
+}
+
+func pageRangeXml(element string, start, end int64) string {
+	return fmt.Sprintf("<%s><Start>%d</Start><End>%d</End></%s>", element, start, end, element)
+}
+
+func (s *pageBlobFromURLSuite) TestPageRangeDiffFollowsOnFromLastListedRange(c *chk.C) {
+	src := pageBlobListingDiff(c, map[string]string{
+		"":            pageRangeXml("PageRange", 0, 511) + pageRangeXml("ClearRange", 1024, 2047) + "<NextMarker>2</NextMarker>",
+		"bytes=2048-": pageRangeXml("PageRange", 4096, 4607) + "<NextMarker>3</NextMarker>",
+		"bytes=4608-": pageRangeXml("ClearRange", 8192, 8703) + "<NextMarker></NextMarker>",
+	})
+	diff := newPageRangeDiff(src, context.Background(), "2022-01-01T00:00:00.0000000Z", "")
+	c.Assert(diff.fetchDiff(), chk.IsNil)
+	c.Assert(diff.diff.PageRange, chk.DeepEquals, []azblob.PageRange{{Start: 0, End: 511}, {Start: 4096, End: 4607}})
+	c.Assert(diff.diff.ClearRange, chk.DeepEquals, []azblob.ClearRange{{Start: 1024, End: 2047}, {Start: 8192, End: 8703}})
+}
+
+func (s *pageBlobFromURLSuite) TestPageRangeDiffCopiesEverythingIfListingGetsNoFurther(c *chk.C) {
+	src := pageBlobListingDiff(c, map[string]string{
+		"": "<NextMarker>2</NextMarker>",
+	})
+	diff := newPageRangeDiff(src, context.Background(), "2022-01-01T00:00:00.0000000Z", "")
+	c.Assert(diff.fetchDiff(), chk.IsNil)
+	c.Assert(diff.diff, chk.IsNil)
+
+	changed, cleared := diff.changesIn(azblob.PageRange{Start: 0, End: 511})
+	c.Assert(changed, chk.Equals, true)
+	c.Assert(cleared, chk.IsNil)
+}
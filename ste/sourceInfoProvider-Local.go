@@ -63,6 +63,11 @@ func (f localFileSourceInfoProvider) IsLocal() bool {
 func (f localFileSourceInfoProvider) OpenSourceFile() (common.CloseableReaderAt, error) {
 	path := f.jptm.Info().Source
 
+	if format := f.jptm.DiskImageFormat(); format != common.EDiskImageFormat.None() {
+		// the size of the transfer is already that of the VHD (see enumeration), so read it as one
+		return common.OpenDiskImageAsFixedVHD(path, format)
+	}
+
 	if custom, ok := interface{}(f).(ICustomLocalOpener); ok {
 		return custom.Open(path)
 	}
@@ -0,0 +1,129 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ste
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+)
+
+// The errors of a job's failed transfers are kept in a file beside its plan files, so that 'jobs show' can say why they failed
+// long after the log has been rotated away. It's a file of its own, rather than part of the plan, because the plan's
+// layout is fixed when the part is created, while the errors are only known as the transfers fail.
+// The name has the ".steV" of plan files, so that the file is removed with them, but not their suffix, so that it isn't mistaken for one.
+const transferErrorsFileNameFormat = "%v.steV%d.errors"
+
+// maxTransferErrorMessageLength keeps the file small, even when every transfer of a big job fails with a verbose error
+const maxTransferErrorMessageLength = 512
+
+// TransferError is why a transfer failed
+type TransferError struct {
+	HTTPStatus int    `json:"httpStatus,omitempty"`
+	ErrorCode  string `json:"errorCode,omitempty"` // the service's error code, e.g. BlobNotFound
+	Message    string `json:"message"`
+}
+
+// transferErrorRecord is a line of the file. A transfer that fails again, e.g. after the job was resumed, gets another line, and the last one wins.
+type transferErrorRecord struct {
+	PartNumber    common.PartNumber `json:"part"`
+	TransferIndex uint32            `json:"transfer"`
+	TransferError
+}
+
+// TransferErrorKey identifies a transfer of a job
+type TransferErrorKey struct {
+	PartNumber    common.PartNumber
+	TransferIndex uint32
+}
+
+func transferErrorsPath(jobID common.JobID) string {
+	return filepath.Join(common.AzcopyJobPlanFolder, fmt.Sprintf(transferErrorsFileNameFormat, jobID, DataSchemaVersion))
+}
+
+// transferErrorLog appends to the file of a job's transfer errors
+type transferErrorLog struct {
+	path string
+	lock *sync.Mutex
+}
+
+func newTransferErrorLog(jobID common.JobID) *transferErrorLog {
+	return &transferErrorLog{path: transferErrorsPath(jobID), lock: &sync.Mutex{}}
+}
+
+// record appends the error of a transfer. The file is opened for each one, since failures are rare enough, and
+// that way a job that's kept in memory, e.g. by 'azcopy daemon', doesn't keep a file open.
+func (l *transferErrorLog) record(partNum common.PartNumber, transferIndex uint32, e TransferError) error {
+	r := transferErrorRecord{PartNumber: partNum, TransferIndex: transferIndex, TransferError: e}
+	r.Message = common.NewAzCopyLogSanitizer().SanitizeLogMessage(r.Message)
+	if len(r.Message) > maxTransferErrorMessageLength {
+		// cut on a rune boundary, so as not to leave half a character before the ellipsis
+		cut := maxTransferErrorMessageLength - 3
+		for cut > 0 && !utf8.RuneStart(r.Message[cut]) {
+			cut--
+		}
+		r.Message = r.Message[:cut] + "..."
+	}
+	line, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	f, err := os.OpenFile(l.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, common.DEFAULT_FILE_PERM)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(line, '\n'))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// ReadTransferErrors reads the last error of each failed transfer of the job. A job with no failures has no errors to read.
+func ReadTransferErrors(jobID common.JobID) (map[TransferErrorKey]TransferError, error) {
+	errs := map[TransferErrorKey]TransferError{}
+	f, err := os.Open(transferErrorsPath(jobID))
+	if os.IsNotExist(err) {
+		return errs, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var r transferErrorRecord
+		if json.Unmarshal(scanner.Bytes(), &r) != nil {
+			continue // e.g. a line that was cut short when the process was killed
+		}
+		errs[TransferErrorKey{r.PartNumber, r.TransferIndex}] = r.TransferError
+	}
+	return errs, scanner.Err()
+}
@@ -29,6 +29,7 @@ import (
 	"hash"
 	"net/http"
 	"net/url"
+	"os"
 	"runtime"
 	"strings"
 	"sync"
@@ -269,6 +270,27 @@ func anyToRemote_file(jptm IJobPartTransferMgr, info TransferInfo, p pipeline.Pi
 		}
 	}
 
+	// step 3b: when following the source, the transfer is an open-ended tail of the file, rather than an upload of what it contains now
+	// Following never ends by itself, so it gets its own goroutine rather than tying up this one. It's tracked as a single
+	// chunk, so that it ends through the same epilogue and cleanup as any other upload.
+	if follower, ok := s.(localFileFollower); ok && jptm.ShouldFollowSource() {
+		jptm.LogChunkStatus(pseudoId, common.EWaitReason.LockDestination())
+		err = jptm.WaitUntilLockDestination(jptm.Context())
+		if err != nil {
+			jptm.LogSendError(info.Source, info.Destination, err.Error(), 0)
+			jptm.SetStatus(common.ETransferStatus.Failed())
+			jptm.ReportTransferDone()
+			return
+		}
+
+		jptm.SetNumberOfChunks(1)
+		jptm.SetActionAfterLastChunk(func() { epilogueWithCleanupSendToRemote(jptm, s, srcInfoProvider) })
+		jptm.LogChunkStatus(pseudoId, common.EWaitReason.ChunkDone())
+
+		go follower.followLocalFile(info.Source)
+		return
+	}
+
 	// step 4: Open the local Source File (if any)
 	common.GetLifecycleMgr().E2EAwaitAllowOpenFiles()
 	jptm.LogChunkStatus(pseudoId, common.EWaitReason.OpenLocalSource())
@@ -381,6 +403,18 @@ func scheduleSendChunks(jptm IJobPartTransferMgr, srcPath string, srcFile common
 		defer close(md5Channel)
 	}
 
+	// If the destination doesn't need zeros to be sent, find the holes in the source (if it's sparse) so we don't have to read them
+	var sparseMap *common.SparseFileMap
+	if zs, ok := s.(zeroSkippingUploader); ok && zs.SkipsZeroChunks() {
+		if f, ok := srcFile.(*os.File); ok {
+			var err error
+			sparseMap, err = common.GetSparseFileMap(f, srcSize)
+			if err == nil && sparseMap.DataSize() < srcSize {
+				jptm.LogAtLevelForCurrentTransfer(pipeline.LogInfo, fmt.Sprintf("Source is sparse. Only %d of its %d bytes contain data", sparseMap.DataSize(), srcSize))
+			}
+		}
+	}
+
 	chunkIDCount := int32(0)
 	for startIndex := int64(0); startIndex < srcSize || isDummyChunkInEmptyFile(startIndex, srcSize); startIndex += int64(chunkSize) {
 
@@ -393,9 +427,23 @@ func scheduleSendChunks(jptm IJobPartTransferMgr, srcPath string, srcFile common
 
 		id := common.NewChunkID(srcPath, startIndex, adjustedChunkSize) // TODO: stop using adjustedChunkSize, below, and use the size that's in the ID
 
+		// If an earlier, interrupted, run of this job already sent this chunk, then (unless we need it for the hash)
+		// there's no need to even read it. We always read the first chunk, since the prologue needs its leading bytes.
+		skipRead := false
+		if rs, ok := s.(resumableSender); ok && startIndex > 0 && !jptm.ShouldPutMd5() {
+			skipRead = rs.ChunkAlreadySent(chunkIDCount, adjustedChunkSize)
+		}
+
 		if srcInfoProvider.IsLocal() {
 			if jptm.WasCanceled() {
 				prefetchErr = jobCancelledLocalPrefetchErr
+			} else if skipRead {
+				chunkReader = nil
+			} else if startIndex > 0 && prefetchErr == nil && sparseMap.IsHole(startIndex, adjustedChunkSize) {
+				// it's a hole in a sparse file, so we know it's all zeros without reading it
+				// (but we don't do this for the first chunk, since the prologue needs its leading bytes)
+				chunkReader = common.NewZeroChunkReader(adjustedChunkSize)
+				chunkReader.WriteBufferTo(md5Hasher)
 			} else {
 				// As long as the prefetch error is nil, we'll attempt a prefetch.
 				// Otherwise, the chunk reader didn't need to be made.
@@ -427,7 +475,9 @@ func scheduleSendChunks(jptm IJobPartTransferMgr, srcPath string, srcFile common
 			// Run prologue before first chunk is scheduled.
 			// If file is not local, we'll get no leading bytes, but we still run the prologue in case
 			// there's other initialization to do in the sender.
+			span := startPhaseSpan(jptm, "prologue")
 			modified := s.Prologue(ps)
+			span.End()
 			if modified {
 				jptm.SetDestinationIsModified()
 			}
@@ -496,6 +546,7 @@ func epilogueWithCleanupSendToRemote(jptm IJobPartTransferMgr, s sender, sip ISo
 	pseudoId := common.NewPseudoChunkIDForWholeFile(info.Source)
 	jptm.LogChunkStatus(pseudoId, common.EWaitReason.Epilogue())
 	defer jptm.LogChunkStatus(pseudoId, common.EWaitReason.ChunkDone()) // normal setting to done doesn't apply to these pseudo ids
+	defer startPhaseSpan(jptm, "epilogue").End()
 
 	if jptm.WasCanceled() {
 		// This is where we detect that transfer has been cancelled. Further statments do not act on
@@ -553,6 +604,9 @@ func epilogueWithCleanupSendToRemote(jptm IJobPartTransferMgr, s sender, sip ISo
 		}
 	}
 
+	// when moving, the source goes once the destination's been verified
+	deleteSourceIfMoving(jptm, jptm.FailActiveSend)
+
 	if jptm.HoldsDestinationLock() { // TODO consider add test of jptm.IsDeadInflight here, so we can remove that from inside all the cleanup methods
 		s.Cleanup() // Perform jptm cleanup, if THIS jptm has the lock on the destination
 	}
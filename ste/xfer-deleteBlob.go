@@ -24,9 +24,26 @@ func DeleteBlob(jptm IJobPartTransferMgr, p pipeline.Pipeline, pacer pacer) {
 
 	// schedule the work as a chunk, so it will run on the main goroutine pool, instead of the
 	// smaller "transfer initiation pool", where this code runs.
-	id := common.NewChunkID(jptm.Info().Source, 0, 0)
-	cf := createChunkFunc(true, jptm, id, func() { doDeleteBlob(jptm, p) })
-	jptm.ScheduleChunks(cf)
+	scheduleDelete := func() {
+		id := common.NewChunkID(jptm.Info().Source, 0, 0)
+		cf := createChunkFunc(true, jptm, id, func() { doDeleteBlob(jptm, p) })
+		jptm.ScheduleChunks(cf)
+	}
+
+	// permanent deletes aren't allowed in batches, but otherwise, blobs are deleted up to 256 at a time
+	batcher := jptm.BlobBatcher()
+	if !batcher.usable() || jptm.PermanentDeleteOption().ToPermanentDeleteOptionType() == azblob.BlobDeletePermanent {
+		scheduleDelete()
+		return
+	}
+
+	u, _ := url.Parse(jptm.Info().Source)
+	batcher.add(blobBatchOperation{
+		jptm:     jptm,
+		request:  NewBlobBatchDeleteRequest(*u, jptm.DeleteSnapshotsOption().ToDeleteSnapshotsOptionType()),
+		done:     func(err error) { reportBlobDeletion(jptm, err) },
+		fallback: func() { doDeleteBlob(jptm, p) },
+	})
 }
 
 func doDeleteBlob(jptm IJobPartTransferMgr, p pipeline.Pipeline) {
@@ -37,6 +54,21 @@ func doDeleteBlob(jptm IJobPartTransferMgr, p pipeline.Pipeline) {
 
 	srcBlobURL := azblob.NewBlobURL(*u, p)
 
+	// note: if deleteSnapshotsOption is 'only', which means deleting all the snapshots but keep the root blob
+	// we still count this delete operation as successful since we accomplished the desired outcome
+	err := error(nil)
+	if jptm.PermanentDeleteOption().ToPermanentDeleteOptionType() == azblob.BlobDeletePermanent {
+		_, err = srcBlobURL.PermanentDelete(jptm.Context(), jptm.DeleteSnapshotsOption().ToDeleteSnapshotsOptionType(), azblob.BlobAccessConditions{})
+	} else {
+		_, err = srcBlobURL.Delete(jptm.Context(), jptm.DeleteSnapshotsOption().ToDeleteSnapshotsOptionType(), azblob.BlobAccessConditions{})
+	}
+	reportBlobDeletion(jptm, err)
+}
+
+// reportBlobDeletion completes the transfer, given the outcome of the delete, whether it was sent by itself or in a batch
+func reportBlobDeletion(jptm IJobPartTransferMgr, err error) {
+	info := jptm.Info()
+
 	// Internal function which checks the transfer status and logs the msg respectively.
 	// Sets the transfer status and Report Transfer as Done.
 	// Internal function is created to avoid redundancy of the above steps from several places in the api.
@@ -58,14 +90,6 @@ func doDeleteBlob(jptm IJobPartTransferMgr, p pipeline.Pipeline) {
 		jptm.ReportTransferDone()
 	}
 
-	// note: if deleteSnapshotsOption is 'only', which means deleting all the snapshots but keep the root blob
-	// we still count this delete operation as successful since we accomplished the desired outcome
-	err := error(nil)
-	if jptm.PermanentDeleteOption().ToPermanentDeleteOptionType() == azblob.BlobDeletePermanent {
-		_, err = srcBlobURL.PermanentDelete(jptm.Context(), jptm.DeleteSnapshotsOption().ToDeleteSnapshotsOptionType(), azblob.BlobAccessConditions{})
-	} else {
-		_, err = srcBlobURL.Delete(jptm.Context(), jptm.DeleteSnapshotsOption().ToDeleteSnapshotsOptionType(), azblob.BlobAccessConditions{})
-	}
 	if err != nil {
 		if strErr, ok := err.(azblob.StorageError); ok {
 			// if the delete failed with err 404, i.e resource not found, then mark the transfer as success.
@@ -30,6 +30,7 @@ import (
 
 	"github.com/Azure/azure-pipeline-go/pipeline"
 	"github.com/Azure/azure-storage-azcopy/v10/common"
+	"github.com/Azure/azure-storage-blob-go/azblob"
 )
 
 const azcopyTempDownloadPrefix string = ".azDownload-%s-"
@@ -129,7 +130,9 @@ func remoteToLocal_file(jptm IJobPartTransferMgr, p pipeline.Pipeline, pacer pac
 		// For files, it just sets a few properties.
 		// For blobs, it sets up a page blob pacer if it's a page blob.
 		// For blobFS, it's a noop.
+		span := startPhaseSpan(jptm, "prologue")
 		dl.Prologue(jptm, p)
+		span.End()
 		epilogueWithCleanupDownload(jptm, dl, nil, nil) // need standard epilogue, rather than a quick exit, so we can preserve modification dates
 		return
 	}
@@ -159,6 +162,8 @@ func remoteToLocal_file(jptm IJobPartTransferMgr, p pipeline.Pipeline, pacer pac
 	}
 
 	var dstFile io.WriteCloser
+	var existingContent io.Reader
+	resumeOffset := int64(0)
 	if strings.EqualFold(info.Destination, common.Dev_Null) {
 		// the user wants to discard the downloaded data
 		dstFile = devNullWriter{}
@@ -171,7 +176,24 @@ func remoteToLocal_file(jptm IJobPartTransferMgr, p pipeline.Pipeline, pacer pac
 		// to correct name.
 		pseudoId := common.NewPseudoChunkIDForWholeFile(info.Source)
 		jptm.LogChunkStatus(pseudoId, common.EWaitReason.CreateLocalFile())
-		dstFile, err = createDestinationFile(jptm, info.getDownloadPath(), fileSize, writeThrough)
+
+		// If a previous run of this job was interrupted part-way through this file, pick up where it left off
+		resumeOffset = getDownloadResumeOffset(jptm, fileSize, downloadChunkSize)
+		if resumeOffset > 0 {
+			f, openErr := common.OpenFileForResume(info.getDownloadPath(), fileSize, resumeOffset, writeThrough)
+			if openErr == nil {
+				dstFile = f
+				existingContent = io.NewSectionReader(f, 0, resumeOffset)
+				jptm.LogAtLevelForCurrentTransfer(pipeline.LogInfo, fmt.Sprintf("Resuming download at offset %d", resumeOffset))
+			} else {
+				jptm.LogAtLevelForCurrentTransfer(pipeline.LogInfo, "Cannot resume partial download, so will start again: "+openErr.Error())
+				resumeOffset = 0
+				jptm.ResetResumeState()
+			}
+		}
+		if resumeOffset == 0 {
+			dstFile, err = createDestinationFile(jptm, info.getDownloadPath(), fileSize, writeThrough)
+		}
 		jptm.LogChunkStatus(pseudoId, common.EWaitReason.ChunkDone()) // normal setting to done doesn't apply to these pseudo ids
 		if err != nil {
 			failFileCreation(err)
@@ -197,17 +219,23 @@ func remoteToLocal_file(jptm IJobPartTransferMgr, p pipeline.Pipeline, pacer pac
 		}*/
 
 	// step 5a: compute num chunks
+	// (only counting those after the resume offset, if any, since the ones before it are already saved)
 	numChunks := uint32(0)
-	if rem := fileSize % downloadChunkSize; rem == 0 {
-		numChunks = uint32(fileSize / downloadChunkSize)
+	remainingSize := fileSize - resumeOffset
+	if rem := remainingSize % downloadChunkSize; rem == 0 {
+		numChunks = uint32(remainingSize / downloadChunkSize)
 	} else {
-		numChunks = uint32(fileSize/downloadChunkSize + 1)
+		numChunks = uint32(remainingSize/downloadChunkSize + 1)
 	}
 
 	// step 5b: create destination writer
 	chunkLogger := jptm.ChunkStatusLogger()
 	sourceMd5Exists := len(info.SrcHTTPHeaders.ContentMD5) > 0
-	dstWriter := common.NewChunkedFileWriter(
+	var reportSavedOffset func(int64)
+	if downloadIsResumable(info) && !jptm.ShouldDecompress() {
+		reportSavedOffset = jptm.SetResumeOffset
+	}
+	dstWriter := common.NewResumableChunkedFileWriter(
 		jptm.Context(),
 		jptm.SlicePool(),
 		jptm.CacheLimiter(),
@@ -216,11 +244,16 @@ func remoteToLocal_file(jptm IJobPartTransferMgr, p pipeline.Pipeline, pacer pac
 		numChunks,
 		MaxRetryPerDownloadBody,
 		jptm.MD5ValidationOption(),
-		sourceMd5Exists)
+		sourceMd5Exists,
+		resumeOffset,
+		existingContent,
+		reportSavedOffset)
 
 	// step 5c: run prologue in downloader (here it can, for example, create things that will require cleanup in the epilogue)
 	common.GetLifecycleMgr().E2EAwaitAllowOpenFiles()
+	span := startPhaseSpan(jptm, "prologue")
 	dl.Prologue(jptm, p)
+	span.End()
 
 	// step 5d: tell jptm what to expect, and how to clean up at the end
 	jptm.SetNumberOfChunks(numChunks)
@@ -236,7 +269,7 @@ func remoteToLocal_file(jptm IJobPartTransferMgr, p pipeline.Pipeline, pacer pac
 	// eventually reach numChunks, since we have no better short-term alternative.
 
 	chunkCount := uint32(0)
-	for startIndex := int64(0); startIndex < fileSize; startIndex += downloadChunkSize {
+	for startIndex := resumeOffset; startIndex < fileSize; startIndex += downloadChunkSize {
 		adjustedChunkSize := downloadChunkSize
 
 		// compute exact size of the chunk
@@ -269,6 +302,27 @@ func remoteToLocal_file(jptm IJobPartTransferMgr, p pipeline.Pipeline, pacer pac
 
 }
 
+// getDownloadResumeOffset returns the offset from which a previously-interrupted download of this file can continue,
+// or zero if it must start from the beginning. We only resume into our own temp file (never the real destination),
+// and never when decompressing, since then the saved offset doesn't correspond to an offset in the source.
+func getDownloadResumeOffset(jptm IJobPartTransferMgr, fileSize int64, chunkSize int64) int64 {
+	info := jptm.Info()
+	offset := jptm.ResumeOffset()
+	if offset <= 0 || chunkSize <= 0 || jptm.ShouldDecompress() || !downloadIsResumable(info) {
+		return 0
+	}
+	if offset%chunkSize != 0 || offset >= fileSize {
+		return 0 // not on a chunk boundary, so not something we saved. Or we had everything, but didn't get to finish
+	}
+	return offset
+}
+
+// downloadIsResumable tells us whether a partial download, of the given transfer, may be left on disk for later resumption
+func downloadIsResumable(info TransferInfo) bool {
+	return !strings.EqualFold(info.getDownloadPath(), info.Destination) &&
+		!strings.EqualFold(info.Destination, common.Dev_Null)
+}
+
 func createDestinationFile(jptm IJobPartTransferMgr, destination string, size int64, writeThrough bool) (file io.WriteCloser, err error) {
 	ct := common.ECompressionType.None()
 	if jptm.ShouldDecompress() {
@@ -282,11 +336,23 @@ func createDestinationFile(jptm IJobPartTransferMgr, destination string, size in
 		// and we still need to set size to zero here, so relying on enumeration more wouldn't simply this code much, if at all.
 	}
 
+	// If the source is a sparse page blob, we won't write its empty ranges, so don't allocate space for them.
+	// Instead, size the file without allocating it, so that they are left as holes
+	sparse := size > 0 && common.SparseLocalFilesSupported && jptm.Info().SrcBlobType == azblob.BlobPageBlob && sparsePageBlobOptimizationsEnabled()
+	allocationSize := common.Iffint64(sparse, 0, size)
+
 	var dstFile io.WriteCloser
-	dstFile, err = common.CreateFileOfSizeWithWriteThroughOption(destination, size, writeThrough, jptm.GetFolderCreationTracker(), jptm.GetForceIfReadOnly())
+	f, err := common.CreateFileOfSizeWithWriteThroughOption(destination, allocationSize, writeThrough, jptm.GetFolderCreationTracker(), jptm.GetForceIfReadOnly())
 	if err != nil {
 		return nil, err
 	}
+	if sparse {
+		if err = f.Truncate(size); err != nil {
+			_ = f.Close()
+			return nil, err
+		}
+	}
+	dstFile = f
 	if jptm.ShouldDecompress() {
 		jptm.LogAtLevelForCurrentTransfer(pipeline.LogInfo, "will be decompressed from "+ct.String())
 
@@ -307,6 +373,7 @@ func epilogueWithCleanupDownload(jptm IJobPartTransferMgr, dl downloader, active
 	pseudoId := common.NewPseudoChunkIDForWholeFile(info.Source)
 	jptm.LogChunkStatus(pseudoId, common.EWaitReason.Epilogue())
 	defer jptm.LogChunkStatus(pseudoId, common.EWaitReason.ChunkDone()) // normal setting to done doesn't apply to these pseudo ids
+	defer startPhaseSpan(jptm, "epilogue").End()
 
 	if jptm.WasCanceled() {
 		// This is where we first realize that the transfer is cancelled. Further statements are no-op and
@@ -352,15 +419,14 @@ func epilogueWithCleanupDownload(jptm IJobPartTransferMgr, dl downloader, active
 			}
 
 			//check if we need to rename back to original name. At this point, we're sure the file is completely
-			//downloaded and not corrupt. Infact, post this point we should only log errors and
-			//not fail the transfer.
-			renameNecessary := !strings.EqualFold(info.getDownloadPath(), info.Destination) && 
-			                   !strings.EqualFold(info.Destination, common.Dev_Null)
-			if err == nil && renameNecessary {
+			//downloaded and not corrupt. But the transfer still fails if the rename does, since the file isn't
+			//where it should be (and a move mustn't go on to delete the source).
+			renameNecessary := !strings.EqualFold(info.getDownloadPath(), info.Destination) &&
+				!strings.EqualFold(info.Destination, common.Dev_Null)
+			if jptm.IsLive() && renameNecessary {
 				renameErr := os.Rename(info.getDownloadPath(), info.Destination)
 				if renameErr != nil {
-					jptm.LogError(info.Destination, fmt.Sprintf(
-						"Failed to rename. File at %s", info.getDownloadPath()), renameErr)
+					jptm.FailActiveDownload(fmt.Sprintf("Renaming downloaded file from %s", info.getDownloadPath()), renameErr)
 				}
 			}
 		}
@@ -389,6 +455,9 @@ func epilogueWithCleanupDownload(jptm IJobPartTransferMgr, dl downloader, active
 		}
 	}
 
+	// when moving, the source goes once the download's been verified
+	deleteSourceIfMoving(jptm, jptm.FailActiveDownload)
+
 	commonDownloaderCompletion(jptm, info, common.EEntityType.File())
 }
 
@@ -406,10 +475,16 @@ func commonDownloaderCompletion(jptm IJobPartTransferMgr, info TransferInfo, ent
 		}
 		// for files only, cleanup local file if applicable
 		if entityType == entityType.File() && jptm.IsDeadInflight() && jptm.HoldsDestinationLock() {
-			jptm.LogAtLevelForCurrentTransfer(pipeline.LogInfo, "Deleting incomplete destination file")
+			if jptm.WasCanceled() && jptm.ResumeOffset() > 0 && downloadIsResumable(info) {
+				// keep what we have, so that resuming the job can continue from where we got to
+				jptm.LogAtLevelForCurrentTransfer(pipeline.LogInfo, fmt.Sprintf("Keeping incomplete temp file, for re-use if the job is resumed. Saved up to offset %d", jptm.ResumeOffset()))
+			} else {
+				jptm.LogAtLevelForCurrentTransfer(pipeline.LogInfo, "Deleting incomplete destination file")
 
-			// the file created locally should be deleted
-			tryDeleteFile(info, jptm)
+				// the file created locally should be deleted
+				tryDeleteFile(info, jptm)
+				jptm.ResetResumeState()
+			}
 		}
 	} else {
 		if !jptm.IsLive() {
@@ -0,0 +1,65 @@
+package ste
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/Azure/azure-pipeline-go/pipeline"
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+	"github.com/Azure/azure-storage-blob-go/azblob"
+)
+
+// restoreBlob undeletes the soft-deleted blob, along with its soft-deleted snapshots, or else makes the version of the
+// blob that's the source of the transfer its current version, by copying that version over the blob.
+// Restore jobs are set-properties jobs, so it's called in place of setting the properties.
+func restoreBlob(jptm IJobPartTransferMgr, p pipeline.Pipeline) error {
+	info := jptm.Info()
+	u, _ := url.Parse(info.Source)
+
+	if jptm.SetPropertiesFlags().ShouldUndelete() {
+		_, err := azblob.NewBlobURL(*u, p).Undelete(jptm.Context())
+		return err
+	}
+
+	blobURLParts := azblob.NewBlobURLParts(*u)
+	blobURLParts.VersionID = ""
+	blobURL := azblob.NewBlobURL(blobURLParts.URL(), p)
+
+	// the version's metadata is copied along with it, since none is given
+	resp, err := blobURL.StartCopyFromURL(jptm.Context(), *u, nil, azblob.ModifiedAccessConditions{}, azblob.BlobAccessConditions{}, azblob.DefaultAccessTier, nil)
+	if err != nil {
+		return err
+	}
+
+	cpk := common.ToClientProvidedKeyOptions(jptm.CpkInfo(), jptm.CpkScopeInfo())
+	return waitForBlobCopy(jptm.Context(), blobURL, resp.CopyStatus(), cpk)
+}
+
+// waitForBlobCopy polls the blob until the copy to it is no longer pending. Copies within an account are usually
+// done by the time they're started, so it's rare that it polls at all.
+func waitForBlobCopy(ctx context.Context, blobURL azblob.BlobURL, status azblob.CopyStatusType, cpk azblob.ClientProvidedKeyOptions) error {
+	for delay := 250 * time.Millisecond; status == azblob.CopyStatusPending; {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+
+		props, err := blobURL.GetProperties(ctx, azblob.BlobAccessConditions{}, cpk)
+		if err != nil {
+			return err
+		}
+		status = props.CopyStatus()
+
+		if delay < 5*time.Second {
+			delay *= 2
+		}
+	}
+
+	if status != azblob.CopyStatusSuccess {
+		return fmt.Errorf("the copy of the version over the blob ended with status %s", status)
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+package ste
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/Azure/azure-pipeline-go/pipeline"
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+	"github.com/Azure/azure-storage-blob-go/azblob"
+)
+
+// SetProperties sets the properties that the job asked for (tier, metadata, blob tags and/or HTTP headers) on a blob, in place.
+// For restore jobs, it undeletes the blob, or makes a version of it the current one, instead.
+func SetProperties(jptm IJobPartTransferMgr, p pipeline.Pipeline, pacer pacer) {
+
+	// If the transfer was cancelled, then reporting transfer as done and increasing the bytestransferred by the size of the source.
+	if jptm.WasCanceled() {
+		jptm.ReportTransferDone()
+		return
+	}
+
+	// when only the tier is changed, blobs are changed up to 256 at a time
+	info := jptm.Info()
+	if batcher := jptm.BlobBatcher(); batcher.usable() && jptm.SetPropertiesFlags() == common.ESetPropertiesFlags.SetTier() {
+		u, _ := url.Parse(info.Source)
+		tier := tierToSet(jptm, info.SrcBlobType)
+		if tier == azblob.AccessTierNone {
+			reportSetProperties(jptm, nil)
+			return
+		}
+
+		batcher.add(blobBatchOperation{
+			jptm:     jptm,
+			request:  NewBlobBatchSetTierRequest(*u, tier, jptm.RehydratePriority().ToRehydratePriorityType()),
+			done:     func(err error) { reportSetProperties(jptm, err) },
+			fallback: func() { setPropertiesBlob(jptm, p) },
+		})
+		return
+	}
+
+	// schedule the work as a chunk, so it will run on the main goroutine pool, instead of the
+	// smaller "transfer initiation pool", where this code runs.
+	id := common.NewChunkID(info.Source, 0, 0)
+	cf := createChunkFunc(true, jptm, id, func() { setPropertiesBlob(jptm, p) })
+	jptm.ScheduleChunks(cf)
+}
+
+func setPropertiesBlob(jptm IJobPartTransferMgr, p pipeline.Pipeline) {
+
+	info := jptm.Info()
+	// Get the url of the blob (or blob version) to set the properties of
+	u, _ := url.Parse(info.Source)
+
+	blobURL := azblob.NewBlobURL(*u, p)
+	flags := jptm.SetPropertiesFlags()
+	if flags.IsRestore() {
+		reportSetProperties(jptm, restoreBlob(jptm, p))
+		return
+	}
+
+	headers, metadata, blobTags, _ := jptm.ResourceDstData(nil)
+	cpk := common.ToClientProvidedKeyOptions(jptm.CpkInfo(), jptm.CpkScopeInfo())
+
+	err := error(nil)
+	if flags.ShouldSetHTTPHeaders() {
+		// Set Blob Properties replaces all the headers, so the ones that weren't given, including the MD5, are kept as they are
+		var props *azblob.BlobGetPropertiesResponse
+		props, err = blobURL.GetProperties(jptm.Context(), azblob.BlobAccessConditions{}, cpk)
+		if err == nil {
+			_, err = blobURL.SetHTTPHeaders(jptm.Context(), mergeHTTPHeaders(props.NewHTTPHeaders(), headers), azblob.BlobAccessConditions{})
+		}
+	}
+
+	if err == nil && flags.ShouldSetMetadata() {
+		_, err = blobURL.SetMetadata(jptm.Context(), metadata.ToAzBlobMetadata(), azblob.BlobAccessConditions{}, cpk)
+	}
+
+	if err == nil && flags.ShouldSetBlobTags() {
+		_, err = blobURL.SetTags(jptm.Context(), nil, nil, nil, blobTags.ToAzBlobTagsMap())
+	}
+
+	// the tier is set last, since the other properties of a blob can't be set once it's archived
+	if err == nil && flags.ShouldSetTier() {
+		if tier := tierToSet(jptm, info.SrcBlobType); tier != azblob.AccessTierNone {
+			_, err = blobURL.SetTier(jptm.Context(), tier, azblob.LeaseAccessConditions{}, jptm.RehydratePriority().ToRehydratePriorityType())
+		}
+	}
+
+	reportSetProperties(jptm, err)
+}
+
+// reportSetProperties completes the transfer, given the outcome of setting the properties, whether the tier was set by
+// itself or in a batch
+func reportSetProperties(jptm IJobPartTransferMgr, err error) {
+	info := jptm.Info()
+	operation := common.IffString(jptm.SetPropertiesFlags().IsRestore(), "RESTORE", "SET-PROPERTIES")
+
+	transferDone := func(status common.TransferStatus, err error) {
+		if status == common.ETransferStatus.Failed() {
+			jptm.LogError(info.Source, operation+" ERROR ", err)
+			if _, httpStatus, _ := (ErrorEx{err}).ErrorCodeAndString(); httpStatus != 0 {
+				jptm.SetErrorCode(int32(httpStatus))
+			}
+		} else {
+			jptm.Log(pipeline.LogInfo, fmt.Sprintf("%s SUCCESSFUL: %s", operation, strings.Split(info.Source, "?")[0]))
+		}
+
+		jptm.SetStatus(status)
+		jptm.ReportTransferDone()
+	}
+
+	if err != nil {
+		// If the status code was 403, it means there was an authentication error and we exit.
+		// User can resume the job if completely ordered with a new sas.
+		if strErr, ok := err.(azblob.StorageError); ok && strErr.Response().StatusCode == http.StatusForbidden {
+			errMsg := fmt.Sprintf("Authentication Failed. The SAS is not correct or expired or does not have the correct permission %s", err.Error())
+			jptm.Log(pipeline.LogError, errMsg)
+			common.GetLifecycleMgr().Error(errMsg)
+		}
+
+		transferDone(common.ETransferStatus.Failed(), err)
+	} else {
+		transferDone(common.ETransferStatus.Success(), nil)
+	}
+}
+
+// tierToSet returns the block blob tier for block blobs, and the page blob tier for page blobs.
+// Append blobs have no tier, and are left as they are.
+func tierToSet(jptm IJobPartTransferMgr, blobType azblob.BlobType) azblob.AccessTierType {
+	blockBlobTier, pageBlobTier := jptm.BlobTiers()
+	switch blobType {
+	case azblob.BlobBlockBlob, azblob.BlobNone: // the type isn't known when the blob wasn't listed, and block blobs are by far the most common
+		if blockBlobTier != common.EBlockBlobTier.None() {
+			return blockBlobTier.ToAccessTierType()
+		}
+	case azblob.BlobPageBlob:
+		if pageBlobTier != common.EPageBlobTier.None() {
+			return pageBlobTier.ToAccessTierType()
+		}
+	}
+	return azblob.AccessTierNone
+}
+
+// mergeHTTPHeaders overwrites the existing headers with those that were given
+func mergeHTTPHeaders(existing azblob.BlobHTTPHeaders, given common.ResourceHTTPHeaders) azblob.BlobHTTPHeaders {
+	if given.ContentType != "" {
+		existing.ContentType = given.ContentType
+	}
+	if given.ContentEncoding != "" {
+		existing.ContentEncoding = given.ContentEncoding
+	}
+	if given.ContentLanguage != "" {
+		existing.ContentLanguage = given.ContentLanguage
+	}
+	if given.ContentDisposition != "" {
+		existing.ContentDisposition = given.ContentDisposition
+	}
+	if given.CacheControl != "" {
+		existing.CacheControl = given.CacheControl
+	}
+	return existing
+}
@@ -59,7 +59,7 @@ func NewRequestLogPolicyFactory(o RequestLogOptions) pipeline.Factory {
 				b := &bytes.Buffer{}
 				fmt.Fprintf(b, "==> OUTGOING REQUEST (Try=%d)\n", try)
 				pipeline.WriteRequestWithResponse(b, prepareRequestForLogging(request), nil, nil)
-				po.Log(pipeline.LogInfo, b.String())
+				logRequest(ctx, po, pipeline.LogInfo, b.String(), request, nil, try)
 			}
 
 			// Set the time for this particular retry operation and then Do the operation.
@@ -156,7 +156,7 @@ func NewRequestLogPolicyFactory(o RequestLogOptions) pipeline.Factory {
 					pipeline.ForceLog(logLevel, msg)
 				}
 				if shouldLog {
-					po.Log(logLevel, msg)
+					logRequest(ctx, po, logLevel, msg, request, response, try)
 				}
 			}
 			return response, err
@@ -164,6 +164,26 @@ func NewRequestLogPolicyFactory(o RequestLogOptions) pipeline.Factory {
 	})
 }
 
+// logRequest logs a message about a try of a request. If the request was made for a transfer, the message goes
+// to the log of its job with the fields of the transfer, so that the JSON log format can say which transfer it was for.
+func logRequest(ctx context.Context, po *pipeline.PolicyOptions, level pipeline.LogLevel, msg string, request pipeline.Request, response pipeline.Response, try int32) {
+	logger, fields, ok := common.LogContextFromContext(ctx)
+	if !ok {
+		po.Log(level, msg)
+		return
+	}
+
+	fields.HTTPMethod = request.Method
+	fields.RetryCount = int(try) - 1
+	if response != nil && response.Response() != nil {
+		r := response.Response()
+		fields.HTTPStatus = r.StatusCode
+		fields.RequestID = r.Header.Get("x-ms-request-id")
+		fields.ErrorCode = r.Header.Get("x-ms-error-code")
+	}
+	common.LogWithFields(logger, level, "", msg, fields)
+}
+
 func isContextCancelledError(err error) bool {
 	if err == nil {
 		return false
@@ -166,10 +166,18 @@ type xferStatsPolicy struct {
 
 // Do accumulates stats for each call
 func (p *xferStatsPolicy) Do(ctx context.Context, request pipeline.Request) (pipeline.Response, error) {
+	if t, ok := ctx.Value(tryTrackerContextKey).(*tryTracker); ok {
+		t.startTry()
+	}
+	metricRequestsInFlight.Inc()
 	start := time.Now()
 
 	resp, err := p.next.Do(ctx, request)
 
+	// the Prometheus metrics cover every request, even those made before the tuner is stable
+	metricRequestsInFlight.Dec()
+	recordTryMetrics(ctx, request, resp, err, time.Since(start).Seconds())
+
 	if p.stats != nil {
 		if p.stats.IsStarted() {
 			atomic.AddInt64(&p.stats.atomicOperationCount, 1)
@@ -0,0 +1,91 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ste
+
+import (
+	"context"
+
+	"github.com/Azure/azure-pipeline-go/pipeline"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+)
+
+type tracePolicy struct {
+	next pipeline.Policy
+}
+
+// Do records a span for each try of a request, as a child of the span of the transfer (or of the enumeration) that made it.
+// It goes after the retry policy, so that each retry is a span of its own. The span is propagated to the service
+// in the traceparent header of https://www.w3.org/TR/trace-context/.
+func (p *tracePolicy) Do(ctx context.Context, request pipeline.Request) (pipeline.Response, error) {
+	ctx, span := common.Tracer().Start(ctx, request.Method, trace.WithSpanKind(trace.SpanKindClient))
+	if !span.IsRecording() {
+		return p.next.Do(ctx, request)
+	}
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("http.method", request.Method),
+		attribute.String("http.url", common.URLExtension{URL: *request.URL}.RedactSecretQueryParamForLogging()))
+	if t, ok := ctx.Value(tryTrackerContextKey).(*tryTracker); ok {
+		// the stats policy, which is closer to the wire, hasn't counted this try yet
+		span.SetAttributes(attribute.Int("azcopy.try", int(t.tries+1)))
+	}
+	propagation.TraceContext{}.Inject(ctx, propagation.HeaderCarrier(request.Header))
+
+	resp, err := p.next.Do(ctx, request)
+
+	if resp != nil && resp.Response() != nil {
+		r := resp.Response()
+		span.SetAttributes(attribute.Int("http.status_code", r.StatusCode))
+		if id := r.Header.Get("x-ms-request-id"); id != "" {
+			span.SetAttributes(attribute.String("azcopy.request_id", id))
+		}
+		if code := r.Header.Get("x-ms-error-code"); code != "" {
+			span.SetAttributes(attribute.String("azcopy.error_code", code))
+		}
+		if r.StatusCode >= 400 {
+			span.SetStatus(codes.Error, r.Status)
+		}
+	}
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return resp, err
+}
+
+func newTracePolicyFactory() pipeline.Factory {
+	return pipeline.FactoryFunc(func(next pipeline.Policy, po *pipeline.PolicyOptions) pipeline.PolicyFunc {
+		r := tracePolicy{next: next}
+		return r.Do
+	})
+}
+
+// startPhaseSpan starts a span for one phase of a transfer, such as its prologue or epilogue, as a child of the span of the transfer.
+// The span isn't recorded when tracing is off
+func startPhaseSpan(jptm IJobPartTransferMgr, name string) trace.Span {
+	_, span := common.Tracer().Start(jptm.Context(), name, trace.WithSpanKind(trace.SpanKindInternal))
+	return span
+}
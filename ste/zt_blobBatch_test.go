@@ -0,0 +1,147 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ste
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/Azure/azure-pipeline-go/pipeline"
+	"github.com/Azure/azure-storage-blob-go/azblob"
+	chk "gopkg.in/check.v1"
+)
+
+type blobBatchSuite struct{}
+
+var _ = chk.Suite(&blobBatchSuite{})
+
+// the parts are deliberately out of order, since it's the Content-ID that says which operation each is for
+const batchResponseBody = "--batchresponse_1\r\n" +
+	"Content-Type: application/http\r\n" +
+	"Content-ID: 2\r\n" +
+	"\r\n" +
+	"HTTP/1.1 409 This operation is not permitted because the blob has snapshots.\r\n" +
+	"x-ms-error-code: SnapshotsPresent\r\n" +
+	"Content-Length: 49\r\n" +
+	"\r\n" +
+	"<Error><Message>This blob has snapshots</Message>\r\n" +
+	"--batchresponse_1\r\n" +
+	"Content-Type: application/http\r\n" +
+	"Content-ID: 0\r\n" +
+	"\r\n" +
+	"HTTP/1.1 202 Accepted\r\n" +
+	"x-ms-delete-type-permanent: false\r\n" +
+	"\r\n" +
+	"\r\n" +
+	"--batchresponse_1\r\n" +
+	"Content-Type: application/http\r\n" +
+	"Content-ID: 1\r\n" +
+	"\r\n" +
+	"HTTP/1.1 404 The specified blob does not exist.\r\n" +
+	"x-ms-error-code: BlobNotFound\r\n" +
+	"Content-Length: 0\r\n" +
+	"\r\n" +
+	"\r\n" +
+	"--batchresponse_1--\r\n"
+
+func newBatchTestPipeline(sent *http.Request, sentBody *[]byte, status int, body string) pipeline.Pipeline {
+	return pipeline.NewPipeline(nil, pipeline.Options{
+		HTTPSender: pipeline.FactoryFunc(func(next pipeline.Policy, po *pipeline.PolicyOptions) pipeline.PolicyFunc {
+			return func(ctx context.Context, request pipeline.Request) (pipeline.Response, error) {
+				*sent = *request.Request
+				*sentBody, _ = ioutil.ReadAll(request.Body)
+				return pipeline.NewHTTPResponse(&http.Response{
+					StatusCode: status,
+					Status:     http.StatusText(status),
+					Header:     http.Header{"Content-Type": []string{"multipart/mixed; boundary=batchresponse_1"}},
+					Body:       ioutil.NopCloser(strings.NewReader(body)),
+				}), nil
+			}
+		}),
+	})
+}
+
+func (s *blobBatchSuite) TestEachSubRequestGetsItsOwnOutcome(c *chk.C) {
+	containerURL, _ := url.Parse("https://account.blob.core.windows.net/container?sv=1&sig=x")
+	blobURL := func(name string) url.URL {
+		parts := azblob.NewBlobURLParts(*containerURL)
+		parts.BlobName = name
+		return parts.URL()
+	}
+	subRequests := []*http.Request{
+		NewBlobBatchDeleteRequest(blobURL("dir/a b.txt"), azblob.DeleteSnapshotsOptionNone),
+		NewBlobBatchDeleteRequest(blobURL("gone.txt"), azblob.DeleteSnapshotsOptionInclude),
+		NewBlobBatchSetTierRequest(blobURL("c.txt"), azblob.AccessTierCool, azblob.RehydratePriorityNone),
+	}
+	blobContainerURL := containerURLOf(blobURL("dir/a b.txt"))
+	c.Assert(blobContainerURL.String(), chk.Equals, "https://account.blob.core.windows.net/container?sig=x&sv=1")
+
+	var sent http.Request
+	var sentBody []byte
+	p := newBatchTestPipeline(&sent, &sentBody, http.StatusAccepted, batchResponseBody)
+	results, err := SubmitBlobBatch(context.Background(), p, NewBlobBatchSigner(azblob.NewAnonymousCredential()), *containerURL, subRequests)
+	c.Assert(err, chk.IsNil)
+
+	// one request, to the container, with each operation in its own part
+	c.Assert(sent.Method, chk.Equals, http.MethodPost)
+	c.Assert(sent.URL.Query().Get("comp"), chk.Equals, "batch")
+	c.Assert(sent.URL.Query().Get("restype"), chk.Equals, "container")
+	c.Assert(sent.URL.Query().Get("sig"), chk.Equals, "x")
+	c.Assert(strings.HasPrefix(sent.Header.Get("Content-Type"), "multipart/mixed; boundary=batch_"), chk.Equals, true)
+	c.Assert(bytes.Count(sentBody, []byte("Content-Type: application/http\r\n")), chk.Equals, 3)
+	c.Assert(bytes.Contains(sentBody, []byte("Content-ID: 2\r\n")), chk.Equals, true)
+	c.Assert(bytes.Contains(sentBody, []byte("DELETE /container/dir/a%20b.txt?sig=x&sv=1 HTTP/1.1\r\n")), chk.Equals, true)
+	c.Assert(bytes.Contains(sentBody, []byte("X-Ms-Delete-Snapshots: include\r\n")), chk.Equals, true)
+	c.Assert(bytes.Contains(sentBody, []byte("PUT /container/c.txt?comp=tier&sig=x&sv=1 HTTP/1.1\r\n")), chk.Equals, true)
+	c.Assert(bytes.Contains(sentBody, []byte("X-Ms-Access-Tier: Cool\r\n")), chk.Equals, true)
+
+	// and the outcomes are those of the operations they're for, as they'd be if they were sent on their own
+	c.Assert(results, chk.HasLen, 3)
+	c.Assert(results[0], chk.IsNil)
+	c.Assert(results[1].(azblob.StorageError).Response().StatusCode, chk.Equals, http.StatusNotFound)
+	c.Assert(results[2].(azblob.StorageError).ServiceCode(), chk.Equals, azblob.ServiceCodeSnapshotsPresent)
+	c.Assert(strings.Contains(results[2].Error(), "This blob has snapshots"), chk.Equals, true)
+}
+
+func (s *blobBatchSuite) TestFailedBatchHasNoOutcomes(c *chk.C) {
+	containerURL, _ := url.Parse("https://account.blob.core.windows.net/container")
+	blob := azblob.NewBlobURLParts(*containerURL)
+	blob.BlobName = "a.txt"
+
+	var sent http.Request
+	var sentBody []byte
+	p := newBatchTestPipeline(&sent, &sentBody, http.StatusBadRequest, "")
+	results, err := SubmitBlobBatch(context.Background(), p, NewBlobBatchSigner(azblob.NewAnonymousCredential()), *containerURL,
+		[]*http.Request{NewBlobBatchDeleteRequest(blob.URL(), azblob.DeleteSnapshotsOptionNone)})
+	c.Assert(err, chk.NotNil)
+	c.Assert(results, chk.IsNil)
+
+	// a response that doesn't answer every operation is a failure too
+	_, err = parseBlobBatchResponse("multipart/mixed; boundary=batchresponse_1", strings.NewReader(batchResponseBody), 4)
+	c.Assert(err, chk.NotNil)
+
+	_, err = SubmitBlobBatch(context.Background(), p, nil, *containerURL, make([]*http.Request, BlobBatchMaxSubRequests+1))
+	c.Assert(err, chk.NotNil)
+}
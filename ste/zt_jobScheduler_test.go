@@ -0,0 +1,164 @@
+// Copyright © Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ste
+
+import (
+	"context"
+	"sync"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+	chk "gopkg.in/check.v1"
+)
+
+type jobSchedulerSuite struct{}
+
+var _ = chk.Suite(&jobSchedulerSuite{})
+
+// newTestScheduler returns a scheduler without any workers, so that the test can dequeue by itself
+func newTestScheduler(ramLimit int64) *JobScheduler {
+	return &JobScheduler{
+		cacheLimiter: common.NewCacheLimiter(ramLimit),
+		transfers:    newFairQueue(false),
+		chunks:       newFairQueue(true),
+		ramLock:      &sync.Mutex{},
+	}
+}
+
+func (s *jobSchedulerSuite) TestFairQueueSharesByPriority(c *chk.C) {
+	scheduler := newTestScheduler(0)
+	low := scheduler.newScheduledJob(100)
+	high := scheduler.newScheduledJob(100)
+	scheduler.setLimits(low, common.EJobPriority.Low(), 0)
+	scheduler.setLimits(high, common.EJobPriority.High(), 0)
+
+	for i := 0; i < 50; i++ {
+		scheduler.transfers.enqueue(low.transfers, "low")
+		scheduler.transfers.enqueue(high.transfers, "high")
+	}
+
+	// while both have work, the high priority job gets four items for each one that the low priority job gets
+	counts := map[interface{}]int{}
+	for i := 0; i < 50; i++ {
+		item, _, ok := scheduler.transfers.dequeue()
+		c.Assert(ok, chk.Equals, true)
+		counts[item]++
+	}
+	c.Assert(counts["high"], chk.Equals, 40)
+	c.Assert(counts["low"], chk.Equals, 10)
+
+	// once the high priority job is done, the low priority one gets everything
+	for i := 0; i < 50; i++ {
+		_, _, ok := scheduler.transfers.dequeue()
+		c.Assert(ok, chk.Equals, true)
+	}
+	_, _, ok := scheduler.transfers.dequeue()
+	c.Assert(ok, chk.Equals, false)
+	c.Assert(scheduler.transfers.members, chk.HasLen, 0)
+}
+
+func (s *jobSchedulerSuite) TestFairQueueNewcomerDoesNotCatchUp(c *chk.C) {
+	scheduler := newTestScheduler(0)
+	first := scheduler.newScheduledJob(100)
+	second := scheduler.newScheduledJob(100)
+
+	for i := 0; i < 20; i++ {
+		scheduler.transfers.enqueue(first.transfers, "first")
+	}
+	for i := 0; i < 10; i++ {
+		scheduler.transfers.dequeue()
+	}
+
+	// a job that joins late shares equally from then on, instead of being owed the items the first job already had
+	for i := 0; i < 10; i++ {
+		scheduler.transfers.enqueue(second.transfers, "second")
+	}
+	counts := map[interface{}]int{}
+	for i := 0; i < 10; i++ {
+		item, _, _ := scheduler.transfers.dequeue()
+		counts[item]++
+	}
+	c.Assert(counts["first"], chk.Equals, 5)
+	c.Assert(counts["second"], chk.Equals, 5)
+}
+
+func (s *jobSchedulerSuite) TestFairQueueCapsConcurrency(c *chk.C) {
+	scheduler := newTestScheduler(0)
+	capped := scheduler.newScheduledJob(100)
+	scheduler.setLimits(capped, common.EJobPriority.High(), 2)
+
+	for i := 0; i < 5; i++ {
+		scheduler.chunks.enqueue(capped.chunks, i)
+	}
+	for i := 0; i < 2; i++ {
+		_, _, ok := scheduler.chunks.dequeue()
+		c.Assert(ok, chk.Equals, true)
+	}
+	_, _, ok := scheduler.chunks.dequeue()
+	c.Assert(ok, chk.Equals, false)
+
+	// when a chunk completes, the job may start another
+	capped.atomicActiveChunks--
+	_, _, ok = scheduler.chunks.dequeue()
+	c.Assert(ok, chk.Equals, true)
+}
+
+func (s *jobSchedulerSuite) TestJobCacheLimiterShares(c *chk.C) {
+	scheduler := newTestScheduler(1000)
+	normal := &jobCacheLimiter{scheduler: scheduler, job: scheduler.newScheduledJob(1)}
+	high := &jobCacheLimiter{scheduler: scheduler, job: scheduler.newScheduledJob(1)}
+	scheduler.setLimits(high.job, common.EJobPriority.High(), 0)
+
+	// alone, a job may use all the RAM (the relaxed limit is used throughout, to keep the arithmetic simple)
+	c.Assert(normal.TryAdd(600, true), chk.Equals, true)
+
+	// a job holding nothing may always start, but then the jobs are held to shares of 1/3 and 2/3
+	c.Assert(high.TryAdd(100, true), chk.Equals, true)
+	c.Assert(normal.TryAdd(100, true), chk.Equals, false)
+	normal.Remove(400)
+	c.Assert(high.TryAdd(566, true), chk.Equals, true)
+	c.Assert(high.TryAdd(1, true), chk.Equals, false)
+	c.Assert(normal.TryAdd(133, true), chk.Equals, true)
+
+	high.Remove(666)
+	normal.Remove(333)
+	c.Assert(scheduler.ramActiveWeight, chk.Equals, int64(0))
+	c.Assert(scheduler.cacheLimiter.TryAdd(1000, true), chk.Equals, true)
+}
+
+func (s *jobSchedulerSuite) TestJobPacerStopsWhenClosedAndRestartsOnResume(c *chk.C) {
+	shared := NewTokenBucketPacer(0, 0)
+	defer shared.Close()
+	p := newJobPacer(shared, 1024*1024)
+
+	c.Assert(p.RequestTrafficAllocation(context.Background(), 1), chk.IsNil)
+	c.Assert(p.job, chk.NotNil)
+
+	// the job's bucket is stopped when the job is done, and closing it again does nothing
+	c.Assert(p.Close(), chk.IsNil)
+	c.Assert(p.job, chk.IsNil)
+	c.Assert(p.Close(), chk.IsNil)
+
+	// a resumed job gets a new bucket, with the latest cap
+	p.UpdateTargetBytesPerSecond(2 * 1024 * 1024)
+	c.Assert(p.RequestTrafficAllocation(context.Background(), 1), chk.IsNil)
+	c.Assert(p.job.(*tokenBucketPacer).targetBytesPerSecond(), chk.Equals, int64(2*1024*1024))
+	c.Assert(p.Close(), chk.IsNil)
+}
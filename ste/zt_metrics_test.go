@@ -0,0 +1,113 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ste
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+
+	"github.com/Azure/azure-pipeline-go/pipeline"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	chk "gopkg.in/check.v1"
+)
+
+type metricsSuite struct{}
+
+var _ = chk.Suite(&metricsSuite{})
+
+// newMetricsTestPipeline sends each request until it succeeds, getting the given outcomes in turn.
+// A zero status code stands for a network error
+func newMetricsTestPipeline(statusCodes ...int) pipeline.Pipeline {
+	tries := 0
+	sender := pipeline.FactoryFunc(func(next pipeline.Policy, po *pipeline.PolicyOptions) pipeline.PolicyFunc {
+		return func(ctx context.Context, request pipeline.Request) (pipeline.Response, error) {
+			code := statusCodes[tries]
+			tries++
+			if code == 0 {
+				return nil, errors.New("connection reset")
+			}
+			return pipeline.NewHTTPResponse(&http.Response{StatusCode: code, Body: http.NoBody}), nil
+		}
+	})
+	retrier := pipeline.FactoryFunc(func(next pipeline.Policy, po *pipeline.PolicyOptions) pipeline.PolicyFunc {
+		return func(ctx context.Context, request pipeline.Request) (resp pipeline.Response, err error) {
+			for try := 0; try < len(statusCodes); try++ {
+				resp, err = next.Do(ctx, request)
+				if err == nil && resp.Response().StatusCode == http.StatusOK {
+					break
+				}
+			}
+			return
+		}
+	})
+	return pipeline.NewPipeline([]pipeline.Factory{newTryTrackingPolicyFactory(), retrier, newXferStatsPolicyFactory(nil)},
+		pipeline.Options{HTTPSender: sender})
+}
+
+// requestDurationCount is the number of tries of the given method whose duration has been observed
+func requestDurationCount(c *chk.C, method string) uint64 {
+	m := &dto.Metric{}
+	c.Assert(metricRequestDuration.WithLabelValues(method).(prometheus.Histogram).Write(m), chk.IsNil)
+	return m.GetHistogram().GetSampleCount()
+}
+
+func (s *metricsSuite) TestRetriesAreCountedByTheStatusCodeThatCausedThem(c *chk.C) {
+	retries := func(code string) float64 { return testutil.ToFloat64(metricRetries.WithLabelValues(code)) }
+	responses := func(code string) float64 { return testutil.ToFloat64(metricResponses.WithLabelValues(code)) }
+	retries503, retriesNetwork, retries200 := retries("503"), retries(networkErrorStatusCode), retries("200")
+	responses503, responses200 := responses("503"), responses("200")
+	headCount := requestDurationCount(c, http.MethodHead)
+
+	u, _ := url.Parse("https://account.blob.core.windows.net/container/blob")
+	request, err := pipeline.NewRequest(http.MethodHead, *u, nil)
+	c.Assert(err, chk.IsNil)
+	resp, err := newMetricsTestPipeline(503, 0, 503, 200).Do(context.Background(), nil, request)
+	c.Assert(err, chk.IsNil)
+	c.Assert(resp.Response().StatusCode, chk.Equals, http.StatusOK)
+
+	// three retries: two after 503s, one after the network error. The final success doesn't cause one
+	c.Assert(retries("503")-retries503, chk.Equals, float64(2))
+	c.Assert(retries(networkErrorStatusCode)-retriesNetwork, chk.Equals, float64(1))
+	c.Assert(retries("200")-retries200, chk.Equals, float64(0))
+
+	c.Assert(responses("503")-responses503, chk.Equals, float64(2))
+	c.Assert(responses("200")-responses200, chk.Equals, float64(1))
+	c.Assert(requestDurationCount(c, http.MethodHead)-headCount, chk.Equals, uint64(4))
+	c.Assert(testutil.ToFloat64(metricRequestsInFlight), chk.Equals, float64(0))
+}
+
+func (s *metricsSuite) TestMetricsAreServedInTheTextFormat(c *chk.C) {
+	metricResponses.WithLabelValues("200").Inc()
+
+	w := httptest.NewRecorder()
+	promhttp.HandlerFor(Metrics, promhttp.HandlerOpts{}).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	c.Assert(w.Code, chk.Equals, http.StatusOK)
+	c.Assert(strings.Contains(w.Body.String(), "# TYPE azcopy_http_responses_total counter"), chk.Equals, true)
+	c.Assert(strings.Contains(w.Body.String(), `azcopy_http_responses_total{status_code="200"}`), chk.Equals, true)
+	c.Assert(strings.Contains(w.Body.String(), "azcopy_http_requests_in_flight 0"), chk.Equals, true)
+}
@@ -0,0 +1,98 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ste
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/Azure/azure-pipeline-go/pipeline"
+	"github.com/Azure/azure-storage-blob-go/azblob"
+	chk "gopkg.in/check.v1"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+)
+
+type setPropertiesSuite struct{}
+
+var _ = chk.Suite(&setPropertiesSuite{})
+
+func (s *setPropertiesSuite) TestOnlyTheGivenHeadersAreChanged(c *chk.C) {
+	existing := azblob.BlobHTTPHeaders{
+		ContentType:     "application/octet-stream",
+		ContentMD5:      []byte{1, 2, 3},
+		ContentEncoding: "gzip",
+		CacheControl:    "no-cache",
+	}
+
+	merged := mergeHTTPHeaders(existing, common.ResourceHTTPHeaders{ContentType: "text/csv", ContentLanguage: "en-US"})
+	c.Assert(merged, chk.DeepEquals, azblob.BlobHTTPHeaders{
+		ContentType:     "text/csv",
+		ContentMD5:      []byte{1, 2, 3},
+		ContentEncoding: "gzip",
+		ContentLanguage: "en-US",
+		CacheControl:    "no-cache",
+	})
+}
+
+func (s *setPropertiesSuite) TestRehydratePriority(c *chk.C) {
+	var p common.RehydratePriorityType
+	c.Assert(p.Parse(""), chk.IsNil)
+	c.Assert(p.ToRehydratePriorityType(), chk.Equals, azblob.RehydratePriorityNone)
+	c.Assert(p.Parse("high"), chk.IsNil)
+	c.Assert(p.ToRehydratePriorityType(), chk.Equals, azblob.RehydratePriorityHigh)
+	c.Assert(p.Parse("urgent"), chk.NotNil)
+}
+
+func (s *setPropertiesSuite) TestRestoredVersionIsWaitedFor(c *chk.C) {
+	// the blob's properties say how the copy of the version over it ended
+	blobWithCopyStatus := func(status azblob.CopyStatusType, polls *int) azblob.BlobURL {
+		u, _ := url.Parse("https://account.blob.core.windows.net/container/a.txt")
+		return azblob.NewBlobURL(*u, pipeline.NewPipeline([]pipeline.Factory{pipeline.MethodFactoryMarker()}, pipeline.Options{
+			HTTPSender: pipeline.FactoryFunc(func(next pipeline.Policy, po *pipeline.PolicyOptions) pipeline.PolicyFunc {
+				return func(ctx context.Context, request pipeline.Request) (pipeline.Response, error) {
+					*polls++
+					return pipeline.NewHTTPResponse(&http.Response{
+						StatusCode: http.StatusOK,
+						Header:     http.Header{"X-Ms-Copy-Status": []string{string(status)}},
+						Body:       http.NoBody,
+					}), nil
+				}
+			}),
+		}))
+	}
+
+	polls := 0
+	err := waitForBlobCopy(context.Background(), blobWithCopyStatus(azblob.CopyStatusSuccess, &polls), azblob.CopyStatusPending, azblob.ClientProvidedKeyOptions{})
+	c.Assert(err, chk.IsNil)
+	c.Assert(polls, chk.Equals, 1)
+
+	polls = 0
+	err = waitForBlobCopy(context.Background(), blobWithCopyStatus(azblob.CopyStatusFailed, &polls), azblob.CopyStatusPending, azblob.ClientProvidedKeyOptions{})
+	c.Assert(err, chk.NotNil)
+
+	// a copy that's done by the time it's started isn't polled at all
+	polls = 0
+	err = waitForBlobCopy(context.Background(), blobWithCopyStatus(azblob.CopyStatusFailed, &polls), azblob.CopyStatusSuccess, azblob.ClientProvidedKeyOptions{})
+	c.Assert(err, chk.IsNil)
+	c.Assert(polls, chk.Equals, 0)
+}
@@ -0,0 +1,96 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ste
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	chk "gopkg.in/check.v1"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+)
+
+type transferErrorsSuite struct{}
+
+var _ = chk.Suite(&transferErrorsSuite{})
+
+func (s *transferErrorsSuite) TestLastErrorOfEachTransferIsRead(c *chk.C) {
+	planFolder := common.AzcopyJobPlanFolder
+	common.AzcopyJobPlanFolder = c.MkDir()
+	defer func() { common.AzcopyJobPlanFolder = planFolder }()
+
+	jobID := common.NewJobID()
+	errs, err := ReadTransferErrors(jobID)
+	c.Assert(err, chk.IsNil)
+	c.Assert(errs, chk.HasLen, 0)
+
+	l := newTransferErrorLog(jobID)
+	c.Assert(l.record(0, 1, TransferError{HTTPStatus: 503, ErrorCode: "ServerBusy", Message: "busy"}), chk.IsNil)
+	c.Assert(l.record(0, 2, TransferError{HTTPStatus: 404, ErrorCode: "BlobNotFound", Message: "not found"}), chk.IsNil)
+	c.Assert(l.record(0, 1, TransferError{HTTPStatus: 403, ErrorCode: "AuthorizationFailure", Message: strings.Repeat("x", 1000)}), chk.IsNil)
+
+	// a line cut short, e.g. by the process being killed, is skipped
+	f, err := os.OpenFile(transferErrorsPath(jobID), os.O_WRONLY|os.O_APPEND, 0644)
+	c.Assert(err, chk.IsNil)
+	_, _ = f.WriteString(`{"part":1,"transf`)
+	c.Assert(f.Close(), chk.IsNil)
+
+	errs, err = ReadTransferErrors(jobID)
+	c.Assert(err, chk.IsNil)
+	c.Assert(errs, chk.HasLen, 2)
+	c.Assert(errs[TransferErrorKey{0, 2}], chk.DeepEquals, TransferError{HTTPStatus: 404, ErrorCode: "BlobNotFound", Message: "not found"})
+	last := errs[TransferErrorKey{0, 1}]
+	c.Assert(last.ErrorCode, chk.Equals, "AuthorizationFailure")
+	c.Assert(last.Message, chk.HasLen, maxTransferErrorMessageLength)
+	c.Assert(strings.HasSuffix(last.Message, "..."), chk.Equals, true)
+}
+
+func (s *transferErrorsSuite) TestLongMessagesAreCutBetweenCharacters(c *chk.C) {
+	planFolder := common.AzcopyJobPlanFolder
+	common.AzcopyJobPlanFolder = c.MkDir()
+	defer func() { common.AzcopyJobPlanFolder = planFolder }()
+
+	// each character is two bytes, and the cut, before the ellipsis, would otherwise fall in the middle of one
+	jobID := common.NewJobID()
+	c.Assert(newTransferErrorLog(jobID).record(0, 0, TransferError{Message: strings.Repeat("é", 600)}), chk.IsNil)
+
+	errs, err := ReadTransferErrors(jobID)
+	c.Assert(err, chk.IsNil)
+	message := errs[TransferErrorKey{0, 0}].Message
+	c.Assert(utf8.ValidString(message), chk.Equals, true)
+	c.Assert(message, chk.Equals, strings.Repeat("é", (maxTransferErrorMessageLength-4)/2)+"...")
+}
+
+func (s *transferErrorsSuite) TestSecretsAreNotKept(c *chk.C) {
+	planFolder := common.AzcopyJobPlanFolder
+	common.AzcopyJobPlanFolder = c.MkDir()
+	defer func() { common.AzcopyJobPlanFolder = planFolder }()
+
+	jobID := common.NewJobID()
+	c.Assert(newTransferErrorLog(jobID).record(0, 0, TransferError{Message: "GET https://a.blob.core.windows.net/c/b?sig=secretvalue failed"}), chk.IsNil)
+
+	raw, err := ioutil.ReadFile(transferErrorsPath(jobID))
+	c.Assert(err, chk.IsNil)
+	c.Assert(strings.Contains(string(raw), "secretvalue"), chk.Equals, false)
+}
@@ -0,0 +1,88 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ste
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"time"
+
+	chk "gopkg.in/check.v1"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+)
+
+type transferEventsSuite struct{}
+
+var _ = chk.Suite(&transferEventsSuite{})
+
+func (s *transferEventsSuite) TestDoneTransfersAreCountedAndStreamed(c *chk.C) {
+	var out bytes.Buffer
+	jm := &jobMgr{jobID: common.NewJobID(), jstm: &jobStatusManager{}}
+	writer := common.NewTransferEventWriter(&out)
+	jm.SetInMemoryTransitJobState(InMemoryTransitJobState{TransferEvents: writer})
+
+	jm.handleXferDone(xferDoneMsg{
+		TransferDetail: common.TransferDetail{
+			Src:            "/data/a.txt",
+			Dst:            "https://account.blob.core.windows.net/c/a.txt?sig=secret",
+			TransferStatus: common.ETransferStatus.Success(),
+			TransferSize:   42,
+		},
+		Duration:             1500 * time.Millisecond,
+		ContentMD5:           []byte{1, 2, 3},
+		DestinationVersionID: "2022-01-01T00:00:00.0000000Z",
+	})
+	jm.handleXferDone(xferDoneMsg{TransferDetail: common.TransferDetail{Src: "/data/b.txt", TransferStatus: common.ETransferStatus.Failed(), ErrorCode: 403}})
+	jm.handleXferDone(xferDoneMsg{TransferDetail: common.TransferDetail{Src: "/data/c.txt", TransferStatus: common.ETransferStatus.SkippedEntityAlreadyExists()}})
+
+	writer.Flush()
+
+	js := jm.jstm.js
+	c.Assert(js.TransfersCompleted, chk.Equals, uint32(1))
+	c.Assert(js.TransfersFailed, chk.Equals, uint32(1))
+	c.Assert(js.TransfersSkipped, chk.Equals, uint32(1))
+	c.Assert(js.TotalBytesTransferred, chk.Equals, uint64(42))
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	c.Assert(lines, chk.HasLen, 3)
+	var events []common.TransferEvent
+	for _, line := range lines {
+		var e common.TransferEvent
+		c.Assert(json.Unmarshal([]byte(line), &e), chk.IsNil)
+		c.Assert(e.JobID, chk.Equals, jm.jobID)
+		events = append(events, e)
+	}
+
+	c.Assert(events[0].Event, chk.Equals, "completed")
+	c.Assert(events[0].Status, chk.Equals, "Success")
+	c.Assert(events[0].Size, chk.Equals, uint64(42))
+	c.Assert(events[0].DurationMs, chk.Equals, int64(1500))
+	c.Assert(events[0].ContentMD5, chk.Equals, "AQID")
+	c.Assert(events[0].DestinationVersionID, chk.Equals, "2022-01-01T00:00:00.0000000Z")
+	c.Assert(strings.Contains(events[0].Destination, "secret"), chk.Equals, false)
+
+	c.Assert(events[1].Event, chk.Equals, "failed")
+	c.Assert(events[1].ErrorCode, chk.Equals, int32(403))
+	c.Assert(events[2].Event, chk.Equals, "skipped")
+	c.Assert(events[2].Status, chk.Equals, "SkippedEntityAlreadyExists")
+}
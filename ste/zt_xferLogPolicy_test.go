@@ -0,0 +1,108 @@
+// Copyright © 2017 Microsoft <wastore@microsoft.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ste
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/Azure/azure-pipeline-go/pipeline"
+	chk "gopkg.in/check.v1"
+
+	"github.com/Azure/azure-storage-azcopy/v10/common"
+)
+
+type xferLogPolicySuite struct{}
+
+var _ = chk.Suite(&xferLogPolicySuite{})
+
+type fieldsLogEntry struct {
+	msg    string
+	fields common.LogFields
+}
+
+// fieldsLogger keeps the fields of what's logged to it
+type fieldsLogger struct {
+	entries []fieldsLogEntry
+}
+
+func (l *fieldsLogger) ShouldLog(level pipeline.LogLevel) bool { return true }
+func (l *fieldsLogger) Log(level pipeline.LogLevel, msg string) {
+	l.LogWithFields(level, "", msg, common.LogFields{})
+}
+func (l *fieldsLogger) LogWithFields(level pipeline.LogLevel, prefix string, msg string, fields common.LogFields) {
+	l.entries = append(l.entries, fieldsLogEntry{msg: prefix + msg, fields: fields})
+}
+func (l *fieldsLogger) Panic(err error) { panic(err) }
+
+func (s *xferLogPolicySuite) TestRequestsOfATransferAreLoggedWithItsFields(c *chk.C) {
+	var pipelineLog []string
+	sender := pipeline.FactoryFunc(func(next pipeline.Policy, po *pipeline.PolicyOptions) pipeline.PolicyFunc {
+		return func(ctx context.Context, request pipeline.Request) (pipeline.Response, error) {
+			header := http.Header{}
+			header.Set("x-ms-request-id", "rid")
+			header.Set("x-ms-error-code", "ServerBusy")
+			return pipeline.NewHTTPResponse(&http.Response{StatusCode: http.StatusServiceUnavailable, Status: "503 Server Busy", Header: header,
+				Body: ioutil.NopCloser(strings.NewReader("<Code>ServerBusy</Code>"))}), nil
+		}
+	})
+	p := pipeline.NewPipeline([]pipeline.Factory{NewRequestLogPolicyFactory(RequestLogOptions{SyslogDisabled: true})},
+		pipeline.Options{HTTPSender: sender, Log: pipeline.LogOptions{
+			Log:       func(level pipeline.LogLevel, msg string) { pipelineLog = append(pipelineLog, msg) },
+			ShouldLog: func(level pipeline.LogLevel) bool { return level <= pipeline.LogInfo },
+		}})
+
+	u, _ := url.Parse("https://account.blob.core.windows.net/container/blob?sig=secret")
+	newRequest := func() pipeline.Request {
+		request, err := pipeline.NewRequest(http.MethodPut, *u, nil)
+		c.Assert(err, chk.IsNil)
+		return request
+	}
+
+	// a request of a transfer goes to the given logger, with the fields of the transfer and of the response
+	logger := &fieldsLogger{}
+	transferIndex := uint32(3)
+	ctx := common.WithLogContext(context.Background(), logger, common.LogFields{TransferIndex: &transferIndex, Source: "/f.txt"})
+	_, err := p.Do(ctx, nil, newRequest())
+	c.Assert(err, chk.IsNil)
+
+	c.Assert(logger.entries, chk.HasLen, 1)
+	c.Assert(strings.Contains(logger.entries[0].msg, "RESPONSE STATUS CODE ERROR"), chk.Equals, true)
+	c.Assert(strings.Contains(logger.entries[0].msg, "secret"), chk.Equals, false)
+	c.Assert(logger.entries[0].fields, chk.DeepEquals, common.LogFields{
+		TransferIndex: &transferIndex,
+		Source:        "/f.txt",
+		HTTPMethod:    http.MethodPut,
+		HTTPStatus:    http.StatusServiceUnavailable,
+		RequestID:     "rid",
+		ErrorCode:     "ServerBusy",
+	})
+	c.Assert(pipelineLog, chk.HasLen, 0)
+
+	// other requests go to the log of the pipeline, as before
+	_, err = p.Do(context.Background(), nil, newRequest())
+	c.Assert(err, chk.IsNil)
+	c.Assert(pipelineLog, chk.HasLen, 1)
+	c.Assert(logger.entries, chk.HasLen, 1)
+}